@@ -110,6 +110,7 @@ export interface GrafanaConfig {
   ldapEnabled: boolean;
   sigV4AuthEnabled: boolean;
   samlEnabled: boolean;
+  totpEnabled: boolean;
   autoAssignOrg: boolean;
   verifyEmailEnabled: boolean;
   oauth: any;
@@ -123,6 +124,7 @@ export interface GrafanaConfig {
   disableSanitizeHtml: boolean;
   theme: GrafanaTheme;
   pluginsToPreload: string[];
+  pluginModuleIntegrity: Record<string, string>;
   featureToggles: FeatureToggles;
   licenseInfo: LicenseInfo;
   http2Enabled: boolean;
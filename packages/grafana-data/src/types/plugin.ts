@@ -50,6 +50,7 @@ export interface PluginMeta<T extends KeyValue = {}> {
 
   // System.load & relative URLS
   module: string;
+  moduleIntegrity?: string;
   baseUrl: string;
 
   // Define plugin requirements
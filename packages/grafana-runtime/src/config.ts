@@ -38,6 +38,7 @@ export class GrafanaBootConfig implements GrafanaConfig {
   ldapEnabled = false;
   sigV4AuthEnabled = false;
   samlEnabled = false;
+  totpEnabled = false;
   autoAssignOrg = true;
   verifyEmailEnabled = false;
   oauth: any;
@@ -51,6 +52,7 @@ export class GrafanaBootConfig implements GrafanaConfig {
   disableSanitizeHtml = false;
   theme: GrafanaTheme;
   pluginsToPreload: string[] = [];
+  pluginModuleIntegrity: Record<string, string> = {};
   featureToggles: FeatureToggles = {
     live: false,
     meta: false,
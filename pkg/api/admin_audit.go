@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+)
+
+// GET /api/admin/audit
+func (hs *HTTPServer) AdminSearchAuditEntries(c *models.ReqContext) response.Response {
+	if !hs.Cfg.Audit.Enabled {
+		return response.Error(http.StatusNotFound, "Audit log not enabled", nil)
+	}
+
+	query := audit.SearchQuery{
+		OrgId:   c.QueryInt64("orgId"),
+		UserId:  c.QueryInt64("userId"),
+		Action:  c.Query("action"),
+		Page:    c.QueryInt("page"),
+		PerPage: c.QueryInt("perpage"),
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.Unix(0, from*int64(time.Millisecond))
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.Unix(0, to*int64(time.Millisecond))
+	}
+
+	result, err := hs.AuditService.Search(c.Req.Context(), query)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search audit entries", err)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
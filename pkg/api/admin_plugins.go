@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// maxPluginArchiveSize is the largest plugin archive accepted for upload.
+const maxPluginArchiveSize = 500 << 20
+
+// POST /api/admin/plugins/install
+func (hs *HTTPServer) AdminInstallPlugin(c *models.ReqContext, cmd dtos.InstallPluginCommand) response.Response {
+	p, err := hs.PluginManager.Install(c.Req.Context(), cmd.PluginId, cmd.Version)
+	if err != nil {
+		return translatePluginInstallErrorToAPIError(err, "Failed to install plugin")
+	}
+
+	return hs.pluginInstalledResponse("Plugin installed", p)
+}
+
+// POST /api/admin/plugins/upload
+func (hs *HTTPServer) AdminUploadPlugin(c *models.ReqContext) response.Response {
+	c.Req.Request.Body = http.MaxBytesReader(c.Resp, c.Req.Request.Body, maxPluginArchiveSize)
+	if err := c.Req.ParseMultipartForm(32 << 20); err != nil {
+		return response.Error(400, "Failed to parse plugin archive upload", err)
+	}
+
+	file, _, err := c.Req.FormFile("file")
+	if err != nil {
+		return response.Error(400, "Plugin archive is missing", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			hs.log.Warn("Failed to close plugin archive", "err", err)
+		}
+	}()
+
+	p, err := hs.PluginManager.InstallFromZip(c.Req.Context(), file)
+	if err != nil {
+		return translatePluginInstallErrorToAPIError(err, "Failed to install plugin")
+	}
+
+	return hs.pluginInstalledResponse("Plugin installed", p)
+}
+
+// POST /api/admin/plugins/:pluginId/update
+func (hs *HTTPServer) AdminUpdatePlugin(c *models.ReqContext, cmd dtos.UpdatePluginCommand) response.Response {
+	pluginID := c.Params(":pluginId")
+	if hs.PluginManager.GetPlugin(pluginID) == nil {
+		return response.Error(404, "Plugin not found", nil)
+	}
+
+	p, err := hs.PluginManager.Install(c.Req.Context(), pluginID, cmd.Version)
+	if err != nil {
+		return translatePluginInstallErrorToAPIError(err, "Failed to update plugin")
+	}
+
+	return hs.pluginInstalledResponse("Plugin updated", p)
+}
+
+// DELETE /api/admin/plugins/:pluginId
+func (hs *HTTPServer) AdminUninstallPlugin(c *models.ReqContext) response.Response {
+	pluginID := c.Params(":pluginId")
+	restartRequired := hs.appRoutesRequireRestart(pluginID)
+	if err := hs.PluginManager.Uninstall(c.Req.Context(), pluginID); err != nil {
+		return translatePluginInstallErrorToAPIError(err, "Failed to uninstall plugin")
+	}
+
+	message := "Plugin uninstalled"
+	if restartRequired {
+		message += ", restart Grafana to remove the routes of the app plugin"
+	}
+	return response.JSON(200, util.DynMap{
+		"message":         message,
+		"restartRequired": restartRequired,
+	})
+}
+
+// GET /api/admin/backend-plugins
+func (hs *HTTPServer) AdminGetBackendPluginStatuses(c *models.ReqContext) response.Response {
+	return response.JSON(200, hs.BackendPluginManager.PluginStatuses())
+}
+
+// GET /api/admin/backend-plugins/:pluginId
+func (hs *HTTPServer) AdminGetBackendPluginStatus(c *models.ReqContext) response.Response {
+	status, err := hs.BackendPluginManager.PluginStatus(c.Params(":pluginId"))
+	if err != nil {
+		return translateBackendPluginErrorToAPIError(err, "Failed to get backend plugin status")
+	}
+
+	return response.JSON(200, status)
+}
+
+// POST /api/admin/backend-plugins/:pluginId/restart
+func (hs *HTTPServer) AdminRestartBackendPlugin(c *models.ReqContext) response.Response {
+	if err := hs.BackendPluginManager.RestartPlugin(c.Req.Context(), c.Params(":pluginId")); err != nil {
+		return translateBackendPluginErrorToAPIError(err, "Failed to restart backend plugin")
+	}
+
+	return response.Success("Backend plugin restarted")
+}
+
+// POST /api/admin/backend-plugins/:pluginId/disable
+func (hs *HTTPServer) AdminDisableBackendPlugin(c *models.ReqContext) response.Response {
+	if err := hs.BackendPluginManager.DisablePlugin(c.Req.Context(), c.Params(":pluginId")); err != nil {
+		return translateBackendPluginErrorToAPIError(err, "Failed to disable backend plugin")
+	}
+
+	return response.Success("Backend plugin disabled")
+}
+
+func (hs *HTTPServer) pluginInstalledResponse(message string, p *plugins.PluginBase) response.Response {
+	restartRequired := hs.appRoutesRequireRestart(p.Id)
+	if restartRequired {
+		message += ", restart Grafana to update the routes of the app plugin"
+	}
+	return response.JSON(200, util.DynMap{
+		"message":         message,
+		"id":              p.Id,
+		"name":            p.Name,
+		"type":            p.Type,
+		"version":         p.Info.Version,
+		"signature":       p.Signature,
+		"restartRequired": restartRequired,
+	})
+}
+
+// appRoutesRequireRestart returns whether a plugin is an app plugin with routes, which are only
+// registered on startup, so that installing, updating or uninstalling it only applies to its routes
+// after a restart.
+func (hs *HTTPServer) appRoutesRequireRestart(pluginID string) bool {
+	app := hs.PluginManager.GetApp(pluginID)
+	return app != nil && len(app.Routes) > 0
+}
+
+func translatePluginInstallErrorToAPIError(err error, message string) response.Response {
+	var notFound plugins.PluginNotFoundError
+	if errors.As(err, &notFound) {
+		return response.Error(404, notFound.Error(), nil)
+	}
+
+	switch {
+	case errors.Is(err, plugins.ErrPluginArchiveInvalid), errors.Is(err, plugins.ErrPluginVersionNotFound),
+		errors.Is(err, plugins.ErrPluginSignatureNotValid), errors.Is(err, plugins.ErrPluginNotExternal),
+		errors.Is(err, plugins.ErrPluginIncluded), errors.Is(err, plugins.ErrPluginRequiresRestart):
+		return response.Error(400, err.Error(), err)
+	}
+
+	return response.Error(500, message, err)
+}
+
+func translateBackendPluginErrorToAPIError(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, backendplugin.ErrPluginNotRegistered):
+		return response.Error(404, "Backend plugin not found", nil)
+	case errors.Is(err, backendplugin.ErrPluginNotStarted):
+		return response.Error(400, "Backend plugin is not started", nil)
+	}
+
+	return response.Error(500, message, err)
+}
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakePluginInstaller struct {
+	fakePluginManager
+
+	installed   *plugins.PluginBase
+	app         *plugins.AppPlugin
+	err         error
+	archive     []byte
+	uninstalled string
+}
+
+func (pm *fakePluginInstaller) GetApp(id string) *plugins.AppPlugin {
+	return pm.app
+}
+
+func (pm *fakePluginInstaller) Install(ctx context.Context, pluginID, version string) (*plugins.PluginBase, error) {
+	return pm.installed, pm.err
+}
+
+func (pm *fakePluginInstaller) InstallFromZip(ctx context.Context, archive io.Reader) (*plugins.PluginBase, error) {
+	var err error
+	pm.archive, err = ioutil.ReadAll(archive)
+	if err != nil {
+		return nil, err
+	}
+	return pm.installed, pm.err
+}
+
+func (pm *fakePluginInstaller) Uninstall(ctx context.Context, pluginID string) error {
+	pm.uninstalled = pluginID
+	return pm.err
+}
+
+func TestAdminPluginsAPIEndpoint(t *testing.T) {
+	installed := &plugins.PluginBase{Id: "test-panel", Type: "panel", Info: plugins.PluginInfo{Version: "1.0.0"}}
+
+	t.Run("When installing a plugin", func(t *testing.T) {
+		for _, tc := range []struct {
+			err          error
+			expectedCode int
+		}{
+			{err: nil, expectedCode: 200},
+			{err: plugins.PluginNotFoundError{PluginID: "test-panel"}, expectedCode: 404},
+			{err: plugins.ErrPluginVersionNotFound, expectedCode: 400},
+			{err: plugins.ErrPluginSignatureNotValid, expectedCode: 400},
+			{err: io.ErrUnexpectedEOF, expectedCode: 500},
+		} {
+			pm := &fakePluginInstaller{installed: installed, err: tc.err}
+			hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm}
+			resp := hs.AdminInstallPlugin(&models.ReqContext{Context: &macaron.Context{Req: macaron.Request{
+				Request: httptest.NewRequest("POST", "/api/admin/plugins/install", nil),
+			}}}, dtos.InstallPluginCommand{PluginId: "test-panel"})
+			assert.Equal(t, tc.expectedCode, resp.Status(), "error %v", tc.err)
+		}
+	})
+
+	loggedInUserScenarioWithRole(t, "When uploading a plugin archive", "POST", "/api/admin/plugins/upload",
+		"/api/admin/plugins/upload", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			pm := &fakePluginInstaller{installed: installed}
+			hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm}
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			fw, err := mw.CreateFormFile("file", "test-panel.zip")
+			require.NoError(t, err)
+			_, err = fw.Write([]byte("archive"))
+			require.NoError(t, err)
+			require.NoError(t, mw.Close())
+
+			sc.m.Post("/api/admin/plugins/upload", sc.defaultHandler)
+			sc.handlerFunc = hs.AdminUploadPlugin
+			sc.resp = httptest.NewRecorder()
+			sc.req, err = http.NewRequest("POST", "/api/admin/plugins/upload", &body)
+			require.NoError(t, err)
+			sc.req.Header.Set("Content-Type", mw.FormDataContentType())
+			sc.exec()
+
+			require.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, []byte("archive"), pm.archive)
+			respJSON := sc.ToJSON()
+			assert.Equal(t, "test-panel", respJSON.Get("id").MustString())
+			assert.Equal(t, "1.0.0", respJSON.Get("version").MustString())
+		})
+
+	t.Run("When installing an app plugin with routes", func(t *testing.T) {
+		app := &plugins.AppPlugin{
+			FrontendPluginBase: plugins.FrontendPluginBase{PluginBase: plugins.PluginBase{Id: "test-app", Type: "app"}},
+			Routes:             []*plugins.AppPluginRoute{{Path: "api/*", URL: "http://localhost"}},
+		}
+		pm := &fakePluginInstaller{installed: &app.PluginBase, app: app}
+		hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm}
+		resp := hs.AdminInstallPlugin(&models.ReqContext{Context: &macaron.Context{Req: macaron.Request{
+			Request: httptest.NewRequest("POST", "/api/admin/plugins/install", nil),
+		}}}, dtos.InstallPluginCommand{PluginId: "test-app"})
+
+		require.Equal(t, 200, resp.Status())
+		var respJSON map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body(), &respJSON))
+		assert.Equal(t, true, respJSON["restartRequired"])
+		assert.Equal(t, "Plugin installed, restart Grafana to update the routes of the app plugin", respJSON["message"])
+	})
+
+	loggedInUserScenarioWithRole(t, "When uninstalling a plugin", "DELETE", "/api/admin/plugins/test-panel",
+		"/api/admin/plugins/:pluginId", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			pm := &fakePluginInstaller{}
+			hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm}
+			sc.handlerFunc = hs.AdminUninstallPlugin
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			assert.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, "test-panel", pm.uninstalled)
+		})
+
+	loggedInUserScenarioWithRole(t, "When uninstalling a core plugin", "DELETE", "/api/admin/plugins/graph",
+		"/api/admin/plugins/:pluginId", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			pm := &fakePluginInstaller{err: plugins.ErrPluginNotExternal}
+			hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm}
+			sc.handlerFunc = hs.AdminUninstallPlugin
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			assert.Equal(t, 400, sc.resp.Code)
+		})
+}
+
+type fakeBackendPluginSupervisor struct {
+	backendplugin.Manager
+
+	statuses  []backendplugin.PluginStatus
+	err       error
+	restarted string
+	disabled  string
+}
+
+func (pm *fakeBackendPluginSupervisor) PluginStatus(pluginID string) (backendplugin.PluginStatus, error) {
+	for _, status := range pm.statuses {
+		if status.PluginID == pluginID {
+			return status, nil
+		}
+	}
+	return backendplugin.PluginStatus{}, backendplugin.ErrPluginNotRegistered
+}
+
+func (pm *fakeBackendPluginSupervisor) PluginStatuses() []backendplugin.PluginStatus {
+	return pm.statuses
+}
+
+func (pm *fakeBackendPluginSupervisor) RestartPlugin(ctx context.Context, pluginID string) error {
+	pm.restarted = pluginID
+	return pm.err
+}
+
+func (pm *fakeBackendPluginSupervisor) DisablePlugin(ctx context.Context, pluginID string) error {
+	pm.disabled = pluginID
+	return pm.err
+}
+
+func TestAdminBackendPluginsAPIEndpoint(t *testing.T) {
+	statuses := []backendplugin.PluginStatus{
+		{
+			PluginID:       "test-datasource",
+			Managed:        true,
+			State:          backendplugin.PluginStateRestarting,
+			Restarts:       3,
+			LastExitError:  "plugin process exited: exit status 2",
+			CircuitBreaker: backendplugin.CircuitOpen,
+		},
+	}
+
+	loggedInUserScenarioWithRole(t, "When listing backend plugin statuses", "GET", "/api/admin/backend-plugins",
+		"/api/admin/backend-plugins", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			hs := &HTTPServer{Cfg: setting.NewCfg(), BackendPluginManager: &fakeBackendPluginSupervisor{statuses: statuses}}
+			sc.handlerFunc = hs.AdminGetBackendPluginStatuses
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, 200, sc.resp.Code)
+			respJSON := sc.ToJSON()
+			assert.Equal(t, "test-datasource", respJSON.GetIndex(0).Get("pluginId").MustString())
+			assert.Equal(t, "restarting", respJSON.GetIndex(0).Get("state").MustString())
+			assert.Equal(t, 3, respJSON.GetIndex(0).Get("restarts").MustInt())
+			assert.Equal(t, "plugin process exited: exit status 2", respJSON.GetIndex(0).Get("lastExitError").MustString())
+			assert.Equal(t, "open", respJSON.GetIndex(0).Get("circuitBreaker").MustString())
+		})
+
+	loggedInUserScenarioWithRole(t, "When getting the status of an unknown backend plugin", "GET",
+		"/api/admin/backend-plugins/unknown", "/api/admin/backend-plugins/:pluginId", models.ROLE_ADMIN,
+		func(sc *scenarioContext) {
+			hs := &HTTPServer{Cfg: setting.NewCfg(), BackendPluginManager: &fakeBackendPluginSupervisor{statuses: statuses}}
+			sc.handlerFunc = hs.AdminGetBackendPluginStatus
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			assert.Equal(t, 404, sc.resp.Code)
+		})
+
+	loggedInUserScenarioWithRole(t, "When restarting a backend plugin", "POST",
+		"/api/admin/backend-plugins/test-datasource/restart", "/api/admin/backend-plugins/:pluginId/restart",
+		models.ROLE_ADMIN, func(sc *scenarioContext) {
+			pm := &fakeBackendPluginSupervisor{}
+			hs := &HTTPServer{Cfg: setting.NewCfg(), BackendPluginManager: pm}
+			sc.m.Post("/api/admin/backend-plugins/:pluginId/restart", sc.defaultHandler)
+			sc.handlerFunc = hs.AdminRestartBackendPlugin
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+			assert.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, "test-datasource", pm.restarted)
+		})
+
+	loggedInUserScenarioWithRole(t, "When disabling a backend plugin which isn't started", "POST",
+		"/api/admin/backend-plugins/test-datasource/disable", "/api/admin/backend-plugins/:pluginId/disable",
+		models.ROLE_ADMIN, func(sc *scenarioContext) {
+			pm := &fakeBackendPluginSupervisor{err: backendplugin.ErrPluginNotStarted}
+			hs := &HTTPServer{Cfg: setting.NewCfg(), BackendPluginManager: pm}
+			sc.m.Post("/api/admin/backend-plugins/:pluginId/disable", sc.defaultHandler)
+			sc.handlerFunc = hs.AdminDisableBackendPlugin
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+			assert.Equal(t, 400, sc.resp.Code)
+			assert.Equal(t, "test-datasource", pm.disabled)
+		})
+}
+
+func TestPluginStaticRoutes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte("module"), 0600))
+
+	pm := &fakePluginManager{}
+	hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm, log: log.New("test")}
+	m := macaron.New()
+	m.Use(hs.pluginStaticRoutes())
+	m.NotFound(func(c *macaron.Context) {
+		c.Resp.WriteHeader(404)
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest("GET", "/public/plugins/test-panel/module.js", nil))
+		return rec
+	}
+
+	require.Equal(t, 404, get().Code)
+
+	pm.staticRoutes = []*plugins.PluginStaticRoute{{PluginId: "test-panel", Directory: dir}}
+	rec := get()
+	require.Equal(t, 200, rec.Code)
+	assert.Equal(t, "module", rec.Body.String())
+
+	pm.staticRoutes = nil
+	require.Equal(t, 404, get().Code)
+}
+
+func TestPluginStaticRoutes_Assets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte("module"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js.br"), []byte("brotli"), 0600))
+
+	info, err := os.Stat(filepath.Join(dir, "module.js"))
+	require.NoError(t, err)
+
+	hash := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	asset := &plugins.PluginAsset{
+		Path:       "module.js",
+		Size:       6,
+		ModTime:    info.ModTime(),
+		Hash:       hash,
+		Integrity:  "sha256-ASNFZ4mrze8BI0VniavN7wEjRWeJq83vASNFZ4mrze8=",
+		Gzip:       []byte("gzip"),
+		BrotliPath: "module.js.br",
+	}
+	pm := &fakePluginManager{staticRoutes: []*plugins.PluginStaticRoute{{
+		PluginId:  "test-panel",
+		Directory: dir,
+		Assets:    plugins.PluginAssets{"module.js": asset},
+	}}}
+	hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm, log: log.New("test")}
+	m := macaron.New()
+	m.Use(hs.pluginStaticRoutes())
+	m.NotFound(func(c *macaron.Context) {
+		c.Resp.WriteHeader(404)
+	})
+
+	get := func(url string, headers map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", url, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		m.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Should serve an asset with its ETag and digest", func(t *testing.T) {
+		rec := get("/public/plugins/test-panel/module.js", nil)
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, "module", rec.Body.String())
+		assert.Equal(t, `"`+hash+`"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "sha-256=ASNFZ4mrze8BI0VniavN7wEjRWeJq83vASNFZ4mrze8=", rec.Header().Get("Digest"))
+		assert.Equal(t, "public, max-age=3600", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+
+		rec = get("/public/plugins/test-panel/module.js", map[string]string{"If-None-Match": `"` + hash + `"`})
+		assert.Equal(t, 304, rec.Code)
+	})
+
+	t.Run("Should serve an asset requested by its content hash as immutable", func(t *testing.T) {
+		rec := get("/public/plugins/test-panel/module.0123456789abcdef.js", nil)
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, "module", rec.Body.String())
+		assert.Equal(t, "public, max-age=31536000, immutable", rec.Header().Get("Cache-Control"))
+
+		rec = get("/public/plugins/test-panel/module.fedcba9876543210.js", nil)
+		assert.Equal(t, 404, rec.Code)
+	})
+
+	t.Run("Should serve the precompressed variants", func(t *testing.T) {
+		rec := get("/public/plugins/test-panel/module.js", map[string]string{"Accept-Encoding": "gzip, br"})
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, "brotli", rec.Body.String())
+		assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, `"`+hash+`-br"`, rec.Header().Get("ETag"))
+		assert.Empty(t, rec.Header().Get("Digest"))
+
+		rec = get("/public/plugins/test-panel/module.js", map[string]string{"Accept-Encoding": "gzip, br;q=0"})
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, "gzip", rec.Body.String())
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		assert.Contains(t, rec.Header().Get("Content-Type"), "javascript")
+	})
+
+	t.Run("Should serve an asset changed since it was indexed as is", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte("rebuilt module"), 0600))
+
+		rec := get("/public/plugins/test-panel/module.js", map[string]string{"Accept-Encoding": "gzip, br"})
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, "rebuilt module", rec.Body.String())
+		assert.NotContains(t, rec.Header().Get("ETag"), hash)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+
+		rec = get("/public/plugins/test-panel/module.0123456789abcdef.js", nil)
+		assert.Equal(t, 404, rec.Code)
+	})
+}
@@ -175,6 +175,20 @@ func (hs *HTTPServer) AdminLogoutUser(c *models.ReqContext) response.Response {
 	return hs.logoutUserFromAllDevicesInternal(c.Req.Context(), userID)
 }
 
+// DELETE /api/admin/users/:id/totp
+func (hs *HTTPServer) AdminResetUserTOTP(c *models.ReqContext) response.Response {
+	userID := c.ParamsInt64(":id")
+
+	if err := hs.TOTPService.Disable(userID); err != nil {
+		if errors.Is(err, models.ErrUserTOTPNotFound) {
+			return response.Error(404, "Two-factor authentication not set up for user", nil)
+		}
+		return response.Error(500, "Failed to reset two-factor authentication", err)
+	}
+
+	return response.Success("Two-factor authentication reset")
+}
+
 // GET /api/admin/users/:id/auth-tokens
 func (hs *HTTPServer) AdminGetUserAuthTokens(c *models.ReqContext) response.Response {
 	userID := c.ParamsInt64(":id")
@@ -29,7 +29,7 @@ func GetAnnotations(c *models.ReqContext) response.Response {
 
 	repo := annotations.GetRepository()
 
-	items, err := repo.Find(query)
+	items, err := repo.Find(c.Req.Context(), query)
 	if err != nil {
 		return response.Error(500, "Failed to get annotations", err)
 	}
@@ -184,7 +184,7 @@ func PatchAnnotation(c *models.ReqContext, cmd dtos.PatchAnnotationsCmd) respons
 		return resp
 	}
 
-	items, err := repo.Find(&annotations.ItemQuery{AnnotationId: annotationID, OrgId: c.OrgId})
+	items, err := repo.Find(c.Req.Context(), &annotations.ItemQuery{AnnotationId: annotationID, OrgId: c.OrgId})
 
 	if err != nil || len(items) == 0 {
 		return response.Error(404, "Could not find annotation to update", err)
@@ -275,7 +275,7 @@ func canSaveByDashboardID(c *models.ReqContext, dashboardID int64) (bool, error)
 }
 
 func canSave(c *models.ReqContext, repo annotations.Repository, annotationID int64) response.Response {
-	items, err := repo.Find(&annotations.ItemQuery{AnnotationId: annotationID, OrgId: c.OrgId})
+	items, err := repo.Find(c.Req.Context(), &annotations.ItemQuery{AnnotationId: annotationID, OrgId: c.OrgId})
 	if err != nil || len(items) == 0 {
 		return response.Error(500, "Could not find annotation to update", err)
 	}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -255,7 +256,7 @@ func (repo *fakeAnnotationsRepo) Save(item *annotations.Item) error {
 func (repo *fakeAnnotationsRepo) Update(item *annotations.Item) error {
 	return nil
 }
-func (repo *fakeAnnotationsRepo) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+func (repo *fakeAnnotationsRepo) Find(_ context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
 	annotations := []*annotations.ItemDTO{{Id: 1}}
 	return annotations, nil
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/setting"
 
 	acmiddleware "github.com/grafana/grafana/pkg/services/accesscontrol/middleware"
 )
@@ -36,6 +37,7 @@ func (hs *HTTPServer) registerRoutes() {
 	redirectFromLegacyPanelEditURL := middleware.RedirectFromLegacyPanelEditURL(hs.Cfg)
 	authorize := acmiddleware.Middleware(hs.AccessControl)
 	quota := middleware.Quota(hs.QuotaService)
+	rateLimit := hs.RateLimitService.Middleware
 	bind := binding.Bind
 
 	r := hs.RouteRegister
@@ -43,7 +45,12 @@ func (hs *HTTPServer) registerRoutes() {
 	// not logged in views
 	r.Get("/logout", hs.Logout)
 	r.Post("/login", quota("session"), bind(dtos.LoginCommand{}), routing.Wrap(hs.LoginPost))
+	r.Get("/login/saml", quota("session"), hs.SAMLLogin)
+	r.Post("/login/totp", quota("session"), bind(dtos.LoginTOTPCommand{}), routing.Wrap(hs.LoginTOTPPost))
+	r.Post("/login/totp/setup", routing.Wrap(hs.LoginTOTPSetup))
 	r.Get("/login/:name", quota("session"), hs.OAuthLogin)
+	r.Get("/saml/metadata", hs.SAMLMetadata)
+	r.Post("/saml/acs", quota("session"), hs.SAMLACS)
 	r.Get("/login", hs.LoginView)
 	r.Get("/invite/:code", hs.Index)
 
@@ -155,6 +162,13 @@ func (hs *HTTPServer) registerRoutes() {
 
 			userRoute.Get("/auth-tokens", routing.Wrap(hs.GetUserAuthTokens))
 			userRoute.Post("/revoke-auth-token", bind(models.RevokeAuthTokenCmd{}), routing.Wrap(hs.RevokeUserAuthToken))
+			userRoute.Post("/revoke-other-auth-tokens", routing.Wrap(hs.RevokeOtherUserAuthTokens))
+
+			userRoute.Get("/totp", routing.Wrap(hs.GetUserTOTPStatus))
+			userRoute.Post("/totp/setup", routing.Wrap(hs.StartUserTOTPSetup))
+			userRoute.Post("/totp/enable", bind(dtos.LoginTOTPCommand{}), routing.Wrap(hs.EnableUserTOTP))
+			userRoute.Post("/totp/disable", bind(dtos.LoginTOTPCommand{}), routing.Wrap(hs.DisableUserTOTP))
+			userRoute.Post("/totp/recovery-codes", bind(dtos.LoginTOTPCommand{}), routing.Wrap(hs.RegenerateUserTOTPRecoveryCodes))
 		}, reqSignedInNoAnonymous)
 
 		// users (admin permission required)
@@ -182,6 +196,10 @@ func (hs *HTTPServer) registerRoutes() {
 			teamsRoute.Delete("/:teamId/members/:userId", routing.Wrap(hs.RemoveTeamMember))
 			teamsRoute.Get("/:teamId/preferences", routing.Wrap(hs.GetTeamPreferences))
 			teamsRoute.Put("/:teamId/preferences", bind(dtos.UpdatePrefsCmd{}), routing.Wrap(hs.UpdateTeamPreferences))
+			teamsRoute.Get("/:teamId/groups", reqOrgAdmin, routing.Wrap(hs.GetTeamGroups))
+			teamsRoute.Post("/:teamId/groups", reqOrgAdmin, bind(models.AddTeamGroupCommand{}), routing.Wrap(hs.AddTeamGroup))
+			teamsRoute.Delete("/:teamId/groups", reqOrgAdmin, routing.Wrap(hs.RemoveTeamGroup))
+			teamsRoute.Delete("/:teamId/groups/:groupId", reqOrgAdmin, routing.Wrap(hs.RemoveTeamGroup))
 		}, reqCanAccessTeams)
 
 		// team without requirement of user to be org admin
@@ -194,6 +212,7 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Group("/org", func(orgRoute routing.RouteRegister) {
 			orgRoute.Get("/", routing.Wrap(GetOrgCurrent))
 			orgRoute.Get("/quotas", routing.Wrap(GetOrgQuotas))
+			orgRoute.Get("/quotas/usage", routing.Wrap(GetQuotaUsage))
 		})
 
 		// current org
@@ -238,6 +257,11 @@ func (hs *HTTPServer) registerRoutes() {
 			orgsRoute.Delete("/users/:userId", routing.Wrap(RemoveOrgUser))
 			orgsRoute.Get("/quotas", routing.Wrap(GetOrgQuotas))
 			orgsRoute.Put("/quotas/:target", bind(models.UpdateOrgQuotaCmd{}), routing.Wrap(UpdateOrgQuota))
+			orgsRoute.Get("/quotas/usage", routing.Wrap(GetQuotaUsage))
+			orgsRoute.Get("/folders/:folderId/quotas", routing.Wrap(GetFolderQuotas))
+			orgsRoute.Put("/folders/:folderId/quotas/:target", bind(models.UpdateFolderQuotaCmd{}), routing.Wrap(UpdateFolderQuota))
+			orgsRoute.Get("/teams/:teamId/quotas", routing.Wrap(GetTeamQuotas))
+			orgsRoute.Put("/teams/:teamId/quotas/:target", bind(models.UpdateTeamQuotaCmd{}), routing.Wrap(UpdateTeamQuota))
 		}, reqGrafanaAdmin)
 
 		// orgs (admin routes)
@@ -252,6 +276,18 @@ func (hs *HTTPServer) registerRoutes() {
 			keysRoute.Delete("/:id", routing.Wrap(DeleteAPIKey))
 		}, reqOrgAdmin)
 
+		// service accounts
+		apiRoute.Group("/serviceaccounts", func(saRoute routing.RouteRegister) {
+			saRoute.Get("/", routing.Wrap(GetServiceAccounts))
+			saRoute.Post("/", bind(models.CreateServiceAccountCommand{}), routing.Wrap(CreateServiceAccount))
+			saRoute.Get("/:serviceAccountId", routing.Wrap(GetServiceAccountByID))
+			saRoute.Patch("/:serviceAccountId", bind(models.UpdateServiceAccountCommand{}), routing.Wrap(UpdateServiceAccount))
+			saRoute.Delete("/:serviceAccountId", routing.Wrap(DeleteServiceAccount))
+			saRoute.Get("/:serviceAccountId/tokens", routing.Wrap(GetServiceAccountTokens))
+			saRoute.Post("/:serviceAccountId/tokens", quota("api_key"), bind(models.AddServiceAccountTokenCommand{}), routing.Wrap(hs.AddServiceAccountToken))
+			saRoute.Delete("/:serviceAccountId/tokens/:tokenId", routing.Wrap(DeleteServiceAccountToken))
+		}, reqOrgAdmin)
+
 		// Preferences
 		apiRoute.Group("/preferences", func(prefRoute routing.RouteRegister) {
 			prefRoute.Post("/set-home-dash", bind(models.SavePreferencesCommand{}), routing.Wrap(SetHomeDashboard))
@@ -287,8 +323,8 @@ func (hs *HTTPServer) registerRoutes() {
 		}, reqOrgAdmin)
 
 		apiRoute.Get("/frontend/settings/", hs.GetFrontendSettings)
-		apiRoute.Any("/datasources/proxy/:id/*", reqSignedIn, hs.ProxyDataSourceRequest)
-		apiRoute.Any("/datasources/proxy/:id", reqSignedIn, hs.ProxyDataSourceRequest)
+		apiRoute.Any("/datasources/proxy/:id/*", reqSignedIn, rateLimit(setting.RateLimitGroupQuery), hs.ProxyDataSourceRequest)
+		apiRoute.Any("/datasources/proxy/:id", reqSignedIn, rateLimit(setting.RateLimitGroupQuery), hs.ProxyDataSourceRequest)
 		apiRoute.Any("/datasources/:id/resources", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/resources/*", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/health", routing.Wrap(hs.CheckDatasourceHealth))
@@ -356,15 +392,15 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// Search
 		apiRoute.Get("/search/sorting", routing.Wrap(hs.ListSortOptions))
-		apiRoute.Get("/search/", routing.Wrap(Search))
+		apiRoute.Get("/search/", rateLimit(setting.RateLimitGroupSearch), routing.Wrap(Search))
 
 		// metrics
-		apiRoute.Post("/tsdb/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetrics))
+		apiRoute.Post("/tsdb/query", rateLimit(setting.RateLimitGroupQuery), bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetrics))
 		apiRoute.Get("/tsdb/testdata/gensql", reqGrafanaAdmin, routing.Wrap(GenerateSQLTestData))
 		apiRoute.Get("/tsdb/testdata/random-walk", routing.Wrap(hs.GetTestDataRandomWalk))
 
 		// DataSource w/ expressions
-		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/query", rateLimit(setting.RateLimitGroupQuery), bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetricsV2))
 
 		apiRoute.Group("/alerts", func(alertsRoute routing.RouteRegister) {
 			alertsRoute.Post("/test", bind(dtos.AlertTestCommand{}), routing.Wrap(hs.AlertTest))
@@ -397,11 +433,11 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Post("/annotations/mass-delete", reqOrgAdmin, bind(dtos.DeleteAnnotationsCmd{}), routing.Wrap(DeleteAnnotations))
 
 		apiRoute.Group("/annotations", func(annotationsRoute routing.RouteRegister) {
-			annotationsRoute.Post("/", bind(dtos.PostAnnotationsCmd{}), routing.Wrap(PostAnnotation))
+			annotationsRoute.Post("/", quota("annotation_per_day"), bind(dtos.PostAnnotationsCmd{}), routing.Wrap(PostAnnotation))
 			annotationsRoute.Delete("/:annotationId", routing.Wrap(DeleteAnnotationByID))
 			annotationsRoute.Put("/:annotationId", bind(dtos.UpdateAnnotationsCmd{}), routing.Wrap(UpdateAnnotation))
 			annotationsRoute.Patch("/:annotationId", bind(dtos.PatchAnnotationsCmd{}), routing.Wrap(PatchAnnotation))
-			annotationsRoute.Post("/graphite", reqEditorRole, bind(dtos.PostGraphiteAnnotationsCmd{}), routing.Wrap(PostGraphiteAnnotation))
+			annotationsRoute.Post("/graphite", reqEditorRole, quota("annotation_per_day"), bind(dtos.PostGraphiteAnnotationsCmd{}), routing.Wrap(PostGraphiteAnnotation))
 		})
 
 		apiRoute.Post("/frontend-metrics", bind(metrics.PostFrontendMetricsCommand{}), routing.Wrap(hs.PostFrontendMetrics))
@@ -418,7 +454,7 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// short urls
 		apiRoute.Post("/short-urls", bind(dtos.CreateShortURLCmd{}), routing.Wrap(hs.createShortURL))
-	}, reqSignedIn)
+	}, reqSignedIn, rateLimit(setting.RateLimitGroupAPI))
 
 	// admin api
 	r.Group("/api/admin", func(adminRoute routing.RouteRegister) {
@@ -434,7 +470,18 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Post("/ldap/sync/:id", routing.Wrap(hs.PostSyncUserWithLDAP))
 		adminRoute.Get("/ldap/:username", routing.Wrap(hs.GetUserFromLDAP))
 		adminRoute.Get("/ldap/status", routing.Wrap(hs.GetLDAPStatus))
-	}, reqGrafanaAdmin)
+		adminRoute.Get("/ldap-sync-status", routing.Wrap(hs.GetLDAPSyncStatus))
+		adminRoute.Get("/audit", routing.Wrap(hs.AdminSearchAuditEntries))
+
+		adminRoute.Post("/plugins/install", bind(dtos.InstallPluginCommand{}), routing.Wrap(hs.AdminInstallPlugin))
+		adminRoute.Post("/plugins/upload", routing.Wrap(hs.AdminUploadPlugin))
+		adminRoute.Post("/plugins/:pluginId/update", bind(dtos.UpdatePluginCommand{}), routing.Wrap(hs.AdminUpdatePlugin))
+		adminRoute.Delete("/plugins/:pluginId", routing.Wrap(hs.AdminUninstallPlugin))
+		adminRoute.Get("/backend-plugins", routing.Wrap(hs.AdminGetBackendPluginStatuses))
+		adminRoute.Get("/backend-plugins/:pluginId", routing.Wrap(hs.AdminGetBackendPluginStatus))
+		adminRoute.Post("/backend-plugins/:pluginId/restart", routing.Wrap(hs.AdminRestartBackendPlugin))
+		adminRoute.Post("/backend-plugins/:pluginId/disable", routing.Wrap(hs.AdminDisableBackendPlugin))
+	}, reqGrafanaAdmin, rateLimit(setting.RateLimitGroupAPI))
 
 	// Administering users
 	r.Group("/api/admin/users", func(adminUserRoute routing.RouteRegister) {
@@ -451,7 +498,8 @@ func (hs *HTTPServer) registerRoutes() {
 		adminUserRoute.Post("/:id/logout", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersLogout, userIDScope), routing.Wrap(hs.AdminLogoutUser))
 		adminUserRoute.Get("/:id/auth-tokens", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersAuthTokenList, userIDScope), routing.Wrap(hs.AdminGetUserAuthTokens))
 		adminUserRoute.Post("/:id/revoke-auth-token", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersAuthTokenUpdate, userIDScope), bind(models.RevokeAuthTokenCmd{}), routing.Wrap(hs.AdminRevokeUserAuthToken))
-	})
+		adminUserRoute.Delete("/:id/totp", authorize(reqGrafanaAdmin, accesscontrol.ActionUsersTOTPDelete, userIDScope), routing.Wrap(hs.AdminResetUserTOTP))
+	}, rateLimit(setting.RateLimitGroupAPI))
 
 	// rendering
 	r.Get("/render/*", reqSignedIn, hs.RenderToPng)
@@ -464,11 +512,13 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Get("/avatar/:hash", avatarCacheServer.Handler)
 
 	// Snapshots
-	r.Post("/api/snapshots/", reqSnapshotPublicModeOrSignedIn, bind(models.CreateDashboardSnapshotCommand{}), CreateDashboardSnapshot)
+	r.Post("/api/snapshots/", reqSnapshotPublicModeOrSignedIn, quota("dashboard_snapshot"), bind(models.CreateDashboardSnapshotCommand{}), CreateDashboardSnapshot)
 	r.Get("/api/snapshot/shared-options/", reqSignedIn, GetSharingOptions)
 	r.Get("/api/snapshots/:key", routing.Wrap(GetDashboardSnapshot))
 	r.Get("/api/snapshots-delete/:deleteKey", reqSnapshotPublicModeOrSignedIn, routing.Wrap(DeleteDashboardSnapshotByDeleteKey))
 	r.Delete("/api/snapshots/:key", reqEditorRole, routing.Wrap(DeleteDashboardSnapshot))
+	r.Post("/api/snapshots/:key/archive", reqSignedIn, bind(dtos.ExportDashboardSnapshotArchiveCommand{}), routing.Wrap(hs.ExportDashboardSnapshotArchive))
+	r.Post("/api/snapshots/import", reqSignedIn, quota("dashboard_snapshot"), routing.Wrap(ImportDashboardSnapshotArchive))
 
 	// Frontend logs
 	sourceMapStore := frontendlogging.NewSourceMapStore(hs.Cfg, hs.PluginManager, frontendlogging.ReadSourceMapFromFS)
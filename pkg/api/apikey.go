@@ -26,10 +26,11 @@ func GetAPIKeys(c *models.ReqContext) response.Response {
 			expiration = &v
 		}
 		result[i] = &models.ApiKeyDTO{
-			Id:         t.Id,
-			Name:       t.Name,
-			Role:       t.Role,
-			Expiration: expiration,
+			Id:               t.Id,
+			Name:             t.Name,
+			Role:             t.Role,
+			Expiration:       expiration,
+			ServiceAccountId: t.ServiceAccountId,
 		}
 	}
 
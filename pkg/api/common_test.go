@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/auth/jwt"
+	"github.com/grafana/grafana/pkg/services/auth/totp"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -189,6 +190,10 @@ func getContextHandler(t *testing.T, cfg *setting.Cfg) *contexthandler.ContextHa
 			Name:     jwt.ServiceName,
 			Instance: authJWTSvc,
 		},
+		{
+			Name:     totp.ServiceName,
+			Instance: &totp.Service{Bus: bus.GetBus()},
+		},
 		{
 			Name:     contexthandler.ServiceName,
 			Instance: ctxHdlr,
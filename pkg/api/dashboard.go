@@ -246,6 +246,37 @@ func (hs *HTTPServer) deleteDashboard(c *models.ReqContext) response.Response {
 	})
 }
 
+// dashboardBytesAdded returns the number of bytes that saving a dashboard adds to the dashboard_bytes usage,
+// which is its size minus the size of the dashboard it replaces, and the number of bytes it adds to the usage
+// of its folder, which is its whole size when the dashboard moves there from another folder.
+func dashboardBytesAdded(dash *models.Dashboard) (int64, int64, error) {
+	data, err := dash.Data.Encode()
+	if err != nil {
+		return 0, 0, err
+	}
+	size := int64(len(data))
+	if dash.Id == 0 && dash.Uid == "" {
+		return size, size, nil
+	}
+
+	query := models.GetDashboardQuery{Id: dash.Id, Uid: dash.Uid, OrgId: dash.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return size, size, nil
+		}
+		return 0, 0, err
+	}
+	existing, err := query.Result.Data.Encode()
+	if err != nil {
+		return 0, 0, err
+	}
+	added := size - int64(len(existing))
+	if query.Result.FolderId != dash.FolderId {
+		return added, size, nil
+	}
+	return added, added, nil
+}
+
 func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboardCommand) response.Response {
 	cmd.OrgId = c.OrgId
 	cmd.UserId = c.UserId
@@ -254,7 +285,21 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 
 	newDashboard := dash.Id == 0 && dash.Uid == ""
 	if newDashboard {
-		limitReached, err := hs.QuotaService.QuotaReached(c, "dashboard")
+		limitReached, err := hs.QuotaService.QuotaReachedInFolder(c, "dashboard", dash.FolderId)
+		if err != nil {
+			return response.Error(500, "failed to get quota", err)
+		}
+		if limitReached {
+			return response.Error(403, "Quota reached", nil)
+		}
+	}
+	if hs.Cfg.Quota.Enabled {
+		bytesAdded, folderBytesAdded, err := dashboardBytesAdded(dash)
+		if err != nil {
+			return response.Error(500, "Failed to get dashboard size", err)
+		}
+		limitReached, err := hs.QuotaService.QuotaExceededInFolderByScope(c, "dashboard_bytes", dash.FolderId,
+			bytesAdded, folderBytesAdded)
 		if err != nil {
 			return response.Error(500, "failed to get quota", err)
 		}
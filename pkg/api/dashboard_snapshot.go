@@ -3,7 +3,9 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"time"
 
@@ -11,9 +13,11 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/components/snapshotarchive"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -325,3 +329,188 @@ func SearchDashboardSnapshots(c *models.ReqContext) response.Response {
 
 	return response.JSON(200, dtos)
 }
+
+// maxSnapshotArchiveSize is the largest snapshot archive accepted for import.
+const maxSnapshotArchiveSize = 100 << 20
+
+// POST /api/snapshots/:key/archive
+func (hs *HTTPServer) ExportDashboardSnapshotArchive(c *models.ReqContext, cmd dtos.ExportDashboardSnapshotArchiveCommand) response.Response {
+	query := &models.GetDashboardSnapshotQuery{Key: c.Params(":key")}
+	if err := bus.Dispatch(query); err != nil {
+		if errors.Is(err, models.ErrDashboardSnapshotNotFound) {
+			return response.Error(404, "Dashboard snapshot not found", err)
+		}
+		return response.Error(500, "Failed to get dashboard snapshot", err)
+	}
+
+	snapshot := query.Result
+	if snapshot.OrgId != c.OrgId || snapshot.Expires.Before(time.Now()) {
+		return response.Error(404, "Dashboard snapshot not found", nil)
+	}
+	if snapshot.External {
+		return response.Error(400, "External snapshots can't be exported", nil)
+	}
+
+	dashboard, err := snapshot.DashboardJSON()
+	if err != nil {
+		return response.Error(500, "Failed to get dashboard data for dashboard snapshot", err)
+	}
+	dashboardJSON, err := dashboard.Encode()
+	if err != nil {
+		return response.Error(500, "Failed to encode dashboard snapshot", err)
+	}
+
+	archive := &snapshotarchive.Archive{
+		Name:      snapshot.Name,
+		Created:   snapshot.Created,
+		Expires:   snapshot.Expires,
+		Dashboard: dashboardJSON,
+		Images:    make(map[string][]byte),
+	}
+
+	if cmd.RenderPanels {
+		if !hs.RenderService.IsAvailable() {
+			return response.Error(400, "Image renderer is not available", nil)
+		}
+		if err := hs.renderSnapshotPanels(c, snapshot.Key, dashboard, cmd, archive.Images); err != nil {
+			return response.Error(500, "Failed to render snapshot panels", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := snapshotarchive.Write(&buf, archive, cmd.Password); err != nil {
+		return response.Error(500, "Failed to create snapshot archive", err)
+	}
+
+	return response.Respond(200, buf.Bytes()).
+		SetHeader("Content-Type", "application/zip").
+		SetHeader("Content-Disposition", fmt.Sprintf(`attachment; filename="snapshot-%s.zip"`, snapshot.Key))
+}
+
+func (hs *HTTPServer) renderSnapshotPanels(c *models.ReqContext, key string, dashboard *simplejson.Json,
+	cmd dtos.ExportDashboardSnapshotArchiveCommand, images map[string][]byte) error {
+	width, height := cmd.Width, cmd.Height
+	if width <= 0 {
+		width = 1000
+	}
+	if height <= 0 {
+		height = 500
+	}
+
+	for _, panelID := range getSnapshotPanelIDs(dashboard) {
+		result, err := hs.RenderService.Render(c.Req.Context(), rendering.Opts{
+			Width:           width,
+			Height:          height,
+			Timeout:         time.Minute,
+			OrgId:           c.OrgId,
+			UserId:          c.UserId,
+			OrgRole:         c.OrgRole,
+			Path:            fmt.Sprintf("dashboard-solo/snapshot/%s?orgId=%d&panelId=%d", key, c.OrgId, panelID),
+			ConcurrentLimit: hs.Cfg.RendererConcurrentRequestLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		image, err := ioutil.ReadFile(result.FilePath)
+		if err != nil {
+			return err
+		}
+		images[fmt.Sprintf("panel-%d.png", panelID)] = image
+	}
+
+	return nil
+}
+
+// getSnapshotPanelIDs returns the ids of all panels in a dashboard, including panels in collapsed rows.
+func getSnapshotPanelIDs(dashboard *simplejson.Json) []int64 {
+	var ids []int64
+	for _, p := range dashboard.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(p)
+		if panel.Get("type").MustString() == "row" {
+			for _, rp := range panel.Get("panels").MustArray() {
+				ids = append(ids, simplejson.NewFromAny(rp).Get("id").MustInt64())
+			}
+			continue
+		}
+		ids = append(ids, panel.Get("id").MustInt64())
+	}
+
+	return ids
+}
+
+// POST /api/snapshots/import
+func ImportDashboardSnapshotArchive(c *models.ReqContext) response.Response {
+	c.Req.Request.Body = http.MaxBytesReader(c.Resp, c.Req.Request.Body, maxSnapshotArchiveSize)
+	if err := c.Req.ParseMultipartForm(maxSnapshotArchiveSize); err != nil {
+		return response.Error(400, "Failed to parse snapshot archive upload", err)
+	}
+
+	file, header, err := c.Req.FormFile("archive")
+	if err != nil {
+		return response.Error(400, "Snapshot archive is missing", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			plog.Warn("Failed to close snapshot archive", "err", err)
+		}
+	}()
+
+	archive, err := snapshotarchive.Read(file, header.Size, c.Req.FormValue("password"))
+	if err != nil {
+		switch {
+		case errors.Is(err, snapshotarchive.ErrPasswordRequired), errors.Is(err, snapshotarchive.ErrDecryptionFailed):
+			return response.Error(401, err.Error(), err)
+		case errors.Is(err, snapshotarchive.ErrChecksumMismatch), errors.Is(err, snapshotarchive.ErrInvalidArchive),
+			errors.Is(err, snapshotarchive.ErrUnsupportedVersion):
+			return response.Error(400, err.Error(), err)
+		}
+		return response.Error(500, "Failed to read snapshot archive", err)
+	}
+
+	// the rendered panel images are for viewing the snapshot outside of Grafana, which shows the
+	// snapshot from its data instead, so they are only dropped when the caller agrees to
+	if len(archive.Images) > 0 && c.Req.FormValue("skipImages") != "true" {
+		return response.Error(400, "Snapshot archives with panel images can only be imported with skipImages", nil)
+	}
+
+	now := time.Now()
+	if archive.Expired(now) {
+		return response.Error(410, "Dashboard snapshot in archive has expired", nil)
+	}
+
+	dashboard, err := simplejson.NewJson(archive.Dashboard)
+	if err != nil {
+		return response.Error(400, "Invalid dashboard in snapshot archive", err)
+	}
+
+	cmd := models.CreateDashboardSnapshotCommand{
+		Dashboard: dashboard,
+		Name:      archive.Name,
+		OrgId:     c.OrgId,
+		UserId:    c.UserId,
+	}
+	if !archive.Expires.IsZero() {
+		cmd.Expires = int64(archive.Expires.Sub(now).Seconds())
+	}
+	if cmd.Key, err = util.GetRandomString(32); err != nil {
+		return response.Error(500, "Could not generate random string", err)
+	}
+	if cmd.DeleteKey, err = util.GetRandomString(32); err != nil {
+		return response.Error(500, "Could not generate random string", err)
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return response.Error(500, "Failed to create snapshot", err)
+	}
+
+	metrics.MApiDashboardSnapshotCreate.Inc()
+
+	return response.JSON(200, util.DynMap{
+		"key":       cmd.Key,
+		"deleteKey": cmd.DeleteKey,
+		"url":       setting.ToAbsUrl("dashboard/snapshot/" + cmd.Key),
+		"deleteUrl": setting.ToAbsUrl("api/snapshots-delete/" + cmd.DeleteKey),
+		"id":        cmd.Result.Id,
+	})
+}
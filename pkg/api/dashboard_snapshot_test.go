@@ -1,7 +1,9 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,9 +13,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/securedata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/components/snapshotarchive"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -293,3 +298,141 @@ func TestDashboardSnapshotAPIEndpoint_singleSnapshot(t *testing.T) {
 			})
 	})
 }
+
+func TestDashboardSnapshotAPIEndpoint_archive(t *testing.T) {
+	t.Run("When exporting a snapshot as an archive", func(t *testing.T) {
+		loggedInUserScenarioWithRole(t, "Should return an encrypted archive when calling POST on", "GET",
+			"/api/snapshots/12345/archive", "/api/snapshots/:key/archive", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				encrypted, err := securedata.Encrypt([]byte(`{"id":100,"panels":[{"id":1}]}`))
+				require.NoError(t, err)
+				mockSnapshotResult := &models.DashboardSnapshot{
+					Key:                "12345",
+					Name:               "Incident",
+					OrgId:              testOrgID,
+					DashboardEncrypted: encrypted,
+					Expires:            time.Now().Add(time.Hour),
+				}
+				bus.AddHandler("test", func(query *models.GetDashboardSnapshotQuery) error {
+					query.Result = mockSnapshotResult
+					return nil
+				})
+
+				hs := &HTTPServer{Cfg: sc.cfg}
+				sc.handlerFunc = func(c *models.ReqContext) response.Response {
+					return hs.ExportDashboardSnapshotArchive(c, dtos.ExportDashboardSnapshotArchiveCommand{Password: "secret"})
+				}
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				require.Equal(t, 200, sc.resp.Code)
+				assert.Equal(t, "application/zip", sc.resp.Header().Get("Content-Type"))
+
+				archive, err := snapshotarchive.ReadBytes(sc.resp.Body.Bytes(), "secret")
+				require.NoError(t, err)
+				assert.Equal(t, "Incident", archive.Name)
+				assert.JSONEq(t, `{"id":100,"panels":[{"id":1}]}`, string(archive.Dashboard))
+			})
+
+		loggedInUserScenarioWithRole(t, "Should not export a snapshot from another org when calling POST on", "GET",
+			"/api/snapshots/12345/archive", "/api/snapshots/:key/archive", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				bus.AddHandler("test", func(query *models.GetDashboardSnapshotQuery) error {
+					query.Result = &models.DashboardSnapshot{
+						Key:       "12345",
+						OrgId:     testOrgID + 1,
+						Dashboard: simplejson.New(),
+						Expires:   time.Now().Add(time.Hour),
+					}
+					return nil
+				})
+
+				hs := &HTTPServer{Cfg: sc.cfg}
+				sc.handlerFunc = func(c *models.ReqContext) response.Response {
+					return hs.ExportDashboardSnapshotArchive(c, dtos.ExportDashboardSnapshotArchiveCommand{})
+				}
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+				assert.Equal(t, 404, sc.resp.Code)
+			})
+	})
+
+	importArchive := func(sc *scenarioContext, archive *snapshotarchive.Archive, password string, skipImages bool) {
+		var archiveBuf bytes.Buffer
+		require.NoError(t, snapshotarchive.Write(&archiveBuf, archive, password))
+
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("archive", "snapshot.zip")
+		require.NoError(t, err)
+		_, err = fw.Write(archiveBuf.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, mw.WriteField("password", password))
+		if skipImages {
+			require.NoError(t, mw.WriteField("skipImages", "true"))
+		}
+		require.NoError(t, mw.Close())
+
+		sc.m.Post("/api/snapshots/import", sc.defaultHandler)
+		sc.handlerFunc = ImportDashboardSnapshotArchive
+		sc.resp = httptest.NewRecorder()
+		sc.req, err = http.NewRequest("POST", "/api/snapshots/import", &body)
+		require.NoError(t, err)
+		sc.req.Header.Set("Content-Type", mw.FormDataContentType())
+		sc.exec()
+	}
+
+	t.Run("When importing a snapshot archive", func(t *testing.T) {
+		loggedInUserScenarioWithRole(t, "Should create a snapshot when calling POST on", "POST",
+			"/api/snapshots/import", "/api/snapshots/import", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				var createCmd *models.CreateDashboardSnapshotCommand
+				bus.AddHandler("test", func(cmd *models.CreateDashboardSnapshotCommand) error {
+					createCmd = cmd
+					cmd.Result = &models.DashboardSnapshot{Id: 7}
+					return nil
+				})
+
+				importArchive(sc, &snapshotarchive.Archive{
+					Name:      "Incident",
+					Expires:   time.Now().Add(time.Hour),
+					Dashboard: []byte(`{"id":100}`),
+				}, "secret", false)
+
+				require.Equal(t, 200, sc.resp.Code)
+				require.NotNil(t, createCmd)
+				assert.Equal(t, "Incident", createCmd.Name)
+				assert.Equal(t, testOrgID, createCmd.OrgId)
+				assert.Equal(t, int64(100), createCmd.Dashboard.Get("id").MustInt64())
+				assert.InDelta(t, time.Hour.Seconds(), float64(createCmd.Expires), 60)
+				assert.NotEmpty(t, createCmd.Key)
+			})
+
+		loggedInUserScenarioWithRole(t, "Should reject an expired archive when calling POST on", "POST",
+			"/api/snapshots/import", "/api/snapshots/import", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				importArchive(sc, &snapshotarchive.Archive{
+					Name:      "Incident",
+					Expires:   time.Now().Add(-time.Hour),
+					Dashboard: []byte(`{"id":100}`),
+				}, "", false)
+
+				assert.Equal(t, 410, sc.resp.Code)
+			})
+
+		loggedInUserScenarioWithRole(t, "Should only import an archive with images when skipping them when calling POST on", "POST",
+			"/api/snapshots/import", "/api/snapshots/import", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				bus.AddHandler("test", func(cmd *models.CreateDashboardSnapshotCommand) error {
+					cmd.Result = &models.DashboardSnapshot{Id: 7}
+					return nil
+				})
+				archive := &snapshotarchive.Archive{
+					Name:      "Incident",
+					Expires:   time.Now().Add(time.Hour),
+					Dashboard: []byte(`{"id":100}`),
+					Images:    map[string][]byte{"panel-1.png": {0x89, 'P', 'N', 'G'}},
+				}
+
+				importArchive(sc, archive, "", false)
+				assert.Equal(t, 400, sc.resp.Code)
+
+				importArchive(sc, archive, "", true)
+				assert.Equal(t, 200, sc.resp.Code)
+			})
+	})
+}
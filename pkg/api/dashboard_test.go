@@ -84,6 +84,47 @@ type testState struct {
 //   2. and the dashboard is in a folder which does have an acl
 // 3. Post dashboard response tests
 
+func TestDashboardBytesAdded(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	existing := simplejson.NewFromAny(map[string]interface{}{"title": "Dash"})
+	bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+		if query.Uid != "existing" {
+			return models.ErrDashboardNotFound
+		}
+		query.Result = &models.Dashboard{Uid: "existing", FolderId: 1, Data: existing}
+		return nil
+	})
+
+	data := simplejson.NewFromAny(map[string]interface{}{"title": "Dash", "description": "Dashboard"})
+	encoded, err := data.Encode()
+	require.NoError(t, err)
+	existingEncoded, err := existing.Encode()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		uid            string
+		folderID       int64
+		expected       int64
+		expectedFolder int64
+	}{
+		{desc: "new dashboard", expected: int64(len(encoded)), expectedFolder: int64(len(encoded))},
+		{desc: "dashboard that doesn't exist", uid: "missing", folderID: 1, expected: int64(len(encoded)), expectedFolder: int64(len(encoded))},
+		{desc: "dashboard moved to another folder", uid: "existing", folderID: 2, expected: int64(len(encoded) - len(existingEncoded)), expectedFolder: int64(len(encoded))},
+		{desc: "updated dashboard", uid: "existing", folderID: 1, expected: int64(len(encoded) - len(existingEncoded)), expectedFolder: int64(len(encoded) - len(existingEncoded))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dash := &models.Dashboard{Uid: tc.uid, FolderId: tc.folderID, OrgId: 1, Data: data}
+			added, folderAdded, err := dashboardBytesAdded(dash)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, added)
+			assert.Equal(t, tc.expectedFolder, folderAdded)
+		})
+	}
+}
+
 func TestDashboardAPIEndpoint(t *testing.T) {
 	t.Run("Given a dashboard with a parent folder which does not have an ACL", func(t *testing.T) {
 		setUp := func() *testState {
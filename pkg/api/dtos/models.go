@@ -24,6 +24,10 @@ type LoginCommand struct {
 	Remember bool   `json:"remember"`
 }
 
+type LoginTOTPCommand struct {
+	Code string `json:"code" binding:"Required"`
+}
+
 type CurrentUser struct {
 	IsSignedIn                 bool              `json:"isSignedIn"`
 	Id                         int64             `json:"id"`
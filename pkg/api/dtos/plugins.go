@@ -6,18 +6,19 @@ import (
 )
 
 type PluginSetting struct {
-	Name          string                      `json:"name"`
-	Type          string                      `json:"type"`
-	Id            string                      `json:"id"`
-	Enabled       bool                        `json:"enabled"`
-	Pinned        bool                        `json:"pinned"`
-	Module        string                      `json:"module"`
-	BaseUrl       string                      `json:"baseUrl"`
-	Info          *plugins.PluginInfo         `json:"info"`
-	Includes      []*plugins.PluginInclude    `json:"includes"`
-	Dependencies  *plugins.PluginDependencies `json:"dependencies"`
-	JsonData      map[string]interface{}      `json:"jsonData"`
-	DefaultNavUrl string                      `json:"defaultNavUrl"`
+	Name            string                      `json:"name"`
+	Type            string                      `json:"type"`
+	Id              string                      `json:"id"`
+	Enabled         bool                        `json:"enabled"`
+	Pinned          bool                        `json:"pinned"`
+	Module          string                      `json:"module"`
+	ModuleIntegrity string                      `json:"moduleIntegrity,omitempty"`
+	BaseUrl         string                      `json:"baseUrl"`
+	Info            *plugins.PluginInfo         `json:"info"`
+	Includes        []*plugins.PluginInclude    `json:"includes"`
+	Dependencies    *plugins.PluginDependencies `json:"dependencies"`
+	JsonData        map[string]interface{}      `json:"jsonData"`
+	DefaultNavUrl   string                      `json:"defaultNavUrl"`
 
 	LatestVersion string                        `json:"latestVersion"`
 	HasUpdate     bool                          `json:"hasUpdate"`
@@ -66,3 +67,12 @@ type ImportDashboardCommand struct {
 	Inputs    []plugins.ImportDashboardInput `json:"inputs"`
 	FolderId  int64                          `json:"folderId"`
 }
+
+type InstallPluginCommand struct {
+	PluginId string `json:"pluginId" binding:"Required"`
+	Version  string `json:"version"`
+}
+
+type UpdatePluginCommand struct {
+	Version string `json:"version"`
+}
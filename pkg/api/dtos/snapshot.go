@@ -0,0 +1,10 @@
+package dtos
+
+// ExportDashboardSnapshotArchiveCommand is the command for downloading a dashboard snapshot as a portable archive.
+type ExportDashboardSnapshotArchiveCommand struct {
+	// Password encrypts the snapshot data in the archive when set.
+	Password     string `json:"password"`
+	RenderPanels bool   `json:"renderPanels"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
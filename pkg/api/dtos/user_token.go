@@ -11,6 +11,7 @@ type UserToken struct {
 	OperatingSystemVersion string    `json:"osVersion"`
 	Browser                string    `json:"browser"`
 	BrowserVersion         string    `json:"browserVersion"`
+	Location               string    `json:"location"`
 	CreatedAt              time.Time `json:"createdAt"`
 	SeenAt                 time.Time `json:"seenAt"`
 }
@@ -76,6 +76,9 @@ func logSentryEventScenario(t *testing.T, desc string, event frontendlogging.Fro
 				{
 					Directory: "/usr/local/telepathic-panel",
 					PluginId:  "telepathic",
+					Assets: plugins.PluginAssets{
+						"foo.js": {Path: "foo.js", Hash: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+					},
 				},
 			},
 		}
@@ -270,6 +273,12 @@ func TestFrontendLoggingEndpoint(t *testing.T) {
 									Lineno:   3,
 									Colno:    10,
 								},
+								{
+									Function: "hashed",
+									Filename: "http://localhost:3000/public/plugins/telepathic/foo.0123456789abcdef.js", // plugin asset loaded by its content hash, source map found and mapped
+									Lineno:   3,
+									Colno:    10,
+								},
 							},
 						},
 					},
@@ -288,8 +297,9 @@ func TestFrontendLoggingEndpoint(t *testing.T) {
   at wat (http://localhost:3000/public/build/bar.js:3:10)
   at nope (http://localhost:3000/baz.js:3:10)
   at fake (http://localhost:3000/public/build/../../secrets.txt:3:10)
-  at ? (core|webpack:///./some_source.ts:3:2)`)
-				assert.Len(t, sourceMapReads, 6)
+  at ? (core|webpack:///./some_source.ts:3:2)
+  at ? (telepathic|webpack:///./some_source.ts:3:2)`)
+				assert.Len(t, sourceMapReads, 7)
 				assert.Equal(t, "/staticroot", sourceMapReads[0].dir)
 				assert.Equal(t, "build/moo/foo.js.map", sourceMapReads[0].path)
 				assert.Equal(t, "/usr/local/telepathic-panel", sourceMapReads[1].dir)
@@ -302,6 +312,8 @@ func TestFrontendLoggingEndpoint(t *testing.T) {
 				assert.Equal(t, "secrets.txt.map", sourceMapReads[4].path)
 				assert.Equal(t, "/staticroot", sourceMapReads[5].dir)
 				assert.Equal(t, "build/foo.js.map", sourceMapReads[5].path)
+				assert.Equal(t, "/usr/local/telepathic-panel", sourceMapReads[6].dir)
+				assert.Equal(t, "/foo.js.map", sourceMapReads[6].path)
 			})
 	})
 }
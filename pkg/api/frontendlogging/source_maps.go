@@ -81,14 +81,21 @@ func (store *SourceMapStore) guessSourceMapLocation(sourceURL string) (*sourceMa
 				pluginID: "",
 			}, nil
 		}
-		// if source comes from a plugin, look in plugin dir
-	} else if strings.HasPrefix(u.Path, "/public/plugins/") {
+		// if source comes from a plugin, locally or CDN, look in plugin dir
+	} else if strings.HasPrefix(u.Path, "/public/plugins/") || (store.cfg.PluginsCDNBaseURL != "" &&
+		strings.HasPrefix(sourceURL, store.cfg.PluginsCDNBaseURL) && strings.Contains(u.Path, "/public/plugins/")) {
+		pluginPath := "/public/plugins/" + strings.SplitN(u.Path, "/public/plugins/", 2)[1]
 		for _, route := range store.pluginManager.StaticRoutes() {
 			pluginPrefix := filepath.Join("/public/plugins/", route.PluginId)
-			if strings.HasPrefix(u.Path, pluginPrefix) {
+			if strings.HasPrefix(pluginPath, pluginPrefix) {
+				sourcePath := pluginPath[len(pluginPrefix):]
+				// the source maps of the assets loaded by their content hash are named after the file
+				if asset, _ := route.Assets.Lookup(strings.TrimPrefix(sourcePath, "/")); asset != nil {
+					sourcePath = "/" + asset.Path
+				}
 				return &sourceMapLocation{
 					dir:      route.Directory,
-					path:     u.Path[len(pluginPrefix):] + ".map",
+					path:     sourcePath + ".map",
 					pluginID: route.PluginId,
 				}, nil
 			}
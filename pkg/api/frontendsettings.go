@@ -136,6 +136,23 @@ func (hs *HTTPServer) getFrontendSettingsMap(c *models.ReqContext) (map[string]i
 		}
 	}
 
+	// the integrity of the signed modules is checked when they're loaded, preloaded ones included
+	pluginModuleIntegrity := map[string]string{}
+	addModuleIntegrity := func(p plugins.PluginBase) {
+		if p.ModuleIntegrity != "" {
+			pluginModuleIntegrity[p.Module] = p.ModuleIntegrity
+		}
+	}
+	for _, app := range enabledPlugins.Apps {
+		addModuleIntegrity(app.PluginBase)
+	}
+	for _, ds := range enabledPlugins.DataSources {
+		addModuleIntegrity(ds.PluginBase)
+	}
+	for _, panel := range enabledPlugins.Panels {
+		addModuleIntegrity(panel.PluginBase)
+	}
+
 	dataSources, err := hs.getFSDataSources(c, enabledPlugins)
 	if err != nil {
 		return nil, err
@@ -165,16 +182,17 @@ func (hs *HTTPServer) getFrontendSettingsMap(c *models.ReqContext) (map[string]i
 		}
 
 		panels[panel.Id] = map[string]interface{}{
-			"module":        panel.Module,
-			"baseUrl":       panel.BaseUrl,
-			"name":          panel.Name,
-			"id":            panel.Id,
-			"info":          panel.Info,
-			"hideFromList":  panel.HideFromList,
-			"sort":          getPanelSort(panel.Id),
-			"skipDataQuery": panel.SkipDataQuery,
-			"state":         panel.State,
-			"signature":     panel.Signature,
+			"module":          panel.Module,
+			"moduleIntegrity": panel.ModuleIntegrity,
+			"baseUrl":         panel.BaseUrl,
+			"name":            panel.Name,
+			"id":              panel.Id,
+			"info":            panel.Info,
+			"hideFromList":    panel.HideFromList,
+			"sort":            getPanelSort(panel.Id),
+			"skipDataQuery":   panel.SkipDataQuery,
+			"state":           panel.State,
+			"signature":       panel.Signature,
 		}
 	}
 
@@ -206,6 +224,7 @@ func (hs *HTTPServer) getFrontendSettingsMap(c *models.ReqContext) (map[string]i
 		"autoAssignOrg":              setting.AutoAssignOrg,
 		"verifyEmailEnabled":         setting.VerifyEmailEnabled,
 		"sigV4AuthEnabled":           setting.SigV4AuthEnabled,
+		"totpEnabled":                hs.TOTPService.IsEnabled(),
 		"exploreEnabled":             setting.ExploreEnabled,
 		"googleAnalyticsId":          setting.GoogleAnalyticsId,
 		"disableLoginForm":           setting.DisableLoginForm,
@@ -219,6 +238,7 @@ func (hs *HTTPServer) getFrontendSettingsMap(c *models.ReqContext) (map[string]i
 		"editorsCanAdmin":            hs.Cfg.EditorsCanAdmin,
 		"disableSanitizeHtml":        hs.Cfg.DisableSanitizeHtml,
 		"pluginsToPreload":           pluginsToPreload,
+		"pluginModuleIntegrity":      pluginModuleIntegrity,
 		"buildInfo": map[string]interface{}{
 			"hideVersion":   hideVersion,
 			"version":       version,
@@ -1,15 +1,19 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -17,6 +21,7 @@ import (
 	httpstatic "github.com/grafana/grafana/pkg/api/static"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/geoip"
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
@@ -30,16 +35,21 @@ import (
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/auth/saml"
+	"github.com/grafana/grafana/pkg/services/auth/totp"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/ldapsync"
 	"github.com/grafana/grafana/pkg/services/librarypanels"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/push"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/provisioning"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/ratelimit"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/search"
 	"github.com/grafana/grafana/pkg/services/shorturls"
@@ -97,6 +107,12 @@ type HTTPServer struct {
 	DataService            *tsdb.Service                           `inject:""`
 	PluginDashboardService *plugindashboards.Service               `inject:""`
 	AlertEngine            *alerting.AlertEngine                   `inject:""`
+	LDAPSyncService        *ldapsync.LDAPSyncService               `inject:""`
+	SAMLService            *saml.Service                           `inject:""`
+	TOTPService            *totp.Service                           `inject:""`
+	RateLimitService       *ratelimit.Service                      `inject:""`
+	AuditService           *audit.Service                          `inject:""`
+	GeoIPService           *geoip.Service                          `inject:""`
 	Listener               net.Listener
 }
 
@@ -327,11 +343,7 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 
 	m.Use(middleware.Recovery(hs.Cfg))
 
-	for _, route := range hs.PluginManager.StaticRoutes() {
-		pluginRoute := path.Join("/public/plugins/", route.PluginId)
-		hs.log.Debug("Plugins: Adding route", "route", pluginRoute, "dir", route.Directory)
-		hs.mapStatic(m, route.Directory, "", pluginRoute)
-	}
+	m.Use(hs.pluginStaticRoutes())
 
 	hs.mapStatic(m, hs.Cfg.StaticRootPath, "build", "public/build")
 	hs.mapStatic(m, hs.Cfg.StaticRootPath, "", "public")
@@ -444,7 +456,139 @@ func (hs *HTTPServer) apiHealthHandler(ctx *macaron.Context) {
 	}
 }
 
+// pluginStaticRoutes serves the static files of the plugins. The routes are looked up on every request,
+// since plugins can be installed and uninstalled at runtime.
+func (hs *HTTPServer) pluginStaticRoutes() macaron.Handler {
+	var handlers sync.Map
+	return func(c *macaron.Context) {
+		if !strings.HasPrefix(c.Req.URL.Path, "/public/plugins/") {
+			return
+		}
+		parts := strings.SplitN(strings.TrimPrefix(c.Req.URL.Path, "/public/plugins/"), "/", 2)
+		pluginID := parts[0]
+
+		for _, route := range hs.PluginManager.StaticRoutes() {
+			if route.PluginId != pluginID {
+				continue
+			}
+
+			if len(parts) == 2 && (c.Req.Method == http.MethodGet || c.Req.Method == http.MethodHead) {
+				if asset, hashed := route.Assets.Lookup(parts[1]); asset != nil {
+					if hs.servePluginAsset(c, route.Directory, asset, hashed) {
+						return
+					}
+				}
+			}
+
+			pluginRoute := path.Join("/public/plugins/", route.PluginId)
+			handler, exists := handlers.Load(route.Directory)
+			if !exists {
+				hs.log.Debug("Plugins: Adding route", "route", pluginRoute, "dir", route.Directory)
+				handler, _ = handlers.LoadOrStore(route.Directory, hs.staticHandler(route.Directory, "", pluginRoute))
+			}
+			if _, err := c.Invoke(handler); err != nil {
+				hs.log.Error("Failed to serve plugin static file", "pluginId", pluginID, "err", err)
+			}
+			if c.Written() {
+				return
+			}
+		}
+	}
+}
+
+// servePluginAsset serves an indexed plugin asset, precompressed when the client accepts it, with its ETag. The
+// assets requested with a content hash are immutable. It returns false when the asset couldn't be read, or has
+// changed since it was indexed.
+func (hs *HTTPServer) servePluginAsset(c *macaron.Context, dir string, asset *plugins.PluginAsset, hashed bool) bool {
+	// the index is stale when the plugin files are replaced without reloading the plugin, e.g. by a rebuild
+	info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(asset.Path)))
+	if err != nil || info.Size() != asset.Size || !info.ModTime().Equal(asset.ModTime) {
+		hs.log.Debug("Plugin asset changed since it was indexed", "path", asset.Path, "dir", dir)
+		return false
+	}
+
+	var content io.ReadSeeker
+	encoding := ""
+	acceptEncoding := c.Req.Header.Get("Accept-Encoding")
+	if asset.BrotliPath != "" && acceptsEncoding(acceptEncoding, "br") {
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because the path is indexed from the plugin directory.
+		if f, err := os.Open(filepath.Join(dir, filepath.FromSlash(asset.BrotliPath))); err == nil {
+			defer closePluginAsset(hs.log, f)
+			content, encoding = f, "br"
+		}
+	}
+	if content == nil && len(asset.Gzip) > 0 && acceptsEncoding(acceptEncoding, "gzip") {
+		content, encoding = bytes.NewReader(asset.Gzip), "gzip"
+	}
+	if content == nil {
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because the path is indexed from the plugin directory.
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(asset.Path)))
+		if err != nil {
+			hs.log.Debug("Failed to open plugin asset", "path", asset.Path, "dir", dir, "err", err)
+			return false
+		}
+		defer closePluginAsset(hs.log, f)
+		content = f
+	}
+
+	header := c.Resp.Header()
+	if hashed {
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		header.Set("Cache-Control", "public, max-age=3600")
+	}
+	header.Add("Vary", "Accept-Encoding")
+	if encoding == "" {
+		header.Set("ETag", asset.ETag())
+		if asset.Integrity != "" {
+			header.Set("Digest", "sha-256="+strings.TrimPrefix(asset.Integrity, "sha256-"))
+		}
+	} else {
+		// the representations of the asset have different entity tags
+		header.Set("ETag", strings.TrimSuffix(asset.ETag(), `"`)+"-"+encoding+`"`)
+		header.Set("Content-Encoding", encoding)
+		if contentType := mime.TypeByExtension(path.Ext(asset.Path)); contentType != "" {
+			header.Set("Content-Type", contentType)
+		} else {
+			header.Set("Content-Type", "application/octet-stream")
+		}
+	}
+
+	http.ServeContent(c.Resp, c.Req.Request, path.Base(asset.Path), asset.ModTime, content)
+	return true
+}
+
+func closePluginAsset(logger log.Logger, f *os.File) {
+	if err := f.Close(); err != nil {
+		logger.Warn("Failed to close plugin asset", "path", f.Name(), "err", err)
+	}
+}
+
+// acceptsEncoding returns whether an Accept-Encoding header accepts a content coding.
+func acceptsEncoding(acceptEncoding, encoding string) bool {
+	for _, value := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(value, ";")
+		if name := strings.TrimSpace(params[0]); name != encoding && name != "*" {
+			continue
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if q, err := strconv.ParseFloat(kv[len(kv)-1], 64); kv[0] == "q" && err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func (hs *HTTPServer) mapStatic(m *macaron.Macaron, rootDir string, dir string, prefix string) {
+	m.Use(hs.staticHandler(rootDir, dir, prefix))
+}
+
+func (hs *HTTPServer) staticHandler(rootDir string, dir string, prefix string) macaron.Handler {
 	headers := func(c *macaron.Context) {
 		c.Resp.Header().Set("Cache-Control", "public, max-age=3600")
 	}
@@ -461,14 +605,14 @@ func (hs *HTTPServer) mapStatic(m *macaron.Macaron, rootDir string, dir string,
 		}
 	}
 
-	m.Use(httpstatic.Static(
+	return httpstatic.Static(
 		path.Join(rootDir, dir),
 		httpstatic.StaticOptions{
 			SkipLogging: true,
 			Prefix:      prefix,
 			AddHeaders:  headers,
 		},
-	))
+	)
 }
 
 func (hs *HTTPServer) metricsEndpointBasicAuthEnabled() bool {
@@ -150,6 +150,15 @@ func (hs *HTTPServer) GetLDAPStatus(c *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, serverDTOs)
 }
 
+// GetLDAPSyncStatus returns the schedule and the result of the last background LDAP sync
+func (hs *HTTPServer) GetLDAPSyncStatus(c *models.ReqContext) response.Response {
+	if !ldap.IsEnabled() {
+		return response.Error(http.StatusBadRequest, "LDAP is not enabled", nil)
+	}
+
+	return response.JSON(http.StatusOK, hs.LDAPSyncService.Status())
+}
+
 // PostSyncUserWithLDAP enables a single Grafana user to be synchronized against LDAP
 func (hs *HTTPServer) PostSyncUserWithLDAP(c *models.ReqContext) response.Response {
 	if !ldap.IsEnabled() {
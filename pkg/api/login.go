@@ -96,7 +96,7 @@ func (hs *HTTPServer) LoginView(c *models.ReqContext) {
 	}
 
 	viewData.Settings["oauth"] = enabledOAuths
-	viewData.Settings["samlEnabled"] = hs.License.HasValidLicense() && hs.Cfg.SAMLEnabled
+	viewData.Settings["samlEnabled"] = hs.SAMLService.IsEnabled()
 
 	if loginError, ok := tryGetEncryptedCookie(c, loginErrorCookieName); ok {
 		// this cookie is only set whenever an OAuth login fails
@@ -223,6 +223,13 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) res
 
 	user = authQuery.User
 
+	if authModule == "grafana" {
+		if totpResp := hs.requireTOTPLogin(c, user); totpResp != nil {
+			resp = totpResp
+			return resp
+		}
+	}
+
 	err = hs.loginUserWithUser(user, c)
 	if err != nil {
 		var createTokenErr *models.CreateTokenErr
@@ -234,6 +241,16 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) res
 		return resp
 	}
 
+	result := hs.loginSuccessResult(c)
+
+	metrics.MApiLoginPost.Inc()
+	resp = response.JSON(http.StatusOK, result)
+	return resp
+}
+
+// loginSuccessResult returns the response body of a successful login, with the URL set by the
+// redirect_to cookie.
+func (hs *HTTPServer) loginSuccessResult(c *models.ReqContext) map[string]interface{} {
 	result := map[string]interface{}{
 		"message": "Logged in",
 	}
@@ -247,9 +264,7 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) res
 		cookies.DeleteCookie(c.Resp, "redirect_to", hs.CookieOptionsFromCfg)
 	}
 
-	metrics.MApiLoginPost.Inc()
-	resp = response.JSON(http.StatusOK, result)
-	return resp
+	return result
 }
 
 func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext) error {
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/middleware/cookies"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	samlLogger              = log.New("saml")
+	SAMLRequestIDCookieName = "saml_request_id"
+	errSAMLMissingRequestID = errors.New("login.SAMLLogin(missing saved request)")
+)
+
+// samlCookieOptions returns the options of the cookie tracking the authentication request. The
+// IdP posts the response from another site, so the cookie cannot be restricted to same-site
+// requests.
+func (hs *HTTPServer) samlCookieOptions() cookies.CookieOptions {
+	options := hs.CookieOptionsFromCfg()
+	if options.Secure {
+		options.SameSiteDisabled = false
+		options.SameSiteMode = http.SameSiteNoneMode
+	} else {
+		options.SameSiteDisabled = true
+	}
+	return options
+}
+
+// GET /saml/metadata
+func (hs *HTTPServer) SAMLMetadata(c *models.ReqContext) {
+	if !hs.SAMLService.IsEnabled() {
+		c.Handle(hs.Cfg, http.StatusNotFound, "SAML not enabled", nil)
+		return
+	}
+
+	metadata, err := hs.SAMLService.Metadata()
+	if err != nil {
+		c.Handle(hs.Cfg, http.StatusInternalServerError, "Failed to create SAML metadata", err)
+		return
+	}
+
+	c.Resp.Header().Set("Content-Type", "application/samlmetadata+xml")
+	c.Resp.WriteHeader(http.StatusOK)
+	if _, err := c.Resp.Write(metadata); err != nil {
+		samlLogger.Error("Failed to write SAML metadata", "error", err)
+	}
+}
+
+// GET /login/saml
+func (hs *HTTPServer) SAMLLogin(c *models.ReqContext) {
+	loginInfo := models.LoginInfo{AuthModule: "saml"}
+	if !hs.SAMLService.IsEnabled() {
+		hs.handleOAuthLoginError(c, loginInfo, LoginError{
+			HttpStatus:    http.StatusNotFound,
+			PublicMessage: "SAML not enabled",
+		})
+		return
+	}
+
+	req, err := hs.SAMLService.NewAuthnRequest()
+	if err != nil {
+		hs.handleOAuthLoginError(c, loginInfo, LoginError{
+			HttpStatus:    http.StatusInternalServerError,
+			PublicMessage: "An internal error occurred",
+			Err:           err,
+		})
+		return
+	}
+
+	cookies.WriteCookie(c.Resp, SAMLRequestIDCookieName, req.ID, hs.Cfg.OAuthCookieMaxAge, hs.samlCookieOptions)
+
+	if req.RedirectURL != "" {
+		c.Redirect(req.RedirectURL)
+		return
+	}
+
+	c.Resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Resp.WriteHeader(http.StatusOK)
+	if _, err := c.Resp.Write(req.PostForm); err != nil {
+		samlLogger.Error("Failed to write SAML authentication request", "error", err)
+	}
+}
+
+// POST /saml/acs
+func (hs *HTTPServer) SAMLACS(c *models.ReqContext) {
+	loginInfo := models.LoginInfo{AuthModule: "saml"}
+	if !hs.SAMLService.IsEnabled() {
+		hs.handleOAuthLoginError(c, loginInfo, LoginError{
+			HttpStatus:    http.StatusNotFound,
+			PublicMessage: "SAML not enabled",
+		})
+		return
+	}
+
+	requestID := c.GetCookie(SAMLRequestIDCookieName)
+	cookies.DeleteCookie(c.Resp, SAMLRequestIDCookieName, hs.samlCookieOptions)
+	if requestID == "" {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, errSAMLMissingRequestID)
+		return
+	}
+
+	extUser, err := hs.SAMLService.ParseResponse(c.Req.Request, []string{requestID})
+	if err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, err)
+		return
+	}
+	samlLogger.Debug("SAML login got user info", "userInfo", extUser)
+
+	loginInfo.ExternalUser = *extUser
+	cmd := &models.UpsertUserCommand{
+		ReqContext:    c,
+		ExternalUser:  extUser,
+		SignupAllowed: hs.Cfg.SAMLAllowSignup,
+	}
+	if err := bus.Dispatch(cmd); err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, err)
+		return
+	}
+	loginInfo.User = cmd.Result
+
+	// Do not expose disabled status,
+	// just show incorrect user credentials error (see #17947)
+	if loginInfo.User.IsDisabled {
+		samlLogger.Warn("User is disabled", "user", loginInfo.User.Login)
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, login.ErrInvalidCredentials)
+		return
+	}
+
+	if err := hs.loginUserWithUser(loginInfo.User, c); err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, err)
+		return
+	}
+
+	loginInfo.HTTPStatus = http.StatusOK
+	hs.HooksService.RunLoginHook(&loginInfo, c)
+	metrics.MApiLoginSAML.Inc()
+
+	if redirectTo, err := url.QueryUnescape(c.GetCookie("redirect_to")); err == nil && len(redirectTo) > 0 {
+		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
+			cookies.DeleteCookie(c.Resp, "redirect_to", hs.CookieOptionsFromCfg)
+			c.Redirect(redirectTo)
+			return
+		}
+		log.Debugf("Ignored invalid redirect_to cookie value: %v", redirectTo)
+	}
+
+	c.Redirect(hs.Cfg.AppSubURL + "/")
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/middleware/cookies"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth/totp"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// TOTPLoginCookieName is the cookie identifying a user whose password was checked, until the
+// second factor is checked too.
+const TOTPLoginCookieName = "grafana_totp_login"
+
+var errTOTPLoginExpired = errors.New("two-factor authentication login expired")
+
+// requireTOTPLogin starts the second login step if the user has set up two-factor authentication,
+// or must set it up. It returns the response to send, or nil if the user can be signed in.
+func (hs *HTTPServer) requireTOTPLogin(c *models.ReqContext, user *models.User) *response.NormalResponse {
+	if !hs.TOTPService.IsEnabled() {
+		return nil
+	}
+
+	status, err := hs.TOTPService.GetStatus(user.Id)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+	}
+	if !status.Enabled && !status.Required {
+		return nil
+	}
+
+	if err := hs.writeTOTPLoginCookie(c, user.Id); err != nil {
+		return response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+	}
+
+	return response.JSON(http.StatusOK, map[string]interface{}{
+		"message":           "Two-factor authentication required",
+		"totpRequired":      true,
+		"totpSetupRequired": !status.Enabled,
+	})
+}
+
+func (hs *HTTPServer) writeTOTPLoginCookie(c *models.ReqContext, userID int64) error {
+	expires := time.Now().Add(hs.Cfg.TOTPLoginMaxLifetime)
+	encrypted, err := util.Encrypt([]byte(fmt.Sprintf("%d:%d", userID, expires.Unix())), setting.SecretKey)
+	if err != nil {
+		return err
+	}
+
+	cookies.WriteCookie(c.Resp, TOTPLoginCookieName, hex.EncodeToString(encrypted),
+		int(hs.Cfg.TOTPLoginMaxLifetime.Seconds()), hs.CookieOptionsFromCfg)
+	return nil
+}
+
+// getTOTPLoginUser returns the user whose password was checked by the first login step.
+func (hs *HTTPServer) getTOTPLoginUser(c *models.ReqContext) (*models.User, error) {
+	value, ok := tryGetEncryptedCookie(c, TOTPLoginCookieName)
+	if !ok {
+		return nil, errTOTPLoginExpired
+	}
+
+	var userID, expires int64
+	if _, err := fmt.Sscanf(value, "%d:%d", &userID, &expires); err != nil {
+		return nil, errTOTPLoginExpired
+	}
+	if time.Now().Unix() > expires {
+		return nil, errTOTPLoginExpired
+	}
+
+	query := &models.GetUserByIdQuery{Id: userID}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+	if query.Result.IsDisabled {
+		return nil, login.ErrUserDisabled
+	}
+
+	return query.Result, nil
+}
+
+// POST /login/totp/setup
+func (hs *HTTPServer) LoginTOTPSetup(c *models.ReqContext) response.Response {
+	if !hs.TOTPService.IsEnabled() {
+		return response.Error(http.StatusNotFound, "Two-factor authentication not enabled", nil)
+	}
+
+	user, err := hs.getTOTPLoginUser(c)
+	if err != nil {
+		return response.Error(http.StatusUnauthorized, "Login expired, sign in again", err)
+	}
+
+	status, err := hs.TOTPService.GetStatus(user.Id)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication status", err)
+	}
+	if status.Enabled || !status.Required {
+		return response.Error(http.StatusBadRequest, "Two-factor authentication setup not required", nil)
+	}
+
+	setup, err := hs.TOTPService.StartSetup(user)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to set up two-factor authentication", err)
+	}
+
+	return response.JSON(http.StatusOK, setup)
+}
+
+// POST /login/totp
+func (hs *HTTPServer) LoginTOTPPost(c *models.ReqContext, cmd dtos.LoginTOTPCommand) response.Response {
+	var user *models.User
+	var resp *response.NormalResponse
+
+	defer func() {
+		err := resp.Err()
+		if err == nil && resp.ErrMessage() != "" {
+			err = errors.New(resp.ErrMessage())
+		}
+		loginInfo := &models.LoginInfo{
+			AuthModule: "grafana",
+			User:       user,
+			HTTPStatus: resp.Status(),
+			Error:      err,
+		}
+		if user != nil {
+			loginInfo.LoginUsername = user.Login
+		}
+		hs.HooksService.RunLoginHook(loginInfo, c)
+	}()
+
+	if !hs.TOTPService.IsEnabled() {
+		resp = response.Error(http.StatusNotFound, "Two-factor authentication not enabled", nil)
+		return resp
+	}
+
+	user, err := hs.getTOTPLoginUser(c)
+	if err != nil {
+		resp = response.Error(http.StatusUnauthorized, "Login expired, sign in again", err)
+		return resp
+	}
+
+	status, err := hs.TOTPService.GetStatus(user.Id)
+	if err != nil {
+		resp = response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+		return resp
+	}
+
+	var recoveryCodes []string
+	if status.Enabled {
+		err = bus.Dispatch(&models.TOTPLoginQuery{
+			ReqContext: c,
+			User:       user,
+			Code:       cmd.Code,
+			IpAddress:  c.Req.RemoteAddr,
+			Cfg:        hs.Cfg,
+		})
+	} else {
+		// the user confirms the setup required to sign in
+		recoveryCodes, err = hs.TOTPService.Enable(user.Id, cmd.Code)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTOTPCode) || errors.Is(err, login.ErrTooManyLoginAttempts):
+			resp = response.Error(http.StatusUnauthorized, "Invalid two-factor authentication code", err)
+		case errors.Is(err, totp.ErrSetupNotStarted) || errors.Is(err, models.ErrUserTOTPNotFound):
+			resp = response.Error(http.StatusBadRequest, "Two-factor authentication not set up", err)
+		default:
+			resp = response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+		}
+		return resp
+	}
+
+	cookies.DeleteCookie(c.Resp, TOTPLoginCookieName, hs.CookieOptionsFromCfg)
+
+	if err := hs.loginUserWithUser(user, c); err != nil {
+		var createTokenErr *models.CreateTokenErr
+		if errors.As(err, &createTokenErr) {
+			resp = response.Error(createTokenErr.StatusCode, createTokenErr.ExternalErr, createTokenErr.InternalErr)
+		} else {
+			resp = response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+		}
+		return resp
+	}
+
+	result := hs.loginSuccessResult(c)
+	if recoveryCodes != nil {
+		result["recoveryCodes"] = recoveryCodes
+	}
+
+	metrics.MApiLoginPost.Inc()
+	resp = response.JSON(http.StatusOK, result)
+	return resp
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/auth/totp"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/licensing"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginTOTP(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.TOTPEnabled = true
+	cfg.TOTPLoginMaxLifetime = 5 * time.Minute
+
+	tokenCreated := false
+	authTokenService := auth.NewFakeUserAuthTokenService()
+	authTokenService.CreateTokenProvider = func(ctx context.Context, user *models.User, clientIP net.IP, userAgent string) (*models.UserToken, error) {
+		tokenCreated = true
+		return &models.UserToken{UserId: user.Id, UnhashedToken: "session"}, nil
+	}
+
+	hs := &HTTPServer{
+		log:              log.New("test"),
+		Cfg:              cfg,
+		HooksService:     &hooks.HooksService{},
+		License:          &licensing.OSSLicensingService{},
+		AuthTokenService: authTokenService,
+		TOTPService:      &totp.Service{Cfg: cfg, Bus: bus.GetBus()},
+	}
+
+	sc := setupScenarioContext(t, "/login")
+	user := &models.User{Id: 42, Login: "alice"}
+	bus.AddHandler("grafana-auth", func(query *models.LoginUserQuery) error {
+		query.User = user
+		query.AuthModule = "grafana"
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserByIdQuery) error {
+		query.Result = user
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserTOTPQuery) error {
+		query.Result = &models.UserTOTP{UserId: query.UserId, Enabled: true}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.TOTPLoginQuery) error {
+		if query.User.Id != user.Id || query.Code != "123456" {
+			return models.ErrInvalidTOTPCode
+		}
+		return nil
+	})
+
+	sc.m.Post("/login", routing.Wrap(func(c *models.ReqContext) response.Response {
+		return hs.LoginPost(c, dtos.LoginCommand{User: "alice", Password: "pwd"})
+	}))
+	code := ""
+	sc.m.Post("/login/totp", routing.Wrap(func(c *models.ReqContext) response.Response {
+		return hs.LoginTOTPPost(c, dtos.LoginTOTPCommand{Code: code})
+	}))
+
+	sc.fakeReqNoAssertions("POST", "/login").exec()
+	require.Equal(t, http.StatusOK, sc.resp.Code)
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+	assert.Equal(t, true, result["totpRequired"])
+	assert.Equal(t, false, result["totpSetupRequired"])
+	require.False(t, tokenCreated, "no session before the second step")
+
+	var totpCookie *http.Cookie
+	for _, cookie := range sc.resp.Result().Cookies() {
+		if cookie.Name == TOTPLoginCookieName {
+			totpCookie = cookie
+		}
+	}
+	require.NotNil(t, totpCookie)
+
+	t.Run("Rejects the second step without the first one", func(t *testing.T) {
+		code = "123456"
+		sc.fakeReqNoAssertions("POST", "/login/totp").exec()
+		assert.Equal(t, http.StatusUnauthorized, sc.resp.Code)
+		assert.False(t, tokenCreated)
+	})
+
+	t.Run("Rejects an invalid code", func(t *testing.T) {
+		code = "654321"
+		sc.fakeReqNoAssertions("POST", "/login/totp")
+		sc.req.AddCookie(totpCookie)
+		sc.exec()
+		assert.Equal(t, http.StatusUnauthorized, sc.resp.Code)
+		assert.False(t, tokenCreated)
+	})
+
+	t.Run("Signs in with a valid code", func(t *testing.T) {
+		code = "123456"
+		sc.fakeReqNoAssertions("POST", "/login/totp")
+		sc.req.AddCookie(totpCookie)
+		sc.exec()
+		assert.Equal(t, http.StatusOK, sc.resp.Code)
+		assert.True(t, tokenCreated)
+	})
+}
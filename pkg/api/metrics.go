@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
@@ -76,7 +77,7 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDTO dtos.MetricReq
 
 	resp, err := hs.DataService.HandleRequest(c.Req.Context(), ds, request)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Metric request error", err)
+		return translateMetricRequestErrorToAPIError(err)
 	}
 
 	// This is insanity... but ¯\_(ツ)_/¯, the current query path looks like:
@@ -200,7 +201,7 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 
 	resp, err := hs.DataService.HandleRequest(c.Req.Context(), ds, request)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Metric request error", err)
+		return translateMetricRequestErrorToAPIError(err)
 	}
 
 	statusCode := http.StatusOK
@@ -253,3 +254,17 @@ func (hs *HTTPServer) GetTestDataRandomWalk(c *models.ReqContext) response.Respo
 
 	return response.JSON(200, &resp)
 }
+
+// translateMetricRequestErrorToAPIError translates the errors of data source queries, which backend
+// plugins can reject or time out.
+func translateMetricRequestErrorToAPIError(err error) response.Response {
+	if errors.Is(err, backendplugin.ErrPluginRequestLimitExceeded) {
+		return response.Error(http.StatusTooManyRequests, "Too many concurrent queries to data source", err)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return response.Error(http.StatusGatewayTimeout, "Metric request timed out", err)
+	}
+
+	return response.Error(http.StatusInternalServerError, "Metric request error", err)
+}
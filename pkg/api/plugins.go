@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -105,21 +106,22 @@ func (hs *HTTPServer) GetPluginSettingByID(c *models.ReqContext) response.Respon
 	}
 
 	dto := &dtos.PluginSetting{
-		Type:          def.Type,
-		Id:            def.Id,
-		Name:          def.Name,
-		Info:          &def.Info,
-		Dependencies:  &def.Dependencies,
-		Includes:      def.Includes,
-		BaseUrl:       def.BaseUrl,
-		Module:        def.Module,
-		DefaultNavUrl: def.DefaultNavUrl,
-		LatestVersion: def.GrafanaNetVersion,
-		HasUpdate:     def.GrafanaNetHasUpdate,
-		State:         def.State,
-		Signature:     def.Signature,
-		SignatureType: def.SignatureType,
-		SignatureOrg:  def.SignatureOrg,
+		Type:            def.Type,
+		Id:              def.Id,
+		Name:            def.Name,
+		Info:            &def.Info,
+		Dependencies:    &def.Dependencies,
+		Includes:        def.Includes,
+		BaseUrl:         def.BaseUrl,
+		Module:          def.Module,
+		ModuleIntegrity: def.ModuleIntegrity,
+		DefaultNavUrl:   def.DefaultNavUrl,
+		LatestVersion:   def.GrafanaNetVersion,
+		HasUpdate:       def.GrafanaNetHasUpdate,
+		State:           def.State,
+		Signature:       def.Signature,
+		SignatureType:   def.SignatureType,
+		SignatureOrg:    def.SignatureOrg,
 	}
 
 	if app := hs.PluginManager.GetApp(def.Id); app != nil {
@@ -315,5 +317,13 @@ func translatePluginRequestErrorToAPIError(err error) response.Response {
 		return response.Error(503, "Plugin unavailable", err)
 	}
 
+	if errors.Is(err, backendplugin.ErrPluginRequestLimitExceeded) {
+		return response.Error(429, "Too many requests to plugin", err)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return response.Error(504, "Plugin request timed out", err)
+	}
+
 	return response.Error(500, "Plugin request failed", err)
 }
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
@@ -66,3 +68,121 @@ func UpdateUserQuota(c *models.ReqContext, cmd models.UpdateUserQuotaCmd) respon
 	}
 	return response.Success("Organization quota updated")
 }
+
+func GetQuotaUsage(c *models.ReqContext) response.Response {
+	if !setting.Quota.Enabled {
+		return response.Error(404, "Quotas not enabled", nil)
+	}
+	orgID := c.ParamsInt64(":orgId")
+	if orgID == 0 {
+		orgID = c.OrgId
+	}
+	query := models.GetQuotaUsageQuery{OrgId: orgID}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to get quota usage", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+func GetFolderQuotas(c *models.ReqContext) response.Response {
+	if !setting.Quota.Enabled {
+		return response.Error(404, "Quotas not enabled", nil)
+	}
+	orgID, folderID := c.ParamsInt64(":orgId"), c.ParamsInt64(":folderId")
+	if rsp := checkQuotaFolderExists(orgID, folderID); rsp != nil {
+		return rsp
+	}
+	query := models.GetFolderQuotasQuery{OrgId: orgID, FolderId: folderID}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to get folder quotas", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+func UpdateFolderQuota(c *models.ReqContext, cmd models.UpdateFolderQuotaCmd) response.Response {
+	if !setting.Quota.Enabled {
+		return response.Error(404, "Quotas not enabled", nil)
+	}
+	cmd.OrgId = c.ParamsInt64(":orgId")
+	cmd.FolderId = c.ParamsInt64(":folderId")
+	cmd.Target = c.Params(":target")
+
+	if _, ok := setting.Quota.Folder.ToMap()[cmd.Target]; !ok {
+		return response.Error(404, "Invalid quota target", nil)
+	}
+	if rsp := checkQuotaFolderExists(cmd.OrgId, cmd.FolderId); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return response.Error(500, "Failed to update folder quotas", err)
+	}
+	return response.Success("Folder quota updated")
+}
+
+func GetTeamQuotas(c *models.ReqContext) response.Response {
+	if !setting.Quota.Enabled {
+		return response.Error(404, "Quotas not enabled", nil)
+	}
+	orgID, teamID := c.ParamsInt64(":orgId"), c.ParamsInt64(":teamId")
+	if rsp := checkQuotaTeamExists(orgID, teamID); rsp != nil {
+		return rsp
+	}
+	query := models.GetTeamQuotasQuery{OrgId: orgID, TeamId: teamID}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to get team quotas", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+func UpdateTeamQuota(c *models.ReqContext, cmd models.UpdateTeamQuotaCmd) response.Response {
+	if !setting.Quota.Enabled {
+		return response.Error(404, "Quotas not enabled", nil)
+	}
+	cmd.OrgId = c.ParamsInt64(":orgId")
+	cmd.TeamId = c.ParamsInt64(":teamId")
+	cmd.Target = c.Params(":target")
+
+	if _, ok := setting.Quota.Team.ToMap()[cmd.Target]; !ok {
+		return response.Error(404, "Invalid quota target", nil)
+	}
+	if rsp := checkQuotaTeamExists(cmd.OrgId, cmd.TeamId); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return response.Error(500, "Failed to update team quotas", err)
+	}
+	return response.Success("Team quota updated")
+}
+
+func checkQuotaFolderExists(orgID, folderID int64) response.Response {
+	query := models.GetDashboardQuery{OrgId: orgID, Id: folderID}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return response.Error(404, "Folder not found", err)
+		}
+		return response.Error(500, "Failed to get folder", err)
+	}
+	if !query.Result.IsFolder {
+		return response.Error(404, "Folder not found", nil)
+	}
+	return nil
+}
+
+func checkQuotaTeamExists(orgID, teamID int64) response.Response {
+	query := models.GetTeamByIdQuery{OrgId: orgID, Id: teamID}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return response.Error(404, "Team not found", err)
+		}
+		return response.Error(500, "Failed to get team", err)
+	}
+	return nil
+}
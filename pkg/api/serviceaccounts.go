@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GET /api/serviceaccounts
+func GetServiceAccounts(c *models.ReqContext) response.Response {
+	query := models.GetServiceAccountsQuery{OrgId: c.OrgId, Query: c.Query("query")}
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to list service accounts", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// GET /api/serviceaccounts/:serviceAccountId
+func GetServiceAccountByID(c *models.ReqContext) response.Response {
+	query := models.GetServiceAccountByIdQuery{OrgId: c.OrgId, Id: c.ParamsInt64(":serviceAccountId")}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrServiceAccountNotFound) {
+			return response.Error(404, "Service account not found", err)
+		}
+		return response.Error(500, "Failed to get service account", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// POST /api/serviceaccounts
+func CreateServiceAccount(c *models.ReqContext, cmd models.CreateServiceAccountCommand) response.Response {
+	if !cmd.Role.IsValid() {
+		return response.Error(400, "Invalid role specified", nil)
+	}
+	cmd.OrgId = c.OrgId
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrServiceAccountNameTaken) {
+			return response.Error(409, "Service account name taken", err)
+		}
+		return response.Error(500, "Failed to create service account", err)
+	}
+
+	return response.JSON(200, cmd.Result)
+}
+
+// PATCH /api/serviceaccounts/:serviceAccountId
+func UpdateServiceAccount(c *models.ReqContext, cmd models.UpdateServiceAccountCommand) response.Response {
+	if cmd.Role != "" && !cmd.Role.IsValid() {
+		return response.Error(400, "Invalid role specified", nil)
+	}
+	cmd.OrgId = c.OrgId
+	cmd.Id = c.ParamsInt64(":serviceAccountId")
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrServiceAccountNotFound) {
+			return response.Error(404, "Service account not found", err)
+		}
+		return response.Error(500, "Failed to update service account", err)
+	}
+
+	return response.Success("Service account updated")
+}
+
+// DELETE /api/serviceaccounts/:serviceAccountId
+func DeleteServiceAccount(c *models.ReqContext) response.Response {
+	cmd := models.DeleteServiceAccountCommand{OrgId: c.OrgId, Id: c.ParamsInt64(":serviceAccountId")}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrServiceAccountNotFound) {
+			return response.Error(404, "Service account not found", err)
+		}
+		return response.Error(500, "Failed to delete service account", err)
+	}
+
+	return response.Success("Service account deleted")
+}
+
+// GET /api/serviceaccounts/:serviceAccountId/tokens
+func GetServiceAccountTokens(c *models.ReqContext) response.Response {
+	query := models.GetServiceAccountTokensQuery{OrgId: c.OrgId, ServiceAccountId: c.ParamsInt64(":serviceAccountId")}
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to list service account tokens", err)
+	}
+
+	now := time.Now()
+	result := make([]*models.ServiceAccountTokenDTO, len(query.Result))
+	for i, t := range query.Result {
+		token := &models.ServiceAccountTokenDTO{
+			Id:      t.Id,
+			Name:    t.Name,
+			Created: t.Created,
+		}
+		if t.Expires != nil {
+			v := time.Unix(*t.Expires, 0)
+			token.Expiration = &v
+			token.HasExpired = !v.After(now)
+		}
+		result[i] = token
+	}
+
+	return response.JSON(200, result)
+}
+
+// POST /api/serviceaccounts/:serviceAccountId/tokens
+func (hs *HTTPServer) AddServiceAccountToken(c *models.ReqContext, cmd models.AddServiceAccountTokenCommand) response.Response {
+	if hs.Cfg.ApiKeyMaxSecondsToLive != -1 {
+		if cmd.SecondsToLive == 0 {
+			return response.Error(400, "Number of seconds before expiration should be set", nil)
+		}
+		if cmd.SecondsToLive > hs.Cfg.ApiKeyMaxSecondsToLive {
+			return response.Error(400, "Number of seconds before expiration is greater than the global limit", nil)
+		}
+	}
+	cmd.OrgId = c.OrgId
+	cmd.ServiceAccountId = c.ParamsInt64(":serviceAccountId")
+
+	newKeyInfo, err := apikeygen.New(cmd.OrgId, cmd.Name)
+	if err != nil {
+		return response.Error(500, "Generating service account token failed", err)
+	}
+
+	cmd.Key = newKeyInfo.HashedKey
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrServiceAccountNotFound) {
+			return response.Error(404, "Service account not found", err)
+		}
+		if errors.Is(err, models.ErrInvalidApiKeyExpiration) {
+			return response.Error(400, err.Error(), nil)
+		}
+		if errors.Is(err, models.ErrDuplicateApiKey) {
+			return response.Error(409, err.Error(), nil)
+		}
+		return response.Error(500, "Failed to add service account token", err)
+	}
+
+	result := &dtos.NewApiKeyResult{
+		ID:   cmd.Result.Id,
+		Name: cmd.Result.Name,
+		Key:  newKeyInfo.ClientSecret,
+	}
+
+	return response.JSON(200, result)
+}
+
+// DELETE /api/serviceaccounts/:serviceAccountId/tokens/:tokenId
+func DeleteServiceAccountToken(c *models.ReqContext) response.Response {
+	cmd := models.DeleteServiceAccountTokenCommand{
+		OrgId:            c.OrgId,
+		ServiceAccountId: c.ParamsInt64(":serviceAccountId"),
+		Id:               c.ParamsInt64(":tokenId"),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrServiceAccountTokenNotFound) {
+			return response.Error(404, "Service account token not found", err)
+		}
+		return response.Error(500, "Failed to delete service account token", err)
+	}
+
+	return response.Success("Service account token deleted")
+}
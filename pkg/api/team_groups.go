@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GET /api/teams/:teamId/groups
+func (hs *HTTPServer) GetTeamGroups(c *models.ReqContext) response.Response {
+	query := models.GetTeamGroupsQuery{OrgId: c.OrgId, TeamId: c.ParamsInt64(":teamId")}
+
+	if err := hs.Bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to get Team Groups", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// POST /api/teams/:teamId/groups
+func (hs *HTTPServer) AddTeamGroup(c *models.ReqContext, cmd models.AddTeamGroupCommand) response.Response {
+	cmd.OrgId = c.OrgId
+	cmd.TeamId = c.ParamsInt64(":teamId")
+
+	if err := hs.Bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return response.Error(404, "Team not found", nil)
+		}
+
+		if errors.Is(err, models.ErrTeamGroupAlreadyAdded) {
+			return response.Error(400, "Group is already added to this team", nil)
+		}
+
+		return response.Error(500, "Failed to add Group to Team", err)
+	}
+
+	return response.Success("Group added to Team")
+}
+
+// DELETE /api/teams/:teamId/groups/:groupId
+// DELETE /api/teams/:teamId/groups?groupId=
+func (hs *HTTPServer) RemoveTeamGroup(c *models.ReqContext) response.Response {
+	// group IDs such as GitHub team URLs contain slashes and can only be passed as query parameter
+	groupId := c.Query("groupId")
+	if groupId == "" {
+		groupId = c.Params(":groupId")
+	}
+	if groupId == "" {
+		return response.Error(400, "Group ID is required", nil)
+	}
+
+	cmd := models.DeleteTeamGroupCommand{OrgId: c.OrgId, TeamId: c.ParamsInt64(":teamId"), GroupId: groupId}
+
+	if err := hs.Bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return response.Error(404, "Team not found", nil)
+		}
+
+		if errors.Is(err, models.ErrTeamGroupNotFound) {
+			return response.Error(404, "Group not found", nil)
+		}
+
+		return response.Error(500, "Failed to remove Group from Team", err)
+	}
+
+	return response.Success("Team Group removed")
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamGroupsAPIEndpoint(t *testing.T) {
+	// the scenarios reset the bus, so the server must be created in each scenario
+	newHTTPServer := func() *HTTPServer {
+		return &HTTPServer{
+			Cfg: setting.NewCfg(),
+			Bus: bus.GetBus(),
+		}
+	}
+
+	loggedInUserScenarioWithRole(t, "When calling DELETE with group ID in path", "DELETE", "api/teams/1/groups/cn=editors",
+		"api/teams/:teamId/groups/:groupId", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			var cmd *models.DeleteTeamGroupCommand
+			bus.AddHandler("test", func(c *models.DeleteTeamGroupCommand) error {
+				cmd = c
+				return nil
+			})
+
+			sc.handlerFunc = newHTTPServer().RemoveTeamGroup
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, http.StatusOK, sc.resp.Code)
+			assert.Equal(t, int64(1), cmd.TeamId)
+			assert.Equal(t, "cn=editors", cmd.GroupId)
+		})
+
+	loggedInUserScenarioWithRole(t, "When calling DELETE with group ID in query", "DELETE", "api/teams/1/groups",
+		"api/teams/:teamId/groups", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			var cmd *models.DeleteTeamGroupCommand
+			bus.AddHandler("test", func(c *models.DeleteTeamGroupCommand) error {
+				cmd = c
+				return models.ErrTeamGroupNotFound
+			})
+
+			sc.handlerFunc = newHTTPServer().RemoveTeamGroup
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{"groupId": "@grafana/developers"}).exec()
+
+			require.Equal(t, http.StatusNotFound, sc.resp.Code)
+			assert.Equal(t, "@grafana/developers", cmd.GroupId)
+		})
+
+	loggedInUserScenarioWithRole(t, "When calling DELETE without group ID", "DELETE", "api/teams/1/groups",
+		"api/teams/:teamId/groups", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			sc.handlerFunc = newHTTPServer().RemoveTeamGroup
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, http.StatusBadRequest, sc.resp.Code)
+		})
+}
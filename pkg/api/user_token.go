@@ -23,6 +23,21 @@ func (hs *HTTPServer) RevokeUserAuthToken(c *models.ReqContext, cmd models.Revok
 	return hs.revokeUserAuthTokenInternal(c, c.UserId, cmd)
 }
 
+// POST /api/user/revoke-other-auth-tokens
+func (hs *HTTPServer) RevokeOtherUserAuthTokens(c *models.ReqContext) response.Response {
+	if c.UserToken == nil {
+		return response.Error(400, "Not signed in with a session", nil)
+	}
+
+	if err := hs.AuthTokenService.RevokeOtherUserTokens(c.Req.Context(), c.UserId, c.UserToken.Id); err != nil {
+		return response.Error(500, "Failed to revoke user auth tokens", err)
+	}
+
+	return response.JSON(200, util.DynMap{
+		"message": "User logged out from all other devices",
+	})
+}
+
 func (hs *HTTPServer) logoutUserFromAllDevicesInternal(ctx context.Context, userID int64) response.Response {
 	userQuery := models.GetUserByIdQuery{Id: userID}
 
@@ -102,6 +117,7 @@ func (hs *HTTPServer) getUserAuthTokensInternal(c *models.ReqContext, userID int
 			OperatingSystemVersion: osVersion,
 			Browser:                client.UserAgent.Family,
 			BrowserVersion:         browserVersion,
+			Location:               hs.GeoIPService.Location(token.ClientIp),
 			CreatedAt:              createdAt,
 			SeenAt:                 seenAt,
 		})
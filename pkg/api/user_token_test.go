@@ -106,6 +106,25 @@ func TestUserTokenAPIEndpoint(t *testing.T) {
 		})
 	})
 
+	t.Run("When current user revokes all other auth tokens", func(t *testing.T) {
+		revokeOtherUserAuthTokensScenario(t, "Should keep the active token", &models.UserToken{Id: 3}, func(sc *scenarioContext) {
+			var userID, keptTokenID int64
+			sc.userAuthTokenService.RevokeOtherUserTokensProvider = func(ctx context.Context, userId, keepTokenId int64) error {
+				userID, keptTokenID = userId, keepTokenId
+				return nil
+			}
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			assert.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, testUserID, userID)
+			assert.Equal(t, int64(3), keptTokenID)
+		})
+
+		revokeOtherUserAuthTokensScenario(t, "Should fail without a session", nil, func(sc *scenarioContext) {
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			assert.Equal(t, 400, sc.resp.Code)
+		})
+	})
+
 	t.Run("When gets auth tokens for a user", func(t *testing.T) {
 		currentToken := &models.UserToken{Id: 1}
 
@@ -198,6 +217,34 @@ func revokeUserAuthTokenScenario(t *testing.T, desc string, url string, routePat
 	})
 }
 
+func revokeOtherUserAuthTokensScenario(t *testing.T, desc string, token *models.UserToken, fn scenarioFunc) {
+	t.Run(desc, func(t *testing.T) {
+		t.Cleanup(bus.ClearBusHandlers)
+
+		fakeAuthTokenService := auth.NewFakeUserAuthTokenService()
+
+		hs := HTTPServer{
+			Bus:              bus.GetBus(),
+			AuthTokenService: fakeAuthTokenService,
+		}
+
+		sc := setupScenarioContext(t, "/api/user/revoke-other-auth-tokens")
+		sc.userAuthTokenService = fakeAuthTokenService
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			sc.context = c
+			sc.context.UserId = testUserID
+			sc.context.OrgId = testOrgID
+			sc.context.UserToken = token
+
+			return hs.RevokeOtherUserAuthTokens(c)
+		})
+
+		sc.m.Post("/api/user/revoke-other-auth-tokens", sc.defaultHandler)
+
+		fn(sc)
+	})
+}
+
 func getUserAuthTokensScenario(t *testing.T, desc string, url string, routePattern string, userId int64, fn scenarioFunc) {
 	t.Run(fmt.Sprintf("%s %s", desc, url), func(t *testing.T) {
 		t.Cleanup(bus.ClearBusHandlers)
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth/totp"
+)
+
+// GET /api/user/totp
+func (hs *HTTPServer) GetUserTOTPStatus(c *models.ReqContext) response.Response {
+	if !hs.TOTPService.IsEnabled() {
+		return response.Error(http.StatusNotFound, "Two-factor authentication not enabled", nil)
+	}
+
+	status, err := hs.TOTPService.GetStatus(c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication status", err)
+	}
+
+	return response.JSON(http.StatusOK, status)
+}
+
+// POST /api/user/totp/setup
+func (hs *HTTPServer) StartUserTOTPSetup(c *models.ReqContext) response.Response {
+	if !hs.TOTPService.IsEnabled() {
+		return response.Error(http.StatusNotFound, "Two-factor authentication not enabled", nil)
+	}
+
+	setup, err := hs.TOTPService.StartSetup(&models.User{Id: c.UserId, Login: c.Login})
+	if err != nil {
+		if errors.Is(err, totp.ErrAlreadyEnabled) {
+			return response.Error(http.StatusBadRequest, "Two-factor authentication already enabled", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to set up two-factor authentication", err)
+	}
+
+	return response.JSON(http.StatusOK, setup)
+}
+
+// POST /api/user/totp/enable
+func (hs *HTTPServer) EnableUserTOTP(c *models.ReqContext, cmd dtos.LoginTOTPCommand) response.Response {
+	if !hs.TOTPService.IsEnabled() {
+		return response.Error(http.StatusNotFound, "Two-factor authentication not enabled", nil)
+	}
+
+	recoveryCodes, err := hs.TOTPService.Enable(c.UserId, cmd.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTOTPCode):
+			return response.Error(http.StatusBadRequest, "Invalid two-factor authentication code", err)
+		case errors.Is(err, totp.ErrSetupNotStarted):
+			return response.Error(http.StatusBadRequest, "Two-factor authentication setup not started", err)
+		case errors.Is(err, totp.ErrAlreadyEnabled):
+			return response.Error(http.StatusBadRequest, "Two-factor authentication already enabled", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to enable two-factor authentication", err)
+	}
+
+	return response.JSON(http.StatusOK, map[string]interface{}{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+// POST /api/user/totp/disable
+func (hs *HTTPServer) DisableUserTOTP(c *models.ReqContext, cmd dtos.LoginTOTPCommand) response.Response {
+	if !hs.TOTPService.IsEnabled() {
+		return response.Error(http.StatusNotFound, "Two-factor authentication not enabled", nil)
+	}
+
+	status, err := hs.TOTPService.GetStatus(c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication status", err)
+	}
+	if status.Required {
+		return response.Error(http.StatusBadRequest, "Two-factor authentication is required and cannot be disabled", nil)
+	}
+
+	if errResp := hs.verifyUserTOTP(c, cmd.Code); errResp != nil {
+		return errResp
+	}
+
+	if err := hs.TOTPService.Disable(c.UserId); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to disable two-factor authentication", err)
+	}
+
+	return response.Success("Two-factor authentication disabled")
+}
+
+// POST /api/user/totp/recovery-codes
+func (hs *HTTPServer) RegenerateUserTOTPRecoveryCodes(c *models.ReqContext, cmd dtos.LoginTOTPCommand) response.Response {
+	if !hs.TOTPService.IsEnabled() {
+		return response.Error(http.StatusNotFound, "Two-factor authentication not enabled", nil)
+	}
+
+	if errResp := hs.verifyUserTOTP(c, cmd.Code); errResp != nil {
+		return errResp
+	}
+
+	recoveryCodes, err := hs.TOTPService.RegenerateRecoveryCodes(c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to create recovery codes", err)
+	}
+
+	return response.JSON(http.StatusOK, map[string]interface{}{
+		"message":       "Recovery codes created",
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+// verifyUserTOTP checks a code of the signed in user before changing the second factor, with the
+// same brute force protection as the login.
+func (hs *HTTPServer) verifyUserTOTP(c *models.ReqContext, code string) response.Response {
+	err := bus.Dispatch(&models.TOTPLoginQuery{
+		ReqContext: c,
+		User:       &models.User{Id: c.UserId, Login: c.Login},
+		Code:       code,
+		IpAddress:  c.Req.RemoteAddr,
+		Cfg:        hs.Cfg,
+	})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, models.ErrUserTOTPNotFound):
+		return response.Error(http.StatusBadRequest, "Two-factor authentication not set up", err)
+	case errors.Is(err, models.ErrInvalidTOTPCode) || errors.Is(err, login.ErrTooManyLoginAttempts):
+		return response.Error(http.StatusBadRequest, "Invalid two-factor authentication code", err)
+	default:
+		return response.Error(http.StatusInternalServerError, "Failed to verify two-factor authentication code", err)
+	}
+}
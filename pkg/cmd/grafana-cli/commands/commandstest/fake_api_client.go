@@ -7,30 +7,30 @@ import (
 )
 
 type FakeGrafanaComClient struct {
-	GetPluginFunc      func(pluginId, repoUrl string) (models.Plugin, error)
-	DownloadFileFunc   func(pluginName string, tmpFile *os.File, url string, checksum string) (err error)
-	ListAllPluginsFunc func(repoUrl string) (models.PluginRepo, error)
+	GetPluginFunc      func(pluginId string, repo models.Repository) (models.Plugin, error)
+	DownloadFileFunc   func(pluginName string, tmpFile *os.File, url string, checksum string, headers map[string]string) (err error)
+	ListAllPluginsFunc func(repo models.Repository) (models.PluginRepo, error)
 }
 
-func (client *FakeGrafanaComClient) GetPlugin(pluginID, repoUrl string) (models.Plugin, error) {
+func (client *FakeGrafanaComClient) GetPlugin(pluginID string, repo models.Repository) (models.Plugin, error) {
 	if client.GetPluginFunc != nil {
-		return client.GetPluginFunc(pluginID, repoUrl)
+		return client.GetPluginFunc(pluginID, repo)
 	}
 
 	return models.Plugin{}, nil
 }
 
-func (client *FakeGrafanaComClient) DownloadFile(pluginName string, tmpFile *os.File, url string, checksum string) (err error) {
+func (client *FakeGrafanaComClient) DownloadFile(pluginName string, tmpFile *os.File, url string, checksum string, headers map[string]string) (err error) {
 	if client.DownloadFileFunc != nil {
-		return client.DownloadFileFunc(pluginName, tmpFile, url, checksum)
+		return client.DownloadFileFunc(pluginName, tmpFile, url, checksum, headers)
 	}
 
 	return nil
 }
 
-func (client *FakeGrafanaComClient) ListAllPlugins(repoURL string) (models.PluginRepo, error) {
+func (client *FakeGrafanaComClient) ListAllPlugins(repo models.Repository) (models.PluginRepo, error) {
 	if client.ListAllPluginsFunc != nil {
-		return client.ListAllPluginsFunc(repoURL)
+		return client.ListAllPluginsFunc(repo)
 	}
 	return models.PluginRepo{}, nil
 }
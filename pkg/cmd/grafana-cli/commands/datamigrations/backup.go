@@ -0,0 +1,199 @@
+package datamigrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// Backup writes a consistent backup of the whole database to the archive file given as argument,
+// while Grafana keeps running. The archive doesn't depend on the database type, so it can be
+// restored to another database type.
+func Backup(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("usage: backup <archive file>")
+	}
+
+	// the archive holds the secure settings and the password hashes, like the database
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errutil.Wrap("failed to create archive file", err)
+	}
+
+	var header *BackupHeader
+	err = sqlStore.WithConsistentSnapshot(context.Background(), func(tx *sql.Tx) error {
+		header, err = backupDatabase(tx, sqlStore.Dialect, setting.SecretKey, f)
+		return err
+	})
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = errutil.Wrap("failed to write archive", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+
+	for _, table := range header.Tables {
+		logger.Infof("%s Backed up %d rows of table %s\n", color.GreenString("✔"), table.Rows, table.Name)
+	}
+	logger.Infof("%s Backed up %d tables and %d migrations to %s\n", color.GreenString("✔"), len(header.Tables),
+		len(header.Migrations), path)
+	return nil
+}
+
+// backupDatabase writes the backup archive of the database seen by tx to w.
+func backupDatabase(tx *sql.Tx, dialect migrator.Dialect, secretKey string, w io.Writer) (*BackupHeader, error) {
+	tables, err := queryStrings(tx, dialect.TableNamesSQL())
+	if err != nil {
+		return nil, errutil.Wrap("failed to list the tables", err)
+	}
+	sort.Strings(tables)
+
+	header := &BackupHeader{
+		Version:              BackupVersion,
+		Created:              time.Now().UTC(),
+		GrafanaVersion:       setting.BuildVersion,
+		DatabaseType:         dialect.DriverName(),
+		SecretKeyFingerprint: secretKeyFingerprint(secretKey),
+		Migrations:           []string{},
+		Tables:               make([]TableInfo, 0, len(tables)),
+	}
+	if contains(tables, "migration_log") {
+		header.Migrations, err = queryStrings(tx, "SELECT migration_id FROM migration_log WHERE success = "+
+			dialect.BooleanStr(true)+" ORDER BY id")
+		if err != nil {
+			return nil, errutil.Wrap("failed to read the migration log", err)
+		}
+	}
+	for _, table := range tables {
+		var count int64
+		if err := tx.QueryRow("SELECT COUNT(*) FROM " + dialect.Quote(table)).Scan(&count); err != nil {
+			return nil, errutil.Wrapf(err, "failed to count the rows of table %q", table)
+		}
+		header.Tables = append(header.Tables, TableInfo{Name: table, Rows: count})
+	}
+
+	bw := newBackupWriter(w)
+	if err := bw.write(header); err != nil {
+		return nil, errutil.Wrap("failed to write archive", err)
+	}
+	for _, table := range header.Tables {
+		if err := backupTable(tx, dialect, table, bw); err != nil {
+			return nil, errutil.Wrapf(err, "failed to back up table %q", table.Name)
+		}
+	}
+	if err := bw.Close(); err != nil {
+		return nil, errutil.Wrap("failed to write archive", err)
+	}
+
+	return header, nil
+}
+
+func backupTable(tx *sql.Tx, dialect migrator.Dialect, table TableInfo, bw *backupWriter) error {
+	emptyRows, err := tx.Query(fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", dialect.Quote(table.Name)))
+	if err != nil {
+		return err
+	}
+	columns, columnTypes, err := readColumns(emptyRows)
+	if err != nil {
+		return err
+	}
+
+	backupColumns := make([]BackupColumn, 0, len(columns))
+	for i, column := range columns {
+		backupColumns = append(backupColumns, BackupColumn{Name: column, Binary: isBinaryType(columnTypes[i])})
+	}
+	if err := bw.write(BackupTable{Name: table.Name, Columns: backupColumns, Rows: table.Rows}); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT * FROM " + dialect.Quote(table.Name))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var count int64
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		row := make([]interface{}, len(values))
+		for i, value := range values {
+			row[i] = backupValue(value, columnTypes[i])
+		}
+		if err := bw.write(row); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// the snapshot doesn't change, but a different count would make the archive unreadable
+	if count != table.Rows {
+		return fmt.Errorf("read %d rows instead of %d", count, table.Rows)
+	}
+	return nil
+}
+
+// backupValue converts a value read from the database to its archived value. The binary values
+// are encoded to base64 by the JSON encoder, the other bytes are text.
+func backupValue(value interface{}, columnType string) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		if isBinaryType(columnType) {
+			return v
+		}
+		return string(v)
+	case string:
+		if isBinaryType(columnType) {
+			return []byte(v)
+		}
+	case time.Time:
+		// the times are archived as they are stored, without time zone conversion
+		if baseType(columnType) == "DATE" {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05")
+	}
+	return value
+}
+
+func queryStrings(tx *sql.Tx, query string) ([]string, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,117 @@
+package datamigrations
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// BackupVersion is the current version of the backup archive format.
+//
+// A backup archive is a gzip compressed stream of JSON values: a BackupHeader, then for every table
+// a BackupTable followed by its rows. A row is an array of the column values, where the values of
+// binary columns are base64 encoded and the times are formatted without time zone, as stored.
+const BackupVersion = 1
+
+// ErrUnsupportedBackupVersion occurs when the archive was written with an unknown format version.
+var ErrUnsupportedBackupVersion = errors.New("unsupported backup archive version")
+
+// BackupHeader describes the database of a backup.
+type BackupHeader struct {
+	Version        int       `json:"version"`
+	Created        time.Time `json:"created"`
+	GrafanaVersion string    `json:"grafanaVersion"`
+	DatabaseType   string    `json:"databaseType"`
+	// SecretKeyFingerprint identifies the secret key the secure settings are encrypted with.
+	SecretKeyFingerprint string `json:"secretKeyFingerprint"`
+	// Migrations are the IDs of the executed migrations, which define the schema of the database.
+	Migrations []string    `json:"migrations"`
+	Tables     []TableInfo `json:"tables"`
+}
+
+// TableInfo is the name and the number of rows of a backed up table.
+type TableInfo struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+// BackupTable precedes the rows of a table.
+type BackupTable struct {
+	Name    string         `json:"name"`
+	Columns []BackupColumn `json:"columns"`
+	Rows    int64          `json:"rows"`
+}
+
+type BackupColumn struct {
+	Name   string `json:"name"`
+	Binary bool   `json:"binary,omitempty"`
+}
+
+// secretKeyFingerprint returns the fingerprint of a secret key, which doesn't disclose the key.
+func secretKeyFingerprint(secretKey string) string {
+	sum := sha256.Sum256([]byte("grafana backup secret key:" + secretKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// backupWriter writes a compressed backup archive.
+type backupWriter struct {
+	zw  *gzip.Writer
+	enc *json.Encoder
+}
+
+func newBackupWriter(w io.Writer) *backupWriter {
+	zw := gzip.NewWriter(w)
+	return &backupWriter{zw: zw, enc: json.NewEncoder(zw)}
+}
+
+func (w *backupWriter) write(v interface{}) error {
+	return w.enc.Encode(v)
+}
+
+func (w *backupWriter) Close() error {
+	return w.zw.Close()
+}
+
+// backupReader reads a compressed backup archive.
+type backupReader struct {
+	zr  *gzip.Reader
+	dec *json.Decoder
+}
+
+// newBackupReader reads the header of a compressed backup archive from r.
+func newBackupReader(r io.Reader) (*backupReader, *BackupHeader, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	dec := json.NewDecoder(zr)
+	// the numbers are kept as they are written, since float64 can't hold every int64
+	dec.UseNumber()
+
+	var header BackupHeader
+	if err := dec.Decode(&header); err != nil {
+		_ = zr.Close()
+		return nil, nil, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	if header.Version != BackupVersion {
+		_ = zr.Close()
+		return nil, nil, ErrUnsupportedBackupVersion
+	}
+	return &backupReader{zr: zr, dec: dec}, &header, nil
+}
+
+func (r *backupReader) read(v interface{}) error {
+	if err := r.dec.Decode(v); err != nil {
+		return fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	return nil
+}
+
+func (r *backupReader) Close() error {
+	return r.zr.Close()
+}
@@ -0,0 +1,148 @@
+package datamigrations
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+
+	user, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{
+		Login:   "admin",
+		Email:   "admin@example.org",
+		IsAdmin: true,
+	})
+	require.NoError(t, err)
+	dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:  user.OrgId,
+		UserId: user.Id,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "backed up",
+		}),
+	})
+	require.NoError(t, err)
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	err = sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(&models.DashboardSnapshot{
+			Name:               "snapshot",
+			Key:                "key",
+			DeleteKey:          "delete-key",
+			OrgId:              user.OrgId,
+			UserId:             user.Id,
+			DashboardEncrypted: []byte{0, 1, 2, 255},
+			Created:            created,
+			Updated:            created,
+			Expires:            created,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	var header *BackupHeader
+	err = sqlStore.WithConsistentSnapshot(context.Background(), func(tx *sql.Tx) error {
+		header, err = backupDatabase(tx, sqlStore.Dialect, "secret", &archive)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, secretKeyFingerprint("secret"), header.SecretKeyFingerprint)
+	assert.Contains(t, header.Migrations, "create migration_log table")
+	assert.Contains(t, header.Tables, TableInfo{Name: "dashboard", Rows: 1})
+
+	target := newTestTargetStore(t)
+
+	t.Run("refuses to restore with another secret key", func(t *testing.T) {
+		_, err := restoreDatabase(target, "other secret", bytes.NewReader(archive.Bytes()))
+		require.ErrorIs(t, err, errSecretKeyMismatch)
+	})
+
+	result, err := restoreDatabase(target, "secret", bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Contains(t, result.Tables, "migration_log")
+	assert.Empty(t, result.SkippedTables)
+	assert.Equal(t, int64(1), result.RowCounts["dashboard"])
+
+	err = target.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		var restoredUser models.User
+		has, err := sess.ID(user.Id).Get(&restoredUser)
+		require.NoError(t, err)
+		require.True(t, has)
+		assert.Equal(t, "admin", restoredUser.Login)
+		assert.True(t, restoredUser.IsAdmin)
+
+		var restoredDash models.Dashboard
+		has, err = sess.ID(dash.Id).Get(&restoredDash)
+		require.NoError(t, err)
+		require.True(t, has)
+		assert.Equal(t, dash.Uid, restoredDash.Uid)
+		assert.Equal(t, "backed up", restoredDash.Data.Get("title").MustString())
+
+		var snapshot models.DashboardSnapshot
+		has, err = sess.Where("key=?", "key").Get(&snapshot)
+		require.NoError(t, err)
+		require.True(t, has)
+		assert.Equal(t, []byte{0, 1, 2, 255}, []byte(snapshot.DashboardEncrypted))
+		assert.Equal(t, created.Format(time.RFC3339), snapshot.Created.UTC().Format(time.RFC3339))
+
+		var migrationCount int64
+		_, err = sess.SQL("SELECT COUNT(*) FROM migration_log").Get(&migrationCount)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(header.Migrations)), migrationCount)
+		return nil
+	})
+	require.NoError(t, err)
+
+	t.Run("refuses to restore to a non-empty database", func(t *testing.T) {
+		_, err := restoreDatabase(target, "secret", bytes.NewReader(archive.Bytes()))
+		require.ErrorIs(t, err, errTargetNotEmpty)
+	})
+}
+
+func TestRestoreTableWithoutColumns(t *testing.T) {
+	target := newTestTargetStore(t)
+
+	err := target.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		return restoreTable(sess, target, nil, BackupTable{Name: "dashboard", Rows: 1})
+	})
+	require.EqualError(t, err, `failed to read backup archive: table "dashboard" has no columns`)
+}
+
+func TestCompareMigrations(t *testing.T) {
+	header := &BackupHeader{GrafanaVersion: "7.5.0", Migrations: []string{"a", "b"}}
+
+	require.NoError(t, compareMigrations(header, []string{"b", "a"}))
+	require.EqualError(t, compareMigrations(header, []string{"a"}), `the backup was created by Grafana 7.5.0 `+
+		`with the unknown migration "b": restore it with the Grafana version which created it`)
+	require.EqualError(t, compareMigrations(header, []string{"a", "b", "c"}), `the backup was created by Grafana 7.5.0 `+
+		`without the migration "c": restore it with the Grafana version which created it, then upgrade`)
+}
+
+// newTestTargetStore returns a SQLStore connected to an empty SQLite database.
+func newTestTargetStore(t *testing.T) *sqlstore.SQLStore {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	sec, err := cfg.Raw.NewSection("database")
+	require.NoError(t, err)
+	_, err = sec.NewKey("type", "sqlite3")
+	require.NoError(t, err)
+	_, err = sec.NewKey("path", filepath.Join(t.TempDir(), "target.db"))
+	require.NoError(t, err)
+
+	target := &sqlstore.SQLStore{Cfg: cfg}
+	require.NoError(t, target.Connect())
+	return target
+}
@@ -0,0 +1,414 @@
+package datamigrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// maxCopyParams is the maximum number of parameters of an insert statement. It is the lowest limit
+// of the supported databases, the one of older SQLite versions.
+const maxCopyParams = 999
+
+var errTargetNotEmpty = errors.New("the target database is not empty, it must not contain any table")
+
+// CopyDatabase copies the Grafana database to the empty database given by the --to flag, e.g. to
+// move from SQLite to Postgres or MySQL. Grafana should be stopped during the copy.
+func CopyDatabase(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	to := c.String("to")
+	if to == "" {
+		return fmt.Errorf("usage: copy-database --to <database url>")
+	}
+
+	target, err := sqlStore.NewEngineForURL(to)
+	if err != nil {
+		return errutil.Wrap("failed to connect to the target database", err)
+	}
+	defer func() { _ = target.Close() }()
+
+	var result *copyResult
+	if err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		result, err = copyDatabase(sess, sqlStore.Dialect, target)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	for _, table := range result.SkippedTables {
+		logger.Warnf("Table %q does not exist in the target database and was not copied\n", table)
+	}
+	for _, table := range result.Tables {
+		logger.Infof("%s Copied %d rows of table %s\n", color.GreenString("✔"), result.RowCounts[table], table)
+	}
+	logger.Infof("%s Copied %d tables to the target database\n", color.GreenString("✔"), len(result.Tables))
+	return nil
+}
+
+type copyResult struct {
+	Tables    []string
+	RowCounts map[string]int64
+	// SkippedTables are the tables of the source database not created by the migrations, such
+	// as the tables of services which aren't enabled.
+	SkippedTables []string
+}
+
+// copyDatabase creates the schema of the target database with the migrations, and copies the rows
+// of every table of the source database to it.
+func copyDatabase(source *sqlstore.DBSession, sourceDialect migrator.Dialect, target *xorm.Engine) (*copyResult, error) {
+	targetDialect := migrator.NewDialect(target)
+
+	targetTables, err := tableNames(target.SQL(targetDialect.TableNamesSQL()))
+	if err != nil {
+		return nil, errutil.Wrap("failed to list the tables of the target database", err)
+	}
+	if len(targetTables) > 0 {
+		return nil, errTargetNotEmpty
+	}
+
+	if err := sqlstore.MigrateEngine(target); err != nil {
+		return nil, errutil.Wrap("failed to migrate the target database", err)
+	}
+	if targetTables, err = tableNames(target.SQL(targetDialect.TableNamesSQL())); err != nil {
+		return nil, errutil.Wrap("failed to list the tables of the target database", err)
+	}
+	sourceTables, err := tableNames(source.SQL(sourceDialect.TableNamesSQL()))
+	if err != nil {
+		return nil, errutil.Wrap("failed to list the tables of the source database", err)
+	}
+
+	result := &copyResult{
+		Tables:        []string{},
+		RowCounts:     map[string]int64{},
+		SkippedTables: []string{},
+	}
+	for _, table := range sourceTables {
+		// the target database has its own log of the migrations run above
+		if table == "migration_log" {
+			continue
+		}
+		if !contains(targetTables, table) {
+			result.SkippedTables = append(result.SkippedTables, table)
+			continue
+		}
+
+		c := &tableCopier{
+			source:        source,
+			sourceDialect: sourceDialect,
+			target:        target,
+			targetDialect: targetDialect,
+			table:         table,
+		}
+		count, err := c.copy()
+		if err != nil {
+			return nil, errutil.Wrapf(err, "failed to copy table %q", table)
+		}
+		result.Tables = append(result.Tables, table)
+		result.RowCounts[table] = count
+	}
+
+	return result, nil
+}
+
+// tableNames returns the sorted table names listed by a query of Dialect.TableNamesSQL.
+func tableNames(query *xorm.Session) ([]string, error) {
+	var names []string
+	if err := query.Find(&names); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// tableCopier streams the rows of a table from the source to the target database, converting the
+// values to the column types of the target database.
+type tableCopier struct {
+	source        *sqlstore.DBSession
+	sourceDialect migrator.Dialect
+	target        *xorm.Engine
+	targetDialect migrator.Dialect
+	table         string
+
+	columns     []string
+	columnTypes []string
+}
+
+func (c *tableCopier) copy() (int64, error) {
+	if err := c.readColumns(); err != nil {
+		return 0, err
+	}
+
+	sess := c.target.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return 0, err
+	}
+
+	// the migrations may have created default rows, e.g. the default dashboard permissions
+	if _, err := sess.Exec("DELETE FROM " + c.targetDialect.Quote(c.table)); err != nil {
+		return 0, err
+	}
+	if err := c.copyRows(sess); err != nil {
+		_ = sess.Rollback()
+		return 0, err
+	}
+	if err := resetSequence(sess, c.targetDialect, c.table, c.columns); err != nil {
+		_ = sess.Rollback()
+		return 0, err
+	}
+	if err := sess.Commit(); err != nil {
+		return 0, err
+	}
+
+	return c.verifyRowCount()
+}
+
+// readColumns reads the columns of the table. Every column of the source table must exist in the
+// target table, otherwise data would be lost.
+func (c *tableCopier) readColumns() error {
+	emptyQuery := "SELECT * FROM %s WHERE 1 = 0"
+
+	sourceRows, err := c.source.DB().Query(fmt.Sprintf(emptyQuery, c.sourceDialect.Quote(c.table)))
+	if err != nil {
+		return err
+	}
+	sourceColumns, _, err := readColumns(sourceRows.Rows)
+	if err != nil {
+		return err
+	}
+
+	targetRows, err := c.target.DB().Query(fmt.Sprintf(emptyQuery, c.targetDialect.Quote(c.table)))
+	if err != nil {
+		return err
+	}
+	targetColumns, targetColumnTypes, err := readColumns(targetRows.Rows)
+	if err != nil {
+		return err
+	}
+
+	c.columns = sourceColumns
+	c.columnTypes, err = matchColumnTypes(sourceColumns, targetColumns, targetColumnTypes)
+	return err
+}
+
+// readColumns returns the names and the database type names of the columns of rows, and closes them.
+func readColumns(rows *sql.Rows) ([]string, []string, error) {
+	defer func() { _ = rows.Close() }()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(columnTypes))
+	types := make([]string, 0, len(columnTypes))
+	for _, columnType := range columnTypes {
+		names = append(names, columnType.Name())
+		types = append(types, columnType.DatabaseTypeName())
+	}
+	return names, types, nil
+}
+
+// matchColumnTypes returns the types of the target columns with the names of the source columns.
+// Every source column must exist in the target table, otherwise data would be lost.
+func matchColumnTypes(sourceColumns, targetColumns, targetColumnTypes []string) ([]string, error) {
+	typesByName := make(map[string]string, len(targetColumns))
+	for i, column := range targetColumns {
+		typesByName[column] = targetColumnTypes[i]
+	}
+
+	types := make([]string, 0, len(sourceColumns))
+	for _, column := range sourceColumns {
+		columnType, ok := typesByName[column]
+		if !ok {
+			return nil, fmt.Errorf("column %q does not exist in the target database", column)
+		}
+		types = append(types, columnType)
+	}
+	return types, nil
+}
+
+func (c *tableCopier) copyRows(sess *xorm.Session) error {
+	quotedColumns := make([]string, 0, len(c.columns))
+	for _, column := range c.columns {
+		quotedColumns = append(quotedColumns, c.sourceDialect.Quote(column))
+	}
+	rows, err := c.source.DB().Query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(quotedColumns, ", "),
+		c.sourceDialect.Quote(c.table)))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	batchSize := maxCopyParams / len(c.columns)
+	batch := make([]interface{}, 0, batchSize*len(c.columns))
+	for rows.Next() {
+		values := make([]interface{}, len(c.columns))
+		pointers := make([]interface{}, len(c.columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		for i, value := range values {
+			converted, err := convertValue(value, c.columnTypes[i])
+			if err != nil {
+				return errutil.Wrapf(err, "failed to convert column %q", c.columns[i])
+			}
+			batch = append(batch, converted)
+		}
+
+		if len(batch) == batchSize*len(c.columns) {
+			if err := insertRows(sess, c.targetDialect, c.table, c.columns, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		return insertRows(sess, c.targetDialect, c.table, c.columns, batch)
+	}
+	return nil
+}
+
+// insertRows inserts the rows of which values holds the column values one after the other.
+func insertRows(sess *xorm.Session, dialect migrator.Dialect, table string, columns []string, values []interface{}) error {
+	quotedColumns := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, dialect.Quote(column))
+	}
+	rowPlaceholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	rowCount := len(values) / len(columns)
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO " + dialect.Quote(table) + " (" + strings.Join(quotedColumns, ", ") + ") VALUES ")
+	for i := 0; i < rowCount; i++ {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString(rowPlaceholders)
+	}
+
+	_, err := sess.Exec(append([]interface{}{query.String()}, values...)...)
+	return err
+}
+
+// resetSequence resets the sequence of the id column after rows were inserted with their IDs.
+func resetSequence(sess *xorm.Session, dialect migrator.Dialect, table string, columns []string) error {
+	if !contains(columns, "id") {
+		return nil
+	}
+	if resetSQL := dialect.ResetSequenceSQL(table, "id"); resetSQL != "" {
+		if _, err := sess.Exec(resetSQL); err != nil {
+			return errutil.Wrap("failed to reset the sequence", err)
+		}
+	}
+	return nil
+}
+
+func (c *tableCopier) verifyRowCount() (int64, error) {
+	countQuery := "SELECT COUNT(*) FROM %s"
+
+	var sourceCount, targetCount int64
+	if _, err := c.source.SQL(fmt.Sprintf(countQuery, c.sourceDialect.Quote(c.table))).Get(&sourceCount); err != nil {
+		return 0, err
+	}
+	if _, err := c.target.SQL(fmt.Sprintf(countQuery, c.targetDialect.Quote(c.table))).Get(&targetCount); err != nil {
+		return 0, err
+	}
+	if sourceCount != targetCount {
+		return 0, fmt.Errorf("the target table has %d rows instead of %d", targetCount, sourceCount)
+	}
+
+	return targetCount, nil
+}
+
+// convertValue converts a value read from the source database to the type of the target column,
+// given by its database type name, like BOOL or VARCHAR(190).
+func convertValue(value interface{}, columnType string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	columnType = baseType(columnType)
+	if isBinaryType(columnType) {
+		if v, ok := value.(string); ok {
+			return []byte(v), nil
+		}
+		return value, nil
+	}
+
+	switch columnType {
+	case "BOOL", "BOOLEAN":
+		// SQLite and MySQL store booleans as integers
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case []byte:
+			return strconv.ParseBool(string(v))
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case "DATE":
+		if v, ok := value.(time.Time); ok {
+			return v.Format("2006-01-02"), nil
+		}
+	case "DATETIME", "TIMESTAMP", "TIMESTAMPTZ":
+		// the times are copied as they are stored, without time zone conversion
+		if v, ok := value.(time.Time); ok {
+			return v.Format("2006-01-02 15:04:05"), nil
+		}
+	}
+
+	// the other values are sent as text, since Postgres would store bytes in a text column escaped
+	if v, ok := value.([]byte); ok {
+		return string(v), nil
+	}
+	return value, nil
+}
+
+// baseType returns the upper case database type name without its size, like VARCHAR for varchar(190).
+func baseType(columnType string) string {
+	columnType = strings.ToUpper(columnType)
+	if i := strings.Index(columnType, "("); i >= 0 {
+		columnType = columnType[:i]
+	}
+	return columnType
+}
+
+func isBinaryType(columnType string) bool {
+	switch baseType(columnType) {
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY":
+		return true
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package datamigrations
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestCopyDatabase(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+
+	user, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{
+		Login:   "admin",
+		Email:   "admin@example.org",
+		IsAdmin: true,
+	})
+	require.NoError(t, err)
+	dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:  user.OrgId,
+		UserId: user.Id,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "copied",
+		}),
+	})
+	require.NoError(t, err)
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	err = sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(&models.DashboardSnapshot{
+			Name:               "snapshot",
+			Key:                "key",
+			DeleteKey:          "delete-key",
+			OrgId:              user.OrgId,
+			UserId:             user.Id,
+			DashboardEncrypted: []byte{0, 1, 2, 255},
+			Created:            created,
+			Updated:            created,
+			Expires:            created,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	target, err := xorm.NewEngine("sqlite3", "file:"+filepath.Join(t.TempDir(), "target.db")+"?cache=private&mode=rwc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = target.Close() })
+
+	var result *copyResult
+	err = sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		result, err = copyDatabase(sess, sqlStore.Dialect, target)
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, result.Tables, "user")
+	assert.NotContains(t, result.Tables, "migration_log")
+	assert.Empty(t, result.SkippedTables)
+	assert.Equal(t, int64(1), result.RowCounts["dashboard"])
+	assert.Equal(t, int64(2), result.RowCounts["dashboard_acl"], "default permissions")
+
+	var copiedUser models.User
+	has, err := target.ID(user.Id).Get(&copiedUser)
+	require.NoError(t, err)
+	require.True(t, has)
+	assert.Equal(t, "admin", copiedUser.Login)
+	assert.True(t, copiedUser.IsAdmin)
+
+	var copiedDash models.Dashboard
+	has, err = target.ID(dash.Id).Get(&copiedDash)
+	require.NoError(t, err)
+	require.True(t, has)
+	assert.Equal(t, dash.Uid, copiedDash.Uid)
+	assert.Equal(t, "copied", copiedDash.Data.Get("title").MustString())
+
+	var snapshot models.DashboardSnapshot
+	has, err = target.Where("key=?", "key").Get(&snapshot)
+	require.NoError(t, err)
+	require.True(t, has)
+	assert.Equal(t, []byte{0, 1, 2, 255}, []byte(snapshot.DashboardEncrypted))
+	assert.Equal(t, created.Format(time.RFC3339), snapshot.Created.UTC().Format(time.RFC3339))
+
+	t.Run("refuses to copy to a non-empty database", func(t *testing.T) {
+		err = sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			_, err := copyDatabase(sess, sqlStore.Dialect, target)
+			return err
+		})
+		require.ErrorIs(t, err, errTargetNotEmpty)
+	})
+}
+
+func TestConvertValue(t *testing.T) {
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		value      interface{}
+		columnType string
+		expected   interface{}
+	}{
+		{value: nil, columnType: "BOOL", expected: nil},
+		{value: int64(1), columnType: "BOOL", expected: true},
+		{value: int64(0), columnType: "BOOL", expected: false},
+		{value: []byte("1"), columnType: "BOOL", expected: true},
+		{value: true, columnType: "TINYINT", expected: true},
+		{value: "blob", columnType: "BYTEA", expected: []byte("blob")},
+		{value: []byte{0, 255}, columnType: "MEDIUMBLOB", expected: []byte{0, 255}},
+		{value: created, columnType: "TIMESTAMP", expected: "2021-03-04 05:06:07"},
+		{value: created, columnType: "DATETIME", expected: "2021-03-04 05:06:07"},
+		{value: created, columnType: "DATE", expected: "2021-03-04"},
+		{value: []byte("2021-03-04 05:06:07"), columnType: "DATETIME", expected: "2021-03-04 05:06:07"},
+		{value: []byte("text"), columnType: "VARCHAR(190)", expected: "text"},
+		{value: int64(3), columnType: "INT8", expected: int64(3)},
+	}
+	for _, tc := range tests {
+		converted, err := convertValue(tc.value, tc.columnType)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, converted, "%v to %s", tc.value, tc.columnType)
+	}
+}
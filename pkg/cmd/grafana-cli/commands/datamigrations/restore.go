@@ -0,0 +1,282 @@
+package datamigrations
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var errSecretKeyMismatch = errors.New("the backup was created with another secret key, the secure settings " +
+	"couldn't be decrypted: set the secret_key of the security section to the one of the backed up instance")
+
+// Restore loads the backup archive given as argument into the configured database, which must be
+// empty. The archive must have been created with the secret key of this instance, and by a Grafana
+// version with the same database migrations. Grafana should be stopped during the restore.
+func Restore(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("usage: restore <archive file>")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return errutil.Wrap("failed to open archive file", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	result, err := restoreDatabase(sqlStore, setting.SecretKey, f)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range result.SkippedTables {
+		logger.Warnf("Table %q does not exist in the database and was not restored\n", table)
+	}
+	for _, table := range result.Tables {
+		logger.Infof("%s Restored %d rows of table %s\n", color.GreenString("✔"), result.RowCounts[table], table)
+	}
+	logger.Infof("%s Restored %d tables of the backup created on %s by Grafana %s\n", color.GreenString("✔"),
+		len(result.Tables), result.Header.Created.Format("2006-01-02 15:04:05"), result.Header.GrafanaVersion)
+	return nil
+}
+
+type restoreResult struct {
+	Header    *BackupHeader
+	Tables    []string
+	RowCounts map[string]int64
+	// SkippedTables are the tables of the backup not created by the migrations, such as the tables
+	// of services which aren't enabled.
+	SkippedTables []string
+}
+
+// restoreDatabase validates the backup archive read from r, creates the schema of the empty
+// database with the migrations and loads the rows of the archive.
+func restoreDatabase(sqlStore *sqlstore.SQLStore, secretKey string, r io.Reader) (*restoreResult, error) {
+	br, header, err := newBackupReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = br.Close() }()
+
+	if header.SecretKeyFingerprint != secretKeyFingerprint(secretKey) {
+		return nil, errSecretKeyMismatch
+	}
+
+	mg := sqlStore.Migrator()
+	statuses, err := mg.Status()
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		migrations = append(migrations, status.ID)
+	}
+	if err := compareMigrations(header, migrations); err != nil {
+		return nil, err
+	}
+
+	var tables []string
+	if err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		tables, err = tableNames(sess.SQL(sqlStore.Dialect.TableNamesSQL()))
+		return err
+	}); err != nil {
+		return nil, errutil.Wrap("failed to list the tables of the database", err)
+	}
+	if len(tables) > 0 {
+		return nil, errTargetNotEmpty
+	}
+
+	if err := mg.Start(); err != nil {
+		return nil, errutil.Wrap("failed to migrate the database", err)
+	}
+	if err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		tables, err = tableNames(sess.SQL(sqlStore.Dialect.TableNamesSQL()))
+		return err
+	}); err != nil {
+		return nil, errutil.Wrap("failed to list the tables of the database", err)
+	}
+
+	result := &restoreResult{
+		Header:        header,
+		Tables:        []string{},
+		RowCounts:     map[string]int64{},
+		SkippedTables: []string{},
+	}
+	for _, info := range header.Tables {
+		var table BackupTable
+		if err := br.read(&table); err != nil {
+			return nil, err
+		}
+		if table.Name != info.Name {
+			return nil, fmt.Errorf("failed to read backup archive: expected table %q instead of %q", info.Name, table.Name)
+		}
+
+		if !contains(tables, table.Name) {
+			if err := skipRows(br, table); err != nil {
+				return nil, err
+			}
+			result.SkippedTables = append(result.SkippedTables, table.Name)
+			continue
+		}
+
+		if err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			return restoreTable(sess, sqlStore, br, table)
+		}); err != nil {
+			return nil, errutil.Wrapf(err, "failed to restore table %q", table.Name)
+		}
+		result.Tables = append(result.Tables, table.Name)
+		result.RowCounts[table.Name] = table.Rows
+	}
+
+	return result, nil
+}
+
+// compareMigrations checks that the database migrations of the backup are the ones of this Grafana
+// version, so that the rows of the backup match the schema created by the migrations.
+func compareMigrations(header *BackupHeader, migrations []string) error {
+	for _, id := range header.Migrations {
+		if !contains(migrations, id) {
+			return fmt.Errorf("the backup was created by Grafana %s with the unknown migration %q: restore it with the "+
+				"Grafana version which created it", header.GrafanaVersion, id)
+		}
+	}
+	for _, id := range migrations {
+		if !contains(header.Migrations, id) {
+			return fmt.Errorf("the backup was created by Grafana %s without the migration %q: restore it with the "+
+				"Grafana version which created it, then upgrade", header.GrafanaVersion, id)
+		}
+	}
+	return nil
+}
+
+// restoreTable replaces the rows of a table with the rows read from the archive, in a transaction.
+func restoreTable(sess *sqlstore.DBSession, sqlStore *sqlstore.SQLStore, br *backupReader, table BackupTable) error {
+	if len(table.Columns) == 0 {
+		return fmt.Errorf("failed to read backup archive: table %q has no columns", table.Name)
+	}
+
+	dialect := sqlStore.Dialect
+	emptyRows, err := sess.DB().Query(fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", dialect.Quote(table.Name)))
+	if err != nil {
+		return err
+	}
+	targetColumns, targetColumnTypes, err := readColumns(emptyRows.Rows)
+	if err != nil {
+		return err
+	}
+	columns := make([]string, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, column.Name)
+	}
+	columnTypes, err := matchColumnTypes(columns, targetColumns, targetColumnTypes)
+	if err != nil {
+		return err
+	}
+
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	// the migrations may have created default rows, e.g. the migration log
+	if _, err := sess.Exec("DELETE FROM " + dialect.Quote(table.Name)); err != nil {
+		_ = sess.Rollback()
+		return err
+	}
+
+	batchSize := maxCopyParams / len(columns)
+	batch := make([]interface{}, 0, batchSize*len(columns))
+	for i := int64(0); i < table.Rows; i++ {
+		var row []interface{}
+		if err := br.read(&row); err != nil {
+			_ = sess.Rollback()
+			return err
+		}
+		if len(row) != len(columns) {
+			_ = sess.Rollback()
+			return fmt.Errorf("failed to read backup archive: row with %d values instead of %d", len(row), len(columns))
+		}
+
+		for j, value := range row {
+			converted, err := restoreValue(value, table.Columns[j], columnTypes[j])
+			if err != nil {
+				_ = sess.Rollback()
+				return errutil.Wrapf(err, "failed to convert column %q", columns[j])
+			}
+			batch = append(batch, converted)
+		}
+
+		if len(batch) == batchSize*len(columns) {
+			if err := insertRows(sess.Session, dialect, table.Name, columns, batch); err != nil {
+				_ = sess.Rollback()
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := insertRows(sess.Session, dialect, table.Name, columns, batch); err != nil {
+			_ = sess.Rollback()
+			return err
+		}
+	}
+
+	if err := resetSequence(sess.Session, dialect, table.Name, columns); err != nil {
+		_ = sess.Rollback()
+		return err
+	}
+
+	var count int64
+	if _, err := sess.SQL("SELECT COUNT(*) FROM " + dialect.Quote(table.Name)).Get(&count); err != nil {
+		_ = sess.Rollback()
+		return err
+	}
+	if count != table.Rows {
+		_ = sess.Rollback()
+		return fmt.Errorf("the table has %d rows instead of %d", count, table.Rows)
+	}
+	return sess.Commit()
+}
+
+func skipRows(br *backupReader, table BackupTable) error {
+	for i := int64(0); i < table.Rows; i++ {
+		var row json.RawMessage
+		if err := br.read(&row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreValue converts an archived value to the type of the target column, given by its database
+// type name.
+func restoreValue(value interface{}, column BackupColumn, columnType string) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			value = i
+		} else if value, err = v.Float64(); err != nil {
+			return nil, err
+		}
+	case string:
+		if column.Binary {
+			decoded, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, err
+			}
+			value = decoded
+		}
+	}
+
+	return convertValue(value, columnType)
+}
@@ -0,0 +1,502 @@
+package commands
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/hashicorp/go-version"
+)
+
+// pluginInstall is a plugin version to install, whose archive is downloaded while resolving the dependencies.
+type pluginInstall struct {
+	id      string
+	version string
+	repo    models.Repository
+	// checksums are the checksums of the archives of the version, by OS and architecture.
+	checksums     map[string]string
+	archive       string
+	allowSymlinks bool
+	fromURL       bool
+	// requiredBy is the plugin requiring the plugin, empty for the requested plugins.
+	requiredBy string
+}
+
+// incompatibleGrafanaError is returned for the plugins which don't support the Grafana version.
+type incompatibleGrafanaError struct {
+	constraint     versionConstraint
+	grafanaVersion *version.Version
+}
+
+func (e incompatibleGrafanaError) Error() string {
+	return fmt.Sprintf("requires Grafana %s, but this is Grafana %s", e.constraint, e.grafanaVersion)
+}
+
+// requirement is a version range of a plugin, required by the user or another plugin.
+type requirement struct {
+	constraint versionConstraint
+	requiredBy string
+}
+
+// dependencyResolver resolves the versions of the requested plugins and of the plugins they depend on. The
+// plugins are installed once they're all resolved, so that the plugins directory isn't modified when the
+// dependencies can't be satisfied.
+type dependencyResolver struct {
+	c              utils.CommandLine
+	client         utils.ApiClient
+	pluginsDir     string
+	grafanaVersion *version.Version
+	lock           *pluginLockFile
+	repos          []models.Repository
+	tmpDir         string
+
+	// installs are the plugins to install, each after the plugins it depends on.
+	installs     []*pluginInstall
+	resolved     map[string]*pluginInstall
+	requirements map[string][]requirement
+}
+
+// newDependencyResolver returns a resolver, which must be cleaned up. The lock file is optional.
+func newDependencyResolver(c utils.CommandLine, client utils.ApiClient, lock *pluginLockFile) (*dependencyResolver, error) {
+	r := &dependencyResolver{
+		c:            c,
+		client:       client,
+		pluginsDir:   c.PluginDirectory(),
+		lock:         lock,
+		resolved:     map[string]*pluginInstall{},
+		requirements: map[string][]requirement{},
+	}
+
+	if v, err := version.NewVersion(services.GrafanaVersion()); err == nil {
+		r.grafanaVersion = v.Core()
+	} else {
+		logger.Debugf("Unknown Grafana version %q, the Grafana versions supported by plugins aren't checked\n",
+			services.GrafanaVersion())
+	}
+
+	tmpDir, err := ioutil.TempDir("", "grafana-plugins")
+	if err != nil {
+		return nil, errutil.Wrap("failed to create temporary directory", err)
+	}
+	r.tmpDir = tmpDir
+	return r, nil
+}
+
+// cleanup removes the downloaded archives.
+func (r *dependencyResolver) cleanup() {
+	if err := os.RemoveAll(r.tmpDir); err != nil {
+		logger.Warn("Failed to remove temporary directory", "dir", r.tmpDir, "err", err)
+	}
+}
+
+func (r *dependencyResolver) repositories() ([]models.Repository, error) {
+	if r.repos == nil {
+		repos, err := pluginRepositories(r.c)
+		if err != nil {
+			return nil, err
+		}
+		r.repos = repos
+	}
+	return r.repos, nil
+}
+
+// resolve resolves a requested plugin and its dependencies. The version is either a version, a version range
+// or empty for the latest version supporting the Grafana version, or the locked version if any.
+func (r *dependencyResolver) resolve(pluginID, versionArg string) error {
+	exact := ""
+	var constraint versionConstraint
+	if versionArg != "" {
+		if _, err := version.NewVersion(versionArg); err == nil {
+			exact = versionArg
+		} else if constraint, err = parseVersionConstraint(versionArg); err != nil {
+			return err
+		}
+	}
+
+	r.requirements[pluginID] = append(r.requirements[pluginID], requirement{constraint: constraint})
+	return r.resolvePlugin(pluginID, exact, "")
+}
+
+// resolveURL resolves a requested plugin downloaded from a URL, and its dependencies.
+func (r *dependencyResolver) resolveURL(pluginID, versionArg, url string) error {
+	install := &pluginInstall{id: pluginID, version: versionArg, fromURL: true}
+	if err := r.download(install, url, "", nil); err != nil {
+		return err
+	}
+
+	manifest, err := r.readManifest(install)
+	if err != nil {
+		return err
+	}
+	return r.add(install, manifest)
+}
+
+func (r *dependencyResolver) resolvePlugin(pluginID, exact, requiredBy string) error {
+	if resolved, exists := r.resolved[pluginID]; exists {
+		return r.satisfies(pluginID, resolved.version)
+	}
+
+	// an installed dependency is kept when it's compatible
+	if requiredBy != "" {
+		if local, err := services.ReadPlugin(r.pluginsDir, pluginID); err == nil &&
+			r.satisfies(pluginID, local.Info.Version) == nil && r.supportsGrafana(local.Dependencies) == nil {
+			logger.Debugf("Dependency %s of %s is installed with version %s\n", pluginID, requiredBy, local.Info.Version)
+			if r.lock != nil {
+				if _, locked := r.lock.Plugins[pluginID]; !locked {
+					r.lock.Plugins[pluginID] = lockedPlugin{Version: local.Info.Version}
+				}
+			}
+			return nil
+		}
+	}
+
+	repos, err := r.repositories()
+	if err != nil {
+		return err
+	}
+
+	var lockedChecksum string
+	if locked, ok := r.lockedVersion(pluginID, exact); ok {
+		exact = locked.Version
+		lockedChecksum = locked.Checksums[osAndArchString()]
+		if locked.Repository != "" {
+			repos = nil
+			for _, repo := range r.repos {
+				if repo.Name == locked.Repository {
+					repos = append(repos, repo)
+				}
+			}
+			if len(repos) == 0 {
+				return fmt.Errorf("plugin %s is locked to repository %q, which isn't configured", pluginID,
+					locked.Repository)
+			}
+		}
+	}
+
+	plugin, repo, err := getPlugin(r.client, repos, pluginID)
+	if err != nil {
+		return err
+	}
+
+	candidates, err := r.candidateVersions(pluginID, &plugin, exact)
+	if err != nil {
+		return err
+	}
+
+	// the Grafana versions supported by a version may only be known from its plugin.json
+	for i := range candidates {
+		install := r.newInstall(pluginID, &candidates[i], repo, requiredBy)
+		checksum := install.checksums[osAndArchString()]
+		if checksum == "" {
+			checksum = install.checksums["any"]
+		}
+		if lockedChecksum != "" {
+			checksum = lockedChecksum
+		}
+
+		downloadURL := fmt.Sprintf("%s/%s/versions/%s/download", repo.URL, pluginID, install.version)
+		if err := r.download(install, downloadURL, checksum, repo.Headers); err != nil {
+			return err
+		}
+
+		manifest, err := r.readManifest(install)
+		if err != nil && i < len(candidates)-1 && errors.As(err, &incompatibleGrafanaError{}) {
+			logger.Infof("Skipping %v\n", err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return r.add(install, manifest)
+	}
+
+	return nil
+}
+
+func (r *dependencyResolver) newInstall(pluginID string, v *models.Version, repo models.Repository, requiredBy string) *pluginInstall {
+	install := &pluginInstall{
+		id:         pluginID,
+		version:    v.Version,
+		repo:       repo,
+		checksums:  map[string]string{},
+		requiredBy: requiredBy,
+		// At this point the plugin download is going through grafana.com API and thus the name is validated.
+		// Checking for grafana prefix is how it is done there so no 3rd party plugin should have that prefix.
+		allowSymlinks: strings.HasPrefix(pluginID, "grafana-") && repo.Name == setting.GrafanaComPluginRepositoryName,
+	}
+
+	// Plugins which are downloaded just as sourcecode zipball from github do not have checksum
+	for arch, meta := range v.Arch {
+		install.checksums[arch] = meta.SHA256
+	}
+	return install
+}
+
+// lockedVersion returns the locked version of a plugin, when it satisfies the requirements.
+func (r *dependencyResolver) lockedVersion(pluginID, exact string) (lockedPlugin, bool) {
+	if r.lock == nil {
+		return lockedPlugin{}, false
+	}
+	locked, exists := r.lock.Plugins[pluginID]
+	if !exists || (exact != "" && exact != locked.Version) {
+		return lockedPlugin{}, false
+	}
+	if err := r.satisfies(pluginID, locked.Version); err != nil {
+		logger.Warnf("Ignoring locked version %s of %s: %v\n", locked.Version, pluginID, err)
+		return lockedPlugin{}, false
+	}
+	return locked, true
+}
+
+// selectVersion selects the version of a plugin to install, the latest satisfying the requirements when
+// no version is given.
+func (r *dependencyResolver) selectVersion(pluginID string, plugin *models.Plugin, exact string) (*models.Version, error) {
+	candidates, err := r.candidateVersions(pluginID, plugin, exact)
+	if err != nil {
+		return nil, err
+	}
+	return &candidates[0], nil
+}
+
+// candidateVersions returns the versions of a plugin satisfying the requirements, from the latest.
+func (r *dependencyResolver) candidateVersions(pluginID string, plugin *models.Plugin, exact string) ([]models.Version, error) {
+	if exact != "" {
+		v, err := SelectVersion(plugin, exact)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.satisfies(pluginID, v.Version); err != nil {
+			return nil, err
+		}
+		if err := r.checkGrafanaDependency(v.GrafanaDependency); err != nil {
+			return nil, errutil.Wrapf(err, "plugin %s %s", pluginID, v.Version)
+		}
+		return []models.Version{*v}, nil
+	}
+
+	if latestSupportedVersion(plugin) == nil {
+		return nil, fmt.Errorf("plugin %s is not supported on your architecture and OS", pluginID)
+	}
+	var candidates []models.Version
+	for _, v := range plugin.Versions {
+		ver := v
+		if supportsCurrentArch(&ver) && r.satisfies(pluginID, ver.Version) == nil &&
+			r.checkGrafanaDependency(ver.GrafanaDependency) == nil {
+			candidates = append(candidates, ver)
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	var ranges []string
+	for _, req := range r.requirements[pluginID] {
+		if req.requiredBy != "" {
+			ranges = append(ranges, fmt.Sprintf("%s required by %s", req.constraint, req.requiredBy))
+		} else if len(req.constraint.alternatives) > 0 {
+			ranges = append(ranges, req.constraint.String())
+		}
+	}
+	if r.grafanaVersion != nil {
+		ranges = append(ranges, "Grafana "+r.grafanaVersion.String())
+	}
+	return nil, fmt.Errorf("no version of plugin %s is compatible with %s", pluginID, strings.Join(ranges, ", "))
+}
+
+// satisfies returns an error when a version of a plugin doesn't satisfy its requirements.
+func (r *dependencyResolver) satisfies(pluginID, v string) error {
+	for _, req := range r.requirements[pluginID] {
+		if req.constraint.checkString(v) {
+			continue
+		}
+		if req.requiredBy == "" {
+			return fmt.Errorf("version %s of plugin %s doesn't match %s", v, pluginID, req.constraint)
+		}
+		return fmt.Errorf("plugin %s requires %s %s, which conflicts with version %s", req.requiredBy, pluginID,
+			req.constraint, v)
+	}
+	return nil
+}
+
+// supportsGrafana returns an error when the dependencies of a plugin exclude the Grafana version.
+func (r *dependencyResolver) supportsGrafana(deps models.Dependencies) error {
+	if deps.GrafanaDependency != "" {
+		return r.checkGrafanaDependency(deps.GrafanaDependency)
+	}
+	return r.checkGrafanaDependency(deps.GrafanaVersion)
+}
+
+func (r *dependencyResolver) checkGrafanaDependency(raw string) error {
+	if r.grafanaVersion == nil || raw == "" {
+		return nil
+	}
+
+	constraint, err := parseVersionConstraint(raw)
+	if err != nil {
+		logger.Debugf("Ignoring Grafana dependency: %v\n", err)
+		return nil
+	}
+	if !constraint.check(r.grafanaVersion) {
+		return incompatibleGrafanaError{constraint: constraint, grafanaVersion: r.grafanaVersion}
+	}
+	return nil
+}
+
+func (r *dependencyResolver) download(install *pluginInstall, url, checksum string, headers map[string]string) error {
+	logger.Infof("downloading %v @ %v\n", install.id, install.version)
+	logger.Infof("from: %v\n", url)
+
+	tmpFile, err := ioutil.TempFile(r.tmpDir, "*.zip")
+	if err != nil {
+		return errutil.Wrap("failed to create temporary file", err)
+	}
+	install.archive = tmpFile.Name()
+
+	if err := r.client.DownloadFile(install.id, tmpFile, url, checksum, headers); err != nil {
+		if err := tmpFile.Close(); err != nil {
+			logger.Warn("Failed to close file", "err", err)
+		}
+		return errutil.Wrap("failed to download plugin archive", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errutil.Wrap("failed to close tmp file", err)
+	}
+	return nil
+}
+
+// readManifest reads the plugin.json of a downloaded plugin, if any, and checks the plugin supports the
+// Grafana version.
+func (r *dependencyResolver) readManifest(install *pluginInstall) (*models.InstalledPlugin, error) {
+	manifest, err := readArchivePlugin(install.archive, install.id)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "invalid archive of plugin %s", install.id)
+	}
+	if manifest == nil {
+		return nil, nil
+	}
+
+	if install.version == "" {
+		install.version = manifest.Info.Version
+	}
+	if err := r.supportsGrafana(manifest.Dependencies); err != nil {
+		return nil, errutil.Wrapf(err, "plugin %s %s", install.id, install.version)
+	}
+	return manifest, nil
+}
+
+// add adds a downloaded plugin and resolves its dependencies.
+func (r *dependencyResolver) add(install *pluginInstall, manifest *models.InstalledPlugin) error {
+	r.resolved[install.id] = install
+	if manifest != nil {
+		for _, dep := range manifest.Dependencies.Plugins {
+			constraint, err := parseVersionConstraint(dep.Version)
+			if err != nil {
+				return errutil.Wrapf(err, "dependency %s of plugin %s", dep.ID, install.id)
+			}
+			r.requirements[dep.ID] = append(r.requirements[dep.ID], requirement{
+				constraint: constraint,
+				requiredBy: install.id,
+			})
+			if err := r.resolvePlugin(dep.ID, "", install.id); err != nil {
+				return errutil.Wrapf(err, "failed to resolve dependency %s of plugin %s", dep.ID, install.id)
+			}
+		}
+	}
+
+	r.installs = append(r.installs, install)
+	return nil
+}
+
+// install installs the resolved plugins, and writes the lock file if any.
+func (r *dependencyResolver) install() error {
+	for _, install := range r.installs {
+		logger.Infof("installing %v @ %v\n", install.id, install.version)
+		logger.Infof("into: %v\n", r.pluginsDir)
+		logger.Info("\n")
+
+		if err := extractFiles(install.archive, install.id, r.pluginsDir, install.allowSymlinks); err != nil {
+			return errutil.Wrap("failed to extract plugin archive", err)
+		}
+
+		if install.requiredBy != "" {
+			logger.Infof("Installed dependency: %v ✔\n", install.id)
+		} else {
+			logger.Infof("%s Installed %s successfully \n", color.GreenString("✔"), install.id)
+		}
+
+		if r.lock == nil {
+			continue
+		}
+		if install.fromURL {
+			logger.Warnf("%s is installed from a URL and isn't added to the lock file\n", install.id)
+			continue
+		}
+		r.lock.Plugins[install.id] = lockedPlugin{
+			Version:    install.version,
+			Repository: install.repo.Name,
+			Checksums:  install.checksums,
+		}
+	}
+
+	if r.lock != nil {
+		return r.lock.write()
+	}
+	return nil
+}
+
+// readArchivePlugin reads the plugin.json of a plugin archive, like services.ReadPlugin once the archive is
+// extracted. It returns nil when there's none.
+func readArchivePlugin(archive, pluginID string) (*models.InstalledPlugin, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			logger.Warn("Failed to close archive", "err", err)
+		}
+	}()
+
+	var manifest *zip.File
+	for _, zf := range r.File {
+		switch path.Clean(removeGitBuildFromName(pluginID, zf.Name)) {
+		case path.Join(pluginID, "dist", "plugin.json"):
+			manifest = zf
+		case path.Join(pluginID, "plugin.json"):
+			if manifest == nil {
+				manifest = zf
+			}
+		}
+	}
+	if manifest == nil {
+		return nil, nil
+	}
+
+	f, err := manifest.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logger.Warn("Failed to close plugin.json", "err", err)
+		}
+	}()
+
+	var plugin models.InstalledPlugin
+	if err := json.NewDecoder(f).Decode(&plugin); err != nil {
+		return nil, errutil.Wrap("failed to read plugin.json", err)
+	}
+	return &plugin, nil
+}
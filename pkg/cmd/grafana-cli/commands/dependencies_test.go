@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/commandstest"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallPluginDependencies(t *testing.T) {
+	services.Init("7.5.0-pre", false)
+	t.Cleanup(func() {
+		services.Init("", false)
+	})
+
+	repo := fakePluginRepo{
+		"test-app": {
+			{Version: "2.0.0", Dependencies: models.Dependencies{
+				GrafanaDependency: ">=8.0.0",
+			}},
+			{Version: "1.1.0", Dependencies: models.Dependencies{
+				GrafanaVersion: "7.x.x",
+				Plugins: []models.PluginDependency{
+					{ID: "test-panel", Type: "panel", Version: "^1.0.0"},
+					{ID: "test-datasource", Type: "datasource"},
+				},
+			}},
+		},
+		"test-panel": {
+			{Version: "2.0.0"},
+			{Version: "1.5.0", GrafanaDependency: ">=8.0.0"},
+			{Version: "1.4.0"},
+			{Version: "1.0.0"},
+		},
+		"test-datasource": {
+			{Version: "1.0.0", Dependencies: models.Dependencies{
+				Plugins: []models.PluginDependency{{ID: "test-panel", Type: "panel", Version: "~1.4.0"}},
+			}},
+		},
+	}
+
+	t.Run("Should install the dependencies of the latest compatible version", func(t *testing.T) {
+		pluginsDir := setupFakePluginsDir(t)
+		c, err := commandstest.NewCliContext(map[string]string{"pluginsDir": pluginsDir})
+		require.NoError(t, err)
+
+		err = InstallPlugin("test-app", "", c, repo.client(t))
+		require.NoError(t, err)
+
+		assertInstalledVersion(t, pluginsDir, "test-app", "1.1.0")
+		assertInstalledVersion(t, pluginsDir, "test-panel", "1.4.0")
+		assertInstalledVersion(t, pluginsDir, "test-datasource", "1.0.0")
+	})
+
+	t.Run("Should keep installed dependencies satisfying the requirements", func(t *testing.T) {
+		pluginsDir := setupFakePluginsDir(t)
+		c, err := commandstest.NewCliContext(map[string]string{"pluginsDir": pluginsDir})
+		require.NoError(t, err)
+		err = InstallPlugin("test-panel", "1.4.0", c, repo.client(t))
+		require.NoError(t, err)
+
+		client := repo.client(t)
+		downloadFile := client.DownloadFileFunc
+		client.DownloadFileFunc = func(pluginName string, tmpFile *os.File, url string, checksum string, headers map[string]string) error {
+			require.NotEqual(t, "test-panel", pluginName)
+			return downloadFile(pluginName, tmpFile, url, checksum, headers)
+		}
+		err = InstallPlugin("test-datasource", "", c, client)
+		require.NoError(t, err)
+	})
+
+	t.Run("Should refuse a version requiring another Grafana version", func(t *testing.T) {
+		pluginsDir := setupFakePluginsDir(t)
+		c, err := commandstest.NewCliContext(map[string]string{"pluginsDir": pluginsDir})
+		require.NoError(t, err)
+
+		err = InstallPlugin("test-app", "2.0.0", c, repo.client(t))
+		require.EqualError(t, err, "plugin test-app 2.0.0: requires Grafana >=8.0.0, but this is Grafana 7.5.0")
+		assertNotInstalled(t, pluginsDir, "test-app")
+	})
+
+	t.Run("Should refuse conflicting dependencies without installing anything", func(t *testing.T) {
+		pluginsDir := setupFakePluginsDir(t)
+		c, err := commandstest.NewCliContext(map[string]string{"pluginsDir": pluginsDir})
+		require.NoError(t, err)
+
+		err = InstallPlugin("test-app", "", c, fakePluginRepo{
+			"test-app":   repo["test-app"],
+			"test-panel": {{Version: "1.0.0"}},
+			"test-datasource": {
+				{Version: "1.0.0", Dependencies: models.Dependencies{
+					Plugins: []models.PluginDependency{{ID: "test-panel", Type: "panel", Version: "^2.0.0"}},
+				}},
+			},
+		}.client(t))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "plugin test-datasource requires test-panel ^2.0.0, which conflicts with version 1.0.0")
+		assertNotInstalled(t, pluginsDir, "test-app")
+		assertNotInstalled(t, pluginsDir, "test-panel")
+	})
+
+	t.Run("Should install a version range", func(t *testing.T) {
+		pluginsDir := setupFakePluginsDir(t)
+		c, err := commandstest.NewCliContext(map[string]string{"pluginsDir": pluginsDir})
+		require.NoError(t, err)
+
+		err = InstallPlugin("test-panel", "<1.4.0", c, repo.client(t))
+		require.NoError(t, err)
+		assertInstalledVersion(t, pluginsDir, "test-panel", "1.0.0")
+	})
+}
+
+func TestPluginLockFile(t *testing.T) {
+	repo := fakePluginRepo{
+		"test-app": {
+			{Version: "1.1.0", Dependencies: models.Dependencies{
+				Plugins: []models.PluginDependency{{ID: "test-panel", Type: "panel", Version: "1.0.0"}},
+			}},
+			{Version: "1.0.0"},
+		},
+		"test-panel": {
+			{Version: "1.4.0"},
+			{Version: "1.0.0"},
+		},
+	}
+
+	pluginsDir := setupFakePluginsDir(t)
+	lockFile := filepath.Join(t.TempDir(), "plugins.lock")
+	c, err := commandstest.NewCliContext(map[string]string{"pluginsDir": pluginsDir, "lockFile": lockFile})
+	require.NoError(t, err)
+
+	t.Run("Should lock the installed plugins and their dependencies", func(t *testing.T) {
+		err := InstallPlugin("test-app", "", c, repo.client(t))
+		require.NoError(t, err)
+
+		lock, err := readPluginLockFile(c)
+		require.NoError(t, err)
+		require.Equal(t, map[string]lockedPlugin{
+			"test-app": {
+				Version:    "1.1.0",
+				Repository: "grafana.com",
+				Checksums:  map[string]string{osAndArchString(): "test-app-1.1.0"},
+			},
+			"test-panel": {
+				Version:    "1.4.0",
+				Repository: "grafana.com",
+				Checksums:  map[string]string{osAndArchString(): "test-panel-1.4.0"},
+			},
+		}, lock.Plugins)
+	})
+
+	t.Run("Should install the locked versions when updating all plugins", func(t *testing.T) {
+		otherPluginsDir := filepath.Join(t.TempDir(), "plugins")
+		require.NoError(t, os.Mkdir(otherPluginsDir, 0750))
+		otherC, err := commandstest.NewCliContext(map[string]string{"pluginsDir": otherPluginsDir, "lockFile": lockFile})
+		require.NoError(t, err)
+
+		newerRepo := fakePluginRepo{
+			"test-app":   append([]fakePluginVersion{{Version: "1.2.0"}}, repo["test-app"]...),
+			"test-panel": append([]fakePluginVersion{{Version: "1.5.0"}}, repo["test-panel"]...),
+		}
+		err = Command{Client: newerRepo.client(t)}.upgradeAllCommand(otherC)
+		require.NoError(t, err)
+
+		assertInstalledVersion(t, otherPluginsDir, "test-app", "1.1.0")
+		assertInstalledVersion(t, otherPluginsDir, "test-panel", "1.4.0")
+	})
+
+	t.Run("Should refuse an archive with another checksum than the locked one", func(t *testing.T) {
+		client := repo.client(t)
+		client.DownloadFileFunc = func(pluginName string, tmpFile *os.File, url string, checksum string, headers map[string]string) error {
+			assert.Equal(t, "test-app-1.1.0", checksum)
+			return fmt.Errorf("expected SHA256 checksum does not match the downloaded archive")
+		}
+		err := InstallPlugin("test-app", "", c, client)
+		require.Error(t, err)
+	})
+}
+
+// fakePluginRepo is a plugin repository serving archives with the plugin.json of the versions.
+type fakePluginRepo map[string][]fakePluginVersion
+
+type fakePluginVersion struct {
+	Version           string
+	GrafanaDependency string
+	Dependencies      models.Dependencies
+}
+
+func (repo fakePluginRepo) client(t *testing.T) *commandstest.FakeGrafanaComClient {
+	return &commandstest.FakeGrafanaComClient{
+		GetPluginFunc: func(pluginID string, _ models.Repository) (models.Plugin, error) {
+			versions, exists := repo[pluginID]
+			if !exists {
+				return models.Plugin{}, services.ErrNotFoundError
+			}
+
+			plugin := models.Plugin{ID: pluginID}
+			for _, v := range versions {
+				plugin.Versions = append(plugin.Versions, models.Version{
+					Version:           v.Version,
+					GrafanaDependency: v.GrafanaDependency,
+					Arch: map[string]models.ArchMeta{
+						osAndArchString(): {SHA256: pluginID + "-" + v.Version},
+					},
+				})
+			}
+			return plugin, nil
+		},
+		ListAllPluginsFunc: func(models.Repository) (models.PluginRepo, error) {
+			var result models.PluginRepo
+			for pluginID := range repo {
+				plugin, err := repo.client(t).GetPlugin(pluginID, models.Repository{})
+				require.NoError(t, err)
+				result.Plugins = append(result.Plugins, plugin)
+			}
+			return result, nil
+		},
+		DownloadFileFunc: func(pluginName string, tmpFile *os.File, url string, checksum string, headers map[string]string) error {
+			for _, v := range repo[pluginName] {
+				if url != fmt.Sprintf("/%s/versions/%s/download", pluginName, v.Version) {
+					continue
+				}
+				require.Equal(t, pluginName+"-"+v.Version, checksum)
+
+				manifest, err := json.Marshal(models.InstalledPlugin{
+					ID:           pluginName,
+					Info:         models.PluginInfo{Version: v.Version},
+					Dependencies: v.Dependencies,
+				})
+				require.NoError(t, err)
+
+				zw := zip.NewWriter(tmpFile)
+				w, err := zw.Create(pluginName + "-abc123/plugin.json")
+				require.NoError(t, err)
+				_, err = w.Write(manifest)
+				require.NoError(t, err)
+				return zw.Close()
+			}
+			return fmt.Errorf("unexpected download URL %s", url)
+		},
+	}
+}
+
+func assertInstalledVersion(t *testing.T, pluginsDir, pluginID, version string) {
+	t.Helper()
+	plugin, err := services.ReadPlugin(pluginsDir, pluginID)
+	require.NoError(t, err)
+	assert.Equal(t, version, plugin.Info.Version)
+}
+
+func assertNotInstalled(t *testing.T, pluginsDir, pluginID string) {
+	t.Helper()
+	_, err := ioutil.ReadDir(filepath.Join(pluginsDir, pluginID))
+	assert.True(t, os.IsNotExist(err) || strings.Contains(fmt.Sprint(err), "no such file"), "%s is installed", pluginID)
+}
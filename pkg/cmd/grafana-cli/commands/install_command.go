@@ -6,20 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
 
-	"github.com/fatih/color"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
 	"github.com/grafana/grafana/pkg/util/errutil"
 
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
-	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
 )
 
 func validateInput(c utils.CommandLine, pluginFolder string) error {
@@ -60,96 +57,32 @@ func (cmd Command) installCommand(c utils.CommandLine) error {
 	return InstallPlugin(pluginToInstall, version, c, cmd.Client)
 }
 
-// InstallPlugin downloads the plugin code as a zip file from the Grafana.com API
-// and then extracts the zip into the plugins directory.
+// InstallPlugin downloads the plugin code as a zip file from the plugin repositories, with the plugins it
+// depends on, and then extracts the zip files into the plugins directory. The version is either a version,
+// a version range like "^1.2.0", or empty for the latest version supporting the Grafana version. The plugins
+// are only installed once all the dependencies are resolved.
 func InstallPlugin(pluginName, version string, c utils.CommandLine, client utils.ApiClient) error {
-	pluginFolder := c.PluginDirectory()
-	downloadURL := c.PluginURL()
-	isInternal := false
-
-	var checksum string
-	if downloadURL == "" {
-		if strings.HasPrefix(pluginName, "grafana-") {
-			// At this point the plugin download is going through grafana.com API and thus the name is validated.
-			// Checking for grafana prefix is how it is done there so no 3rd party plugin should have that prefix.
-			// You can supply custom plugin name and then set custom download url to 3rd party plugin but then that
-			// is up to the user to know what she is doing.
-			isInternal = true
-		}
-		plugin, err := client.GetPlugin(pluginName, c.RepoDirectory())
-		if err != nil {
-			return err
-		}
-
-		v, err := SelectVersion(&plugin, version)
-		if err != nil {
-			return err
-		}
-
-		if version == "" {
-			version = v.Version
-		}
-		downloadURL = fmt.Sprintf("%s/%s/versions/%s/download",
-			c.String("repo"),
-			pluginName,
-			version,
-		)
-
-		// Plugins which are downloaded just as sourcecode zipball from github do not have checksum
-		if v.Arch != nil {
-			archMeta, exists := v.Arch[osAndArchString()]
-			if !exists {
-				archMeta = v.Arch["any"]
-			}
-			checksum = archMeta.SHA256
-		}
-	}
-
-	logger.Infof("installing %v @ %v\n", pluginName, version)
-	logger.Infof("from: %v\n", downloadURL)
-	logger.Infof("into: %v\n", pluginFolder)
-	logger.Info("\n")
-
-	// Create temp file for downloading zip file
-	tmpFile, err := ioutil.TempFile("", "*.zip")
+	lock, err := readPluginLockFile(c)
 	if err != nil {
-		return errutil.Wrap("failed to create temporary file", err)
+		return err
 	}
-	defer func() {
-		if err := os.Remove(tmpFile.Name()); err != nil {
-			logger.Warn("Failed to remove temporary file", "file", tmpFile.Name(), "err", err)
-		}
-	}()
 
-	err = client.DownloadFile(pluginName, tmpFile, downloadURL, checksum)
+	r, err := newDependencyResolver(c, client, lock)
 	if err != nil {
-		if err := tmpFile.Close(); err != nil {
-			logger.Warn("Failed to close file", "err", err)
-		}
-		return errutil.Wrap("failed to download plugin archive", err)
-	}
-	err = tmpFile.Close()
-	if err != nil {
-		return errutil.Wrap("failed to close tmp file", err)
+		return err
 	}
+	defer r.cleanup()
 
-	err = extractFiles(tmpFile.Name(), pluginName, pluginFolder, isInternal)
-	if err != nil {
-		return errutil.Wrap("failed to extract plugin archive", err)
+	if downloadURL := c.PluginURL(); downloadURL != "" {
+		err = r.resolveURL(pluginName, version, downloadURL)
+	} else {
+		err = r.resolve(pluginName, version)
 	}
-
-	logger.Infof("%s Installed %s successfully \n", color.GreenString("✔"), pluginName)
-
-	res, _ := services.ReadPlugin(pluginFolder, pluginName)
-	for _, v := range res.Dependencies.Plugins {
-		if err := InstallPlugin(v.ID, "", c, client); err != nil {
-			return errutil.Wrapf(err, "failed to install plugin '%s'", v.ID)
-		}
-
-		logger.Infof("Installed dependency: %v ✔\n", v.ID)
+	if err != nil {
+		return err
 	}
 
-	return err
+	return r.install()
 }
 
 func osAndArchString() string {
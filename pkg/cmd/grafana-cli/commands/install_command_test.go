@@ -113,7 +113,7 @@ func TestInstallPluginCommand(t *testing.T) {
 	require.NoError(t, err)
 
 	client := &commandstest.FakeGrafanaComClient{
-		GetPluginFunc: func(pluginId, repoUrl string) (models.Plugin, error) {
+		GetPluginFunc: func(pluginId string, repo models.Repository) (models.Plugin, error) {
 			require.Equal(t, "test-plugin-panel", pluginId)
 			plugin := models.Plugin{
 				ID:       "test-plugin-panel",
@@ -133,7 +133,7 @@ func TestInstallPluginCommand(t *testing.T) {
 			}
 			return plugin, nil
 		},
-		DownloadFileFunc: func(pluginName string, tmpFile *os.File, url string, checksum string) (err error) {
+		DownloadFileFunc: func(pluginName string, tmpFile *os.File, url string, checksum string, headers map[string]string) (err error) {
 			require.Equal(t, "test-plugin-panel", pluginName)
 			require.Equal(t, "/test-plugin-panel/versions/1.0.0/download", url)
 			require.Equal(t, "test", checksum)
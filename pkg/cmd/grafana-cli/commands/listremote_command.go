@@ -8,7 +8,12 @@ import (
 // listRemoteCommand prints out all plugins in the remote repo with latest version supported on current platform.
 // If there are no supported versions for plugin it is skipped.
 func (cmd Command) listRemoteCommand(c utils.CommandLine) error {
-	plugin, err := cmd.Client.ListAllPlugins(c.RepoDirectory())
+	repos, err := pluginRepositories(c)
+	if err != nil {
+		return err
+	}
+
+	plugin, err := listAllPlugins(cmd.Client, repos)
 	if err != nil {
 		return err
 	}
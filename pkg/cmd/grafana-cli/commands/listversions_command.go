@@ -23,7 +23,12 @@ func (cmd Command) listVersionsCommand(c utils.CommandLine) error {
 
 	pluginToList := c.Args().First()
 
-	plugin, err := cmd.Client.GetPlugin(pluginToList, c.String("repo"))
+	repos, err := pluginRepositories(c)
+	if err != nil {
+		return err
+	}
+
+	plugin, _, err := getPlugin(cmd.Client, repos, pluginToList)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// pluginLockFile pins the versions of the installed plugins, so that installing and updating the plugins of
+// several servers with the same lock file installs the same versions.
+type pluginLockFile struct {
+	path string
+	// exists is set when the lock file was read, rather than created.
+	exists bool
+
+	Plugins map[string]lockedPlugin `json:"plugins"`
+}
+
+// lockedPlugin is a plugin version pinned by the lock file.
+type lockedPlugin struct {
+	Version string `json:"version"`
+	// Repository is the name of the repository the plugin was installed from.
+	Repository string `json:"repository,omitempty"`
+	// Checksums are the SHA256 checksums of the archives of the version, by OS and architecture.
+	Checksums map[string]string `json:"checksums,omitempty"`
+}
+
+// readPluginLockFile reads the lock file given with --lockFile, returning nil when there's none. A lock file
+// which doesn't exist yet is created when written.
+func readPluginLockFile(c utils.CommandLine) (*pluginLockFile, error) {
+	path := c.String("lockFile")
+	if path == "" {
+		return nil, nil
+	}
+
+	lock := &pluginLockFile{path: path, Plugins: map[string]lockedPlugin{}}
+	// We can ignore the gosec G304 warning, since the path comes from a command line flag
+	// nolint:gosec
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, errutil.Wrap("failed to read plugin lock file", err)
+	}
+
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, errutil.Wrapf(err, "invalid plugin lock file %q", path)
+	}
+	if lock.Plugins == nil {
+		lock.Plugins = map[string]lockedPlugin{}
+	}
+	lock.exists = true
+	return lock, nil
+}
+
+// write writes the lock file, with the plugins sorted by ID.
+func (l *pluginLockFile) write() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The lock file is meant to be shared between servers, e.g. with their configuration
+	// nolint:gosec
+	if err := ioutil.WriteFile(l.path, append(data, '\n'), 0644); err != nil {
+		return errutil.Wrap("failed to write plugin lock file", err)
+	}
+	l.exists = true
+	return nil
+}
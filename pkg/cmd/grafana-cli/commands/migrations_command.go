@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func migrationsStatusCommand(c utils.CommandLine, mg *migrator.Migrator) error {
+	statuses, err := mg.Status()
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		switch {
+		case status.Executed:
+			if !c.Bool("pending") {
+				logger.Infof("%s %s (executed %s)\n", color.GreenString("✔"), status.ID, status.Timestamp.Format("2006-01-02 15:04:05"))
+			}
+			continue
+		case status.Error != "":
+			logger.Infof("%s %s (pending, failed %s: %s)\n", color.RedString("✗"), status.ID, status.Timestamp.Format("2006-01-02 15:04:05"), status.Error)
+		default:
+			logger.Infof("%s %s (pending)\n", color.YellowString("•"), status.ID)
+		}
+		pending++
+	}
+
+	logger.Infof("\n%d migrations, %d executed, %d pending\n", len(statuses), len(statuses)-pending, pending)
+	return nil
+}
+
+// migrationsPlanCommand prints the pending migrations as an SQL script to review before upgrading,
+// without executing them.
+func migrationsPlanCommand(c utils.CommandLine, mg *migrator.Migrator) error {
+	planned, err := mg.Plan()
+	if err != nil {
+		return err
+	}
+
+	warnings := 0
+	for _, p := range planned {
+		warnings += len(p.Warnings)
+	}
+
+	logger.Infof("-- %d pending migrations for %s, %d warnings\n\n", len(planned), mg.Dialect.DriverName(), warnings)
+	logger.Info(migrator.FormatPlan(planned))
+	return nil
+}
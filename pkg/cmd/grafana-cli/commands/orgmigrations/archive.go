@@ -0,0 +1,213 @@
+// Package orgmigrations exports and imports whole organizations, so they can be moved between
+// Grafana instances.
+//
+// An archive is a gzip compressed JSON document. Every exported item keeps the ID it has on the
+// exporting instance, so references between items can be mapped to the IDs of the importing instance.
+// Users are global and are not exported: they are referenced by login and email, and matched by login with
+// the users of the importing instance.
+package orgmigrations
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Version is the current version of the archive format.
+const Version = 1
+
+// ErrUnsupportedVersion occurs when the archive was written with an unknown format version.
+var ErrUnsupportedVersion = errors.New("unsupported org archive version")
+
+// Archive is an exported organization.
+type Archive struct {
+	Version       int            `json:"version"`
+	Exported      time.Time      `json:"exported"`
+	Org           Org            `json:"org"`
+	Users         []User         `json:"users"`
+	Teams         []Team         `json:"teams"`
+	Dashboards    []Dashboard    `json:"dashboards"`
+	Permissions   []Permission   `json:"permissions"`
+	DataSources   []DataSource   `json:"dataSources"`
+	LibraryPanels []LibraryPanel `json:"libraryPanels"`
+	Playlists     []Playlist     `json:"playlists"`
+	Preferences   []Preference   `json:"preferences"`
+}
+
+type Org struct {
+	Name     string `json:"name"`
+	Address1 string `json:"address1"`
+	Address2 string `json:"address2"`
+	City     string `json:"city"`
+	ZipCode  string `json:"zipCode"`
+	State    string `json:"state"`
+	Country  string `json:"country"`
+}
+
+// User is a member of the organization.
+type User struct {
+	ID    int64           `json:"id"`
+	Login string          `json:"login"`
+	Email string          `json:"email"`
+	Role  models.RoleType `json:"role"`
+}
+
+type Team struct {
+	ID      int64        `json:"id"`
+	Name    string       `json:"name"`
+	Email   string       `json:"email"`
+	Members []TeamMember `json:"members"`
+	// Groups are the external groups synced to the team.
+	Groups []string `json:"groups,omitempty"`
+}
+
+type TeamMember struct {
+	UserID     int64                 `json:"userId"`
+	External   bool                  `json:"external"`
+	Permission models.PermissionType `json:"permission"`
+}
+
+// Dashboard is a dashboard or a folder, along with its versions.
+type Dashboard struct {
+	ID        int64              `json:"id"`
+	UID       string             `json:"uid"`
+	FolderID  int64              `json:"folderId"`
+	IsFolder  bool               `json:"isFolder"`
+	HasACL    bool               `json:"hasAcl"`
+	Title     string             `json:"title"`
+	GnetID    int64              `json:"gnetId"`
+	PluginID  string             `json:"pluginId"`
+	Version   int                `json:"version"`
+	Created   time.Time          `json:"created"`
+	Updated   time.Time          `json:"updated"`
+	CreatedBy int64              `json:"createdBy"`
+	UpdatedBy int64              `json:"updatedBy"`
+	Data      json.RawMessage    `json:"data"`
+	Versions  []DashboardVersion `json:"versions"`
+}
+
+type DashboardVersion struct {
+	Version       int             `json:"version"`
+	ParentVersion int             `json:"parentVersion"`
+	RestoredFrom  int             `json:"restoredFrom"`
+	Created       time.Time       `json:"created"`
+	CreatedBy     int64           `json:"createdBy"`
+	Message       string          `json:"message"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Permission is an item of the access control list of a dashboard or folder.
+type Permission struct {
+	DashboardID int64                 `json:"dashboardId"`
+	UserID      int64                 `json:"userId,omitempty"`
+	TeamID      int64                 `json:"teamId,omitempty"`
+	Role        *models.RoleType      `json:"role,omitempty"`
+	Permission  models.PermissionType `json:"permission"`
+}
+
+// DataSource is a data source. The values of SecureJSONData are encrypted with the secret key
+// of the target instance.
+type DataSource struct {
+	ID                int64             `json:"id"`
+	UID               string            `json:"uid"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	Access            models.DsAccess   `json:"access"`
+	URL               string            `json:"url"`
+	Password          string            `json:"password"`
+	User              string            `json:"user"`
+	Database          string            `json:"database"`
+	BasicAuth         bool              `json:"basicAuth"`
+	BasicAuthUser     string            `json:"basicAuthUser"`
+	BasicAuthPassword string            `json:"basicAuthPassword"`
+	WithCredentials   bool              `json:"withCredentials"`
+	IsDefault         bool              `json:"isDefault"`
+	ReadOnly          bool              `json:"readOnly"`
+	Version           int               `json:"version"`
+	JSONData          json.RawMessage   `json:"jsonData"`
+	SecureJSONData    map[string][]byte `json:"secureJsonData"`
+}
+
+// LibraryPanel is a library panel along with its versions and the dashboards it is connected to.
+type LibraryPanel struct {
+	UID          string                `json:"uid"`
+	FolderID     int64                 `json:"folderId"`
+	Name         string                `json:"name"`
+	Type         string                `json:"type"`
+	Description  string                `json:"description"`
+	Model        json.RawMessage       `json:"model"`
+	Version      int64                 `json:"version"`
+	Created      time.Time             `json:"created"`
+	Updated      time.Time             `json:"updated"`
+	CreatedBy    int64                 `json:"createdBy"`
+	UpdatedBy    int64                 `json:"updatedBy"`
+	DashboardIDs []int64               `json:"dashboardIds"`
+	Versions     []LibraryPanelVersion `json:"versions"`
+}
+
+type LibraryPanelVersion struct {
+	ParentVersion int64           `json:"parentVersion"`
+	RestoredFrom  int64           `json:"restoredFrom"`
+	Version       int64           `json:"version"`
+	FolderID      int64           `json:"folderId"`
+	Name          string          `json:"name"`
+	Model         json.RawMessage `json:"model"`
+	Created       time.Time       `json:"created"`
+	CreatedBy     int64           `json:"createdBy"`
+}
+
+type Playlist struct {
+	Name     string         `json:"name"`
+	Interval string         `json:"interval"`
+	Items    []PlaylistItem `json:"items"`
+}
+
+type PlaylistItem struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Order int    `json:"order"`
+	Title string `json:"title"`
+}
+
+// Preference holds the preferences of the organization, a team or a user of the organization.
+type Preference struct {
+	UserID          int64  `json:"userId,omitempty"`
+	TeamID          int64  `json:"teamId,omitempty"`
+	HomeDashboardID int64  `json:"homeDashboardId"`
+	Timezone        string `json:"timezone"`
+	Theme           string `json:"theme"`
+}
+
+// WriteArchive writes a compressed archive to w.
+func WriteArchive(w io.Writer, archive *Archive) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(archive); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadArchive reads a compressed archive from r.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read org archive: %w", err)
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+
+	var archive Archive
+	if err := json.NewDecoder(zr).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("failed to read org archive: %w", err)
+	}
+	if archive.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+	return &archive, nil
+}
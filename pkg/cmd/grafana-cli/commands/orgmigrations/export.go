@@ -0,0 +1,473 @@
+package orgmigrations
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// targetSecretKeyEnv is the environment variable holding the secret key of the target instance.
+const targetSecretKeyEnv = "GRAFANA_TARGET_SECRET_KEY"
+
+// stdin is overridden in tests.
+var stdin io.Reader = os.Stdin
+
+// ExportOrg exports the organization given by name or ID as first argument to the archive
+// file given as second argument.
+func ExportOrg(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	orgNameOrID, path := c.Args().Get(0), c.Args().Get(1)
+	if orgNameOrID == "" || path == "" {
+		return fmt.Errorf("usage: export <org name or id> <archive file>")
+	}
+
+	secretKey, err := targetSecretKey(c)
+	if err != nil {
+		return err
+	}
+
+	var archive *Archive
+	if err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		org, err := findOrg(sess, orgNameOrID)
+		if err != nil {
+			return err
+		}
+		archive, err = exportOrg(sess, org, secretKey)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	// the archive holds the data source secrets, encrypted with the secret key of the target instance
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errutil.Wrap("failed to create archive file", err)
+	}
+	if err := WriteArchive(f, archive); err != nil {
+		_ = f.Close()
+		return errutil.Wrap("failed to write archive", err)
+	}
+	if err := f.Close(); err != nil {
+		return errutil.Wrap("failed to write archive", err)
+	}
+
+	logger.Infof("%s Exported org %q with %d dashboards and folders, %d data sources and %d library panels to %s\n",
+		color.GreenString("✔"), archive.Org.Name, len(archive.Dashboards), len(archive.DataSources),
+		len(archive.LibraryPanels), path)
+	return nil
+}
+
+// targetSecretKey returns the secret key of the instance the archive will be imported in. It is read
+// from the standard input or the targetSecretKeyEnv environment variable rather than from a flag, so
+// it doesn't show up in the process list and the shell history. It defaults to the secret key of
+// this instance.
+func targetSecretKey(c utils.CommandLine) (string, error) {
+	if c.Bool("secret-key-stdin") {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", errutil.Wrap("failed to read secret key", err)
+		}
+		secretKey := strings.TrimRight(line, "\r\n")
+		if secretKey == "" {
+			return "", errors.New("no secret key on the standard input")
+		}
+		return secretKey, nil
+	}
+	if secretKey := os.Getenv(targetSecretKeyEnv); secretKey != "" {
+		return secretKey, nil
+	}
+	return setting.SecretKey, nil
+}
+
+func findOrg(sess *sqlstore.DBSession, orgNameOrID string) (*models.Org, error) {
+	var org models.Org
+	has, err := sess.Where("name=?", orgNameOrID).Get(&org)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return &org, nil
+	}
+
+	id, err := strconv.ParseInt(orgNameOrID, 10, 64)
+	if err != nil {
+		return nil, models.ErrOrgNotFound
+	}
+	has, err = sess.ID(id).Get(&org)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, models.ErrOrgNotFound
+	}
+	return &org, nil
+}
+
+// exportOrg exports org. The data source secrets are re-encrypted with secretKey.
+func exportOrg(sess *sqlstore.DBSession, org *models.Org, secretKey string) (*Archive, error) {
+	archive := &Archive{
+		Version:  Version,
+		Exported: time.Now(),
+		Org: Org{
+			Name:     org.Name,
+			Address1: org.Address1,
+			Address2: org.Address2,
+			City:     org.City,
+			ZipCode:  org.ZipCode,
+			State:    org.State,
+			Country:  org.Country,
+		},
+	}
+
+	exporters := []func(*sqlstore.DBSession, int64, *Archive) error{
+		exportUsers,
+		exportTeams,
+		exportDashboards,
+		exportPermissions,
+		func(sess *sqlstore.DBSession, orgID int64, archive *Archive) error {
+			return exportDataSources(sess, orgID, archive, secretKey)
+		},
+		exportLibraryPanels,
+		exportPlaylists,
+		exportPreferences,
+	}
+	for _, export := range exporters {
+		if err := export(sess, org.Id, archive); err != nil {
+			return nil, err
+		}
+	}
+
+	return archive, nil
+}
+
+func exportUsers(sess *sqlstore.DBSession, orgID int64, archive *Archive) error {
+	var orgUsers []*models.OrgUser
+	if err := sess.Where("org_id=?", orgID).Asc("user_id").Find(&orgUsers); err != nil {
+		return errutil.Wrap("failed to export users", err)
+	}
+	userIDs := make([]int64, 0, len(orgUsers))
+	for _, orgUser := range orgUsers {
+		userIDs = append(userIDs, orgUser.UserId)
+	}
+	var users []*models.User
+	if len(userIDs) > 0 {
+		if err := sess.In("id", userIDs).Find(&users); err != nil {
+			return errutil.Wrap("failed to export users", err)
+		}
+	}
+
+	archive.Users = make([]User, 0, len(orgUsers))
+	for _, orgUser := range orgUsers {
+		for _, user := range users {
+			if user.Id == orgUser.UserId {
+				archive.Users = append(archive.Users, User{
+					ID:    user.Id,
+					Login: user.Login,
+					Email: user.Email,
+					Role:  orgUser.Role,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func exportTeams(sess *sqlstore.DBSession, orgID int64, archive *Archive) error {
+	var teams []*models.Team
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&teams); err != nil {
+		return errutil.Wrap("failed to export teams", err)
+	}
+	var members []*models.TeamMember
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&members); err != nil {
+		return errutil.Wrap("failed to export team members", err)
+	}
+	var groups []*models.TeamGroup
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&groups); err != nil {
+		return errutil.Wrap("failed to export team groups", err)
+	}
+
+	archive.Teams = make([]Team, 0, len(teams))
+	for _, team := range teams {
+		t := Team{ID: team.Id, Name: team.Name, Email: team.Email, Members: []TeamMember{}}
+		for _, member := range members {
+			if member.TeamId == team.Id {
+				t.Members = append(t.Members, TeamMember{
+					UserID:     member.UserId,
+					External:   member.External,
+					Permission: member.Permission,
+				})
+			}
+		}
+		for _, group := range groups {
+			if group.TeamId == team.Id {
+				t.Groups = append(t.Groups, group.GroupId)
+			}
+		}
+		archive.Teams = append(archive.Teams, t)
+	}
+	return nil
+}
+
+func exportDashboards(sess *sqlstore.DBSession, orgID int64, archive *Archive) error {
+	var dashboards []*models.Dashboard
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&dashboards); err != nil {
+		return errutil.Wrap("failed to export dashboards", err)
+	}
+	// folders have to be imported before the dashboards they contain
+	sort.SliceStable(dashboards, func(i, j int) bool {
+		return dashboards[i].IsFolder && !dashboards[j].IsFolder
+	})
+
+	var versions []*models.DashboardVersion
+	if err := sess.Where("dashboard_id IN (SELECT id FROM dashboard WHERE org_id=?)", orgID).
+		Asc("dashboard_id", "version").Find(&versions); err != nil {
+		return errutil.Wrap("failed to export dashboard versions", err)
+	}
+	versionsByDashboard := make(map[int64][]DashboardVersion)
+	for _, v := range versions {
+		data, err := v.Data.Encode()
+		if err != nil {
+			return err
+		}
+		versionsByDashboard[v.DashboardId] = append(versionsByDashboard[v.DashboardId], DashboardVersion{
+			Version:       v.Version,
+			ParentVersion: v.ParentVersion,
+			RestoredFrom:  v.RestoredFrom,
+			Created:       v.Created,
+			CreatedBy:     v.CreatedBy,
+			Message:       v.Message,
+			Data:          data,
+		})
+	}
+
+	archive.Dashboards = make([]Dashboard, 0, len(dashboards))
+	for _, dash := range dashboards {
+		data, err := dash.Data.Encode()
+		if err != nil {
+			return err
+		}
+		archive.Dashboards = append(archive.Dashboards, Dashboard{
+			ID:        dash.Id,
+			UID:       dash.Uid,
+			FolderID:  dash.FolderId,
+			IsFolder:  dash.IsFolder,
+			HasACL:    dash.HasAcl,
+			Title:     dash.Title,
+			GnetID:    dash.GnetId,
+			PluginID:  dash.PluginId,
+			Version:   dash.Version,
+			Created:   dash.Created,
+			Updated:   dash.Updated,
+			CreatedBy: dash.CreatedBy,
+			UpdatedBy: dash.UpdatedBy,
+			Data:      data,
+			Versions:  versionsByDashboard[dash.Id],
+		})
+	}
+	return nil
+}
+
+func exportPermissions(sess *sqlstore.DBSession, orgID int64, archive *Archive) error {
+	var acl []*models.DashboardAcl
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&acl); err != nil {
+		return errutil.Wrap("failed to export permissions", err)
+	}
+
+	archive.Permissions = make([]Permission, 0, len(acl))
+	for _, item := range acl {
+		archive.Permissions = append(archive.Permissions, Permission{
+			DashboardID: item.DashboardID,
+			UserID:      item.UserID,
+			TeamID:      item.TeamID,
+			Role:        item.Role,
+			Permission:  item.Permission,
+		})
+	}
+	return nil
+}
+
+func exportDataSources(sess *sqlstore.DBSession, orgID int64, archive *Archive, secretKey string) error {
+	var dataSources []*models.DataSource
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&dataSources); err != nil {
+		return errutil.Wrap("failed to export data sources", err)
+	}
+
+	archive.DataSources = make([]DataSource, 0, len(dataSources))
+	for _, ds := range dataSources {
+		secureJSONData := make(map[string][]byte)
+		for key, value := range ds.SecureJsonData.Decrypt() {
+			encrypted, err := util.Encrypt([]byte(value), secretKey)
+			if err != nil {
+				return errutil.Wrapf(err, "failed to encrypt secure field %q of data source %q", key, ds.Name)
+			}
+			secureJSONData[key] = encrypted
+		}
+
+		var jsonData []byte
+		if ds.JsonData != nil {
+			data, err := ds.JsonData.Encode()
+			if err != nil {
+				return err
+			}
+			jsonData = data
+		}
+
+		archive.DataSources = append(archive.DataSources, DataSource{
+			ID:                ds.Id,
+			UID:               ds.Uid,
+			Name:              ds.Name,
+			Type:              ds.Type,
+			Access:            ds.Access,
+			URL:               ds.Url,
+			Password:          ds.Password,
+			User:              ds.User,
+			Database:          ds.Database,
+			BasicAuth:         ds.BasicAuth,
+			BasicAuthUser:     ds.BasicAuthUser,
+			BasicAuthPassword: ds.BasicAuthPassword,
+			WithCredentials:   ds.WithCredentials,
+			IsDefault:         ds.IsDefault,
+			ReadOnly:          ds.ReadOnly,
+			Version:           ds.Version,
+			JSONData:          jsonData,
+			SecureJSONData:    secureJSONData,
+		})
+	}
+	return nil
+}
+
+func exportLibraryPanels(sess *sqlstore.DBSession, orgID int64, archive *Archive) error {
+	archive.LibraryPanels = []LibraryPanel{}
+
+	// library panels are behind a feature toggle, their tables may not exist
+	exists, err := sess.IsTableExist("library_panel")
+	if err != nil || !exists {
+		return err
+	}
+
+	var panels []*libraryPanel
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&panels); err != nil {
+		return errutil.Wrap("failed to export library panels", err)
+	}
+	var connections []*libraryPanelDashboard
+	if err := sess.Where("librarypanel_id IN (SELECT id FROM library_panel WHERE org_id=?)", orgID).
+		Asc("id").Find(&connections); err != nil {
+		return errutil.Wrap("failed to export library panel connections", err)
+	}
+	var versions []*libraryPanelVersion
+	exists, err = sess.IsTableExist("library_panel_version")
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := sess.Where("librarypanel_id IN (SELECT id FROM library_panel WHERE org_id=?)", orgID).
+			Asc("librarypanel_id", "version").Find(&versions); err != nil {
+			return errutil.Wrap("failed to export library panel versions", err)
+		}
+	}
+
+	for _, panel := range panels {
+		p := LibraryPanel{
+			UID:          panel.UID,
+			FolderID:     panel.FolderID,
+			Name:         panel.Name,
+			Type:         panel.Type,
+			Description:  panel.Description,
+			Model:        panel.Model,
+			Version:      panel.Version,
+			Created:      panel.Created,
+			Updated:      panel.Updated,
+			CreatedBy:    panel.CreatedBy,
+			UpdatedBy:    panel.UpdatedBy,
+			DashboardIDs: []int64{},
+			Versions:     []LibraryPanelVersion{},
+		}
+		for _, connection := range connections {
+			if connection.LibraryPanelID == panel.ID {
+				p.DashboardIDs = append(p.DashboardIDs, connection.DashboardID)
+			}
+		}
+		for _, version := range versions {
+			if version.LibraryPanelID == panel.ID {
+				p.Versions = append(p.Versions, LibraryPanelVersion{
+					ParentVersion: version.ParentVersion,
+					RestoredFrom:  version.RestoredFrom,
+					Version:       version.Version,
+					FolderID:      version.FolderID,
+					Name:          version.Name,
+					Model:         version.Model,
+					Created:       version.Created,
+					CreatedBy:     version.CreatedBy,
+				})
+			}
+		}
+		archive.LibraryPanels = append(archive.LibraryPanels, p)
+	}
+	return nil
+}
+
+func exportPlaylists(sess *sqlstore.DBSession, orgID int64, archive *Archive) error {
+	var playlists []*models.Playlist
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&playlists); err != nil {
+		return errutil.Wrap("failed to export playlists", err)
+	}
+	var items []*models.PlaylistItem
+	if err := sess.Where("playlist_id IN (SELECT id FROM playlist WHERE org_id=?)", orgID).Find(&items); err != nil {
+		return errutil.Wrap("failed to export playlist items", err)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Order < items[j].Order
+	})
+
+	archive.Playlists = make([]Playlist, 0, len(playlists))
+	for _, playlist := range playlists {
+		p := Playlist{Name: playlist.Name, Interval: playlist.Interval, Items: []PlaylistItem{}}
+		for _, item := range items {
+			if item.PlaylistId == playlist.Id {
+				p.Items = append(p.Items, PlaylistItem{
+					Type:  item.Type,
+					Value: item.Value,
+					Order: item.Order,
+					Title: item.Title,
+				})
+			}
+		}
+		archive.Playlists = append(archive.Playlists, p)
+	}
+	return nil
+}
+
+func exportPreferences(sess *sqlstore.DBSession, orgID int64, archive *Archive) error {
+	var prefs []*models.Preferences
+	if err := sess.Where("org_id=?", orgID).Asc("id").Find(&prefs); err != nil {
+		return errutil.Wrap("failed to export preferences", err)
+	}
+
+	archive.Preferences = make([]Preference, 0, len(prefs))
+	for _, pref := range prefs {
+		archive.Preferences = append(archive.Preferences, Preference{
+			UserID:          pref.UserId,
+			TeamID:          pref.TeamId,
+			HomeDashboardID: pref.HomeDashboardId,
+			Timezone:        pref.Timezone,
+			Theme:           pref.Theme,
+		})
+	}
+	return nil
+}
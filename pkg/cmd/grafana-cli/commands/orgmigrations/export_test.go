@@ -0,0 +1,62 @@
+package orgmigrations
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/commandstest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestTargetSecretKey(t *testing.T) {
+	origSecretKey, origStdin := setting.SecretKey, stdin
+	t.Cleanup(func() { setting.SecretKey, stdin = origSecretKey, origStdin })
+	setting.SecretKey = "local-secret-key"
+
+	t.Run("defaults to the secret key of this instance", func(t *testing.T) {
+		c, err := commandstest.NewCliContext(map[string]string{})
+		require.NoError(t, err)
+
+		secretKey, err := targetSecretKey(c)
+		require.NoError(t, err)
+		assert.Equal(t, "local-secret-key", secretKey)
+	})
+
+	t.Run("reads the secret key from the environment", func(t *testing.T) {
+		setTargetSecretKeyEnv(t, "env-secret-key")
+		c, err := commandstest.NewCliContext(map[string]string{})
+		require.NoError(t, err)
+
+		secretKey, err := targetSecretKey(c)
+		require.NoError(t, err)
+		assert.Equal(t, "env-secret-key", secretKey)
+	})
+
+	t.Run("reads the secret key from the standard input", func(t *testing.T) {
+		setTargetSecretKeyEnv(t, "env-secret-key")
+		stdin = strings.NewReader("stdin-secret-key\n")
+		c, err := commandstest.NewCliContext(map[string]string{"secret-key-stdin": "true"})
+		require.NoError(t, err)
+
+		secretKey, err := targetSecretKey(c)
+		require.NoError(t, err)
+		assert.Equal(t, "stdin-secret-key", secretKey)
+
+		stdin = strings.NewReader("")
+		_, err = targetSecretKey(c)
+		require.Error(t, err)
+	})
+}
+
+func setTargetSecretKeyEnv(t *testing.T, value string) {
+	t.Helper()
+
+	require.NoError(t, os.Setenv(targetSecretKeyEnv, value))
+	t.Cleanup(func() {
+		require.NoError(t, os.Unsetenv(targetSecretKeyEnv))
+	})
+}
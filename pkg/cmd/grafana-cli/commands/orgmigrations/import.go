@@ -0,0 +1,506 @@
+package orgmigrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	OrgID int64
+	// SkippedUsers are the logins of the users of the archive that don't exist in this instance.
+	// Their memberships, permissions and preferences are not imported.
+	SkippedUsers []string
+	// SkippedLibraryPanels is the number of library panels not imported because the panel library
+	// is not enabled in this instance.
+	SkippedLibraryPanels int
+}
+
+// ImportOrg imports the archive file given as first argument as a new organization.
+func ImportOrg(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("usage: import <archive file>")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return errutil.Wrap("failed to open archive file", err)
+	}
+	archive, err := ReadArchive(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	if name == "" {
+		name = archive.Org.Name
+	}
+
+	var result *ImportResult
+	if err := sqlStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		result, err = importOrg(sess, archive, name, c.Bool("match-users-by-email"))
+		return err
+	}); err != nil {
+		return err
+	}
+
+	for _, login := range result.SkippedUsers {
+		logger.Warnf("User %q does not exist, its memberships, permissions and preferences were not imported\n", login)
+	}
+	if result.SkippedLibraryPanels > 0 {
+		logger.Warnf("The panel library is not enabled, %d library panels were not imported\n", result.SkippedLibraryPanels)
+	}
+	logger.Infof("%s Imported org %q with ID %d\n", color.GreenString("✔"), name, result.OrgID)
+	return nil
+}
+
+// importer creates the items of an archive in a new org, mapping the IDs of the archive
+// to the IDs of the created items.
+type importer struct {
+	sess   *sqlstore.DBSession
+	orgID  int64
+	now    time.Time
+	result *ImportResult
+	// matchUsersByEmail tells whether users of the archive are also matched by email, when no user
+	// has their login.
+	matchUsersByEmail bool
+
+	userIDs      map[int64]int64
+	teamIDs      map[int64]int64
+	dashboardIDs map[int64]int64
+}
+
+func importOrg(sess *sqlstore.DBSession, archive *Archive, name string, matchUsersByEmail bool) (*ImportResult, error) {
+	exists, err := sess.Where("name=?", name).Exist(&models.Org{})
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, models.ErrOrgNameTaken
+	}
+
+	now := time.Now()
+	org := models.Org{
+		Name:     name,
+		Address1: archive.Org.Address1,
+		Address2: archive.Org.Address2,
+		City:     archive.Org.City,
+		ZipCode:  archive.Org.ZipCode,
+		State:    archive.Org.State,
+		Country:  archive.Org.Country,
+		Created:  now,
+		Updated:  now,
+	}
+	if _, err := sess.Insert(&org); err != nil {
+		return nil, errutil.Wrap("failed to create org", err)
+	}
+
+	i := &importer{
+		sess:              sess,
+		orgID:             org.Id,
+		now:               now,
+		result:            &ImportResult{OrgID: org.Id, SkippedUsers: []string{}},
+		matchUsersByEmail: matchUsersByEmail,
+		userIDs:           make(map[int64]int64),
+		teamIDs:           make(map[int64]int64),
+		dashboardIDs:      make(map[int64]int64),
+	}
+	importers := []func(*Archive) error{
+		i.importUsers,
+		i.importTeams,
+		i.importDashboards,
+		i.importPermissions,
+		i.importDataSources,
+		i.importLibraryPanels,
+		i.importPlaylists,
+		i.importPreferences,
+	}
+	for _, importItems := range importers {
+		if err := importItems(archive); err != nil {
+			return nil, err
+		}
+	}
+
+	return i.result, nil
+}
+
+// userID returns the ID of a user of the archive in this instance, 0 if it doesn't exist.
+func (i *importer) userID(id int64) int64 {
+	return i.userIDs[id]
+}
+
+func (i *importer) importUsers(archive *Archive) error {
+	for _, u := range archive.Users {
+		var user models.User
+		has, err := i.sess.Where("login=?", u.Login).Get(&user)
+		if err != nil {
+			return err
+		}
+		// the same email can belong to another person on another instance, who would get the role of the user
+		if !has && i.matchUsersByEmail && u.Email != "" {
+			if has, err = i.sess.Where("email=?", u.Email).Get(&user); err != nil {
+				return err
+			}
+		}
+		if !has {
+			i.result.SkippedUsers = append(i.result.SkippedUsers, u.Login)
+			continue
+		}
+		i.userIDs[u.ID] = user.Id
+
+		if _, err := i.sess.Insert(&models.OrgUser{
+			OrgId:   i.orgID,
+			UserId:  user.Id,
+			Role:    u.Role,
+			Created: i.now,
+			Updated: i.now,
+		}); err != nil {
+			return errutil.Wrapf(err, "failed to add user %q to org", u.Login)
+		}
+	}
+	return nil
+}
+
+func (i *importer) importTeams(archive *Archive) error {
+	for _, t := range archive.Teams {
+		team := models.Team{
+			OrgId:   i.orgID,
+			Name:    t.Name,
+			Email:   t.Email,
+			Created: i.now,
+			Updated: i.now,
+		}
+		if _, err := i.sess.Insert(&team); err != nil {
+			return errutil.Wrapf(err, "failed to create team %q", t.Name)
+		}
+		i.teamIDs[t.ID] = team.Id
+
+		for _, m := range t.Members {
+			userID := i.userID(m.UserID)
+			if userID == 0 {
+				continue
+			}
+			if _, err := i.sess.Insert(&models.TeamMember{
+				OrgId:      i.orgID,
+				TeamId:     team.Id,
+				UserId:     userID,
+				External:   m.External,
+				Permission: m.Permission,
+				Created:    i.now,
+				Updated:    i.now,
+			}); err != nil {
+				return errutil.Wrapf(err, "failed to add member to team %q", t.Name)
+			}
+		}
+
+		for _, groupID := range t.Groups {
+			if _, err := i.sess.Insert(&models.TeamGroup{
+				OrgId:   i.orgID,
+				TeamId:  team.Id,
+				GroupId: groupID,
+				Created: i.now,
+				Updated: i.now,
+			}); err != nil {
+				return errutil.Wrapf(err, "failed to add group to team %q", t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (i *importer) importDashboards(archive *Archive) error {
+	for _, d := range archive.Dashboards {
+		data, err := simplejson.NewJson(d.Data)
+		if err != nil {
+			return errutil.Wrapf(err, "invalid JSON for dashboard %q", d.Title)
+		}
+
+		dash := models.Dashboard{
+			Uid:       d.UID,
+			Slug:      models.SlugifyTitle(d.Title),
+			OrgId:     i.orgID,
+			GnetId:    d.GnetID,
+			Version:   d.Version,
+			PluginId:  d.PluginID,
+			Created:   d.Created,
+			Updated:   d.Updated,
+			CreatedBy: i.userID(d.CreatedBy),
+			UpdatedBy: i.userID(d.UpdatedBy),
+			FolderId:  i.dashboardIDs[d.FolderID],
+			IsFolder:  d.IsFolder,
+			HasAcl:    d.HasACL,
+			Title:     d.Title,
+			Data:      data,
+		}
+		if _, err := i.sess.Insert(&dash); err != nil {
+			return errutil.Wrapf(err, "failed to create dashboard %q", d.Title)
+		}
+		i.dashboardIDs[d.ID] = dash.Id
+
+		// the ID is part of the dashboard JSON
+		dash.SetId(dash.Id)
+		if _, err := i.sess.ID(dash.Id).Cols("data").Update(&dash); err != nil {
+			return errutil.Wrapf(err, "failed to create dashboard %q", d.Title)
+		}
+
+		for _, tag := range dash.GetTags() {
+			if _, err := i.sess.Insert(&sqlstore.DashboardTag{DashboardId: dash.Id, Term: tag}); err != nil {
+				return errutil.Wrapf(err, "failed to create tags of dashboard %q", d.Title)
+			}
+		}
+
+		for _, v := range d.Versions {
+			versionData, err := simplejson.NewJson(v.Data)
+			if err != nil {
+				return errutil.Wrapf(err, "invalid JSON for version %d of dashboard %q", v.Version, d.Title)
+			}
+			versionData.Set("id", dash.Id)
+			if _, err := i.sess.Insert(&models.DashboardVersion{
+				DashboardId:   dash.Id,
+				ParentVersion: v.ParentVersion,
+				RestoredFrom:  v.RestoredFrom,
+				Version:       v.Version,
+				Created:       v.Created,
+				CreatedBy:     i.userID(v.CreatedBy),
+				Message:       v.Message,
+				Data:          versionData,
+			}); err != nil {
+				return errutil.Wrapf(err, "failed to create version %d of dashboard %q", v.Version, d.Title)
+			}
+		}
+	}
+	return nil
+}
+
+func (i *importer) importPermissions(archive *Archive) error {
+	for _, p := range archive.Permissions {
+		item := models.DashboardAcl{
+			OrgID:       i.orgID,
+			DashboardID: i.dashboardIDs[p.DashboardID],
+			Role:        p.Role,
+			Permission:  p.Permission,
+			Created:     i.now,
+			Updated:     i.now,
+		}
+		if item.DashboardID == 0 {
+			continue
+		}
+		if p.UserID != 0 {
+			if item.UserID = i.userID(p.UserID); item.UserID == 0 {
+				continue
+			}
+		}
+		if p.TeamID != 0 {
+			if item.TeamID = i.teamIDs[p.TeamID]; item.TeamID == 0 {
+				continue
+			}
+		}
+
+		if _, err := i.sess.Insert(&item); err != nil {
+			return errutil.Wrap("failed to create permission", err)
+		}
+	}
+	return nil
+}
+
+func (i *importer) importDataSources(archive *Archive) error {
+	for _, ds := range archive.DataSources {
+		var jsonData *simplejson.Json
+		if len(ds.JSONData) > 0 {
+			data, err := simplejson.NewJson(ds.JSONData)
+			if err != nil {
+				return errutil.Wrapf(err, "invalid JSON data for data source %q", ds.Name)
+			}
+			jsonData = data
+		}
+
+		if _, err := i.sess.Insert(&models.DataSource{
+			OrgId:             i.orgID,
+			Version:           ds.Version,
+			Name:              ds.Name,
+			Type:              ds.Type,
+			Access:            ds.Access,
+			Url:               ds.URL,
+			Password:          ds.Password,
+			User:              ds.User,
+			Database:          ds.Database,
+			BasicAuth:         ds.BasicAuth,
+			BasicAuthUser:     ds.BasicAuthUser,
+			BasicAuthPassword: ds.BasicAuthPassword,
+			WithCredentials:   ds.WithCredentials,
+			IsDefault:         ds.IsDefault,
+			JsonData:          jsonData,
+			SecureJsonData:    securejsondata.SecureJsonData(ds.SecureJSONData),
+			ReadOnly:          ds.ReadOnly,
+			Uid:               ds.UID,
+			Created:           i.now,
+			Updated:           i.now,
+		}); err != nil {
+			return errutil.Wrapf(err, "failed to create data source %q", ds.Name)
+		}
+	}
+	return nil
+}
+
+func (i *importer) importLibraryPanels(archive *Archive) error {
+	if len(archive.LibraryPanels) == 0 {
+		return nil
+	}
+	exists, err := i.sess.IsTableExist("library_panel")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		i.result.SkippedLibraryPanels = len(archive.LibraryPanels)
+		return nil
+	}
+	hasVersions, err := i.sess.IsTableExist("library_panel_version")
+	if err != nil {
+		return err
+	}
+
+	for _, p := range archive.LibraryPanels {
+		panel := libraryPanel{
+			OrgID:       i.orgID,
+			FolderID:    i.dashboardIDs[p.FolderID],
+			UID:         p.UID,
+			Name:        p.Name,
+			Type:        p.Type,
+			Description: p.Description,
+			Model:       p.Model,
+			Version:     p.Version,
+			Created:     p.Created,
+			Updated:     p.Updated,
+			CreatedBy:   i.userID(p.CreatedBy),
+			UpdatedBy:   i.userID(p.UpdatedBy),
+		}
+		if _, err := i.sess.Insert(&panel); err != nil {
+			return errutil.Wrapf(err, "failed to create library panel %q", p.Name)
+		}
+
+		for _, dashboardID := range p.DashboardIDs {
+			if i.dashboardIDs[dashboardID] == 0 {
+				continue
+			}
+			if _, err := i.sess.Insert(&libraryPanelDashboard{
+				LibraryPanelID: panel.ID,
+				DashboardID:    i.dashboardIDs[dashboardID],
+				Created:        i.now,
+				CreatedBy:      panel.UpdatedBy,
+			}); err != nil {
+				return errutil.Wrapf(err, "failed to connect library panel %q", p.Name)
+			}
+		}
+
+		if !hasVersions {
+			continue
+		}
+		versions := p.Versions
+		if len(versions) == 0 {
+			// same as the versions created by the library panel migration
+			versions = []LibraryPanelVersion{{
+				ParentVersion: p.Version,
+				RestoredFrom:  p.Version,
+				Version:       p.Version,
+				FolderID:      p.FolderID,
+				Name:          p.Name,
+				Model:         p.Model,
+				Created:       p.Updated,
+				CreatedBy:     p.UpdatedBy,
+			}}
+		}
+		for _, v := range versions {
+			if _, err := i.sess.Insert(&libraryPanelVersion{
+				LibraryPanelID: panel.ID,
+				ParentVersion:  v.ParentVersion,
+				RestoredFrom:   v.RestoredFrom,
+				Version:        v.Version,
+				FolderID:       i.dashboardIDs[v.FolderID],
+				Name:           v.Name,
+				Model:          v.Model,
+				Created:        v.Created,
+				CreatedBy:      i.userID(v.CreatedBy),
+			}); err != nil {
+				return errutil.Wrapf(err, "failed to create version %d of library panel %q", v.Version, p.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (i *importer) importPlaylists(archive *Archive) error {
+	for _, p := range archive.Playlists {
+		playlist := models.Playlist{
+			Name:     p.Name,
+			Interval: p.Interval,
+			OrgId:    i.orgID,
+		}
+		if _, err := i.sess.Insert(&playlist); err != nil {
+			return errutil.Wrapf(err, "failed to create playlist %q", p.Name)
+		}
+
+		for _, item := range p.Items {
+			value := item.Value
+			if item.Type == "dashboard_by_id" {
+				id, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || i.dashboardIDs[id] == 0 {
+					continue
+				}
+				value = strconv.FormatInt(i.dashboardIDs[id], 10)
+			}
+			if _, err := i.sess.Insert(&models.PlaylistItem{
+				PlaylistId: playlist.Id,
+				Type:       item.Type,
+				Value:      value,
+				Order:      item.Order,
+				Title:      item.Title,
+			}); err != nil {
+				return errutil.Wrapf(err, "failed to create items of playlist %q", p.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (i *importer) importPreferences(archive *Archive) error {
+	for _, p := range archive.Preferences {
+		pref := models.Preferences{
+			OrgId:           i.orgID,
+			HomeDashboardId: i.dashboardIDs[p.HomeDashboardID],
+			Timezone:        p.Timezone,
+			Theme:           p.Theme,
+			Created:         i.now,
+			Updated:         i.now,
+		}
+		if p.UserID != 0 {
+			if pref.UserId = i.userID(p.UserID); pref.UserId == 0 {
+				continue
+			}
+		}
+		if p.TeamID != 0 {
+			if pref.TeamId = i.teamIDs[p.TeamID]; pref.TeamId == 0 {
+				continue
+			}
+		}
+
+		if _, err := i.sess.Insert(&pref); err != nil {
+			return errutil.Wrap("failed to create preferences", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package orgmigrations
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportOrg(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+
+	user, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{
+		Login: "editor",
+		Email: "editor@example.org",
+	})
+	require.NoError(t, err)
+	org, err := sqlStore.CreateOrgWithMember("exported", user.Id)
+	require.NoError(t, err)
+
+	team, err := sqlStore.CreateTeam("team", "", org.Id)
+	require.NoError(t, err)
+	err = sqlStore.AddTeamMember(user.Id, org.Id, team.Id, false, models.PERMISSION_ADMIN)
+	require.NoError(t, err)
+	err = sqlstore.AddTeamGroup(&models.AddTeamGroupCommand{OrgId: org.Id, TeamId: team.Id, GroupId: "cn=editors"})
+	require.NoError(t, err)
+
+	folder, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:    org.Id,
+		IsFolder: true,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "folder",
+		}),
+	})
+	require.NoError(t, err)
+	dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:    org.Id,
+		FolderId: folder.Id,
+		UserId:   user.Id,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "dashboard",
+			"tags":  []interface{}{"exported"},
+		}),
+	})
+	require.NoError(t, err)
+
+	err = sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(&models.DashboardAcl{
+			OrgID:       org.Id,
+			DashboardID: folder.Id,
+			TeamID:      team.Id,
+			Permission:  models.PERMISSION_EDIT,
+			Created:     folder.Created,
+			Updated:     folder.Updated,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	err = sqlstore.AddDataSource(&models.AddDataSourceCommand{
+		OrgId:          org.Id,
+		Name:           "prometheus",
+		Type:           "prometheus",
+		Access:         models.DS_ACCESS_PROXY,
+		SecureJsonData: map[string]string{"password": "secret"},
+	})
+	require.NoError(t, err)
+
+	var archive *Archive
+	err = sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		archive, err = exportOrg(sess, &org, "target-secret-key")
+		return err
+	})
+	require.NoError(t, err)
+
+	t.Run("archive can be read back", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteArchive(&buf, archive))
+		read, err := ReadArchive(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, archive.Org, read.Org)
+		assert.Len(t, read.Dashboards, 2)
+	})
+
+	t.Run("secrets are encrypted with the target secret key", func(t *testing.T) {
+		require.Len(t, archive.DataSources, 1)
+		decrypted, err := util.Decrypt(archive.DataSources[0].SecureJSONData["password"], "target-secret-key")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(decrypted))
+	})
+
+	t.Run("cannot import with the name of an existing org", func(t *testing.T) {
+		err := sqlStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			_, err := importOrg(sess, archive, "exported", false)
+			return err
+		})
+		require.Equal(t, models.ErrOrgNameTaken, err)
+	})
+
+	var result *ImportResult
+	err = sqlStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		result, err = importOrg(sess, archive, "imported", false)
+		return err
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, org.Id, result.OrgID)
+	assert.Empty(t, result.SkippedUsers)
+
+	t.Run("dashboards are imported with mapped IDs", func(t *testing.T) {
+		query := models.GetDashboardQuery{OrgId: result.OrgID, Uid: dash.Uid}
+		require.NoError(t, sqlstore.GetDashboard(&query))
+		assert.NotEqual(t, dash.Id, query.Result.Id)
+		assert.Equal(t, query.Result.Id, query.Result.Data.Get("id").MustInt64())
+		assert.Equal(t, user.Id, query.Result.CreatedBy)
+
+		folderQuery := models.GetDashboardQuery{OrgId: result.OrgID, Uid: folder.Uid}
+		require.NoError(t, sqlstore.GetDashboard(&folderQuery))
+		assert.Equal(t, folderQuery.Result.Id, query.Result.FolderId)
+
+		versionsQuery := models.GetDashboardVersionsQuery{OrgId: result.OrgID, DashboardId: query.Result.Id}
+		require.NoError(t, sqlstore.GetDashboardVersions(&versionsQuery))
+		assert.Len(t, versionsQuery.Result, 1)
+	})
+
+	t.Run("members and teams are imported", func(t *testing.T) {
+		usersQuery := models.GetOrgUsersQuery{OrgId: result.OrgID}
+		require.NoError(t, sqlstore.GetOrgUsers(&usersQuery))
+		require.Len(t, usersQuery.Result, 1)
+		assert.Equal(t, user.Id, usersQuery.Result[0].UserId)
+
+		teamsQuery := models.GetTeamsByUserQuery{OrgId: result.OrgID, UserId: user.Id}
+		require.NoError(t, sqlstore.GetTeamsByUser(&teamsQuery))
+		require.Len(t, teamsQuery.Result, 1)
+		assert.NotEqual(t, team.Id, teamsQuery.Result[0].Id)
+
+		groupsQuery := models.GetTeamGroupsQuery{OrgId: result.OrgID, TeamId: teamsQuery.Result[0].Id}
+		require.NoError(t, sqlstore.GetTeamGroups(&groupsQuery))
+		require.Len(t, groupsQuery.Result, 1)
+		assert.Equal(t, "cn=editors", groupsQuery.Result[0].GroupId)
+
+		folderQuery := models.GetDashboardQuery{OrgId: result.OrgID, Uid: folder.Uid}
+		require.NoError(t, sqlstore.GetDashboard(&folderQuery))
+		aclQuery := models.GetDashboardAclInfoListQuery{OrgID: result.OrgID, DashboardID: folderQuery.Result.Id}
+		require.NoError(t, sqlstore.GetDashboardAclInfoList(&aclQuery))
+		var teamIDs []int64
+		for _, item := range aclQuery.Result {
+			teamIDs = append(teamIDs, item.TeamId)
+		}
+		assert.Contains(t, teamIDs, teamsQuery.Result[0].Id)
+	})
+
+	t.Run("data sources are imported", func(t *testing.T) {
+		query := models.GetDataSourceQuery{OrgId: result.OrgID, Name: "prometheus"}
+		require.NoError(t, sqlstore.GetDataSource(&query))
+		assert.Equal(t, archive.DataSources[0].SecureJSONData["password"], query.Result.SecureJsonData["password"])
+	})
+
+	t.Run("users are matched by email only when enabled", func(t *testing.T) {
+		renamed := *archive
+		renamed.Users = append(renamed.Users[:0:0], renamed.Users...)
+		renamed.Users[0].Login = "renamed"
+
+		var result *ImportResult
+		err := sqlStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			result, err = importOrg(sess, &renamed, "by login", false)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"renamed"}, result.SkippedUsers)
+
+		err = sqlStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			result, err = importOrg(sess, &renamed, "by email", true)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.SkippedUsers)
+		usersQuery := models.GetOrgUsersQuery{OrgId: result.OrgID}
+		require.NoError(t, sqlstore.GetOrgUsers(&usersQuery))
+		require.Len(t, usersQuery.Result, 1)
+		assert.Equal(t, user.Id, usersQuery.Result[0].UserId)
+	})
+}
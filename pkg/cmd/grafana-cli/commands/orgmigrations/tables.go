@@ -0,0 +1,52 @@
+package orgmigrations
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The library panel tables are owned by the librarypanels service, these are the columns
+// needed to copy their rows.
+
+type libraryPanel struct {
+	ID          int64  `xorm:"pk autoincr 'id'"`
+	OrgID       int64  `xorm:"org_id"`
+	FolderID    int64  `xorm:"folder_id"`
+	UID         string `xorm:"uid"`
+	Name        string
+	Type        string
+	Description string
+	Model       json.RawMessage
+	Version     int64
+
+	Created time.Time
+	Updated time.Time
+
+	CreatedBy int64
+	UpdatedBy int64
+}
+
+type libraryPanelDashboard struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	LibraryPanelID int64 `xorm:"librarypanel_id"`
+	DashboardID    int64 `xorm:"dashboard_id"`
+
+	Created time.Time
+
+	CreatedBy int64
+}
+
+type libraryPanelVersion struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	LibraryPanelID int64 `xorm:"librarypanel_id"`
+	ParentVersion  int64
+	RestoredFrom   int64
+	Version        int64
+	FolderID       int64 `xorm:"folder_id"`
+	Name           string
+	Model          json.RawMessage
+
+	Created time.Time
+
+	CreatedBy int64
+}
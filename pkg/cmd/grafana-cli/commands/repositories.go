@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// pluginRepositories returns the plugin repositories in the order they're queried. These are the
+// repositories of the Grafana configuration when it's given with --config or --homepath, and the
+// --repo repository otherwise.
+func pluginRepositories(c utils.CommandLine) ([]models.Repository, error) {
+	if c.IsSet("repo") || (c.String("config") == "" && c.String("homepath") == "") {
+		headers, err := parseRepoHeaders(c.StringSlice("repoHeader"))
+		if err != nil {
+			return nil, err
+		}
+
+		name := setting.GrafanaComPluginRepositoryName
+		if c.IsSet("repo") {
+			name = c.RepoDirectory()
+		}
+		return []models.Repository{
+			{Name: name, URL: strings.TrimSuffix(c.RepoDirectory(), "/"), Headers: headers},
+		}, nil
+	}
+
+	cfg := setting.NewCfg()
+	if err := cfg.Load(&setting.CommandLineArgs{
+		Config:   c.String("config"),
+		HomePath: c.String("homepath"),
+		Args:     strings.Split(c.String("configOverrides"), " "),
+	}); err != nil {
+		return nil, errutil.Wrap("failed to load configuration", err)
+	}
+
+	repos := make([]models.Repository, 0, len(cfg.PluginRepositories))
+	for _, repo := range cfg.PluginRepositories {
+		repos = append(repos, models.Repository{Name: repo.Name, URL: repo.URL, Headers: repo.Headers})
+	}
+	if len(repos) == 0 {
+		return nil, errors.New("no plugin repository is configured")
+	}
+	return repos, nil
+}
+
+// parseRepoHeaders parses the headers of the --repoHeader flags, formatted as "Name: value".
+func parseRepoHeaders(values []string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid repository header %q, expected \"Name: value\"", value)
+		}
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
+
+// getPlugin gets a plugin from the first repository knowing it. The lower priority repositories aren't
+// queried when a repository fails, since they could provide another plugin with the same ID.
+func getPlugin(client utils.ApiClient, repos []models.Repository, pluginID string) (models.Plugin, models.Repository, error) {
+	for i, repo := range repos {
+		plugin, err := client.GetPlugin(pluginID, repo)
+		if errors.Is(err, services.ErrNotFoundError) && i < len(repos)-1 {
+			logger.Debugf("plugin %s not found in repository %s\n", pluginID, repo.Name)
+			continue
+		}
+		if err != nil && len(repos) > 1 {
+			err = errutil.Wrapf(err, "plugin repository %q", repo.Name)
+		}
+		return plugin, repo, err
+	}
+
+	return models.Plugin{}, models.Repository{}, errors.New("no plugin repository is configured")
+}
+
+// listAllPlugins lists the plugins of the repositories. A plugin is listed from the first repository
+// knowing it.
+func listAllPlugins(client utils.ApiClient, repos []models.Repository) (models.PluginRepo, error) {
+	var result models.PluginRepo
+	listed := map[string]bool{}
+	for _, repo := range repos {
+		repoPlugins, err := client.ListAllPlugins(repo)
+		if err != nil {
+			return models.PluginRepo{}, err
+		}
+
+		for _, plugin := range repoPlugins.Plugins {
+			if listed[plugin.ID] {
+				continue
+			}
+			listed[plugin.ID] = true
+			result.Plugins = append(result.Plugins, plugin)
+		}
+	}
+
+	return result, nil
+}
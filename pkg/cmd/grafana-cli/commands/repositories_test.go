@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/commandstest"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginRepositories(t *testing.T) {
+	t.Run("Should use the repo flag", func(t *testing.T) {
+		c, err := commandstest.NewCliContext(map[string]string{"repo": "https://plugins.example.com/api/plugins/"})
+		require.NoError(t, err)
+
+		repos, err := pluginRepositories(c)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Repository{
+			{
+				Name: "https://plugins.example.com/api/plugins/", URL: "https://plugins.example.com/api/plugins",
+				Headers: map[string]string{},
+			},
+		}, repos)
+	})
+
+	t.Run("Should use the repositories of the configuration", func(t *testing.T) {
+		origGrafanaComURL := setting.GrafanaComUrl
+		t.Cleanup(func() {
+			setting.GrafanaComUrl = origGrafanaComURL
+		})
+
+		configFile := filepath.Join(t.TempDir(), "grafana.ini")
+		err := ioutil.WriteFile(configFile, []byte(`
+[paths]
+data = `+t.TempDir()+`
+logs = `+t.TempDir()+`
+
+[plugin_repository.internal]
+url = https://plugins.example.com/api/plugins
+priority = 10
+header.Authorization = Bearer token
+`), 0600)
+		require.NoError(t, err)
+		homePath, err := filepath.Abs("../../../..")
+		require.NoError(t, err)
+		c, err := commandstest.NewCliContext(map[string]string{"config": configFile, "homepath": homePath})
+		require.NoError(t, err)
+
+		repos, err := pluginRepositories(c)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Repository{
+			{
+				Name: "internal", URL: "https://plugins.example.com/api/plugins",
+				Headers: map[string]string{"Authorization": "Bearer token"},
+			},
+			{Name: "grafana.com", URL: "https://grafana.com/api/plugins", Headers: map[string]string{}},
+		}, repos)
+	})
+}
+
+func TestParseRepoHeaders(t *testing.T) {
+	headers, err := parseRepoHeaders([]string{"Authorization: Bearer token", "X-Org:1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token", "X-Org": "1"}, headers)
+
+	_, err = parseRepoHeaders([]string{"Authorization"})
+	require.Error(t, err)
+}
+
+func TestGetPluginFromRepositories(t *testing.T) {
+	services.Init("test", false)
+
+	newRepository := func(t *testing.T, mux *http.ServeMux) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			mux.ServeHTTP(w, r)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	internal := newRepository(t, http.NewServeMux())
+	publicMux := http.NewServeMux()
+	publicMux.HandleFunc("/api/plugins/repo/test-plugin-panel", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"id": "test-plugin-panel", "versions": [{"version": "1.0.0"}]}`)
+	})
+	publicMux.HandleFunc("/api/plugins/test-plugin-panel/versions/1.0.0/download", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/grafana-simple-json-datasource-ec18fa4da8096a952608a7e4c7782b4260b41bcf.zip")
+	})
+	public := newRepository(t, publicMux)
+
+	repos := []models.Repository{
+		{Name: "internal", URL: internal.URL + "/api/plugins", Headers: map[string]string{"Authorization": "Bearer token"}},
+		{Name: "public", URL: public.URL + "/api/plugins", Headers: map[string]string{"Authorization": "Bearer token"}},
+	}
+
+	plugin, repo, err := getPlugin(&services.GrafanaComClient{}, repos, "test-plugin-panel")
+	require.NoError(t, err)
+	assert.Equal(t, "public", repo.Name)
+	assert.Equal(t, "test-plugin-panel", plugin.ID)
+
+	tmpFile, err := ioutil.TempFile(t.TempDir(), "*.zip")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, tmpFile.Close())
+	})
+	err = (&services.GrafanaComClient{}).DownloadFile("test-plugin-panel", tmpFile,
+		repo.URL+"/test-plugin-panel/versions/1.0.0/download", "", repo.Headers)
+	require.NoError(t, err)
+	info, err := tmpFile.Stat()
+	require.NoError(t, err)
+	assert.NotZero(t, info.Size())
+
+	_, _, err = getPlugin(&services.GrafanaComClient{}, repos, "unknown")
+	require.Error(t, err)
+
+	repos[0].Headers = nil
+	_, _, err = getPlugin(&services.GrafanaComClient{}, repos, "test-plugin-panel")
+	require.EqualError(t, err, `plugin repository "internal": Failed to send request: 401 Unauthorized`)
+}
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"sort"
+
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
@@ -9,17 +11,46 @@ import (
 )
 
 func shouldUpgrade(installed string, remote *models.Plugin) bool {
+	latest := latestSupportedVersion(remote)
+	if latest == nil {
+		return false
+	}
+	return isNewerVersion(latest.Version, installed)
+}
+
+// isNewerVersion returns whether a version is newer than the installed one.
+func isNewerVersion(v, installed string) bool {
 	installedVersion, err := version.NewVersion(installed)
 	if err != nil {
 		return false
 	}
 
-	latest := latestSupportedVersion(remote)
-	latestVersion, err := version.NewVersion(latest.Version)
+	newVersion, err := version.NewVersion(v)
 	if err != nil {
 		return false
 	}
-	return installedVersion.LessThan(latestVersion)
+	return installedVersion.LessThan(newVersion)
+}
+
+// resolveUpdate resolves the update of an installed plugin to the latest version supporting the Grafana
+// version, returning whether there's a newer one.
+func resolveUpdate(r *dependencyResolver, localPlugin models.InstalledPlugin, remotePlugin *models.Plugin) (bool, error) {
+	if !shouldUpgrade(localPlugin.Info.Version, remotePlugin) {
+		return false, nil
+	}
+
+	v, err := r.selectVersion(localPlugin.ID, remotePlugin, "")
+	if err != nil {
+		logger.Warnf("%s can't be updated: %v\n", localPlugin.ID, err)
+		return false, nil
+	}
+	if !isNewerVersion(v.Version, localPlugin.Info.Version) {
+		logger.Infof("%s has newer versions, which don't support this Grafana version\n", localPlugin.ID)
+		return false, nil
+	}
+
+	logger.Infof("Updating %v \n", localPlugin.ID)
+	return true, r.resolve(localPlugin.ID, v.Version)
 }
 
 func (cmd Command) upgradeAllCommand(c utils.CommandLine) error {
@@ -27,12 +58,33 @@ func (cmd Command) upgradeAllCommand(c utils.CommandLine) error {
 
 	localPlugins := services.GetLocalPlugins(pluginsDir)
 
-	remotePlugins, err := cmd.Client.ListAllPlugins(c.String("repo"))
+	lock, err := readPluginLockFile(c)
+	if err != nil {
+		return err
+	}
+
+	r, err := newDependencyResolver(c, cmd.Client, lock)
+	if err != nil {
+		return err
+	}
+	defer r.cleanup()
+
+	if lock != nil && lock.exists {
+		if err := resolveLockedPlugins(r, lock, localPlugins); err != nil {
+			return err
+		}
+		return r.install()
+	}
+
+	repos, err := r.repositories()
 	if err != nil {
 		return err
 	}
 
-	pluginsToUpgrade := make([]models.InstalledPlugin, 0)
+	remotePlugins, err := listAllPlugins(cmd.Client, repos)
+	if err != nil {
+		return err
+	}
 
 	for _, localPlugin := range localPlugins {
 		for _, p := range remotePlugins.Plugins {
@@ -40,25 +92,63 @@ func (cmd Command) upgradeAllCommand(c utils.CommandLine) error {
 			if localPlugin.ID != remotePlugin.ID {
 				continue
 			}
-			if shouldUpgrade(localPlugin.Info.Version, &remotePlugin) {
-				pluginsToUpgrade = append(pluginsToUpgrade, localPlugin)
+
+			updated, err := resolveUpdate(r, localPlugin, &remotePlugin)
+			if err != nil {
+				return err
+			}
+			if !updated && lock != nil {
+				lockInstalledPlugin(lock, localPlugin, &remotePlugin)
 			}
 		}
 	}
 
-	for _, p := range pluginsToUpgrade {
-		logger.Infof("Updating %v \n", p.ID)
+	return r.install()
+}
 
-		err := services.RemoveInstalledPlugin(pluginsDir, p.ID)
-		if err != nil {
-			return err
+// resolveLockedPlugins resolves the locked versions of the plugins which are missing or installed with
+// another version. The plugins which aren't locked are left as they are.
+func resolveLockedPlugins(r *dependencyResolver, lock *pluginLockFile, localPlugins []models.InstalledPlugin) error {
+	installed := map[string]string{}
+	for _, localPlugin := range localPlugins {
+		installed[localPlugin.ID] = localPlugin.Info.Version
+		if _, locked := lock.Plugins[localPlugin.ID]; !locked {
+			logger.Infof("%s isn't in the lock file, skipping\n", localPlugin.ID)
 		}
+	}
+
+	pluginIDs := make([]string, 0, len(lock.Plugins))
+	for pluginID := range lock.Plugins {
+		pluginIDs = append(pluginIDs, pluginID)
+	}
+	sort.Strings(pluginIDs)
 
-		err = InstallPlugin(p.ID, "", c, cmd.Client)
-		if err != nil {
+	for _, pluginID := range pluginIDs {
+		locked := lock.Plugins[pluginID]
+		if installed[pluginID] == locked.Version {
+			continue
+		}
+
+		logger.Infof("Updating %v to locked version %v \n", pluginID, locked.Version)
+		if err := r.resolve(pluginID, locked.Version); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
+
+// lockInstalledPlugin adds an installed plugin to the lock file, with the checksums of the version if the
+// repository knows it.
+func lockInstalledPlugin(lock *pluginLockFile, localPlugin models.InstalledPlugin, remotePlugin *models.Plugin) {
+	locked := lockedPlugin{Version: localPlugin.Info.Version}
+	for _, v := range remotePlugin.Versions {
+		if v.Version != locked.Version || v.Arch == nil {
+			continue
+		}
+		locked.Checksums = map[string]string{}
+		for arch, meta := range v.Arch {
+			locked.Checksums[arch] = meta.SHA256
+		}
+	}
+	lock.Plugins[localPlugin.ID] = locked
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
-	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
 func (cmd Command) upgradeCommand(c utils.CommandLine) error {
@@ -18,17 +17,33 @@ func (cmd Command) upgradeCommand(c utils.CommandLine) error {
 		return err
 	}
 
-	plugin, err2 := cmd.Client.GetPlugin(pluginName, c.RepoDirectory())
-	if err2 != nil {
-		return err2
+	lock, err := readPluginLockFile(c)
+	if err != nil {
+		return err
+	}
+
+	r, err := newDependencyResolver(c, cmd.Client, lock)
+	if err != nil {
+		return err
+	}
+	defer r.cleanup()
+
+	repos, err := r.repositories()
+	if err != nil {
+		return err
 	}
 
-	if shouldUpgrade(localPlugin.Info.Version, &plugin) {
-		if err := services.RemoveInstalledPlugin(pluginsDir, pluginName); err != nil {
-			return errutil.Wrapf(err, "failed to remove plugin '%s'", pluginName)
-		}
+	plugin, _, err := getPlugin(cmd.Client, repos, pluginName)
+	if err != nil {
+		return err
+	}
 
-		return InstallPlugin(pluginName, "", c, cmd.Client)
+	updated, err := resolveUpdate(r, localPlugin, &plugin)
+	if err != nil {
+		return err
+	}
+	if updated {
+		return r.install()
 	}
 
 	logger.Infof("%s %s is up to date \n", color.GreenString("✔"), pluginName)
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// versionConstraint is a range of plugin or Grafana versions, in the formats used by plugin.json: "7.x.x",
+// "^7.2.0", "~1.2.0", ">=7.0.0 <8.0.0" and alternatives separated by "||". A single version like "1.0.0"
+// is the minimum version. An empty constraint, "*" or "x" allows every version.
+type versionConstraint struct {
+	raw          string
+	alternatives []version.Constraints
+}
+
+func parseVersionConstraint(raw string) (versionConstraint, error) {
+	vc := versionConstraint{raw: strings.TrimSpace(raw)}
+	for _, alternative := range strings.Split(vc.raw, "||") {
+		var constraints []string
+		for _, term := range strings.Fields(strings.ReplaceAll(alternative, ",", " ")) {
+			converted, err := convertVersionTerm(term)
+			if err != nil {
+				return versionConstraint{}, fmt.Errorf("invalid version constraint %q: %w", raw, err)
+			}
+			constraints = append(constraints, converted...)
+		}
+		if len(constraints) == 0 {
+			// an empty alternative allows every version
+			return versionConstraint{raw: vc.raw}, nil
+		}
+
+		c, err := version.NewConstraint(strings.Join(constraints, ","))
+		if err != nil {
+			return versionConstraint{}, fmt.Errorf("invalid version constraint %q: %w", raw, err)
+		}
+		vc.alternatives = append(vc.alternatives, c)
+	}
+
+	return vc, nil
+}
+
+// convertVersionTerm converts a term of a constraint to the constraints of go-version.
+func convertVersionTerm(term string) ([]string, error) {
+	switch {
+	case strings.HasPrefix(term, "^"):
+		parts, err := versionParts(term[1:])
+		if err != nil {
+			return nil, err
+		}
+		// the caret allows changes below the leftmost non-zero number
+		var upper string
+		switch {
+		case parts[0] > 0:
+			upper = fmt.Sprintf("%d.0.0", parts[0]+1)
+		case parts[1] > 0:
+			upper = fmt.Sprintf("0.%d.0", parts[1]+1)
+		default:
+			upper = fmt.Sprintf("0.0.%d", parts[2]+1)
+		}
+		return []string{">=" + term[1:], "<" + upper}, nil
+	case strings.HasPrefix(term, "~") && !strings.HasPrefix(term, "~>"):
+		parts, err := versionParts(term[1:])
+		if err != nil {
+			return nil, err
+		}
+		return []string{">=" + term[1:], fmt.Sprintf("<%d.%d.0", parts[0], parts[1]+1)}, nil
+	case strings.ContainsAny(term[:1], "<>=!~"):
+		return []string{term}, nil
+	}
+
+	if !strings.ContainsAny(term, "xX*") {
+		// a single version is the minimum version
+		if _, err := version.NewVersion(term); err != nil {
+			return nil, err
+		}
+		return []string{">=" + term}, nil
+	}
+
+	// wildcards like 7.x.x only constrain the leading numbers
+	var fixed []int
+	for _, part := range strings.Split(term, ".") {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", term)
+		}
+		fixed = append(fixed, n)
+	}
+
+	switch len(fixed) {
+	case 0:
+		return nil, nil
+	case 1:
+		return []string{fmt.Sprintf(">=%d.0.0", fixed[0]), fmt.Sprintf("<%d.0.0", fixed[0]+1)}, nil
+	default:
+		return []string{fmt.Sprintf(">=%d.%d.0", fixed[0], fixed[1]), fmt.Sprintf("<%d.%d.0", fixed[0], fixed[1]+1)}, nil
+	}
+}
+
+// versionParts returns the major, minor and patch numbers of a version.
+func versionParts(raw string) ([3]int, error) {
+	v, err := version.NewVersion(raw)
+	if err != nil {
+		return [3]int{}, err
+	}
+	segments := v.Segments()
+	return [3]int{segments[0], segments[1], segments[2]}, nil
+}
+
+// check returns whether a version satisfies the constraint.
+func (vc versionConstraint) check(v *version.Version) bool {
+	if len(vc.alternatives) == 0 {
+		return true
+	}
+	for _, c := range vc.alternatives {
+		if c.Check(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkString returns whether a version satisfies the constraint. Invalid versions don't satisfy
+// constraints, except an empty one.
+func (vc versionConstraint) checkString(raw string) bool {
+	if len(vc.alternatives) == 0 {
+		return true
+	}
+	v, err := version.NewVersion(raw)
+	if err != nil {
+		return false
+	}
+	return vc.check(v)
+}
+
+func (vc versionConstraint) String() string {
+	if vc.raw == "" {
+		return "*"
+	}
+	return vc.raw
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionConstraint(t *testing.T) {
+	for _, tc := range []struct {
+		constraint string
+		matching   []string
+		other      []string
+	}{
+		{constraint: "", matching: []string{"0.1.0", "8.0.0"}},
+		{constraint: "*", matching: []string{"0.1.0", "8.0.0"}},
+		{constraint: "7.x.x", matching: []string{"7.0.0", "7.5.2"}, other: []string{"6.7.0", "8.0.0"}},
+		{constraint: "7.2.x", matching: []string{"7.2.0", "7.2.9"}, other: []string{"7.1.0", "7.3.0"}},
+		{constraint: "1.2.0", matching: []string{"1.2.0", "2.0.0"}, other: []string{"1.1.9"}},
+		{constraint: "^1.2.0", matching: []string{"1.2.0", "1.9.0"}, other: []string{"1.1.0", "2.0.0"}},
+		{constraint: "^0.2.1", matching: []string{"0.2.1", "0.2.9"}, other: []string{"0.3.0"}},
+		{constraint: "^0.0.3", matching: []string{"0.0.3"}, other: []string{"0.0.2", "0.0.4", "0.1.0"}},
+		{constraint: "~1.2.0", matching: []string{"1.2.0", "1.2.5"}, other: []string{"1.3.0"}},
+		{constraint: ">=7.0.0 <7.5.0", matching: []string{"7.0.0", "7.4.9"}, other: []string{"6.0.0", "7.5.0"}},
+		{constraint: ">=7.0.0, <7.5.0", matching: []string{"7.4.0"}, other: []string{"7.5.0"}},
+		{constraint: "6.x.x || >=7.4.0", matching: []string{"6.7.0", "7.4.0"}, other: []string{"7.0.0"}},
+	} {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := parseVersionConstraint(tc.constraint)
+			require.NoError(t, err)
+			for _, v := range tc.matching {
+				assert.True(t, c.checkString(v), v)
+			}
+			for _, v := range tc.other {
+				assert.False(t, c.checkString(v), v)
+			}
+		})
+	}
+
+	t.Run("Should return error for invalid constraint", func(t *testing.T) {
+		_, err := parseVersionConstraint("7.a.x")
+		require.Error(t, err)
+		_, err = parseVersionConstraint("latest")
+		require.Error(t, err)
+	})
+}
+
+func TestConvertVersionTerm(t *testing.T) {
+	for _, tc := range []struct {
+		term     string
+		expected []string
+	}{
+		{term: "^1.2.3", expected: []string{">=1.2.3", "<2.0.0"}},
+		{term: "^0.2.3", expected: []string{">=0.2.3", "<0.3.0"}},
+		{term: "^0.0.3", expected: []string{">=0.0.3", "<0.0.4"}},
+		{term: "^0.0.0", expected: []string{">=0.0.0", "<0.0.1"}},
+		{term: "~1.2.3", expected: []string{">=1.2.3", "<1.3.0"}},
+		{term: "7.x.x", expected: []string{">=7.0.0", "<8.0.0"}},
+		{term: "7.2.x", expected: []string{">=7.2.0", "<7.3.0"}},
+		{term: "1.2.0", expected: []string{">=1.2.0"}},
+		{term: "<8.0.0", expected: []string{"<8.0.0"}},
+		{term: "x", expected: nil},
+	} {
+		t.Run(tc.term, func(t *testing.T) {
+			converted, err := convertVersionTerm(tc.term)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted)
+		})
+	}
+}
@@ -40,6 +40,15 @@ func main() {
 				Value:   "https://grafana.com/api/plugins",
 				EnvVars: []string{"GF_PLUGIN_REPO"},
 			},
+			&cli.StringSliceFlag{
+				Name:  "repoHeader",
+				Usage: "HTTP header sent to the plugin repository, e.g. 'Authorization: Bearer <token>'",
+			},
+			&cli.StringFlag{
+				Name:    "lockFile",
+				Usage:   "Path to a lock file pinning the versions of the plugins, which is created if it doesn't exist",
+				EnvVars: []string{"GF_PLUGIN_LOCK_FILE"},
+			},
 			&cli.StringFlag{
 				Name:    "pluginUrl",
 				Usage:   "Full url to the plugin zip file instead of downloading the plugin from grafana.com/api",
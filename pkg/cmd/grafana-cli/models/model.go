@@ -14,8 +14,20 @@ type InstalledPlugin struct {
 }
 
 type Dependencies struct {
-	GrafanaVersion string   `json:"grafanaVersion"`
-	Plugins        []Plugin `json:"plugins"`
+	// GrafanaDependency is the semver range of the supported Grafana versions, e.g. ">=7.3.0". It supersedes
+	// GrafanaVersion, e.g. "7.x.x".
+	GrafanaDependency string             `json:"grafanaDependency"`
+	GrafanaVersion    string             `json:"grafanaVersion"`
+	Plugins           []PluginDependency `json:"plugins"`
+}
+
+// PluginDependency is a plugin required by another plugin.
+type PluginDependency struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Version is the version range of the plugin, e.g. "^1.2.0". A single version is the minimum version.
+	Version string `json:"version"`
 }
 
 type PluginInfo struct {
@@ -33,6 +45,8 @@ type Version struct {
 	Commit  string `json:"commit"`
 	URL     string `json:"url"`
 	Version string `json:"version"`
+	// GrafanaDependency is the semver range of the Grafana versions supported by the version, if known.
+	GrafanaDependency string `json:"grafanaDependency"`
 	// Arch contains architecture metadata.
 	Arch map[string]ArchMeta `json:"arch"`
 }
@@ -46,6 +60,14 @@ type PluginRepo struct {
 	Version string   `json:"version"`
 }
 
+// Repository is a plugin repository implementing the plugin API of grafana.com.
+type Repository struct {
+	Name string
+	URL  string
+	// Headers are sent with every request to the repository, e.g. for authentication.
+	Headers map[string]string
+}
+
 type IoUtil interface {
 	Stat(path string) (os.FileInfo, error)
 	RemoveAll(path string) error
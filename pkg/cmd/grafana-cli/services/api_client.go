@@ -23,9 +23,9 @@ type GrafanaComClient struct {
 	retryCount int
 }
 
-func (client *GrafanaComClient) GetPlugin(pluginId, repoUrl string) (models.Plugin, error) {
-	logger.Debugf("getting plugin metadata from: %v pluginId: %v \n", repoUrl, pluginId)
-	body, err := sendRequestGetBytes(HttpClient, repoUrl, "repo", pluginId)
+func (client *GrafanaComClient) GetPlugin(pluginId string, repo models.Repository) (models.Plugin, error) {
+	logger.Debugf("getting plugin metadata from: %v pluginId: %v \n", repo.URL, pluginId)
+	body, err := sendRequestGetBytes(HttpClient, repo.URL, repo.Headers, "repo", pluginId)
 	if err != nil {
 		if errors.Is(err, ErrNotFoundError) {
 			return models.Plugin{}, errutil.Wrap(
@@ -44,7 +44,7 @@ func (client *GrafanaComClient) GetPlugin(pluginId, repoUrl string) (models.Plug
 	return data, nil
 }
 
-func (client *GrafanaComClient) DownloadFile(pluginName string, tmpFile *os.File, url string, checksum string) (err error) {
+func (client *GrafanaComClient) DownloadFile(pluginName string, tmpFile *os.File, url string, checksum string, headers map[string]string) (err error) {
 	// Try handling URL as a local file path first
 	if _, err := os.Stat(url); err == nil {
 		// We can ignore this gosec G304 warning since `url` stems from command line flag "pluginUrl". If the
@@ -76,7 +76,7 @@ func (client *GrafanaComClient) DownloadFile(pluginName string, tmpFile *os.File
 				if err != nil {
 					return
 				}
-				err = client.DownloadFile(pluginName, tmpFile, url, checksum)
+				err = client.DownloadFile(pluginName, tmpFile, url, checksum, headers)
 			} else {
 				client.retryCount = 0
 				failure := fmt.Sprintf("%v", r)
@@ -91,7 +91,7 @@ func (client *GrafanaComClient) DownloadFile(pluginName string, tmpFile *os.File
 
 	// Using no timeout here as some plugins can be bigger and smaller timeout would prevent to download a plugin on
 	// slow network. As this is CLI operation hanging is not a big of an issue as user can just abort.
-	bodyReader, err := sendRequest(HttpClientNoTimeout, url)
+	bodyReader, err := sendRequest(HttpClientNoTimeout, url, headers)
 	if err != nil {
 		return errutil.Wrap("Failed to send request", err)
 	}
@@ -115,8 +115,8 @@ func (client *GrafanaComClient) DownloadFile(pluginName string, tmpFile *os.File
 	return nil
 }
 
-func (client *GrafanaComClient) ListAllPlugins(repoUrl string) (models.PluginRepo, error) {
-	body, err := sendRequestGetBytes(HttpClient, repoUrl, "repo")
+func (client *GrafanaComClient) ListAllPlugins(repo models.Repository) (models.PluginRepo, error) {
+	body, err := sendRequestGetBytes(HttpClient, repo.URL, repo.Headers, "repo")
 
 	if err != nil {
 		logger.Info("Failed to send request", "error", err)
@@ -133,8 +133,8 @@ func (client *GrafanaComClient) ListAllPlugins(repoUrl string) (models.PluginRep
 	return data, nil
 }
 
-func sendRequestGetBytes(client http.Client, repoUrl string, subPaths ...string) ([]byte, error) {
-	bodyReader, err := sendRequest(client, repoUrl, subPaths...)
+func sendRequestGetBytes(client http.Client, repoUrl string, headers map[string]string, subPaths ...string) ([]byte, error) {
+	bodyReader, err := sendRequest(client, repoUrl, headers, subPaths...)
 	if err != nil {
 		return []byte{}, err
 	}
@@ -146,8 +146,8 @@ func sendRequestGetBytes(client http.Client, repoUrl string, subPaths ...string)
 	return ioutil.ReadAll(bodyReader)
 }
 
-func sendRequest(client http.Client, repoUrl string, subPaths ...string) (io.ReadCloser, error) {
-	req, err := createRequest(repoUrl, subPaths...)
+func sendRequest(client http.Client, repoUrl string, headers map[string]string, subPaths ...string) (io.ReadCloser, error) {
+	req, err := createRequest(repoUrl, headers, subPaths...)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +159,7 @@ func sendRequest(client http.Client, repoUrl string, subPaths ...string) (io.Rea
 	return handleResponse(res)
 }
 
-func createRequest(repoUrl string, subPaths ...string) (*http.Request, error) {
+func createRequest(repoUrl string, headers map[string]string, subPaths ...string) (*http.Request, error) {
 	u, err := url.Parse(repoUrl)
 	if err != nil {
 		return nil, err
@@ -178,6 +178,9 @@ func createRequest(repoUrl string, subPaths ...string) (*http.Request, error) {
 	req.Header.Set("grafana-os", runtime.GOOS)
 	req.Header.Set("grafana-arch", runtime.GOARCH)
 	req.Header.Set("User-Agent", "grafana "+grafanaVersion)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
 
 	return req, err
 }
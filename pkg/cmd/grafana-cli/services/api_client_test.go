@@ -6,10 +6,13 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
 )
 
 func TestHandleResponse(t *testing.T) {
@@ -97,3 +100,48 @@ func asBadRequestError(t *testing.T, err error) *BadRequestError {
 	assert.FailNow(t, "Error was not of type BadRequestError")
 	return nil
 }
+
+func TestRepositoryRedirects(t *testing.T) {
+	Init("7.5.0", false)
+
+	var cdnHeader http.Header
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cdnHeader = r.Header.Clone()
+		_, _ = w.Write([]byte(`{"plugins": []}`))
+	}))
+	t.Cleanup(cdn.Close)
+	repo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, cdn.URL+r.URL.Path, http.StatusFound)
+	}))
+	t.Cleanup(repo.Close)
+
+	headers := map[string]string{"Authorization": "Bearer secret", "X-Api-Key": "secret"}
+	client := GrafanaComClient{}
+
+	t.Run("does not send the repository headers to other hosts when listing plugins", func(t *testing.T) {
+		cdnHeader = nil
+		_, err := client.ListAllPlugins(models.Repository{URL: repo.URL, Headers: headers})
+		require.NoError(t, err)
+		require.NotNil(t, cdnHeader)
+		assert.Empty(t, cdnHeader.Get("Authorization"))
+		assert.Empty(t, cdnHeader.Get("X-Api-Key"))
+		assert.Equal(t, "7.5.0", cdnHeader.Get("Grafana-Version"))
+	})
+
+	t.Run("does not send the repository headers to other hosts when downloading plugins", func(t *testing.T) {
+		cdnHeader = nil
+		tmpFile, err := ioutil.TempFile(t.TempDir(), "plugin")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = tmpFile.Close() })
+
+		err = client.DownloadFile("test-plugin", tmpFile, repo.URL+"/plugin.zip", "", headers)
+		require.NoError(t, err)
+		require.NotNil(t, cdnHeader)
+		assert.Empty(t, cdnHeader.Get("Authorization"))
+		assert.Empty(t, cdnHeader.Get("X-Api-Key"))
+	})
+}
@@ -41,6 +41,11 @@ func Init(version string, skipTLSVerify bool) {
 	HttpClientNoTimeout = makeHttpClient(skipTLSVerify, 0)
 }
 
+// GrafanaVersion returns the version of Grafana the plugins are installed for.
+func GrafanaVersion() string {
+	return grafanaVersion
+}
+
 func makeHttpClient(skipTLSVerify bool, timeout time.Duration) http.Client {
 	tr := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
@@ -58,9 +63,29 @@ func makeHttpClient(skipTLSVerify bool, timeout time.Duration) http.Client {
 	}
 
 	return http.Client{
-		Timeout:   timeout,
-		Transport: tr,
+		Timeout:       timeout,
+		Transport:     tr,
+		CheckRedirect: checkRepositoryRedirect,
+	}
+}
+
+// checkRepositoryRedirect follows the redirects of plugin repositories, e.g. to the CDN hosting the plugin
+// archives. The headers of the repository, which may hold its credentials, aren't sent to other hosts.
+func checkRepositoryRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	if req.URL.Host != via[0].URL.Host {
+		for name := range req.Header {
+			switch name {
+			case "Grafana-Version", "Grafana-Os", "Grafana-Arch", "User-Agent":
+			default:
+				req.Header.Del(name)
+			}
+		}
 	}
+	return nil
 }
 
 func ReadPlugin(pluginDir, pluginName string) (models.InstalledPlugin, error) {
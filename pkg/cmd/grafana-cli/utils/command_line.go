@@ -13,6 +13,7 @@ type CommandLine interface {
 	Application() *cli.App
 	Args() cli.Args
 	Bool(name string) bool
+	IsSet(name string) bool
 	Int(name string) int
 	String(name string) string
 	StringSlice(name string) []string
@@ -25,9 +26,9 @@ type CommandLine interface {
 }
 
 type ApiClient interface {
-	GetPlugin(pluginId, repoUrl string) (models.Plugin, error)
-	DownloadFile(pluginName string, tmpFile *os.File, url string, checksum string) (err error)
-	ListAllPlugins(repoUrl string) (models.PluginRepo, error)
+	GetPlugin(pluginId string, repo models.Repository) (models.Plugin, error)
+	DownloadFile(pluginName string, tmpFile *os.File, url string, checksum string, headers map[string]string) (err error)
+	ListAllPlugins(repo models.Repository) (models.PluginRepo, error)
 }
 
 type ContextCommandLine struct {
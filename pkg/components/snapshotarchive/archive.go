@@ -0,0 +1,278 @@
+// Package snapshotarchive reads and writes portable dashboard snapshot archives.
+//
+// An archive is a zip file with a manifest.json describing its content, the snapshot
+// dashboard JSON (including the embedded data frames) and optionally rendered panel
+// images. Every file is listed in the manifest with its SHA-256 checksum. When a
+// password is given, the dashboard and images are encrypted with it.
+package snapshotarchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Version is the current version of the archive format.
+const Version = 1
+
+const (
+	manifestFile      = "manifest.json"
+	dashboardFile     = "dashboard.json"
+	imageDir          = "images"
+	encryptedFileExt  = ".enc"
+	maxArchiveEntries = 1000
+	// maxUncompressedSize is the largest total size of the decompressed files of an archive.
+	maxUncompressedSize = 512 << 20
+)
+
+var (
+	// ErrUnsupportedVersion occurs when the archive was written with an unknown format version.
+	ErrUnsupportedVersion = errors.New("snapshotarchive: unsupported archive version")
+	// ErrChecksumMismatch occurs when a file in the archive does not match the checksum in the manifest.
+	ErrChecksumMismatch = errors.New("snapshotarchive: checksum mismatch")
+	// ErrInvalidArchive occurs when the archive is missing files or contains unexpected ones.
+	ErrInvalidArchive = errors.New("snapshotarchive: invalid archive")
+	// ErrPasswordRequired occurs when reading an encrypted archive without a password.
+	ErrPasswordRequired = errors.New("snapshotarchive: archive is encrypted, password required")
+	// ErrDecryptionFailed occurs when an encrypted archive can't be decrypted with the given password.
+	ErrDecryptionFailed = errors.New("snapshotarchive: failed to decrypt archive")
+)
+
+// Manifest describes the content of an archive.
+type Manifest struct {
+	Version   int               `json:"version"`
+	Name      string            `json:"name"`
+	Created   time.Time         `json:"created"`
+	Expires   time.Time         `json:"expires"`
+	Encrypted bool              `json:"encrypted"`
+	Files     map[string]string `json:"files"`
+}
+
+// Archive is the decoded content of a snapshot archive.
+type Archive struct {
+	Name      string
+	Created   time.Time
+	Expires   time.Time
+	Dashboard []byte
+	// Images maps panel image names, such as "panel-2.png", to PNG data.
+	Images map[string][]byte
+}
+
+// Expired returns true if the snapshot in the archive has expired at the given time.
+func (a *Archive) Expired(now time.Time) bool {
+	return !a.Expires.IsZero() && !a.Expires.After(now)
+}
+
+// Write writes a as a zip archive to w. If password is not empty, the dashboard
+// and images are encrypted with it.
+func Write(w io.Writer, a *Archive, password string) error {
+	manifest := Manifest{
+		Version:   Version,
+		Name:      a.Name,
+		Created:   a.Created,
+		Expires:   a.Expires,
+		Encrypted: password != "",
+		Files:     make(map[string]string),
+	}
+
+	files := map[string][]byte{dashboardFile: a.Dashboard}
+	for name, data := range a.Images {
+		files[path.Join(imageDir, path.Base(name))] = data
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		data := files[name]
+		if manifest.Encrypted {
+			encrypted, err := util.Encrypt(data, password)
+			if err != nil {
+				return err
+			}
+			data = encrypted
+			name += encryptedFileExt
+		}
+
+		if err := writeFile(zw, name, data); err != nil {
+			return err
+		}
+		manifest.Files[name] = checksum(data)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(zw, manifestFile, manifestJSON); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// Read reads and verifies a zip archive of the given size from r. password is
+// required for encrypted archives and ignored otherwise.
+func Read(r io.ReaderAt, size int64, password string) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	if len(zr.File) > maxArchiveEntries {
+		return nil, fmt.Errorf("%w: too many files", ErrInvalidArchive)
+	}
+
+	files := make(map[string][]byte, len(zr.File))
+	remaining := int64(maxUncompressedSize)
+	for _, f := range zr.File {
+		data, err := readFile(f, remaining)
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name] = data
+		remaining -= int64(len(data))
+	}
+
+	manifestJSON, ok := files[manifestFile]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, manifestFile)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	if manifest.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+	if manifest.Encrypted && password == "" {
+		return nil, ErrPasswordRequired
+	}
+
+	a := &Archive{
+		Name:    manifest.Name,
+		Created: manifest.Created,
+		Expires: manifest.Expires,
+		Images:  make(map[string][]byte),
+	}
+	for name, data := range files {
+		if name == manifestFile {
+			continue
+		}
+		expected, ok := manifest.Files[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not listed in manifest", ErrInvalidArchive, name)
+		}
+		if checksum(data) != expected {
+			return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, name)
+		}
+
+		if manifest.Encrypted {
+			decrypted, err := decrypt(data, password)
+			if err != nil {
+				return nil, err
+			}
+			data = decrypted
+			name = strings.TrimSuffix(name, encryptedFileExt)
+		}
+
+		switch {
+		case name == dashboardFile:
+			a.Dashboard = data
+		case path.Dir(name) == imageDir:
+			a.Images[path.Base(name)] = data
+		default:
+			return nil, fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, name)
+		}
+	}
+	for name := range manifest.Files {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, name)
+		}
+	}
+	if a.Dashboard == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, dashboardFile)
+	}
+	// the cipher is not authenticated, a wrong password only shows as garbage
+	if !json.Valid(a.Dashboard) {
+		if manifest.Encrypted {
+			return nil, ErrDecryptionFailed
+		}
+		return nil, fmt.Errorf("%w: %s is not valid JSON", ErrInvalidArchive, dashboardFile)
+	}
+
+	return a, nil
+}
+
+// ReadBytes reads and verifies a zip archive held in memory.
+func ReadBytes(data []byte, password string) (*Archive, error) {
+	return Read(bytes.NewReader(data), int64(len(data)), password)
+}
+
+func writeFile(zw *zip.Writer, name string, data []byte) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+// readFile reads a file of the archive, which must not be larger than limit once decompressed.
+// The size in the header of the file isn't trusted, the data read is limited as well.
+func readFile(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%w: archive is too large", ErrInvalidArchive)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	data, err := ioutil.ReadAll(io.LimitReader(rc, int64(f.UncompressedSize64)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+	if uint64(len(data)) > f.UncompressedSize64 {
+		return nil, fmt.Errorf("%w: %s is larger than its size in the archive", ErrInvalidArchive, f.Name)
+	}
+	return data, nil
+}
+
+func decrypt(data []byte, password string) ([]byte, error) {
+	// util.Decrypt expects an 8 byte salt followed by the AES IV
+	if len(data) < 8+aes.BlockSize {
+		return nil, ErrDecryptionFailed
+	}
+
+	decrypted, err := util.Decrypt(data, password)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return decrypted, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
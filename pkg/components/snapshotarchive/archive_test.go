@@ -0,0 +1,126 @@
+package snapshotarchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestArchive() *Archive {
+	return &Archive{
+		Name:      "Incident 42",
+		Created:   time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC),
+		Expires:   time.Date(2021, 3, 8, 12, 0, 0, 0, time.UTC),
+		Dashboard: []byte(`{"title":"Incident 42","panels":[{"id":1,"snapshotData":[]}]}`),
+		Images: map[string][]byte{
+			"panel-1.png": {0x89, 'P', 'N', 'G'},
+		},
+	}
+}
+
+func TestArchive(t *testing.T) {
+	t.Run("Round trip without password", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, newTestArchive(), ""))
+
+		a, err := ReadBytes(buf.Bytes(), "")
+		require.NoError(t, err)
+		assert.Equal(t, newTestArchive(), a)
+	})
+
+	t.Run("Round trip with password", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, newTestArchive(), "secret"))
+		assert.NotContains(t, buf.String(), "Incident 42\",\"panels")
+
+		a, err := ReadBytes(buf.Bytes(), "secret")
+		require.NoError(t, err)
+		assert.Equal(t, newTestArchive(), a)
+	})
+
+	t.Run("Encrypted archive without password fails", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, newTestArchive(), "secret"))
+
+		_, err := ReadBytes(buf.Bytes(), "")
+		assert.True(t, errors.Is(err, ErrPasswordRequired))
+	})
+
+	t.Run("Encrypted archive with wrong password fails", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, newTestArchive(), "secret"))
+
+		_, err := ReadBytes(buf.Bytes(), "wrong")
+		assert.True(t, errors.Is(err, ErrDecryptionFailed))
+	})
+
+	t.Run("Tampered file fails checksum verification", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, newTestArchive(), ""))
+
+		tampered := rewriteFile(t, buf.Bytes(), dashboardFile, []byte(`{"title":"Tampered"}`))
+		_, err := ReadBytes(tampered, "")
+		assert.True(t, errors.Is(err, ErrChecksumMismatch))
+	})
+
+	t.Run("Unlisted file is rejected", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, newTestArchive(), ""))
+
+		tampered := rewriteFile(t, buf.Bytes(), "extra.txt", []byte("extra"))
+		_, err := ReadBytes(tampered, "")
+		assert.True(t, errors.Is(err, ErrInvalidArchive))
+	})
+
+	t.Run("Files larger than the limit are rejected before decompressing them", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		require.NoError(t, writeFile(zw, dashboardFile, make([]byte, 1<<20)))
+		require.NoError(t, zw.Close())
+
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		require.Less(t, buf.Len(), 1<<12, "the zeros compress well")
+
+		_, err = readFile(zr.File[0], 1<<19)
+		assert.True(t, errors.Is(err, ErrInvalidArchive))
+		data, err := readFile(zr.File[0], 1<<20)
+		require.NoError(t, err)
+		assert.Len(t, data, 1<<20)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		a := newTestArchive()
+		assert.False(t, a.Expired(a.Expires.Add(-time.Minute)))
+		assert.True(t, a.Expired(a.Expires))
+		assert.False(t, (&Archive{}).Expired(time.Now()))
+	})
+}
+
+// rewriteFile copies a zip archive, replacing or adding the named file.
+func rewriteFile(t *testing.T, archive []byte, name string, data []byte) []byte {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		if f.Name == name {
+			continue
+		}
+		content, err := readFile(f, maxUncompressedSize)
+		require.NoError(t, err)
+		require.NoError(t, writeFile(zw, f.Name, content))
+	}
+	require.NoError(t, writeFile(zw, name, data))
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,109 @@
+// Package geoip looks up the approximate location of IP addresses in a MaxMind database, such as
+// GeoLite2 City, to show where the user sessions come from.
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	registry.RegisterService(&Service{})
+}
+
+// record holds the fields read from the City and Country databases.
+type record struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+}
+
+type Service struct {
+	Cfg *setting.Cfg `inject:""`
+
+	log log.Logger
+	// mu guards the reader, which can't be used once closed
+	mu     sync.RWMutex
+	reader *maxminddb.Reader
+}
+
+func (s *Service) Init() error {
+	s.log = log.New("geoip")
+
+	if s.Cfg.SessionGeoIPDatabasePath == "" {
+		return nil
+	}
+
+	reader, err := maxminddb.Open(s.Cfg.SessionGeoIPDatabasePath)
+	if err != nil {
+		return fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	s.reader = reader
+
+	return nil
+}
+
+// Run closes the GeoIP database when Grafana shuts down.
+func (s *Service) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reader == nil {
+		return nil
+	}
+
+	err := s.reader.Close()
+	s.reader = nil
+	return err
+}
+
+// Location returns the city and the country of an IP address, such as "Stockholm, Sweden", or an
+// empty string if there is no GeoIP database or the address is not found.
+func (s *Service) Location(ip string) string {
+	if s == nil {
+		return ""
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.reader == nil {
+		return ""
+	}
+
+	var r record
+	if err := s.reader.Lookup(parsed, &r); err != nil {
+		s.log.Debug("Failed to look up IP address", "ip", ip, "error", err)
+		return ""
+	}
+
+	return formatLocation(r)
+}
+
+func formatLocation(r record) string {
+	var parts []string
+	if city := r.City.Names["en"]; city != "" {
+		parts = append(parts, city)
+	}
+	if country := r.Country.Names["en"]; country != "" {
+		parts = append(parts, country)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,33 @@
+package geoip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocation(t *testing.T) {
+	t.Run("without a database the location is empty", func(t *testing.T) {
+		var s *Service
+		assert.Empty(t, s.Location("81.2.69.142"))
+		assert.Empty(t, (&Service{}).Location("81.2.69.142"))
+	})
+
+	t.Run("stops without a database", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.NoError(t, (&Service{}).Run(ctx))
+	})
+
+	t.Run("formats the city and the country", func(t *testing.T) {
+		var r record
+		assert.Empty(t, formatLocation(r))
+
+		r.Country.Names = map[string]string{"en": "Sweden", "de": "Schweden"}
+		assert.Equal(t, "Sweden", formatLocation(r))
+
+		r.City.Names = map[string]string{"en": "Stockholm"}
+		assert.Equal(t, "Stockholm, Sweden", formatLocation(r))
+	})
+}
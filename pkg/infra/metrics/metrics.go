@@ -104,6 +104,12 @@ var (
 
 	// MRenderingQueue is a metric gauge for image rendering queue size
 	MRenderingQueue prometheus.Gauge
+
+	// MApiRateLimited is a metric counter for API requests rejected by rate limits
+	MApiRateLimited *prometheus.CounterVec
+
+	// MApiRateLimitErrors is a metric counter for rate limit checks that failed and let the request through
+	MApiRateLimitErrors prometheus.Counter
 )
 
 // Timers
@@ -373,6 +379,21 @@ func init() {
 		[]string{"status"},
 	)
 
+	MApiRateLimited = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "api_rate_limited_total",
+			Help:      "counter for API requests rejected by rate limits",
+			Namespace: ExporterName,
+		},
+		[]string{"route_group", "limit"},
+	)
+
+	MApiRateLimitErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "api_rate_limit_errors_total",
+		Help:      "counter for rate limit checks that failed and let the request through",
+		Namespace: ExporterName,
+	})
+
 	MRenderingSummary = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Name:       "rendering_request_duration_milliseconds",
@@ -582,6 +603,8 @@ func initMetricVars() {
 		MRenderingRequestTotal,
 		MRenderingSummary,
 		MRenderingQueue,
+		MApiRateLimited,
+		MApiRateLimitErrors,
 		MAlertingActiveAlerts,
 		MStatTotalDashboards,
 		MStatTotalFolders,
@@ -0,0 +1,47 @@
+package network
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client of a request, which is the address of the peer,
+// unless the peer is a trusted proxy. The X-Real-IP and X-Forwarded-For headers of a trusted proxy
+// give the address of the client then, the latter being the last address not of a trusted proxy.
+// It returns an error if the address of the peer is not a valid IP address.
+func ClientIP(req *http.Request, trustedProxies []*net.IPNet) (net.IP, error) {
+	peer, err := GetIPFromAddress(req.RemoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	if !isTrustedProxy(peer, trustedProxies) {
+		return peer, nil
+	}
+
+	if realIP := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); realIP != nil {
+		return realIP, nil
+	}
+	client := peer
+	forwarded := strings.Split(req.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+	return client, nil
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
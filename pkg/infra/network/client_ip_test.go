@@ -0,0 +1,75 @@
+package network
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	trustedProxies := []*net.IPNet{proxies}
+
+	testCases := []struct {
+		desc       string
+		remoteAddr string
+		header     http.Header
+		exp        string
+		expErr     bool
+	}{
+		{
+			desc:       "Peer without headers",
+			remoteAddr: "1.2.3.4:5000",
+			exp:        "1.2.3.4",
+		},
+		{
+			desc:       "Untrusted peer with forwarded headers",
+			remoteAddr: "1.2.3.4:5000",
+			header:     http.Header{"X-Forwarded-For": {"5.6.7.8"}, "X-Real-Ip": {"5.6.7.8"}},
+			exp:        "1.2.3.4",
+		},
+		{
+			desc:       "Trusted peer with X-Real-IP",
+			remoteAddr: "10.0.0.1:5000",
+			header:     http.Header{"X-Real-Ip": {"5.6.7.8"}},
+			exp:        "5.6.7.8",
+		},
+		{
+			desc:       "Trusted peer with X-Forwarded-For through trusted proxies",
+			remoteAddr: "10.0.0.1:5000",
+			header:     http.Header{"X-Forwarded-For": {"1.1.1.1, 5.6.7.8, 10.0.0.2"}},
+			exp:        "5.6.7.8",
+		},
+		{
+			desc:       "Trusted peer with invalid forwarded headers",
+			remoteAddr: "10.0.0.1:5000",
+			header:     http.Header{"X-Forwarded-For": {"<script>"}, "X-Real-Ip": {"not an IP address"}},
+			exp:        "10.0.0.1",
+		},
+		{
+			desc:       "Invalid peer",
+			remoteAddr: "@",
+			expErr:     true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: tc.remoteAddr, Header: tc.header}
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+
+			ip, err := ClientIP(req, trustedProxies)
+			if tc.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, ip.String())
+		})
+	}
+}
@@ -26,6 +26,7 @@ var loginLogger = log.New("login")
 
 func Init() {
 	bus.AddHandler("auth", authenticateUser)
+	bus.AddHandler("auth", authenticateTOTP)
 }
 
 // authenticateUser authenticates the user via username & password
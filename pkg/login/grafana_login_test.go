@@ -49,6 +49,15 @@ func TestLoginUsingGrafanaDB(t *testing.T) {
 		assert.False(t, sc.validatePasswordCalled)
 		assert.Nil(t, sc.loginUserQuery.User)
 	})
+
+	grafanaLoginScenario(t, "When login with service account", func(sc *grafanaLoginScenarioContext) {
+		sc.withServiceAccount()
+		err := loginUsingGrafanaDB(sc.loginUserQuery)
+		require.EqualError(t, err, ErrInvalidCredentials.Error())
+
+		assert.False(t, sc.validatePasswordCalled)
+		assert.Nil(t, sc.loginUserQuery.User)
+	})
 }
 
 type grafanaLoginScenarioContext struct {
@@ -131,3 +140,9 @@ func (sc *grafanaLoginScenarioContext) withDisabledUser() {
 		IsDisabled: true,
 	})
 }
+
+func (sc *grafanaLoginScenarioContext) withServiceAccount() {
+	sc.getUserByLoginQueryReturns(&models.User{
+		IsServiceAccount: true,
+	})
+}
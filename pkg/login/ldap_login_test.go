@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/ldap"
 	"github.com/grafana/grafana/pkg/services/multildap"
@@ -44,12 +45,39 @@ func TestLoginUsingLDAP(t *testing.T) {
 		assert.False(t, enabled)
 		assert.False(t, sc.LDAPAuthenticatorMock.loginCalled)
 	})
+
+	LDAPLoginScenario(t, "When LDAP login succeeds, groups are synced", func(sc *LDAPLoginScenarioContext) {
+		setting.LDAPEnabled = true
+
+		sc.withLoginResult(true)
+		sc.LDAPAuthenticatorMock.externalUser = &models.ExternalUserInfo{
+			AuthModule: models.AuthModuleLDAP,
+			Login:      "user",
+			Groups:     []string{"cn=admins,ou=groups,dc=grafana,dc=org"},
+		}
+
+		var upsert *models.UpsertUserCommand
+		bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+			upsert = cmd
+			cmd.Result = &models.User{Login: cmd.ExternalUser.Login}
+			return nil
+		})
+
+		enabled, err := loginUsingLDAP(sc.loginUserQuery)
+		require.NoError(t, err)
+
+		assert.True(t, enabled)
+		require.NotNil(t, upsert)
+		assert.Equal(t, []string{"cn=admins,ou=groups,dc=grafana,dc=org"}, upsert.ExternalUser.Groups)
+		assert.Equal(t, "user", sc.loginUserQuery.User.Login)
+	})
 }
 
 type mockAuth struct {
-	validLogin  bool
-	loginCalled bool
-	pingCalled  bool
+	validLogin   bool
+	loginCalled  bool
+	pingCalled   bool
+	externalUser *models.ExternalUserInfo
 }
 
 func (auth *mockAuth) Ping() ([]*multildap.ServerStatus, error) {
@@ -68,7 +96,7 @@ func (auth *mockAuth) Login(query *models.LoginUserQuery) (
 		return nil, errTest
 	}
 
-	return nil, nil
+	return auth.externalUser, nil
 }
 
 func (auth *mockAuth) Users(logins []string) (
@@ -100,3 +100,38 @@ func (s *SocialBase) searchJSONForAttr(attributePath string, data []byte) (strin
 
 	return "", nil
 }
+
+func (s *SocialBase) searchJSONForStringArrayAttr(attributePath string, data []byte) ([]string, error) {
+	if attributePath == "" {
+		return nil, errors.New("no attribute path specified")
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("empty user info JSON response provided")
+	}
+
+	var buf interface{}
+	if err := json.Unmarshal(data, &buf); err != nil {
+		return nil, errutil.Wrap("failed to unmarshal user info JSON response", err)
+	}
+
+	val, err := jmespath.Search(attributePath, buf)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "failed to search user info JSON response with provided path: %q", attributePath)
+	}
+
+	switch val := val.(type) {
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		result := make([]string, 0, len(val))
+		for _, v := range val {
+			if strVal, ok := v.(string); ok {
+				result = append(result, strVal)
+			}
+		}
+		return result, nil
+	}
+
+	return nil, nil
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/mail"
 	"regexp"
+	"strconv"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -28,6 +29,7 @@ type SocialGenericOAuth struct {
 	roleAttributePath    string
 	roleAttributeStrict  bool
 	idTokenAttributeName string
+	groupsAttributePath  string
 	teamIds              []int
 }
 
@@ -45,6 +47,10 @@ func (s *SocialGenericOAuth) IsTeamMember(client *http.Client) bool {
 		return false
 	}
 
+	return s.isTeamMember(teamMemberships)
+}
+
+func (s *SocialGenericOAuth) isTeamMember(teamMemberships []int) bool {
 	for _, teamId := range s.teamIds {
 		for _, membershipId := range teamMemberships {
 			if teamId == membershipId {
@@ -142,6 +148,16 @@ func (s *SocialGenericOAuth) UserInfo(client *http.Client, token *oauth2.Token)
 			}
 		}
 
+		if len(userInfo.Groups) == 0 && s.groupsAttributePath != "" {
+			groups, err := s.searchJSONForStringArrayAttr(s.groupsAttributePath, data.rawJSON)
+			if err != nil {
+				s.log.Error("Failed to search JSON for groups attribute", "error", err)
+			} else if len(groups) > 0 {
+				s.log.Debug("Setting user info groups from extracted groups")
+				userInfo.Groups = groups
+			}
+		}
+
 		if userInfo.Role == "" {
 			role, err := s.extractRole(data)
 			if err != nil {
@@ -171,8 +187,17 @@ func (s *SocialGenericOAuth) UserInfo(client *http.Client, token *oauth2.Token)
 		return nil, errors.New("invalid role")
 	}
 
-	if !s.IsTeamMember(client) {
-		return nil, errors.New("user not a member of one of the required teams")
+	if len(s.teamIds) > 0 {
+		teamMemberships, ok := s.FetchTeamMemberships(client)
+		if !ok || !s.isTeamMember(teamMemberships) {
+			return nil, errors.New("user not a member of one of the required teams")
+		}
+
+		if s.groupsAttributePath == "" {
+			for _, teamId := range teamMemberships {
+				userInfo.Groups = append(userInfo.Groups, strconv.Itoa(teamId))
+			}
+		}
 	}
 
 	if !s.IsOrganizationMember(client) {
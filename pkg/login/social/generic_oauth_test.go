@@ -541,6 +541,92 @@ func TestUserInfoSearchesForName(t *testing.T) {
 	})
 }
 
+func TestUserInfoSearchesForGroups(t *testing.T) {
+	t.Run("Given a generic OAuth provider", func(t *testing.T) {
+		provider := SocialGenericOAuth{
+			SocialBase: &SocialBase{
+				log: newLogger("generic_oauth_test", log15.LvlDebug),
+			},
+		}
+
+		tests := []struct {
+			Name                string
+			ResponseBody        interface{}
+			TeamsResponseBody   interface{}
+			GroupsAttributePath string
+			TeamIds             []int
+			ExpectedGroups      []string
+		}{
+			{
+				Name: "Given a groups path, a valid API response, use API response",
+				ResponseBody: map[string]interface{}{
+					"login":  "johndoe",
+					"email":  "john.doe@example.com",
+					"groups": []string{"admins", "developers"},
+				},
+				GroupsAttributePath: "groups[*]",
+				ExpectedGroups:      []string{"admins", "developers"},
+			},
+			{
+				Name: "Given a groups path returning a string, use it as single group",
+				ResponseBody: map[string]interface{}{
+					"login": "johndoe",
+					"email": "john.doe@example.com",
+					"info":  map[string]interface{}{"department": "support"},
+				},
+				GroupsAttributePath: "info.department",
+				ExpectedGroups:      []string{"support"},
+			},
+			{
+				Name: "Given no groups path and team ids, use team memberships",
+				ResponseBody: map[string]interface{}{
+					"login": "johndoe",
+					"email": "john.doe@example.com",
+				},
+				TeamsResponseBody: []map[string]interface{}{{"id": 1}, {"id": 7}},
+				TeamIds:           []int{7},
+				ExpectedGroups:    []string{"1", "7"},
+			},
+			{
+				Name: "Given no groups path and no team ids, no groups",
+				ResponseBody: map[string]interface{}{
+					"login":  "johndoe",
+					"email":  "john.doe@example.com",
+					"groups": []string{"admins"},
+				},
+				ExpectedGroups: nil,
+			},
+		}
+
+		for _, test := range tests {
+			provider.groupsAttributePath = test.GroupsAttributePath
+			provider.teamIds = test.TeamIds
+			t.Run(test.Name, func(t *testing.T) {
+				body, err := json.Marshal(test.ResponseBody)
+				require.NoError(t, err)
+				teamsBody, err := json.Marshal(test.TeamsResponseBody)
+				require.NoError(t, err)
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					if r.URL.Path == "/teams" {
+						_, err = w.Write(teamsBody)
+					} else {
+						_, err = w.Write(body)
+					}
+					require.NoError(t, err)
+				}))
+				defer ts.Close()
+				provider.apiUrl = ts.URL
+
+				actualResult, err := provider.UserInfo(ts.Client(), &oauth2.Token{})
+				require.NoError(t, err)
+				require.Equal(t, test.ExpectedGroups, actualResult.Groups)
+			})
+		}
+	})
+}
+
 func TestPayloadCompression(t *testing.T) {
 	provider := SocialGenericOAuth{
 		SocialBase: &SocialBase{
@@ -188,6 +188,7 @@ func NewOAuthService() {
 				roleAttributeStrict:  info.RoleAttributeStrict,
 				loginAttributePath:   sec.Key("login_attribute_path").String(),
 				idTokenAttributeName: sec.Key("id_token_attribute_name").String(),
+				groupsAttributePath:  sec.Key("groups_attribute_path").String(),
 				teamIds:              sec.Key("team_ids").Ints(","),
 				allowedOrganizations: util.SplitString(sec.Key("allowed_organizations").String()),
 			}
@@ -0,0 +1,32 @@
+package login
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// authenticateTOTP checks the second factor of a user whose password was already checked. Invalid
+// codes count as invalid login attempts, so codes cannot be brute forced.
+func authenticateTOTP(query *models.TOTPLoginQuery) error {
+	loginQuery := &models.LoginUserQuery{
+		ReqContext: query.ReqContext,
+		Username:   query.User.Login,
+		IpAddress:  query.IpAddress,
+		Cfg:        query.Cfg,
+	}
+
+	if err := validateLoginAttempts(loginQuery); err != nil {
+		return err
+	}
+
+	err := bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: query.User.Id, Code: query.Code})
+	if errors.Is(err, models.ErrInvalidTOTPCode) {
+		if err := saveInvalidLoginAttempt(loginQuery); err != nil {
+			loginLogger.Error("Failed to save invalid login attempt", "err", err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,58 @@
+package login
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticateTOTP(t *testing.T) {
+	newQuery := func(code string) *models.TOTPLoginQuery {
+		bus.AddHandler("test", func(cmd *models.VerifyUserTOTPCommand) error {
+			if cmd.UserId != 1 || cmd.Code != "123456" {
+				return models.ErrInvalidTOTPCode
+			}
+			return nil
+		})
+
+		return &models.TOTPLoginQuery{
+			User:      &models.User{Id: 1, Login: "user"},
+			Code:      code,
+			IpAddress: "192.168.1.1:56433",
+		}
+	}
+
+	authScenario(t, "When a user enters a valid code", func(sc *authScenarioContext) {
+		mockLoginAttemptValidation(nil, sc)
+		mockSaveInvalidLoginAttempt(sc)
+
+		err := authenticateTOTP(newQuery("123456"))
+
+		require.NoError(t, err)
+		assert.True(t, sc.loginAttemptValidationWasCalled)
+		assert.False(t, sc.saveInvalidLoginAttemptWasCalled)
+	})
+
+	authScenario(t, "When a user enters an invalid code", func(sc *authScenarioContext) {
+		mockLoginAttemptValidation(nil, sc)
+		mockSaveInvalidLoginAttempt(sc)
+
+		err := authenticateTOTP(newQuery("654321"))
+
+		require.Equal(t, models.ErrInvalidTOTPCode, err)
+		assert.True(t, sc.saveInvalidLoginAttemptWasCalled)
+	})
+
+	authScenario(t, "When a user has too many login attempts", func(sc *authScenarioContext) {
+		mockLoginAttemptValidation(ErrTooManyLoginAttempts, sc)
+		mockSaveInvalidLoginAttempt(sc)
+
+		err := authenticateTOTP(newQuery("123456"))
+
+		require.Equal(t, ErrTooManyLoginAttempts, err)
+		assert.False(t, sc.saveInvalidLoginAttemptWasCalled)
+	})
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/network"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength is the length of the audit_entry.request_id column.
+const maxRequestIDLength = 64
+
+// notAuditedRoutes are API routes which are not GET requests, but don't change anything in
+// Grafana: queries, proxies and tests.
+var notAuditedRoutes = map[string]bool{
+	"/api/tsdb/query":                  true,
+	"/api/ds/query":                    true,
+	"/api/datasources/proxy/:id":       true,
+	"/api/datasources/proxy/:id/*":     true,
+	"/api/datasources/:id/resources":   true,
+	"/api/datasources/:id/resources/*": true,
+	"/api/datasources/:id/health":      true,
+	"/api/dashboards/calculate-diff":   true,
+	"/api/alerts/test":                 true,
+	"/api/alert-notifications/test":    true,
+	"/api/frontend-metrics":            true,
+	"/api/live/publish":                true,
+	"/api/live/push/:streamId":         true,
+	"/api/snapshots/:key/archive":      true,
+	"/api/gnet/*":                      true,
+}
+
+var auditLogger = log.New("audit")
+
+// Audit records the mutating requests to the API routes in the audit log, once they are handled.
+// The request ID is taken from the X-Request-Id header, or generated and returned in that header.
+func Audit(cfg *setting.Cfg) func(handler string) macaron.Handler {
+	return func(handler string) macaron.Handler {
+		return func(res http.ResponseWriter, req *http.Request, c *macaron.Context) {
+			if !cfg.Audit.Enabled || !isAuditedRequest(req.Method, handler) {
+				return
+			}
+
+			requestID := req.Header.Get(requestIDHeader)
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				requestID = util.GenerateShortUID()
+			}
+			res.Header().Set(requestIDHeader, requestID)
+
+			c.Next()
+
+			ctx, ok := c.Data["ctx"].(*models.ReqContext)
+			if !ok {
+				return
+			}
+
+			entry := &models.AuditEntry{
+				Action:    req.Method + " " + handler,
+				Target:    req.URL.Path,
+				Status:    res.(macaron.ResponseWriter).Status(),
+				RequestId: requestID,
+			}
+			// the client IP is only taken from the forwarded headers of the trusted proxies
+			if ip, err := network.ClientIP(req, cfg.RateLimiting.TrustedProxies); err == nil {
+				entry.IpAddress = ip.String()
+			}
+			if ctx.SignedInUser != nil {
+				entry.OrgId = ctx.OrgId
+				entry.UserId = ctx.UserId
+				entry.UserLogin = ctx.Login
+				entry.ApiKeyId = ctx.ApiKeyId
+			}
+
+			if err := bus.Dispatch(&models.AddAuditEntryCommand{Entry: entry}); err != nil {
+				auditLogger.Error("Failed to record audit entry", "action", entry.Action, "error", err)
+			}
+		}
+	}
+}
+
+func isAuditedRequest(method, handler string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return strings.HasPrefix(handler, "/api/") && !notAuditedRoutes[handler]
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	var entries []*models.AuditEntry
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(cmd *models.AddAuditEntryCommand) error {
+		entries = append(entries, cmd.Entry)
+		return nil
+	})
+
+	cfg := setting.NewCfg()
+	cfg.Audit.Enabled = true
+
+	m := macaron.New()
+	m.Use(func(c *macaron.Context) {
+		ctx := &models.ReqContext{
+			Context:      c,
+			SignedInUser: &models.SignedInUser{OrgId: 2, UserId: 3, Login: "editor", ApiKeyId: 4},
+		}
+		c.Data["ctx"] = ctx
+		c.Map(ctx)
+	})
+	audit := Audit(cfg)
+	handler := func(c *models.ReqContext) {
+		c.Resp.WriteHeader(http.StatusAccepted)
+	}
+	m.Delete("/api/datasources/:id", audit("/api/datasources/:id"), handler)
+	m.Get("/api/datasources/:id", audit("/api/datasources/:id"), handler)
+	m.Post("/api/ds/query", audit("/api/ds/query"), handler)
+	m.Post("/login", audit("/login"), handler)
+
+	request := func(method, path string, header http.Header) *httptest.ResponseRecorder {
+		t.Helper()
+		entries = nil
+		req, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.RemoteAddr = "10.0.0.1:1234"
+		resp := httptest.NewRecorder()
+		m.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("records mutating API requests", func(t *testing.T) {
+		resp := request(http.MethodDelete, "/api/datasources/5", nil)
+
+		require.Len(t, entries, 1)
+		entry := entries[0]
+		assert.Equal(t, int64(2), entry.OrgId)
+		assert.Equal(t, int64(3), entry.UserId)
+		assert.Equal(t, "editor", entry.UserLogin)
+		assert.Equal(t, int64(4), entry.ApiKeyId)
+		assert.Equal(t, "DELETE /api/datasources/:id", entry.Action)
+		assert.Equal(t, "/api/datasources/5", entry.Target)
+		assert.Equal(t, http.StatusAccepted, entry.Status)
+		assert.Equal(t, "10.0.0.1", entry.IpAddress)
+		assert.NotEmpty(t, entry.RequestId)
+		assert.Equal(t, entry.RequestId, resp.Header().Get("X-Request-Id"))
+	})
+
+	t.Run("takes the client IP from the forwarded headers of trusted proxies only", func(t *testing.T) {
+		header := http.Header{"X-Forwarded-For": {"1.2.3.4"}, "X-Real-Ip": {"1.2.3.4"}}
+		request(http.MethodDelete, "/api/datasources/5", header)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "10.0.0.1", entries[0].IpAddress)
+
+		_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+		require.NoError(t, err)
+		cfg.RateLimiting.TrustedProxies = []*net.IPNet{proxies}
+		t.Cleanup(func() { cfg.RateLimiting.TrustedProxies = nil })
+
+		request(http.MethodDelete, "/api/datasources/5", header)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "1.2.3.4", entries[0].IpAddress)
+
+		request(http.MethodDelete, "/api/datasources/5", http.Header{"X-Real-Ip": {strings.Repeat("a", 100)}})
+		require.Len(t, entries, 1)
+		assert.Equal(t, "10.0.0.1", entries[0].IpAddress)
+	})
+
+	t.Run("keeps the request ID of the client", func(t *testing.T) {
+		resp := request(http.MethodDelete, "/api/datasources/5", http.Header{"X-Request-Id": {"req-1"}})
+
+		require.Len(t, entries, 1)
+		assert.Equal(t, "req-1", entries[0].RequestId)
+		assert.Equal(t, "req-1", resp.Header().Get("X-Request-Id"))
+	})
+
+	t.Run("does not record reads, queries and routes outside of the API", func(t *testing.T) {
+		request(http.MethodGet, "/api/datasources/5", nil)
+		request(http.MethodPost, "/api/ds/query", nil)
+		request(http.MethodPost, "/login", nil)
+
+		assert.Empty(t, entries)
+	})
+
+	t.Run("does nothing when disabled", func(t *testing.T) {
+		cfg.Audit.Enabled = false
+		t.Cleanup(func() { cfg.Audit.Enabled = true })
+
+		resp := request(http.MethodDelete, "/api/datasources/5", nil)
+
+		assert.Empty(t, entries)
+		assert.Empty(t, resp.Header().Get("X-Request-Id"))
+	})
+}
@@ -14,7 +14,8 @@ var gzipIgnoredPathPrefixes = []string{
 	"/api/datasources/proxy", // Ignore datasource proxy requests.
 	"/api/plugin-proxy/",
 	"/metrics",
-	"/live/ws", // WebSocket does not support gzip compression.
+	"/live/ws",         // WebSocket does not support gzip compression.
+	"/public/plugins/", // Plugin assets are precompressed.
 }
 
 func Gziper() macaron.Handler {
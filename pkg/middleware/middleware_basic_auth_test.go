@@ -73,6 +73,28 @@ func TestMiddlewareBasicAuth(t *testing.T) {
 		assert.Equal(t, id, sc.context.UserId)
 	}, configure)
 
+	middlewareScenario(t, "Should return error if user has two-factor authentication", func(t *testing.T, sc *scenarioContext) {
+		bus.AddHandler("grafana-auth", func(query *models.LoginUserQuery) error {
+			query.User = &models.User{Id: id}
+			query.AuthModule = "grafana"
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetUserTOTPQuery) error {
+			query.Result = &models.UserTOTP{UserId: query.UserId, Enabled: true}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/")
+		sc.req.SetBasicAuth("myUser", "password")
+		sc.exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, "Basic auth is not allowed for users with two-factor authentication", sc.respJson["message"])
+	}, func(cfg *setting.Cfg) {
+		configure(cfg)
+		cfg.TOTPEnabled = true
+	})
+
 	middlewareScenario(t, "Auth sequence", func(t *testing.T, sc *scenarioContext) {
 		const password = "MyPass"
 		const salt = "Salt"
@@ -3,13 +3,17 @@ package middleware
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMiddlewareJWTAuth(t *testing.T) {
@@ -111,6 +115,303 @@ func TestMiddlewareJWTAuth(t *testing.T) {
 		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
 	}, configure, configureEmailClaim)
 
+	configureAutoSignUp := func(cfg *setting.Cfg) {
+		cfg.JWTAuthAutoSignUp = true
+		cfg.JWTAuthSyncTTL = 10
+	}
+
+	middlewareScenario(t, "Valid token with auto sign-up syncs the user once", func(t *testing.T, sc *scenarioContext) {
+		myUsername := "vladimir"
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"foo-username": myUsername,
+			}, nil
+		}
+		sc.jwtAuthService.ExternalUserInfoProvider = func(claims models.JWTClaims) (*models.ExternalUserInfo, error) {
+			return &models.ExternalUserInfo{
+				AuthModule: models.AuthModuleJWT,
+				AuthId:     myUsername,
+				Login:      myUsername,
+				OrgRoles:   map[int64]models.RoleType{orgID: models.ROLE_EDITOR},
+			}, nil
+		}
+		upserts := 0
+		bus.AddHandler("upsert-user", func(cmd *models.UpsertUserCommand) error {
+			upserts++
+			assert.True(t, cmd.SignupAllowed)
+			assert.Equal(t, myUsername, cmd.ExternalUser.Login)
+			cmd.Result = &models.User{Id: id, Login: cmd.ExternalUser.Login}
+			return nil
+		})
+		bus.AddHandler("get-sign-user", func(query *models.GetSignedInUserQuery) error {
+			if query.UserId != id {
+				return models.ErrUserNotFound
+			}
+			query.Result = &models.SignedInUser{
+				UserId:  id,
+				OrgId:   orgID,
+				OrgRole: models.ROLE_EDITOR,
+				Login:   myUsername,
+			}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, id, sc.context.UserId)
+		assert.Equal(t, models.ROLE_EDITOR, sc.context.OrgRole)
+
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, id, sc.context.UserId)
+		assert.Equal(t, 1, upserts)
+	}, configure, configureUsernameClaim, configureAutoSignUp)
+
+	middlewareScenario(t, "Valid token with auto sign-up for a disabled user", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"foo-username": "vladimir",
+			}, nil
+		}
+		bus.AddHandler("upsert-user", func(cmd *models.UpsertUserCommand) error {
+			cmd.Result = &models.User{Id: id, Login: "vladimir", IsDisabled: true}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+	}, configure, configureUsernameClaim, configureAutoSignUp)
+
+	middlewareScenario(t, "Valid token with auto sign-up without a login claim", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{"foo": "bar"}, nil
+		}
+		sc.jwtAuthService.ExternalUserInfoProvider = func(claims models.JWTClaims) (*models.ExternalUserInfo, error) {
+			return nil, errors.New("failed to get an authentication claim from JWT")
+		}
+
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+	}, configure, configureUsernameClaim, configureAutoSignUp)
+
+	configureURLLogin := func(cfg *setting.Cfg) {
+		cfg.AppURL = "http://localhost:3000/"
+		cfg.JWTAuthURLLogin = true
+		cfg.JWTAuthURLParamName = "auth_token"
+		cfg.JWTAuthURLSessionLifetime = time.Hour
+	}
+
+	configureOrgClaim := func(cfg *setting.Cfg) {
+		cfg.JWTAuthOrgClaim = "org"
+	}
+
+	middlewareScenario(t, "Valid token in URL is exchanged for a session", func(t *testing.T, sc *scenarioContext) {
+		const sessionToken = "session-token"
+		var verifiedToken string
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			verifiedToken = token
+			return models.JWTClaims{
+				"foo-username": "vladimir",
+				"org":          float64(orgID),
+			}, nil
+		}
+		bus.AddHandler("get-sign-user", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{
+				UserId: id,
+				OrgId:  query.OrgId,
+				Login:  query.Login,
+			}
+			return nil
+		})
+		bus.AddHandler("get-user-orgs", func(query *models.GetUserOrgListQuery) error {
+			query.Result = []*models.UserOrgDTO{{OrgId: orgID}}
+			return nil
+		})
+		var usingOrg *models.SetUsingOrgCommand
+		bus.AddHandler("set-using-org", func(cmd *models.SetUsingOrgCommand) error {
+			usingOrg = cmd
+			return nil
+		})
+		var createdFor int64
+		sc.userAuthTokenService.CreateTokenProvider = func(ctx context.Context, user *models.User, clientIP net.IP, userAgent string) (*models.UserToken, error) {
+			createdFor = user.Id
+			return &models.UserToken{Id: 1, UserId: user.Id, UnhashedToken: sessionToken}, nil
+		}
+
+		sc.fakeReqWithParams("GET", "/", map[string]string{"auth_token": token, "kiosk": "tv"}).exec()
+		assert.Equal(t, token, verifiedToken)
+		assert.Equal(t, 302, sc.resp.Code)
+		assert.Equal(t, "/?kiosk=tv", sc.resp.Header().Get("Location"))
+		assert.Equal(t, id, createdFor)
+		require.NotNil(t, usingOrg)
+		assert.Equal(t, orgID, usingOrg.OrgId)
+
+		cookie := sc.resp.Header().Get("Set-Cookie")
+		assert.Contains(t, cookie, "grafana_session="+sessionToken)
+		assert.Contains(t, cookie, "Max-Age=3600")
+
+		t.Run("session expires after its lifetime", func(t *testing.T) {
+			var revoked bool
+			sc.userAuthTokenService.LookupTokenProvider = func(ctx context.Context, unhashedToken string) (*models.UserToken, error) {
+				return &models.UserToken{Id: 1, UserId: id, UnhashedToken: unhashedToken}, nil
+			}
+			sc.userAuthTokenService.RevokeTokenProvider = func(ctx context.Context, token *models.UserToken, soft bool) error {
+				revoked = token.Id == 1
+				return nil
+			}
+
+			sc.fakeReq("GET", "/").withTokenSessionCookie(sessionToken).exec()
+			assert.Equal(t, 200, sc.resp.Code)
+			assert.True(t, sc.context.IsSignedIn)
+			assert.False(t, revoked)
+
+			sc.contextHandler.GetTime = func() time.Time {
+				return time.Now().Add(2 * time.Hour)
+			}
+			sc.fakeReq("GET", "/").withTokenSessionCookie(sessionToken).exec()
+			assert.Equal(t, 200, sc.resp.Code)
+			assert.False(t, sc.context.IsSignedIn)
+			assert.True(t, revoked)
+		})
+	}, configure, configureUsernameClaim, configureURLLogin, configureOrgClaim)
+
+	middlewareScenario(t, "Token in URL does not switch to an org the user is not a member of", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"foo-username": "vladimir",
+				"org":          float64(orgID),
+			}, nil
+		}
+		bus.AddHandler("get-sign-user", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{
+				UserId: id,
+				OrgId:  query.OrgId,
+				Login:  query.Login,
+			}
+			return nil
+		})
+		bus.AddHandler("get-user-orgs", func(query *models.GetUserOrgListQuery) error {
+			query.Result = []*models.UserOrgDTO{{OrgId: 1}}
+			return nil
+		})
+		switched := false
+		bus.AddHandler("set-using-org", func(cmd *models.SetUsingOrgCommand) error {
+			switched = true
+			return nil
+		})
+
+		sc.fakeReqWithParams("GET", "/", map[string]string{"auth_token": token}).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+		assert.False(t, switched)
+		assert.Empty(t, sc.resp.Header().Get("Set-Cookie"))
+	}, configure, configureUsernameClaim, configureURLLogin, configureOrgClaim)
+
+	for _, serveFromSubPath := range []bool{false, true} {
+		serveFromSubPath := serveFromSubPath
+		configureSubPath := func(cfg *setting.Cfg) {
+			cfg.AppURL = "http://localhost:3000/grafana/"
+			cfg.AppSubURL = "/grafana"
+			cfg.ServeFromSubPath = serveFromSubPath
+		}
+
+		name := fmt.Sprintf("Token in URL redirects within the sub path (serve_from_sub_path = %t)", serveFromSubPath)
+		middlewareScenario(t, name, func(t *testing.T, sc *scenarioContext) {
+			sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+				return models.JWTClaims{"foo-username": "vladimir"}, nil
+			}
+			bus.AddHandler("get-sign-user", func(query *models.GetSignedInUserQuery) error {
+				query.Result = &models.SignedInUser{UserId: id, OrgId: orgID, Login: query.Login}
+				return nil
+			})
+			sc.userAuthTokenService.CreateTokenProvider = func(ctx context.Context, user *models.User, clientIP net.IP, userAgent string) (*models.UserToken, error) {
+				return &models.UserToken{Id: 1, UserId: user.Id, UnhashedToken: "session-token"}, nil
+			}
+
+			// the reverse proxy strips the sub path unless Grafana serves from it
+			path := "/d/abc"
+			if serveFromSubPath {
+				path = "/grafana/d/abc"
+			}
+			sc.fakeReqWithParams("GET", path, map[string]string{"auth_token": token}).exec()
+			assert.Equal(t, 302, sc.resp.Code)
+			assert.Equal(t, "/grafana/d/abc", sc.resp.Header().Get("Location"))
+		}, configure, configureUsernameClaim, configureURLLogin, configureSubPath)
+	}
+
+	middlewareScenario(t, "Token in URL does not redirect to another host", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{"foo-username": "vladimir"}, nil
+		}
+		bus.AddHandler("get-sign-user", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{UserId: id, OrgId: orgID, Login: query.Login}
+			return nil
+		})
+		sc.userAuthTokenService.CreateTokenProvider = func(ctx context.Context, user *models.User, clientIP net.IP, userAgent string) (*models.UserToken, error) {
+			return &models.UserToken{Id: 1, UserId: user.Id, UnhashedToken: "session-token"}, nil
+		}
+
+		for path, location := range map[string]string{
+			"//evil.com":  "/evil.com",
+			"///evil.com": "/evil.com",
+			"/\\evil.com": "/%5Cevil.com",
+		} {
+			sc.fakeReqWithParams("GET", "/", map[string]string{"auth_token": token})
+			sc.req.URL.Path = path
+			sc.exec()
+			assert.Equal(t, 302, sc.resp.Code)
+			assert.Equal(t, location, sc.resp.Header().Get("Location"), path)
+		}
+	}, configure, configureUsernameClaim, configureURLLogin)
+
+	middlewareScenario(t, "Invalid token in URL", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return nil, errors.New("token is invalid")
+		}
+
+		sc.fakeReqWithParams("GET", "/", map[string]string{"auth_token": token}).exec()
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+		assert.Empty(t, sc.resp.Header().Get("Set-Cookie"))
+	}, configure, configureUsernameClaim, configureURLLogin)
+
+	middlewareScenario(t, "Token in URL is ignored when URL login is disabled", func(t *testing.T, sc *scenarioContext) {
+		verified := false
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			verified = true
+			return models.JWTClaims{"foo-username": "vladimir"}, nil
+		}
+
+		sc.fakeReqWithParams("GET", "/", map[string]string{"auth_token": token}).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.False(t, verified)
+		assert.False(t, sc.context.IsSignedIn)
+	}, configure, configureUsernameClaim)
+
+	middlewareScenario(t, "Valid token with org claim", func(t *testing.T, sc *scenarioContext) {
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"foo-username": "vladimir",
+				"org":          "3",
+			}, nil
+		}
+		bus.AddHandler("get-sign-user", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{
+				UserId: id,
+				OrgId:  query.OrgId,
+				Login:  query.Login,
+			}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, int64(3), sc.context.OrgId)
+	}, configure, configureUsernameClaim, configureOrgClaim)
+
 	middlewareScenario(t, "Invalid token", func(t *testing.T, sc *scenarioContext) {
 		var verifiedToken string
 		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
@@ -25,6 +25,7 @@ import (
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/auth/jwt"
+	"github.com/grafana/grafana/pkg/services/auth/totp"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/contexthandler/authproxy"
 	"github.com/grafana/grafana/pkg/services/rendering"
@@ -163,6 +164,58 @@ func TestMiddlewareContext(t *testing.T) {
 		assert.Equal(t, models.ROLE_EDITOR, sc.context.OrgRole)
 	})
 
+	middlewareScenario(t, "Valid service account token", func(t *testing.T, sc *scenarioContext) {
+		const orgID int64 = 12
+		const serviceAccountID int64 = 33
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(query *models.GetApiKeyByNameQuery) error {
+			saID := serviceAccountID
+			query.Result = &models.ApiKey{Id: 7, OrgId: orgID, Role: models.ROLE_EDITOR, Key: keyhash, ServiceAccountId: &saID}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetServiceAccountByIdQuery) error {
+			query.Result = &models.ServiceAccountDTO{Id: query.Id, OrgId: query.OrgId, Role: models.ROLE_ADMIN}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: query.OrgId, OrgRole: models.ROLE_ADMIN, IsServiceAccount: true}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+
+		assert.True(t, sc.context.IsSignedIn)
+		assert.True(t, sc.context.IsServiceAccount)
+		assert.Equal(t, serviceAccountID, sc.context.UserId)
+		assert.Equal(t, orgID, sc.context.OrgId)
+		assert.Equal(t, models.ROLE_ADMIN, sc.context.OrgRole)
+		assert.Equal(t, int64(7), sc.context.ApiKeyId)
+	})
+
+	middlewareScenario(t, "Valid token of disabled service account", func(t *testing.T, sc *scenarioContext) {
+		keyhash, err := util.EncodePassword("v5nAwpMafFP6znaS4urhdWDLS5511M42", "asd")
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(query *models.GetApiKeyByNameQuery) error {
+			saID := int64(33)
+			query.Result = &models.ApiKey{OrgId: 12, Role: models.ROLE_EDITOR, Key: keyhash, ServiceAccountId: &saID}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetServiceAccountByIdQuery) error {
+			query.Result = &models.ServiceAccountDTO{Id: query.Id, OrgId: query.OrgId, IsDisabled: true}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withValidApiKey().exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, "Service account is disabled", sc.respJson["message"])
+	})
+
 	middlewareScenario(t, "Valid API key, but does not match DB hash", func(t *testing.T, sc *scenarioContext) {
 		const keyhash = "Something_not_matching"
 
@@ -226,6 +279,37 @@ func TestMiddlewareContext(t *testing.T) {
 		assert.Empty(t, sc.resp.Header().Get("Set-Cookie"))
 	})
 
+	middlewareScenario(t, "Auth token in cookie which is expired in the organization", func(
+		t *testing.T, sc *scenarioContext) {
+		const userID int64 = 12
+
+		sc.withTokenSessionCookie("token")
+
+		bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{OrgId: 2, UserId: userID}
+			return nil
+		})
+
+		sc.userAuthTokenService.LookupTokenProvider = func(ctx context.Context, unhashedToken string) (*models.UserToken, error) {
+			return &models.UserToken{
+				UserId:        userID,
+				UnhashedToken: unhashedToken,
+				CreatedAt:     time.Now().Add(-2 * time.Hour).Unix(),
+				RotatedAt:     time.Now().Add(-time.Hour).Unix(),
+			}, nil
+		}
+
+		sc.fakeReq("GET", "/").exec()
+
+		require.NotNil(t, sc.context)
+		assert.False(t, sc.context.IsSignedIn)
+		assert.Nil(t, sc.context.UserToken)
+		assert.Contains(t, sc.resp.Header().Get("Set-Cookie"), "grafana_session=;")
+	}, func(cfg *setting.Cfg) {
+		cfg.LoginMaxInactiveLifetime = 7 * 24 * time.Hour
+		cfg.OrgLoginMaxInactiveLifetime = map[int64]time.Duration{2: 30 * time.Minute}
+	})
+
 	middlewareScenario(t, "Non-expired auth token in cookie which is being rotated", func(t *testing.T, sc *scenarioContext) {
 		const userID int64 = 12
 
@@ -637,6 +721,10 @@ func getContextHandler(t *testing.T, cfg *setting.Cfg) *contexthandler.ContextHa
 			Name:     jwt.ServiceName,
 			Instance: authJWTSvc,
 		},
+		{
+			Name:     totp.ServiceName,
+			Instance: &totp.Service{Bus: bus.GetBus()},
+		},
 		{
 			Name:     contexthandler.ServiceName,
 			Instance: ctxHdlr,
@@ -127,6 +127,20 @@ func TestMiddlewareQuota(t *testing.T) {
 				}
 				return nil
 			})
+
+			bus.AddHandler("userTeams", func(query *models.GetTeamsByUserQuery) error {
+				query.Result = []*models.TeamDTO{{Id: 3, OrgId: query.OrgId}}
+				return nil
+			})
+
+			bus.AddHandler("teamQuota", func(query *models.GetTeamQuotaByTargetQuery) error {
+				query.Result = &models.TeamQuotaDTO{
+					Target: query.Target,
+					Limit:  query.Default,
+					Used:   quotaUsed,
+				}
+				return nil
+			})
 		}
 
 		middlewareScenario(t, "global datasource quota reached", func(t *testing.T, sc *scenarioContext) {
@@ -195,6 +209,32 @@ func TestMiddlewareQuota(t *testing.T) {
 			cfg.Quota.Org.Dashboard = quotaUsed
 		})
 
+		middlewareScenario(t, "team dashboard quota reached", func(t *testing.T, sc *scenarioContext) {
+			setUp(sc)
+
+			quotaHandler := getQuotaHandler(sc, "dashboard")
+			sc.m.Get("/dashboard", quotaHandler, sc.defaultHandler)
+			sc.fakeReq("GET", "/dashboard").exec()
+			assert.Equal(t, 403, sc.resp.Code)
+		}, func(cfg *setting.Cfg) {
+			configure(cfg)
+
+			cfg.Quota.Team.Dashboard = quotaUsed
+		})
+
+		middlewareScenario(t, "org annotation per day quota reached", func(t *testing.T, sc *scenarioContext) {
+			setUp(sc)
+
+			quotaHandler := getQuotaHandler(sc, "annotation_per_day")
+			sc.m.Get("/annotations", quotaHandler, sc.defaultHandler)
+			sc.fakeReq("GET", "/annotations").exec()
+			assert.Equal(t, 403, sc.resp.Code)
+		}, func(cfg *setting.Cfg) {
+			configure(cfg)
+
+			cfg.Quota.Org.AnnotationPerDay = quotaUsed
+		})
+
 		middlewareScenario(t, "org dashboard quota reached, but quotas disabled", func(t *testing.T, sc *scenarioContext) {
 			setUp(sc)
 
@@ -230,10 +270,29 @@ func configure(cfg *setting.Cfg) {
 			Dashboard:  5,
 			DataSource: 5,
 			ApiKey:     5,
+
+			AlertRule:         5,
+			LibraryPanel:      5,
+			DashboardSnapshot: 5,
+			AnnotationPerDay:  5,
+			DashboardBytes:    -1,
 		},
 		User: &setting.UserQuota{
 			Org: 5,
 		},
+		Folder: &setting.FolderQuota{
+			Dashboard:      5,
+			AlertRule:      5,
+			LibraryPanel:   5,
+			DashboardBytes: -1,
+		},
+		Team: &setting.TeamQuota{
+			Dashboard:         5,
+			LibraryPanel:      5,
+			DashboardSnapshot: 5,
+			AnnotationPerDay:  5,
+			DashboardBytes:    -1,
+		},
 		Global: &setting.GlobalQuota{
 			Org:        5,
 			User:       5,
@@ -241,6 +300,12 @@ func configure(cfg *setting.Cfg) {
 			DataSource: 5,
 			ApiKey:     5,
 			Session:    5,
+
+			AlertRule:         5,
+			LibraryPanel:      5,
+			DashboardSnapshot: 5,
+			AnnotationPerDay:  5,
+			DashboardBytes:    -1,
 		},
 	}
 }
@@ -18,6 +18,9 @@ type ApiKey struct {
 	Created time.Time
 	Updated time.Time
 	Expires *int64
+	// ServiceAccountId is the ID of the service account owning the key. Keys without a
+	// service account are legacy API keys.
+	ServiceAccountId *int64
 }
 
 // ---------------------
@@ -61,8 +64,9 @@ type GetApiKeyByIdQuery struct {
 // DTO & Projections
 
 type ApiKeyDTO struct {
-	Id         int64      `json:"id"`
-	Name       string     `json:"name"`
-	Role       RoleType   `json:"role"`
-	Expiration *time.Time `json:"expiration,omitempty"`
+	Id               int64      `json:"id"`
+	Name             string     `json:"name"`
+	Role             RoleType   `json:"role"`
+	Expiration       *time.Time `json:"expiration,omitempty"`
+	ServiceAccountId *int64     `json:"serviceAccountId,omitempty"`
 }
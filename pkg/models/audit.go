@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditEntry records a mutating API request: who did what, on which target and from where.
+type AuditEntry struct {
+	Id        int64     `json:"id"`
+	OrgId     int64     `json:"orgId"`
+	UserId    int64     `json:"userId"`
+	UserLogin string    `json:"userLogin"`
+	ApiKeyId  int64     `json:"apiKeyId"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Status    int       `json:"status"`
+	IpAddress string    `json:"ipAddress"`
+	RequestId string    `json:"requestId"`
+	Created   time.Time `json:"created"`
+}
+
+// ---------------------
+// COMMANDS
+
+type AddAuditEntryCommand struct {
+	Entry *AuditEntry
+}
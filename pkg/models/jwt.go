@@ -8,16 +8,22 @@ type JWTClaims map[string]interface{}
 
 type JWTService interface {
 	Verify(ctx context.Context, strToken string) (JWTClaims, error)
+	ExternalUserInfo(claims JWTClaims) (*ExternalUserInfo, error)
 }
 
 type FakeJWTService struct {
-	VerifyProvider func(context.Context, string) (JWTClaims, error)
+	VerifyProvider           func(context.Context, string) (JWTClaims, error)
+	ExternalUserInfoProvider func(JWTClaims) (*ExternalUserInfo, error)
 }
 
 func (s *FakeJWTService) Verify(ctx context.Context, token string) (JWTClaims, error) {
 	return s.VerifyProvider(ctx, token)
 }
 
+func (s *FakeJWTService) ExternalUserInfo(claims JWTClaims) (*ExternalUserInfo, error) {
+	return s.ExternalUserInfoProvider(claims)
+}
+
 func (s *FakeJWTService) Init() error {
 	return nil
 }
@@ -27,5 +33,8 @@ func NewFakeJWTService() *FakeJWTService {
 		VerifyProvider: func(ctx context.Context, token string) (JWTClaims, error) {
 			return JWTClaims{}, nil
 		},
+		ExternalUserInfoProvider: func(claims JWTClaims) (*ExternalUserInfo, error) {
+			return &ExternalUserInfo{}, nil
+		},
 	}
 }
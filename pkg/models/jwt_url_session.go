@@ -0,0 +1,20 @@
+package models
+
+import "errors"
+
+// Typed errors
+var (
+	ErrJWTURLSessionNotFound = errors.New("session was not created from a JWT in the URL")
+)
+
+// JWTURLSession is a session created from a JWT passed in the URL. ExpiresAt is the Unix time
+// the session expires at, since sessions are otherwise only limited by the login lifetime settings.
+type JWTURLSession struct {
+	Id        int64
+	TokenId   int64
+	ExpiresAt int64
+}
+
+func (s JWTURLSession) TableName() string {
+	return "jwt_url_session"
+}
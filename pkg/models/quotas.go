@@ -8,13 +8,15 @@ import (
 var ErrInvalidQuotaTarget = errors.New("invalid quota target")
 
 type Quota struct {
-	Id      int64
-	OrgId   int64
-	UserId  int64
-	Target  string
-	Limit   int64
-	Created time.Time
-	Updated time.Time
+	Id       int64
+	OrgId    int64
+	UserId   int64
+	FolderId int64
+	TeamId   int64
+	Target   string
+	Limit    int64
+	Created  time.Time
+	Updated  time.Time
 }
 
 type QuotaScope struct {
@@ -37,6 +39,30 @@ type UserQuotaDTO struct {
 	Used   int64  `json:"used"`
 }
 
+type FolderQuotaDTO struct {
+	OrgId    int64  `json:"org_id"`
+	FolderId int64  `json:"folder_id"`
+	Target   string `json:"target"`
+	Limit    int64  `json:"limit"`
+	Used     int64  `json:"used"`
+}
+
+type TeamQuotaDTO struct {
+	OrgId  int64  `json:"org_id"`
+	TeamId int64  `json:"team_id"`
+	Target string `json:"target"`
+	Limit  int64  `json:"limit"`
+	Used   int64  `json:"used"`
+}
+
+// QuotaUsageDTO reports the usage of an org against its limits, including the folders and
+// teams of the org with limits that differ from the defaults.
+type QuotaUsageDTO struct {
+	Org     []*OrgQuotaDTO    `json:"org"`
+	Folders []*FolderQuotaDTO `json:"folders"`
+	Teams   []*TeamQuotaDTO   `json:"teams"`
+}
+
 type GlobalQuotaDTO struct {
 	Target string `json:"target"`
 	Limit  int64  `json:"limit"`
@@ -67,6 +93,39 @@ type GetUserQuotasQuery struct {
 	Result []*UserQuotaDTO
 }
 
+type GetFolderQuotaByTargetQuery struct {
+	Target   string
+	OrgId    int64
+	FolderId int64
+	Default  int64
+	Result   *FolderQuotaDTO
+}
+
+type GetFolderQuotasQuery struct {
+	OrgId    int64
+	FolderId int64
+	Result   []*FolderQuotaDTO
+}
+
+type GetTeamQuotaByTargetQuery struct {
+	Target  string
+	OrgId   int64
+	TeamId  int64
+	Default int64
+	Result  *TeamQuotaDTO
+}
+
+type GetTeamQuotasQuery struct {
+	OrgId  int64
+	TeamId int64
+	Result []*TeamQuotaDTO
+}
+
+type GetQuotaUsageQuery struct {
+	OrgId  int64
+	Result *QuotaUsageDTO
+}
+
 type GetGlobalQuotaByTargetQuery struct {
 	Target  string
 	Default int64
@@ -84,3 +143,17 @@ type UpdateUserQuotaCmd struct {
 	Limit  int64  `json:"limit"`
 	UserId int64  `json:"-"`
 }
+
+type UpdateFolderQuotaCmd struct {
+	Target   string `json:"target"`
+	Limit    int64  `json:"limit"`
+	OrgId    int64  `json:"-"`
+	FolderId int64  `json:"-"`
+}
+
+type UpdateTeamQuotaCmd struct {
+	Target string `json:"target"`
+	Limit  int64  `json:"limit"`
+	OrgId  int64  `json:"-"`
+	TeamId int64  `json:"-"`
+}
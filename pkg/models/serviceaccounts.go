@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrServiceAccountNotFound      = errors.New("service account not found")
+	ErrServiceAccountNameTaken     = errors.New("service account name is taken")
+	ErrServiceAccountTokenNotFound = errors.New("service account token not found")
+)
+
+// ServiceAccountLoginPrefix is the prefix of the logins of service accounts.
+const ServiceAccountLoginPrefix = "sa-"
+
+// ---------------------
+// COMMANDS
+
+type CreateServiceAccountCommand struct {
+	Name  string   `json:"name" binding:"Required"`
+	Role  RoleType `json:"role" binding:"Required"`
+	OrgId int64    `json:"-"`
+
+	Result *ServiceAccountDTO `json:"-"`
+}
+
+type UpdateServiceAccountCommand struct {
+	Name       string   `json:"name"`
+	Role       RoleType `json:"role"`
+	IsDisabled *bool    `json:"isDisabled"`
+	OrgId      int64    `json:"-"`
+	Id         int64    `json:"-"`
+}
+
+type DeleteServiceAccountCommand struct {
+	OrgId int64
+	Id    int64
+}
+
+type AddServiceAccountTokenCommand struct {
+	Name             string `json:"name" binding:"Required"`
+	SecondsToLive    int64  `json:"secondsToLive"`
+	OrgId            int64  `json:"-"`
+	ServiceAccountId int64  `json:"-"`
+	Key              string `json:"-"`
+
+	Result *ApiKey `json:"-"`
+}
+
+type DeleteServiceAccountTokenCommand struct {
+	OrgId            int64
+	ServiceAccountId int64
+	Id               int64
+}
+
+// ----------------------
+// QUERIES
+
+type GetServiceAccountsQuery struct {
+	OrgId  int64
+	Query  string
+	Result []*ServiceAccountDTO
+}
+
+type GetServiceAccountByIdQuery struct {
+	OrgId  int64
+	Id     int64
+	Result *ServiceAccountDTO
+}
+
+type GetServiceAccountTokensQuery struct {
+	OrgId            int64
+	ServiceAccountId int64
+	Result           []*ApiKey
+}
+
+// ------------------------
+// DTO & Projections
+
+type ServiceAccountDTO struct {
+	Id         int64     `json:"id"`
+	OrgId      int64     `json:"orgId"`
+	Name       string    `json:"name"`
+	Login      string    `json:"login"`
+	Role       RoleType  `json:"role"`
+	IsDisabled bool      `json:"isDisabled"`
+	Tokens     int64     `json:"tokens"`
+	Teams      []string  `json:"teams"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Created    time.Time `json:"created"`
+}
+
+type ServiceAccountTokenDTO struct {
+	Id         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Created    time.Time  `json:"created"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+	HasExpired bool       `json:"hasExpired"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrTeamGroupAlreadyAdded = errors.New("group is already added to this team")
+	ErrTeamGroupNotFound     = errors.New("group not found in this team")
+)
+
+// TeamGroup maps an external group, such as an LDAP group DN or an OAuth group, to a team. Users
+// are added to and removed from the team according to their groups when they sign in.
+type TeamGroup struct {
+	Id      int64
+	OrgId   int64
+	TeamId  int64
+	GroupId string
+
+	Created time.Time
+	Updated time.Time
+}
+
+// ---------------------
+// COMMANDS
+
+type AddTeamGroupCommand struct {
+	GroupId string `json:"groupId" binding:"Required"`
+	OrgId   int64  `json:"-"`
+	TeamId  int64  `json:"-"`
+}
+
+type DeleteTeamGroupCommand struct {
+	GroupId string
+	OrgId   int64
+	TeamId  int64
+}
+
+// ----------------------
+// QUERIES
+
+type GetTeamGroupsQuery struct {
+	OrgId  int64
+	TeamId int64
+	Result []*TeamGroupDTO
+}
+
+// GetTeamGroupsByGroupIdsQuery returns the teams of all orgs mapped to any of the groups.
+type GetTeamGroupsByGroupIdsQuery struct {
+	GroupIds []string
+	Result   []*TeamGroup
+}
+
+// ----------------------
+// Projections and DTOs
+
+type TeamGroupDTO struct {
+	OrgId   int64  `json:"orgId"`
+	TeamId  int64  `json:"teamId"`
+	GroupId string `json:"groupId"`
+}
@@ -9,6 +9,8 @@ import (
 
 const (
 	AuthModuleLDAP = "ldap"
+	AuthModuleJWT  = "jwt"
+	AuthModuleSAML = "auth.saml"
 )
 
 type UserAuth struct {
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Typed errors
+var (
+	ErrUserTOTPNotFound = errors.New("two-factor authentication is not set up for this user")
+	ErrInvalidTOTPCode  = errors.New("invalid two-factor authentication code")
+)
+
+// UserTOTP is the time-based one-time password (TOTP) second factor of a user. The secret is
+// encrypted with the secret key, and the recovery codes are hashed. LastUsedStep is the time step
+// of the last accepted code.
+type UserTOTP struct {
+	Id            int64
+	UserId        int64
+	Secret        string
+	RecoveryCodes string
+	Enabled       bool
+	LastUsedStep  int64
+
+	Created time.Time
+	Updated time.Time
+}
+
+func (t UserTOTP) TableName() string {
+	return "user_totp"
+}
+
+// ---------------------
+// COMMANDS
+
+type SaveUserTOTPCommand struct {
+	UserId        int64
+	Secret        string
+	RecoveryCodes string
+	Enabled       bool
+}
+
+type DeleteUserTOTPCommand struct {
+	UserId int64
+}
+
+// UseUserTOTPStepCommand records the time step of an accepted code. It fails with
+// ErrInvalidTOTPCode if a code of the same or a later step was already accepted.
+type UseUserTOTPStepCommand struct {
+	UserId int64
+	Step   int64
+}
+
+// UseUserTOTPRecoveryCodeCommand removes a hashed recovery code of a user. It fails with
+// ErrInvalidTOTPCode if the user doesn't have the recovery code, or if it's used concurrently.
+type UseUserTOTPRecoveryCodeCommand struct {
+	UserId int64
+	Hash   string
+	Result int
+}
+
+// VerifyUserTOTPCommand checks a code of the authenticator app, or a recovery code, which can only
+// be used once.
+type VerifyUserTOTPCommand struct {
+	UserId int64
+	Code   string
+}
+
+// ---------------------
+// QUERIES
+
+type GetUserTOTPQuery struct {
+	UserId int64
+	Result *UserTOTP
+}
+
+// TOTPLoginQuery authenticates the second step of a login, after the password of the user has
+// been checked.
+type TOTPLoginQuery struct {
+	ReqContext *ReqContext
+	User       *User
+	Code       string
+	IpAddress  string
+	Cfg        *setting.Cfg
+}
@@ -0,0 +1,187 @@
+package plugins
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// hashLength is the length of the content hashes of the asset URLs.
+const hashLength = 16
+
+// maxPrecompressedSize is the largest total size of the gzip compressed assets of a plugin, which are kept in
+// memory. The assets beyond it aren't precompressed. It's overridden in tests.
+var maxPrecompressedSize int64 = 16 << 20
+
+var hashedAssetRegexp = regexp.MustCompile(`^(.*)\.([0-9a-f]{16})(\.[^./]+)$`)
+
+// assetExtensions are the extensions of the files indexed as assets, which excludes the plugin executables. The
+// values tell whether the files are compressed. Source maps are large and rarely requested, so they aren't.
+var assetExtensions = map[string]bool{
+	".js":    true,
+	".css":   true,
+	".json":  true,
+	".map":   false,
+	".svg":   true,
+	".html":  true,
+	".txt":   true,
+	".md":    true,
+	".png":   false,
+	".jpg":   false,
+	".jpeg":  false,
+	".gif":   false,
+	".ico":   false,
+	".webp":  false,
+	".woff":  false,
+	".woff2": false,
+	".ttf":   true,
+	".eot":   true,
+}
+
+// PluginAsset is a static file of a plugin, indexed when the plugin is initialized.
+type PluginAsset struct {
+	// Path is the path of the file, relative to the plugin directory and slash separated.
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Hash is the hex encoded SHA-256 hash of the content.
+	Hash string
+	// Integrity is the subresource integrity hash of the file, when it's signed in the manifest.
+	Integrity string
+	// Gzip is the gzip compressed content, when it's smaller.
+	Gzip []byte
+	// BrotliPath is the path of the brotli compressed variant shipped with the plugin, if any.
+	BrotliPath string
+}
+
+// ETag returns the entity tag of the asset.
+func (a *PluginAsset) ETag() string {
+	return `"` + a.Hash + `"`
+}
+
+// HashedPath returns the path of the asset with a content hash, for instance module.0123456789abcdef.js.
+func (a *PluginAsset) HashedPath() string {
+	ext := path.Ext(a.Path)
+	return strings.TrimSuffix(a.Path, ext) + "." + a.Hash[:hashLength] + ext
+}
+
+// PluginAssets are the assets of a plugin directory, by path.
+type PluginAssets map[string]*PluginAsset
+
+// Lookup returns the asset of a path relative to the plugin directory, which may contain a content hash. It
+// returns nil when the hash doesn't match the content. The second return value tells whether the path is hashed.
+func (pa PluginAssets) Lookup(p string) (*PluginAsset, bool) {
+	if a, exists := pa[p]; exists {
+		return a, false
+	}
+
+	m := hashedAssetRegexp.FindStringSubmatch(p)
+	if m == nil {
+		return nil, false
+	}
+	if a, exists := pa[m[1]+m[3]]; exists && strings.HasPrefix(a.Hash, m[2]) {
+		return a, true
+	}
+	return nil, false
+}
+
+// newPluginAssets indexes the assets among the files of a plugin directory. The hashes of the signed files give
+// the integrity of the assets matching them. Files which can't be read aren't indexed, and are served as is. The
+// assets are precompressed up to maxPrecompressedSize.
+func newPluginAssets(dir string, files []string, signedFiles map[string]string, precompress bool) PluginAssets {
+	shipped := make(map[string]bool, len(files))
+	for _, f := range files {
+		shipped[f] = true
+	}
+
+	assets := PluginAssets{}
+	precompressed := int64(0)
+	for _, f := range files {
+		compress, exists := assetExtensions[strings.ToLower(path.Ext(f))]
+		if !exists {
+			continue
+		}
+
+		asset, err := newPluginAsset(filepath.Join(dir, filepath.FromSlash(f)), compress && precompress,
+			maxPrecompressedSize-precompressed)
+		if err != nil {
+			continue
+		}
+		precompressed += int64(len(asset.Gzip))
+		asset.Path = f
+		if signedFiles[f] == asset.Hash {
+			asset.Integrity = integrity(asset.Hash)
+		}
+		if shipped[f+".br"] {
+			asset.BrotliPath = f + ".br"
+		}
+		assets[f] = asset
+	}
+
+	return assets
+}
+
+// newPluginAsset indexes a file, with its gzip compressed content when compress is set and the content
+// compresses to less than maxGzipSize.
+func newPluginAsset(file string, compress bool, maxGzipSize int64) (*PluginAsset, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `file` is based
+	// on the plugin folder structure on disk and not user input.
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	var w io.Writer = h
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	if compress && maxGzipSize > 0 {
+		gz = gzip.NewWriter(&buf)
+		w = io.MultiWriter(h, gz)
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return nil, err
+	}
+
+	asset := &PluginAsset{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Hash:    hex.EncodeToString(h.Sum(nil)),
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		if size := int64(buf.Len()); size < asset.Size && size <= maxGzipSize {
+			asset.Gzip = buf.Bytes()
+		}
+	}
+	return asset, nil
+}
+
+// integrity returns the subresource integrity hash of a hex encoded SHA-256 hash.
+func integrity(hash string) string {
+	b, err := hex.DecodeString(hash)
+	if err != nil {
+		return ""
+	}
+	return "sha256-" + base64.StdEncoding.EncodeToString(b)
+}
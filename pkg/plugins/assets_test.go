@@ -0,0 +1,110 @@
+package plugins
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginAssets(t *testing.T) {
+	dir := t.TempDir()
+	module := strings.Repeat("console.log('module');\n", 100)
+	files := map[string]string{
+		"module.js":     module,
+		"module.js.br":  "brotli",
+		"module.js.map": module,
+		"img/logo.png":  "png",
+		"gpx_plugin":    "executable",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+	signedFiles := map[string]string{
+		"img/logo.png": "0000000000000000000000000000000000000000000000000000000000000000",
+	}
+
+	assets := newPluginAssets(dir, []string{"module.js", "module.js.br", "img/logo.png", "gpx_plugin"}, signedFiles,
+		true)
+	require.Len(t, assets, 2)
+
+	t.Run("Should index the assets with their hashes and precompressed variants", func(t *testing.T) {
+		asset := assets["module.js"]
+		require.NotNil(t, asset)
+		assert.Equal(t, "module.js", asset.Path)
+		assert.Equal(t, int64(len(module)), asset.Size)
+		assert.Len(t, asset.Hash, 64)
+		assert.Equal(t, `"`+asset.Hash+`"`, asset.ETag())
+		assert.Equal(t, "module."+asset.Hash[:16]+".js", asset.HashedPath())
+		assert.Equal(t, "module.js.br", asset.BrotliPath)
+		assert.Empty(t, asset.Integrity)
+
+		r, err := gzip.NewReader(bytes.NewReader(asset.Gzip))
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, module, string(content))
+
+		logo := assets["img/logo.png"]
+		require.NotNil(t, logo)
+		assert.Nil(t, logo.Gzip)
+		assert.Empty(t, logo.Integrity, "the hash doesn't match the signed one")
+	})
+
+	t.Run("Should not precompress the source maps", func(t *testing.T) {
+		maps := newPluginAssets(dir, []string{"module.js.map"}, nil, true)
+		require.Contains(t, maps, "module.js.map")
+		assert.Nil(t, maps["module.js.map"].Gzip)
+	})
+
+	t.Run("Should not precompress beyond the size limit", func(t *testing.T) {
+		origMaxPrecompressedSize := maxPrecompressedSize
+		t.Cleanup(func() {
+			maxPrecompressedSize = origMaxPrecompressedSize
+		})
+		maxPrecompressedSize = int64(len(assets["module.js"].Gzip)) - 1
+
+		limited := newPluginAssets(dir, []string{"module.js"}, nil, true)
+		require.Contains(t, limited, "module.js")
+		assert.Nil(t, limited["module.js"].Gzip)
+	})
+
+	t.Run("Should set the integrity of the signed assets", func(t *testing.T) {
+		signed := newPluginAssets(dir, []string{"module.js"}, map[string]string{"module.js": assets["module.js"].Hash},
+			false)
+		require.Contains(t, signed, "module.js")
+		assert.True(t, strings.HasPrefix(signed["module.js"].Integrity, "sha256-"))
+		assert.Nil(t, signed["module.js"].Gzip)
+	})
+
+	t.Run("Should look up hashed paths", func(t *testing.T) {
+		asset, hashed := assets.Lookup("module.js")
+		assert.Equal(t, assets["module.js"], asset)
+		assert.False(t, hashed)
+
+		asset, hashed = assets.Lookup(assets["module.js"].HashedPath())
+		assert.Equal(t, assets["module.js"], asset)
+		assert.True(t, hashed)
+
+		asset, _ = assets.Lookup("module.0123456789abcdef.js")
+		assert.Nil(t, asset)
+
+		asset, _ = assets.Lookup("gpx_plugin")
+		assert.Nil(t, asset)
+	})
+}
+
+func TestIntegrity(t *testing.T) {
+	// the SHA-256 hash of an empty file
+	assert.Equal(t, "sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+		integrity("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+	assert.Empty(t, integrity("not hex"))
+}
@@ -0,0 +1,160 @@
+package backendplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/instrumentation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultCircuitBreakerThreshold is the number of consecutive failed requests opening the circuit.
+	DefaultCircuitBreakerThreshold = 5
+	// DefaultCircuitBreakerCooldown is how long the circuit stays open before a request probes the plugin.
+	DefaultCircuitBreakerCooldown = 30 * time.Second
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed lets the requests through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails the requests fast.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single request through, probing whether the plugin recovered.
+	CircuitHalfOpen CircuitState = "halfOpen"
+)
+
+// CircuitBreakerPlugin is implemented by the backend plugins failing requests fast with a circuit breaker.
+type CircuitBreakerPlugin interface {
+	CircuitBreaker() *CircuitBreaker
+}
+
+// CircuitBreaker fails the requests to a backend plugin fast while the plugin is down, instead of letting
+// them wait for the plugin to respond. The circuit opens after consecutive failed requests, or when the
+// plugin process exits, and closes when a request succeeds again or the process is restarted.
+type CircuitBreaker struct {
+	pluginID  string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// down is set while the plugin process isn't running, which keeps the circuit open.
+	down bool
+}
+
+// NewCircuitBreaker returns a closed circuit breaker for a backend plugin.
+func NewCircuitBreaker(pluginID string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		pluginID:  pluginID,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     CircuitClosed,
+	}
+	instrumentation.SetPluginCircuitBreakerOpen(pluginID, false)
+	return cb
+}
+
+// Allow returns an error wrapping ErrPluginUnavailable when the circuit is open. Every allowed request
+// must be recorded with Record.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if cb.down || cb.now().Before(cb.openedAt.Add(cb.cooldown)) {
+			return fmt.Errorf("%w: circuit breaker is open", ErrPluginUnavailable)
+		}
+		cb.setState(CircuitHalfOpen)
+	case CircuitHalfOpen:
+		return fmt.Errorf("%w: circuit breaker is open", ErrPluginUnavailable)
+	}
+	return nil
+}
+
+// Record records the result of an allowed request. Only the errors telling that the plugin is unavailable
+// or doesn't respond count as failures.
+func (cb *CircuitBreaker) Record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !isPluginFailure(err) {
+		cb.failures = 0
+		if cb.state == CircuitHalfOpen {
+			cb.setState(CircuitClosed)
+		}
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold {
+		cb.open()
+	}
+}
+
+// Trip opens the circuit until Reset, e.g. when the plugin process exits.
+func (cb *CircuitBreaker) Trip() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.down = true
+	cb.open()
+}
+
+// Reset closes the circuit, e.g. when the plugin process is restarted.
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.down = false
+	cb.failures = 0
+	cb.setState(CircuitClosed)
+}
+
+// State returns the state of the circuit.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+func (cb *CircuitBreaker) open() {
+	cb.openedAt = cb.now()
+	cb.setState(CircuitOpen)
+}
+
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	cb.state = state
+	instrumentation.SetPluginCircuitBreakerOpen(cb.pluginID, state != CircuitClosed)
+}
+
+// isPluginFailure returns whether a request error tells that the plugin is unavailable or doesn't respond,
+// as opposed to the errors returned by a working plugin.
+func isPluginFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrPluginUnavailable) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		if s, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+			code := s.GRPCStatus().Code()
+			return code == codes.Unavailable || code == codes.DeadlineExceeded
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package backendplugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	newBreaker := func() *CircuitBreaker {
+		cb := NewCircuitBreaker("test", 2, time.Minute)
+		cb.now = func() time.Time { return now }
+		return cb
+	}
+
+	t.Run("Should open after consecutive failures", func(t *testing.T) {
+		cb := newBreaker()
+		require.NoError(t, cb.Allow())
+		cb.Record(ErrPluginUnavailable)
+		require.Equal(t, CircuitClosed, cb.State())
+
+		require.NoError(t, cb.Allow())
+		cb.Record(status.Error(codes.Unavailable, "connection refused"))
+		require.Equal(t, CircuitOpen, cb.State())
+
+		err := cb.Allow()
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrPluginUnavailable))
+	})
+
+	t.Run("Should only count plugin failures", func(t *testing.T) {
+		cb := newBreaker()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, cb.Allow())
+			cb.Record(errors.New("query failed"))
+			require.NoError(t, cb.Allow())
+			cb.Record(status.Error(codes.InvalidArgument, "invalid query"))
+		}
+		require.Equal(t, CircuitClosed, cb.State())
+
+		require.NoError(t, cb.Allow())
+		cb.Record(context.DeadlineExceeded)
+		require.NoError(t, cb.Allow())
+		cb.Record(nil)
+		require.NoError(t, cb.Allow())
+		cb.Record(errutil.Wrap("failed to call resource", status.Error(codes.DeadlineExceeded, "timeout")))
+		require.Equal(t, CircuitClosed, cb.State())
+	})
+
+	t.Run("Should probe the plugin after the cooldown", func(t *testing.T) {
+		cb := newBreaker()
+		cb.Record(ErrPluginUnavailable)
+		cb.Record(ErrPluginUnavailable)
+		require.Equal(t, CircuitOpen, cb.State())
+
+		now = now.Add(time.Minute)
+		require.NoError(t, cb.Allow())
+		require.Equal(t, CircuitHalfOpen, cb.State())
+		require.Error(t, cb.Allow())
+
+		cb.Record(ErrPluginUnavailable)
+		require.Equal(t, CircuitOpen, cb.State())
+
+		now = now.Add(time.Minute)
+		require.NoError(t, cb.Allow())
+		cb.Record(nil)
+		require.Equal(t, CircuitClosed, cb.State())
+		require.NoError(t, cb.Allow())
+	})
+
+	t.Run("Should stay open while tripped", func(t *testing.T) {
+		cb := newBreaker()
+		cb.Trip()
+		require.Equal(t, CircuitOpen, cb.State())
+
+		now = now.Add(time.Hour)
+		require.Error(t, cb.Allow())
+
+		cb.Reset()
+		require.Equal(t, CircuitClosed, cb.State())
+		require.NoError(t, cb.Allow())
+	})
+}
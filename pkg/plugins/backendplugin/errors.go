@@ -5,10 +5,14 @@ import "errors"
 var (
 	// ErrPluginNotRegistered error returned when plugin not registered.
 	ErrPluginNotRegistered = errors.New("plugin not registered")
+	// ErrPluginNotStarted error returned when plugin is registered but not started.
+	ErrPluginNotStarted = errors.New("plugin not started")
 	// ErrHealthCheckFailed error returned when health check failed.
 	ErrHealthCheckFailed = errors.New("health check failed")
 	// ErrPluginUnavailable error returned when plugin is unavailable.
 	ErrPluginUnavailable = errors.New("plugin unavailable")
 	// ErrMethodNotImplemented error returned when plugin method not implemented.
 	ErrMethodNotImplemented = errors.New("method not implemented")
+	// ErrPluginRequestLimitExceeded error returned when plugin has too many concurrent requests.
+	ErrPluginRequestLimitExceeded = errors.New("too many concurrent plugin requests")
 )
@@ -25,9 +25,12 @@ type clientV2 struct {
 	grpcplugin.DataClient
 	grpcplugin.StreamClient
 	pluginextensionv2.RendererPlugin
+	// callOptions are the options of every call, limiting the size of the messages.
+	callOptions []grpc.CallOption
 }
 
-func newClientV2(descriptor PluginDescriptor, logger log.Logger, rpcClient plugin.ClientProtocol) (pluginClient, error) {
+func newClientV2(descriptor PluginDescriptor, logger log.Logger, rpcClient plugin.ClientProtocol,
+	breaker *backendplugin.CircuitBreaker, limits *requestLimits) (pluginClient, error) {
 	rawDiagnostics, err := rpcClient.Dispense("diagnostics")
 	if err != nil {
 		return nil, err
@@ -53,7 +56,7 @@ func newClientV2(descriptor PluginDescriptor, logger log.Logger, rpcClient plugi
 		return nil, err
 	}
 
-	c := clientV2{}
+	c := clientV2{callOptions: limits.getCallOptions()}
 	if rawDiagnostics != nil {
 		if diagnosticsClient, ok := rawDiagnostics.(grpcplugin.DiagnosticsClient); ok {
 			c.DiagnosticsClient = diagnosticsClient
@@ -68,7 +71,7 @@ func newClientV2(descriptor PluginDescriptor, logger log.Logger, rpcClient plugi
 
 	if rawData != nil {
 		if dataClient, ok := rawData.(grpcplugin.DataClient); ok {
-			c.DataClient = instrumentDataClient(dataClient)
+			c.DataClient = instrumentDataClient(dataClient, breaker, limits)
 		}
 	}
 
@@ -103,7 +106,7 @@ func (c *clientV2) CollectMetrics(ctx context.Context) (*backend.CollectMetricsR
 		return &backend.CollectMetricsResult{}, nil
 	}
 
-	protoResp, err := c.DiagnosticsClient.CollectMetrics(ctx, &pluginv2.CollectMetricsRequest{})
+	protoResp, err := c.DiagnosticsClient.CollectMetrics(ctx, &pluginv2.CollectMetricsRequest{}, c.callOptions...)
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
 			return &backend.CollectMetricsResult{}, nil
@@ -121,7 +124,8 @@ func (c *clientV2) CheckHealth(ctx context.Context, req *backend.CheckHealthRequ
 	}
 
 	protoContext := backend.ToProto().PluginContext(req.PluginContext)
-	protoResp, err := c.DiagnosticsClient.CheckHealth(ctx, &pluginv2.CheckHealthRequest{PluginContext: protoContext})
+	protoResp, err := c.DiagnosticsClient.CheckHealth(ctx, &pluginv2.CheckHealthRequest{PluginContext: protoContext},
+		c.callOptions...)
 
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
@@ -142,7 +146,7 @@ func (c *clientV2) CallResource(ctx context.Context, req *backend.CallResourceRe
 	}
 
 	protoReq := backend.ToProto().CallResourceRequest(req)
-	protoStream, err := c.ResourceClient.CallResource(ctx, protoReq)
+	protoStream, err := c.ResourceClient.CallResource(ctx, protoReq, c.callOptions...)
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
 			return backendplugin.ErrMethodNotImplemented
@@ -175,7 +179,7 @@ func (c *clientV2) SubscribeStream(ctx context.Context, req *backend.SubscribeSt
 	if c.StreamClient == nil {
 		return nil, backendplugin.ErrMethodNotImplemented
 	}
-	protoResp, err := c.StreamClient.SubscribeStream(ctx, backend.ToProto().SubscribeStreamRequest(req))
+	protoResp, err := c.StreamClient.SubscribeStream(ctx, backend.ToProto().SubscribeStreamRequest(req), c.callOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +190,7 @@ func (c *clientV2) PublishStream(ctx context.Context, req *backend.PublishStream
 	if c.StreamClient == nil {
 		return nil, backendplugin.ErrMethodNotImplemented
 	}
-	protoResp, err := c.StreamClient.PublishStream(ctx, backend.ToProto().PublishStreamRequest(req))
+	protoResp, err := c.StreamClient.PublishStream(ctx, backend.ToProto().PublishStreamRequest(req), c.callOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -199,7 +203,7 @@ func (c *clientV2) RunStream(ctx context.Context, req *backend.RunStreamRequest,
 	}
 
 	protoReq := backend.ToProto().RunStreamRequest(req)
-	protoStream, err := c.StreamClient.RunStream(ctx, protoReq)
+	protoStream, err := c.StreamClient.RunStream(ctx, protoReq, c.callOptions...)
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
 			return backendplugin.ErrMethodNotImplemented
@@ -230,16 +234,25 @@ func (fn dataClientQueryDataFunc) QueryData(ctx context.Context, req *pluginv2.Q
 	return fn(ctx, req, opts...)
 }
 
-func instrumentDataClient(plugin grpcplugin.DataClient) grpcplugin.DataClient {
+// instrumentDataClient instruments the data queries, which are limited and fail fast when the circuit
+// breaker is open.
+func instrumentDataClient(plugin grpcplugin.DataClient, breaker *backendplugin.CircuitBreaker,
+	limits *requestLimits) grpcplugin.DataClient {
 	if plugin == nil {
 		return nil
 	}
 
 	return dataClientQueryDataFunc(func(ctx context.Context, req *pluginv2.QueryDataRequest, opts ...grpc.CallOption) (*pluginv2.QueryDataResponse, error) {
 		var resp *pluginv2.QueryDataResponse
-		err := instrumentation.InstrumentQueryDataRequest(req.PluginContext.PluginId, func() (innerErr error) {
-			resp, innerErr = plugin.QueryData(ctx, req)
-			return
+		err := instrumentation.InstrumentQueryDataRequest(req.PluginContext.PluginId, func() error {
+			return limits.call(ctx, backendplugin.RequestKindQueryData, func(ctx context.Context) (innerErr error) {
+				if innerErr = breaker.Allow(); innerErr != nil {
+					return
+				}
+				resp, innerErr = plugin.QueryData(ctx, req, append(limits.getCallOptions(), opts...)...)
+				breaker.Record(innerErr)
+				return
+			})
 		})
 		return resp, err
 	})
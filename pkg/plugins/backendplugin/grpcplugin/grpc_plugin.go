@@ -3,11 +3,14 @@ package grpcplugin
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os/exec"
 	"sync"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/hashicorp/go-plugin"
 )
 
@@ -20,9 +23,13 @@ type pluginClient interface {
 
 type grpcPlugin struct {
 	descriptor    PluginDescriptor
-	clientFactory func() *plugin.Client
+	clientFactory func() *plugin.ClientConfig
 	client        *plugin.Client
+	cmd           *exec.Cmd
 	pluginClient  pluginClient
+	breaker       *backendplugin.CircuitBreaker
+	limits        setting.PluginLimits
+	requestLimits *requestLimits
 	logger        log.Logger
 	mutex         sync.RWMutex
 }
@@ -33,8 +40,10 @@ func newPlugin(descriptor PluginDescriptor) backendplugin.PluginFactoryFunc {
 		return &grpcPlugin{
 			descriptor: descriptor,
 			logger:     logger,
-			clientFactory: func() *plugin.Client {
-				return plugin.NewClient(newClientConfig(descriptor.executablePath, env, logger, descriptor.versionedPlugins))
+			breaker: backendplugin.NewCircuitBreaker(pluginID, backendplugin.DefaultCircuitBreakerThreshold,
+				backendplugin.DefaultCircuitBreakerCooldown),
+			clientFactory: func() *plugin.ClientConfig {
+				return newClientConfig(descriptor.executablePath, env, logger, descriptor.versionedPlugins)
 			},
 		}, nil
 	}
@@ -52,14 +61,23 @@ func (p *grpcPlugin) Start(ctx context.Context) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	p.client = p.clientFactory()
+	clientConfig := p.clientFactory()
+	p.cmd = clientConfig.Cmd
+	p.client = plugin.NewClient(clientConfig)
 	rpcClient, err := p.client.Client()
 	if err != nil {
 		return err
 	}
 
+	if p.limits.MemoryLimit > 0 && p.cmd.Process != nil {
+		if err := limitMemory(p.limits.CgroupParent, p.descriptor.pluginID, p.cmd.Process.Pid, p.limits.MemoryLimit); err != nil {
+			p.logger.Error("Failed to limit the memory of the plugin process", "error", err)
+		}
+	}
+
+	p.requestLimits = newRequestLimits(p.descriptor.pluginID, p.limits)
 	if p.client.NegotiatedVersion() > 1 {
-		p.pluginClient, err = newClientV2(p.descriptor, p.logger, rpcClient)
+		p.pluginClient, err = newClientV2(p.descriptor, p.logger, rpcClient, p.breaker, p.requestLimits)
 		if err != nil {
 			return err
 		}
@@ -84,6 +102,11 @@ func (p *grpcPlugin) Stop(ctx context.Context) error {
 	if p.client != nil {
 		p.client.Kill()
 	}
+	if p.limits.MemoryLimit > 0 {
+		if err := removeMemoryLimit(p.limits.CgroupParent, p.descriptor.pluginID); err != nil {
+			p.logger.Debug("Failed to remove the cgroup of the plugin process", "error", err)
+		}
+	}
 	return nil
 }
 
@@ -100,61 +123,128 @@ func (p *grpcPlugin) Exited() bool {
 	return true
 }
 
-func (p *grpcPlugin) getPluginClient() (pluginClient, bool) {
+// ExitError returns the exit status of the plugin process once it exited.
+func (p *grpcPlugin) ExitError() error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.client == nil || !p.client.Exited() || p.cmd == nil || p.cmd.ProcessState == nil {
+		return nil
+	}
+	return fmt.Errorf("plugin process exited: %s", p.cmd.ProcessState)
+}
+
+// SetLimits sets the limits of the plugin, which apply from the next time it's started.
+func (p *grpcPlugin) SetLimits(limits setting.PluginLimits) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.limits = limits
+}
+
+// CircuitBreaker returns the circuit breaker failing the requests fast while the plugin is down.
+func (p *grpcPlugin) CircuitBreaker() *backendplugin.CircuitBreaker {
+	return p.breaker
+}
+
+func (p *grpcPlugin) getRequestLimits() *requestLimits {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.requestLimits
+}
+
+// getPluginClient returns the client of the running plugin. The result of the request must be recorded
+// with the circuit breaker when no error is returned.
+func (p *grpcPlugin) getPluginClient() (pluginClient, error) {
+	if err := p.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
 	p.mutex.RLock()
 	if p.client == nil || p.client.Exited() || p.pluginClient == nil {
 		p.mutex.RUnlock()
-		return nil, false
+		p.breaker.Record(backendplugin.ErrPluginUnavailable)
+		return nil, backendplugin.ErrPluginUnavailable
 	}
 	pluginClient := p.pluginClient
 	p.mutex.RUnlock()
-	return pluginClient, true
+	return pluginClient, nil
 }
 
 func (p *grpcPlugin) CollectMetrics(ctx context.Context) (*backend.CollectMetricsResult, error) {
-	pluginClient, ok := p.getPluginClient()
-	if !ok {
-		return nil, backendplugin.ErrPluginUnavailable
-	}
-	return pluginClient.CollectMetrics(ctx)
+	var resp *backend.CollectMetricsResult
+	err := p.getRequestLimits().call(ctx, "", func(ctx context.Context) error {
+		pluginClient, err := p.getPluginClient()
+		if err != nil {
+			return err
+		}
+		resp, err = pluginClient.CollectMetrics(ctx)
+		p.breaker.Record(err)
+		return err
+	})
+	return resp, err
 }
 
 func (p *grpcPlugin) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
-	pluginClient, ok := p.getPluginClient()
-	if !ok {
-		return nil, backendplugin.ErrPluginUnavailable
-	}
-	return pluginClient.CheckHealth(ctx, req)
+	var resp *backend.CheckHealthResult
+	err := p.getRequestLimits().call(ctx, "", func(ctx context.Context) error {
+		pluginClient, err := p.getPluginClient()
+		if err != nil {
+			return err
+		}
+		resp, err = pluginClient.CheckHealth(ctx, req)
+		p.breaker.Record(err)
+		return err
+	})
+	return resp, err
 }
 
 func (p *grpcPlugin) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
-	pluginClient, ok := p.getPluginClient()
-	if !ok {
-		return backendplugin.ErrPluginUnavailable
-	}
-	return pluginClient.CallResource(ctx, req, sender)
+	return p.getRequestLimits().call(ctx, backendplugin.RequestKindCallResource, func(ctx context.Context) error {
+		pluginClient, err := p.getPluginClient()
+		if err != nil {
+			return err
+		}
+		err = pluginClient.CallResource(ctx, req, sender)
+		p.breaker.Record(err)
+		return err
+	})
 }
 
 func (p *grpcPlugin) SubscribeStream(ctx context.Context, request *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
-	pluginClient, ok := p.getPluginClient()
-	if !ok {
-		return nil, backendplugin.ErrPluginUnavailable
-	}
-	return pluginClient.SubscribeStream(ctx, request)
+	var resp *backend.SubscribeStreamResponse
+	err := p.getRequestLimits().call(ctx, "", func(ctx context.Context) error {
+		pluginClient, err := p.getPluginClient()
+		if err != nil {
+			return err
+		}
+		resp, err = pluginClient.SubscribeStream(ctx, request)
+		p.breaker.Record(err)
+		return err
+	})
+	return resp, err
 }
 
 func (p *grpcPlugin) PublishStream(ctx context.Context, request *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
-	pluginClient, ok := p.getPluginClient()
-	if !ok {
-		return nil, backendplugin.ErrPluginUnavailable
-	}
-	return pluginClient.PublishStream(ctx, request)
+	var resp *backend.PublishStreamResponse
+	err := p.getRequestLimits().call(ctx, "", func(ctx context.Context) error {
+		pluginClient, err := p.getPluginClient()
+		if err != nil {
+			return err
+		}
+		resp, err = pluginClient.PublishStream(ctx, request)
+		p.breaker.Record(err)
+		return err
+	})
+	return resp, err
 }
 
 func (p *grpcPlugin) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender backend.StreamPacketSender) error {
-	pluginClient, ok := p.getPluginClient()
-	if !ok {
-		return backendplugin.ErrPluginUnavailable
-	}
-	return pluginClient.RunStream(ctx, req, sender)
+	return p.getRequestLimits().call(ctx, backendplugin.RequestKindRunStream, func(ctx context.Context) error {
+		pluginClient, err := p.getPluginClient()
+		if err != nil {
+			return err
+		}
+		err = pluginClient.RunStream(ctx, req, sender)
+		p.breaker.Record(err)
+		return err
+	})
 }
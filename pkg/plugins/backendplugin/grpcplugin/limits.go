@@ -0,0 +1,68 @@
+package grpcplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"google.golang.org/grpc"
+)
+
+// requestLimits applies the limits of a plugin to its requests. A nil requestLimits doesn't limit them.
+type requestLimits struct {
+	limiter *backendplugin.RequestLimiter
+	timeout time.Duration
+	// callOptions limit the size of the messages of every call.
+	callOptions []grpc.CallOption
+}
+
+func newRequestLimits(pluginID string, limits setting.PluginLimits) *requestLimits {
+	rl := &requestLimits{
+		limiter: backendplugin.NewRequestLimiter(pluginID, limits),
+		timeout: limits.RequestTimeout,
+	}
+	if limits.MaxRecvMsgSize > 0 {
+		rl.callOptions = append(rl.callOptions, grpc.MaxCallRecvMsgSize(limits.MaxRecvMsgSize))
+	}
+	if limits.MaxSendMsgSize > 0 {
+		rl.callOptions = append(rl.callOptions, grpc.MaxCallSendMsgSize(limits.MaxSendMsgSize))
+	}
+	return rl
+}
+
+// call calls fn once the concurrency limit of the kind of request allows it, with the request timeout
+// unless the request is a stream. The other kinds of requests than the limited ones are only timed out.
+func (rl *requestLimits) call(ctx context.Context, kind backendplugin.RequestKind, fn func(ctx context.Context) error) error {
+	if rl == nil {
+		return fn(ctx)
+	}
+
+	release, err := rl.limiter.Acquire(ctx, kind)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if rl.timeout <= 0 || kind == backendplugin.RequestKindRunStream {
+		return fn(ctx)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, rl.timeout)
+	defer cancel()
+	err = fn(timeoutCtx)
+	if err != nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("plugin didn't respond within %s: %w", rl.timeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// getCallOptions returns the options of the calls to the plugin.
+func (rl *requestLimits) getCallOptions() []grpc.CallOption {
+	if rl == nil {
+		return nil
+	}
+	return rl.callOptions
+}
@@ -0,0 +1,66 @@
+package grpcplugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimits(t *testing.T) {
+	waitForCancel := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	t.Run("Should time out requests", func(t *testing.T) {
+		rl := newRequestLimits("test", setting.PluginLimits{RequestTimeout: 10 * time.Millisecond})
+		err := rl.call(context.Background(), backendplugin.RequestKindQueryData, waitForCancel)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.EqualError(t, err, "plugin didn't respond within 10ms: context deadline exceeded")
+	})
+
+	t.Run("Should not time out streams", func(t *testing.T) {
+		rl := newRequestLimits("test", setting.PluginLimits{RequestTimeout: time.Nanosecond})
+		err := rl.call(context.Background(), backendplugin.RequestKindRunStream, func(ctx context.Context) error {
+			_, hasDeadline := ctx.Deadline()
+			require.False(t, hasDeadline)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should return the error of a canceled request", func(t *testing.T) {
+		rl := newRequestLimits("test", setting.PluginLimits{RequestTimeout: time.Minute})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := rl.call(ctx, "", waitForCancel)
+		require.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("Should release the request once it completes", func(t *testing.T) {
+		rl := newRequestLimits("test", setting.PluginLimits{MaxConcurrentResourceCalls: 1})
+		for i := 0; i < 2; i++ {
+			err := rl.call(context.Background(), backendplugin.RequestKindCallResource, func(ctx context.Context) error {
+				return nil
+			})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Should limit the size of the messages", func(t *testing.T) {
+		require.Empty(t, newRequestLimits("test", setting.PluginLimits{}).getCallOptions())
+		require.Len(t, newRequestLimits("test", setting.PluginLimits{MaxRecvMsgSize: 1024, MaxSendMsgSize: 1024}).getCallOptions(), 2)
+
+		var rl *requestLimits
+		require.Empty(t, rl.getCallOptions())
+		require.NoError(t, rl.call(context.Background(), "", func(ctx context.Context) error {
+			return nil
+		}))
+	})
+}
@@ -0,0 +1,48 @@
+package grpcplugin
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// limitMemory moves the process of a plugin to a cgroup limiting its memory, which is created in the
+// cgroup v2 parent directory.
+func limitMemory(parent, pluginID string, pid int, limit int64) error {
+	if parent == "" {
+		return errors.New("memory limits require a cgroup_parent")
+	}
+
+	dir := pluginCgroupDir(parent, pluginID)
+	// nolint:gosec
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return err
+	}
+	if err := writeCgroupFile(dir, "memory.max", strconv.FormatInt(limit, 10)); err != nil {
+		return err
+	}
+	return writeCgroupFile(dir, "cgroup.procs", strconv.Itoa(pid))
+}
+
+// removeMemoryLimit removes the cgroup of a plugin once its process exited.
+func removeMemoryLimit(parent, pluginID string) error {
+	if parent == "" {
+		return nil
+	}
+	if err := os.Remove(pluginCgroupDir(parent, pluginID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func pluginCgroupDir(parent, pluginID string) string {
+	return filepath.Join(parent, "grafana-plugin-"+filepath.Base(pluginID))
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	// The cgroup files are created by the kernel with their own permissions
+	// nolint:gosec
+	return ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0644)
+}
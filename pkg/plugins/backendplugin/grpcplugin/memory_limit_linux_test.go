@@ -0,0 +1,41 @@
+package grpcplugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitMemory(t *testing.T) {
+	parent := t.TempDir()
+
+	err := limitMemory(parent, "test-datasource", 1234, 268435456)
+	require.NoError(t, err)
+
+	dir := filepath.Join(parent, "grafana-plugin-test-datasource")
+	memoryMax, err := ioutil.ReadFile(filepath.Join(dir, "memory.max"))
+	require.NoError(t, err)
+	require.Equal(t, "268435456", string(memoryMax))
+	procs, err := ioutil.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	require.NoError(t, err)
+	require.Equal(t, "1234", string(procs))
+
+	t.Run("Should require a cgroup parent", func(t *testing.T) {
+		require.EqualError(t, limitMemory("", "test-datasource", 1234, 268435456),
+			"memory limits require a cgroup_parent")
+	})
+
+	t.Run("Should remove the cgroup", func(t *testing.T) {
+		// the kernel removes the files of a cgroup with its directory
+		require.NoError(t, os.Remove(filepath.Join(dir, "memory.max")))
+		require.NoError(t, os.Remove(filepath.Join(dir, "cgroup.procs")))
+
+		require.NoError(t, removeMemoryLimit(parent, "test-datasource"))
+		_, err := os.Stat(dir)
+		require.True(t, os.IsNotExist(err))
+		require.NoError(t, removeMemoryLimit(parent, "test-datasource"))
+	})
+}
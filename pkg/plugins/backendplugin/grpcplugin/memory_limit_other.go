@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package grpcplugin
+
+import "errors"
+
+// limitMemory isn't supported, since cgroups are specific to Linux.
+func limitMemory(parent, pluginID string, pid int, limit int64) error {
+	return errors.New("memory limits are only supported on Linux")
+}
+
+func removeMemoryLimit(parent, pluginID string) error {
+	return nil
+}
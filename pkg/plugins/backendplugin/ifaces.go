@@ -6,6 +6,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 // Manager manages backend plugins.
@@ -14,6 +15,19 @@ type Manager interface {
 	Register(pluginID string, factory PluginFactoryFunc) error
 	// StartPlugin starts a non-managed backend plugin
 	StartPlugin(ctx context.Context, pluginID string) error
+	// StartManagedPlugin starts a managed backend plugin registered after the manager started, e.g. a
+	// plugin installed at runtime.
+	StartManagedPlugin(pluginID string) error
+	// UnregisterAndStop stops a backend plugin and unregisters it, e.g. when it is uninstalled.
+	UnregisterAndStop(ctx context.Context, pluginID string) error
+	// RestartPlugin restarts the process of a started backend plugin, enabling it again when disabled.
+	RestartPlugin(ctx context.Context, pluginID string) error
+	// DisablePlugin stops the process of a started backend plugin until it's restarted with RestartPlugin.
+	DisablePlugin(ctx context.Context, pluginID string) error
+	// PluginStatus returns the status of the process of a registered backend plugin.
+	PluginStatus(pluginID string) (PluginStatus, error)
+	// PluginStatuses returns the statuses of the processes of the registered backend plugins.
+	PluginStatuses() []PluginStatus
 	// CollectMetrics collects metrics from a registered backend plugin.
 	CollectMetrics(ctx context.Context, pluginID string) (*backend.CollectMetricsResult, error)
 	// CheckHealth checks the health of a registered backend plugin.
@@ -40,3 +54,15 @@ type Plugin interface {
 	backend.CallResourceHandler
 	backend.StreamHandler
 }
+
+// ExitErrorPlugin is implemented by the backend plugins telling why their process exited.
+type ExitErrorPlugin interface {
+	// ExitError returns the reason why the plugin process exited, or nil when it's running.
+	ExitError() error
+}
+
+// LimitedPlugin is implemented by the backend plugins whose requests and process resources can be limited.
+type LimitedPlugin interface {
+	// SetLimits sets the limits of the plugin, which apply from the next time it's started.
+	SetLimits(limits setting.PluginLimits)
+}
@@ -10,8 +10,14 @@ import (
 )
 
 var (
-	pluginRequestCounter  *prometheus.CounterVec
-	pluginRequestDuration *prometheus.SummaryVec
+	pluginRequestCounter      *prometheus.CounterVec
+	pluginRequestDuration     *prometheus.SummaryVec
+	pluginRestartCounter      *prometheus.CounterVec
+	pluginUpGauge             *prometheus.GaugeVec
+	pluginCircuitBreakerGauge *prometheus.GaugeVec
+	pluginQueuedRequestsGauge *prometheus.GaugeVec
+	pluginQueueDuration       *prometheus.HistogramVec
+	pluginRejectedCounter     *prometheus.CounterVec
 )
 
 func init() {
@@ -28,7 +34,90 @@ func init() {
 		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
 	}, []string{"plugin_id", "endpoint"})
 
-	prometheus.MustRegister(pluginRequestCounter, pluginRequestDuration)
+	pluginRestartCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_restart_total",
+		Help:      "The total amount of restarts of plugin processes which exited",
+	}, []string{"plugin_id"})
+
+	pluginUpGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_up",
+		Help:      "Whether the plugin process is running (1) or not (0)",
+	}, []string{"plugin_id"})
+
+	pluginCircuitBreakerGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_circuit_breaker_open",
+		Help:      "Whether the circuit breaker of the plugin fails the requests (1) or not (0)",
+	}, []string{"plugin_id"})
+
+	pluginQueuedRequestsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_queued_requests",
+		Help:      "The amount of plugin requests waiting for a concurrency limit",
+	}, []string{"plugin_id", "endpoint"})
+
+	pluginQueueDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "grafana",
+		Name:      "plugin_request_queue_duration_seconds",
+		Help:      "How long plugin requests waited for a concurrency limit",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30},
+	}, []string{"plugin_id", "endpoint"})
+
+	pluginRejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_request_rejected_total",
+		Help:      "The total amount of plugin requests rejected by a concurrency limit",
+	}, []string{"plugin_id", "endpoint"})
+
+	prometheus.MustRegister(pluginRequestCounter, pluginRequestDuration, pluginRestartCounter, pluginUpGauge,
+		pluginCircuitBreakerGauge, pluginQueuedRequestsGauge, pluginQueueDuration, pluginRejectedCounter)
+}
+
+// ObservePluginRestart counts a restart of a plugin process which exited.
+func ObservePluginRestart(pluginID string) {
+	pluginRestartCounter.WithLabelValues(pluginID).Inc()
+}
+
+// SetPluginUp sets whether a plugin process is running.
+func SetPluginUp(pluginID string, up bool) {
+	pluginUpGauge.WithLabelValues(pluginID).Set(boolToFloat(up))
+}
+
+// SetPluginCircuitBreakerOpen sets whether the circuit breaker of a plugin fails the requests.
+func SetPluginCircuitBreakerOpen(pluginID string, open bool) {
+	pluginCircuitBreakerGauge.WithLabelValues(pluginID).Set(boolToFloat(open))
+}
+
+// ObservePluginRequestQueued counts a plugin request waiting for a concurrency limit, and returns the
+// function to call once it stops waiting.
+func ObservePluginRequestQueued(pluginID, endpoint string) func() {
+	start := time.Now()
+	gauge := pluginQueuedRequestsGauge.WithLabelValues(pluginID, endpoint)
+	gauge.Inc()
+	return func() {
+		gauge.Dec()
+		pluginQueueDuration.WithLabelValues(pluginID, endpoint).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObservePluginRequestRejected counts a plugin request rejected by a concurrency limit.
+func ObservePluginRequestRejected(pluginID, endpoint string) {
+	pluginRejectedCounter.WithLabelValues(pluginID, endpoint).Inc()
+}
+
+// DeletePluginMetrics deletes the metrics of the process of a plugin, e.g. when the plugin is uninstalled.
+func DeletePluginMetrics(pluginID string) {
+	pluginUpGauge.DeleteLabelValues(pluginID)
+	pluginCircuitBreakerGauge.DeleteLabelValues(pluginID)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
 }
 
 // instrumentPluginRequest instruments success rate and latency of `fn`
@@ -9,9 +9,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -28,8 +28,10 @@ import (
 
 func init() {
 	registry.RegisterServiceWithPriority(&manager{
-		logger:  log.New("plugins.backend"),
-		plugins: map[string]backendplugin.Plugin{},
+		logger:        log.New("plugins.backend"),
+		plugins:       map[string]backendplugin.Plugin{},
+		supervisors:   map[string]*supervisor{},
+		restartPolicy: defaultRestartPolicy,
 	}, registry.MediumHigh)
 }
 
@@ -40,6 +42,15 @@ type manager struct {
 	pluginsMu              sync.RWMutex
 	plugins                map[string]backendplugin.Plugin
 	logger                 log.Logger
+
+	// lifecycleMu serializes starting, restarting and stopping the plugins, which can take a while and
+	// mustn't hold pluginsMu.
+	lifecycleMu sync.Mutex
+	// runCtx is the context of the running manager, and supervisors restart the processes of the
+	// started plugins when they exit. Both are guarded by pluginsMu.
+	runCtx        context.Context
+	supervisors   map[string]*supervisor
+	restartPolicy restartPolicy
 }
 
 func (m *manager) Init() error {
@@ -90,6 +101,9 @@ func (m *manager) Register(pluginID string, factory backendplugin.PluginFactoryF
 	if err != nil {
 		return err
 	}
+	if limitedPlugin, ok := plugin.(backendplugin.LimitedPlugin); ok {
+		limitedPlugin.SetLimits(m.Cfg.PluginLimitsFor(pluginID))
+	}
 
 	m.plugins[pluginID] = plugin
 	m.logger.Debug("Backend plugin registered", "pluginId", pluginID)
@@ -128,24 +142,103 @@ func (m *manager) GetDataPlugin(pluginID string) interface{} {
 
 // start starts all managed backend plugins
 func (m *manager) start(ctx context.Context) {
-	m.pluginsMu.RLock()
-	defer m.pluginsMu.RUnlock()
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+	m.pluginsMu.Lock()
+	m.runCtx = ctx
+	var managed []backendplugin.Plugin
 	for _, p := range m.plugins {
-		if !p.IsManaged() {
-			continue
+		if p.IsManaged() {
+			managed = append(managed, p)
 		}
+	}
+	m.pluginsMu.Unlock()
 
-		if err := startPluginAndRestartKilledProcesses(ctx, p); err != nil {
+	for _, p := range managed {
+		if err := m.startPlugin(ctx, p, ctx); err != nil {
 			p.Logger().Error("Failed to start plugin", "error", err)
 			continue
 		}
 	}
 }
 
+// startPlugin starts a plugin and supervises its process until the parent context is done. The
+// caller must hold lifecycleMu.
+func (m *manager) startPlugin(ctx context.Context, p backendplugin.Plugin, parentCtx context.Context) error {
+	s := newSupervisor(p, m.restartPolicy, parentCtx)
+	m.pluginsMu.RLock()
+	if previous := m.supervisors[p.PluginID()]; previous != nil {
+		s.inherit(previous)
+	}
+	m.pluginsMu.RUnlock()
+	err := s.start(ctx)
+
+	m.pluginsMu.Lock()
+	m.supervisors[p.PluginID()] = s
+	m.pluginsMu.Unlock()
+	return err
+}
+
+// StartManagedPlugin starts a managed backend plugin registered after the manager started.
+func (m *manager) StartManagedPlugin(pluginID string) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+	m.pluginsMu.RLock()
+	p, registered := m.plugins[pluginID]
+	runCtx := m.runCtx
+	s := m.supervisors[pluginID]
+	m.pluginsMu.RUnlock()
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	if !p.IsManaged() {
+		return errors.New("backend plugin is not managed and must be started with StartPlugin")
+	}
+
+	// the plugin is started with the other plugins when the manager runs
+	if runCtx == nil {
+		return nil
+	}
+	if s != nil && s.running() {
+		return nil
+	}
+
+	return m.startPlugin(runCtx, p, runCtx)
+}
+
+// UnregisterAndStop stops a backend plugin and unregisters it.
+func (m *manager) UnregisterAndStop(ctx context.Context, pluginID string) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+	m.pluginsMu.Lock()
+	p, registered := m.plugins[pluginID]
+	if !registered {
+		m.pluginsMu.Unlock()
+		return backendplugin.ErrPluginNotRegistered
+	}
+	delete(m.plugins, pluginID)
+	s := m.supervisors[pluginID]
+	delete(m.supervisors, pluginID)
+	m.pluginsMu.Unlock()
+
+	// the killed process must not be restarted once stopped
+	if s != nil {
+		s.stop()
+	}
+	instrumentation.DeletePluginMetrics(pluginID)
+
+	m.logger.Debug("Stopping and unregistering backend plugin", "pluginId", pluginID)
+	return p.Stop(ctx)
+}
+
 // StartPlugin starts a non-managed backend plugin
 func (m *manager) StartPlugin(ctx context.Context, pluginID string) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
 	m.pluginsMu.RLock()
 	p, registered := m.plugins[pluginID]
+	s := m.supervisors[pluginID]
 	m.pluginsMu.RUnlock()
 	if !registered {
 		return backendplugin.ErrPluginNotRegistered
@@ -155,13 +248,117 @@ func (m *manager) StartPlugin(ctx context.Context, pluginID string) error {
 		return errors.New("backend plugin is managed and cannot be manually started")
 	}
 
-	return startPluginAndRestartKilledProcesses(ctx, p)
+	if s != nil {
+		s.stop()
+	}
+	return m.startPlugin(ctx, p, ctx)
 }
 
-// stop stops all managed backend plugins
-func (m *manager) stop(ctx context.Context) {
+// RestartPlugin restarts the process of a started backend plugin, resetting its restart backoff.
+func (m *manager) RestartPlugin(ctx context.Context, pluginID string) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+	m.pluginsMu.RLock()
+	p, registered := m.plugins[pluginID]
+	runCtx := m.runCtx
+	s := m.supervisors[pluginID]
+	m.pluginsMu.RUnlock()
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	parentCtx := runCtx
+	if s != nil {
+		parentCtx = s.parentCtx
+		s.stop()
+	}
+	if parentCtx == nil || parentCtx.Err() != nil {
+		return backendplugin.ErrPluginNotStarted
+	}
+
+	p.Logger().Info("Restarting plugin")
+	if err := p.Stop(ctx); err != nil {
+		return err
+	}
+	return m.startPlugin(ctx, p, parentCtx)
+}
+
+// DisablePlugin stops the process of a started backend plugin until it's restarted with RestartPlugin.
+func (m *manager) DisablePlugin(ctx context.Context, pluginID string) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+	m.pluginsMu.RLock()
+	p, registered := m.plugins[pluginID]
+	s := m.supervisors[pluginID]
+	m.pluginsMu.RUnlock()
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+	if s == nil {
+		return backendplugin.ErrPluginNotStarted
+	}
+
+	p.Logger().Info("Disabling plugin")
+	s.stop()
+	s.disabled()
+	return p.Stop(ctx)
+}
+
+// PluginStatus returns the status of the process of a registered backend plugin.
+func (m *manager) PluginStatus(pluginID string) (backendplugin.PluginStatus, error) {
 	m.pluginsMu.RLock()
 	defer m.pluginsMu.RUnlock()
+	p, registered := m.plugins[pluginID]
+	if !registered {
+		return backendplugin.PluginStatus{}, backendplugin.ErrPluginNotRegistered
+	}
+
+	return m.pluginStatus(p), nil
+}
+
+// PluginStatuses returns the statuses of the processes of the registered backend plugins.
+func (m *manager) PluginStatuses() []backendplugin.PluginStatus {
+	m.pluginsMu.RLock()
+	defer m.pluginsMu.RUnlock()
+
+	statuses := make([]backendplugin.PluginStatus, 0, len(m.plugins))
+	for _, p := range m.plugins {
+		statuses = append(statuses, m.pluginStatus(p))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PluginID < statuses[j].PluginID
+	})
+	return statuses
+}
+
+// pluginStatus returns the status of a registered plugin. The caller must hold pluginsMu.
+func (m *manager) pluginStatus(p backendplugin.Plugin) backendplugin.PluginStatus {
+	if s := m.supervisors[p.PluginID()]; s != nil {
+		return s.status()
+	}
+
+	status := backendplugin.PluginStatus{
+		PluginID: p.PluginID(),
+		Managed:  p.IsManaged(),
+		State:    backendplugin.PluginStateNotStarted,
+	}
+	if cbp, ok := p.(backendplugin.CircuitBreakerPlugin); ok {
+		status.CircuitBreaker = cbp.CircuitBreaker().State()
+	}
+	return status
+}
+
+// stop stops all managed backend plugins
+func (m *manager) stop(ctx context.Context) {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+	m.pluginsMu.Lock()
+	defer m.pluginsMu.Unlock()
+	m.runCtx = nil
+	for _, s := range m.supervisors {
+		s.stop()
+	}
+	m.supervisors = map[string]*supervisor{}
 	var wg sync.WaitGroup
 	for _, p := range m.plugins {
 		wg.Add(1)
@@ -351,6 +548,16 @@ func handleCallResourceError(err error, reqCtx *models.ReqContext) {
 		return
 	}
 
+	if errors.Is(err, backendplugin.ErrPluginRequestLimitExceeded) {
+		reqCtx.JsonApiErr(429, "Too many requests to plugin", err)
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		reqCtx.JsonApiErr(504, "Plugin request timed out", err)
+		return
+	}
+
 	reqCtx.JsonApiErr(500, "Failed to call resource", err)
 }
 
@@ -409,45 +616,6 @@ func flushStream(plugin backendplugin.Plugin, stream callResourceClientResponseS
 	}
 }
 
-func startPluginAndRestartKilledProcesses(ctx context.Context, p backendplugin.Plugin) error {
-	if err := p.Start(ctx); err != nil {
-		return err
-	}
-
-	go func(ctx context.Context, p backendplugin.Plugin) {
-		if err := restartKilledProcess(ctx, p); err != nil {
-			p.Logger().Error("Attempt to restart killed plugin process failed", "error", err)
-		}
-	}(ctx, p)
-
-	return nil
-}
-
-func restartKilledProcess(ctx context.Context, p backendplugin.Plugin) error {
-	ticker := time.NewTicker(time.Second * 1)
-
-	for {
-		select {
-		case <-ctx.Done():
-			if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
-				return err
-			}
-			return nil
-		case <-ticker.C:
-			if !p.Exited() {
-				continue
-			}
-
-			p.Logger().Debug("Restarting plugin")
-			if err := p.Start(ctx); err != nil {
-				p.Logger().Error("Failed to restart plugin", "error", err)
-				continue
-			}
-			p.Logger().Debug("Plugin restarted")
-		}
-	}
-}
-
 // callResourceClientResponseStream is used for receiving resource call responses.
 type callResourceClientResponseStream interface {
 	Recv() (*backend.CallResourceResponse, error)
@@ -46,6 +46,9 @@ func TestManager(t *testing.T) {
 			ctx.license.edition = "Open Source"
 			ctx.license.hasLicense = false
 			ctx.cfg.BuildVersion = "7.0.0"
+			ctx.cfg.PluginLimits = map[string]setting.PluginLimits{
+				testPluginID: {MaxConcurrentQueries: 2, RequestTimeout: time.Minute},
+			}
 
 			t.Run("Should be able to register plugin", func(t *testing.T) {
 				err := ctx.manager.Register(testPluginID, ctx.factory)
@@ -59,6 +62,11 @@ func TestManager(t *testing.T) {
 					require.Error(t, err)
 				})
 
+				t.Run("Should set the limits of the plugin", func(t *testing.T) {
+					require.Equal(t, setting.PluginLimits{MaxConcurrentQueries: 2, RequestTimeout: time.Minute},
+						ctx.plugin.limits)
+				})
+
 				t.Run("Should provide expected host environment variables", func(t *testing.T) {
 					require.Len(t, ctx.env, 4)
 					require.EqualValues(t, []string{"GF_VERSION=7.0.0", "GF_EDITION=Open Source", fmt.Sprintf("%s=true", awsds.AssumeRoleEnabledEnvVarKeyName), fmt.Sprintf("%s=keys,credentials", awsds.AllowedAuthProvidersEnvVarKeyName)}, ctx.env)
@@ -195,6 +203,56 @@ func TestManager(t *testing.T) {
 		})
 	})
 
+	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		t.Run("Managed plugin installed at runtime scenario", func(t *testing.T) {
+			cCtx, cancel := context.WithCancel(context.Background())
+			var wg sync.WaitGroup
+			wg.Add(1)
+			var runErr error
+			go func() {
+				runErr = ctx.manager.Run(cCtx)
+				wg.Done()
+			}()
+			time.Sleep(time.Millisecond)
+
+			err := ctx.manager.StartManagedPlugin(testPluginID)
+			require.Equal(t, backendplugin.ErrPluginNotRegistered, err)
+
+			err = ctx.manager.Register(testPluginID, ctx.factory)
+			require.NoError(t, err)
+
+			t.Run("Should start plugin once", func(t *testing.T) {
+				err := ctx.manager.StartManagedPlugin(testPluginID)
+				require.NoError(t, err)
+				err = ctx.manager.StartManagedPlugin(testPluginID)
+				require.NoError(t, err)
+				require.Equal(t, 1, ctx.plugin.startCount)
+			})
+
+			t.Run("Should stop and unregister plugin", func(t *testing.T) {
+				err := ctx.manager.UnregisterAndStop(context.Background(), testPluginID)
+				require.NoError(t, err)
+				require.Equal(t, 1, ctx.plugin.stopCount)
+				_, registered := ctx.manager.Get(testPluginID)
+				require.False(t, registered)
+
+				err = ctx.manager.UnregisterAndStop(context.Background(), testPluginID)
+				require.Equal(t, backendplugin.ErrPluginNotRegistered, err)
+			})
+
+			t.Run("Should not restart unregistered plugin when killed", func(t *testing.T) {
+				ctx.plugin.kill()
+				time.Sleep(1100 * time.Millisecond)
+				require.Equal(t, 1, ctx.plugin.startCount)
+			})
+
+			cancel()
+			wg.Wait()
+			require.Equal(t, context.Canceled, runErr)
+			require.Equal(t, 1, ctx.plugin.stopCount)
+		})
+	})
+
 	newManagerScenario(t, false, func(t *testing.T, ctx *managerScenarioCtx) {
 		t.Run("Unmanaged plugin scenario", func(t *testing.T) {
 			ctx.license.edition = "Open Source"
@@ -296,6 +354,8 @@ func newManagerScenario(t *testing.T, managed bool, fn func(t *testing.T, ctx *m
 			PluginRequestValidator: validator,
 			logger:                 log.New("test"),
 			plugins:                map[string]backendplugin.Plugin{},
+			supervisors:            map[string]*supervisor{},
+			restartPolicy:          defaultRestartPolicy,
 		},
 	}
 
@@ -323,6 +383,7 @@ type testPlugin struct {
 	stopCount  int
 	managed    bool
 	exited     bool
+	limits     setting.PluginLimits
 	backend.CollectMetricsHandlerFunc
 	backend.CheckHealthHandlerFunc
 	backend.CallResourceHandlerFunc
@@ -362,6 +423,10 @@ func (tp *testPlugin) Exited() bool {
 	return tp.exited
 }
 
+func (tp *testPlugin) SetLimits(limits setting.PluginLimits) {
+	tp.limits = limits
+}
+
 func (tp *testPlugin) kill() {
 	tp.mutex.Lock()
 	defer tp.mutex.Unlock()
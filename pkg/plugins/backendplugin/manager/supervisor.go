@@ -0,0 +1,275 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/instrumentation"
+)
+
+// restartPolicy configures how the processes of the plugins are restarted when they exit.
+type restartPolicy struct {
+	// checkInterval is how often the processes are checked.
+	checkInterval time.Duration
+	// minBackoff is the delay before restarting a process the first time, which doubles every time it
+	// exits again before running for stableAfter, up to maxBackoff.
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	stableAfter time.Duration
+}
+
+var defaultRestartPolicy = restartPolicy{
+	checkInterval: time.Second,
+	minBackoff:    time.Second,
+	maxBackoff:    5 * time.Minute,
+	stableAfter:   time.Minute,
+}
+
+// backoff returns the delay before restarting a process after a number of consecutive failures.
+func (rp restartPolicy) backoff(failures int) time.Duration {
+	delay := rp.minBackoff
+	for i := 1; i < failures && delay < rp.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > rp.maxBackoff {
+		return rp.maxBackoff
+	}
+	return delay
+}
+
+// supervisor restarts the process of a plugin when it exits, with an exponential backoff between the
+// restarts of a crash-looping plugin. Its circuit breaker is open while the process is down.
+type supervisor struct {
+	plugin  backendplugin.Plugin
+	breaker *backendplugin.CircuitBreaker
+	policy  restartPolicy
+	// parentCtx is the context the plugin was started with, which restarting it manually reuses.
+	parentCtx context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+
+	mu          sync.Mutex
+	state       backendplugin.PluginState
+	restarts    int
+	failures    int
+	startedAt   time.Time
+	lastExitErr error
+	lastExit    time.Time
+	nextRestart time.Time
+}
+
+func newSupervisor(p backendplugin.Plugin, policy restartPolicy, parentCtx context.Context) *supervisor {
+	s := &supervisor{
+		plugin:    p,
+		policy:    policy,
+		parentCtx: parentCtx,
+		state:     backendplugin.PluginStateNotStarted,
+	}
+	if cbp, ok := p.(backendplugin.CircuitBreakerPlugin); ok {
+		s.breaker = cbp.CircuitBreaker()
+	}
+	return s
+}
+
+// start starts the plugin and supervises its process until the parent context is done or stop is
+// called. The supervisor of a plugin failing to start doesn't restart it.
+func (s *supervisor) start(ctx context.Context) error {
+	if err := s.plugin.Start(ctx); err != nil {
+		s.mu.Lock()
+		s.lastExitErr = err
+		s.mu.Unlock()
+		return err
+	}
+	s.started()
+
+	runCtx, cancel := context.WithCancel(s.parentCtx)
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.run(runCtx)
+	return nil
+}
+
+// stop stops supervising the process, waiting for a restart in progress to complete. The process
+// itself isn't stopped.
+func (s *supervisor) stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.cancel = nil
+}
+
+// running returns whether the process is supervised.
+func (s *supervisor) running() bool {
+	return s.cancel != nil
+}
+
+func (s *supervisor) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.policy.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if ctx.Err() != nil || !s.plugin.Exited() {
+			continue
+		}
+
+		delay := s.exited()
+		for {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			s.plugin.Logger().Debug("Restarting plugin")
+			if err := s.plugin.Start(ctx); err != nil {
+				delay = s.restartFailed(err)
+				continue
+			}
+			s.restarted()
+			break
+		}
+	}
+}
+
+// started records that the process was started, e.g. manually.
+func (s *supervisor) started() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = backendplugin.PluginStateRunning
+	s.startedAt = time.Now()
+	s.nextRestart = time.Time{}
+	s.setUp(true)
+}
+
+// exited records that the process exited and returns the delay before restarting it.
+func (s *supervisor) exited() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastExit = time.Now()
+	s.lastExitErr = errors.New("plugin process exited")
+	if eep, ok := s.plugin.(backendplugin.ExitErrorPlugin); ok {
+		if err := eep.ExitError(); err != nil {
+			s.lastExitErr = err
+		}
+	}
+	if s.lastExit.Sub(s.startedAt) >= s.policy.stableAfter {
+		s.failures = 0
+	}
+	s.failures++
+	delay := s.scheduleRestart()
+	s.setUp(false)
+
+	s.plugin.Logger().Warn("Plugin process exited", "error", s.lastExitErr, "restartIn", delay)
+	return delay
+}
+
+// restartFailed records that restarting the process failed and returns the delay before retrying.
+func (s *supervisor) restartFailed(err error) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastExitErr = err
+	s.failures++
+	delay := s.scheduleRestart()
+
+	s.plugin.Logger().Error("Failed to restart plugin", "error", err, "retryIn", delay)
+	return delay
+}
+
+// restarted records that the process was restarted after exiting.
+func (s *supervisor) restarted() {
+	s.mu.Lock()
+	s.restarts++
+	s.mu.Unlock()
+	instrumentation.ObservePluginRestart(s.plugin.PluginID())
+	s.started()
+	s.plugin.Logger().Debug("Plugin restarted")
+}
+
+// disabled records that the process was stopped by an administrator.
+func (s *supervisor) disabled() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = backendplugin.PluginStateDisabled
+	s.nextRestart = time.Time{}
+	s.setUp(false)
+}
+
+// scheduleRestart returns the delay before restarting the process. The caller must hold the lock.
+func (s *supervisor) scheduleRestart() time.Duration {
+	delay := s.policy.backoff(s.failures)
+	s.state = backendplugin.PluginStateRestarting
+	s.nextRestart = time.Now().Add(delay)
+	return delay
+}
+
+// setUp sets whether the process is running, and the circuit breaker accordingly. The caller must
+// hold the lock.
+func (s *supervisor) setUp(up bool) {
+	instrumentation.SetPluginUp(s.plugin.PluginID(), up)
+	if s.breaker == nil {
+		return
+	}
+	if up {
+		s.breaker.Reset()
+	} else {
+		s.breaker.Trip()
+	}
+}
+
+// inherit keeps the history of the supervisor replaced by s when the plugin is restarted manually.
+func (s *supervisor) inherit(previous *supervisor) {
+	previous.mu.Lock()
+	defer previous.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restarts = previous.restarts
+	s.lastExitErr = previous.lastExitErr
+	s.lastExit = previous.lastExit
+}
+
+func (s *supervisor) status() backendplugin.PluginStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := backendplugin.PluginStatus{
+		PluginID: s.plugin.PluginID(),
+		Managed:  s.plugin.IsManaged(),
+		State:    s.state,
+		Restarts: s.restarts,
+	}
+	// the process exited since it was last checked
+	if status.State == backendplugin.PluginStateRunning && s.plugin.Exited() {
+		status.State = backendplugin.PluginStateRestarting
+	}
+	if s.lastExitErr != nil {
+		status.LastExitError = s.lastExitErr.Error()
+	}
+	if !s.lastExit.IsZero() {
+		lastExit := s.lastExit
+		status.LastExitTime = &lastExit
+	}
+	if !s.nextRestart.IsZero() {
+		nextRestart := s.nextRestart
+		status.NextRestartTime = &nextRestart
+	}
+	if s.breaker != nil {
+		status.CircuitBreaker = s.breaker.State()
+	}
+	return status
+}
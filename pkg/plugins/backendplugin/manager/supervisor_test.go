@@ -0,0 +1,156 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartPolicy(t *testing.T) {
+	policy := restartPolicy{minBackoff: time.Second, maxBackoff: 5 * time.Second}
+	for failures, expected := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		4:  5 * time.Second,
+		50: 5 * time.Second,
+	} {
+		require.Equal(t, expected, policy.backoff(failures), "failures: %d", failures)
+	}
+}
+
+func TestPluginSupervision(t *testing.T) {
+	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		ctx.manager.restartPolicy = restartPolicy{
+			checkInterval: time.Millisecond,
+			minBackoff:    time.Millisecond,
+			maxBackoff:    4 * time.Millisecond,
+			stableAfter:   time.Hour,
+		}
+		breaker := backendplugin.NewCircuitBreaker(testPluginID, 5, time.Minute)
+		err := ctx.manager.Register(testPluginID, func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+			p, err := ctx.factory(pluginID, logger, env)
+			return &circuitBreakerTestPlugin{testPlugin: p.(*testPlugin), breaker: breaker}, err
+		})
+		require.NoError(t, err)
+
+		t.Run("Plugin not started", func(t *testing.T) {
+			status, err := ctx.manager.PluginStatus(testPluginID)
+			require.NoError(t, err)
+			require.Equal(t, backendplugin.PluginStatus{
+				PluginID:       testPluginID,
+				Managed:        true,
+				State:          backendplugin.PluginStateNotStarted,
+				CircuitBreaker: backendplugin.CircuitClosed,
+			}, status)
+
+			err = ctx.manager.RestartPlugin(context.Background(), testPluginID)
+			require.Equal(t, backendplugin.ErrPluginNotStarted, err)
+			err = ctx.manager.DisablePlugin(context.Background(), testPluginID)
+			require.Equal(t, backendplugin.ErrPluginNotStarted, err)
+
+			_, err = ctx.manager.PluginStatus("unknown")
+			require.Equal(t, backendplugin.ErrPluginNotRegistered, err)
+			err = ctx.manager.RestartPlugin(context.Background(), "unknown")
+			require.Equal(t, backendplugin.ErrPluginNotRegistered, err)
+		})
+
+		cCtx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			_ = ctx.manager.Run(cCtx)
+			wg.Done()
+		}()
+		require.Eventually(t, func() bool { return ctx.plugin.getStartCount() == 1 }, time.Second, time.Millisecond)
+
+		t.Run("Should restart crash-looping plugin with backoff", func(t *testing.T) {
+			for i := 1; i <= 3; i++ {
+				ctx.plugin.kill()
+				require.Eventually(t, func() bool {
+					status, err := ctx.manager.PluginStatus(testPluginID)
+					require.NoError(t, err)
+					return status.Restarts == i && status.State == backendplugin.PluginStateRunning
+				}, time.Second, time.Millisecond)
+			}
+
+			status, err := ctx.manager.PluginStatus(testPluginID)
+			require.NoError(t, err)
+			require.Equal(t, "plugin process exited", status.LastExitError)
+			require.NotNil(t, status.LastExitTime)
+			require.Nil(t, status.NextRestartTime)
+			require.Equal(t, backendplugin.CircuitClosed, status.CircuitBreaker)
+			require.Equal(t, 4, ctx.plugin.getStartCount())
+
+			s := ctx.manager.supervisors[testPluginID]
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			require.Equal(t, 3, s.failures)
+		})
+
+		t.Run("Should not restart disabled plugin", func(t *testing.T) {
+			err := ctx.manager.DisablePlugin(context.Background(), testPluginID)
+			require.NoError(t, err)
+			require.Equal(t, 1, ctx.plugin.getStopCount())
+
+			status, err := ctx.manager.PluginStatus(testPluginID)
+			require.NoError(t, err)
+			require.Equal(t, backendplugin.PluginStateDisabled, status.State)
+			require.Equal(t, backendplugin.CircuitOpen, status.CircuitBreaker)
+			require.ErrorIs(t, breaker.Allow(), backendplugin.ErrPluginUnavailable)
+
+			ctx.plugin.kill()
+			time.Sleep(10 * time.Millisecond)
+			require.Equal(t, 4, ctx.plugin.getStartCount())
+		})
+
+		t.Run("Should restart disabled plugin manually", func(t *testing.T) {
+			err := ctx.manager.RestartPlugin(context.Background(), testPluginID)
+			require.NoError(t, err)
+			require.Equal(t, 5, ctx.plugin.getStartCount())
+			require.Equal(t, 2, ctx.plugin.getStopCount())
+
+			statuses := ctx.manager.PluginStatuses()
+			require.Len(t, statuses, 1)
+			require.Equal(t, backendplugin.PluginStateRunning, statuses[0].State)
+			require.Equal(t, 3, statuses[0].Restarts)
+			require.Equal(t, backendplugin.CircuitClosed, statuses[0].CircuitBreaker)
+			require.NoError(t, breaker.Allow())
+			breaker.Record(nil)
+
+			s := ctx.manager.supervisors[testPluginID]
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			require.Equal(t, 0, s.failures)
+		})
+
+		cancel()
+		wg.Wait()
+	})
+}
+
+type circuitBreakerTestPlugin struct {
+	*testPlugin
+	breaker *backendplugin.CircuitBreaker
+}
+
+func (p *circuitBreakerTestPlugin) CircuitBreaker() *backendplugin.CircuitBreaker {
+	return p.breaker
+}
+
+func (tp *testPlugin) getStartCount() int {
+	tp.mutex.RLock()
+	defer tp.mutex.RUnlock()
+	return tp.startCount
+}
+
+func (tp *testPlugin) getStopCount() int {
+	tp.mutex.RLock()
+	defer tp.mutex.RUnlock()
+	return tp.stopCount
+}
@@ -0,0 +1,79 @@
+package backendplugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/instrumentation"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// RequestKind is a kind of request to a backend plugin, whose concurrency is limited separately.
+type RequestKind string
+
+const (
+	RequestKindQueryData    RequestKind = "queryData"
+	RequestKindCallResource RequestKind = "callResource"
+	RequestKindRunStream    RequestKind = "runStream"
+)
+
+// RequestLimiter limits the concurrent requests of each kind to a backend plugin. A request over the limit
+// waits for another request to complete, up to the queue timeout. A nil RequestLimiter doesn't limit the
+// requests.
+type RequestLimiter struct {
+	pluginID     string
+	queueTimeout time.Duration
+	slots        map[RequestKind]chan struct{}
+}
+
+// NewRequestLimiter returns a limiter of the requests to a backend plugin.
+func NewRequestLimiter(pluginID string, limits setting.PluginLimits) *RequestLimiter {
+	l := &RequestLimiter{
+		pluginID:     pluginID,
+		queueTimeout: limits.QueueTimeout,
+		slots:        map[RequestKind]chan struct{}{},
+	}
+	for kind, max := range map[RequestKind]int{
+		RequestKindQueryData:    limits.MaxConcurrentQueries,
+		RequestKindCallResource: limits.MaxConcurrentResourceCalls,
+		RequestKindRunStream:    limits.MaxConcurrentStreams,
+	} {
+		if max > 0 {
+			l.slots[kind] = make(chan struct{}, max)
+		}
+	}
+	return l
+}
+
+// Acquire waits for a request to be allowed and returns the function to call once it completes. It returns
+// an error wrapping ErrPluginRequestLimitExceeded when the request waited for the queue timeout, or the
+// error of the context when it's done first.
+func (l *RequestLimiter) Acquire(ctx context.Context, kind RequestKind) (func(), error) {
+	if l == nil || l.slots[kind] == nil {
+		return func() {}, nil
+	}
+	slots := l.slots[kind]
+	release := func() { <-slots }
+
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	dequeued := instrumentation.ObservePluginRequestQueued(l.pluginID, string(kind))
+	defer dequeued()
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		instrumentation.ObservePluginRequestRejected(l.pluginID, string(kind))
+		return nil, fmt.Errorf("%w: %s is limited to %d concurrent requests", ErrPluginRequestLimitExceeded,
+			kind, cap(slots))
+	}
+}
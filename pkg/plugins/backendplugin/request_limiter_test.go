@@ -0,0 +1,71 @@
+package backendplugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimiter(t *testing.T) {
+	t.Run("Should not limit requests without a limit", func(t *testing.T) {
+		l := NewRequestLimiter("test", setting.PluginLimits{MaxConcurrentQueries: 1})
+		for i := 0; i < 3; i++ {
+			_, err := l.Acquire(context.Background(), RequestKindCallResource)
+			require.NoError(t, err)
+		}
+
+		var nilLimiter *RequestLimiter
+		release, err := nilLimiter.Acquire(context.Background(), RequestKindQueryData)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("Should reject requests over the limit after the queue timeout", func(t *testing.T) {
+		l := NewRequestLimiter("test", setting.PluginLimits{MaxConcurrentQueries: 2, QueueTimeout: 10 * time.Millisecond})
+		for i := 0; i < 2; i++ {
+			_, err := l.Acquire(context.Background(), RequestKindQueryData)
+			require.NoError(t, err)
+		}
+
+		_, err := l.Acquire(context.Background(), RequestKindQueryData)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrPluginRequestLimitExceeded))
+		require.EqualError(t, err, "too many concurrent plugin requests: queryData is limited to 2 concurrent requests")
+	})
+
+	t.Run("Should queue requests over the limit until a request completes", func(t *testing.T) {
+		l := NewRequestLimiter("test", setting.PluginLimits{MaxConcurrentStreams: 1, QueueTimeout: time.Minute})
+		release, err := l.Acquire(context.Background(), RequestKindRunStream)
+		require.NoError(t, err)
+
+		acquired := make(chan error)
+		go func() {
+			_, err := l.Acquire(context.Background(), RequestKindRunStream)
+			acquired <- err
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("request over the limit shouldn't be allowed")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		release()
+		require.NoError(t, <-acquired)
+	})
+
+	t.Run("Should stop waiting when the request is canceled", func(t *testing.T) {
+		l := NewRequestLimiter("test", setting.PluginLimits{MaxConcurrentResourceCalls: 1, QueueTimeout: time.Minute})
+		_, err := l.Acquire(context.Background(), RequestKindCallResource)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = l.Acquire(ctx, RequestKindCallResource)
+		require.Equal(t, context.Canceled, err)
+	})
+}
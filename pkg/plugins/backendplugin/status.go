@@ -0,0 +1,31 @@
+package backendplugin
+
+import "time"
+
+// PluginState is the state of the process of a backend plugin.
+type PluginState string
+
+const (
+	// PluginStateNotStarted is the state of a plugin which isn't started, e.g. when it failed to start.
+	PluginStateNotStarted PluginState = "notStarted"
+	// PluginStateRunning is the state of a plugin whose process is running.
+	PluginStateRunning PluginState = "running"
+	// PluginStateRestarting is the state of a plugin whose process exited and is waiting to be restarted.
+	PluginStateRestarting PluginState = "restarting"
+	// PluginStateDisabled is the state of a plugin stopped by an administrator.
+	PluginStateDisabled PluginState = "disabled"
+)
+
+// PluginStatus is the status of the process of a backend plugin.
+type PluginStatus struct {
+	PluginID string      `json:"pluginId"`
+	Managed  bool        `json:"managed"`
+	State    PluginState `json:"state"`
+	// Restarts is the number of times the process was restarted after exiting.
+	Restarts        int        `json:"restarts"`
+	LastExitError   string     `json:"lastExitError,omitempty"`
+	LastExitTime    *time.Time `json:"lastExitTime,omitempty"`
+	NextRestartTime *time.Time `json:"nextRestartTime,omitempty"`
+	// CircuitBreaker is the state of the circuit breaker of the plugin, if it has one.
+	CircuitBreaker CircuitState `json:"circuitBreaker,omitempty"`
+}
@@ -12,15 +12,23 @@ import (
 
 type FrontendPluginBase struct {
 	PluginBase
+
+	assets PluginAssets
 }
 
 func (fp *FrontendPluginBase) InitFrontendPlugin(cfg *setting.Cfg) []*PluginStaticRoute {
 	var staticRoutes []*PluginStaticRoute
 	if isExternalPlugin(fp.PluginDir, cfg) {
+		// in development, the plugins are rebuilt while Grafana runs and their files are served as is
+		fp.assets = nil
+		if cfg.Env != setting.Dev {
+			fp.assets = newPluginAssets(fp.PluginDir, fp.Files, fp.SignedFiles, cfg.PluginsPrecompressAssets)
+		}
 		staticRoutes = []*PluginStaticRoute{
 			{
 				Directory: fp.PluginDir,
 				PluginId:  fp.Id,
+				Assets:    fp.assets,
 			},
 		}
 	}
@@ -51,7 +59,7 @@ func (fp *FrontendPluginBase) setPathsBasedOnApp(app *AppPlugin, cfg *setting.Cf
 	fp.BaseUrl = app.BaseUrl
 
 	if isExternalPlugin(app.PluginDir, cfg) {
-		fp.Module = util.JoinURLFragments("plugins/"+app.Id, appSubPath) + "/module"
+		fp.setExternalModule(cfg, app.Id, app.assets, path.Join(strings.Trim(appSubPath, "/"), "module"))
 	} else {
 		fp.Module = util.JoinURLFragments("app/plugins/app/"+app.Id, appSubPath) + "/module"
 	}
@@ -59,12 +67,16 @@ func (fp *FrontendPluginBase) setPathsBasedOnApp(app *AppPlugin, cfg *setting.Cf
 
 func (fp *FrontendPluginBase) handleModuleDefaults(cfg *setting.Cfg) {
 	if isExternalPlugin(fp.PluginDir, cfg) {
-		fp.Module = path.Join("plugins", fp.Id, "module")
+		fp.setExternalModule(cfg, fp.Id, fp.assets, "module")
 		fp.BaseUrl = path.Join("public/plugins", fp.Id)
+		if cfg.PluginsCDNBaseURL != "" {
+			fp.BaseUrl = cfg.PluginsCDNBaseURL + "/" + fp.BaseUrl
+		}
 		return
 	}
 
 	fp.IsCorePlugin = true
+	fp.ModuleIntegrity = ""
 	// Previously there was an assumption that the plugin directory
 	// should be public/app/plugins/<plugin type>/<plugin id>
 	// However this can be an issue if the plugin directory should be renamed to something else
@@ -75,6 +87,23 @@ func (fp *FrontendPluginBase) handleModuleDefaults(cfg *setting.Cfg) {
 	fp.BaseUrl = path.Join("public/app/plugins", fp.Type, currentDir)
 }
 
+// setExternalModule sets the module of an external plugin, served by the static route of a plugin. An indexed
+// module is loaded by its content hash, so that it can be cached for a long time, and from the CDN when set.
+func (fp *FrontendPluginBase) setExternalModule(cfg *setting.Cfg, routePluginID string, assets PluginAssets,
+	modulePath string) {
+	fp.Module = path.Join("plugins", routePluginID, modulePath)
+	fp.ModuleIntegrity = ""
+	if asset, exists := assets[modulePath+".js"]; exists {
+		fp.Module = path.Join("plugins", routePluginID, strings.TrimSuffix(asset.HashedPath(), ".js"))
+		fp.ModuleIntegrity = asset.Integrity
+	}
+
+	// SystemJS only adds the extension to the modules of the plugins package
+	if cfg.PluginsCDNBaseURL != "" {
+		fp.Module = cfg.PluginsCDNBaseURL + "/public/" + fp.Module + ".js"
+	}
+}
+
 func isExternalPlugin(pluginDir string, cfg *setting.Cfg) bool {
 	return !strings.Contains(pluginDir, cfg.StaticRootPath)
 }
@@ -88,5 +117,11 @@ func evalRelativePluginUrlPath(pathStr string, baseUrl string) string {
 	if u.IsAbs() {
 		return pathStr
 	}
+
+	// the base URL is absolute when the assets are loaded from the CDN
+	if base, err := url.Parse(baseUrl); err == nil && base.IsAbs() {
+		base.Path = path.Join(base.Path, pathStr)
+		return base.String()
+	}
 	return path.Join(baseUrl, pathStr)
 }
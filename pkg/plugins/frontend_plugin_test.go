@@ -1,10 +1,14 @@
 package plugins
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFrontendPlugin(t *testing.T) {
@@ -32,3 +36,62 @@ func TestFrontendPlugin(t *testing.T) {
 		So(fp.Module, ShouldEqual, "app/plugins/app/testdata/datasources/datasource/module")
 	})
 }
+
+func TestFrontendPlugin_ExternalModule(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte("module"), 0600))
+	newPlugin := func() *FrontendPluginBase {
+		return &FrontendPluginBase{
+			PluginBase: PluginBase{
+				Id:        "test-panel",
+				Type:      "panel",
+				PluginDir: dir,
+				Files:     []string{"module.js"},
+				Info:      PluginInfo{Logos: PluginLogos{Small: "img/logo.svg"}},
+			},
+		}
+	}
+
+	t.Run("Should load the module by its content hash", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.StaticRootPath = filepath.Join(dir, "public")
+		fp := newPlugin()
+		routes := fp.InitFrontendPlugin(cfg)
+		require.Len(t, routes, 1)
+		module := routes[0].Assets["module.js"]
+		require.NotNil(t, module)
+
+		assert.Equal(t, "plugins/test-panel/module."+module.Hash[:16], fp.Module)
+		assert.Equal(t, "public/plugins/test-panel", fp.BaseUrl)
+		assert.Equal(t, "public/plugins/test-panel/img/logo.svg", fp.Info.Logos.Small)
+	})
+
+	t.Run("Should not index the assets in development", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.StaticRootPath = filepath.Join(dir, "public")
+		cfg.Env = setting.Dev
+		fp := newPlugin()
+		routes := fp.InitFrontendPlugin(cfg)
+		require.Len(t, routes, 1)
+		assert.Empty(t, routes[0].Assets)
+
+		assert.Equal(t, "plugins/test-panel/module", fp.Module)
+		assert.Empty(t, fp.ModuleIntegrity)
+	})
+
+	t.Run("Should load the assets from the CDN", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.StaticRootPath = filepath.Join(dir, "public")
+		cfg.PluginsCDNBaseURL = "https://cdn.example.com/grafana"
+		fp := newPlugin()
+		routes := fp.InitFrontendPlugin(cfg)
+		require.Len(t, routes, 1)
+		module := routes[0].Assets["module.js"]
+		require.NotNil(t, module)
+
+		assert.Equal(t, "https://cdn.example.com/grafana/public/plugins/test-panel/module."+module.Hash[:16]+".js",
+			fp.Module)
+		assert.Equal(t, "https://cdn.example.com/grafana/public/plugins/test-panel", fp.BaseUrl)
+		assert.Equal(t, "https://cdn.example.com/grafana/public/plugins/test-panel/img/logo.svg", fp.Info.Logos.Small)
+	})
+}
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"context"
+	"io"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
@@ -56,6 +57,13 @@ type Manager interface {
 	LoadPluginDashboard(pluginID, path string) (*models.Dashboard, error)
 	// IsAppInstalled returns whether an app is installed.
 	IsAppInstalled(id string) bool
+	// Install downloads a plugin version from the plugin repositories, the latest one when empty, and
+	// installs it without restart. An installed plugin is updated.
+	Install(ctx context.Context, pluginID, version string) (*PluginBase, error)
+	// InstallFromZip installs or updates the plugin of a zip archive without restart.
+	InstallFromZip(ctx context.Context, archive io.Reader) (*PluginBase, error)
+	// Uninstall unloads a plugin installed in the plugins directory, and removes it.
+	Uninstall(ctx context.Context, pluginID string) error
 }
 
 type ImportDashboardInput struct {
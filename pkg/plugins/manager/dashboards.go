@@ -11,7 +11,7 @@ import (
 )
 
 func (pm *PluginManager) GetPluginDashboards(orgID int64, pluginID string) ([]*plugins.PluginDashboardInfoDTO, error) {
-	plugin, exists := pm.plugins[pluginID]
+	plugin, exists := pm.getPlugin(pluginID)
 	if !exists {
 		return nil, plugins.PluginNotFoundError{PluginID: pluginID}
 	}
@@ -71,7 +71,7 @@ func (pm *PluginManager) GetPluginDashboards(orgID int64, pluginID string) ([]*p
 }
 
 func (pm *PluginManager) LoadPluginDashboard(pluginID, path string) (*models.Dashboard, error) {
-	plugin, exists := pm.plugins[pluginID]
+	plugin, exists := pm.getPlugin(pluginID)
 	if !exists {
 		return nil, plugins.PluginNotFoundError{PluginID: pluginID}
 	}
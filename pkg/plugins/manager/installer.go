@@ -0,0 +1,666 @@
+package manager
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// stagingDirPrefix prefixes the directories of the plugins directory where plugin archives are
+// extracted and verified before they're installed. These directories aren't scanned.
+const stagingDirPrefix = ".staging-"
+
+var (
+	downloadClient = http.Client{Timeout: 5 * time.Minute, CheckRedirect: checkRepositoryRedirect}
+	pluginIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	// maxExtractedSize is the largest total size of the files extracted from a plugin archive,
+	// overridden in tests.
+	maxExtractedSize uint64 = 2 << 30
+)
+
+type grafanaComPlugin struct {
+	Versions []grafanaComPluginVersion `json:"versions"`
+}
+
+type grafanaComPluginVersion struct {
+	Version string                          `json:"version"`
+	Arch    map[string]grafanaComPluginArch `json:"arch"`
+}
+
+type grafanaComPluginArch struct {
+	SHA256 string `json:"sha256"`
+}
+
+// Install downloads a plugin version from the plugin repositories, the latest one when empty, and
+// installs it without restart. An installed plugin is updated.
+func (pm *PluginManager) Install(ctx context.Context, pluginID, version string) (*plugins.PluginBase, error) {
+	if !pluginIDRegexp.MatchString(pluginID) {
+		return nil, plugins.PluginNotFoundError{PluginID: pluginID}
+	}
+
+	repo, v, err := pm.findPluginVersion(ctx, pluginID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// plugins which are downloaded as source code zipball from GitHub don't have a checksum
+	checksum := ""
+	if arch, exists := v.Arch[osAndArch()]; exists {
+		checksum = arch.SHA256
+	} else if arch, exists := v.Arch["any"]; exists {
+		checksum = arch.SHA256
+	}
+
+	archive, err := ioutil.TempFile("", "plugin-*.zip")
+	if err != nil {
+		return nil, errutil.Wrap("failed to create temporary file", err)
+	}
+	defer func() {
+		if err := os.Remove(archive.Name()); err != nil {
+			pm.log.Warn("Failed to remove temporary file", "file", archive.Name(), "err", err)
+		}
+	}()
+
+	downloadURL := fmt.Sprintf("%s/%s/versions/%s/download", repo.URL, url.PathEscape(pluginID),
+		url.PathEscape(v.Version))
+	pm.log.Info("Downloading plugin", "pluginId", pluginID, "version", v.Version, "repository", repo.Name,
+		"url", downloadURL)
+	err = downloadArchive(ctx, repo, downloadURL, checksum, archive)
+	if closeErr := archive.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errutil.Wrap("failed to download plugin archive", err)
+	}
+
+	return pm.installArchive(ctx, archive.Name(), pluginID)
+}
+
+// InstallFromZip installs or updates the plugin of a zip archive without restart.
+func (pm *PluginManager) InstallFromZip(ctx context.Context, r io.Reader) (*plugins.PluginBase, error) {
+	archive, err := ioutil.TempFile("", "plugin-*.zip")
+	if err != nil {
+		return nil, errutil.Wrap("failed to create temporary file", err)
+	}
+	defer func() {
+		if err := os.Remove(archive.Name()); err != nil {
+			pm.log.Warn("Failed to remove temporary file", "file", archive.Name(), "err", err)
+		}
+	}()
+
+	_, err = io.Copy(archive, r)
+	if closeErr := archive.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errutil.Wrap("failed to read plugin archive", err)
+	}
+
+	return pm.installArchive(ctx, archive.Name(), "")
+}
+
+// Uninstall unloads a plugin installed in the plugins directory, stops it and removes its directory,
+// including the plugins it includes.
+func (pm *PluginManager) Uninstall(ctx context.Context, pluginID string) error {
+	pm.installMu.Lock()
+	defer pm.installMu.Unlock()
+
+	p, exists := pm.getPlugin(pluginID)
+	if !exists {
+		return plugins.PluginNotFoundError{PluginID: pluginID}
+	}
+	if p.Root != nil {
+		return fmt.Errorf("%w: uninstall plugin %q instead", plugins.ErrPluginIncluded, p.Root.Id)
+	}
+	if p.Type == "renderer" {
+		return plugins.ErrPluginRequiresRestart
+	}
+	dir, err := pm.installDir(p)
+	if err != nil {
+		return err
+	}
+
+	pm.log.Info("Uninstalling plugin", "pluginId", pluginID, "dir", dir)
+	if err := pm.unload(ctx, dir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return errutil.Wrap("failed to remove plugin directory", err)
+	}
+	return nil
+}
+
+// installArchive installs the plugin of a zip archive. When an expected plugin ID is given, the
+// archive must contain this plugin. An installed plugin is replaced, and restored if the new version
+// fails to load.
+func (pm *PluginManager) installArchive(ctx context.Context, archivePath, expectedID string) (*plugins.PluginBase, error) {
+	pm.installMu.Lock()
+	defer pm.installMu.Unlock()
+
+	if err := os.MkdirAll(pm.Cfg.PluginsPath, os.ModePerm); err != nil {
+		return nil, errutil.Wrap("failed to create plugins directory", err)
+	}
+	stagingDir, err := ioutil.TempDir(pm.Cfg.PluginsPath, stagingDirPrefix)
+	if err != nil {
+		return nil, errutil.Wrap("failed to create staging directory", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(stagingDir); err != nil {
+			pm.log.Warn("Failed to remove staging directory", "dir", stagingDir, "err", err)
+		}
+	}()
+
+	extractDir := filepath.Join(stagingDir, "archive")
+	if err := pm.extractArchive(archivePath, extractDir); err != nil {
+		return nil, err
+	}
+	staged, err := pm.verifyStagedPlugin(extractDir)
+	if err != nil {
+		return nil, err
+	}
+	if expectedID != "" && staged.plugin.Id != expectedID {
+		return nil, fmt.Errorf("%w: the archive contains the plugin %q instead of %q",
+			plugins.ErrPluginArchiveInvalid, staged.plugin.Id, expectedID)
+	}
+	if staged.plugin.Type == "renderer" {
+		return nil, plugins.ErrPluginRequiresRestart
+	}
+
+	dst := filepath.Join(pm.Cfg.PluginsPath, staged.plugin.Id)
+	if existing, exists := pm.getPlugin(staged.plugin.Id); exists {
+		if existing.Root != nil {
+			return nil, fmt.Errorf("%w: update plugin %q instead", plugins.ErrPluginIncluded, existing.Root.Id)
+		}
+		if dst, err = pm.installDir(existing); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range staged.pluginIDs {
+		if p, exists := pm.getPlugin(id); exists && !isWithinDir(p.PluginDir, dst) {
+			return nil, fmt.Errorf("the archive contains the plugin %q, which is already installed in %q", id,
+				p.PluginDir)
+		}
+	}
+
+	if err := pm.unload(ctx, dst); err != nil {
+		return nil, err
+	}
+	backupDir := ""
+	if _, err := os.Stat(dst); err == nil {
+		backupDir = filepath.Join(stagingDir, "backup")
+		if err := os.Rename(dst, backupDir); err != nil {
+			pm.restore(ctx, dst, "")
+			return nil, errutil.Wrap("failed to move installed plugin", err)
+		}
+	}
+	if err := os.Rename(staged.dir, dst); err != nil {
+		pm.restore(ctx, dst, backupDir)
+		return nil, errutil.Wrap("failed to move plugin to the plugins directory", err)
+	}
+
+	p, err := pm.load(staged.plugin.Id, dst)
+	if err != nil {
+		pm.log.Error("Failed to install plugin", "pluginId", staged.plugin.Id, "err", err)
+		if err := pm.unload(ctx, dst); err != nil {
+			pm.log.Warn("Failed to unload plugin", "pluginId", staged.plugin.Id, "err", err)
+		}
+		if err := os.RemoveAll(dst); err != nil {
+			pm.log.Warn("Failed to remove plugin directory", "dir", dst, "err", err)
+		}
+		pm.restore(ctx, dst, backupDir)
+		return nil, err
+	}
+
+	pm.log.Info("Installed plugin", "pluginId", p.Id, "version", p.Info.Version, "dir", dst)
+	return p, nil
+}
+
+// restore moves a previously installed plugin back to its directory and loads it again, after a
+// failed update.
+func (pm *PluginManager) restore(ctx context.Context, dir, backupDir string) {
+	if backupDir != "" {
+		if err := os.Rename(backupDir, dir); err != nil {
+			pm.log.Error("Failed to restore previous plugin version", "dir", dir, "err", err)
+			return
+		}
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+
+	pm.pluginsMu.Lock()
+	defer pm.pluginsMu.Unlock()
+	if err := pm.scan(dir, true); err != nil {
+		pm.log.Error("Failed to load previous plugin version", "dir", dir, "err", err)
+		return
+	}
+	for _, p := range pm.initPlugins(dir) {
+		err := pm.BackendPluginManager.StartManagedPlugin(p.Id)
+		if err != nil && !errors.Is(err, backendplugin.ErrPluginNotRegistered) {
+			pm.log.Error("Failed to start previous plugin version", "pluginId", p.Id, "err", err)
+		}
+	}
+}
+
+// load scans the directory of an installed plugin, initializes the plugins found, like on startup,
+// and starts the backend plugins.
+func (pm *PluginManager) load(pluginID, dir string) (*plugins.PluginBase, error) {
+	pm.pluginsMu.Lock()
+	delete(pm.pluginScanningErrors, pluginID)
+	if err := pm.scan(dir, true); err != nil {
+		pm.pluginsMu.Unlock()
+		return nil, err
+	}
+	loaded := pm.initPlugins(dir)
+	p, exists := pm.plugins[pluginID]
+	scanningErr, failed := pm.pluginScanningErrors[pluginID]
+	pm.pluginsMu.Unlock()
+
+	if failed {
+		return nil, fmt.Errorf("%w: %s", plugins.ErrPluginSignatureNotValid, scanningErr.ErrorCode)
+	}
+	if !exists {
+		return nil, fmt.Errorf("failed to load plugin %q", pluginID)
+	}
+
+	// the frontend plugins aren't registered with the backend plugin manager
+	for _, lp := range loaded {
+		err := pm.BackendPluginManager.StartManagedPlugin(lp.Id)
+		if err != nil && !errors.Is(err, backendplugin.ErrPluginNotRegistered) {
+			return nil, errutil.Wrapf(err, "failed to start plugin %q", lp.Id)
+		}
+	}
+	return p, nil
+}
+
+// initPlugins initializes the plugins loaded from a directory, and adds their static routes. The
+// caller must hold the write lock.
+func (pm *PluginManager) initPlugins(dir string) []*plugins.PluginBase {
+	var loaded []*plugins.PluginBase
+	for _, p := range pm.plugins {
+		if isWithinDir(p.PluginDir, dir) {
+			loaded = append(loaded, p)
+		}
+	}
+
+	for _, p := range loaded {
+		if panel, exists := pm.panels[p.Id]; exists {
+			pm.staticRoutes = append(pm.staticRoutes, panel.InitFrontendPlugin(pm.Cfg)...)
+		}
+		if ds, exists := pm.dataSources[p.Id]; exists {
+			pm.staticRoutes = append(pm.staticRoutes, ds.InitFrontendPlugin(pm.Cfg)...)
+		}
+	}
+	for _, p := range loaded {
+		if app, exists := pm.apps[p.Id]; exists {
+			pm.staticRoutes = append(pm.staticRoutes, app.InitApp(pm.panels, pm.dataSources, pm.Cfg)...)
+			if len(app.Routes) > 0 {
+				pm.log.Warn("The routes of the app plugin are added on restart", "pluginId", app.Id)
+			}
+		}
+		metrics.SetPluginBuildInformation(p.Id, p.Type, p.Info.Version)
+	}
+
+	return loaded
+}
+
+// unload removes the plugins of a directory and their static routes, and stops the backend plugins.
+func (pm *PluginManager) unload(ctx context.Context, dir string) error {
+	pm.pluginsMu.Lock()
+	var unloaded []*plugins.PluginBase
+	for id, p := range pm.plugins {
+		if !isWithinDir(p.PluginDir, dir) {
+			continue
+		}
+		delete(pm.plugins, id)
+		delete(pm.dataSources, id)
+		delete(pm.panels, id)
+		delete(pm.apps, id)
+		delete(pm.pluginScanningErrors, id)
+		unloaded = append(unloaded, p)
+	}
+	staticRoutes := make([]*plugins.PluginStaticRoute, 0, len(pm.staticRoutes))
+	for _, route := range pm.staticRoutes {
+		if !isWithinDir(route.Directory, dir) {
+			staticRoutes = append(staticRoutes, route)
+		}
+	}
+	pm.staticRoutes = staticRoutes
+	pm.pluginsMu.Unlock()
+
+	for _, p := range unloaded {
+		pm.log.Info("Unloading plugin", "pluginId", p.Id)
+		err := pm.BackendPluginManager.UnregisterAndStop(ctx, p.Id)
+		if err != nil && !errors.Is(err, backendplugin.ErrPluginNotRegistered) {
+			return errutil.Wrapf(err, "failed to stop plugin %q", p.Id)
+		}
+	}
+	return nil
+}
+
+// installDir returns the directory of the plugins directory a plugin is installed in.
+func (pm *PluginManager) installDir(p *plugins.PluginBase) (string, error) {
+	rel, err := relativePath(pm.Cfg.PluginsPath, p.PluginDir)
+	if err != nil || rel == "." || !isWithinDir(p.PluginDir, pm.Cfg.PluginsPath) {
+		return "", plugins.ErrPluginNotExternal
+	}
+	return filepath.Join(pm.Cfg.PluginsPath, strings.Split(rel, string(filepath.Separator))[0]), nil
+}
+
+type stagedPlugin struct {
+	plugin *plugins.PluginBase
+	// dir is the directory to install, which contains the plugin.
+	dir string
+	// pluginIDs are the IDs of the plugin and of the plugins it includes.
+	pluginIDs []string
+}
+
+// verifyStagedPlugin finds the plugin of an extracted archive, which is its top-most plugin.json, and
+// validates its signature with the policy of the external plugins.
+func (pm *PluginManager) verifyStagedPlugin(extractDir string) (*stagedPlugin, error) {
+	var pluginJSONs []string
+	err := filepath.Walk(extractDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && info.Name() == "plugin.json" {
+			pluginJSONs = append(pluginJSONs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rootJSON := ""
+	for _, path := range pluginJSONs {
+		if rootJSON == "" || strings.Count(path, string(filepath.Separator)) < strings.Count(rootJSON, string(filepath.Separator)) {
+			rootJSON = path
+		}
+	}
+	if rootJSON == "" {
+		return nil, fmt.Errorf("%w: plugin.json not found", plugins.ErrPluginArchiveInvalid)
+	}
+
+	staged := &stagedPlugin{dir: extractDir}
+	for _, path := range pluginJSONs {
+		p, err := readPluginJSON(path)
+		if err != nil {
+			return nil, err
+		}
+		staged.pluginIDs = append(staged.pluginIDs, p.Id)
+		if path == rootJSON {
+			staged.plugin = p
+		}
+	}
+
+	p := staged.plugin
+	if !pluginIDRegexp.MatchString(p.Id) {
+		return nil, fmt.Errorf("%w: invalid plugin ID %q", plugins.ErrPluginArchiveInvalid, p.Id)
+	}
+	if rel, err := relativePath(extractDir, p.PluginDir); err != nil {
+		return nil, err
+	} else if rel != "." {
+		staged.dir = filepath.Join(extractDir, strings.Split(rel, string(filepath.Separator))[0])
+	}
+
+	p.Files, err = collectPluginFilesWithin(p.PluginDir)
+	if err != nil {
+		return nil, err
+	}
+	signatureState, err := getPluginSignatureState(pm.log, pm.signatureKeyring, p)
+	if err != nil {
+		return nil, err
+	}
+	p.Signature = signatureState.Status
+	p.SignatureType = signatureState.Type
+	p.SignatureOrg = signatureState.SigningOrg
+
+	scanner := &PluginScanner{
+		cfg:                           pm.Cfg,
+		requireSigned:                 true,
+		log:                           pm.log,
+		allowUnsignedPluginsCondition: pm.AllowUnsignedPluginsCondition,
+	}
+	if signingError := scanner.validateSignature(p); signingError != nil {
+		return nil, fmt.Errorf("%w: %s", plugins.ErrPluginSignatureNotValid, signingError.ErrorCode)
+	}
+
+	return staged, nil
+}
+
+// extractArchive extracts a plugin archive to a directory. The symlinks are skipped.
+func (pm *PluginManager) extractArchive(archivePath, dir string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", plugins.ErrPluginArchiveInvalid, err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			pm.log.Warn("Failed to close plugin archive", "err", err)
+		}
+	}()
+
+	remaining := maxExtractedSize
+	for _, zf := range r.File {
+		dstPath := filepath.Join(dir, filepath.FromSlash(zf.Name))
+		if !strings.HasPrefix(dstPath, dir+string(filepath.Separator)) {
+			return fmt.Errorf("%w: archive member %q is outside of the plugin directory",
+				plugins.ErrPluginArchiveInvalid, zf.Name)
+		}
+
+		if zf.FileInfo().IsDir() {
+			// nolint:gosec
+			if err := os.MkdirAll(dstPath, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if zf.Mode()&os.ModeSymlink != 0 {
+			pm.log.Warn("Skipping symlink of plugin archive", "file", zf.Name)
+			continue
+		}
+
+		if zf.UncompressedSize64 > remaining {
+			return fmt.Errorf("%w: the extracted archive is too large", plugins.ErrPluginArchiveInvalid)
+		}
+		remaining -= zf.UncompressedSize64
+
+		// nolint:gosec
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			return err
+		}
+		if err := extractArchiveFile(zf, dstPath); err != nil {
+			return errutil.Wrapf(err, "failed to extract %q", zf.Name)
+		}
+	}
+
+	return nil
+}
+
+// extractArchiveFile extracts a file of an archive. The size in the header of the file isn't trusted,
+// the data extracted is limited to it as well.
+func extractArchiveFile(zf *zip.File, dstPath string) (err error) {
+	mode := zf.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	// the executables of backend plugins are archived without permissions on some systems
+	if strings.HasSuffix(dstPath, "_linux_amd64") || strings.HasSuffix(dstPath, "_darwin_amd64") {
+		mode = 0755
+	}
+
+	src, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := src.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because the path is checked to be within the
+	// staging directory.
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	n, err := io.Copy(dst, io.LimitReader(src, int64(zf.UncompressedSize64)+1))
+	if err != nil {
+		return err
+	}
+	if uint64(n) > zf.UncompressedSize64 {
+		return fmt.Errorf("%w: the file is larger than its size in the archive", plugins.ErrPluginArchiveInvalid)
+	}
+	return nil
+}
+
+func readPluginJSON(path string) (*plugins.PluginBase, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because the path is within the staging
+	// directory.
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plugins.PluginBase{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%w: %s", plugins.ErrPluginArchiveInvalid, err)
+	}
+	if p.Id == "" || p.Type == "" {
+		return nil, fmt.Errorf("%w: did not find type or id properties in plugin.json", plugins.ErrPluginArchiveInvalid)
+	}
+	p.PluginDir = filepath.Dir(path)
+	return p, nil
+}
+
+// getPluginVersion gets a plugin version supported by this OS and architecture from the plugin
+// repository, the latest one when empty.
+func getPluginVersion(ctx context.Context, repo setting.PluginRepository, pluginID, version string) (
+	*grafanaComPluginVersion, error) {
+	req, err := newRepositoryRequest(ctx, repo, repo.URL+"/repo/"+url.PathEscape(pluginID))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errutil.Wrap("failed to get plugin from repository", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, plugins.PluginNotFoundError{PluginID: pluginID}
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("failed to get plugin from repository: %s", resp.Status)
+	}
+
+	var plugin grafanaComPlugin
+	if err := json.NewDecoder(resp.Body).Decode(&plugin); err != nil {
+		return nil, errutil.Wrap("failed to read plugin from repository", err)
+	}
+
+	// the versions are sorted, the newest first
+	for _, v := range plugin.Versions {
+		if version != "" && v.Version != version {
+			continue
+		}
+		if v.Arch == nil {
+			return &v, nil
+		}
+		if _, exists := v.Arch[osAndArch()]; exists {
+			return &v, nil
+		}
+		if _, exists := v.Arch["any"]; exists {
+			return &v, nil
+		}
+		if version != "" {
+			break
+		}
+	}
+
+	if version == "" {
+		return nil, fmt.Errorf("%w: plugin %q isn't supported on %s", plugins.ErrPluginVersionNotFound, pluginID,
+			osAndArch())
+	}
+	return nil, fmt.Errorf("%w: version %q of plugin %q isn't available on %s", plugins.ErrPluginVersionNotFound,
+		version, pluginID, osAndArch())
+}
+
+// downloadArchive downloads a plugin archive to w, and verifies its checksum when not empty.
+func downloadArchive(ctx context.Context, repo setting.PluginRepository, downloadURL, checksum string,
+	w io.Writer) error {
+	req, err := newRepositoryRequest(ctx, repo, downloadURL)
+	if err != nil {
+		return err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), resp.Body); err != nil {
+		return err
+	}
+	if checksum != "" && !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), checksum) {
+		return fmt.Errorf("%w: the checksum of the downloaded archive doesn't match", plugins.ErrPluginArchiveInvalid)
+	}
+	return nil
+}
+
+func osAndArch() string {
+	return strings.ToLower(runtime.GOOS) + "-" + runtime.GOARCH
+}
+
+// isWithinDir returns whether a path is a directory or is within it.
+func isWithinDir(path, dir string) bool {
+	rel, err := relativePath(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func relativePath(base, path string) (string, error) {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absBase, absPath)
+}
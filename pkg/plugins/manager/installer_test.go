@@ -0,0 +1,355 @@
+package manager
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testPanelJSON = `{"type": "panel", "name": "Test panel", "id": "test-panel", "info": {"version": "%s"}}`
+
+func TestPluginManager_InstallFromZip(t *testing.T) {
+	t.Run("Should install, update and uninstall a plugin", func(t *testing.T) {
+		pm := createInstallerManager(t)
+
+		p, err := pm.InstallFromZip(context.Background(), createZip(t, map[string]string{
+			"test-panel/plugin.json": fmt.Sprintf(testPanelJSON, "1.0.0"),
+			"test-panel/module.js":   "",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "test-panel", p.Id)
+		assert.Equal(t, "1.0.0", p.Info.Version)
+		require.NotNil(t, pm.GetPlugin("test-panel"))
+		assertStaticRoute(t, pm, "test-panel", filepath.Join(pm.Cfg.PluginsPath, "test-panel"))
+
+		p, err = pm.InstallFromZip(context.Background(), createZip(t, map[string]string{
+			"test-panel-abc123/dist/plugin.json": fmt.Sprintf(testPanelJSON, "2.0.0"),
+			"test-panel-abc123/dist/module.js":   "",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "2.0.0", p.Info.Version)
+		assert.Equal(t, "2.0.0", pm.GetPlugin("test-panel").Info.Version)
+		assertStaticRoute(t, pm, "test-panel", filepath.Join(pm.Cfg.PluginsPath, "test-panel", "dist"))
+		assertPluginsPathContent(t, pm, "test-panel")
+
+		err = pm.Uninstall(context.Background(), "test-panel")
+		require.NoError(t, err)
+		assert.Nil(t, pm.GetPlugin("test-panel"))
+		assert.Empty(t, pm.StaticRoutes())
+		assertPluginsPathContent(t, pm)
+
+		err = pm.Uninstall(context.Background(), "test-panel")
+		require.Equal(t, plugins.PluginNotFoundError{PluginID: "test-panel"}, err)
+	})
+
+	t.Run("Should start and stop backend plugin", func(t *testing.T) {
+		fm := &fakeBackendPluginManager{}
+		pm := createInstallerManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = fm
+		})
+
+		p, err := pm.InstallFromZip(context.Background(), createZipFromDir(t, "testdata/valid-v2-signature/plugin"))
+		require.NoError(t, err)
+		assert.Equal(t, plugins.PluginSignatureValid, p.Signature)
+		assert.Equal(t, []string{"test"}, fm.registeredPlugins)
+		assert.Equal(t, []string{"test"}, fm.startedPlugins)
+
+		err = pm.Uninstall(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test"}, fm.stoppedPlugins)
+		assertPluginsPathContent(t, pm)
+	})
+
+	t.Run("Should refuse unsigned backend plugin", func(t *testing.T) {
+		fm := &fakeBackendPluginManager{}
+		pm := createInstallerManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = fm
+		})
+
+		_, err := pm.InstallFromZip(context.Background(), createZipFromDir(t, "testdata/unsigned/plugin"))
+		require.ErrorIs(t, err, plugins.ErrPluginSignatureNotValid)
+		assert.Nil(t, pm.GetPlugin("test"))
+		assert.Empty(t, fm.registeredPlugins)
+		assertPluginsPathContent(t, pm)
+	})
+
+	t.Run("Should refuse archive member outside of the plugin directory", func(t *testing.T) {
+		pm := createInstallerManager(t)
+
+		_, err := pm.InstallFromZip(context.Background(), createZip(t, map[string]string{
+			"test-panel/plugin.json": fmt.Sprintf(testPanelJSON, "1.0.0"),
+			"../../evil.js":          "",
+		}))
+		require.ErrorIs(t, err, plugins.ErrPluginArchiveInvalid)
+		assertPluginsPathContent(t, pm)
+	})
+
+	t.Run("Should refuse archive larger than the limit once extracted", func(t *testing.T) {
+		pm := createInstallerManager(t)
+		maxExtractedSize = 1 << 20
+		t.Cleanup(func() { maxExtractedSize = 2 << 30 })
+
+		_, err := pm.InstallFromZip(context.Background(), createZip(t, map[string]string{
+			"test-panel/plugin.json": fmt.Sprintf(testPanelJSON, "1.0.0"),
+			"test-panel/module.js":   string(make([]byte, 1<<20)),
+		}))
+		require.ErrorIs(t, err, plugins.ErrPluginArchiveInvalid)
+		assertPluginsPathContent(t, pm)
+	})
+
+	t.Run("Should refuse archive without plugin.json", func(t *testing.T) {
+		pm := createInstallerManager(t)
+
+		_, err := pm.InstallFromZip(context.Background(), createZip(t, map[string]string{
+			"test-panel/module.js": "",
+		}))
+		require.ErrorIs(t, err, plugins.ErrPluginArchiveInvalid)
+	})
+
+	t.Run("Should refuse to uninstall core plugin", func(t *testing.T) {
+		pm := createInstallerManager(t)
+
+		err := pm.Uninstall(context.Background(), "graph")
+		require.ErrorIs(t, err, plugins.ErrPluginNotExternal)
+		assert.NotNil(t, pm.GetPlugin("graph"))
+	})
+}
+
+func TestPluginManager_Install(t *testing.T) {
+	archive, err := ioutil.ReadAll(createZip(t, map[string]string{
+		"test-panel/plugin.json": fmt.Sprintf(testPanelJSON, "1.1.0"),
+		"test-panel/module.js":   "",
+	}))
+	require.NoError(t, err)
+	sum := sha256.Sum256(archive)
+	checksum := hex.EncodeToString(sum[:])
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/plugins/repo/test-panel", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"versions": [
+			{"version": "2.0.0", "arch": {"unsupported-arch": {"sha256": "123"}}},
+			{"version": "1.1.0", "arch": {"any": {"sha256": %q}}},
+			{"version": "1.0.0", "arch": {"any": {"sha256": "123"}}}
+		]}`, checksum)
+	})
+	mux.HandleFunc("/api/plugins/test-panel/versions/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	origGrafanaComURL := setting.GrafanaComUrl
+	t.Cleanup(func() {
+		setting.GrafanaComUrl = origGrafanaComURL
+	})
+	setting.GrafanaComUrl = server.URL
+
+	t.Run("Should install latest supported version", func(t *testing.T) {
+		pm := createInstallerManager(t)
+
+		p, err := pm.Install(context.Background(), "test-panel", "")
+		require.NoError(t, err)
+		assert.Equal(t, "1.1.0", p.Info.Version)
+		assertPluginsPathContent(t, pm, "test-panel")
+	})
+
+	t.Run("Should refuse archive with another checksum", func(t *testing.T) {
+		pm := createInstallerManager(t)
+
+		_, err := pm.Install(context.Background(), "test-panel", "1.0.0")
+		require.ErrorIs(t, err, plugins.ErrPluginArchiveInvalid)
+		assertPluginsPathContent(t, pm)
+	})
+
+	t.Run("Should refuse unsupported version", func(t *testing.T) {
+		pm := createInstallerManager(t)
+
+		_, err := pm.Install(context.Background(), "test-panel", "2.0.0")
+		require.ErrorIs(t, err, plugins.ErrPluginVersionNotFound)
+	})
+
+	t.Run("Should return not found for unknown plugin", func(t *testing.T) {
+		pm := createInstallerManager(t)
+
+		_, err := pm.Install(context.Background(), "unknown", "")
+		require.Equal(t, plugins.PluginNotFoundError{PluginID: "unknown"}, err)
+	})
+
+	internalArchive, err := ioutil.ReadAll(createZip(t, map[string]string{
+		"test-panel/plugin.json": fmt.Sprintf(testPanelJSON, "1.0.0-internal"),
+		"test-panel/module.js":   "",
+	}))
+	require.NoError(t, err)
+	internalMux := http.NewServeMux()
+	internalMux.HandleFunc("/api/plugins/repo/test-panel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"versions": [{"version": "1.0.0-internal"}]}`)
+	})
+	internalMux.HandleFunc("/api/plugins/test-panel/versions/1.0.0-internal/download", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(internalArchive)
+	})
+	internalServer := httptest.NewServer(internalMux)
+	t.Cleanup(internalServer.Close)
+
+	t.Run("Should install from the first repository knowing the plugin", func(t *testing.T) {
+		pm := createInstallerManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginRepositories = []setting.PluginRepository{
+				{
+					Name: "internal", URL: internalServer.URL + "/api/plugins",
+					Headers: map[string]string{"Authorization": "Bearer token"},
+				},
+				{Name: "grafana.com", URL: server.URL + "/api/plugins"},
+			}
+		})
+
+		p, err := pm.Install(context.Background(), "test-panel", "")
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0-internal", p.Info.Version)
+
+		_, err = pm.Install(context.Background(), "unknown", "")
+		require.Equal(t, plugins.PluginNotFoundError{PluginID: "unknown"}, err)
+	})
+
+	t.Run("Should not send the headers of the repository to other hosts", func(t *testing.T) {
+		var cdnHeader http.Header
+		cdnServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cdnHeader = r.Header
+			_, _ = w.Write(internalArchive)
+		}))
+		t.Cleanup(cdnServer.Close)
+
+		redirectMux := http.NewServeMux()
+		redirectMux.HandleFunc("/api/plugins/repo/test-panel", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"versions": [{"version": "1.0.0-internal"}]}`)
+		})
+		redirectMux.HandleFunc("/api/plugins/test-panel/versions/1.0.0-internal/download", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Api-Key") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, cdnServer.URL+"/test-panel.zip", http.StatusFound)
+		})
+		redirectServer := httptest.NewServer(redirectMux)
+		t.Cleanup(redirectServer.Close)
+
+		pm := createInstallerManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginRepositories = []setting.PluginRepository{
+				{
+					Name: "internal", URL: redirectServer.URL + "/api/plugins",
+					Headers: map[string]string{"X-Api-Key": "secret"},
+				},
+			}
+		})
+
+		_, err := pm.Install(context.Background(), "test-panel", "")
+		require.NoError(t, err)
+		require.NotNil(t, cdnHeader)
+		assert.Empty(t, cdnHeader.Get("X-Api-Key"))
+	})
+
+	t.Run("Should not fall back to the next repository when a repository fails", func(t *testing.T) {
+		pm := createInstallerManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginRepositories = []setting.PluginRepository{
+				{Name: "internal", URL: internalServer.URL + "/api/plugins"},
+				{Name: "grafana.com", URL: server.URL + "/api/plugins"},
+			}
+		})
+
+		_, err := pm.Install(context.Background(), "test-panel", "")
+		require.EqualError(t, err, `plugin repository "internal": failed to get plugin from repository: 401 Unauthorized`)
+		assertPluginsPathContent(t, pm)
+	})
+}
+
+func assertStaticRoute(t *testing.T, pm *PluginManager, pluginID, dir string) {
+	t.Helper()
+
+	routes := pm.StaticRoutes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, pluginID, routes[0].PluginId)
+	assert.Equal(t, dir, routes[0].Directory)
+	assert.Contains(t, routes[0].Assets, "module.js")
+}
+
+func createInstallerManager(t *testing.T, cbs ...func(*PluginManager)) *PluginManager {
+	t.Helper()
+
+	pm := createManager(t, append([]func(*PluginManager){func(pm *PluginManager) {
+		pm.Cfg.PluginsPath = t.TempDir()
+	}}, cbs...)...)
+	err := pm.Init()
+	require.NoError(t, err)
+	return pm
+}
+
+func assertPluginsPathContent(t *testing.T, pm *PluginManager, names ...string) {
+	t.Helper()
+
+	infos, err := ioutil.ReadDir(pm.Cfg.PluginsPath)
+	require.NoError(t, err)
+	content := []string{}
+	for _, info := range infos {
+		content = append(content, info.Name())
+	}
+	assert.ElementsMatch(t, names, content)
+}
+
+func createZip(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return &buf
+}
+
+func createZipFromDir(t *testing.T, dir string) *bytes.Buffer {
+	t.Helper()
+
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files["plugin/"+filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	require.NoError(t, err)
+	return createZip(t, files)
+}
@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/fs"
@@ -25,6 +26,7 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
+	"golang.org/x/crypto/openpgp"
 )
 
 var (
@@ -42,6 +44,7 @@ type PluginScanner struct {
 	log                           log.Logger
 	plugins                       map[string]*plugins.PluginBase
 	allowUnsignedPluginsCondition unsignedPluginConditionFunc
+	keyring                       openpgp.EntityList
 }
 
 type PluginManager struct {
@@ -57,7 +60,13 @@ type PluginManager struct {
 	grafanaLatestVersion          string
 	grafanaHasUpdate              bool
 	pluginScanningErrors          map[string]plugins.PluginError
+	// signatureKeyring holds the keys trusted to sign plugins.
+	signatureKeyring openpgp.EntityList
+	// installMu serializes the plugin installations.
+	installMu sync.Mutex
 
+	// pluginsMu guards the plugins, since they can be installed and uninstalled at runtime.
+	pluginsMu    sync.RWMutex
 	renderer     *plugins.RendererPlugin
 	dataSources  map[string]*plugins.DataSourcePlugin
 	plugins      map[string]*plugins.PluginBase
@@ -89,6 +98,12 @@ func (pm *PluginManager) Init() error {
 	plog = log.New("plugins")
 	pm.pluginScanningErrors = map[string]plugins.PluginError{}
 
+	keyring, err := loadSignatureKeyring(pm.Cfg.PluginSignaturePublicKeyFiles)
+	if err != nil {
+		return err
+	}
+	pm.signatureKeyring = keyring
+
 	pm.log.Info("Starting plugin search")
 
 	plugDir := filepath.Join(pm.Cfg.StaticRootPath, "app/plugins")
@@ -182,14 +197,20 @@ func (pm *PluginManager) Run(ctx context.Context) error {
 }
 
 func (pm *PluginManager) Renderer() *plugins.RendererPlugin {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	return pm.renderer
 }
 
 func (pm *PluginManager) GetDataSource(id string) *plugins.DataSourcePlugin {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	return pm.dataSources[id]
 }
 
 func (pm *PluginManager) DataSources() []*plugins.DataSourcePlugin {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	var rslt []*plugins.DataSourcePlugin
 	for _, ds := range pm.dataSources {
 		rslt = append(rslt, ds)
@@ -199,18 +220,26 @@ func (pm *PluginManager) DataSources() []*plugins.DataSourcePlugin {
 }
 
 func (pm *PluginManager) DataSourceCount() int {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	return len(pm.dataSources)
 }
 
 func (pm *PluginManager) PanelCount() int {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	return len(pm.panels)
 }
 
 func (pm *PluginManager) AppCount() int {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	return len(pm.apps)
 }
 
 func (pm *PluginManager) Plugins() []*plugins.PluginBase {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	var rslt []*plugins.PluginBase
 	for _, p := range pm.plugins {
 		rslt = append(rslt, p)
@@ -220,6 +249,8 @@ func (pm *PluginManager) Plugins() []*plugins.PluginBase {
 }
 
 func (pm *PluginManager) Apps() []*plugins.AppPlugin {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	var rslt []*plugins.AppPlugin
 	for _, p := range pm.apps {
 		rslt = append(rslt, p)
@@ -229,10 +260,14 @@ func (pm *PluginManager) Apps() []*plugins.AppPlugin {
 }
 
 func (pm *PluginManager) GetPlugin(id string) *plugins.PluginBase {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	return pm.plugins[id]
 }
 
 func (pm *PluginManager) GetApp(id string) *plugins.AppPlugin {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	return pm.apps[id]
 }
 
@@ -270,6 +305,7 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 		log:                           pm.log,
 		plugins:                       map[string]*plugins.PluginBase{},
 		allowUnsignedPluginsCondition: pm.AllowUnsignedPluginsCondition,
+		keyring:                       pm.signatureKeyring,
 	}
 
 	// 1st pass: Scan plugins, also mapping plugins to their respective directories
@@ -432,6 +468,8 @@ func (pm *PluginManager) loadPlugin(jsonParser *json.Decoder, pluginBase *plugin
 	pb.Signature = pluginBase.Signature
 	pb.SignatureType = pluginBase.SignatureType
 	pb.SignatureOrg = pluginBase.SignatureOrg
+	pb.Files = pluginBase.Files
+	pb.SignedFiles = pluginBase.SignedFiles
 
 	pm.plugins[pb.Id] = pb
 	pm.log.Debug("Successfully added plugin", "id", pb.Id)
@@ -450,6 +488,10 @@ func (s *PluginScanner) walker(currentPath string, f os.FileInfo, err error) err
 		return util.ErrWalkSkipDir
 	}
 
+	if f.IsDir() && strings.HasPrefix(f.Name(), stagingDirPrefix) {
+		return util.ErrWalkSkipDir
+	}
+
 	if f.IsDir() {
 		return nil
 	}
@@ -499,7 +541,7 @@ func (s *PluginScanner) loadPlugin(pluginJSONFilePath string) error {
 		return err
 	}
 
-	signatureState, err := getPluginSignatureState(s.log, &pluginCommon)
+	signatureState, err := getPluginSignatureState(s.log, s.keyring, &pluginCommon)
 	if err != nil {
 		s.log.Warn("Could not get plugin signature state", "pluginID", pluginCommon.Id, "err", err)
 		return err
@@ -507,6 +549,7 @@ func (s *PluginScanner) loadPlugin(pluginJSONFilePath string) error {
 	pluginCommon.Signature = signatureState.Status
 	pluginCommon.SignatureType = signatureState.Type
 	pluginCommon.SignatureOrg = signatureState.SigningOrg
+	pluginCommon.SignedFiles = signatureState.Files
 
 	s.plugins[currentDir] = &pluginCommon
 
@@ -533,6 +576,7 @@ func (s *PluginScanner) validateSignature(plugin *plugins.PluginBase) *plugins.P
 			s.log.Debug("Setting descendant plugin's signature to that of root", "plugin", plugin.Id,
 				"root", plugin.Root.Id, "signature", plugin.Signature, "rootSignature", plugin.Root.Signature)
 			plugin.Signature = plugin.Root.Signature
+			plugin.SignedFiles = signedFilesWithin(plugin.Root, plugin.PluginDir)
 			if plugin.Signature == plugins.PluginSignatureValid {
 				s.log.Debug("Plugin has valid signature (inherited from root)", "id", plugin.Id)
 				return nil
@@ -598,6 +642,8 @@ func (s *PluginScanner) allowUnsigned(plugin *plugins.PluginBase) bool {
 
 // ScanningErrors returns plugin scanning errors encountered.
 func (pm *PluginManager) ScanningErrors() []plugins.PluginError {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	scanningErrs := make([]plugins.PluginError, 0)
 	for id, e := range pm.pluginScanningErrors {
 		scanningErrs = append(scanningErrs, plugins.PluginError{
@@ -609,7 +655,7 @@ func (pm *PluginManager) ScanningErrors() []plugins.PluginError {
 }
 
 func (pm *PluginManager) GetPluginMarkdown(pluginId string, name string) ([]byte, error) {
-	plug, exists := pm.plugins[pluginId]
+	plug, exists := pm.getPlugin(pluginId)
 	if !exists {
 		return nil, plugins.PluginNotFoundError{PluginID: pluginId}
 	}
@@ -663,9 +709,26 @@ func collectPluginFilesWithin(rootDir string) ([]string, error) {
 	return files, err
 }
 
+// signedFilesWithin returns the files signed in the manifest of a root plugin which are within the directory
+// of a descendant plugin, by path relative to that directory.
+func signedFilesWithin(root *plugins.PluginBase, dir string) map[string]string {
+	rel, err := filepath.Rel(root.PluginDir, dir)
+	if err != nil || len(root.SignedFiles) == 0 {
+		return nil
+	}
+	prefix := filepath.ToSlash(rel) + "/"
+	files := map[string]string{}
+	for p, hash := range root.SignedFiles {
+		if strings.HasPrefix(p, prefix) {
+			files[strings.TrimPrefix(p, prefix)] = hash
+		}
+	}
+	return files
+}
+
 // GetDataPlugin gets a DataPlugin with a certain name. If none is found, nil is returned.
 func (pm *PluginManager) GetDataPlugin(id string) plugins.DataPlugin {
-	if p, exists := pm.dataSources[id]; exists && p.CanHandleDataQueries() {
+	if p := pm.GetDataSource(id); p != nil && p.CanHandleDataQueries() {
 		return p
 	}
 
@@ -680,5 +743,14 @@ func (pm *PluginManager) GetDataPlugin(id string) plugins.DataPlugin {
 }
 
 func (pm *PluginManager) StaticRoutes() []*plugins.PluginStaticRoute {
-	return pm.staticRoutes
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
+	return append([]*plugins.PluginStaticRoute{}, pm.staticRoutes...)
+}
+
+func (pm *PluginManager) getPlugin(id string) (*plugins.PluginBase, bool) {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
+	p, exists := pm.plugins[id]
+	return p, exists
 }
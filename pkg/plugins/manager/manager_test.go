@@ -1,9 +1,15 @@
 package manager
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -14,6 +20,9 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
 	"gopkg.in/ini.v1"
 )
 
@@ -199,6 +208,113 @@ func TestPluginManager_Init(t *testing.T) {
 		assert.Equal(t, []error{fmt.Errorf(`plugin "test"'s signature has been modified`)}, pm.scanningErrors)
 		assert.Nil(t, pm.plugins[("test")])
 	})
+
+	t.Run("With back-end plugin signed with a configured public key", func(t *testing.T) {
+		pluginsPath := t.TempDir()
+		keyFile := createPluginSignedWithOwnKey(t, pluginsPath, plugins.PrivateType)
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginsPath = pluginsPath
+			pm.Cfg.PluginSignaturePublicKeyFiles = []string{keyFile}
+		})
+		err := pm.Init()
+		require.NoError(t, err)
+		require.Empty(t, pm.scanningErrors)
+
+		require.NotNil(t, pm.plugins["test"])
+		assert.Equal(t, plugins.PluginSignatureValid, pm.plugins["test"].Signature)
+		assert.Equal(t, "Example Org", pm.plugins["test"].SignatureOrg)
+	})
+
+	t.Run("With back-end plugin signed with a public key which isn't configured", func(t *testing.T) {
+		pluginsPath := t.TempDir()
+		createPluginSignedWithOwnKey(t, pluginsPath, plugins.PrivateType)
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginsPath = pluginsPath
+		})
+		err := pm.Init()
+		require.NoError(t, err)
+		assert.Equal(t, []error{fmt.Errorf(`plugin "test" has an invalid signature`)}, pm.scanningErrors)
+		assert.Nil(t, pm.plugins["test"])
+	})
+
+	t.Run("With back-end plugin signed with a configured public key as a Grafana plugin", func(t *testing.T) {
+		pluginsPath := t.TempDir()
+		keyFile := createPluginSignedWithOwnKey(t, pluginsPath, plugins.GrafanaType)
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginsPath = pluginsPath
+			pm.Cfg.PluginSignaturePublicKeyFiles = []string{keyFile}
+		})
+		err := pm.Init()
+		require.NoError(t, err)
+		assert.Equal(t, []error{fmt.Errorf(`plugin "test" has an invalid signature`)}, pm.scanningErrors)
+		assert.Nil(t, pm.plugins["test"])
+	})
+
+	t.Run("With invalid configured public key", func(t *testing.T) {
+		keyFile := filepath.Join(t.TempDir(), "key.asc")
+		require.NoError(t, ioutil.WriteFile(keyFile, []byte("invalid"), 0600))
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginSignaturePublicKeyFiles = []string{keyFile}
+		})
+		err := pm.Init()
+		require.Error(t, err)
+	})
+}
+
+// createPluginSignedWithOwnKey creates a back-end plugin in the plugins directory, with a manifest signed by a new
+// key, and returns the file of its armored public key.
+func createPluginSignedWithOwnKey(t *testing.T, pluginsPath string, signatureType plugins.PluginSignatureType) string {
+	t.Helper()
+
+	origAppURL := setting.AppUrl
+	t.Cleanup(func() {
+		setting.AppUrl = origAppURL
+	})
+	setting.AppUrl = "http://localhost:3000/"
+
+	entity, err := openpgp.NewEntity("Example Org", "", "plugins@example.com", nil)
+	require.NoError(t, err)
+
+	pluginDir := filepath.Join(pluginsPath, "test")
+	require.NoError(t, os.MkdirAll(pluginDir, 0750))
+	pluginJSON := `{"type": "datasource", "name": "Test", "id": "test", "backend": true, "executable": "test",
+		"info": {"version": "1.0.0"}}`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(pluginDir, "plugin.json"), []byte(pluginJSON), 0600))
+	sum := sha256.Sum256([]byte(pluginJSON))
+
+	manifest, err := json.Marshal(pluginManifest{
+		Plugin:          "test",
+		Version:         "1.0.0",
+		KeyID:           entity.PrimaryKey.KeyIdString(),
+		Files:           map[string]string{"plugin.json": hex.EncodeToString(sum[:])},
+		ManifestVersion: "2.0.0",
+		SignatureType:   signatureType,
+		SignedByOrg:     "example",
+		SignedByOrgName: "Example Org",
+		RootURLs:        []string{setting.AppUrl},
+	})
+	require.NoError(t, err)
+	var signed bytes.Buffer
+	w, err := clearsign.Encode(&signed, entity.PrivateKey, nil)
+	require.NoError(t, err)
+	_, err = w.Write(manifest)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, ioutil.WriteFile(filepath.Join(pluginDir, "MANIFEST.txt"), signed.Bytes(), 0600))
+
+	var publicKey bytes.Buffer
+	aw, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(aw))
+	require.NoError(t, aw.Close())
+	keyFile := filepath.Join(t.TempDir(), "key.asc")
+	require.NoError(t, ioutil.WriteFile(keyFile, publicKey.Bytes(), 0600))
+
+	return keyFile
 }
 
 func TestPluginManager_IsBackendOnlyPlugin(t *testing.T) {
@@ -225,6 +341,8 @@ type fakeBackendPluginManager struct {
 	backendplugin.Manager
 
 	registeredPlugins []string
+	startedPlugins    []string
+	stoppedPlugins    []string
 }
 
 func (f *fakeBackendPluginManager) Register(pluginID string, factory backendplugin.PluginFactoryFunc) error {
@@ -236,6 +354,31 @@ func (f *fakeBackendPluginManager) StartPlugin(ctx context.Context, pluginID str
 	return nil
 }
 
+func (f *fakeBackendPluginManager) StartManagedPlugin(pluginID string) error {
+	if !f.isRegistered(pluginID) {
+		return backendplugin.ErrPluginNotRegistered
+	}
+	f.startedPlugins = append(f.startedPlugins, pluginID)
+	return nil
+}
+
+func (f *fakeBackendPluginManager) UnregisterAndStop(ctx context.Context, pluginID string) error {
+	if !f.isRegistered(pluginID) {
+		return backendplugin.ErrPluginNotRegistered
+	}
+	f.stoppedPlugins = append(f.stoppedPlugins, pluginID)
+	return nil
+}
+
+func (f *fakeBackendPluginManager) isRegistered(pluginID string) bool {
+	for _, id := range f.registeredPlugins {
+		if id == pluginID {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *fakeBackendPluginManager) CollectMetrics(ctx context.Context, pluginID string) (*backend.CollectMetricsResult, error) {
 	return nil, nil
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/url"
@@ -68,9 +69,47 @@ func (m *pluginManifest) isV2() bool {
 	return strings.HasPrefix(m.ManifestVersion, "2.")
 }
 
+// loadSignatureKeyring returns the keys trusted to sign plugin manifests: the Grafana key, and the armored
+// public keys of the given files, e.g. the root key of an organization signing its private plugins. The keys
+// of the files are only trusted to sign manifests of the private signature type.
+func loadSignatureKeyring(keyFiles []string) (openpgp.EntityList, error) {
+	keyring, err := loadGrafanaKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, keyFile := range keyFiles {
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because `keyFile` comes from the configuration.
+		f, err := os.Open(keyFile)
+		if err != nil {
+			return nil, errutil.Wrap("failed to open plugin signature public key", err)
+		}
+		keys, err := openpgp.ReadArmoredKeyRing(f)
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, errutil.Wrapf(err, "failed to parse plugin signature public key %q", keyFile)
+		}
+		keyring = append(keyring, keys...)
+	}
+
+	return keyring, nil
+}
+
+func loadGrafanaKeyring() (openpgp.EntityList, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewBufferString(publicKeyText))
+	if err != nil {
+		return nil, errutil.Wrap("failed to parse public key", err)
+	}
+	return keyring, nil
+}
+
 // readPluginManifest attempts to read and verify the plugin manifest
-// if any error occurs or the manifest is not valid, this will return an error
-func readPluginManifest(body []byte) (*pluginManifest, error) {
+// if any error occurs or the manifest is not valid, this will return an error.
+// The manifest must be signed by a key of the keyring, or by the Grafana key when the keyring is nil.
+func readPluginManifest(body []byte, keyring openpgp.EntityList) (*pluginManifest, error) {
 	block, _ := clearsign.Decode(body)
 	if block == nil {
 		return nil, errors.New("unable to decode manifest")
@@ -83,22 +122,35 @@ func readPluginManifest(body []byte) (*pluginManifest, error) {
 		return nil, errutil.Wrap("Error parsing manifest JSON", err)
 	}
 
-	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewBufferString(publicKeyText))
-	if err != nil {
-		return nil, errutil.Wrap("failed to parse public key", err)
+	if keyring == nil {
+		if keyring, err = loadSignatureKeyring(nil); err != nil {
+			return nil, err
+		}
 	}
 
-	if _, err := openpgp.CheckDetachedSignature(keyring,
+	signer, err := openpgp.CheckDetachedSignature(keyring,
 		bytes.NewBuffer(block.Bytes),
-		block.ArmoredSignature.Body); err != nil {
+		block.ArmoredSignature.Body)
+	if err != nil {
 		return nil, errutil.Wrap("failed to check signature", err)
 	}
 
+	// only Grafana signs the other signature types, e.g. the grafana type of the core plugins
+	if manifest.SignatureType != plugins.PrivateType {
+		grafanaKeyring, err := loadGrafanaKeyring()
+		if err != nil {
+			return nil, err
+		}
+		if len(grafanaKeyring.KeysById(signer.PrimaryKey.KeyId)) == 0 {
+			return nil, fmt.Errorf("manifest of signature type %q isn't signed by Grafana", manifest.SignatureType)
+		}
+	}
+
 	return manifest, nil
 }
 
 // getPluginSignatureState returns the signature state for a plugin.
-func getPluginSignatureState(log log.Logger, plugin *plugins.PluginBase) (plugins.PluginSignatureState, error) {
+func getPluginSignatureState(log log.Logger, keyring openpgp.EntityList, plugin *plugins.PluginBase) (plugins.PluginSignatureState, error) {
 	log.Debug("Getting signature state of plugin", "plugin", plugin.Id, "isBackend", plugin.Backend)
 	manifestPath := filepath.Join(plugin.PluginDir, "MANIFEST.txt")
 
@@ -113,7 +165,7 @@ func getPluginSignatureState(log log.Logger, plugin *plugins.PluginBase) (plugin
 		}, nil
 	}
 
-	manifest, err := readPluginManifest(byteValue)
+	manifest, err := readPluginManifest(byteValue, keyring)
 	if err != nil {
 		log.Debug("Plugin signature invalid", "id", plugin.Id)
 		return plugins.PluginSignatureState{
@@ -223,5 +275,6 @@ func getPluginSignatureState(log log.Logger, plugin *plugins.PluginBase) (plugin
 		Status:     plugins.PluginSignatureValid,
 		Type:       manifest.SignatureType,
 		SigningOrg: manifest.SignedByOrgName,
+		Files:      manifest.Files,
 	}, nil
 }
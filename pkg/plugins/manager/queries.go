@@ -16,6 +16,8 @@ func (pm *PluginManager) GetPluginSettings(orgID int64) (map[string]*models.Plug
 		pluginMap[plug.PluginId] = plug
 	}
 
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	for _, pluginDef := range pm.plugins {
 		// ignore entries that exists
 		if _, ok := pluginMap[pluginDef.Id]; ok {
@@ -63,6 +65,8 @@ func (pm *PluginManager) GetEnabledPlugins(orgID int64) (*plugins.EnabledPlugins
 		return enabledPlugins, err
 	}
 
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	for pluginID, app := range pm.apps {
 		if b, ok := pluginSettingMap[pluginID]; ok {
 			app.Pinned = b.Pinned
@@ -88,6 +92,8 @@ func (pm *PluginManager) GetEnabledPlugins(orgID int64) (*plugins.EnabledPlugins
 
 // IsAppInstalled checks if an app plugin with provided plugin ID is installed.
 func (pm *PluginManager) IsAppInstalled(pluginID string) bool {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	_, exists := pm.apps[pluginID]
 	return exists
 }
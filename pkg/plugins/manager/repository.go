@@ -0,0 +1,105 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// pluginRepositories returns the plugin repositories in the order they're queried. grafana.com is the
+// only repository when they aren't configured, e.g. in tests.
+func (pm *PluginManager) pluginRepositories() []setting.PluginRepository {
+	if pm.Cfg.PluginRepositories != nil {
+		return pm.Cfg.PluginRepositories
+	}
+
+	return []setting.PluginRepository{
+		{Name: setting.GrafanaComPluginRepositoryName, URL: setting.GrafanaComUrl + "/api/plugins"},
+	}
+}
+
+// findPluginVersion returns a plugin version of the first repository knowing the plugin. The lower
+// priority repositories aren't queried when a repository fails, since they could provide another plugin
+// with the same ID.
+func (pm *PluginManager) findPluginVersion(ctx context.Context, pluginID, version string) (
+	setting.PluginRepository, *grafanaComPluginVersion, error) {
+	for _, repo := range pm.pluginRepositories() {
+		v, err := getPluginVersion(ctx, repo, pluginID, version)
+		var notFound plugins.PluginNotFoundError
+		if errors.As(err, &notFound) {
+			pm.log.Debug("Plugin not found in repository", "pluginId", pluginID, "repository", repo.Name)
+			continue
+		}
+		if err != nil {
+			return repo, nil, errutil.Wrapf(err, "plugin repository %q", repo.Name)
+		}
+		return repo, v, nil
+	}
+
+	return setting.PluginRepository{}, nil, plugins.PluginNotFoundError{PluginID: pluginID}
+}
+
+// getPluginVersions returns the latest versions of plugins known by a repository.
+func getPluginVersions(repo setting.PluginRepository, pluginIDs []string) ([]grafanaNetPlugin, error) {
+	query := url.Values{}
+	query.Set("slugIn", strings.Join(pluginIDs, ","))
+	query.Set("grafanaVersion", setting.BuildVersion)
+	req, err := newRepositoryRequest(context.Background(), repo, repo.URL+"/versioncheck?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	var versions []grafanaNetPlugin
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// newRepositoryRequest creates a request to a plugin repository, with the headers of the repository.
+func newRepositoryRequest(ctx context.Context, repo setting.PluginRepository, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("grafana-version", setting.BuildVersion)
+	for name, value := range repo.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// checkRepositoryRedirect follows the redirects of plugin repositories, e.g. to the CDN hosting the plugin
+// archives. The headers of the repository, which may hold its credentials, aren't sent to other hosts.
+func checkRepositoryRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	if req.URL.Host != via[0].URL.Host {
+		for name := range req.Header {
+			if name != "Grafana-Version" {
+				req.Header.Del(name)
+			}
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,7 +14,7 @@ import (
 )
 
 var (
-	httpClient = http.Client{Timeout: 10 * time.Second}
+	httpClient = http.Client{Timeout: 10 * time.Second, CheckRedirect: checkRepositoryRedirect}
 )
 
 type grafanaNetPlugin struct {
@@ -26,7 +27,9 @@ type gitHubLatest struct {
 	Testing string `json:"testing"`
 }
 
-func (pm *PluginManager) getAllExternalPluginSlugs() string {
+func (pm *PluginManager) getAllExternalPluginSlugs() []string {
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
 	var result []string
 	for _, plug := range pm.plugins {
 		if plug.IsCorePlugin {
@@ -35,8 +38,9 @@ func (pm *PluginManager) getAllExternalPluginSlugs() string {
 
 		result = append(result, plug.Id)
 	}
+	sort.Strings(result)
 
-	return strings.Join(result, ",")
+	return result
 }
 
 func (pm *PluginManager) checkForUpdates() {
@@ -46,59 +50,19 @@ func (pm *PluginManager) checkForUpdates() {
 
 	pm.log.Debug("Checking for updates")
 
-	pluginSlugs := pm.getAllExternalPluginSlugs()
-	resp, err := httpClient.Get("https://grafana.com/api/plugins/versioncheck?slugIn=" + pluginSlugs + "&grafanaVersion=" + setting.BuildVersion)
-	if err != nil {
-		log.Tracef("Failed to get plugins repo from grafana.com, %v", err.Error())
-		return
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Warn("Failed to close response body", "err", err)
-		}
-	}()
+	pm.checkForPluginUpdates()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Tracef("Update check failed, reading response from grafana.com, %v", err.Error())
-		return
-	}
-
-	gNetPlugins := []grafanaNetPlugin{}
-	err = json.Unmarshal(body, &gNetPlugins)
-	if err != nil {
-		log.Tracef("Failed to unmarshal plugin repo, reading response from grafana.com, %v", err.Error())
-		return
-	}
-
-	for _, plug := range pm.plugins {
-		for _, gplug := range gNetPlugins {
-			if gplug.Slug == plug.Id {
-				plug.GrafanaNetVersion = gplug.Version
-
-				plugVersion, err1 := version.NewVersion(plug.Info.Version)
-				gplugVersion, err2 := version.NewVersion(gplug.Version)
-
-				if err1 != nil || err2 != nil {
-					plug.GrafanaNetHasUpdate = plug.Info.Version != plug.GrafanaNetVersion
-				} else {
-					plug.GrafanaNetHasUpdate = plugVersion.LessThan(gplugVersion)
-				}
-			}
-		}
-	}
-
-	resp2, err := httpClient.Get("https://raw.githubusercontent.com/grafana/grafana/master/latest.json")
+	resp, err := httpClient.Get("https://raw.githubusercontent.com/grafana/grafana/master/latest.json")
 	if err != nil {
 		log.Tracef("Failed to get latest.json repo from github.com: %v", err.Error())
 		return
 	}
 	defer func() {
-		if err := resp2.Body.Close(); err != nil {
+		if err := resp.Body.Close(); err != nil {
 			pm.log.Warn("Failed to close response body", "err", err)
 		}
 	}()
-	body, err = ioutil.ReadAll(resp2.Body)
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		log.Tracef("Update check failed, reading response from github.com, %v", err.Error())
 		return
@@ -125,3 +89,43 @@ func (pm *PluginManager) checkForUpdates() {
 		pm.grafanaHasUpdate = currVersion.LessThan(latestVersion)
 	}
 }
+
+// checkForPluginUpdates gets the latest versions of the external plugins from the plugin repositories. The
+// version of a plugin is taken from the first repository knowing it.
+func (pm *PluginManager) checkForPluginUpdates() {
+	pluginSlugs := pm.getAllExternalPluginSlugs()
+	gNetPlugins := map[string]grafanaNetPlugin{}
+	for _, repo := range pm.pluginRepositories() {
+		repoPlugins, err := getPluginVersions(repo, pluginSlugs)
+		if err != nil {
+			log.Tracef("Failed to get plugin versions from plugin repository %s, %v", repo.Name, err.Error())
+			break
+		}
+
+		for _, gplug := range repoPlugins {
+			if _, exists := gNetPlugins[gplug.Slug]; !exists {
+				gNetPlugins[gplug.Slug] = gplug
+			}
+		}
+	}
+
+	pm.pluginsMu.RLock()
+	defer pm.pluginsMu.RUnlock()
+	for _, plug := range pm.plugins {
+		gplug, exists := gNetPlugins[plug.Id]
+		if !exists {
+			continue
+		}
+
+		plug.GrafanaNetVersion = gplug.Version
+
+		plugVersion, err1 := version.NewVersion(plug.Info.Version)
+		gplugVersion, err2 := version.NewVersion(gplug.Version)
+
+		if err1 != nil || err2 != nil {
+			plug.GrafanaNetHasUpdate = plug.Info.Version != plug.GrafanaNetVersion
+		} else {
+			plug.GrafanaNetHasUpdate = plugVersion.LessThan(gplugVersion)
+		}
+	}
+}
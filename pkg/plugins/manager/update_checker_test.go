@@ -0,0 +1,74 @@
+package manager
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestPluginManager_checkForPluginUpdates(t *testing.T) {
+	newRepository := func(t *testing.T, versions string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/plugins/versioncheck", r.URL.Path)
+			assert.Contains(t, r.URL.Query().Get("slugIn"), "test-app")
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = fmt.Fprint(w, versions)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	t.Run("Should take the version of the first repository knowing the plugin", func(t *testing.T) {
+		internal := newRepository(t, `[{"slug": "test-app", "version": "1.1.0"}]`)
+		public := newRepository(t, `[{"slug": "test-app", "version": "2.0.0"}, {"slug": "test-panel", "version": "1.0.1"}]`)
+		headers := map[string]string{"Authorization": "Bearer token"}
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginRepositories = []setting.PluginRepository{
+				{Name: "internal", URL: internal.URL + "/api/plugins", Headers: headers},
+				{Name: "public", URL: public.URL + "/api/plugins", Headers: headers},
+			}
+		})
+		pm.plugins = map[string]*plugins.PluginBase{
+			"test-app":   {Id: "test-app", Info: plugins.PluginInfo{Version: "1.0.0"}},
+			"test-panel": {Id: "test-panel", Info: plugins.PluginInfo{Version: "1.0.1"}},
+			"graph":      {Id: "graph", IsCorePlugin: true},
+		}
+
+		pm.checkForPluginUpdates()
+
+		assert.Equal(t, "1.1.0", pm.plugins["test-app"].GrafanaNetVersion)
+		assert.True(t, pm.plugins["test-app"].GrafanaNetHasUpdate)
+		assert.Equal(t, "1.0.1", pm.plugins["test-panel"].GrafanaNetVersion)
+		assert.False(t, pm.plugins["test-panel"].GrafanaNetHasUpdate)
+		assert.Empty(t, pm.plugins["graph"].GrafanaNetVersion)
+	})
+
+	t.Run("Should not query the next repositories when a repository fails", func(t *testing.T) {
+		internal := newRepository(t, `[]`)
+		public := newRepository(t, `[{"slug": "test-app", "version": "2.0.0"}]`)
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginRepositories = []setting.PluginRepository{
+				{Name: "internal", URL: internal.URL + "/api/plugins"},
+				{Name: "public", URL: public.URL + "/api/plugins", Headers: map[string]string{"Authorization": "Bearer token"}},
+			}
+		})
+		pm.plugins = map[string]*plugins.PluginBase{
+			"test-app": {Id: "test-app", Info: plugins.PluginInfo{Version: "1.0.0"}},
+		}
+
+		pm.checkForPluginUpdates()
+
+		assert.Empty(t, pm.plugins["test-app"].GrafanaNetVersion)
+	})
+}
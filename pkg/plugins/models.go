@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -13,6 +14,23 @@ const (
 	PluginTypeDashboard = "dashboard"
 )
 
+var (
+	// ErrPluginArchiveInvalid occurs when a plugin archive isn't a zip file with a plugin.json.
+	ErrPluginArchiveInvalid = errors.New("invalid plugin archive")
+	// ErrPluginVersionNotFound occurs when a plugin version isn't available for this OS and architecture.
+	ErrPluginVersionNotFound = errors.New("plugin version not found")
+	// ErrPluginSignatureNotValid occurs when installing a plugin which isn't allowed by the signature policy.
+	ErrPluginSignatureNotValid = errors.New("plugin signature is not valid")
+	// ErrPluginNotExternal occurs when updating or uninstalling a plugin which isn't installed in the
+	// plugins directory, such as a core or bundled plugin.
+	ErrPluginNotExternal = errors.New("plugin is not installed in the plugins directory")
+	// ErrPluginIncluded occurs when uninstalling a plugin included in an app plugin.
+	ErrPluginIncluded = errors.New("plugin is included in another plugin")
+	// ErrPluginRequiresRestart occurs when installing or uninstalling a plugin which is only loaded on
+	// startup, such as the renderer plugin.
+	ErrPluginRequiresRestart = errors.New("plugin can only be installed or uninstalled with a restart")
+)
+
 type PluginNotFoundError struct {
 	PluginID string
 }
@@ -59,6 +77,9 @@ type PluginBase struct {
 	Signature    PluginSignatureStatus `json:"signature"`
 	Backend      bool                  `json:"backend"`
 
+	// ModuleIntegrity is the subresource integrity hash of the module, when it's signed.
+	ModuleIntegrity string `json:"moduleIntegrity,omitempty"`
+
 	IncludedInAppId string              `json:"-"`
 	PluginDir       string              `json:"-"`
 	DefaultNavUrl   string              `json:"-"`
@@ -66,6 +87,7 @@ type PluginBase struct {
 	Files           []string            `json:"-"`
 	SignatureType   PluginSignatureType `json:"-"`
 	SignatureOrg    string              `json:"-"`
+	SignedFiles     map[string]string   `json:"-"`
 
 	GrafanaNetVersion   string `json:"-"`
 	GrafanaNetHasUpdate bool   `json:"-"`
@@ -135,6 +157,8 @@ type PluginScreenshots struct {
 type PluginStaticRoute struct {
 	Directory string
 	PluginId  string
+	// Assets are the indexed files of the directory, served with content hashes and ETags.
+	Assets PluginAssets
 }
 
 type EnabledPlugins struct {
@@ -35,4 +35,7 @@ type PluginSignatureState struct {
 	Status     PluginSignatureStatus
 	Type       PluginSignatureType
 	SigningOrg string
+	// Files are the SHA-256 hashes of the signed files, by path relative to the plugin directory, when the
+	// signature is valid.
+	Files map[string]string
 }
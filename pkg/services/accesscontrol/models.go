@@ -62,6 +62,7 @@ const (
 	ActionUsersLogout            = "users:logout"
 	ActionUsersQuotasList        = "users.quotas:list"
 	ActionUsersQuotasUpdate      = "users.quotas:update"
+	ActionUsersTOTPDelete        = "users.totp:delete"
 
 	// Global Scopes
 	ScopeUsersAll  = "users:*"
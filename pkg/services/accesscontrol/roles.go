@@ -33,7 +33,7 @@ var PredefinedRoles = map[string]RoleDTO{
 	},
 	usersAdminEdit: {
 		Name:    usersAdminEdit,
-		Version: 1,
+		Version: 2,
 		Permissions: []Permission{
 			{
 				// Inherited from grafana:roles:users:admin:read
@@ -94,6 +94,10 @@ var PredefinedRoles = map[string]RoleDTO{
 				Action: ActionUsersQuotasUpdate,
 				Scope:  ScopeUsersAll,
 			},
+			{
+				Action: ActionUsersTOTPDelete,
+				Scope:  ScopeUsersAll,
+			},
 		},
 	},
 }
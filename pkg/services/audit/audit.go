@@ -0,0 +1,145 @@
+// Package audit stores the audit log of the mutating API requests, recorded by the audit
+// middleware, and deletes the entries older than the audit retention.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var getTime = time.Now
+
+func init() {
+	registry.RegisterService(&Service{})
+}
+
+type Service struct {
+	Cfg               *setting.Cfg                  `inject:""`
+	Bus               bus.Bus                       `inject:""`
+	SQLStore          *sqlstore.SQLStore            `inject:""`
+	ServerLockService *serverlock.ServerLockService `inject:""`
+
+	log log.Logger
+
+	// logFile mirrors the audit entries as JSON lines, if audit.log_path is set
+	logMutex sync.Mutex
+	logFile  *os.File
+}
+
+func (s *Service) Init() error {
+	s.log = log.New("audit")
+	s.Bus.AddHandler(s.addAuditEntry)
+
+	if s.IsDisabled() || s.Cfg.Audit.LogPath == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Cfg.Audit.LogPath), 0750); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because the path comes from the configuration.
+	file, err := os.OpenFile(s.Cfg.Audit.LogPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	s.logFile = file
+
+	return nil
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.Cfg.Audit.Enabled
+}
+
+// Run deletes the audit entries older than the retention every hour.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		s.cleanUp(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			s.closeLogFile()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) cleanUp(ctx context.Context) {
+	if s.Cfg.Audit.Retention <= 0 {
+		return
+	}
+
+	err := s.ServerLockService.LockAndExecute(ctx, "cleanup old audit entries", time.Hour/2, func() {
+		affected, err := s.deleteEntriesOlderThan(ctx, getTime().Add(-s.Cfg.Audit.Retention))
+		if err != nil {
+			s.log.Error("Failed to delete old audit entries", "error", err)
+			return
+		}
+		s.log.Debug("Deleted old audit entries", "rows affected", affected)
+	})
+	if err != nil {
+		s.log.Error("Failed to lock and execute cleanup of old audit entries", "error", err)
+	}
+}
+
+func (s *Service) addAuditEntry(cmd *models.AddAuditEntryCommand) error {
+	cmd.Entry.Created = getTime()
+
+	if err := s.insertEntry(context.Background(), cmd.Entry); err != nil {
+		return err
+	}
+
+	s.writeLogFile(cmd.Entry)
+	return nil
+}
+
+// writeLogFile mirrors an entry to the log file. Failures are only logged, since the entry is
+// already stored in the database.
+func (s *Service) writeLogFile(entry *models.AuditEntry) {
+	s.logMutex.Lock()
+	defer s.logMutex.Unlock()
+
+	if s.logFile == nil {
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		s.log.Error("Failed to encode audit entry", "error", err)
+		return
+	}
+	if _, err := s.logFile.Write(append(line, '\n')); err != nil {
+		s.log.Error("Failed to write audit log file", "path", s.Cfg.Audit.LogPath, "error", err)
+	}
+}
+
+func (s *Service) closeLogFile() {
+	s.logMutex.Lock()
+	defer s.logMutex.Unlock()
+
+	if s.logFile == nil {
+		return
+	}
+	if err := s.logFile.Close(); err != nil {
+		s.log.Warn("Failed to close audit log file", "error", err)
+	}
+	s.logFile = nil
+}
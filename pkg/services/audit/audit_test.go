@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func setupTestService(t *testing.T, logPath string) *Service {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.Audit = setting.AuditSettings{Enabled: true, Retention: 24 * time.Hour, LogPath: logPath}
+	s := &Service{Cfg: cfg, Bus: bus.New(), SQLStore: sqlstore.InitTestDB(t)}
+	require.NoError(t, s.Init())
+	t.Cleanup(s.closeLogFile)
+
+	return s
+}
+
+func addEntry(t *testing.T, s *Service, created time.Time, entry models.AuditEntry) {
+	t.Helper()
+
+	origGetTime := getTime
+	getTime = func() time.Time { return created }
+	t.Cleanup(func() { getTime = origGetTime })
+
+	require.NoError(t, s.Bus.Dispatch(&models.AddAuditEntryCommand{Entry: &entry}))
+}
+
+func TestService(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("stores, searches and deletes audit entries", func(t *testing.T) {
+		s := setupTestService(t, "")
+		addEntry(t, s, now.Add(-48*time.Hour), models.AuditEntry{OrgId: 1, UserId: 1, Action: "POST /api/dashboards/db"})
+		addEntry(t, s, now.Add(-time.Hour), models.AuditEntry{OrgId: 1, UserId: 2, Action: "DELETE /api/datasources/:id", Target: "/api/datasources/3"})
+		addEntry(t, s, now, models.AuditEntry{OrgId: 2, UserId: 2, Action: "DELETE /api/dashboards/uid/:uid"})
+
+		result, err := s.Search(context.Background(), SearchQuery{})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Entries, 3)
+		assert.Equal(t, "DELETE /api/dashboards/uid/:uid", result.Entries[0].Action)
+		assert.Equal(t, defaultPerPage, result.PerPage)
+
+		result, err = s.Search(context.Background(), SearchQuery{OrgId: 1, Action: "DELETE"})
+		require.NoError(t, err)
+		require.Len(t, result.Entries, 1)
+		assert.Equal(t, "/api/datasources/3", result.Entries[0].Target)
+
+		result, err = s.Search(context.Background(), SearchQuery{UserId: 2, From: now.Add(-2 * time.Hour), To: now.Add(-time.Minute)})
+		require.NoError(t, err)
+		require.Len(t, result.Entries, 1)
+		assert.Equal(t, int64(1), result.Entries[0].OrgId)
+
+		result, err = s.Search(context.Background(), SearchQuery{Page: 2, PerPage: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Entries, 1)
+		assert.Equal(t, "POST /api/dashboards/db", result.Entries[0].Action)
+
+		affected, err := s.deleteEntriesOlderThan(context.Background(), now.Add(-s.Cfg.Audit.Retention))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+	})
+
+	t.Run("truncates the values longer than their column", func(t *testing.T) {
+		s := setupTestService(t, "")
+		login := strings.Repeat("é", userLoginLength+10)
+		addEntry(t, s, now, models.AuditEntry{OrgId: 1, UserLogin: login, Action: "POST " + strings.Repeat("a", actionLength)})
+
+		result, err := s.Search(context.Background(), SearchQuery{})
+		require.NoError(t, err)
+		require.Len(t, result.Entries, 1)
+		assert.Equal(t, login[:2*userLoginLength], result.Entries[0].UserLogin)
+		assert.Len(t, result.Entries[0].Action, actionLength)
+	})
+
+	t.Run("mirrors audit entries to the log file", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "audit", "audit.log")
+		s := setupTestService(t, logPath)
+		addEntry(t, s, now, models.AuditEntry{OrgId: 1, UserLogin: "admin", Action: "POST /api/auth/keys", RequestId: "abc"})
+		addEntry(t, s, now, models.AuditEntry{OrgId: 1, UserLogin: "admin", Action: "DELETE /api/auth/keys/:id"})
+
+		file, err := os.Open(logPath)
+		require.NoError(t, err)
+		defer func() { _ = file.Close() }()
+
+		var entries []models.AuditEntry
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var entry models.AuditEntry
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+			entries = append(entries, entry)
+		}
+		require.Len(t, entries, 2)
+		assert.Equal(t, "POST /api/auth/keys", entries[0].Action)
+		assert.Equal(t, "abc", entries[0].RequestId)
+		assert.Equal(t, "admin", entries[1].UserLogin)
+	})
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+const (
+	defaultPerPage = 100
+	maxPerPage     = 1000
+)
+
+// SearchQuery filters the audit entries. Zero values don't filter.
+type SearchQuery struct {
+	OrgId  int64
+	UserId int64
+	// Action matches the entries whose action contains it, e.g. "DELETE" or "/api/datasources".
+	Action  string
+	From    time.Time
+	To      time.Time
+	Page    int
+	PerPage int
+}
+
+type SearchResult struct {
+	TotalCount int64                `json:"totalCount"`
+	Entries    []*models.AuditEntry `json:"entries"`
+	Page       int                  `json:"page"`
+	PerPage    int                  `json:"perPage"`
+}
+
+// Search returns the audit entries matching the query, the most recent first.
+func (s *Service) Search(ctx context.Context, query SearchQuery) (*SearchResult, error) {
+	if query.PerPage <= 0 {
+		query.PerPage = defaultPerPage
+	}
+	if query.PerPage > maxPerPage {
+		query.PerPage = maxPerPage
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	var conditions []string
+	var params []interface{}
+	if query.OrgId != 0 {
+		conditions = append(conditions, "org_id = ?")
+		params = append(params, query.OrgId)
+	}
+	if query.UserId != 0 {
+		conditions = append(conditions, "user_id = ?")
+		params = append(params, query.UserId)
+	}
+	if query.Action != "" {
+		conditions = append(conditions, "action "+s.SQLStore.Dialect.LikeStr()+" ?")
+		params = append(params, "%"+query.Action+"%")
+	}
+	if !query.From.IsZero() {
+		conditions = append(conditions, "created >= ?")
+		params = append(params, query.From)
+	}
+	if !query.To.IsZero() {
+		conditions = append(conditions, "created <= ?")
+		params = append(params, query.To)
+	}
+
+	result := &SearchResult{
+		Entries: make([]*models.AuditEntry, 0),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	}
+	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		// the conditions are reset after each query of the session
+		filtered := func() *xorm.Session {
+			sess := dbSession.Table("audit_entry")
+			if len(conditions) > 0 {
+				sess.Where(strings.Join(conditions, " AND "), params...)
+			}
+			return sess
+		}
+
+		count, err := filtered().Count()
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		return filtered().
+			Desc("created", "id").
+			Limit(query.PerPage, (query.Page-1)*query.PerPage).
+			Find(&result.Entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Lengths of the string columns of the audit_entry table, which Target is not limited by.
+const (
+	userLoginLength = 190
+	actionLength    = 255
+	ipAddressLength = 50
+	requestIDLength = 64
+)
+
+// insertEntry stores an entry, truncating the values longer than their column so that no entry is
+// lost because of its length.
+func (s *Service) insertEntry(ctx context.Context, entry *models.AuditEntry) error {
+	entry.UserLogin = truncate(entry.UserLogin, userLoginLength)
+	entry.Action = truncate(entry.Action, actionLength)
+	entry.IpAddress = truncate(entry.IpAddress, ipAddressLength)
+	entry.RequestId = truncate(entry.RequestId, requestIDLength)
+
+	return s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		_, err := dbSession.Insert(entry)
+		return err
+	})
+}
+
+func (s *Service) deleteEntriesOlderThan(ctx context.Context, olderThan time.Time) (int64, error) {
+	var affected int64
+	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		res, err := dbSession.Exec("DELETE FROM audit_entry WHERE created < ?", olderThan)
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected, err
+}
+
+// truncate returns the first n characters of value.
+func truncate(value string, n int) string {
+	runes := []rune(value)
+	if len(runes) <= n {
+		return value
+	}
+	return string(runes[:n])
+}
@@ -101,6 +101,12 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *models.Use
 
 	s.log.Debug("user auth token created", "tokenId", userAuthToken.Id, "userId", userAuthToken.UserId, "clientIP", userAuthToken.ClientIp, "userAgent", userAuthToken.UserAgent, "authToken", userAuthToken.AuthToken)
 
+	if s.Cfg.LoginMaxConcurrentSessions > 0 {
+		if err := s.evictOldestTokens(ctx, user.Id, s.Cfg.LoginMaxConcurrentSessions); err != nil {
+			return nil, err
+		}
+	}
+
 	var userToken models.UserToken
 	err = userAuthToken.toUserToken(&userToken)
 
@@ -344,6 +350,61 @@ func (s *UserAuthTokenService) RevokeAllUserTokens(ctx context.Context, userId i
 	})
 }
 
+// RevokeOtherUserTokens revokes all the tokens of a user except one, to log out from all the other
+// devices.
+func (s *UserAuthTokenService) RevokeOtherUserTokens(ctx context.Context, userId, keepTokenId int64) error {
+	return s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		sql := `DELETE from user_auth_token WHERE user_id = ? AND id <> ?`
+		res, err := dbSession.Exec(sql, userId, keepTokenId)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		s.log.Debug("other user tokens for user revoked", "userId", userId, "tokenId", keepTokenId, "count", affected)
+
+		return nil
+	})
+}
+
+// evictOldestTokens deletes the oldest active tokens of a user above the concurrent sessions limit.
+func (s *UserAuthTokenService) evictOldestTokens(ctx context.Context, userId int64, limit int) error {
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		var tokens []*userAuthToken
+		err := dbSession.Where("user_id = ? AND created_at > ? AND rotated_at > ? AND revoked_at = 0",
+			userId,
+			s.createdAfterParam(),
+			s.rotatedAfterParam()).
+			Desc("created_at", "id").
+			Find(&tokens)
+		if err != nil {
+			return err
+		}
+
+		if len(tokens) <= limit {
+			return nil
+		}
+
+		evictedIds := make([]int64, 0, len(tokens)-limit)
+		for _, token := range tokens[limit:] {
+			evictedIds = append(evictedIds, token.Id)
+		}
+
+		affected, err := dbSession.In("id", evictedIds).Delete(&userAuthToken{})
+		if err != nil {
+			return err
+		}
+
+		s.log.Debug("oldest user tokens evicted", "userId", userId, "limit", limit, "count", affected)
+
+		return nil
+	})
+}
+
 func (s *UserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context, userIds []int64) error {
 	return s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
 		if len(userIds) == 0 {
@@ -130,6 +130,56 @@ func TestUserAuthToken(t *testing.T) {
 					So(err, ShouldBeNil)
 					So(model2, ShouldBeNil)
 				})
+
+				Convey("Can revoke all other user tokens", func() {
+					err := userAuthTokenService.RevokeOtherUserTokens(context.Background(), userID, userToken2.Id)
+					So(err, ShouldBeNil)
+
+					model, err := ctx.getAuthTokenByID(userToken.Id)
+					So(err, ShouldBeNil)
+					So(model, ShouldBeNil)
+
+					model2, err := ctx.getAuthTokenByID(userToken2.Id)
+					So(err, ShouldBeNil)
+					So(model2, ShouldNotBeNil)
+				})
+			})
+
+			Convey("When concurrent sessions are limited should evict the oldest tokens", func() {
+				userAuthTokenService.Cfg.LoginMaxConcurrentSessions = 2
+				defer func() { userAuthTokenService.Cfg.LoginMaxConcurrentSessions = 0 }()
+
+				otherUserToken, err := userAuthTokenService.CreateToken(context.Background(), &models.User{Id: userID + 1},
+					net.ParseIP("192.168.10.11"), "some user agent")
+				So(err, ShouldBeNil)
+
+				getTime = func() time.Time { return t.Add(time.Minute) }
+				userToken2, err := userAuthTokenService.CreateToken(context.Background(), user,
+					net.ParseIP("192.168.10.12"), "some user agent")
+				So(err, ShouldBeNil)
+
+				tokens, err := userAuthTokenService.GetUserTokens(context.Background(), userID)
+				So(err, ShouldBeNil)
+				So(tokens, ShouldHaveLength, 2)
+
+				getTime = func() time.Time { return t.Add(2 * time.Minute) }
+				userToken3, err := userAuthTokenService.CreateToken(context.Background(), user,
+					net.ParseIP("192.168.10.13"), "some user agent")
+				So(err, ShouldBeNil)
+
+				tokens, err = userAuthTokenService.GetUserTokens(context.Background(), userID)
+				So(err, ShouldBeNil)
+				So(tokens, ShouldHaveLength, 2)
+				So(tokens[0].Id, ShouldEqual, userToken2.Id)
+				So(tokens[1].Id, ShouldEqual, userToken3.Id)
+
+				model, err := ctx.getAuthTokenByID(userToken.Id)
+				So(err, ShouldBeNil)
+				So(model, ShouldBeNil)
+
+				otherModel, err := ctx.getAuthTokenByID(otherUserToken.Id)
+				So(err, ShouldBeNil)
+				So(otherModel, ShouldNotBeNil)
 			})
 
 			Convey("When revoking users tokens in a batch", func() {
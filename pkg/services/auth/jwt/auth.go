@@ -30,6 +30,7 @@ type AuthService struct {
 	log              log.Logger
 	expect           map[string]interface{}
 	expectRegistered jwt.Expected
+	orgMapping       []orgMapping
 }
 
 func (s *AuthService) Init() error {
@@ -45,6 +46,9 @@ func (s *AuthService) Init() error {
 	if err := s.initKeySet(); err != nil {
 		return err
 	}
+	if err := s.initOrgMapping(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -0,0 +1,219 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/jmespath/go-jmespath"
+)
+
+// ErrMissingLoginClaim occurs when a token has neither a username nor an email claim.
+var ErrMissingLoginClaim = errors.New("failed to get an authentication claim from JWT")
+
+// orgMapping maps a value of the org attribute to a role in an org given by ID or name.
+type orgMapping struct {
+	value string
+	org   string
+	role  models.RoleType
+}
+
+func (s *AuthService) initOrgMapping() error {
+	s.orgMapping = nil
+	for _, item := range util.SplitString(s.Cfg.JWTAuthOrgMapping) {
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid org mapping %q, <value>:<org id or name>:<role> expected", item)
+		}
+
+		mapping := orgMapping{value: parts[0], org: parts[1]}
+		if len(parts) == 3 && parts[2] != "" {
+			mapping.role = models.RoleType(parts[2])
+			if !mapping.role.IsValid() {
+				return fmt.Errorf("invalid role %q in org mapping %q", parts[2], item)
+			}
+		}
+		s.orgMapping = append(s.orgMapping, mapping)
+	}
+
+	return nil
+}
+
+// ExternalUserInfo returns the user described by the claims of a verified token, used to sign up
+// and sync the user.
+func (s *AuthService) ExternalUserInfo(claims models.JWTClaims) (*models.ExternalUserInfo, error) {
+	extUser := &models.ExternalUserInfo{
+		AuthModule: models.AuthModuleJWT,
+		OrgRoles:   map[int64]models.RoleType{},
+	}
+
+	if key := s.Cfg.JWTAuthUsernameClaim; key != "" {
+		extUser.Login, _ = claims[key].(string)
+	}
+	if key := s.Cfg.JWTAuthEmailClaim; key != "" {
+		extUser.Email, _ = claims[key].(string)
+	}
+	if key := s.Cfg.JWTAuthNameClaim; key != "" {
+		extUser.Name, _ = claims[key].(string)
+	}
+	if extUser.Login == "" && extUser.Email == "" {
+		return nil, ErrMissingLoginClaim
+	}
+	if extUser.Login == "" {
+		extUser.Login = extUser.Email
+	}
+
+	extUser.AuthId, _ = claims["sub"].(string)
+	if extUser.AuthId == "" {
+		extUser.AuthId = extUser.Login
+	}
+
+	role, err := s.extractRole(claims)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" {
+		orgID := int64(1)
+		if s.Cfg.AutoAssignOrg && s.Cfg.AutoAssignOrgId > 0 {
+			orgID = int64(s.Cfg.AutoAssignOrgId)
+		}
+		extUser.OrgRoles[orgID] = role
+	}
+
+	if err := s.mapOrgs(claims, role, extUser); err != nil {
+		return nil, err
+	}
+
+	if s.Cfg.JWTAuthGroupsAttributePath != "" {
+		extUser.Groups, err = searchClaimsForStrings(s.Cfg.JWTAuthGroupsAttributePath, claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return extUser, nil
+}
+
+// extractRole returns the role given by the role attribute path, or an empty role if there is none.
+func (s *AuthService) extractRole(claims models.JWTClaims) (models.RoleType, error) {
+	if s.Cfg.JWTAuthRoleAttributePath == "" {
+		return "", nil
+	}
+
+	values, err := searchClaimsForStrings(s.Cfg.JWTAuthRoleAttributePath, claims)
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	role := models.RoleType(values[0])
+	if !role.IsValid() {
+		s.log.Warn("Ignoring invalid role from JWT", "role", role)
+		return "", nil
+	}
+	return role, nil
+}
+
+// mapOrgs adds the org roles given by the org mapping to extUser. Mappings without a role use the
+// role given by the role attribute path, or the role of users auto-assigned to an org.
+func (s *AuthService) mapOrgs(claims models.JWTClaims, role models.RoleType, extUser *models.ExternalUserInfo) error {
+	if len(s.orgMapping) == 0 {
+		return nil
+	}
+
+	var values []string
+	if s.Cfg.JWTAuthOrgAttributePath != "" {
+		var err error
+		if values, err = searchClaimsForStrings(s.Cfg.JWTAuthOrgAttributePath, claims); err != nil {
+			return err
+		}
+	}
+
+	if role == "" {
+		role = models.RoleType(s.Cfg.AutoAssignOrgRole)
+	}
+
+	for _, mapping := range s.orgMapping {
+		if mapping.value != "*" && !containsString(values, mapping.value) {
+			continue
+		}
+
+		orgID, err := getOrgID(mapping.org)
+		if err != nil {
+			if errors.Is(err, models.ErrOrgNotFound) {
+				s.log.Warn("Ignoring org mapping of unknown org", "org", mapping.org)
+				continue
+			}
+			return err
+		}
+
+		mappedRole := mapping.role
+		if mappedRole == "" {
+			mappedRole = role
+		}
+		// keep the highest role when several mappings match the same org
+		if current, ok := extUser.OrgRoles[orgID]; !ok || !current.Includes(mappedRole) {
+			extUser.OrgRoles[orgID] = mappedRole
+		}
+	}
+
+	return nil
+}
+
+func getOrgID(orgIDOrName string) (int64, error) {
+	if id, err := strconv.ParseInt(orgIDOrName, 10, 64); err == nil {
+		query := models.GetOrgByIdQuery{Id: id}
+		if err := bus.Dispatch(&query); err != nil {
+			return 0, err
+		}
+		return query.Result.Id, nil
+	}
+
+	query := models.GetOrgByNameQuery{Name: orgIDOrName}
+	if err := bus.Dispatch(&query); err != nil {
+		return 0, err
+	}
+	return query.Result.Id, nil
+}
+
+// searchClaimsForStrings evaluates a JMESPath expression against the claims. The result must be a
+// string or an array of strings.
+func searchClaimsForStrings(path string, claims models.JWTClaims) ([]string, error) {
+	val, err := jmespath.Search(path, map[string]interface{}(claims))
+	if err != nil {
+		return nil, errutil.Wrapf(err, "failed to search JWT claims with provided path: %q", path)
+	}
+
+	switch val := val.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		values := make([]string, 0, len(val))
+		for _, v := range val {
+			if v, ok := v.(string); ok {
+				values = append(values, v)
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("JWT claims searched with path %q has invalid type %T, string or array expected", path, val)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
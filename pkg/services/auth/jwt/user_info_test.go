@@ -0,0 +1,151 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalUserInfo(t *testing.T) {
+	key := rsaKeys[0]
+
+	configureClaims := func(t *testing.T, cfg *setting.Cfg) {
+		cfg.JWTAuthUsernameClaim = "sub"
+		cfg.JWTAuthEmailClaim = "email"
+		cfg.JWTAuthNameClaim = "name"
+		cfg.AutoAssignOrg = true
+		cfg.AutoAssignOrgId = 1
+		cfg.AutoAssignOrgRole = "Viewer"
+	}
+
+	configureRoleAttributePath := func(t *testing.T, cfg *setting.Cfg) {
+		cfg.JWTAuthRoleAttributePath = "contains(roles[*], 'admin') && 'Admin' || contains(roles[*], 'editor') && 'Editor' || 'Viewer'"
+	}
+
+	createOrg := func(t *testing.T, name string) int64 {
+		cmd := models.CreateOrgCommand{Name: name}
+		require.NoError(t, bus.Dispatch(&cmd))
+		return cmd.Result.Id
+	}
+
+	verify := func(t *testing.T, sc scenarioContext, claims map[string]interface{}) *models.ExternalUserInfo {
+		verifiedClaims, err := sc.authJWTSvc.Verify(sc.ctx, sign(t, key, claims))
+		require.NoError(t, err)
+		extUser, err := sc.authJWTSvc.ExternalUserInfo(verifiedClaims)
+		require.NoError(t, err)
+		return extUser
+	}
+
+	scenario(t, "maps login, email and name claims", func(t *testing.T, sc scenarioContext) {
+		extUser := verify(t, sc, map[string]interface{}{
+			"sub":   "vladimir",
+			"email": "vladimir@example.com",
+			"name":  "Vladimir",
+		})
+		assert.Equal(t, models.AuthModuleJWT, extUser.AuthModule)
+		assert.Equal(t, "vladimir", extUser.AuthId)
+		assert.Equal(t, "vladimir", extUser.Login)
+		assert.Equal(t, "vladimir@example.com", extUser.Email)
+		assert.Equal(t, "Vladimir", extUser.Name)
+		assert.Empty(t, extUser.OrgRoles)
+	}, configurePKIXPublicKeyFile, configureClaims)
+
+	scenario(t, "rejects claims without login or email", func(t *testing.T, sc scenarioContext) {
+		verifiedClaims, err := sc.authJWTSvc.Verify(sc.ctx, sign(t, key, map[string]interface{}{
+			"name": "Vladimir",
+		}))
+		require.NoError(t, err)
+		_, err = sc.authJWTSvc.ExternalUserInfo(verifiedClaims)
+		require.Equal(t, ErrMissingLoginClaim, err)
+	}, configurePKIXPublicKeyFile, configureClaims)
+
+	scenario(t, "maps role using role attribute path", func(t *testing.T, sc scenarioContext) {
+		extUser := verify(t, sc, map[string]interface{}{
+			"sub":   "vladimir",
+			"roles": []interface{}{"editor"},
+		})
+		assert.Equal(t, map[int64]models.RoleType{1: models.ROLE_EDITOR}, extUser.OrgRoles)
+
+		extUser = verify(t, sc, map[string]interface{}{
+			"sub":   "vladimir",
+			"roles": []interface{}{},
+		})
+		assert.Equal(t, map[int64]models.RoleType{1: models.ROLE_VIEWER}, extUser.OrgRoles)
+	}, configurePKIXPublicKeyFile, configureClaims, configureRoleAttributePath)
+
+	scenario(t, "ignores invalid role", func(t *testing.T, sc scenarioContext) {
+		extUser := verify(t, sc, map[string]interface{}{
+			"sub":  "vladimir",
+			"role": "Superuser",
+		})
+		assert.Empty(t, extUser.OrgRoles)
+	}, configurePKIXPublicKeyFile, configureClaims, func(t *testing.T, cfg *setting.Cfg) {
+		cfg.JWTAuthRoleAttributePath = "role"
+	})
+
+	scenario(t, "maps orgs using org mapping", func(t *testing.T, sc scenarioContext) {
+		engineeringID := createOrg(t, "Engineering")
+		supportID := createOrg(t, "Support")
+		require.NoError(t, sc.authJWTSvc.initOrgMapping())
+
+		extUser := verify(t, sc, map[string]interface{}{
+			"sub":   "vladimir",
+			"roles": []interface{}{"editor"},
+			"orgs":  []interface{}{"engineering", "support"},
+		})
+		assert.Equal(t, map[int64]models.RoleType{
+			1:             models.ROLE_EDITOR,
+			engineeringID: models.ROLE_EDITOR,
+			supportID:     models.ROLE_VIEWER,
+		}, extUser.OrgRoles)
+
+		extUser = verify(t, sc, map[string]interface{}{
+			"sub":   "vladimir",
+			"roles": []interface{}{},
+			"orgs":  "engineering",
+		})
+		assert.Equal(t, map[int64]models.RoleType{
+			1:             models.ROLE_VIEWER,
+			engineeringID: models.ROLE_VIEWER,
+		}, extUser.OrgRoles)
+	}, configurePKIXPublicKeyFile, configureClaims, configureRoleAttributePath, func(t *testing.T, cfg *setting.Cfg) {
+		cfg.JWTAuthOrgAttributePath = "orgs"
+		cfg.JWTAuthOrgMapping = "engineering:Engineering support:Support:Viewer unknown:Unknown:Admin"
+	})
+
+	scenario(t, "maps any user with wildcard org mapping", func(t *testing.T, sc scenarioContext) {
+		orgID := createOrg(t, "Everyone")
+		sc.cfg.JWTAuthOrgMapping = "*:Everyone:Editor"
+		require.NoError(t, sc.authJWTSvc.initOrgMapping())
+
+		extUser := verify(t, sc, map[string]interface{}{
+			"sub": "vladimir",
+		})
+		assert.Equal(t, map[int64]models.RoleType{orgID: models.ROLE_EDITOR}, extUser.OrgRoles)
+	}, configurePKIXPublicKeyFile, configureClaims)
+
+	scenario(t, "maps groups using groups attribute path", func(t *testing.T, sc scenarioContext) {
+		extUser := verify(t, sc, map[string]interface{}{
+			"sub": "vladimir",
+			"realm_access": map[string]interface{}{
+				"groups": []interface{}{"admins", "developers"},
+			},
+		})
+		assert.Equal(t, []string{"admins", "developers"}, extUser.Groups)
+	}, configurePKIXPublicKeyFile, configureClaims, func(t *testing.T, cfg *setting.Cfg) {
+		cfg.JWTAuthGroupsAttributePath = "realm_access.groups"
+	})
+}
+
+func TestOrgMappingValidation(t *testing.T) {
+	for _, mapping := range []string{"engineering", ":1", "engineering:1:Superuser", "a:b:c:d"} {
+		_, err := initAuthService(t, configurePKIXPublicKeyFile, func(t *testing.T, cfg *setting.Cfg) {
+			cfg.JWTAuthOrgMapping = mapping
+		})
+		assert.Error(t, err, mapping)
+	}
+}
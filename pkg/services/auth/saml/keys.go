@@ -0,0 +1,142 @@
+package saml
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	crewjamsaml "github.com/crewjam/saml"
+)
+
+// loadSetting returns the content of a setting given either as a base64-encoded value or as
+// a path to a file.
+func loadSetting(name, value, path string) ([]byte, error) {
+	switch {
+	case value != "" && path != "":
+		return nil, fmt.Errorf("only one of %s and %s_path can be set", name, name)
+	case value != "":
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		return data, nil
+	case path != "":
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because the path comes from the configuration
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s_path: %w", name, err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("%s or %s_path must be set", name, name)
+	}
+}
+
+func loadCertificate(value, path string) (*x509.Certificate, error) {
+	data, err := loadSetting("certificate", value, path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("certificate is not a PEM encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func loadPrivateKey(value, path string) (*rsa.PrivateKey, error) {
+	data, err := loadSetting("private_key", value, path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private_key is not a PEM encoded key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private_key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key must be an RSA key")
+	}
+	return rsaKey, nil
+}
+
+func loadIDPMetadata(value, path, metadataURL string) (*crewjamsaml.EntityDescriptor, error) {
+	var data []byte
+	var err error
+	if metadataURL != "" {
+		if value != "" || path != "" {
+			return nil, errors.New("only one of idp_metadata, idp_metadata_path and idp_metadata_url can be set")
+		}
+		data, err = fetchIDPMetadata(metadataURL)
+	} else {
+		data, err = loadSetting("idp_metadata", value, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseIDPMetadata(data)
+}
+
+func fetchIDPMetadata(metadataURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch idp_metadata_url: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch idp_metadata_url: status %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// parseIDPMetadata parses an EntityDescriptor, or the first IdP EntityDescriptor of an
+// EntitiesDescriptor.
+func parseIDPMetadata(data []byte) (*crewjamsaml.EntityDescriptor, error) {
+	entity := &crewjamsaml.EntityDescriptor{}
+	if err := xml.Unmarshal(data, entity); err == nil {
+		if len(entity.IDPSSODescriptors) == 0 {
+			return nil, errors.New("the IdP metadata has no IDPSSODescriptor")
+		}
+		return entity, nil
+	}
+
+	entities := &crewjamsaml.EntitiesDescriptor{}
+	if err := xml.Unmarshal(data, entities); err != nil {
+		return nil, fmt.Errorf("failed to parse the IdP metadata: %w", err)
+	}
+	for i := range entities.EntityDescriptors {
+		if len(entities.EntityDescriptors[i].IDPSSODescriptors) > 0 {
+			return &entities.EntityDescriptors[i], nil
+		}
+	}
+	return nil, errors.New("the IdP metadata has no IDPSSODescriptor")
+}
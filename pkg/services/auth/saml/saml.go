@@ -0,0 +1,238 @@
+// Package saml implements a SAML 2.0 service provider, letting users sign in with a SAML identity
+// provider.
+package saml
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	crewjamsaml "github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const ServiceName = "SAMLService"
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         ServiceName,
+		Instance:     &Service{},
+		InitPriority: registry.Medium,
+	})
+}
+
+// ErrInvalidResponse occurs when the IdP response cannot be validated. The details are only logged.
+var ErrInvalidResponse = errors.New("invalid SAML response")
+
+var signatureMethods = map[string]struct {
+	method string
+	hash   crypto.Hash
+}{
+	"rsa-sha1":   {dsig.RSASHA1SignatureMethod, crypto.SHA1},
+	"rsa-sha256": {dsig.RSASHA256SignatureMethod, crypto.SHA256},
+	"rsa-sha512": {dsig.RSASHA512SignatureMethod, crypto.SHA512},
+}
+
+type Service struct {
+	Cfg *setting.Cfg `inject:""`
+
+	log        log.Logger
+	sp         *crewjamsaml.ServiceProvider
+	orgMapping map[string][]int64
+}
+
+func (s *Service) Init() error {
+	s.log = log.New("saml.auth")
+
+	if !s.Cfg.SAMLEnabled {
+		return nil
+	}
+
+	if err := s.initServiceProvider(); err != nil {
+		return fmt.Errorf("failed to set up SAML: %w", err)
+	}
+	if err := s.initOrgMapping(); err != nil {
+		return fmt.Errorf("failed to set up SAML: %w", err)
+	}
+
+	return nil
+}
+
+// IsEnabled returns true if users can sign in with SAML.
+func (s *Service) IsEnabled() bool {
+	return s != nil && s.sp != nil
+}
+
+func (s *Service) initServiceProvider() error {
+	cert, err := loadCertificate(s.Cfg.SAMLCertificate, s.Cfg.SAMLCertificatePath)
+	if err != nil {
+		return err
+	}
+	key, err := loadPrivateKey(s.Cfg.SAMLPrivateKey, s.Cfg.SAMLPrivateKeyPath)
+	if err != nil {
+		return err
+	}
+	idpMetadata, err := loadIDPMetadata(s.Cfg.SAMLIDPMetadata, s.Cfg.SAMLIDPMetadataPath, s.Cfg.SAMLIDPMetadataURL)
+	if err != nil {
+		return err
+	}
+
+	rootURL, err := url.Parse(s.Cfg.AppURL)
+	if err != nil {
+		return fmt.Errorf("invalid root_url %q: %w", s.Cfg.AppURL, err)
+	}
+
+	sp := &crewjamsaml.ServiceProvider{
+		Key:                   key,
+		Certificate:           cert,
+		MetadataURL:           *rootURL.ResolveReference(&url.URL{Path: "saml/metadata"}),
+		AcsURL:                *rootURL.ResolveReference(&url.URL{Path: "saml/acs"}),
+		IDPMetadata:           idpMetadata,
+		MetadataValidDuration: s.Cfg.SAMLMetadataValidDuration,
+	}
+	if s.Cfg.SAMLSignatureAlgorithm != "" {
+		signature, ok := signatureMethods[s.Cfg.SAMLSignatureAlgorithm]
+		if !ok {
+			return fmt.Errorf("unsupported signature_algorithm %q", s.Cfg.SAMLSignatureAlgorithm)
+		}
+		sp.SignatureMethod = signature.method
+	}
+
+	// the response must be processed shortly after the IdP issued it
+	crewjamsaml.MaxIssueDelay = s.Cfg.SAMLMaxIssueDelay
+
+	s.sp = sp
+	return nil
+}
+
+// Metadata returns the metadata XML describing Grafana as a service provider.
+func (s *Service) Metadata() ([]byte, error) {
+	metadata := s.sp.Metadata()
+	// single logout is not supported
+	for i := range metadata.SPSSODescriptors {
+		metadata.SPSSODescriptors[i].SingleLogoutServices = nil
+	}
+
+	return xml.MarshalIndent(metadata, "", "  ")
+}
+
+// AuthnRequest is an authentication request to send to the IdP.
+type AuthnRequest struct {
+	// ID must be passed to ParseResponse to validate the response to this request.
+	ID string
+	// RedirectURL is set when the IdP supports the HTTP-Redirect binding.
+	RedirectURL string
+	// PostForm is an HTML page submitting the request with the HTTP-POST binding otherwise.
+	PostForm []byte
+}
+
+// NewAuthnRequest creates an authentication request, signed when a signature algorithm is set.
+func (s *Service) NewAuthnRequest() (*AuthnRequest, error) {
+	if location := s.sp.GetSSOBindingLocation(crewjamsaml.HTTPRedirectBinding); location != "" {
+		req, err := s.sp.MakeAuthenticationRequest(location)
+		if err != nil {
+			return nil, err
+		}
+		// with the redirect binding the signature is passed in the query instead of the XML
+		req.Signature = nil
+		redirectURL := req.Redirect("")
+		if err := s.signRedirectURL(redirectURL); err != nil {
+			return nil, err
+		}
+
+		return &AuthnRequest{ID: req.ID, RedirectURL: redirectURL.String()}, nil
+	}
+
+	location := s.sp.GetSSOBindingLocation(crewjamsaml.HTTPPostBinding)
+	if location == "" {
+		return nil, errors.New("the IdP supports neither the HTTP-Redirect nor the HTTP-POST binding")
+	}
+	req, err := s.sp.MakeAuthenticationRequest(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthnRequest{ID: req.ID, PostForm: req.Post("")}, nil
+}
+
+// signRedirectURL adds the SigAlg and Signature parameters of the HTTP-Redirect binding.
+func (s *Service) signRedirectURL(u *url.URL) error {
+	if s.sp.SignatureMethod == "" {
+		return nil
+	}
+	signature := signatureMethods[s.Cfg.SAMLSignatureAlgorithm]
+
+	// the signed string keeps the order of the parameters given by the binding
+	query := u.Query()
+	signed := "SAMLRequest=" + url.QueryEscape(query.Get("SAMLRequest"))
+	if relayState := query.Get("RelayState"); relayState != "" {
+		signed += "&RelayState=" + url.QueryEscape(relayState)
+	}
+	signed += "&SigAlg=" + url.QueryEscape(signature.method)
+
+	hash := signature.hash.New()
+	if _, err := hash.Write([]byte(signed)); err != nil {
+		return err
+	}
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.sp.Key, signature.hash, hash.Sum(nil))
+	if err != nil {
+		return err
+	}
+
+	u.RawQuery = signed + "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(sig))
+	return nil
+}
+
+// ParseResponse validates the response posted by the IdP to the ACS endpoint, which must answer
+// one of the given requests, and returns the user described by the assertion.
+func (s *Service) ParseResponse(req *http.Request, requestIDs []string) (*models.ExternalUserInfo, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	assertion, err := s.sp.ParseResponse(req, requestIDs)
+	if err != nil {
+		var invalidErr *crewjamsaml.InvalidResponseError
+		if errors.As(err, &invalidErr) {
+			s.log.Warn("Invalid SAML response", "error", invalidErr.PrivateErr)
+			s.log.Debug("Invalid SAML response", "response", invalidErr.Response)
+		}
+		return nil, ErrInvalidResponse
+	}
+
+	return s.ExternalUserInfo(assertion)
+}
+
+// attributeValues returns the values of the assertion attribute with the given name or friendly name.
+func attributeValues(assertion *crewjamsaml.Assertion, name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	var values []string
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			if attr.Name != name && !strings.EqualFold(attr.FriendlyName, name) {
+				continue
+			}
+			for _, value := range attr.Values {
+				if value.Value != "" {
+					values = append(values, value.Value)
+				}
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,374 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"html"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	crewjamsaml "github.com/crewjam/saml"
+	"github.com/crewjam/saml/logger"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// testKeyPair is an RSA key with a self-signed certificate.
+type testKeyPair struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestKeyPair(t *testing.T, commonName string) testKeyPair {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return testKeyPair{key: key, cert: cert}
+}
+
+func (k testKeyPair) certificatePEM() string {
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: k.cert.Raw}))
+}
+
+func (k testKeyPair) privateKeyPEM() string {
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k.key)}))
+}
+
+// idpStub is a local identity provider signing the responses with test keys.
+type idpStub struct {
+	server  *httptest.Server
+	idp     *crewjamsaml.IdentityProvider
+	session *crewjamsaml.Session
+	spMeta  *crewjamsaml.EntityDescriptor
+}
+
+func (stub *idpStub) GetSession(w http.ResponseWriter, r *http.Request, req *crewjamsaml.IdpAuthnRequest) *crewjamsaml.Session {
+	return stub.session
+}
+
+func (stub *idpStub) GetServiceProvider(r *http.Request, serviceProviderID string) (*crewjamsaml.EntityDescriptor, error) {
+	return stub.spMeta, nil
+}
+
+func newIDPStub(t *testing.T) *idpStub {
+	t.Helper()
+
+	stub := &idpStub{
+		session: &crewjamsaml.Session{
+			ID:         "session-id",
+			CreateTime: time.Now(),
+			ExpireTime: time.Now().Add(time.Hour),
+			NameID:     "alice-id",
+			Groups:     []string{"developers", "operators"},
+			CustomAttributes: []crewjamsaml.Attribute{
+				{Name: "login", Values: []crewjamsaml.AttributeValue{{Type: "xs:string", Value: "alice"}}},
+				{Name: "mail", Values: []crewjamsaml.AttributeValue{{Type: "xs:string", Value: "alice@example.org"}}},
+				{FriendlyName: "displayName", Name: "urn:oid:2.16.840.1.113730.3.1.241",
+					Values: []crewjamsaml.AttributeValue{{Type: "xs:string", Value: "Alice"}}},
+				{Name: "role", Values: []crewjamsaml.AttributeValue{{Type: "xs:string", Value: "developer"}}},
+				{Name: "org", Values: []crewjamsaml.AttributeValue{
+					{Type: "xs:string", Value: "Engineering"}, {Type: "xs:string", Value: "Support"}}},
+			},
+		},
+	}
+
+	keys := newTestKeyPair(t, "idp.example.org")
+	mux := http.NewServeMux()
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+
+	serverURL, err := url.Parse(stub.server.URL)
+	require.NoError(t, err)
+	stub.idp = &crewjamsaml.IdentityProvider{
+		Key:                     keys.key,
+		Certificate:             keys.cert,
+		Logger:                  logger.DefaultLogger,
+		MetadataURL:             *serverURL.ResolveReference(&url.URL{Path: "/metadata"}),
+		SSOURL:                  *serverURL.ResolveReference(&url.URL{Path: "/sso"}),
+		SessionProvider:         stub,
+		ServiceProviderProvider: stub,
+	}
+	mux.HandleFunc("/metadata", stub.idp.ServeMetadata)
+	mux.HandleFunc("/sso", stub.idp.ServeSSO)
+
+	return stub
+}
+
+// login sends the authentication request to the IdP and returns the request posted back to the ACS
+// endpoint.
+func (stub *idpStub) login(t *testing.T, req *AuthnRequest) *http.Request {
+	t.Helper()
+
+	resp, err := http.Get(req.RedirectURL)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	matches := regexp.MustCompile(`action="([^"]*)".*name="SAMLResponse" value="([^"]*)"`).FindStringSubmatch(string(body))
+	require.Len(t, matches, 3, string(body))
+
+	form := url.Values{"SAMLResponse": {html.UnescapeString(matches[2])}}
+	acsReq := httptest.NewRequest(http.MethodPost, html.UnescapeString(matches[1]), strings.NewReader(form.Encode()))
+	acsReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return acsReq
+}
+
+func setupService(t *testing.T, stub *idpStub, configure func(cfg *setting.Cfg)) *Service {
+	t.Helper()
+
+	keys := newTestKeyPair(t, "grafana.example.org")
+	cfg := setting.NewCfg()
+	cfg.AppURL = "http://localhost:3000/"
+	cfg.SAMLEnabled = true
+	cfg.SAMLCertificate = keys.certificatePEM()
+	cfg.SAMLPrivateKey = keys.privateKeyPEM()
+	cfg.SAMLIDPMetadataURL = stub.server.URL + "/metadata"
+	cfg.SAMLMaxIssueDelay = 90 * time.Second
+	cfg.SAMLAssertionAttributeName = "displayName"
+	cfg.SAMLAssertionAttributeLogin = "login"
+	cfg.SAMLAssertionAttributeEmail = "mail"
+	if configure != nil {
+		configure(cfg)
+	}
+
+	s := &Service{Cfg: cfg}
+	require.NoError(t, s.Init())
+	require.True(t, s.IsEnabled())
+
+	metadata, err := s.Metadata()
+	require.NoError(t, err)
+	stub.spMeta = &crewjamsaml.EntityDescriptor{}
+	require.NoError(t, xml.Unmarshal(metadata, stub.spMeta))
+
+	return s
+}
+
+func TestSAMLLogin(t *testing.T) {
+	t.Run("Signs in with the response of the IdP", func(t *testing.T) {
+		stub := newIDPStub(t)
+		s := setupService(t, stub, func(cfg *setting.Cfg) {
+			cfg.SAMLAssertionAttributeGroups = "eduPersonAffiliation"
+		})
+
+		req, err := s.NewAuthnRequest()
+		require.NoError(t, err)
+		require.NotEmpty(t, req.ID)
+		assert.True(t, strings.HasPrefix(req.RedirectURL, stub.server.URL+"/sso?SAMLRequest="))
+
+		extUser, err := s.ParseResponse(stub.login(t, req), []string{req.ID})
+		require.NoError(t, err)
+		assert.Equal(t, models.AuthModuleSAML, extUser.AuthModule)
+		assert.Equal(t, "alice-id", extUser.AuthId)
+		assert.Equal(t, "alice", extUser.Login)
+		assert.Equal(t, "alice@example.org", extUser.Email)
+		assert.Equal(t, "Alice", extUser.Name)
+		assert.Equal(t, []string{"developers", "operators"}, extUser.Groups)
+		assert.Empty(t, extUser.OrgRoles)
+	})
+
+	t.Run("Rejects a response to another request", func(t *testing.T) {
+		stub := newIDPStub(t)
+		s := setupService(t, stub, nil)
+
+		req, err := s.NewAuthnRequest()
+		require.NoError(t, err)
+
+		_, err = s.ParseResponse(stub.login(t, req), []string{"id-other"})
+		require.ErrorIs(t, err, ErrInvalidResponse)
+	})
+
+	t.Run("Rejects a response signed by another IdP", func(t *testing.T) {
+		stub := newIDPStub(t)
+		s := setupService(t, stub, nil)
+
+		req, err := s.NewAuthnRequest()
+		require.NoError(t, err)
+
+		other := newTestKeyPair(t, "evil.example.org")
+		stub.idp.Key, stub.idp.Certificate = other.key, other.cert
+
+		_, err = s.ParseResponse(stub.login(t, req), []string{req.ID})
+		require.ErrorIs(t, err, ErrInvalidResponse)
+	})
+
+	t.Run("Rejects a response issued too long ago", func(t *testing.T) {
+		stub := newIDPStub(t)
+		s := setupService(t, stub, nil)
+
+		req, err := s.NewAuthnRequest()
+		require.NoError(t, err)
+		acsReq := stub.login(t, req)
+
+		origTimeNow := crewjamsaml.TimeNow
+		t.Cleanup(func() { crewjamsaml.TimeNow = origTimeNow })
+		crewjamsaml.TimeNow = func() time.Time { return time.Now().Add(10 * time.Minute) }
+
+		_, err = s.ParseResponse(acsReq, []string{req.ID})
+		require.ErrorIs(t, err, ErrInvalidResponse)
+	})
+
+	t.Run("Signs the authentication request", func(t *testing.T) {
+		stub := newIDPStub(t)
+		s := setupService(t, stub, func(cfg *setting.Cfg) {
+			cfg.SAMLSignatureAlgorithm = "rsa-sha256"
+		})
+
+		req, err := s.NewAuthnRequest()
+		require.NoError(t, err)
+
+		redirectURL, err := url.Parse(req.RedirectURL)
+		require.NoError(t, err)
+		query := redirectURL.Query()
+		assert.Equal(t, dsig.RSASHA256SignatureMethod, query.Get("SigAlg"))
+
+		signed := redirectURL.RawQuery[:strings.Index(redirectURL.RawQuery, "&Signature=")]
+		signature, err := base64.StdEncoding.DecodeString(query.Get("Signature"))
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte(signed))
+		require.NoError(t, rsa.VerifyPKCS1v15(&s.sp.Key.PublicKey, crypto.SHA256, digest[:], signature))
+
+		// the IdP accepts the signed request
+		_, err = s.ParseResponse(stub.login(t, req), []string{req.ID})
+		require.NoError(t, err)
+	})
+
+	t.Run("Uses the HTTP-POST binding when the IdP does not support HTTP-Redirect", func(t *testing.T) {
+		stub := newIDPStub(t)
+		s := setupService(t, stub, func(cfg *setting.Cfg) {
+			cfg.SAMLSignatureAlgorithm = "rsa-sha256"
+		})
+		descriptor := &s.sp.IDPMetadata.IDPSSODescriptors[0]
+		var services []crewjamsaml.Endpoint
+		for _, service := range descriptor.SingleSignOnServices {
+			if service.Binding == crewjamsaml.HTTPPostBinding {
+				services = append(services, service)
+			}
+		}
+		descriptor.SingleSignOnServices = services
+
+		req, err := s.NewAuthnRequest()
+		require.NoError(t, err)
+		assert.Empty(t, req.RedirectURL)
+		assert.Contains(t, string(req.PostForm), `name="SAMLRequest"`)
+	})
+}
+
+func TestSAMLMetadata(t *testing.T) {
+	stub := newIDPStub(t)
+	s := setupService(t, stub, func(cfg *setting.Cfg) {
+		cfg.SAMLSignatureAlgorithm = "rsa-sha256"
+	})
+
+	assert.Equal(t, "http://localhost:3000/saml/metadata", stub.spMeta.EntityID)
+	require.Len(t, stub.spMeta.SPSSODescriptors, 1)
+	descriptor := stub.spMeta.SPSSODescriptors[0]
+	assert.Equal(t, "http://localhost:3000/saml/acs", descriptor.AssertionConsumerServices[0].Location)
+	assert.True(t, *descriptor.AuthnRequestsSigned)
+	assert.Empty(t, descriptor.SingleLogoutServices)
+	assert.Len(t, descriptor.KeyDescriptors, 2)
+	assert.NotNil(t, s.sp.IDPMetadata)
+}
+
+func TestSAMLInit(t *testing.T) {
+	keys := newTestKeyPair(t, "grafana.example.org")
+
+	testCases := []struct {
+		desc      string
+		configure func(cfg *setting.Cfg)
+		err       string
+	}{
+		{
+			desc:      "missing certificate",
+			configure: func(cfg *setting.Cfg) { cfg.SAMLCertificate = "" },
+			err:       "certificate or certificate_path must be set",
+		},
+		{
+			desc:      "certificate set twice",
+			configure: func(cfg *setting.Cfg) { cfg.SAMLCertificatePath = "/tmp/cert.pem" },
+			err:       "only one of certificate and certificate_path can be set",
+		},
+		{
+			desc:      "invalid signature algorithm",
+			configure: func(cfg *setting.Cfg) { cfg.SAMLSignatureAlgorithm = "dsa-sha1" },
+			err:       `unsupported signature_algorithm "dsa-sha1"`,
+		},
+		{
+			desc:      "invalid org mapping",
+			configure: func(cfg *setting.Cfg) { cfg.SAMLOrgMapping = "Engineering" },
+			err:       `invalid org mapping "Engineering"`,
+		},
+		{
+			desc:      "invalid IdP metadata",
+			configure: func(cfg *setting.Cfg) { cfg.SAMLIDPMetadata = base64.StdEncoding.EncodeToString([]byte("<xml/>")) },
+			err:       "failed to parse the IdP metadata",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.AppURL = "http://localhost:3000/"
+			cfg.SAMLEnabled = true
+			cfg.SAMLCertificate = keys.certificatePEM()
+			cfg.SAMLPrivateKey = keys.privateKeyPEM()
+			cfg.SAMLIDPMetadata = base64.StdEncoding.EncodeToString([]byte(idpMetadata))
+			tc.configure(cfg)
+
+			err := (&Service{Cfg: cfg}).Init()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		s := &Service{Cfg: setting.NewCfg()}
+		require.NoError(t, s.Init())
+		assert.False(t, s.IsEnabled())
+	})
+
+	t.Run("IdP metadata wrapped in EntitiesDescriptor", func(t *testing.T) {
+		entities := `<EntitiesDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata">` + idpMetadata + `</EntitiesDescriptor>`
+		metadata, err := parseIDPMetadata([]byte(entities))
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp.example.org/metadata", metadata.EntityID)
+	})
+}
+
+const idpMetadata = `<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.org/metadata">
+  <IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.org/sso"/>
+  </IDPSSODescriptor>
+</EntityDescriptor>`
@@ -0,0 +1,137 @@
+package saml
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	crewjamsaml "github.com/crewjam/saml"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	// ErrMissingLogin occurs when an assertion has neither a login nor an email attribute.
+	ErrMissingLogin = errors.New("failed to get the login of the user from the SAML assertion")
+	// ErrOrganizationNotAllowed occurs when the user is not a member of any of the allowed organizations.
+	ErrOrganizationNotAllowed = errors.New("user is not a member of any of the allowed organizations")
+)
+
+// initOrgMapping parses the org mapping, a list of <organization>:<org id>.
+func (s *Service) initOrgMapping() error {
+	s.orgMapping = map[string][]int64{}
+	for _, item := range util.SplitString(s.Cfg.SAMLOrgMapping) {
+		sep := strings.LastIndex(item, ":")
+		if sep < 1 {
+			return fmt.Errorf("invalid org mapping %q, <organization>:<org id> expected", item)
+		}
+		orgID, err := strconv.ParseInt(item[sep+1:], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid org id in org mapping %q", item)
+		}
+
+		org := item[:sep]
+		s.orgMapping[org] = append(s.orgMapping[org], orgID)
+	}
+
+	return nil
+}
+
+// ExternalUserInfo returns the user described by a validated assertion, used to sign up and sync
+// the user.
+func (s *Service) ExternalUserInfo(assertion *crewjamsaml.Assertion) (*models.ExternalUserInfo, error) {
+	extUser := &models.ExternalUserInfo{
+		AuthModule: models.AuthModuleSAML,
+		Login:      firstValue(attributeValues(assertion, s.Cfg.SAMLAssertionAttributeLogin)),
+		Email:      firstValue(attributeValues(assertion, s.Cfg.SAMLAssertionAttributeEmail)),
+		Name:       firstValue(attributeValues(assertion, s.Cfg.SAMLAssertionAttributeName)),
+		Groups:     attributeValues(assertion, s.Cfg.SAMLAssertionAttributeGroups),
+		OrgRoles:   map[int64]models.RoleType{},
+	}
+	if extUser.Login == "" && extUser.Email == "" {
+		return nil, ErrMissingLogin
+	}
+	if extUser.Login == "" {
+		extUser.Login = extUser.Email
+	}
+
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		extUser.AuthId = assertion.Subject.NameID.Value
+	}
+	if extUser.AuthId == "" {
+		extUser.AuthId = extUser.Login
+	}
+
+	orgs := attributeValues(assertion, s.Cfg.SAMLAssertionAttributeOrg)
+	if len(s.Cfg.SAMLAllowedOrganizations) > 0 && !containsAny(s.Cfg.SAMLAllowedOrganizations, orgs) {
+		s.log.Warn("User is not a member of any of the allowed organizations", "user", extUser.Login, "organizations", orgs)
+		return nil, ErrOrganizationNotAllowed
+	}
+
+	role := s.mapRole(assertion, extUser)
+
+	if len(s.orgMapping) > 0 {
+		if role == "" {
+			role = models.RoleType(s.Cfg.AutoAssignOrgRole)
+		}
+		for _, org := range orgs {
+			for _, orgID := range s.orgMapping[org] {
+				extUser.OrgRoles[orgID] = role
+			}
+		}
+	} else if role != "" {
+		orgID := int64(1)
+		if s.Cfg.AutoAssignOrg && s.Cfg.AutoAssignOrgId > 0 {
+			orgID = int64(s.Cfg.AutoAssignOrgId)
+		}
+		extUser.OrgRoles[orgID] = role
+	}
+
+	return extUser, nil
+}
+
+// mapRole returns the role given by the role attribute, or an empty role when role sync is not
+// configured. It also sets whether the user is a Grafana admin.
+func (s *Service) mapRole(assertion *crewjamsaml.Assertion, extUser *models.ExternalUserInfo) models.RoleType {
+	if s.Cfg.SAMLAssertionAttributeRole == "" {
+		return ""
+	}
+
+	values := attributeValues(assertion, s.Cfg.SAMLAssertionAttributeRole)
+	if len(s.Cfg.SAMLRoleValuesGrafanaAdmin) > 0 {
+		isGrafanaAdmin := containsAny(s.Cfg.SAMLRoleValuesGrafanaAdmin, values)
+		extUser.IsGrafanaAdmin = &isGrafanaAdmin
+		if isGrafanaAdmin {
+			return models.ROLE_ADMIN
+		}
+	}
+
+	switch {
+	case containsAny(s.Cfg.SAMLRoleValuesAdmin, values):
+		return models.ROLE_ADMIN
+	case containsAny(s.Cfg.SAMLRoleValuesEditor, values):
+		return models.ROLE_EDITOR
+	default:
+		return models.ROLE_VIEWER
+	}
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func containsAny(list []string, values []string) bool {
+	for _, value := range values {
+		for _, item := range list {
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package saml
+
+import (
+	"testing"
+
+	crewjamsaml "github.com/crewjam/saml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func newAssertion(nameID string, attributes map[string][]string) *crewjamsaml.Assertion {
+	statement := crewjamsaml.AttributeStatement{}
+	for name, values := range attributes {
+		attr := crewjamsaml.Attribute{Name: name}
+		for _, value := range values {
+			attr.Values = append(attr.Values, crewjamsaml.AttributeValue{Type: "xs:string", Value: value})
+		}
+		statement.Attributes = append(statement.Attributes, attr)
+	}
+
+	assertion := &crewjamsaml.Assertion{AttributeStatements: []crewjamsaml.AttributeStatement{statement}}
+	if nameID != "" {
+		assertion.Subject = &crewjamsaml.Subject{NameID: &crewjamsaml.NameID{Value: nameID}}
+	}
+	return assertion
+}
+
+func newUserInfoService(t *testing.T, configure func(cfg *setting.Cfg)) *Service {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.SAMLAssertionAttributeName = "displayName"
+	cfg.SAMLAssertionAttributeLogin = "login"
+	cfg.SAMLAssertionAttributeEmail = "mail"
+	cfg.AutoAssignOrgRole = "Viewer"
+	if configure != nil {
+		configure(cfg)
+	}
+
+	s := &Service{Cfg: cfg, log: log.New("saml.auth.test")}
+	require.NoError(t, s.initOrgMapping())
+	return s
+}
+
+func TestExternalUserInfo(t *testing.T) {
+	t.Run("Uses the email as login", func(t *testing.T) {
+		s := newUserInfoService(t, nil)
+
+		extUser, err := s.ExternalUserInfo(newAssertion("", map[string][]string{"mail": {"alice@example.org"}}))
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.org", extUser.Login)
+		assert.Equal(t, "alice@example.org", extUser.AuthId)
+	})
+
+	t.Run("Fails without login and email", func(t *testing.T) {
+		s := newUserInfoService(t, nil)
+
+		_, err := s.ExternalUserInfo(newAssertion("alice-id", map[string][]string{"displayName": {"Alice"}}))
+		require.ErrorIs(t, err, ErrMissingLogin)
+	})
+
+	t.Run("Maps roles", func(t *testing.T) {
+		s := newUserInfoService(t, func(cfg *setting.Cfg) {
+			cfg.SAMLAssertionAttributeRole = "role"
+			cfg.SAMLRoleValuesEditor = []string{"editor", "developer"}
+			cfg.SAMLRoleValuesAdmin = []string{"admin"}
+			cfg.SAMLRoleValuesGrafanaAdmin = []string{"superadmin"}
+		})
+
+		testCases := []struct {
+			roles          []string
+			role           models.RoleType
+			isGrafanaAdmin bool
+		}{
+			{roles: nil, role: models.ROLE_VIEWER},
+			{roles: []string{"developer"}, role: models.ROLE_EDITOR},
+			{roles: []string{"developer", "admin"}, role: models.ROLE_ADMIN},
+			{roles: []string{"superadmin"}, role: models.ROLE_ADMIN, isGrafanaAdmin: true},
+		}
+		for _, tc := range testCases {
+			extUser, err := s.ExternalUserInfo(newAssertion("alice-id", map[string][]string{
+				"login": {"alice"},
+				"role":  tc.roles,
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, map[int64]models.RoleType{1: tc.role}, extUser.OrgRoles, "roles %v", tc.roles)
+			require.NotNil(t, extUser.IsGrafanaAdmin)
+			assert.Equal(t, tc.isGrafanaAdmin, *extUser.IsGrafanaAdmin, "roles %v", tc.roles)
+		}
+	})
+
+	t.Run("Maps organizations", func(t *testing.T) {
+		s := newUserInfoService(t, func(cfg *setting.Cfg) {
+			cfg.SAMLAssertionAttributeRole = "role"
+			cfg.SAMLRoleValuesEditor = []string{"developer"}
+			cfg.SAMLAssertionAttributeOrg = "org"
+			cfg.SAMLOrgMapping = "Engineering:2, Engineering:3, Sales:4"
+		})
+
+		extUser, err := s.ExternalUserInfo(newAssertion("alice-id", map[string][]string{
+			"login": {"alice"},
+			"role":  {"developer"},
+			"org":   {"Engineering", "Support"},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]models.RoleType{2: models.ROLE_EDITOR, 3: models.ROLE_EDITOR}, extUser.OrgRoles)
+		assert.Nil(t, extUser.IsGrafanaAdmin)
+	})
+
+	t.Run("Restricts to allowed organizations", func(t *testing.T) {
+		s := newUserInfoService(t, func(cfg *setting.Cfg) {
+			cfg.SAMLAssertionAttributeOrg = "org"
+			cfg.SAMLAllowedOrganizations = []string{"Engineering", "Sales"}
+		})
+
+		_, err := s.ExternalUserInfo(newAssertion("alice-id", map[string][]string{
+			"login": {"alice"},
+			"org":   {"Support"},
+		}))
+		require.ErrorIs(t, err, ErrOrganizationNotAllowed)
+
+		extUser, err := s.ExternalUserInfo(newAssertion("alice-id", map[string][]string{
+			"login": {"alice"},
+			"org":   {"Support", "Sales"},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", extUser.Login)
+	})
+}
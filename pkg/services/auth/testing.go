@@ -8,16 +8,17 @@ import (
 )
 
 type FakeUserAuthTokenService struct {
-	CreateTokenProvider          func(ctx context.Context, user *models.User, clientIP net.IP, userAgent string) (*models.UserToken, error)
-	TryRotateTokenProvider       func(ctx context.Context, token *models.UserToken, clientIP net.IP, userAgent string) (bool, error)
-	LookupTokenProvider          func(ctx context.Context, unhashedToken string) (*models.UserToken, error)
-	RevokeTokenProvider          func(ctx context.Context, token *models.UserToken, soft bool) error
-	RevokeAllUserTokensProvider  func(ctx context.Context, userId int64) error
-	ActiveAuthTokenCount         func(ctx context.Context) (int64, error)
-	GetUserTokenProvider         func(ctx context.Context, userId, userTokenId int64) (*models.UserToken, error)
-	GetUserTokensProvider        func(ctx context.Context, userId int64) ([]*models.UserToken, error)
-	GetUserRevokedTokensProvider func(ctx context.Context, userId int64) ([]*models.UserToken, error)
-	BatchRevokedTokenProvider    func(ctx context.Context, userIds []int64) error
+	CreateTokenProvider           func(ctx context.Context, user *models.User, clientIP net.IP, userAgent string) (*models.UserToken, error)
+	TryRotateTokenProvider        func(ctx context.Context, token *models.UserToken, clientIP net.IP, userAgent string) (bool, error)
+	LookupTokenProvider           func(ctx context.Context, unhashedToken string) (*models.UserToken, error)
+	RevokeTokenProvider           func(ctx context.Context, token *models.UserToken, soft bool) error
+	RevokeAllUserTokensProvider   func(ctx context.Context, userId int64) error
+	RevokeOtherUserTokensProvider func(ctx context.Context, userId, keepTokenId int64) error
+	ActiveAuthTokenCount          func(ctx context.Context) (int64, error)
+	GetUserTokenProvider          func(ctx context.Context, userId, userTokenId int64) (*models.UserToken, error)
+	GetUserTokensProvider         func(ctx context.Context, userId int64) ([]*models.UserToken, error)
+	GetUserRevokedTokensProvider  func(ctx context.Context, userId int64) ([]*models.UserToken, error)
+	BatchRevokedTokenProvider     func(ctx context.Context, userIds []int64) error
 }
 
 func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
@@ -43,6 +44,9 @@ func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
 		RevokeAllUserTokensProvider: func(ctx context.Context, userId int64) error {
 			return nil
 		},
+		RevokeOtherUserTokensProvider: func(ctx context.Context, userId, keepTokenId int64) error {
+			return nil
+		},
 		BatchRevokedTokenProvider: func(ctx context.Context, userIds []int64) error {
 			return nil
 		},
@@ -85,6 +89,10 @@ func (s *FakeUserAuthTokenService) RevokeAllUserTokens(ctx context.Context, user
 	return s.RevokeAllUserTokensProvider(context.Background(), userId)
 }
 
+func (s *FakeUserAuthTokenService) RevokeOtherUserTokens(ctx context.Context, userId, keepTokenId int64) error {
+	return s.RevokeOtherUserTokensProvider(context.Background(), userId, keepTokenId)
+}
+
 func (s *FakeUserAuthTokenService) ActiveTokenCount(ctx context.Context) (int64, error) {
 	return s.ActiveAuthTokenCount(context.Background())
 }
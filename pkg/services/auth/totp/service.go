@@ -0,0 +1,272 @@
+// Package totp implements time-based one-time password (TOTP) two-factor authentication for users
+// signing in with a Grafana username and password.
+package totp
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const ServiceName = "TOTPService"
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         ServiceName,
+		Instance:     &Service{},
+		InitPriority: registry.Medium,
+	})
+}
+
+var (
+	// ErrAlreadyEnabled occurs when setting up two-factor authentication that is already enabled.
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrSetupNotStarted occurs when enabling two-factor authentication before setting it up.
+	ErrSetupNotStarted = errors.New("two-factor authentication setup not started")
+)
+
+// timeNow is overridden in tests.
+var timeNow = time.Now
+
+type Service struct {
+	Cfg *setting.Cfg `inject:""`
+	Bus bus.Bus      `inject:""`
+
+	log log.Logger
+}
+
+func (s *Service) Init() error {
+	s.log = log.New("auth.totp")
+	s.Bus.AddHandler(s.verifyUserTOTP)
+	return nil
+}
+
+// IsEnabled returns true if users can set up two-factor authentication.
+func (s *Service) IsEnabled() bool {
+	return s != nil && s.Cfg.TOTPEnabled
+}
+
+// Status describes the two-factor authentication of a user.
+type Status struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+// GetStatus returns whether a user has enabled two-factor authentication, and whether the user
+// must enable it.
+func (s *Service) GetStatus(userID int64) (*Status, error) {
+	required, err := s.isRequired(userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{Required: required}
+
+	userTOTP, err := s.getUserTOTP(userID)
+	if errors.Is(err, models.ErrUserTOTPNotFound) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status.Enabled = userTOTP.Enabled
+	if userTOTP.Enabled {
+		status.RecoveryCodesLeft = len(splitRecoveryCodes(userTOTP.RecoveryCodes))
+	}
+	return status, nil
+}
+
+// isRequired returns true if two-factor authentication is enforced for all users, or for an
+// organization the user is a member of.
+func (s *Service) isRequired(userID int64) (bool, error) {
+	if s.Cfg.TOTPEnforced {
+		return true, nil
+	}
+	if len(s.Cfg.TOTPEnforcedOrgIds) == 0 {
+		return false, nil
+	}
+
+	query := &models.GetUserOrgListQuery{UserId: userID}
+	if err := s.Bus.Dispatch(query); err != nil {
+		return false, err
+	}
+	for _, org := range query.Result {
+		for _, orgID := range s.Cfg.TOTPEnforcedOrgIds {
+			if org.OrgId == orgID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Setup is the secret to add to an authenticator app.
+type Setup struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+}
+
+// StartSetup creates a new secret for the user. Two-factor authentication is enabled once the user
+// confirms the setup with a code of the authenticator app.
+func (s *Service) StartSetup(user *models.User) (*Setup, error) {
+	userTOTP, err := s.getUserTOTP(user.Id)
+	if err != nil && !errors.Is(err, models.ErrUserTOTPNotFound) {
+		return nil, err
+	}
+	if userTOTP != nil && userTOTP.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := util.Encrypt([]byte(secret), setting.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &models.SaveUserTOTPCommand{
+		UserId: user.Id,
+		Secret: base64.StdEncoding.EncodeToString(encrypted),
+	}
+	if err := s.Bus.Dispatch(cmd); err != nil {
+		return nil, err
+	}
+
+	return &Setup{Secret: secret, URL: keyURI(s.Cfg.TOTPIssuer, user.Login, secret)}, nil
+}
+
+// Enable confirms the setup with a code of the authenticator app, and returns the recovery codes
+// of the user.
+func (s *Service) Enable(userID int64, code string) ([]string, error) {
+	userTOTP, err := s.getUserTOTP(userID)
+	if errors.Is(err, models.ErrUserTOTPNotFound) {
+		return nil, ErrSetupNotStarted
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userTOTP.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := decryptSecret(userTOTP.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := validateCode(secret, code, timeNow())
+	if !ok {
+		return nil, models.ErrInvalidTOTPCode
+	}
+	if err := s.Bus.Dispatch(&models.UseUserTOTPStepCommand{UserId: userID, Step: step}); err != nil {
+		return nil, err
+	}
+
+	return s.saveRecoveryCodes(userTOTP)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user.
+func (s *Service) RegenerateRecoveryCodes(userID int64) ([]string, error) {
+	userTOTP, err := s.getUserTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !userTOTP.Enabled {
+		return nil, models.ErrUserTOTPNotFound
+	}
+
+	return s.saveRecoveryCodes(userTOTP)
+}
+
+func (s *Service) saveRecoveryCodes(userTOTP *models.UserTOTP) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	cmd := &models.SaveUserTOTPCommand{
+		UserId:        userTOTP.UserId,
+		Secret:        userTOTP.Secret,
+		RecoveryCodes: strings.Join(hashes, ","),
+		Enabled:       true,
+	}
+	if err := s.Bus.Dispatch(cmd); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable removes the second factor of the user.
+func (s *Service) Disable(userID int64) error {
+	return s.Bus.Dispatch(&models.DeleteUserTOTPCommand{UserId: userID})
+}
+
+// verifyUserTOTP checks a code of the authenticator app, which is rejected if a code of the same or
+// a later time step was already used, or a recovery code, which is removed once used.
+func (s *Service) verifyUserTOTP(cmd *models.VerifyUserTOTPCommand) error {
+	userTOTP, err := s.getUserTOTP(cmd.UserId)
+	if err != nil {
+		return err
+	}
+	if !userTOTP.Enabled {
+		return models.ErrUserTOTPNotFound
+	}
+
+	secret, err := decryptSecret(userTOTP.Secret)
+	if err != nil {
+		return err
+	}
+	if step, ok := validateCode(secret, cmd.Code, timeNow()); ok {
+		return s.Bus.Dispatch(&models.UseUserTOTPStepCommand{UserId: cmd.UserId, Step: step})
+	}
+
+	useRecoveryCode := &models.UseUserTOTPRecoveryCodeCommand{UserId: cmd.UserId, Hash: hashRecoveryCode(cmd.Code)}
+	if err := s.Bus.Dispatch(useRecoveryCode); err != nil {
+		return err
+	}
+
+	s.log.Info("Recovery code used", "userId", cmd.UserId, "recoveryCodesLeft", useRecoveryCode.Result)
+	return nil
+}
+
+func (s *Service) getUserTOTP(userID int64) (*models.UserTOTP, error) {
+	query := &models.GetUserTOTPQuery{UserId: userID}
+	if err := s.Bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
+
+func decryptSecret(encoded string) (string, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	secret, err := util.Decrypt(encrypted, setting.SecretKey)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func splitRecoveryCodes(codes string) []string {
+	if codes == "" {
+		return nil
+	}
+	return strings.Split(codes, ",")
+}
@@ -0,0 +1,188 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore keeps the second factors of users in memory.
+type fakeStore map[int64]models.UserTOTP
+
+func newTestService(t *testing.T, cfg *setting.Cfg, orgs []int64) (*Service, fakeStore) {
+	t.Helper()
+
+	store := fakeStore{}
+	b := bus.New()
+	b.AddHandler(func(query *models.GetUserTOTPQuery) error {
+		userTOTP, ok := store[query.UserId]
+		if !ok {
+			return models.ErrUserTOTPNotFound
+		}
+		query.Result = &userTOTP
+		return nil
+	})
+	b.AddHandler(func(cmd *models.SaveUserTOTPCommand) error {
+		store[cmd.UserId] = models.UserTOTP{
+			UserId: cmd.UserId, Secret: cmd.Secret, RecoveryCodes: cmd.RecoveryCodes, Enabled: cmd.Enabled,
+			LastUsedStep: store[cmd.UserId].LastUsedStep,
+		}
+		return nil
+	})
+	b.AddHandler(func(cmd *models.UseUserTOTPStepCommand) error {
+		userTOTP, ok := store[cmd.UserId]
+		if !ok || userTOTP.LastUsedStep >= cmd.Step {
+			return models.ErrInvalidTOTPCode
+		}
+		userTOTP.LastUsedStep = cmd.Step
+		store[cmd.UserId] = userTOTP
+		return nil
+	})
+	b.AddHandler(func(cmd *models.UseUserTOTPRecoveryCodeCommand) error {
+		userTOTP, ok := store[cmd.UserId]
+		if !ok {
+			return models.ErrInvalidTOTPCode
+		}
+		hashes := splitRecoveryCodes(userTOTP.RecoveryCodes)
+		for i, hash := range hashes {
+			if hash == cmd.Hash {
+				userTOTP.RecoveryCodes = strings.Join(append(hashes[:i:i], hashes[i+1:]...), ",")
+				store[cmd.UserId] = userTOTP
+				cmd.Result = len(hashes) - 1
+				return nil
+			}
+		}
+		return models.ErrInvalidTOTPCode
+	})
+	b.AddHandler(func(cmd *models.DeleteUserTOTPCommand) error {
+		if _, ok := store[cmd.UserId]; !ok {
+			return models.ErrUserTOTPNotFound
+		}
+		delete(store, cmd.UserId)
+		return nil
+	})
+	b.AddHandler(func(query *models.GetUserOrgListQuery) error {
+		for _, orgID := range orgs {
+			query.Result = append(query.Result, &models.UserOrgDTO{OrgId: orgID})
+		}
+		return nil
+	})
+
+	s := &Service{Cfg: cfg, Bus: b, log: log.New("auth.totp.test")}
+	b.AddHandler(s.verifyUserTOTP)
+	return s, store
+}
+
+func TestService(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	user := &models.User{Id: 1, Login: "alice"}
+	cfg := setting.NewCfg()
+	cfg.TOTPIssuer = "Grafana"
+
+	t.Run("Sets up and verifies codes", func(t *testing.T) {
+		s, store := newTestService(t, cfg, nil)
+
+		setup, err := s.StartSetup(user)
+		require.NoError(t, err)
+		assert.Contains(t, setup.URL, "otpauth://totp/Grafana:alice?")
+		assert.NotContains(t, store[user.Id].Secret, setup.Secret, "secret is stored encrypted")
+
+		status, err := s.GetStatus(user.Id)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled, "not enabled before the setup is confirmed")
+		require.Equal(t, models.ErrUserTOTPNotFound, s.Bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: user.Id, Code: "000000"}))
+
+		_, err = s.Enable(user.Id, "abcdef")
+		require.Equal(t, models.ErrInvalidTOTPCode, err)
+
+		code, err := generateCode(setup.Secret, now)
+		require.NoError(t, err)
+		recoveryCodes, err := s.Enable(user.Id, code)
+		require.NoError(t, err)
+		require.Len(t, recoveryCodes, recoveryCodeCount)
+
+		status, err = s.GetStatus(user.Id)
+		require.NoError(t, err)
+		assert.Equal(t, &Status{Enabled: true, RecoveryCodesLeft: recoveryCodeCount}, status)
+
+		_, err = s.StartSetup(user)
+		require.Equal(t, ErrAlreadyEnabled, err)
+
+		require.Equal(t, models.ErrInvalidTOTPCode, s.Bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: user.Id, Code: code}),
+			"the code confirming the setup can't be used again")
+		require.Equal(t, models.ErrInvalidTOTPCode, s.Bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: user.Id, Code: "abcdef"}))
+
+		next, err := generateCode(setup.Secret, now.Add(period*time.Second))
+		require.NoError(t, err)
+		require.NoError(t, s.Bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: user.Id, Code: next}))
+		require.Equal(t, models.ErrInvalidTOTPCode, s.Bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: user.Id, Code: next}),
+			"a code can only be used once")
+		require.Equal(t, models.ErrInvalidTOTPCode, s.Bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: user.Id, Code: code}),
+			"a code of an earlier step is rejected")
+	})
+
+	t.Run("Recovery codes can be used once", func(t *testing.T) {
+		s, _ := newTestService(t, cfg, nil)
+
+		setup, err := s.StartSetup(user)
+		require.NoError(t, err)
+		code, err := generateCode(setup.Secret, now)
+		require.NoError(t, err)
+		recoveryCodes, err := s.Enable(user.Id, code)
+		require.NoError(t, err)
+
+		cmd := &models.VerifyUserTOTPCommand{UserId: user.Id, Code: recoveryCodes[3]}
+		require.NoError(t, s.Bus.Dispatch(cmd))
+		require.Equal(t, models.ErrInvalidTOTPCode, s.Bus.Dispatch(cmd))
+
+		status, err := s.GetStatus(user.Id)
+		require.NoError(t, err)
+		assert.Equal(t, recoveryCodeCount-1, status.RecoveryCodesLeft)
+
+		newCodes, err := s.RegenerateRecoveryCodes(user.Id)
+		require.NoError(t, err)
+		require.Equal(t, models.ErrInvalidTOTPCode, s.Bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: user.Id, Code: recoveryCodes[0]}))
+		require.NoError(t, s.Bus.Dispatch(&models.VerifyUserTOTPCommand{UserId: user.Id, Code: newCodes[0]}))
+
+		require.NoError(t, s.Disable(user.Id))
+		status, err = s.GetStatus(user.Id)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled)
+	})
+
+	t.Run("Enforces for all users or members of orgs", func(t *testing.T) {
+		s, _ := newTestService(t, cfg, []int64{1, 3})
+		status, err := s.GetStatus(user.Id)
+		require.NoError(t, err)
+		assert.False(t, status.Required)
+
+		orgCfg := setting.NewCfg()
+		orgCfg.TOTPEnforcedOrgIds = []int64{2, 3}
+		s, _ = newTestService(t, orgCfg, []int64{1, 3})
+		status, err = s.GetStatus(user.Id)
+		require.NoError(t, err)
+		assert.True(t, status.Required)
+
+		s, _ = newTestService(t, orgCfg, []int64{1})
+		status, err = s.GetStatus(user.Id)
+		require.NoError(t, err)
+		assert.False(t, status.Required)
+
+		serverCfg := setting.NewCfg()
+		serverCfg.TOTPEnforced = true
+		s, _ = newTestService(t, serverCfg, nil)
+		status, err = s.GetStatus(user.Id)
+		require.NoError(t, err)
+		assert.True(t, status.Required)
+	})
+}
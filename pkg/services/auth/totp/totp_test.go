@@ -0,0 +1,77 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the secret of the RFC 6238 test vectors, "12345678901234567890" base32-encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+	for _, tc := range testCases {
+		code, err := generateCode(rfcSecret, time.Unix(tc.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tc.code, code, "time %d", tc.unix)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / period
+
+	testCases := []struct {
+		desc string
+		code string
+		t    time.Time
+		step int64
+		ok   bool
+	}{
+		{desc: "current code", code: "005924", t: now, step: step, ok: true},
+		{desc: "code with spaces", code: " 005924 ", t: now, step: step, ok: true},
+		{desc: "previous code", code: "005924", t: now.Add(period * time.Second), step: step, ok: true},
+		{desc: "expired code", code: "005924", t: now.Add(3 * period * time.Second)},
+		{desc: "wrong code", code: "005925", t: now},
+		{desc: "empty code", code: "", t: now},
+	}
+	for _, tc := range testCases {
+		s, ok := validateCode(rfcSecret, tc.code, tc.t)
+		assert.Equal(t, tc.ok, ok, tc.desc)
+		assert.Equal(t, tc.step, s, tc.desc)
+	}
+}
+
+func TestKeyURI(t *testing.T) {
+	u, err := url.Parse(keyURI("My Grafana", "alice", rfcSecret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/My Grafana:alice", u.Path)
+	assert.Equal(t, rfcSecret, u.Query().Get("secret"))
+	assert.Equal(t, "My Grafana", u.Query().Get("issuer"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	assert.NotEqual(t, codes[0], codes[1])
+
+	assert.Equal(t, hashRecoveryCode(codes[0]), hashRecoveryCode(" "+codes[0][:5]+codes[0][6:]+" "))
+	assert.NotEqual(t, hashRecoveryCode(codes[0]), hashRecoveryCode(codes[1]))
+}
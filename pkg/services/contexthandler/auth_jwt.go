@@ -1,15 +1,29 @@
 package contexthandler
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/network"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/middleware/cookies"
 	"github.com/grafana/grafana/pkg/models"
+	authjwt "github.com/grafana/grafana/pkg/services/auth/jwt"
 )
 
 const InvalidJWT = "Invalid JWT"
 
+// jwtSyncCachePrefix is the prefix of the cache keys of the users synced from a token.
+const jwtSyncCachePrefix = "auth-jwt-sync-%s"
+
 func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64) bool {
 	if !h.Cfg.JWTAuthEnabled || h.Cfg.JWTAuthHeaderName == "" {
 		return false
@@ -20,14 +34,191 @@ func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64)
 		return false
 	}
 
-	claims, err := h.JWTAuthService.Verify(ctx.Req.Context(), jwtToken)
+	user, err := h.getJWTUser(ctx, orgId, jwtToken)
 	if err != nil {
-		ctx.Logger.Debug("Failed to verify JWT", "error", err)
 		ctx.JsonApiErr(401, InvalidJWT, err)
 		return true
 	}
 
-	query := models.GetSignedInUserQuery{OrgId: orgId}
+	ctx.SignedInUser = user
+	ctx.IsSignedIn = true
+
+	return true
+}
+
+// initContextWithJWTFromURL signs in the user with a token passed in the URL, and exchanges
+// the token for a session cookie. GET requests are redirected to the same URL without the
+// token, so it doesn't stay in the browser history.
+func (h *ContextHandler) initContextWithJWTFromURL(ctx *models.ReqContext, orgID int64) bool {
+	if !h.Cfg.JWTAuthEnabled || !h.Cfg.JWTAuthURLLogin || h.Cfg.JWTAuthURLParamName == "" {
+		return false
+	}
+
+	jwtToken := ctx.Query(h.Cfg.JWTAuthURLParamName)
+	if jwtToken == "" {
+		return false
+	}
+
+	user, err := h.getJWTUser(ctx, orgID, jwtToken)
+	if err != nil {
+		ctx.JsonApiErr(401, InvalidJWT, err)
+		return true
+	}
+
+	// make the org of the token the current org of the session
+	if user.OrgId > 0 && orgID == 0 && h.Cfg.JWTAuthOrgClaim != "" {
+		member, err := isOrgMember(user.UserId, user.OrgId)
+		if err != nil {
+			ctx.Logger.Error("Failed to get orgs of JWT user", "userId", user.UserId, "error", err)
+			ctx.JsonApiErr(500, "Failed to get orgs of user", err)
+			return true
+		}
+		if !member {
+			ctx.Logger.Debug("JWT user is not a member of the org of the token", "userId", user.UserId, "orgId", user.OrgId)
+			ctx.JsonApiErr(401, InvalidJWT, models.ErrOrgNotFound)
+			return true
+		}
+		if err := bus.Dispatch(&models.SetUsingOrgCommand{UserId: user.UserId, OrgId: user.OrgId}); err != nil {
+			ctx.Logger.Error("Failed to set current org of JWT user", "userId", user.UserId, "orgId", user.OrgId, "error", err)
+			ctx.JsonApiErr(401, InvalidJWT, err)
+			return true
+		}
+	}
+
+	token, err := h.createJWTURLSession(ctx, user.UserId)
+	if err != nil {
+		ctx.Logger.Error("Failed to create session from JWT", "userId", user.UserId, "error", err)
+		ctx.JsonApiErr(500, "Failed to create session", err)
+		return true
+	}
+
+	ctx.SignedInUser = user
+	ctx.IsSignedIn = true
+	ctx.UserToken = token
+
+	if ctx.Req.Method == "GET" {
+		query := ctx.Req.URL.Query()
+		query.Del(h.Cfg.JWTAuthURLParamName)
+		// the path contains the sub path only when Grafana serves from it
+		path := ctx.Req.URL.EscapedPath()
+		if h.Cfg.ServeFromSubPath {
+			path = strings.TrimPrefix(path, h.Cfg.AppSubURL)
+		}
+		// collapse the leading slashes, so the redirect can't be read as a protocol-relative URL
+		newURL := h.Cfg.AppSubURL + "/" + strings.TrimLeft(path, "/\\")
+		if len(query) > 0 {
+			newURL += "?" + query.Encode()
+		}
+		ctx.Redirect(newURL, 302)
+	}
+
+	return true
+}
+
+// isOrgMember returns true if the user is a member of the org.
+func isOrgMember(userID, orgID int64) (bool, error) {
+	query := models.GetUserOrgListQuery{UserId: userID}
+	if err := bus.Dispatch(&query); err != nil {
+		return false, err
+	}
+
+	for _, org := range query.Result {
+		if org.OrgId == orgID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// createJWTURLSession creates a session for the user and writes its cookie. The expiry of the
+// session is stored in the database, since sessions are otherwise only limited by the login
+// lifetime settings.
+func (h *ContextHandler) createJWTURLSession(ctx *models.ReqContext, userID int64) (*models.UserToken, error) {
+	addr := ctx.RemoteAddr()
+	ip, err := network.GetIPFromAddress(addr)
+	if err != nil {
+		ctx.Logger.Debug("Failed to get client IP address", "addr", addr, "err", err)
+		ip = nil
+	}
+
+	reqCtx := context.WithValue(ctx.Req.Context(), models.RequestURIKey{}, ctx.Req.RequestURI)
+	token, err := h.AuthTokenService.CreateToken(reqCtx, &models.User{Id: userID}, ip, ctx.Req.UserAgent())
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := h.Cfg.JWTAuthURLSessionLifetime
+	if h.Cfg.LoginMaxLifetime > 0 && h.Cfg.LoginMaxLifetime < lifetime {
+		lifetime = h.Cfg.LoginMaxLifetime
+	}
+	getTime := h.GetTime
+	if getTime == nil {
+		getTime = time.Now
+	}
+	expiry := getTime().Add(lifetime).Unix()
+	if err := h.SQLStore.CreateJWTURLSession(ctx.Req.Context(), token.Id, expiry); err != nil {
+		if err := h.AuthTokenService.RevokeToken(ctx.Req.Context(), token, false); err != nil {
+			ctx.Logger.Error("Failed to revoke session created from JWT", "error", err)
+		}
+		return nil, err
+	}
+
+	cookies.WriteSessionCookie(ctx, h.Cfg, token.UnhashedToken, lifetime)
+	return token, nil
+}
+
+// jwtURLSessionExpired returns true if token is a session created from a token passed in the URL,
+// and its lifetime is over. Expired sessions are revoked.
+func (h *ContextHandler) jwtURLSessionExpired(ctx *models.ReqContext, token *models.UserToken) (bool, error) {
+	if !h.Cfg.JWTAuthEnabled || !h.Cfg.JWTAuthURLLogin {
+		return false, nil
+	}
+
+	session, err := h.SQLStore.GetJWTURLSession(ctx.Req.Context(), token.Id)
+	if err != nil {
+		if errors.Is(err, models.ErrJWTURLSessionNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	getTime := h.GetTime
+	if getTime == nil {
+		getTime = time.Now
+	}
+	if getTime().Unix() < session.ExpiresAt {
+		return false, nil
+	}
+
+	if err := h.AuthTokenService.RevokeToken(ctx.Req.Context(), token, false); err != nil {
+		ctx.Logger.Error("Failed to revoke expired session created from JWT", "error", err)
+	}
+	if err := h.SQLStore.DeleteJWTURLSession(ctx.Req.Context(), token.Id); err != nil {
+		ctx.Logger.Error("Failed to remove expiry of session created from JWT", "error", err)
+	}
+	return true, nil
+}
+
+// getJWTUser verifies the token and returns the user it identifies, in the org given by orgID
+// or by the org claim of the token.
+func (h *ContextHandler) getJWTUser(ctx *models.ReqContext, orgID int64, jwtToken string) (*models.SignedInUser, error) {
+	claims, err := h.JWTAuthService.Verify(ctx.Req.Context(), jwtToken)
+	if err != nil {
+		ctx.Logger.Debug("Failed to verify JWT", "error", err)
+		return nil, err
+	}
+
+	if orgID == 0 {
+		if orgID, err = h.getJWTOrgID(claims); err != nil {
+			ctx.Logger.Debug("Failed to get org from JWT", "error", err)
+			return nil, err
+		}
+	}
+
+	if h.jwtSyncEnabled() {
+		return h.getSyncedJWTUser(ctx, orgID, jwtToken, claims)
+	}
+
+	query := models.GetSignedInUserQuery{OrgId: orgID}
 
 	if key := h.Cfg.JWTAuthUsernameClaim; key != "" {
 		query.Login, _ = claims[key].(string)
@@ -38,8 +229,7 @@ func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64)
 
 	if query.Login == "" && query.Email == "" {
 		ctx.Logger.Debug("Failed to get an authentication claim from JWT")
-		ctx.JsonApiErr(401, InvalidJWT, err)
-		return true
+		return nil, authjwt.ErrMissingLoginClaim
 	}
 
 	if err := bus.Dispatch(&query); err != nil {
@@ -53,12 +243,136 @@ func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64)
 		} else {
 			ctx.Logger.Error("Failed to get signed in user", "error", err)
 		}
-		ctx.JsonApiErr(401, InvalidJWT, err)
-		return true
+		return nil, err
 	}
 
-	ctx.SignedInUser = query.Result
-	ctx.IsSignedIn = true
+	return query.Result, nil
+}
 
-	return true
+// getJWTOrgID returns the ID of the org given by the org claim, or 0 if there is none.
+// The claim holds either the ID or the name of the org.
+func (h *ContextHandler) getJWTOrgID(claims models.JWTClaims) (int64, error) {
+	if h.Cfg.JWTAuthOrgClaim == "" {
+		return 0, nil
+	}
+
+	switch value := claims[h.Cfg.JWTAuthOrgClaim].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int64(value), nil
+	case string:
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return id, nil
+		}
+		org, err := h.SQLStore.GetOrgByName(value)
+		if err != nil {
+			return 0, err
+		}
+		return org.Id, nil
+	default:
+		return 0, fmt.Errorf("%q claim has invalid type %T, number or string expected", h.Cfg.JWTAuthOrgClaim, value)
+	}
+}
+
+// jwtSyncEnabled returns true if users are signed up or synced from the claims of the token,
+// rather than only looked up.
+func (h *ContextHandler) jwtSyncEnabled() bool {
+	return h.Cfg.JWTAuthAutoSignUp ||
+		h.Cfg.JWTAuthRoleAttributePath != "" ||
+		h.Cfg.JWTAuthOrgMapping != "" ||
+		h.Cfg.JWTAuthGroupsAttributePath != ""
+}
+
+// getSyncedJWTUser signs up or syncs the user described by the claims through the login
+// service. The ID of the synced user is cached for the given token, so the user is only
+// synced again once the cache entry expires.
+func (h *ContextHandler) getSyncedJWTUser(ctx *models.ReqContext, orgID int64, token string,
+	claims models.JWTClaims) (*models.SignedInUser, error) {
+	cacheKey := jwtSyncCacheKey(token)
+
+	synced := false
+	userID, err := h.getCachedJWTUserID(cacheKey)
+	if err != nil {
+		if userID, err = h.syncJWTUser(ctx, claims); err != nil {
+			return nil, err
+		}
+		synced = true
+	}
+
+	query := models.GetSignedInUserQuery{OrgId: orgID, UserId: userID}
+	if err := bus.Dispatch(&query); err != nil {
+		if !errors.Is(err, models.ErrUserNotFound) {
+			ctx.Logger.Error("Failed to get signed in user", "error", err)
+			return nil, err
+		}
+
+		// The cache entry is stale, for example because the user has been deleted
+		ctx.Logger.Debug("Failed to find synced JWT user, syncing again", "userId", userID)
+		if err := h.RemoteCache.Delete(cacheKey); err != nil && !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			ctx.Logger.Error("Failed to remove synced JWT user from cache", "error", err)
+		}
+		if query.UserId, err = h.syncJWTUser(ctx, claims); err != nil {
+			return nil, err
+		}
+		synced = true
+		if err := bus.Dispatch(&query); err != nil {
+			ctx.Logger.Error("Failed to get signed in user", "error", err)
+			return nil, err
+		}
+	}
+
+	if synced {
+		expiration := time.Duration(h.Cfg.JWTAuthSyncTTL) * time.Minute
+		if err := h.RemoteCache.Set(cacheKey, query.Result.UserId, expiration); err != nil {
+			ctx.Logger.Error("Failed to store synced JWT user in cache", "error", err)
+		}
+	}
+
+	return query.Result, nil
+}
+
+// syncJWTUser signs up or updates the user described by the claims, and returns its ID.
+func (h *ContextHandler) syncJWTUser(ctx *models.ReqContext, claims models.JWTClaims) (int64, error) {
+	extUser, err := h.JWTAuthService.ExternalUserInfo(claims)
+	if err != nil {
+		ctx.Logger.Debug("Failed to get user info from JWT", "error", err)
+		return 0, err
+	}
+
+	upsert := &models.UpsertUserCommand{
+		ReqContext:    ctx,
+		SignupAllowed: h.Cfg.JWTAuthAutoSignUp,
+		ExternalUser:  extUser,
+	}
+	if err := bus.Dispatch(upsert); err != nil {
+		ctx.Logger.Debug("Failed to sync JWT user", "login", extUser.Login, "email", extUser.Email, "error", err)
+		return 0, err
+	}
+
+	// Do not expose disabled status,
+	// just show incorrect user credentials error (see #17947)
+	if upsert.Result.IsDisabled {
+		ctx.Logger.Warn("User is disabled", "user", upsert.Result.Login)
+		return 0, login.ErrInvalidCredentials
+	}
+
+	return upsert.Result.Id, nil
+}
+
+func (h *ContextHandler) getCachedJWTUserID(cacheKey string) (int64, error) {
+	value, err := h.RemoteCache.Get(cacheKey)
+	if err != nil {
+		return 0, err
+	}
+	userID, ok := value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected cached JWT user ID of type %T", value)
+	}
+	return userID, nil
+}
+
+func jwtSyncCacheKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return fmt.Sprintf(jwtSyncCachePrefix, hex.EncodeToString(hash[:]))
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/auth/jwt"
+	"github.com/grafana/grafana/pkg/services/auth/totp"
 	"github.com/grafana/grafana/pkg/services/contexthandler/authproxy"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -132,6 +133,10 @@ func getContextHandler(t *testing.T) *ContextHandler {
 			Name:     jwt.ServiceName,
 			Instance: authJWTSvc,
 		},
+		{
+			Name:     totp.ServiceName,
+			Instance: &totp.Service{Bus: bus.GetBus()},
+		},
 		{
 			Name:     ServiceName,
 			Instance: svc,
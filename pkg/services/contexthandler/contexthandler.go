@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/middleware/cookies"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/auth/totp"
 	"github.com/grafana/grafana/pkg/services/contexthandler/authproxy"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/rendering"
@@ -48,6 +49,7 @@ type ContextHandler struct {
 	RemoteCache      *remotecache.RemoteCache `inject:""`
 	RenderService    rendering.Service        `inject:""`
 	SQLStore         *sqlstore.SQLStore       `inject:""`
+	TOTPService      *totp.Service            `inject:""`
 
 	// GetTime returns the current time.
 	// Stubbable by tests.
@@ -61,6 +63,9 @@ func (h *ContextHandler) Init() error {
 
 // Middleware provides a middleware to initialize the Macaron context.
 func (h *ContextHandler) Middleware(c *macaron.Context) {
+	// read the writes of the request from the primary database, instead of a read replica
+	c.Req.Request = c.Req.WithContext(sqlstore.ContextWithWriteTracker(c.Req.Context(), c.Req.Method))
+
 	ctx := &models.ReqContext{
 		Context:        c,
 		SignedInUser:   &models.SignedInUser{},
@@ -92,6 +97,7 @@ func (h *ContextHandler) Middleware(c *macaron.Context) {
 	case h.initContextWithAPIKey(ctx):
 	case h.initContextWithBasicAuth(ctx, orgID):
 	case h.initContextWithAuthProxy(ctx, orgID):
+	case h.initContextWithJWTFromURL(ctx, orgID):
 	case h.initContextWithToken(ctx, orgID):
 	case h.initContextWithJWT(ctx, orgID):
 	case h.initContextWithAnonymousUser(ctx):
@@ -185,6 +191,10 @@ func (h *ContextHandler) initContextWithAPIKey(ctx *models.ReqContext) bool {
 		return true
 	}
 
+	if apikey.ServiceAccountId != nil {
+		return h.initContextWithServiceAccount(ctx, apikey)
+	}
+
 	ctx.IsSignedIn = true
 	ctx.SignedInUser = &models.SignedInUser{}
 	ctx.OrgRole = apikey.Role
@@ -193,6 +203,31 @@ func (h *ContextHandler) initContextWithAPIKey(ctx *models.ReqContext) bool {
 	return true
 }
 
+// initContextWithServiceAccount signs in the service account owning a token, so that the teams and
+// permissions of the service account apply.
+func (h *ContextHandler) initContextWithServiceAccount(ctx *models.ReqContext, apikey *models.ApiKey) bool {
+	saQuery := models.GetServiceAccountByIdQuery{OrgId: apikey.OrgId, Id: *apikey.ServiceAccountId}
+	if err := bus.Dispatch(&saQuery); err != nil {
+		ctx.JsonApiErr(401, InvalidAPIKey, err)
+		return true
+	}
+	if saQuery.Result.IsDisabled {
+		ctx.JsonApiErr(401, "Service account is disabled", nil)
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{UserId: saQuery.Result.Id, OrgId: apikey.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		ctx.JsonApiErr(401, InvalidAPIKey, err)
+		return true
+	}
+
+	ctx.IsSignedIn = true
+	ctx.SignedInUser = query.Result
+	ctx.ApiKeyId = apikey.Id
+	return true
+}
+
 func (h *ContextHandler) initContextWithBasicAuth(ctx *models.ReqContext, orgID int64) bool {
 	if !h.Cfg.BasicAuthEnabled {
 		return false
@@ -230,6 +265,19 @@ func (h *ContextHandler) initContextWithBasicAuth(ctx *models.ReqContext, orgID
 
 	user := authQuery.User
 
+	// basic auth can't check a second factor, so users who have to sign in with one use API keys instead
+	if authQuery.AuthModule == "grafana" && h.TOTPService.IsEnabled() {
+		status, err := h.TOTPService.GetStatus(user.Id)
+		if err != nil {
+			ctx.JsonApiErr(500, "Failed to get two-factor authentication status", err)
+			return true
+		}
+		if status.Enabled || status.Required {
+			ctx.JsonApiErr(401, "Basic auth is not allowed for users with two-factor authentication", nil)
+			return true
+		}
+	}
+
 	query := models.GetSignedInUserQuery{UserId: user.Id, OrgId: orgID}
 	if err := bus.Dispatch(&query); err != nil {
 		ctx.Logger.Error(
@@ -269,12 +317,29 @@ func (h *ContextHandler) initContextWithToken(ctx *models.ReqContext, orgID int6
 		return false
 	}
 
+	expired, err := h.jwtURLSessionExpired(ctx, token)
+	if err != nil {
+		ctx.Logger.Error("Failed to get expiry of session created from JWT", "userId", token.UserId, "error", err)
+		return false
+	}
+	if expired {
+		ctx.Logger.Debug("Session created from JWT expired", "userId", token.UserId)
+		cookies.WriteSessionCookie(ctx, h.Cfg, "", -1)
+		return false
+	}
+
 	query := models.GetSignedInUserQuery{UserId: token.UserId, OrgId: orgID}
 	if err := bus.Dispatch(&query); err != nil {
 		ctx.Logger.Error("Failed to get user with id", "userId", token.UserId, "error", err)
 		return false
 	}
 
+	if h.sessionExpiredForOrg(token, query.Result.OrgId) {
+		ctx.Logger.Debug("Session expired in organization", "userId", token.UserId, "orgId", query.Result.OrgId)
+		cookies.WriteSessionCookie(ctx, h.Cfg, "", -1)
+		return false
+	}
+
 	ctx.SignedInUser = query.Result
 	ctx.IsSignedIn = true
 	ctx.UserToken = token
@@ -286,6 +351,23 @@ func (h *ContextHandler) initContextWithToken(ctx *models.ReqContext, orgID int6
 	return true
 }
 
+// sessionExpiredForOrg checks a session against the lifetimes of the organization of the request,
+// which can be shorter than the global lifetimes checked when looking up the token.
+func (h *ContextHandler) sessionExpiredForOrg(token *models.UserToken, orgID int64) bool {
+	if len(h.Cfg.OrgLoginMaxInactiveLifetime) == 0 && len(h.Cfg.OrgLoginMaxLifetime) == 0 {
+		return false
+	}
+
+	getTime := h.GetTime
+	if getTime == nil {
+		getTime = time.Now
+	}
+	now := getTime()
+
+	maxInactive, maxLifetime := h.Cfg.LoginLifetimesForOrg(orgID)
+	return token.CreatedAt <= now.Add(-maxLifetime).Unix() || token.RotatedAt <= now.Add(-maxInactive).Unix()
+}
+
 func (h *ContextHandler) rotateEndOfRequestFunc(ctx *models.ReqContext, authTokenService models.UserTokenService,
 	token *models.UserToken) macaron.BeforeFunc {
 	return func(w macaron.ResponseWriter) {
@@ -0,0 +1,248 @@
+// Package ldapsync periodically syncs all LDAP-backed users with the LDAP servers,
+// so that changes in LDAP are applied without waiting for the users to log in.
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// pageSize is the number of Grafana users looked up in LDAP at once.
+	pageSize = 500
+	// maxErrors is the number of errors kept in the status of a sync.
+	maxErrors = 100
+)
+
+var (
+	getLDAPConfig = multildap.GetConfig
+	newLDAP       = multildap.New
+)
+
+// SyncResult describes a single run of the LDAP sync.
+type SyncResult struct {
+	Started       time.Time `json:"started"`
+	Finished      time.Time `json:"finished"`
+	SyncedUsers   int       `json:"syncedUsers"`
+	DisabledUsers int       `json:"disabledUsers"`
+	Errors        []string  `json:"errors"`
+}
+
+// SyncStatus describes the state of the LDAP sync.
+type SyncStatus struct {
+	Enabled  bool        `json:"enabled"`
+	Schedule string      `json:"schedule"`
+	NextSync *time.Time  `json:"nextSync,omitempty"`
+	PrevSync *SyncResult `json:"prevSync,omitempty"`
+}
+
+// LDAPSyncService syncs the LDAP users on the schedule set by ldap.sync_cron.
+type LDAPSyncService struct {
+	Cfg               *setting.Cfg                  `inject:""`
+	Bus               bus.Bus                       `inject:""`
+	ServerLockService *serverlock.ServerLockService `inject:""`
+	AuthTokenService  models.UserTokenService       `inject:""`
+
+	log      log.Logger
+	schedule cron.Schedule
+
+	mutex    sync.Mutex
+	nextSync *time.Time
+	prevSync *SyncResult
+}
+
+func init() {
+	registry.RegisterService(&LDAPSyncService{})
+}
+
+func (s *LDAPSyncService) Init() error {
+	s.log = log.New("ldap.sync")
+
+	if !s.IsDisabled() {
+		parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+		schedule, err := parser.Parse(strings.Trim(s.Cfg.LDAPSyncCron, `"' `))
+		if err != nil {
+			return fmt.Errorf("invalid ldap sync_cron %q: %w", s.Cfg.LDAPSyncCron, err)
+		}
+		s.schedule = schedule
+	}
+
+	return nil
+}
+
+// IsDisabled returns true if LDAP or the LDAP background sync is disabled.
+func (s *LDAPSyncService) IsDisabled() bool {
+	return !s.Cfg.LDAPEnabled || !s.Cfg.LDAPActiveSyncEnabled
+}
+
+func (s *LDAPSyncService) Run(ctx context.Context) error {
+	for {
+		next := s.schedule.Next(time.Now())
+		s.mutex.Lock()
+		s.nextSync = &next
+		s.mutex.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			err := s.ServerLockService.LockAndExecute(ctx, "ldap sync", time.Minute, func() {
+				s.Sync(ctx)
+			})
+			if err != nil {
+				s.log.Error("Failed to lock and execute LDAP sync", "error", err)
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Status returns the schedule and the result of the last LDAP sync run by this instance.
+func (s *LDAPSyncService) Status() SyncStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return SyncStatus{
+		Enabled:  !s.IsDisabled(),
+		Schedule: strings.Trim(s.Cfg.LDAPSyncCron, `"' `),
+		NextSync: s.nextSync,
+		PrevSync: s.prevSync,
+	}
+}
+
+// Sync updates every LDAP user in Grafana with the information found in LDAP,
+// disables the users that are no longer found and revokes their sessions.
+func (s *LDAPSyncService) Sync(ctx context.Context) *SyncResult {
+	result := &SyncResult{Started: time.Now(), Errors: []string{}}
+	s.log.Info("Starting LDAP sync")
+
+	if err := s.sync(ctx, result); err != nil {
+		s.log.Error("LDAP sync failed", "error", err)
+		result.addError(err.Error())
+	}
+
+	result.Finished = time.Now()
+	s.log.Info("LDAP sync finished", "synced", result.SyncedUsers, "disabled", result.DisabledUsers,
+		"errors", len(result.Errors), "duration", result.Finished.Sub(result.Started))
+
+	s.mutex.Lock()
+	s.prevSync = result
+	s.mutex.Unlock()
+
+	return result
+}
+
+func (s *LDAPSyncService) sync(ctx context.Context, result *SyncResult) error {
+	config, err := getLDAPConfig(s.Cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get LDAP config: %w", err)
+	}
+	if config == nil {
+		return errors.New("LDAP is not enabled")
+	}
+	ldapServers := newLDAP(config.Servers)
+
+	var missing []int64
+	// the number of enabled users, and of users found in LDAP, tell an incomplete search result
+	enabled, foundTotal := 0, 0
+	for page := 1; ; page++ {
+		query := &models.SearchUsersQuery{AuthModule: models.AuthModuleLDAP, Page: page, Limit: pageSize}
+		if err := s.Bus.Dispatch(query); err != nil {
+			return fmt.Errorf("failed to search LDAP users: %w", err)
+		}
+		users := query.Result.Users
+		if len(users) == 0 {
+			break
+		}
+
+		logins := make([]string, 0, len(users))
+		for _, user := range users {
+			logins = append(logins, user.Login)
+		}
+
+		// An error from LDAP must stop the sync, otherwise every user would be disabled
+		externalUsers, err := ldapServers.Users(logins)
+		if err != nil {
+			return fmt.Errorf("failed to get users from LDAP: %w", err)
+		}
+		found := make(map[string]*models.ExternalUserInfo, len(externalUsers))
+		for _, externalUser := range externalUsers {
+			found[strings.ToLower(externalUser.Login)] = externalUser
+		}
+		foundTotal += len(externalUsers)
+
+		for _, user := range users {
+			if !user.IsDisabled {
+				enabled++
+			}
+			externalUser, ok := found[strings.ToLower(user.Login)]
+			if !ok {
+				if user.IsDisabled {
+					continue
+				}
+				if user.Login == s.Cfg.AdminUser {
+					result.addError(fmt.Sprintf("refusing to disable grafana super admin %q", user.Login))
+					continue
+				}
+				missing = append(missing, user.Id)
+				continue
+			}
+
+			upsertCmd := &models.UpsertUserCommand{ExternalUser: externalUser, SignupAllowed: false}
+			if err := s.Bus.Dispatch(upsertCmd); err != nil {
+				result.addError(fmt.Sprintf("failed to sync user %q: %s", user.Login, err))
+				continue
+			}
+			result.SyncedUsers++
+		}
+
+		if len(users) < pageSize {
+			break
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// An empty or truncated search result in LDAP must not disable every user
+	if foundTotal == 0 {
+		return fmt.Errorf("refusing to disable %d users, since none of the users were found in LDAP", len(missing))
+	}
+	if maxPercent := s.Cfg.LDAPSyncMaxDisabledPercent; len(missing)*100 > maxPercent*enabled {
+		return fmt.Errorf("refusing to disable %d of %d users, more than sync_max_disabled_percent (%d%%)",
+			len(missing), enabled, maxPercent)
+	}
+
+	if err := s.Bus.Dispatch(&models.BatchDisableUsersCommand{UserIds: missing, IsDisabled: true}); err != nil {
+		return fmt.Errorf("failed to disable users not found in LDAP: %w", err)
+	}
+	result.DisabledUsers = len(missing)
+
+	if err := s.AuthTokenService.BatchRevokeAllUserTokens(ctx, missing); err != nil {
+		return fmt.Errorf("failed to revoke sessions of disabled users: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SyncResult) addError(err string) {
+	if len(r.Errors) < maxErrors {
+		r.Errors = append(r.Errors, err)
+	}
+}
@@ -0,0 +1,235 @@
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLDAP struct {
+	multildap.IMultiLDAP
+	users    []*models.ExternalUserInfo
+	err      error
+	requests [][]string
+}
+
+func (f *fakeLDAP) Users(logins []string) ([]*models.ExternalUserInfo, error) {
+	f.requests = append(f.requests, logins)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var result []*models.ExternalUserInfo
+	for _, login := range logins {
+		for _, user := range f.users {
+			if strings.EqualFold(user.Login, login) {
+				result = append(result, user)
+			}
+		}
+	}
+	return result, nil
+}
+
+type syncScenario struct {
+	service  *LDAPSyncService
+	ldap     *fakeLDAP
+	upserted []string
+	disabled []int64
+	revoked  []int64
+}
+
+func setupSyncScenario(t *testing.T, users []*models.UserSearchHitDTO) *syncScenario {
+	t.Helper()
+
+	sc := &syncScenario{ldap: &fakeLDAP{}}
+
+	origGetLDAPConfig, origNewLDAP := getLDAPConfig, newLDAP
+	t.Cleanup(func() {
+		getLDAPConfig, newLDAP = origGetLDAPConfig, origNewLDAP
+	})
+	getLDAPConfig = func(*setting.Cfg) (*ldap.Config, error) {
+		return &ldap.Config{Servers: []*ldap.ServerConfig{{Host: "ldap.example.org"}}}, nil
+	}
+	newLDAP = func([]*ldap.ServerConfig) multildap.IMultiLDAP {
+		return sc.ldap
+	}
+
+	b := bus.New()
+	b.AddHandler(func(query *models.SearchUsersQuery) error {
+		assert.Equal(t, models.AuthModuleLDAP, query.AuthModule)
+		start := (query.Page - 1) * query.Limit
+		if start > len(users) {
+			start = len(users)
+		}
+		end := start + query.Limit
+		if end > len(users) {
+			end = len(users)
+		}
+		query.Result.Users = users[start:end]
+		query.Result.TotalCount = int64(len(users))
+		return nil
+	})
+	b.AddHandler(func(cmd *models.UpsertUserCommand) error {
+		assert.False(t, cmd.SignupAllowed)
+		sc.upserted = append(sc.upserted, cmd.ExternalUser.Login)
+		return nil
+	})
+	b.AddHandler(func(cmd *models.BatchDisableUsersCommand) error {
+		assert.True(t, cmd.IsDisabled)
+		sc.disabled = append(sc.disabled, cmd.UserIds...)
+		return nil
+	})
+
+	tokenService := auth.NewFakeUserAuthTokenService()
+	tokenService.BatchRevokedTokenProvider = func(ctx context.Context, userIds []int64) error {
+		sc.revoked = append(sc.revoked, userIds...)
+		return nil
+	}
+
+	cfg := setting.NewCfg()
+	cfg.LDAPEnabled = true
+	cfg.LDAPActiveSyncEnabled = true
+	cfg.LDAPSyncCron = `"0 0 1 * * *"`
+	cfg.LDAPSyncMaxDisabledPercent = 50
+	cfg.AdminUser = "admin"
+
+	sc.service = &LDAPSyncService{
+		Cfg:              cfg,
+		Bus:              b,
+		AuthTokenService: tokenService,
+		log:              log.New("ldap.sync.test"),
+	}
+	require.NoError(t, sc.service.Init())
+
+	return sc
+}
+
+func TestLDAPSync(t *testing.T) {
+	t.Run("Syncs found users and disables missing users", func(t *testing.T) {
+		sc := setupSyncScenario(t, []*models.UserSearchHitDTO{
+			{Id: 1, Login: "admin"},
+			{Id: 2, Login: "alice"},
+			{Id: 3, Login: "bob"},
+			{Id: 4, Login: "carol", IsDisabled: true},
+			{Id: 5, Login: "Dave"},
+		})
+		sc.ldap.users = []*models.ExternalUserInfo{
+			{Login: "alice", AuthModule: models.AuthModuleLDAP},
+			{Login: "dave", AuthModule: models.AuthModuleLDAP},
+		}
+
+		result := sc.service.Sync(context.Background())
+
+		assert.Equal(t, []string{"alice", "dave"}, sc.upserted)
+		assert.Equal(t, []int64{3}, sc.disabled)
+		assert.Equal(t, []int64{3}, sc.revoked)
+		assert.Equal(t, 2, result.SyncedUsers)
+		assert.Equal(t, 1, result.DisabledUsers)
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], "super admin")
+
+		status := sc.service.Status()
+		assert.True(t, status.Enabled)
+		assert.Equal(t, "0 0 1 * * *", status.Schedule)
+		assert.Same(t, result, status.PrevSync)
+	})
+
+	t.Run("Pages through the users", func(t *testing.T) {
+		users := make([]*models.UserSearchHitDTO, pageSize+1)
+		for i := range users {
+			users[i] = &models.UserSearchHitDTO{Id: int64(i + 1), Login: fmt.Sprintf("user%d", i)}
+		}
+		sc := setupSyncScenario(t, users)
+		sc.ldap.users = []*models.ExternalUserInfo{{Login: "user0", AuthModule: models.AuthModuleLDAP}}
+		sc.service.Cfg.LDAPSyncMaxDisabledPercent = 100
+
+		sc.service.Sync(context.Background())
+
+		require.Len(t, sc.ldap.requests, 2)
+		assert.Len(t, sc.ldap.requests[0], pageSize)
+		assert.Len(t, sc.ldap.requests[1], 1)
+		assert.Len(t, sc.disabled, pageSize)
+	})
+
+	t.Run("Does not disable users when none of them are found in LDAP", func(t *testing.T) {
+		sc := setupSyncScenario(t, []*models.UserSearchHitDTO{{Id: 2, Login: "alice"}, {Id: 3, Login: "bob"}})
+		sc.service.Cfg.LDAPSyncMaxDisabledPercent = 100
+
+		result := sc.service.Sync(context.Background())
+
+		assert.Empty(t, sc.disabled)
+		assert.Empty(t, sc.revoked)
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], "none of the users were found")
+	})
+
+	t.Run("Does not disable more users than the configured share", func(t *testing.T) {
+		sc := setupSyncScenario(t, []*models.UserSearchHitDTO{
+			{Id: 2, Login: "alice"},
+			{Id: 3, Login: "bob"},
+			{Id: 4, Login: "carol"},
+			{Id: 5, Login: "dave", IsDisabled: true},
+		})
+		sc.ldap.users = []*models.ExternalUserInfo{{Login: "alice", AuthModule: models.AuthModuleLDAP}}
+
+		result := sc.service.Sync(context.Background())
+
+		assert.Equal(t, []string{"alice"}, sc.upserted)
+		assert.Empty(t, sc.disabled)
+		assert.Empty(t, sc.revoked)
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], "refusing to disable 2 of 3 users")
+
+		sc.service.Cfg.LDAPSyncMaxDisabledPercent = 70
+		result = sc.service.Sync(context.Background())
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, []int64{3, 4}, sc.disabled)
+	})
+
+	t.Run("Does not disable users when LDAP fails", func(t *testing.T) {
+		sc := setupSyncScenario(t, []*models.UserSearchHitDTO{{Id: 2, Login: "alice"}})
+		sc.ldap.err = errors.New("connection refused")
+
+		result := sc.service.Sync(context.Background())
+
+		assert.Empty(t, sc.upserted)
+		assert.Empty(t, sc.disabled)
+		assert.Empty(t, sc.revoked)
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], "connection refused")
+	})
+}
+
+func TestLDAPSyncInit(t *testing.T) {
+	t.Run("Fails on invalid schedule", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.LDAPEnabled = true
+		cfg.LDAPActiveSyncEnabled = true
+		cfg.LDAPSyncCron = "every day"
+
+		err := (&LDAPSyncService{Cfg: cfg}).Init()
+		require.Error(t, err)
+	})
+
+	t.Run("Is disabled without LDAP", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.LDAPActiveSyncEnabled = true
+		cfg.LDAPSyncCron = "every day"
+
+		s := &LDAPSyncService{Cfg: cfg}
+		require.NoError(t, s.Init())
+		assert.True(t, s.IsDisabled())
+	})
+}
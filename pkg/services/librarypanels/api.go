@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/util"
@@ -26,11 +27,22 @@ func (lps *LibraryPanelService) registerAPIEndpoints() {
 		libraryPanels.Get("/:uid", middleware.ReqSignedIn, routing.Wrap(lps.getHandler))
 		libraryPanels.Get("/:uid/dashboards/", middleware.ReqSignedIn, routing.Wrap(lps.getConnectedDashboardsHandler))
 		libraryPanels.Patch("/:uid", middleware.ReqSignedIn, binding.Bind(patchLibraryPanelCommand{}), routing.Wrap(lps.patchHandler))
+		libraryPanels.Get("/:uid/versions", middleware.ReqSignedIn, routing.Wrap(lps.getVersionsHandler))
+		libraryPanels.Get("/:uid/versions/:version", middleware.ReqSignedIn, routing.Wrap(lps.getVersionHandler))
+		libraryPanels.Post("/:uid/versions/diff", middleware.ReqSignedIn, binding.Bind(calculateLibraryPanelDiffCommand{}), routing.Wrap(lps.diffHandler))
+		libraryPanels.Post("/:uid/restore", middleware.ReqSignedIn, binding.Bind(restoreLibraryPanelVersionCommand{}), routing.Wrap(lps.restoreHandler))
+		libraryPanels.Post("/export", middleware.ReqSignedIn, binding.Bind(exportLibraryPanelsCommand{}), routing.Wrap(lps.exportHandler))
+		libraryPanels.Post("/import", middleware.ReqEditorRole, binding.Bind(importLibraryPanelsCommand{}), routing.Wrap(lps.importHandler))
+		libraryPanels.Post("/:uid/copy", middleware.ReqGrafanaAdmin, binding.Bind(copyLibraryPanelCommand{}), routing.Wrap(lps.copyHandler))
 	})
 }
 
 // createHandler handles POST /api/library-panels.
 func (lps *LibraryPanelService) createHandler(c *models.ReqContext, cmd createLibraryPanelCommand) response.Response {
+	if rsp := lps.checkQuota(c, cmd.FolderID); rsp != nil {
+		return rsp
+	}
+
 	panel, err := lps.createLibraryPanel(c, cmd)
 	if err != nil {
 		return toLibraryPanelError(err, "Failed to create library panel")
@@ -109,6 +121,101 @@ func (lps *LibraryPanelService) patchHandler(c *models.ReqContext, cmd patchLibr
 	return response.JSON(200, util.DynMap{"result": libraryPanel})
 }
 
+// getVersionsHandler handles GET /api/library-panels/:uid/versions.
+func (lps *LibraryPanelService) getVersionsHandler(c *models.ReqContext) response.Response {
+	versions, err := lps.getLibraryPanelVersions(c, c.Params(":uid"), c.QueryInt("limit"), c.QueryInt("start"))
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel versions")
+	}
+
+	return response.JSON(200, util.DynMap{"result": versions})
+}
+
+// getVersionHandler handles GET /api/library-panels/:uid/versions/:version.
+func (lps *LibraryPanelService) getVersionHandler(c *models.ReqContext) response.Response {
+	version, err := lps.getLibraryPanelVersion(c, c.Params(":uid"), c.ParamsInt64(":version"))
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel version")
+	}
+
+	return response.JSON(200, util.DynMap{"result": version})
+}
+
+// diffHandler handles POST /api/library-panels/:uid/versions/diff.
+func (lps *LibraryPanelService) diffHandler(c *models.ReqContext, cmd calculateLibraryPanelDiffCommand) response.Response {
+	result, err := lps.calculateLibraryPanelDiff(c, c.Params(":uid"), cmd)
+	if err != nil {
+		return toLibraryPanelError(err, "Unable to compute diff")
+	}
+
+	if dashdiffs.ParseDiffType(cmd.DiffType) == dashdiffs.DiffDelta {
+		return response.Respond(200, result.Delta).SetHeader("Content-Type", "application/json")
+	}
+
+	return response.Respond(200, result.Delta).SetHeader("Content-Type", "text/html")
+}
+
+// restoreHandler handles POST /api/library-panels/:uid/restore.
+func (lps *LibraryPanelService) restoreHandler(c *models.ReqContext, cmd restoreLibraryPanelVersionCommand) response.Response {
+	libraryPanel, err := lps.restoreLibraryPanelVersion(c, c.Params(":uid"), cmd.Version)
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to restore library panel version")
+	}
+
+	return response.JSON(200, util.DynMap{"result": libraryPanel})
+}
+
+// exportHandler handles POST /api/library-panels/export.
+func (lps *LibraryPanelService) exportHandler(c *models.ReqContext, cmd exportLibraryPanelsCommand) response.Response {
+	bundle, err := lps.exportLibraryPanels(c, cmd)
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to export library panels")
+	}
+
+	return response.JSON(200, bundle)
+}
+
+// importHandler handles POST /api/library-panels/import.
+func (lps *LibraryPanelService) importHandler(c *models.ReqContext, cmd importLibraryPanelsCommand) response.Response {
+	if rsp := lps.checkQuota(c, cmd.FolderID); rsp != nil {
+		return rsp
+	}
+
+	result, err := lps.importLibraryPanels(c, cmd)
+	if err != nil {
+		rsp := toLibraryPanelError(err, "Failed to import library panels")
+		if len(result.LibraryPanels) == 0 {
+			return rsp
+		}
+		lps.log.Error("Failed to import the dashboards of a library panel bundle", "error", err)
+		return response.JSON(rsp.Status(), util.DynMap{"message": "Failed to import dashboards", "result": result})
+	}
+
+	return response.JSON(200, util.DynMap{"result": result})
+}
+
+// copyHandler handles POST /api/library-panels/:uid/copy.
+func (lps *LibraryPanelService) copyHandler(c *models.ReqContext, cmd copyLibraryPanelCommand) response.Response {
+	libraryPanel, err := lps.copyLibraryPanel(c, c.Params(":uid"), cmd)
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to copy library panel")
+	}
+
+	return response.JSON(200, util.DynMap{"result": libraryPanel})
+}
+
+// checkQuota returns an error response if the library panel quota of the org, folder or teams of the user is reached.
+func (lps *LibraryPanelService) checkQuota(c *models.ReqContext, folderID int64) response.Response {
+	limitReached, err := lps.QuotaService.QuotaReachedInFolder(c, "library_panel", folderID)
+	if err != nil {
+		return response.Error(500, "Failed to get quota", err)
+	}
+	if limitReached {
+		return response.Error(403, "Quota reached", nil)
+	}
+	return nil
+}
+
 func toLibraryPanelError(err error, message string) response.Response {
 	if errors.Is(err, errLibraryPanelAlreadyExists) {
 		return response.Error(400, errLibraryPanelAlreadyExists.Error(), err)
@@ -122,6 +229,24 @@ func toLibraryPanelError(err error, message string) response.Response {
 	if errors.Is(err, errLibraryPanelVersionMismatch) {
 		return response.Error(412, errLibraryPanelVersionMismatch.Error(), err)
 	}
+	if errors.Is(err, errLibraryPanelVersionNotFound) {
+		return response.Error(404, errLibraryPanelVersionNotFound.Error(), err)
+	}
+	if errors.Is(err, errLibraryPanelVersionDiffIdentical) {
+		return response.Error(400, errLibraryPanelVersionDiffIdentical.Error(), err)
+	}
+	if errors.Is(err, errLibraryPanelImportConflict) {
+		return response.Error(409, errLibraryPanelImportConflict.Error(), err)
+	}
+	if errors.Is(err, errLibraryPanelBundleVersion) {
+		return response.Error(400, errLibraryPanelBundleVersion.Error(), err)
+	}
+	if errors.Is(err, errLibraryPanelImportConflictStrategy) {
+		return response.Error(400, errLibraryPanelImportConflictStrategy.Error(), err)
+	}
+	if errors.Is(err, models.ErrOrgNotFound) {
+		return response.Error(404, models.ErrOrgNotFound.Error(), err)
+	}
 	if errors.Is(err, models.ErrFolderNotFound) {
 		return response.Error(404, models.ErrFolderNotFound.Error(), err)
 	}
@@ -131,5 +256,9 @@ func toLibraryPanelError(err error, message string) response.Response {
 	if errors.Is(err, errLibraryPanelHasConnectedDashboards) {
 		return response.Error(403, errLibraryPanelHasConnectedDashboards.Error(), err)
 	}
+	var dashboardErr models.DashboardErr
+	if errors.As(err, &dashboardErr) {
+		return response.Error(dashboardErr.StatusCode, dashboardErr.Error(), err)
+	}
 	return response.Error(500, message, err)
 }
@@ -0,0 +1,403 @@
+package librarypanels
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// exportLibraryPanels builds a LibraryPanelBundle with the requested Library Panels and Dashboards. Library Panels
+// referenced by the exported Dashboards are added to the bundle automatically.
+func (lps *LibraryPanelService) exportLibraryPanels(c *models.ReqContext, cmd exportLibraryPanelsCommand) (LibraryPanelBundle, error) {
+	bundle := LibraryPanelBundle{
+		Version:       libraryPanelBundleVersion,
+		Exported:      time.Now(),
+		LibraryPanels: make([]LibraryPanelBundleItem, 0),
+		Dashboards:    make([]LibraryPanelBundleDashboard, 0),
+	}
+
+	panelUIDs := make([]string, 0, len(cmd.LibraryPanelUIDs))
+	panelUIDs = append(panelUIDs, cmd.LibraryPanelUIDs...)
+	for _, dashboardUID := range cmd.DashboardUIDs {
+		query := models.GetDashboardQuery{Uid: dashboardUID, OrgId: c.SignedInUser.OrgId}
+		if err := bus.Dispatch(&query); err != nil {
+			return LibraryPanelBundle{}, err
+		}
+		dash := query.Result
+		if dash.IsFolder {
+			return LibraryPanelBundle{}, models.ErrDashboardNotFound
+		}
+
+		g := guardian.New(dash.Id, c.SignedInUser.OrgId, c.SignedInUser)
+		if canView, err := g.CanView(); err != nil {
+			return LibraryPanelBundle{}, err
+		} else if !canView {
+			return LibraryPanelBundle{}, models.ErrDashboardNotFound
+		}
+
+		uids, err := getLibraryPanelUIDs(dash.Data)
+		if err != nil {
+			return LibraryPanelBundle{}, err
+		}
+		panelUIDs = append(panelUIDs, uids...)
+
+		// ids and versions only make sense in the instance the dashboard was exported from
+		data := simplejson.NewFromAny(dash.Data.Interface())
+		data.Del("id")
+		data.Del("version")
+		bundle.Dashboards = append(bundle.Dashboards, LibraryPanelBundleDashboard{
+			UID:       dash.Uid,
+			Title:     dash.Title,
+			Dashboard: data,
+		})
+	}
+
+	seen := make(map[string]bool)
+	for _, uid := range panelUIDs {
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		panel, err := lps.getLibraryPanel(c, uid)
+		if err != nil {
+			return LibraryPanelBundle{}, err
+		}
+		bundle.LibraryPanels = append(bundle.LibraryPanels, LibraryPanelBundleItem{
+			UID:         panel.UID,
+			Name:        panel.Name,
+			Type:        panel.Type,
+			Description: panel.Description,
+			Model:       panel.Model,
+		})
+	}
+
+	return bundle, nil
+}
+
+// importLibraryPanels creates or re-links the Library Panels in a LibraryPanelBundle inside the given folder and
+// then saves the bundled Dashboards, connecting them to the imported Library Panels.
+func (lps *LibraryPanelService) importLibraryPanels(c *models.ReqContext, cmd importLibraryPanelsCommand) (LibraryPanelImportResult, error) {
+	result := LibraryPanelImportResult{
+		LibraryPanels: make([]LibraryPanelImportStatus, 0),
+		Dashboards:    make([]LibraryPanelImportStatus, 0),
+	}
+	if cmd.Bundle.Version != libraryPanelBundleVersion {
+		return result, errLibraryPanelBundleVersion
+	}
+	strategy := cmd.ConflictStrategy
+	if strategy == "" {
+		strategy = importConflictLink
+	}
+	if strategy != importConflictLink && strategy != importConflictOverwrite && strategy != importConflictFail {
+		return result, errLibraryPanelImportConflictStrategy
+	}
+	if err := lps.requirePermissionsOnFolder(c.SignedInUser, cmd.FolderID); err != nil {
+		return result, err
+	}
+
+	// maps the UIDs in the bundle to the UIDs of the library panels in this org
+	uidMap := make(map[string]string)
+	statuses := make([]LibraryPanelImportStatus, 0, len(cmd.Bundle.LibraryPanels))
+	err := lps.SQLStore.WithTransactionalDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		for _, item := range cmd.Bundle.LibraryPanels {
+			panel, status, err := lps.importLibraryPanel(session, c.SignedInUser, cmd.FolderID, item, strategy)
+			if err != nil {
+				return fmt.Errorf("%q: %w", item.Name, err)
+			}
+			uidMap[item.UID] = panel.UID
+			statuses = append(statuses, LibraryPanelImportStatus{
+				UID:       panel.UID,
+				Name:      panel.Name,
+				BundleUID: item.UID,
+				Status:    status,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	result.LibraryPanels = statuses
+
+	// the dashboards are saved through the dashboard service, which does not share the transaction above, so
+	// when saving one of them fails the result still reports the library panels and dashboards already imported.
+
+	dashSvc := dashboards.NewService(lps.SQLStore)
+	for _, bundleDash := range cmd.Bundle.Dashboards {
+		if bundleDash.Dashboard == nil {
+			continue
+		}
+		data := simplejson.NewFromAny(bundleDash.Dashboard.Interface())
+		data.Del("id")
+		uids, err := relinkLibraryPanels(data, uidMap)
+		if err != nil {
+			return result, err
+		}
+
+		dash := models.NewDashboardFromJson(data)
+		dash.OrgId = c.SignedInUser.OrgId
+		dash.FolderId = cmd.FolderID
+		saved, err := dashSvc.SaveDashboard(&dashboards.SaveDashboardDTO{
+			OrgId:     c.SignedInUser.OrgId,
+			User:      c.SignedInUser,
+			Message:   "Imported from library panel bundle",
+			Overwrite: cmd.Overwrite,
+			Dashboard: dash,
+		}, true)
+		if err != nil {
+			return result, err
+		}
+		if err := lps.connectLibraryPanelsForDashboard(c, uids, saved.Id); err != nil {
+			return result, err
+		}
+
+		status := importStatusUpdated
+		if saved.Version == 1 {
+			status = importStatusCreated
+		}
+		result.Dashboards = append(result.Dashboards, LibraryPanelImportStatus{
+			UID:       saved.Uid,
+			Name:      saved.Title,
+			BundleUID: bundleDash.UID,
+			Status:    status,
+		})
+	}
+
+	return result, nil
+}
+
+// copyLibraryPanel copies a Library Panel into a folder of another org, keeping its UID.
+func (lps *LibraryPanelService) copyLibraryPanel(c *models.ReqContext, uid string, cmd copyLibraryPanelCommand) (LibraryPanelDTO, error) {
+	orgQuery := models.GetOrgByIdQuery{Id: cmd.OrgID}
+	if err := bus.Dispatch(&orgQuery); err != nil {
+		return LibraryPanelDTO{}, err
+	}
+
+	var dto LibraryPanelDTO
+	err := lps.SQLStore.WithTransactionalDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		source, err := getLibraryPanel(session, uid, c.SignedInUser.OrgId)
+		if err != nil {
+			return err
+		}
+		if !isGeneralFolder(cmd.FolderID) {
+			var folders []struct {
+				ID int64 `xorm:"id"`
+			}
+			sql := "SELECT id FROM dashboard WHERE id=? AND org_id=? AND is_folder=" + lps.SQLStore.Dialect.BooleanStr(true)
+			if err := session.SQL(sql, cmd.FolderID, cmd.OrgID).Find(&folders); err != nil {
+				return err
+			}
+			if len(folders) == 0 {
+				return models.ErrFolderNotFound
+			}
+		}
+
+		item := LibraryPanelBundleItem{
+			UID:         source.UID,
+			Name:        source.Name,
+			Type:        source.Type,
+			Description: source.Description,
+			Model:       source.Model,
+		}
+		target := *c.SignedInUser
+		target.OrgId = cmd.OrgID
+		panel, _, err := lps.importLibraryPanel(session, &target, cmd.FolderID, item, importConflictFail)
+		if err != nil {
+			return err
+		}
+
+		dto = LibraryPanelDTO{
+			ID:          panel.ID,
+			OrgID:       panel.OrgID,
+			FolderID:    panel.FolderID,
+			UID:         panel.UID,
+			Name:        panel.Name,
+			Type:        panel.Type,
+			Description: panel.Description,
+			Model:       panel.Model,
+			Version:     panel.Version,
+			Meta: LibraryPanelDTOMeta{
+				CanEdit:             true,
+				ConnectedDashboards: 0,
+				Created:             panel.Created,
+				Updated:             panel.Updated,
+				CreatedBy: LibraryPanelDTOMetaUser{
+					ID:        panel.CreatedBy,
+					Name:      c.SignedInUser.Login,
+					AvatarUrl: dtos.GetGravatarUrl(c.SignedInUser.Email),
+				},
+				UpdatedBy: LibraryPanelDTOMetaUser{
+					ID:        panel.UpdatedBy,
+					Name:      c.SignedInUser.Login,
+					AvatarUrl: dtos.GetGravatarUrl(c.SignedInUser.Email),
+				},
+			},
+		}
+
+		return nil
+	})
+
+	return dto, err
+}
+
+// importLibraryPanel stores a bundled Library Panel in the org of the user and the given folder. A Library Panel with
+// the same UID in the org, or with the same name in the folder, is handled according to strategy, provided the user
+// can edit the folder it is in.
+func (lps *LibraryPanelService) importLibraryPanel(session *sqlstore.DBSession, user *models.SignedInUser, folderID int64,
+	item LibraryPanelBundleItem, strategy string) (LibraryPanel, string, error) {
+	orgID := user.OrgId
+	userID := user.UserId
+	existing, err := getLibraryPanel(session, item.UID, orgID)
+	if errors.Is(err, errLibraryPanelNotFound) {
+		existing, err = getLibraryPanelByName(session, orgID, folderID, item.Name)
+	}
+	if err != nil && !errors.Is(err, errLibraryPanelNotFound) {
+		return LibraryPanel{}, "", err
+	}
+
+	if errors.Is(err, errLibraryPanelNotFound) {
+		libraryPanel := LibraryPanel{
+			OrgID:       orgID,
+			FolderID:    folderID,
+			UID:         item.UID,
+			Name:        item.Name,
+			Type:        item.Type,
+			Description: item.Description,
+			Model:       item.Model,
+			Version:     1,
+
+			Created: time.Now(),
+			Updated: time.Now(),
+
+			CreatedBy: userID,
+			UpdatedBy: userID,
+		}
+		if libraryPanel.UID == "" {
+			libraryPanel.UID = util.GenerateShortUID()
+		}
+		if err := syncFieldsWithModel(&libraryPanel); err != nil {
+			return LibraryPanel{}, "", err
+		}
+		if _, err := session.Insert(&libraryPanel); err != nil {
+			if lps.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
+				return LibraryPanel{}, "", errLibraryPanelAlreadyExists
+			}
+			return LibraryPanel{}, "", err
+		}
+		if err := insertLibraryPanelVersion(session, libraryPanel, 0, 0); err != nil {
+			return LibraryPanel{}, "", err
+		}
+		return libraryPanel, importStatusCreated, nil
+	}
+
+	libraryPanel := LibraryPanel{
+		ID:          existing.ID,
+		OrgID:       existing.OrgID,
+		FolderID:    existing.FolderID,
+		UID:         existing.UID,
+		Name:        existing.Name,
+		Type:        existing.Type,
+		Description: existing.Description,
+		Model:       existing.Model,
+		Version:     existing.Version,
+		Created:     existing.Created,
+		CreatedBy:   existing.CreatedBy,
+		Updated:     existing.Updated,
+		UpdatedBy:   existing.UpdatedBy,
+	}
+
+	if strategy == importConflictFail {
+		return LibraryPanel{}, "", errLibraryPanelImportConflict
+	}
+	if err := lps.requirePermissionsOnFolder(user, existing.FolderID); err != nil {
+		return LibraryPanel{}, "", err
+	}
+	if strategy == importConflictLink {
+		return libraryPanel, importStatusLinked, nil
+	}
+
+	libraryPanel.Model = item.Model
+	libraryPanel.Version = existing.Version + 1
+	libraryPanel.Updated = time.Now()
+	libraryPanel.UpdatedBy = userID
+	if err := syncFieldsWithModel(&libraryPanel); err != nil {
+		return LibraryPanel{}, "", err
+	}
+	if rowsAffected, err := session.ID(existing.ID).Update(&libraryPanel); err != nil {
+		return LibraryPanel{}, "", err
+	} else if rowsAffected != 1 {
+		return LibraryPanel{}, "", errLibraryPanelNotFound
+	}
+	if err := insertLibraryPanelVersion(session, libraryPanel, existing.Version, 0); err != nil {
+		return LibraryPanel{}, "", err
+	}
+
+	return libraryPanel, importStatusUpdated, nil
+}
+
+func getLibraryPanelByName(session *sqlstore.DBSession, orgID int64, folderID int64, name string) (LibraryPanelWithMeta, error) {
+	libraryPanels := make([]LibraryPanelWithMeta, 0)
+	sql := sqlStatmentLibrayPanelDTOWithMeta + "WHERE lp.org_id=? AND lp.folder_id=? AND lp.name=?"
+	if err := session.SQL(sql, orgID, folderID, name).Find(&libraryPanels); err != nil {
+		return LibraryPanelWithMeta{}, err
+	}
+	if len(libraryPanels) == 0 {
+		return LibraryPanelWithMeta{}, errLibraryPanelNotFound
+	}
+
+	return libraryPanels[0], nil
+}
+
+// getLibraryPanelUIDs returns the UIDs of all Library Panels referenced in dashboard JSON.
+func getLibraryPanelUIDs(data *simplejson.Json) ([]string, error) {
+	var uids []string
+	for _, panel := range data.Get("panels").MustArray() {
+		libraryPanel := simplejson.NewFromAny(panel).Get("libraryPanel")
+		if libraryPanel.Interface() == nil {
+			continue
+		}
+
+		uid := libraryPanel.Get("uid").MustString()
+		if len(uid) == 0 {
+			return nil, errLibraryPanelHeaderUIDMissing
+		}
+		uids = append(uids, uid)
+	}
+
+	return uids, nil
+}
+
+// relinkLibraryPanels rewrites the Library Panel UIDs in dashboard JSON according to uidMap and returns the
+// resulting UIDs.
+func relinkLibraryPanels(data *simplejson.Json, uidMap map[string]string) ([]string, error) {
+	var uids []string
+	panels := data.Get("panels").MustArray()
+	for i, panel := range panels {
+		libraryPanel := simplejson.NewFromAny(panel).Get("libraryPanel")
+		if libraryPanel.Interface() == nil {
+			continue
+		}
+
+		uid := libraryPanel.Get("uid").MustString()
+		if len(uid) == 0 {
+			return nil, errLibraryPanelHeaderUIDMissing
+		}
+		if newUID, ok := uidMap[uid]; ok {
+			uid = newUID
+			data.Get("panels").GetIndex(i).Get("libraryPanel").Set("uid", uid)
+		}
+		uids = append(uids, uid)
+	}
+
+	return uids, nil
+}
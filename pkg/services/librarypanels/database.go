@@ -2,11 +2,14 @@ package librarypanels
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/util"
@@ -86,7 +89,7 @@ func (lps *LibraryPanelService) createLibraryPanel(c *models.ReqContext, cmd cre
 			}
 			return err
 		}
-		return nil
+		return insertLibraryPanelVersion(session, libraryPanel, 0, 0)
 	})
 
 	dto := LibraryPanelDTO{
@@ -193,6 +196,9 @@ func (lps *LibraryPanelService) deleteLibraryPanel(c *models.ReqContext, uid str
 			return errLibraryPanelHasConnectedDashboards
 		}
 
+		if _, err := session.Exec("DELETE FROM library_panel_version WHERE librarypanel_id=?", panel.ID); err != nil {
+			return err
+		}
 		result, err := session.Exec("DELETE FROM library_panel WHERE id=?", panel.ID)
 		if err != nil {
 			return err
@@ -294,6 +300,10 @@ func (lps *LibraryPanelService) deleteLibraryPanelsInFolder(c *models.ReqContext
 			if err != nil {
 				return err
 			}
+			_, err = session.Exec("DELETE FROM library_panel_version WHERE librarypanel_id=?", panelID.ID)
+			if err != nil {
+				return err
+			}
 		}
 		if _, err := session.Exec("DELETE FROM library_panel WHERE folder_id=? AND org_id=?", folderID, c.SignedInUser.OrgId); err != nil {
 			return err
@@ -632,6 +642,256 @@ func (lps *LibraryPanelService) patchLibraryPanel(c *models.ReqContext, cmd patc
 		} else if rowsAffected != 1 {
 			return errLibraryPanelNotFound
 		}
+		if err := insertLibraryPanelVersion(session, libraryPanel, panelInDB.Version, 0); err != nil {
+			return err
+		}
+
+		dto = LibraryPanelDTO{
+			ID:          libraryPanel.ID,
+			OrgID:       libraryPanel.OrgID,
+			FolderID:    libraryPanel.FolderID,
+			UID:         libraryPanel.UID,
+			Name:        libraryPanel.Name,
+			Type:        libraryPanel.Type,
+			Description: libraryPanel.Description,
+			Model:       libraryPanel.Model,
+			Version:     libraryPanel.Version,
+			Meta: LibraryPanelDTOMeta{
+				CanEdit:             true,
+				ConnectedDashboards: panelInDB.ConnectedDashboards,
+				Created:             libraryPanel.Created,
+				Updated:             libraryPanel.Updated,
+				CreatedBy: LibraryPanelDTOMetaUser{
+					ID:        panelInDB.CreatedBy,
+					Name:      panelInDB.CreatedByName,
+					AvatarUrl: dtos.GetGravatarUrl(panelInDB.CreatedByEmail),
+				},
+				UpdatedBy: LibraryPanelDTOMetaUser{
+					ID:        libraryPanel.UpdatedBy,
+					Name:      c.SignedInUser.Login,
+					AvatarUrl: dtos.GetGravatarUrl(c.SignedInUser.Email),
+				},
+			},
+		}
+
+		return nil
+	})
+
+	return dto, err
+}
+
+func insertLibraryPanelVersion(session *sqlstore.DBSession, libraryPanel LibraryPanel, parentVersion int64, restoredFrom int64) error {
+	version := libraryPanelVersion{
+		LibraryPanelID: libraryPanel.ID,
+		ParentVersion:  parentVersion,
+		RestoredFrom:   restoredFrom,
+		Version:        libraryPanel.Version,
+		FolderID:       libraryPanel.FolderID,
+		Name:           libraryPanel.Name,
+		Model:          libraryPanel.Model,
+		Created:        libraryPanel.Updated,
+		CreatedBy:      libraryPanel.UpdatedBy,
+	}
+	if _, err := session.Insert(&version); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (lps *LibraryPanelService) getLibraryPanelVersionWithMeta(session *sqlstore.DBSession, libraryPanelID int64, version int64) (libraryPanelVersionWithMeta, error) {
+	versions := make([]libraryPanelVersionWithMeta, 0)
+	builder := sqlstore.SQLBuilder{}
+	builder.Write(lps.sqlStatementLibraryPanelVersionWithMeta())
+	builder.Write(" WHERE lpv.librarypanel_id=? AND lpv.version=?", libraryPanelID, version)
+	if err := session.SQL(builder.GetSQLString(), builder.GetParams()...).Find(&versions); err != nil {
+		return libraryPanelVersionWithMeta{}, err
+	}
+	if len(versions) == 0 {
+		return libraryPanelVersionWithMeta{}, errLibraryPanelVersionNotFound
+	}
+	if len(versions) > 1 {
+		return libraryPanelVersionWithMeta{}, fmt.Errorf("found %d panel versions, while expecting at most one", len(versions))
+	}
+
+	return versions[0], nil
+}
+
+func (lps *LibraryPanelService) sqlStatementLibraryPanelVersionWithMeta() string {
+	userTable := lps.SQLStore.Dialect.Quote("user")
+	return `
+SELECT
+	lpv.id, lpv.librarypanel_id, lpv.parent_version, lpv.restored_from, lpv.version, lpv.folder_id, lpv.name, lpv.model, lpv.created, lpv.created_by
+	, u.login AS created_by_name
+	, u.email AS created_by_email
+FROM library_panel_version AS lpv
+	LEFT JOIN ` + userTable + ` AS u ON lpv.created_by = u.id
+`
+}
+
+func toLibraryPanelVersionDTO(uid string, version libraryPanelVersionWithMeta, includeModel bool) LibraryPanelVersionDTO {
+	dto := LibraryPanelVersionDTO{
+		ID:            version.ID,
+		UID:           uid,
+		ParentVersion: version.ParentVersion,
+		RestoredFrom:  version.RestoredFrom,
+		Version:       version.Version,
+		FolderID:      version.FolderID,
+		Name:          version.Name,
+		Created:       version.Created,
+		CreatedBy: LibraryPanelDTOMetaUser{
+			ID:        version.CreatedBy,
+			Name:      version.CreatedByName,
+			AvatarUrl: dtos.GetGravatarUrl(version.CreatedByEmail),
+		},
+	}
+
+	switch {
+	case version.RestoredFrom == version.Version:
+		dto.Message = "Initial save (created by migration)"
+	case version.RestoredFrom > 0:
+		dto.Message = fmt.Sprintf("Restored from version %d", version.RestoredFrom)
+	case version.ParentVersion == 0:
+		dto.Message = "Initial save"
+	}
+
+	if includeModel {
+		dto.Model = version.Model
+	}
+
+	return dto
+}
+
+// getLibraryPanelVersions gets all versions of a Library Panel, newest first.
+func (lps *LibraryPanelService) getLibraryPanelVersions(c *models.ReqContext, uid string, limit int, start int) ([]LibraryPanelVersionDTO, error) {
+	panel, err := lps.getLibraryPanel(c, uid)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 1000
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	retDTOs := make([]LibraryPanelVersionDTO, 0)
+	err = lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		versions := make([]libraryPanelVersionWithMeta, 0)
+		builder := sqlstore.SQLBuilder{}
+		builder.Write(lps.sqlStatementLibraryPanelVersionWithMeta())
+		builder.Write(" WHERE lpv.librarypanel_id=?", panel.ID)
+		builder.Write(" ORDER BY lpv.version DESC")
+		builder.Write(lps.SQLStore.Dialect.LimitOffset(int64(limit), int64(start)))
+		if err := session.SQL(builder.GetSQLString(), builder.GetParams()...).Find(&versions); err != nil {
+			return err
+		}
+
+		for _, version := range versions {
+			retDTOs = append(retDTOs, toLibraryPanelVersionDTO(panel.UID, version, false))
+		}
+
+		return nil
+	})
+
+	return retDTOs, err
+}
+
+// getLibraryPanelVersion gets a specific version of a Library Panel.
+func (lps *LibraryPanelService) getLibraryPanelVersion(c *models.ReqContext, uid string, version int64) (LibraryPanelVersionDTO, error) {
+	panel, err := lps.getLibraryPanel(c, uid)
+	if err != nil {
+		return LibraryPanelVersionDTO{}, err
+	}
+
+	var dto LibraryPanelVersionDTO
+	err = lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		versionInDB, err := lps.getLibraryPanelVersionWithMeta(session, panel.ID, version)
+		if err != nil {
+			return err
+		}
+
+		dto = toLibraryPanelVersionDTO(panel.UID, versionInDB, true)
+
+		return nil
+	})
+
+	return dto, err
+}
+
+// calculateLibraryPanelDiff computes the diff between the models of two versions of a Library Panel.
+func (lps *LibraryPanelService) calculateLibraryPanelDiff(c *models.ReqContext, uid string, cmd calculateLibraryPanelDiffCommand) (*dashdiffs.Result, error) {
+	base, err := lps.getLibraryPanelVersion(c, uid, cmd.Base)
+	if err != nil {
+		return nil, err
+	}
+	newVersion, err := lps.getLibraryPanelVersion(c, uid, cmd.New)
+	if err != nil {
+		return nil, err
+	}
+
+	baseData, err := simplejson.NewJson(base.Model)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := simplejson.NewJson(newVersion.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := dashdiffs.CalculateJSONDiff(baseData, newData, dashdiffs.ParseDiffType(cmd.DiffType))
+	if errors.Is(err, dashdiffs.ErrNilDiff) {
+		return nil, errLibraryPanelVersionDiffIdentical
+	}
+
+	return result, err
+}
+
+// restoreLibraryPanelVersion restores a Library Panel to a previous version by saving it as a new version.
+func (lps *LibraryPanelService) restoreLibraryPanelVersion(c *models.ReqContext, uid string, version int64) (LibraryPanelDTO, error) {
+	var dto LibraryPanelDTO
+	err := lps.SQLStore.WithTransactionalDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		panelInDB, err := getLibraryPanel(session, uid, c.SignedInUser.OrgId)
+		if err != nil {
+			return err
+		}
+		if err := lps.requirePermissionsOnFolder(c.SignedInUser, panelInDB.FolderID); err != nil {
+			return err
+		}
+		versionInDB, err := lps.getLibraryPanelVersionWithMeta(session, panelInDB.ID, version)
+		if err != nil {
+			return err
+		}
+
+		var libraryPanel = LibraryPanel{
+			ID:          panelInDB.ID,
+			OrgID:       panelInDB.OrgID,
+			FolderID:    panelInDB.FolderID,
+			UID:         panelInDB.UID,
+			Name:        versionInDB.Name,
+			Type:        panelInDB.Type,
+			Description: panelInDB.Description,
+			Model:       versionInDB.Model,
+			Version:     panelInDB.Version + 1,
+			Created:     panelInDB.Created,
+			CreatedBy:   panelInDB.CreatedBy,
+			Updated:     time.Now(),
+			UpdatedBy:   c.SignedInUser.UserId,
+		}
+		if err := syncFieldsWithModel(&libraryPanel); err != nil {
+			return err
+		}
+		if rowsAffected, err := session.ID(panelInDB.ID).Update(&libraryPanel); err != nil {
+			if lps.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
+				return errLibraryPanelAlreadyExists
+			}
+			return err
+		} else if rowsAffected != 1 {
+			return errLibraryPanelNotFound
+		}
+		if err := insertLibraryPanelVersion(session, libraryPanel, panelInDB.Version, versionInDB.Version); err != nil {
+			return err
+		}
 
 		dto = LibraryPanelDTO{
 			ID:          libraryPanel.ID,
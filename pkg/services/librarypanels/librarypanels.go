@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/setting"
@@ -18,6 +19,7 @@ type LibraryPanelService struct {
 	Cfg           *setting.Cfg          `inject:""`
 	SQLStore      *sqlstore.SQLStore    `inject:""`
 	RouteRegister routing.RouteRegister `inject:""`
+	QuotaService  *quota.QuotaService   `inject:""`
 	log           log.Logger
 }
 
@@ -277,4 +279,55 @@ func (lps *LibraryPanelService) AddMigration(mg *migrator.Migrator) {
 
 	mg.AddMigration("create library_panel_dashboard table v1", migrator.NewAddTableMigration(libraryPanelDashboardV1))
 	mg.AddMigration("add index library_panel_dashboard librarypanel_id & dashboard_id", migrator.NewAddIndexMigration(libraryPanelDashboardV1, libraryPanelDashboardV1.Indices[0]))
+
+	libraryPanelVersionV1 := migrator.Table{
+		Name: "library_panel_version",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "librarypanel_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "parent_version", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "restored_from", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "version", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "folder_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "model", Type: migrator.DB_Text, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"librarypanel_id", "version"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create library_panel_version table v1", migrator.NewAddTableMigration(libraryPanelVersionV1))
+	mg.AddMigration("add index library_panel_version librarypanel_id & version", migrator.NewAddIndexMigration(libraryPanelVersionV1, libraryPanelVersionV1.Indices[0]))
+
+	// existing library panels get their current state stored as the first known version,
+	// parent_version = restored_from = version marks the row as created by migration
+	const saveExistingLibraryPanelsSQL = `INSERT INTO library_panel_version
+(
+	librarypanel_id,
+	parent_version,
+	restored_from,
+	version,
+	folder_id,
+	name,
+	model,
+	created,
+	created_by
+)
+SELECT
+	library_panel.id,
+	library_panel.version,
+	library_panel.version,
+	library_panel.version,
+	library_panel.folder_id,
+	library_panel.name,
+	library_panel.model,
+	library_panel.updated,
+	library_panel.updated_by
+FROM library_panel;`
+	mg.AddMigration("save existing library panels in library_panel_version table v1", migrator.NewRawSQLMigration(saveExistingLibraryPanelsSQL))
+	mg.AddMigration("alter library_panel_version.model to mediumtext v1", migrator.NewRawSQLMigration("").
+		Mysql("ALTER TABLE library_panel_version MODIFY model MEDIUMTEXT;"))
 }
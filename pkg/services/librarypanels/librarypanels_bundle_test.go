@@ -0,0 +1,264 @@
+package librarypanels
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	dboards "github.com/grafana/grafana/pkg/dashboards"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+type libraryPanelImportResult struct {
+	Result LibraryPanelImportResult `json:"result"`
+}
+
+func TestExportLibraryPanels(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin exports a dashboard, it should include the library panels it references",
+		func(t *testing.T, sc scenarioContext) {
+			dash := createDashboardWithLibraryPanel(t, sc, "Dashboard with library panel")
+
+			resp := sc.service.exportHandler(sc.reqContext, exportLibraryPanelsCommand{DashboardUIDs: []string{dash.Uid}})
+			require.Equal(t, 200, resp.Status())
+
+			var bundle LibraryPanelBundle
+			err := json.Unmarshal(resp.Body(), &bundle)
+			require.NoError(t, err)
+			require.Equal(t, libraryPanelBundleVersion, bundle.Version)
+			require.Len(t, bundle.Dashboards, 1)
+			require.Equal(t, dash.Uid, bundle.Dashboards[0].UID)
+			require.Nil(t, bundle.Dashboards[0].Dashboard.Get("id").Interface())
+			require.Len(t, bundle.LibraryPanels, 1)
+			require.Equal(t, sc.initialResult.Result.UID, bundle.LibraryPanels[0].UID)
+			require.Equal(t, "Text - Library Panel", bundle.LibraryPanels[0].Name)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin exports a dashboard that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			resp := sc.service.exportHandler(sc.reqContext, exportLibraryPanelsCommand{DashboardUIDs: []string{"unknown"}})
+			require.Equal(t, 404, resp.Status())
+		})
+}
+
+func TestImportLibraryPanels(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin imports a bundle into an org without the library panel, it should create it and connect the dashboard",
+		func(t *testing.T, sc scenarioContext) {
+			dash := createDashboardWithLibraryPanel(t, sc, "Dashboard with library panel")
+			bundle := exportBundle(t, sc, dash.Uid)
+
+			_, err := sc.sqlStore.CreateOrgWithMember("Other org", sc.user.UserId)
+			require.NoError(t, err)
+			sc.reqContext.SignedInUser.OrgId = 2
+			resp := sc.service.importHandler(sc.reqContext, importLibraryPanelsCommand{Bundle: bundle})
+			require.Equal(t, 200, resp.Status())
+
+			var result libraryPanelImportResult
+			err = json.Unmarshal(resp.Body(), &result)
+			require.NoError(t, err)
+			require.Len(t, result.Result.LibraryPanels, 1)
+			require.Equal(t, importStatusCreated, result.Result.LibraryPanels[0].Status)
+			require.Equal(t, sc.initialResult.Result.UID, result.Result.LibraryPanels[0].UID)
+			require.Len(t, result.Result.Dashboards, 1)
+			require.Equal(t, importStatusCreated, result.Result.Dashboards[0].Status)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp = sc.service.getHandler(sc.reqContext)
+			panel := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, int64(2), panel.Result.OrgID)
+			require.Equal(t, int64(1), panel.Result.Meta.ConnectedDashboards)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin imports a bundle into an org that has the library panel, it should link to it",
+		func(t *testing.T, sc scenarioContext) {
+			dash := createDashboardWithLibraryPanel(t, sc, "Dashboard with library panel")
+			bundle := exportBundle(t, sc, dash.Uid)
+			bundle.Dashboards[0].Dashboard.Set("uid", "imported")
+			bundle.Dashboards[0].Dashboard.Set("title", "Imported dashboard")
+
+			resp := sc.service.importHandler(sc.reqContext, importLibraryPanelsCommand{Bundle: bundle})
+			require.Equal(t, 200, resp.Status())
+
+			var result libraryPanelImportResult
+			err := json.Unmarshal(resp.Body(), &result)
+			require.NoError(t, err)
+			require.Equal(t, importStatusLinked, result.Result.LibraryPanels[0].Status)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp = sc.service.getHandler(sc.reqContext)
+			panel := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, int64(1), panel.Result.Version)
+			require.Equal(t, int64(2), panel.Result.Meta.ConnectedDashboards)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin imports a bundle with the overwrite strategy, it should update the library panel",
+		func(t *testing.T, sc scenarioContext) {
+			bundle := exportBundle(t, sc, "")
+			bundle.LibraryPanels[0].Model = []byte(`{"type": "graph", "description": "Imported description"}`)
+
+			resp := sc.service.importHandler(sc.reqContext, importLibraryPanelsCommand{Bundle: bundle, FolderID: sc.folder.Id, ConflictStrategy: importConflictOverwrite})
+			require.Equal(t, 200, resp.Status())
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp = sc.service.getHandler(sc.reqContext)
+			panel := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, int64(2), panel.Result.Version)
+			require.Equal(t, "graph", panel.Result.Type)
+			require.Equal(t, "Imported description", panel.Result.Description)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin imports a bundle with the fail strategy and the library panel exists, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			bundle := exportBundle(t, sc, "")
+
+			resp := sc.service.importHandler(sc.reqContext, importLibraryPanelsCommand{Bundle: bundle, ConflictStrategy: importConflictFail})
+			require.Equal(t, 409, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an editor imports a bundle that links to a library panel in a folder the editor can't edit, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			bundle := exportBundle(t, sc, "")
+			updateFolderACL(t, sc.sqlStore, sc.folder.Id, []folderACLItem{{models.ROLE_ADMIN, models.PERMISSION_EDIT}})
+			sc.reqContext.SignedInUser.OrgRole = models.ROLE_EDITOR
+
+			for _, strategy := range []string{importConflictLink, importConflictOverwrite} {
+				resp := sc.service.importHandler(sc.reqContext, importLibraryPanelsCommand{Bundle: bundle, ConflictStrategy: strategy})
+				require.Equal(t, 403, resp.Status())
+			}
+
+			sc.reqContext.SignedInUser.OrgRole = models.ROLE_ADMIN
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.getHandler(sc.reqContext)
+			panel := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, int64(1), panel.Result.Version)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin imports a bundle and saving one of its dashboards fails, it should report what was imported",
+		func(t *testing.T, sc scenarioContext) {
+			dash := createDashboardWithLibraryPanel(t, sc, "Dashboard with library panel")
+			bundle := exportBundle(t, sc, dash.Uid)
+			data, err := bundle.Dashboards[0].Dashboard.Encode()
+			require.NoError(t, err)
+			bundle.Dashboards = nil
+			for _, uid := range []string{"imported-1", "imported-2"} {
+				dashboard, err := simplejson.NewJson(data)
+				require.NoError(t, err)
+				dashboard.Set("uid", uid)
+				dashboard.Set("title", "Imported dashboard")
+				bundle.Dashboards = append(bundle.Dashboards, LibraryPanelBundleDashboard{UID: uid, Dashboard: dashboard})
+			}
+
+			resp := sc.service.importHandler(sc.reqContext, importLibraryPanelsCommand{Bundle: bundle})
+			require.Equal(t, 412, resp.Status())
+
+			var result libraryPanelImportResult
+			err = json.Unmarshal(resp.Body(), &result)
+			require.NoError(t, err)
+			require.Len(t, result.Result.LibraryPanels, 1)
+			require.Equal(t, importStatusLinked, result.Result.LibraryPanels[0].Status)
+			require.Len(t, result.Result.Dashboards, 1)
+			require.Equal(t, "imported-1", result.Result.Dashboards[0].UID)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin imports a bundle with an unsupported version, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			bundle := exportBundle(t, sc, "")
+			bundle.Version = 99
+
+			resp := sc.service.importHandler(sc.reqContext, importLibraryPanelsCommand{Bundle: bundle})
+			require.Equal(t, 400, resp.Status())
+		})
+}
+
+func TestCopyLibraryPanel(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin copies a library panel to another org, it should succeed",
+		func(t *testing.T, sc scenarioContext) {
+			_, err := sc.sqlStore.CreateOrgWithMember("Other org", sc.user.UserId)
+			require.NoError(t, err)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.copyHandler(sc.reqContext, copyLibraryPanelCommand{OrgID: 2})
+			result := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, int64(2), result.Result.OrgID)
+			require.Equal(t, int64(0), result.Result.FolderID)
+			require.Equal(t, sc.initialResult.Result.UID, result.Result.UID)
+			require.Equal(t, int64(1), result.Result.Version)
+
+			resp = sc.service.copyHandler(sc.reqContext, copyLibraryPanelCommand{OrgID: 2})
+			require.Equal(t, 409, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin copies a library panel to an org that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.copyHandler(sc.reqContext, copyLibraryPanelCommand{OrgID: 42})
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin copies a library panel to a folder in another org that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			_, err := sc.sqlStore.CreateOrgWithMember("Other org", sc.user.UserId)
+			require.NoError(t, err)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.copyHandler(sc.reqContext, copyLibraryPanelCommand{OrgID: 2, FolderID: sc.folder.Id})
+			require.Equal(t, 404, resp.Status())
+		})
+}
+
+func createDashboardWithLibraryPanel(t *testing.T, sc scenarioContext, title string) *models.Dashboard {
+	t.Helper()
+
+	origUpdateAlerting := dashboards.UpdateAlerting
+	t.Cleanup(func() {
+		dashboards.UpdateAlerting = origUpdateAlerting
+	})
+	dashboards.UpdateAlerting = func(store dboards.Store, orgID int64, dashboard *models.Dashboard,
+		user *models.SignedInUser) error {
+		return nil
+	}
+
+	dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+		"title": title,
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id": int64(1),
+				"libraryPanel": map[string]interface{}{
+					"uid":  sc.initialResult.Result.UID,
+					"name": sc.initialResult.Result.Name,
+				},
+			},
+		},
+	}))
+	dash.FolderId = sc.folder.Id
+	dashboard, err := dashboards.NewService(sc.sqlStore).SaveDashboard(&dashboards.SaveDashboardDTO{
+		Dashboard: dash,
+		OrgId:     sc.user.OrgId,
+		User:      &sc.user,
+	}, true)
+	require.NoError(t, err)
+
+	err = sc.service.connectDashboard(sc.reqContext, sc.initialResult.Result.UID, dashboard.Id)
+	require.NoError(t, err)
+
+	return dashboard
+}
+
+func exportBundle(t *testing.T, sc scenarioContext, dashboardUID string) LibraryPanelBundle {
+	t.Helper()
+
+	cmd := exportLibraryPanelsCommand{LibraryPanelUIDs: []string{sc.initialResult.Result.UID}}
+	if dashboardUID != "" {
+		cmd.DashboardUIDs = []string{dashboardUID}
+	}
+	resp := sc.service.exportHandler(sc.reqContext, cmd)
+	require.Equal(t, 200, resp.Status())
+
+	var bundle LibraryPanelBundle
+	err := json.Unmarshal(resp.Body(), &bundle)
+	require.NoError(t, err)
+
+	return bundle
+}
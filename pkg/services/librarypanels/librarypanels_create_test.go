@@ -5,6 +5,9 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestCreateLibraryPanel(t *testing.T) {
@@ -101,4 +104,21 @@ func TestCreateLibraryPanel(t *testing.T) {
 				t.Fatalf("Result mismatch (-want +got):\n%s", diff)
 			}
 		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to create a library panel and the org quota is reached, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.service.Cfg.Quota = setting.QuotaSettings{
+				Enabled: true,
+				Org:     &setting.OrgQuota{LibraryPanel: 1},
+				Folder:  &setting.FolderQuota{LibraryPanel: -1},
+				Team:    &setting.TeamQuota{LibraryPanel: -1},
+				Global:  &setting.GlobalQuota{LibraryPanel: -1},
+			}
+			sc.reqContext.IsSignedIn = true
+			sc.reqContext.Logger = log.New("test")
+
+			command := getCreateCommand(sc.folder.Id, "Text - Library Panel2")
+			resp := sc.service.createHandler(sc.reqContext, command)
+			require.Equal(t, 403, resp.Status())
+		})
 }
@@ -15,9 +15,11 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	dboards "github.com/grafana/grafana/pkg/dashboards"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -700,8 +702,10 @@ type libraryPanelDashboardsResult struct {
 
 func overrideLibraryPanelServiceInRegistry(cfg *setting.Cfg) LibraryPanelService {
 	lps := LibraryPanelService{
-		SQLStore: nil,
-		Cfg:      cfg,
+		SQLStore:     nil,
+		Cfg:          cfg,
+		QuotaService: &quota.QuotaService{Cfg: cfg},
+		log:          log.New("librarypanels"),
 	}
 
 	overrideServiceFunc := func(d registry.Descriptor) (*registry.Descriptor, bool) {
@@ -0,0 +1,149 @@
+package librarypanels
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type libraryPanelVersionsResult struct {
+	Result []LibraryPanelVersionDTO `json:"result"`
+}
+
+type libraryPanelVersionResult struct {
+	Result LibraryPanelVersionDTO `json:"result"`
+}
+
+func TestLibraryPanelVersions(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin creates a library panel, it should store the initial version",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			var result libraryPanelVersionsResult
+			err := json.Unmarshal(resp.Body(), &result)
+			require.NoError(t, err)
+			require.Len(t, result.Result, 1)
+			require.Equal(t, int64(1), result.Result[0].Version)
+			require.Equal(t, "Initial save", result.Result[0].Message)
+			require.Nil(t, result.Result[0].Model)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin patches a library panel, it should store a new version",
+		func(t *testing.T, sc scenarioContext) {
+			patchPanel(t, sc, "Panel - New name", 1)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			var result libraryPanelVersionsResult
+			err := json.Unmarshal(resp.Body(), &result)
+			require.NoError(t, err)
+			require.Len(t, result.Result, 2)
+			require.Equal(t, int64(2), result.Result[0].Version)
+			require.Equal(t, int64(1), result.Result[0].ParentVersion)
+			require.Equal(t, "Panel - New name", result.Result[0].Name)
+			require.Equal(t, int64(1), result.Result[1].Version)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to get a library panel version that exists, it should return the model",
+		func(t *testing.T, sc scenarioContext) {
+			patchPanel(t, sc, "Panel - New name", 1)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID, ":version": "1"})
+			resp := sc.service.getVersionHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			var result libraryPanelVersionResult
+			err := json.Unmarshal(resp.Body(), &result)
+			require.NoError(t, err)
+			require.Equal(t, "Text - Library Panel", result.Result.Name)
+			var model map[string]interface{}
+			err = json.Unmarshal(result.Result.Model, &model)
+			require.NoError(t, err)
+			require.Equal(t, "Text - Library Panel", model["title"])
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to get a library panel version that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID, ":version": "5"})
+			resp := sc.service.getVersionHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to diff two library panel versions, it should succeed",
+		func(t *testing.T, sc scenarioContext) {
+			patchPanel(t, sc, "Panel - New name", 1)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			cmd := calculateLibraryPanelDiffCommand{Base: 1, New: 2, DiffType: "json"}
+			resp := sc.service.diffHandler(sc.reqContext, cmd)
+			require.Equal(t, 200, resp.Status())
+			require.Contains(t, string(resp.Body()), "Panel - New name")
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin tries to diff identical library panel versions, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			cmd := calculateLibraryPanelDiffCommand{Base: 1, New: 1, DiffType: "basic"}
+			resp := sc.service.diffHandler(sc.reqContext, cmd)
+			require.Equal(t, 400, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin restores a library panel version, it should bump the version and restore the model",
+		func(t *testing.T, sc scenarioContext) {
+			patchPanel(t, sc, "Panel - New name", 1)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.restoreHandler(sc.reqContext, restoreLibraryPanelVersionCommand{Version: 1})
+			var result = validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, int64(3), result.Result.Version)
+			require.Equal(t, "Text - Library Panel", result.Result.Name)
+			require.Equal(t, "Text - Library Panel", result.Result.Model["title"])
+
+			resp = sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var versions libraryPanelVersionsResult
+			err := json.Unmarshal(resp.Body(), &versions)
+			require.NoError(t, err)
+			require.Len(t, versions.Result, 3)
+			require.Equal(t, int64(1), versions.Result[0].RestoredFrom)
+			require.Equal(t, "Restored from version 1", versions.Result[0].Message)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin restores a library panel version that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.restoreHandler(sc.reqContext, restoreLibraryPanelVersionCommand{Version: 7})
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin deletes a library panel, it should delete its versions",
+		func(t *testing.T, sc scenarioContext) {
+			patchPanel(t, sc, "Panel - New name", 1)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.deleteHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			count, err := sc.sqlStore.NewSession().Table("library_panel_version").Count()
+			require.NoError(t, err)
+			require.Equal(t, int64(0), count)
+		})
+}
+
+func patchPanel(t *testing.T, sc scenarioContext, name string, version int64) {
+	t.Helper()
+
+	cmd := patchLibraryPanelCommand{
+		FolderID: -1,
+		Name:     name,
+		Version:  version,
+	}
+	sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+	resp := sc.service.patchHandler(sc.reqContext, cmd)
+	require.Equal(t, 200, resp.Status())
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
 // LibraryPanel is the model for library panel definitions.
@@ -102,6 +104,109 @@ type libraryPanelDashboard struct {
 	CreatedBy int64
 }
 
+// libraryPanelVersion is the model for a stored revision of a library panel.
+type libraryPanelVersion struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	LibraryPanelID int64 `xorm:"librarypanel_id"`
+	ParentVersion  int64
+	RestoredFrom   int64
+	Version        int64
+	FolderID       int64 `xorm:"folder_id"`
+	Name           string
+	Model          json.RawMessage
+
+	Created time.Time
+
+	CreatedBy int64
+}
+
+// libraryPanelVersionWithMeta is the model used to retrieve library panel versions with additional meta information.
+type libraryPanelVersionWithMeta struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	LibraryPanelID int64 `xorm:"librarypanel_id"`
+	ParentVersion  int64
+	RestoredFrom   int64
+	Version        int64
+	FolderID       int64 `xorm:"folder_id"`
+	Name           string
+	Model          json.RawMessage
+
+	Created time.Time
+
+	CreatedBy      int64
+	CreatedByName  string
+	CreatedByEmail string
+}
+
+// LibraryPanelVersionDTO is the frontend DTO for library panel versions.
+type LibraryPanelVersionDTO struct {
+	ID            int64                   `json:"id"`
+	UID           string                  `json:"uid"`
+	ParentVersion int64                   `json:"parentVersion"`
+	RestoredFrom  int64                   `json:"restoredFrom"`
+	Version       int64                   `json:"version"`
+	FolderID      int64                   `json:"folderId"`
+	Name          string                  `json:"name"`
+	Message       string                  `json:"message"`
+	Model         json.RawMessage         `json:"model,omitempty"`
+	Created       time.Time               `json:"created"`
+	CreatedBy     LibraryPanelDTOMetaUser `json:"createdBy"`
+}
+
+// libraryPanelBundleVersion is the current version of the library panel bundle format.
+const libraryPanelBundleVersion = 1
+
+// LibraryPanelBundle is a portable set of library panels together with the dashboards that reference them by UID.
+type LibraryPanelBundle struct {
+	Version       int                           `json:"version"`
+	Exported      time.Time                     `json:"exported"`
+	LibraryPanels []LibraryPanelBundleItem      `json:"libraryPanels"`
+	Dashboards    []LibraryPanelBundleDashboard `json:"dashboards"`
+}
+
+// LibraryPanelBundleItem is a library panel definition inside a LibraryPanelBundle.
+type LibraryPanelBundleItem struct {
+	UID         string          `json:"uid"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Description string          `json:"description"`
+	Model       json.RawMessage `json:"model"`
+}
+
+// LibraryPanelBundleDashboard is a dashboard inside a LibraryPanelBundle.
+type LibraryPanelBundleDashboard struct {
+	UID       string           `json:"uid"`
+	Title     string           `json:"title"`
+	Dashboard *simplejson.Json `json:"dashboard"`
+}
+
+// LibraryPanelImportResult is the result of importing a LibraryPanelBundle.
+type LibraryPanelImportResult struct {
+	LibraryPanels []LibraryPanelImportStatus `json:"libraryPanels"`
+	Dashboards    []LibraryPanelImportStatus `json:"dashboards"`
+}
+
+// LibraryPanelImportStatus describes what happened to a single bundle entry during import.
+type LibraryPanelImportStatus struct {
+	UID       string `json:"uid"`
+	Name      string `json:"name"`
+	BundleUID string `json:"bundleUid"`
+	Status    string `json:"status"`
+}
+
+const (
+	// importConflictLink links dashboards to the library panel that already exists in the target org.
+	importConflictLink = "link"
+	// importConflictOverwrite replaces the existing library panel with the one in the bundle.
+	importConflictOverwrite = "overwrite"
+	// importConflictFail aborts the import when a library panel already exists in the target org.
+	importConflictFail = "fail"
+
+	importStatusCreated = "created"
+	importStatusLinked  = "linked"
+	importStatusUpdated = "updated"
+)
+
 var (
 	// errLibraryPanelAlreadyExists is an error for when the user tries to add a library panel that already exists.
 	errLibraryPanelAlreadyExists = errors.New("library panel with that name already exists")
@@ -119,6 +224,16 @@ var (
 	errLibraryPanelVersionMismatch = errors.New("the library panel has been changed by someone else")
 	// errLibraryPanelHasConnectedDashboards is an error for when an user deletes a library panel that is connected to library panels.
 	errLibraryPanelHasConnectedDashboards = errors.New("the library panel is linked to dashboards")
+	// errLibraryPanelVersionNotFound is an error for when a library panel version can't be found.
+	errLibraryPanelVersionNotFound = errors.New("library panel version could not be found")
+	// errLibraryPanelVersionDiffIdentical is an error for when two compared library panel versions are identical.
+	errLibraryPanelVersionDiffIdentical = errors.New("library panel versions are identical")
+	// errLibraryPanelImportConflict is an error for when an imported library panel already exists in the target org.
+	errLibraryPanelImportConflict = errors.New("library panel with that uid already exists in the target organization")
+	// errLibraryPanelBundleVersion is an error for when a library panel bundle has an unsupported format version.
+	errLibraryPanelBundleVersion = errors.New("unsupported library panel bundle version")
+	// errLibraryPanelImportConflictStrategy is an error for when an import uses an unknown conflict strategy.
+	errLibraryPanelImportConflictStrategy = errors.New("unknown conflict strategy, expected one of link, overwrite or fail")
 )
 
 // Commands
@@ -137,3 +252,35 @@ type patchLibraryPanelCommand struct {
 	Model    json.RawMessage `json:"model"`
 	Version  int64           `json:"version" binding:"Required"`
 }
+
+// calculateLibraryPanelDiffCommand is the command for comparing two versions of a LibraryPanel
+type calculateLibraryPanelDiffCommand struct {
+	Base     int64  `json:"base" binding:"Required"`
+	New      int64  `json:"new" binding:"Required"`
+	DiffType string `json:"diffType" binding:"Required"`
+}
+
+// restoreLibraryPanelVersionCommand is the command for restoring a LibraryPanel to a previous version
+type restoreLibraryPanelVersionCommand struct {
+	Version int64 `json:"version" binding:"Required"`
+}
+
+// exportLibraryPanelsCommand is the command for exporting LibraryPanels and Dashboards as a LibraryPanelBundle
+type exportLibraryPanelsCommand struct {
+	LibraryPanelUIDs []string `json:"libraryPanelUids"`
+	DashboardUIDs    []string `json:"dashboardUids"`
+}
+
+// importLibraryPanelsCommand is the command for importing a LibraryPanelBundle
+type importLibraryPanelsCommand struct {
+	Bundle           LibraryPanelBundle `json:"bundle" binding:"Required"`
+	FolderID         int64              `json:"folderId"`
+	ConflictStrategy string             `json:"conflictStrategy"`
+	Overwrite        bool               `json:"overwrite"`
+}
+
+// copyLibraryPanelCommand is the command for copying a LibraryPanel to another org
+type copyLibraryPanelCommand struct {
+	OrgID    int64 `json:"orgId" binding:"Required"`
+	FolderID int64 `json:"folderId"`
+}
@@ -119,17 +119,19 @@ func (ls *Implementation) UpsertUser(cmd *models.UpsertUserCommand) error {
 		}
 	}
 
-	if ls.TeamSync != nil {
-		err := ls.TeamSync(cmd.Result, extUser)
-		if err != nil {
-			return err
-		}
+	teamSync := ls.TeamSync
+	if teamSync == nil {
+		teamSync = ls.syncTeams
+	}
+	if err := teamSync(cmd.Result, extUser); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-// SetTeamSyncFunc sets the function received through args as the team sync function.
+// SetTeamSyncFunc sets the function received through args as the team sync function, replacing
+// the built-in team sync.
 func (ls *Implementation) SetTeamSyncFunc(teamSyncFunc login.TeamSyncFunc) {
 	ls.TeamSync = teamSyncFunc
 }
@@ -267,3 +269,64 @@ func syncOrgRoles(user *models.User, extUser *models.ExternalUserInfo) error {
 
 	return nil
 }
+
+// syncTeams adds the user to the teams mapped to its external groups, in the orgs the user is a
+// member of. The user is removed from teams it was added to by team sync when it's no longer a
+// member of a group mapped to the team. Memberships added manually are left alone.
+func (ls *Implementation) syncTeams(user *models.User, extUser *models.ExternalUserInfo) error {
+	if extUser.AuthModule == "" {
+		return nil
+	}
+
+	orgsQuery := &models.GetUserOrgListQuery{UserId: user.Id}
+	if err := bus.Dispatch(orgsQuery); err != nil {
+		return err
+	}
+	userOrgs := map[int64]bool{}
+	for _, org := range orgsQuery.Result {
+		userOrgs[org.OrgId] = true
+	}
+
+	groupsQuery := &models.GetTeamGroupsByGroupIdsQuery{GroupIds: extUser.Groups}
+	if err := bus.Dispatch(groupsQuery); err != nil {
+		return err
+	}
+	teamOrgs := map[int64]int64{}
+	for _, teamGroup := range groupsQuery.Result {
+		if userOrgs[teamGroup.OrgId] {
+			teamOrgs[teamGroup.TeamId] = teamGroup.OrgId
+		}
+	}
+
+	membersQuery := &models.GetTeamMembersQuery{UserId: user.Id}
+	if err := bus.Dispatch(membersQuery); err != nil {
+		return err
+	}
+
+	for _, member := range membersQuery.Result {
+		if _, ok := teamOrgs[member.TeamId]; ok {
+			delete(teamOrgs, member.TeamId)
+			continue
+		}
+		if !member.External {
+			continue
+		}
+
+		logger.Debug("Removing user from team as part of team sync", "userId", user.Id, "orgId", member.OrgId,
+			"teamId", member.TeamId)
+		cmd := &models.RemoveTeamMemberCommand{OrgId: member.OrgId, TeamId: member.TeamId, UserId: user.Id}
+		if err := bus.Dispatch(cmd); err != nil && !errors.Is(err, models.ErrTeamMemberNotFound) {
+			return err
+		}
+	}
+
+	for teamID, orgID := range teamOrgs {
+		logger.Debug("Adding user to team as part of team sync", "userId", user.Id, "orgId", orgID, "teamId", teamID)
+		err := ls.SQLStore.AddTeamMember(user.Id, orgID, teamID, true, 0)
+		if err != nil && !errors.Is(err, models.ErrTeamMemberAlreadyAdded) && !errors.Is(err, models.ErrTeamNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
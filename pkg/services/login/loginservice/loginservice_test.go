@@ -1,12 +1,14 @@
 package loginservice
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	log "github.com/inconshreveable/log15"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -130,6 +132,75 @@ func Test_teamSync(t *testing.T) {
 	})
 }
 
+func Test_syncTeams(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	login := Implementation{
+		Bus:      bus.New(),
+		SQLStore: sqlStore,
+	}
+
+	bus.ClearBusHandlers()
+	t.Cleanup(func() { bus.ClearBusHandlers() })
+	bus.AddHandler("test", sqlstore.GetUserOrgList)
+	bus.AddHandler("test", sqlstore.GetTeamGroupsByGroupIds)
+	bus.AddHandler("test", sqlstore.GetTeamMembers)
+	bus.AddHandler("test", sqlstore.RemoveTeamMember)
+
+	user, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "ldap_user", Email: "ldap_user@example.org"})
+	require.NoError(t, err)
+
+	createTeam := func(orgID int64, name string, groupIDs ...string) int64 {
+		team, err := sqlStore.CreateTeam(name, "", orgID)
+		require.NoError(t, err)
+		for _, groupID := range groupIDs {
+			require.NoError(t, sqlstore.AddTeamGroup(&models.AddTeamGroupCommand{OrgId: orgID, TeamId: team.Id, GroupId: groupID}))
+		}
+		return team.Id
+	}
+	adminsID := createTeam(user.OrgId, "admins", "cn=admins,ou=groups,dc=grafana,dc=org")
+	devsID := createTeam(user.OrgId, "devs", "cn=devs,ou=groups,dc=grafana,dc=org")
+	manualID := createTeam(user.OrgId, "manual")
+	require.NoError(t, sqlStore.AddTeamMember(user.Id, user.OrgId, manualID, false, 0))
+
+	otherOrg := models.CreateOrgCommand{Name: "Other"}
+	require.NoError(t, sqlstore.CreateOrg(&otherOrg))
+	createTeam(otherOrg.Result.Id, "other devs", "cn=devs,ou=groups,dc=grafana,dc=org")
+
+	teamIDs := func() []int64 {
+		query := models.GetTeamMembersQuery{UserId: user.Id}
+		require.NoError(t, sqlstore.GetTeamMembers(&query))
+		ids := []int64{}
+		for _, member := range query.Result {
+			ids = append(ids, member.TeamId)
+		}
+		return ids
+	}
+
+	t.Run("adds user to teams of its groups in its orgs", func(t *testing.T) {
+		err := login.syncTeams(user, &models.ExternalUserInfo{
+			AuthModule: models.AuthModuleLDAP,
+			Groups:     []string{"cn=admins,ou=groups,dc=grafana,dc=org", "cn=devs,ou=groups,dc=grafana,dc=org"},
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{adminsID, devsID, manualID}, teamIDs())
+	})
+
+	t.Run("removes user from teams of groups it left", func(t *testing.T) {
+		err := login.syncTeams(user, &models.ExternalUserInfo{
+			AuthModule: models.AuthModuleLDAP,
+			Groups:     []string{"cn=devs,ou=groups,dc=grafana,dc=org"},
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{devsID, manualID}, teamIDs())
+	})
+
+	t.Run("does not sync users without auth module", func(t *testing.T) {
+		err := login.syncTeams(user, &models.ExternalUserInfo{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{devsID, manualID}, teamIDs())
+	})
+}
+
 func createSimpleUser() models.User {
 	user := models.User{
 		Id: 1,
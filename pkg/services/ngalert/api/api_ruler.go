@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
 
 	apimodels "github.com/grafana/alerting-api/pkg/api"
 	"github.com/grafana/grafana/pkg/api/response"
@@ -17,8 +18,9 @@ import (
 )
 
 type RulerSrv struct {
-	store store.RuleStore
-	log   log.Logger
+	store        store.RuleStore
+	quotaService *quota.QuotaService
+	log          log.Logger
 }
 
 func (srv RulerSrv) RouteDeleteNamespaceRulesConfig(c *models.ReqContext) response.Response {
@@ -175,18 +177,32 @@ func (srv RulerSrv) RouteGetRulesConfig(c *models.ReqContext) response.Response
 
 func (srv RulerSrv) RoutePostNameRulesConfig(c *models.ReqContext, ruleGroupConfig apimodels.PostableRuleGroupConfig) response.Response {
 	namespace := c.Params(":Namespace")
-	namespaceUID, err := srv.store.GetNamespaceUIDByTitle(namespace, c.SignedInUser.OrgId, c.SignedInUser)
+	folder, err := srv.store.GetNamespaceByTitle(namespace, c.SignedInUser.OrgId, c.SignedInUser)
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get namespace: %s", namespace), err)
 	}
 
 	// TODO check permissions
-	// TODO check quota
 	// TODO validate UID uniqueness in the payload
 
+	// rules without UID are new rules
+	var newRules int64
+	for _, r := range ruleGroupConfig.Rules {
+		if r.GrafanaManagedAlert != nil && r.GrafanaManagedAlert.UID == "" {
+			newRules++
+		}
+	}
+	limitReached, err := srv.quotaService.QuotaExceededInFolder(c, "alert_rule", folder.Id, newRules)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "failed to get quota", err)
+	}
+	if limitReached {
+		return response.Error(http.StatusForbidden, "quota reached", nil)
+	}
+
 	if err := srv.store.UpdateRuleGroup(store.UpdateRuleGroupCmd{
 		OrgID:           c.SignedInUser.OrgId,
-		NamespaceUID:    namespaceUID,
+		NamespaceUID:    folder.Uid,
 		RuleGroupConfig: ruleGroupConfig,
 	}); err != nil {
 		return response.Error(http.StatusInternalServerError, "failed to update rule group", err)
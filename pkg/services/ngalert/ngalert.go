@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/setting"
@@ -45,6 +46,7 @@ type AlertNG struct {
 	DataService     *tsdb.Service                           `inject:""`
 	Alertmanager    *notifier.Alertmanager                  `inject:""`
 	DataProxy       *datasourceproxy.DatasourceProxyService `inject:""`
+	QuotaService    *quota.QuotaService                     `inject:""`
 	Log             log.Logger
 	schedule        schedule.ScheduleService
 	stateTracker    *state.StateTracker
@@ -86,6 +88,7 @@ func (ng *AlertNG) Init() error {
 		AlertingStore:   store,
 		Alertmanager:    ng.Alertmanager,
 		StateTracker:    ng.stateTracker,
+		QuotaService:    ng.QuotaService,
 	}
 	api.RegisterAPIEndpoints()
 
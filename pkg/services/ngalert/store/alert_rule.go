@@ -44,6 +44,7 @@ type RuleStore interface {
 	GetNamespaceAlertRules(query *ngmodels.ListNamespaceAlertRulesQuery) error
 	GetRuleGroupAlertRules(query *ngmodels.ListRuleGroupAlertRulesQuery) error
 	GetNamespaceUIDByTitle(string, int64, *models.SignedInUser) (string, error)
+	GetNamespaceByTitle(string, int64, *models.SignedInUser) (*models.Folder, error)
 	GetNamespaceByUID(string, int64, *models.SignedInUser) (string, error)
 	GetOrgRuleGroups(query *ngmodels.ListOrgRuleGroupsQuery) error
 	UpsertAlertRules([]UpsertRule) error
@@ -312,14 +313,19 @@ func (st DBstore) GetRuleGroupAlertRules(query *ngmodels.ListRuleGroupAlertRules
 
 // GetNamespaceUIDByTitle is a handler for retrieving a namespace UID by its title.
 func (st DBstore) GetNamespaceUIDByTitle(namespace string, orgID int64, user *models.SignedInUser) (string, error) {
-	s := dashboards.NewFolderService(orgID, user, st.SQLStore)
-	folder, err := s.GetFolderByTitle(namespace)
+	folder, err := st.GetNamespaceByTitle(namespace, orgID, user)
 	if err != nil {
 		return "", err
 	}
 	return folder.Uid, nil
 }
 
+// GetNamespaceByTitle is a handler for retrieving the folder of a namespace by its title.
+func (st DBstore) GetNamespaceByTitle(namespace string, orgID int64, user *models.SignedInUser) (*models.Folder, error) {
+	s := dashboards.NewFolderService(orgID, user, st.SQLStore)
+	return s.GetFolderByTitle(namespace)
+}
+
 // GetNamespaceByUID is a handler for retrieving namespace by its UID.
 func (st DBstore) GetNamespaceByUID(UID string, orgID int64, user *models.SignedInUser) (string, error) {
 	s := dashboards.NewFolderService(orgID, user, st.SQLStore)
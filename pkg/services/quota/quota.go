@@ -25,6 +25,41 @@ func (qs *QuotaService) Init() error {
 }
 
 func (qs *QuotaService) QuotaReached(c *models.ReqContext, target string) (bool, error) {
+	return qs.quotaReached(c, target, nil, nil)
+}
+
+// QuotaReachedInFolder is like QuotaReached, and also checks the quota of the folder
+// for the targets that can be limited per folder.
+func (qs *QuotaService) QuotaReachedInFolder(c *models.ReqContext, target string, folderID int64) (bool, error) {
+	return qs.quotaReached(c, target, &folderID, nil)
+}
+
+// QuotaExceededInFolder is like QuotaReachedInFolder, but checks whether adding an amount to the usage,
+// like the size of a dashboard or a number of alert rules, exceeds the quota. An amount that isn't
+// positive never exceeds it.
+func (qs *QuotaService) QuotaExceededInFolder(c *models.ReqContext, target string, folderID int64, amount int64) (bool, error) {
+	return qs.QuotaExceededInFolderByScope(c, target, folderID, amount, amount)
+}
+
+// QuotaExceededInFolderByScope is like QuotaExceededInFolder, but adds folderAmount to the usage of the
+// folder and amount to the usage of the other scopes, like when a dashboard moves to another folder.
+func (qs *QuotaService) QuotaExceededInFolderByScope(c *models.ReqContext, target string, folderID int64,
+	amount int64, folderAmount int64) (bool, error) {
+	if amount <= 0 && folderAmount <= 0 {
+		return false, nil
+	}
+	return qs.quotaReached(c, target, &folderID, &quotaAmounts{amount: amount, folderAmount: folderAmount})
+}
+
+// quotaAmounts are the amounts added to the usage of the scopes, the folder scope having its own.
+type quotaAmounts struct {
+	amount       int64
+	folderAmount int64
+}
+
+// quotaReached returns whether a quota of target is reached, or exceeded by adding amounts to the usage.
+// With amounts, the scopes whose amount isn't positive are not checked.
+func (qs *QuotaService) quotaReached(c *models.ReqContext, target string, folderID *int64, amounts *quotaAmounts) (bool, error) {
 	if !qs.Cfg.Quota.Enabled {
 		return false, nil
 	}
@@ -35,13 +70,23 @@ func (qs *QuotaService) QuotaReached(c *models.ReqContext, target string) (bool,
 		return false, nil
 	}
 
-	// get the list of scopes that this target is valid for. Org, User, Global
+	// get the list of scopes that this target is valid for. Org, User, Folder, Team, Global
 	scopes, err := qs.getQuotaScopes(target)
 	if err != nil {
 		return false, err
 	}
 
 	for _, scope := range scopes {
+		var amount int64
+		if amounts != nil {
+			amount = amounts.amount
+			if scope.Name == "folder" {
+				amount = amounts.folderAmount
+			}
+			if amount <= 0 {
+				continue
+			}
+		}
 		c.Logger.Debug("Checking quota", "target", target, "scope", scope)
 
 		switch scope.Name {
@@ -68,7 +113,7 @@ func (qs *QuotaService) QuotaReached(c *models.ReqContext, target string) (bool,
 			if err := bus.Dispatch(&query); err != nil {
 				return true, err
 			}
-			if query.Result.Used >= scope.DefaultLimit {
+			if limitReached(query.Result.Used, scope.DefaultLimit, amount) {
 				return true, nil
 			}
 		case "org":
@@ -86,7 +131,7 @@ func (qs *QuotaService) QuotaReached(c *models.ReqContext, target string) (bool,
 				return true, nil
 			}
 
-			if query.Result.Used >= query.Result.Limit {
+			if limitReached(query.Result.Used, query.Result.Limit, amount) {
 				return true, nil
 			}
 		case "user":
@@ -104,15 +149,65 @@ func (qs *QuotaService) QuotaReached(c *models.ReqContext, target string) (bool,
 				return true, nil
 			}
 
-			if query.Result.Used >= query.Result.Limit {
+			if limitReached(query.Result.Used, query.Result.Limit, amount) {
 				return true, nil
 			}
+		case "folder":
+			if !c.IsSignedIn || folderID == nil {
+				continue
+			}
+			query := models.GetFolderQuotaByTargetQuery{OrgId: c.OrgId, FolderId: *folderID, Target: scope.Target, Default: scope.DefaultLimit}
+			if err := bus.Dispatch(&query); err != nil {
+				return true, err
+			}
+			if query.Result.Limit < 0 {
+				continue
+			}
+			if query.Result.Limit == 0 {
+				return true, nil
+			}
+
+			if limitReached(query.Result.Used, query.Result.Limit, amount) {
+				return true, nil
+			}
+		case "team":
+			if !c.IsSignedIn || c.UserId == 0 {
+				continue
+			}
+			teamsQuery := models.GetTeamsByUserQuery{OrgId: c.OrgId, UserId: c.UserId}
+			if err := bus.Dispatch(&teamsQuery); err != nil {
+				return true, err
+			}
+			for _, team := range teamsQuery.Result {
+				query := models.GetTeamQuotaByTargetQuery{OrgId: c.OrgId, TeamId: team.Id, Target: scope.Target, Default: scope.DefaultLimit}
+				if err := bus.Dispatch(&query); err != nil {
+					return true, err
+				}
+				if query.Result.Limit < 0 {
+					continue
+				}
+				if query.Result.Limit == 0 {
+					return true, nil
+				}
+
+				if limitReached(query.Result.Used, query.Result.Limit, amount) {
+					return true, nil
+				}
+			}
 		}
 	}
 
 	return false, nil
 }
 
+// limitReached returns whether the usage reaches a limit or, when a positive amount is added to it, exceeds it.
+func limitReached(used, limit, amount int64) bool {
+	if amount > 0 {
+		return used+amount > limit
+	}
+	return used >= limit
+}
+
 func (qs *QuotaService) getQuotaScopes(target string) ([]models.QuotaScope, error) {
 	scopes := make([]models.QuotaScope, 0)
 	switch target {
@@ -132,6 +227,45 @@ func (qs *QuotaService) getQuotaScopes(target string) ([]models.QuotaScope, erro
 		scopes = append(scopes,
 			models.QuotaScope{Name: "global", Target: target, DefaultLimit: qs.Cfg.Quota.Global.Dashboard},
 			models.QuotaScope{Name: "org", Target: target, DefaultLimit: qs.Cfg.Quota.Org.Dashboard},
+			models.QuotaScope{Name: "folder", Target: target, DefaultLimit: qs.Cfg.Quota.Folder.Dashboard},
+			models.QuotaScope{Name: "team", Target: target, DefaultLimit: qs.Cfg.Quota.Team.Dashboard},
+		)
+		return scopes, nil
+	case "dashboard_bytes":
+		scopes = append(scopes,
+			models.QuotaScope{Name: "global", Target: target, DefaultLimit: qs.Cfg.Quota.Global.DashboardBytes},
+			models.QuotaScope{Name: "org", Target: target, DefaultLimit: qs.Cfg.Quota.Org.DashboardBytes},
+			models.QuotaScope{Name: "folder", Target: target, DefaultLimit: qs.Cfg.Quota.Folder.DashboardBytes},
+			models.QuotaScope{Name: "team", Target: target, DefaultLimit: qs.Cfg.Quota.Team.DashboardBytes},
+		)
+		return scopes, nil
+	case "alert_rule":
+		scopes = append(scopes,
+			models.QuotaScope{Name: "global", Target: target, DefaultLimit: qs.Cfg.Quota.Global.AlertRule},
+			models.QuotaScope{Name: "org", Target: target, DefaultLimit: qs.Cfg.Quota.Org.AlertRule},
+			models.QuotaScope{Name: "folder", Target: target, DefaultLimit: qs.Cfg.Quota.Folder.AlertRule},
+		)
+		return scopes, nil
+	case "library_panel":
+		scopes = append(scopes,
+			models.QuotaScope{Name: "global", Target: target, DefaultLimit: qs.Cfg.Quota.Global.LibraryPanel},
+			models.QuotaScope{Name: "org", Target: target, DefaultLimit: qs.Cfg.Quota.Org.LibraryPanel},
+			models.QuotaScope{Name: "folder", Target: target, DefaultLimit: qs.Cfg.Quota.Folder.LibraryPanel},
+			models.QuotaScope{Name: "team", Target: target, DefaultLimit: qs.Cfg.Quota.Team.LibraryPanel},
+		)
+		return scopes, nil
+	case "dashboard_snapshot":
+		scopes = append(scopes,
+			models.QuotaScope{Name: "global", Target: target, DefaultLimit: qs.Cfg.Quota.Global.DashboardSnapshot},
+			models.QuotaScope{Name: "org", Target: target, DefaultLimit: qs.Cfg.Quota.Org.DashboardSnapshot},
+			models.QuotaScope{Name: "team", Target: target, DefaultLimit: qs.Cfg.Quota.Team.DashboardSnapshot},
+		)
+		return scopes, nil
+	case "annotation_per_day":
+		scopes = append(scopes,
+			models.QuotaScope{Name: "global", Target: target, DefaultLimit: qs.Cfg.Quota.Global.AnnotationPerDay},
+			models.QuotaScope{Name: "org", Target: target, DefaultLimit: qs.Cfg.Quota.Org.AnnotationPerDay},
+			models.QuotaScope{Name: "team", Target: target, DefaultLimit: qs.Cfg.Quota.Team.AnnotationPerDay},
 		)
 		return scopes, nil
 	case "data_source":
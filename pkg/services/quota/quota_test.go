@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaExceededInFolder(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	const used = 3
+	bus.AddHandler("test", func(query *models.GetGlobalQuotaByTargetQuery) error {
+		query.Result = &models.GlobalQuotaDTO{Target: query.Target, Used: used}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetOrgQuotaByTargetQuery) error {
+		query.Result = &models.OrgQuotaDTO{Target: query.Target, Limit: query.Default, Used: used}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetFolderQuotaByTargetQuery) error {
+		query.Result = &models.FolderQuotaDTO{Target: query.Target, Limit: query.Default, Used: used}
+		return nil
+	})
+
+	cfg := setting.NewCfg()
+	cfg.Quota = setting.QuotaSettings{
+		Enabled: true,
+		Org:     &setting.OrgQuota{AlertRule: -1},
+		Folder:  &setting.FolderQuota{AlertRule: 5},
+		Global:  &setting.GlobalQuota{AlertRule: -1},
+	}
+	qs := &QuotaService{Cfg: cfg}
+	c := &models.ReqContext{
+		SignedInUser: &models.SignedInUser{OrgId: 1, UserId: 1},
+		IsSignedIn:   true,
+		Logger:       log.New("test"),
+	}
+
+	testCases := []struct {
+		desc     string
+		amount   int64
+		exceeded bool
+	}{
+		{desc: "nothing added", amount: 0, exceeded: false},
+		{desc: "usage below the limit", amount: 1, exceeded: false},
+		{desc: "usage at the limit", amount: 2, exceeded: false},
+		{desc: "usage above the limit", amount: 3, exceeded: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			exceeded, err := qs.QuotaExceededInFolder(c, "alert_rule", 1, tc.amount)
+			require.NoError(t, err)
+			assert.Equal(t, tc.exceeded, exceeded)
+		})
+	}
+
+	t.Run("Should only add the whole size of a moved dashboard to its new folder", func(t *testing.T) {
+		// the org is close to its limit, and the dashboard is 1 byte smaller than before the move
+		cfg.Quota.Org.DashboardBytes = used + 1
+		cfg.Quota.Folder.DashboardBytes = used + 100
+		cfg.Quota.Global.DashboardBytes = used + 1
+		cfg.Quota.Team = &setting.TeamQuota{DashboardBytes: -1}
+
+		exceeded, err := qs.QuotaExceededInFolderByScope(c, "dashboard_bytes", 1, -1, 50)
+		require.NoError(t, err)
+		assert.False(t, exceeded)
+
+		exceeded, err = qs.QuotaExceededInFolder(c, "dashboard_bytes", 1, 50)
+		require.NoError(t, err)
+		assert.True(t, exceeded)
+
+		cfg.Quota.Folder.DashboardBytes = used + 10
+		exceeded, err = qs.QuotaExceededInFolderByScope(c, "dashboard_bytes", 1, -1, 50)
+		require.NoError(t, err)
+		assert.True(t, exceeded)
+	})
+
+	t.Run("Should still report a reached quota without an amount", func(t *testing.T) {
+		reached, err := qs.QuotaReachedInFolder(c, "alert_rule", 1)
+		require.NoError(t, err)
+		assert.False(t, reached)
+
+		cfg.Quota.Folder.AlertRule = used
+		reached, err = qs.QuotaReachedInFolder(c, "alert_rule", 1)
+		require.NoError(t, err)
+		assert.True(t, reached)
+	})
+}
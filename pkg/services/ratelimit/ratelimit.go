@@ -0,0 +1,227 @@
+// Package ratelimit limits the rate of HTTP API requests per user, API key and organization, with
+// token buckets kept in the remote cache so that the limits are shared by all Grafana instances.
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/network"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const ServiceName = "RateLimitService"
+
+func init() {
+	remotecache.Register(bucket{})
+	registry.Register(&registry.Descriptor{
+		Name:         ServiceName,
+		Instance:     &Service{},
+		InitPriority: registry.Medium,
+	})
+}
+
+// timeNow is overridden in tests.
+var timeNow = time.Now
+
+// lockStripes is the number of mutexes the buckets are spread over.
+const lockStripes = 64
+
+// bucket is the state of a token bucket, as stored in the remote cache.
+type bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+type Service struct {
+	Cfg         *setting.Cfg             `inject:""`
+	RemoteCache *remotecache.RemoteCache `inject:""`
+
+	log   log.Logger
+	cache remotecache.CacheStorage
+	// locks serialize the updates of the buckets within this instance, a bucket being guarded by the
+	// lock its key hashes to
+	locks [lockStripes]sync.Mutex
+}
+
+func (s *Service) Init() error {
+	s.log = log.New("ratelimit")
+	s.cache = s.RemoteCache
+	return nil
+}
+
+// limitCheck is a limit applying to a request, and the key of its bucket.
+type limitCheck struct {
+	name  string
+	key   string
+	limit setting.RateLimit
+}
+
+// Middleware rejects the requests exceeding the limits of the route group, with the 429 status
+// and a Retry-After header. Requests are let through if the remote cache fails.
+func (s *Service) Middleware(group string) macaron.Handler {
+	return func(c *models.ReqContext) {
+		if s == nil || !s.Cfg.RateLimiting.Enabled {
+			return
+		}
+
+		checks := limitChecks(group, s.Cfg.RateLimiting.Groups[group], c, s.Cfg.RateLimiting.TrustedProxies)
+		rejected, retryAfter := s.take(checks, timeNow())
+		if rejected == nil {
+			return
+		}
+
+		metrics.MApiRateLimited.WithLabelValues(group, rejected.name).Inc()
+		c.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JsonApiErr(http.StatusTooManyRequests, "Rate limit reached", nil)
+	}
+}
+
+// limitChecks returns the limits applying to a request: the limit of the API key, or of the user,
+// then the limit of the organization. Anonymous requests are limited per client IP.
+func limitChecks(group string, limits setting.RouteGroupRateLimits, c *models.ReqContext,
+	trustedProxies []*net.IPNet) []limitCheck {
+	var checks []limitCheck
+	prefix := "ratelimit-" + group + "-"
+
+	switch {
+	case c.ApiKeyId > 0:
+		if limits.APIKey.Enabled() {
+			checks = append(checks, limitCheck{"api_key", prefix + fmt.Sprintf("apikey-%d", c.ApiKeyId), limits.APIKey})
+		}
+	case c.IsSignedIn && c.UserId > 0:
+		if limits.User.Enabled() {
+			checks = append(checks, limitCheck{"user", prefix + fmt.Sprintf("user-%d", c.UserId), limits.User})
+		}
+	default:
+		if limits.User.Enabled() {
+			checks = append(checks, limitCheck{"user", prefix + "ip-" + clientIP(c.Req.Request, trustedProxies), limits.User})
+		}
+	}
+
+	if c.OrgId > 0 && limits.Org.Enabled() {
+		checks = append(checks, limitCheck{"org", prefix + fmt.Sprintf("org-%d", c.OrgId), limits.Org})
+	}
+
+	return checks
+}
+
+// clientIP returns the IP address of the client of a request, or the address of the peer if it is
+// not a valid IP address.
+func clientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	ip, err := network.ClientIP(req, trustedProxies)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return ip.String()
+}
+
+// take takes a token from the buckets of all checks, unless a bucket is empty. Then no token is
+// taken, and it returns the check of the empty bucket and how long to wait for its next token.
+// The checks whose bucket can't be read or written are skipped. Instances update buckets without a
+// distributed lock, so concurrent requests on several instances can slightly exceed the limit.
+func (s *Service) take(checks []limitCheck, now time.Time) (*limitCheck, time.Duration) {
+	unlock := s.lock(checks)
+	defer unlock()
+
+	buckets := make([]*bucket, len(checks))
+	for i, check := range checks {
+		b, err := s.getBucket(check, now)
+		if err != nil {
+			s.checkFailed(check, err)
+			continue
+		}
+		if b.Tokens < 1 {
+			return &checks[i], time.Duration((1 - b.Tokens) / check.limit.RPS * float64(time.Second))
+		}
+		buckets[i] = b
+	}
+
+	for i, check := range checks {
+		b := buckets[i]
+		if b == nil {
+			continue
+		}
+		b.Tokens--
+
+		// once full again, the bucket is the same as a missing one
+		burst := float64(check.limit.Burst)
+		expire := time.Duration((burst-b.Tokens)/check.limit.RPS*float64(time.Second)) + time.Second
+		if err := s.cache.Set(check.key, *b, expire); err != nil {
+			s.checkFailed(check, err)
+		}
+	}
+
+	return nil, 0
+}
+
+// getBucket returns the bucket of a check, refilled with the tokens added since its last update.
+func (s *Service) getBucket(check limitCheck, now time.Time) (*bucket, error) {
+	burst := float64(check.limit.Burst)
+	b := bucket{Tokens: burst, Updated: now}
+
+	item, err := s.cache.Get(check.key)
+	switch {
+	case err == nil:
+		if cached, ok := item.(bucket); ok {
+			b = cached
+		}
+	case !errors.Is(err, remotecache.ErrCacheItemNotFound):
+		return nil, err
+	}
+
+	if elapsed := now.Sub(b.Updated).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*check.limit.RPS)
+		b.Updated = now
+	}
+	return &b, nil
+}
+
+func (s *Service) checkFailed(check limitCheck, err error) {
+	metrics.MApiRateLimitErrors.Inc()
+	s.log.Warn("Failed to check rate limit", "key", check.key, "error", err)
+}
+
+// lock locks the buckets of the checks, taking their locks in order so that concurrent requests
+// don't deadlock, and returns the function unlocking them.
+func (s *Service) lock(checks []limitCheck) func() {
+	stripes := make([]int, 0, len(checks))
+	for _, check := range checks {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(check.key))
+		stripe := int(h.Sum32() % lockStripes)
+
+		exists := false
+		for _, locked := range stripes {
+			exists = exists || locked == stripe
+		}
+		if !exists {
+			stripes = append(stripes, stripe)
+		}
+	}
+	sort.Ints(stripes)
+
+	for _, stripe := range stripes {
+		s.locks[stripe].Lock()
+	}
+	return func() {
+		for i := len(stripes) - 1; i >= 0; i-- {
+			s.locks[stripes[i]].Unlock()
+		}
+	}
+}
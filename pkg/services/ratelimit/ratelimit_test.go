@@ -0,0 +1,227 @@
+package ratelimit
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeCache struct {
+	mu    sync.Mutex
+	items map[string]interface{}
+	err   error
+}
+
+func (f *fakeCache) Get(key string) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	item, ok := f.items[key]
+	if !ok {
+		return nil, remotecache.ErrCacheItemNotFound
+	}
+	return item, nil
+}
+
+func (f *fakeCache) Set(key string, value interface{}, expire time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.items[key] = value
+	return nil
+}
+
+func (f *fakeCache) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, key)
+	return nil
+}
+
+type rateLimitScenario struct {
+	t       *testing.T
+	cache   *fakeCache
+	service *Service
+	now     time.Time
+	// header is sent with the requests
+	header http.Header
+}
+
+func setupRateLimitScenario(t *testing.T, limits setting.RouteGroupRateLimits) *rateLimitScenario {
+	t.Helper()
+
+	sc := &rateLimitScenario{t: t, cache: &fakeCache{items: map[string]interface{}{}}, now: time.Now()}
+	cfg := setting.NewCfg()
+	cfg.RateLimiting = setting.RateLimitingSettings{
+		Enabled: true,
+		Groups:  map[string]setting.RouteGroupRateLimits{setting.RateLimitGroupAPI: limits},
+	}
+	sc.service = &Service{Cfg: cfg, log: log.New("ratelimit"), cache: sc.cache}
+
+	origTimeNow := timeNow
+	timeNow = func() time.Time { return sc.now }
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	return sc
+}
+
+// request sends a request through the middleware of the api group, with the identity set on the
+// request context.
+func (sc *rateLimitScenario) request(identity models.SignedInUser) *httptest.ResponseRecorder {
+	sc.t.Helper()
+
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Use(func(c *macaron.Context) {
+		user := identity
+		c.Map(&models.ReqContext{Context: c, SignedInUser: &user, IsSignedIn: user.UserId > 0})
+	})
+	m.Get("/api/foo", sc.service.Middleware(setting.RateLimitGroupAPI), func(c *models.ReqContext) {
+		c.JSON(http.StatusOK, map[string]interface{}{"message": "OK"})
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/api/foo", nil)
+	require.NoError(sc.t, err)
+	req.RemoteAddr = "10.0.0.1:1234"
+	for name, values := range sc.header {
+		req.Header[name] = values
+	}
+	m.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestMiddleware(t *testing.T) {
+	user := models.SignedInUser{UserId: 1, OrgId: 1}
+
+	t.Run("limits the requests of a user", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{User: setting.RateLimit{RPS: 1, Burst: 2}})
+
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+
+		resp := sc.request(user)
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+
+		// other users have their own bucket
+		assert.Equal(t, http.StatusOK, sc.request(models.SignedInUser{UserId: 2, OrgId: 1}).Code)
+
+		sc.now = sc.now.Add(time.Second)
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+		assert.Equal(t, http.StatusTooManyRequests, sc.request(user).Code)
+	})
+
+	t.Run("limits API keys separately from users", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{
+			User:   setting.RateLimit{RPS: 1, Burst: 1},
+			APIKey: setting.RateLimit{RPS: 0.5, Burst: 2},
+		})
+		apiKey := models.SignedInUser{ApiKeyId: 3, OrgId: 1}
+
+		assert.Equal(t, http.StatusOK, sc.request(apiKey).Code)
+		assert.Equal(t, http.StatusOK, sc.request(apiKey).Code)
+		resp := sc.request(apiKey)
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "2", resp.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+	})
+
+	t.Run("limits the requests of an organization", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{Org: setting.RateLimit{RPS: 1, Burst: 2}})
+
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+		assert.Equal(t, http.StatusOK, sc.request(models.SignedInUser{UserId: 2, OrgId: 1}).Code)
+		assert.Equal(t, http.StatusTooManyRequests, sc.request(models.SignedInUser{ApiKeyId: 3, OrgId: 1}).Code)
+		assert.Equal(t, http.StatusOK, sc.request(models.SignedInUser{UserId: 4, OrgId: 2}).Code)
+	})
+
+	t.Run("limits anonymous requests per client IP", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{User: setting.RateLimit{RPS: 1, Burst: 1}})
+
+		assert.Equal(t, http.StatusOK, sc.request(models.SignedInUser{}).Code)
+		assert.Equal(t, http.StatusTooManyRequests, sc.request(models.SignedInUser{}).Code)
+		_, err := sc.cache.Get("ratelimit-api-ip-10.0.0.1")
+		require.NoError(t, err)
+	})
+
+	t.Run("ignores the forwarded client IP of untrusted peers", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{User: setting.RateLimit{RPS: 1, Burst: 1}})
+
+		sc.header = http.Header{"X-Forwarded-For": {"192.168.0.1"}}
+		assert.Equal(t, http.StatusOK, sc.request(models.SignedInUser{}).Code)
+		sc.header = http.Header{"X-Real-Ip": {"192.168.0.2"}}
+		assert.Equal(t, http.StatusTooManyRequests, sc.request(models.SignedInUser{}).Code)
+		_, err := sc.cache.Get("ratelimit-api-ip-10.0.0.1")
+		require.NoError(t, err)
+	})
+
+	t.Run("limits anonymous requests per the client IP forwarded by trusted proxies", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{User: setting.RateLimit{RPS: 1, Burst: 1}})
+		_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+		require.NoError(t, err)
+		sc.service.Cfg.RateLimiting.TrustedProxies = []*net.IPNet{proxies}
+
+		// the client can't choose its address by prepending to the header
+		sc.header = http.Header{"X-Forwarded-For": {"1.2.3.4, 192.168.0.1, 10.0.0.2"}}
+		assert.Equal(t, http.StatusOK, sc.request(models.SignedInUser{}).Code)
+		sc.header = http.Header{"X-Forwarded-For": {"5.6.7.8, 192.168.0.1"}}
+		assert.Equal(t, http.StatusTooManyRequests, sc.request(models.SignedInUser{}).Code)
+
+		sc.header = http.Header{"X-Real-Ip": {"192.168.0.2"}}
+		assert.Equal(t, http.StatusOK, sc.request(models.SignedInUser{}).Code)
+		_, err = sc.cache.Get("ratelimit-api-ip-192.168.0.1")
+		require.NoError(t, err)
+	})
+
+	t.Run("takes no token when another limit is reached", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{
+			User: setting.RateLimit{RPS: 1, Burst: 1},
+			Org:  setting.RateLimit{RPS: 1, Burst: 1},
+		})
+
+		assert.Equal(t, http.StatusOK, sc.request(models.SignedInUser{UserId: 2, OrgId: 1}).Code)
+		assert.Equal(t, http.StatusTooManyRequests, sc.request(user).Code)
+
+		// the rejected request left the bucket of the user full
+		sc.service.Cfg.RateLimiting.Groups[setting.RateLimitGroupAPI] = setting.RouteGroupRateLimits{
+			User: setting.RateLimit{RPS: 1, Burst: 1},
+		}
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+	})
+
+	t.Run("lets requests through when the cache fails", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{User: setting.RateLimit{RPS: 1, Burst: 1}})
+		sc.cache.err = errors.New("cache down")
+
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+	})
+
+	t.Run("does nothing when rate limiting is disabled", func(t *testing.T) {
+		sc := setupRateLimitScenario(t, setting.RouteGroupRateLimits{User: setting.RateLimit{RPS: 1, Burst: 1}})
+		sc.service.Cfg.RateLimiting.Enabled = false
+
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+		assert.Equal(t, http.StatusOK, sc.request(user).Code)
+		assert.Empty(t, sc.cache.items)
+	})
+}
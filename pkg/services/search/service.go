@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"sort"
 
 	"github.com/grafana/grafana/pkg/setting"
@@ -58,7 +59,7 @@ type SearchService struct {
 }
 
 func (s *SearchService) Init() error {
-	s.Bus.AddHandler(s.searchHandler)
+	s.Bus.AddHandlerCtx(s.searchHandler)
 	s.sortOptions = map[string]SortOption{
 		sortAlphaAsc.Name:  sortAlphaAsc,
 		sortAlphaDesc.Name: sortAlphaDesc,
@@ -67,7 +68,7 @@ func (s *SearchService) Init() error {
 	return nil
 }
 
-func (s *SearchService) searchHandler(query *Query) error {
+func (s *SearchService) searchHandler(ctx context.Context, query *Query) error {
 	dashboardQuery := FindPersistedDashboardsQuery{
 		Title:        query.Title,
 		SignedInUser: query.SignedInUser,
@@ -85,7 +86,7 @@ func (s *SearchService) searchHandler(query *Query) error {
 		dashboardQuery.Sort = sortOpt
 	}
 
-	if err := bus.Dispatch(&dashboardQuery); err != nil {
+	if err := bus.DispatchCtx(ctx, &dashboardQuery); err != nil {
 		return err
 	}
 
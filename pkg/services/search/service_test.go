@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -10,7 +11,7 @@ import (
 )
 
 func TestSearch_SortedResults(t *testing.T) {
-	bus.AddHandler("test", func(query *FindPersistedDashboardsQuery) error {
+	bus.AddHandlerCtx("test", func(_ context.Context, query *FindPersistedDashboardsQuery) error {
 		query.Result = HitList{
 			&Hit{ID: 16, Title: "CCAA", Type: "dash-db", Tags: []string{"BB", "AA"}},
 			&Hit{ID: 10, Title: "AABB", Type: "dash-db", Tags: []string{"CC", "AA"}},
@@ -40,7 +41,7 @@ func TestSearch_SortedResults(t *testing.T) {
 		},
 	}
 
-	err := svc.searchHandler(query)
+	err := svc.searchHandler(context.Background(), query)
 	require.Nil(t, err)
 
 	// Assert results are sorted.
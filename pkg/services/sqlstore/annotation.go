@@ -2,6 +2,7 @@ package sqlstore
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -117,7 +118,7 @@ func (r *SQLAnnotationRepo) Update(item *annotations.Item) error {
 	})
 }
 
-func (r *SQLAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+func (r *SQLAnnotationRepo) Find(ctx context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
 	var sql bytes.Buffer
 	params := make([]interface{}, 0)
 
@@ -227,7 +228,10 @@ func (r *SQLAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.I
 
 	items := make([]*annotations.ItemDTO, 0)
 
-	if err := x.SQL(sql.String(), params...).Find(&items); err != nil {
+	err := withReadReplicaDbSession(ctx, func(sess *DBSession) error {
+		return sess.SQL(sql.String(), params...).Find(&items)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -3,6 +3,7 @@
 package sqlstore
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -80,7 +81,7 @@ func TestAnnotations(t *testing.T) {
 		assert.Greater(t, globalAnnotation2.Id, int64(0))
 
 		t.Run("Can query for annotation by dashboard id", func(t *testing.T) {
-			items, err := repo.Find(&annotations.ItemQuery{
+			items, err := repo.Find(context.Background(), &annotations.ItemQuery{
 				OrgId:       1,
 				DashboardId: 1,
 				From:        0,
@@ -98,7 +99,7 @@ func TestAnnotations(t *testing.T) {
 		})
 
 		t.Run("Can query for annotation by id", func(t *testing.T) {
-			items, err := repo.Find(&annotations.ItemQuery{
+			items, err := repo.Find(context.Background(), &annotations.ItemQuery{
 				OrgId:        1,
 				AnnotationId: annotation2.Id,
 			})
@@ -108,7 +109,7 @@ func TestAnnotations(t *testing.T) {
 		})
 
 		t.Run("Should not find any when item is outside time range", func(t *testing.T) {
-			items, err := repo.Find(&annotations.ItemQuery{
+			items, err := repo.Find(context.Background(), &annotations.ItemQuery{
 				OrgId:       1,
 				DashboardId: 1,
 				From:        12,
@@ -119,7 +120,7 @@ func TestAnnotations(t *testing.T) {
 		})
 
 		t.Run("Should not find one when tag filter does not match", func(t *testing.T) {
-			items, err := repo.Find(&annotations.ItemQuery{
+			items, err := repo.Find(context.Background(), &annotations.ItemQuery{
 				OrgId:       1,
 				DashboardId: 1,
 				From:        1,
@@ -131,7 +132,7 @@ func TestAnnotations(t *testing.T) {
 		})
 
 		t.Run("Should not find one when type filter does not match", func(t *testing.T) {
-			items, err := repo.Find(&annotations.ItemQuery{
+			items, err := repo.Find(context.Background(), &annotations.ItemQuery{
 				OrgId:       1,
 				DashboardId: 1,
 				From:        1,
@@ -143,7 +144,7 @@ func TestAnnotations(t *testing.T) {
 		})
 
 		t.Run("Should find one when all tag filters does match", func(t *testing.T) {
-			items, err := repo.Find(&annotations.ItemQuery{
+			items, err := repo.Find(context.Background(), &annotations.ItemQuery{
 				OrgId:       1,
 				DashboardId: 1,
 				From:        1,
@@ -155,7 +156,7 @@ func TestAnnotations(t *testing.T) {
 		})
 
 		t.Run("Should find two annotations using partial match", func(t *testing.T) {
-			items, err := repo.Find(&annotations.ItemQuery{
+			items, err := repo.Find(context.Background(), &annotations.ItemQuery{
 				OrgId:    1,
 				From:     1,
 				To:       25,
@@ -167,7 +168,7 @@ func TestAnnotations(t *testing.T) {
 		})
 
 		t.Run("Should find one when all key value tag filters does match", func(t *testing.T) {
-			items, err := repo.Find(&annotations.ItemQuery{
+			items, err := repo.Find(context.Background(), &annotations.ItemQuery{
 				OrgId:       1,
 				DashboardId: 1,
 				From:        1,
@@ -185,7 +186,7 @@ func TestAnnotations(t *testing.T) {
 				From:        0,
 				To:          15,
 			}
-			items, err := repo.Find(query)
+			items, err := repo.Find(context.Background(), query)
 			require.NoError(t, err)
 
 			annotationId := items[0].Id
@@ -197,7 +198,7 @@ func TestAnnotations(t *testing.T) {
 			})
 			require.NoError(t, err)
 
-			items, err = repo.Find(query)
+			items, err = repo.Find(context.Background(), query)
 			require.NoError(t, err)
 
 			assert.Equal(t, annotationId, items[0].Id)
@@ -212,7 +213,7 @@ func TestAnnotations(t *testing.T) {
 				From:        0,
 				To:          15,
 			}
-			items, err := repo.Find(query)
+			items, err := repo.Find(context.Background(), query)
 			require.NoError(t, err)
 
 			annotationId := items[0].Id
@@ -224,7 +225,7 @@ func TestAnnotations(t *testing.T) {
 			})
 			require.NoError(t, err)
 
-			items, err = repo.Find(query)
+			items, err = repo.Find(context.Background(), query)
 			require.NoError(t, err)
 
 			assert.Equal(t, annotationId, items[0].Id)
@@ -240,14 +241,14 @@ func TestAnnotations(t *testing.T) {
 				From:        0,
 				To:          15,
 			}
-			items, err := repo.Find(query)
+			items, err := repo.Find(context.Background(), query)
 			require.NoError(t, err)
 
 			annotationId := items[0].Id
 			err = repo.Delete(&annotations.DeleteParams{Id: annotationId, OrgId: 1})
 			require.NoError(t, err)
 
-			items, err = repo.Find(query)
+			items, err = repo.Find(context.Background(), query)
 			require.NoError(t, err)
 			assert.Empty(t, items)
 		})
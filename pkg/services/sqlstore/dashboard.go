@@ -29,7 +29,7 @@ func init() {
 	bus.AddHandler("sql", GetDashboard)
 	bus.AddHandler("sql", GetDashboards)
 	bus.AddHandler("sql", DeleteDashboard)
-	bus.AddHandler("sql", SearchDashboards)
+	bus.AddHandlerCtx("sql", SearchDashboards)
 	bus.AddHandler("sql", GetDashboardTags)
 	bus.AddHandler("sql", GetDashboardSlugById)
 	bus.AddHandler("sql", GetDashboardUIDById)
@@ -263,7 +263,7 @@ type DashboardSearchProjection struct {
 	SortMeta    int64
 }
 
-func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSearchProjection, error) {
+func findDashboards(ctx context.Context, query *search.FindPersistedDashboardsQuery) ([]DashboardSearchProjection, error) {
 	filters := []interface{}{
 		permissions.DashboardPermissionFilter{
 			OrgRole:         query.SignedInUser.OrgRole,
@@ -322,7 +322,9 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 	}
 
 	sql, params := sb.ToSQL(limit, page)
-	err := x.SQL(sql, params...).Find(&res)
+	err := withReadReplicaDbSession(ctx, func(sess *DBSession) error {
+		return sess.SQL(sql, params...).Find(&res)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -330,8 +332,8 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 	return res, nil
 }
 
-func SearchDashboards(query *search.FindPersistedDashboardsQuery) error {
-	res, err := findDashboards(query)
+func SearchDashboards(ctx context.Context, query *search.FindPersistedDashboardsQuery) error {
+	res, err := findDashboards(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -433,7 +435,7 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 	}
 
 	if dashboard.IsFolder {
-		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
+		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?", "DELETE FROM quota WHERE folder_id = ?")
 
 		dashIds := []struct {
 			Id int64
@@ -3,6 +3,7 @@
 package sqlstore
 
 import (
+	"context"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -31,7 +32,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 						OrgId:        1,
 						DashboardIds: []int64{folder.Id, dashInRoot.Id},
 					}
-					err := SearchDashboards(query)
+					err := SearchDashboards(context.Background(), query)
 					So(err, ShouldBeNil)
 					So(len(query.Result), ShouldEqual, 2)
 					So(query.Result[0].ID, ShouldEqual, folder.Id)
@@ -54,7 +55,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 						SignedInUser: &models.SignedInUser{UserId: currentUser.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER},
 						OrgId:        1, DashboardIds: []int64{folder.Id, dashInRoot.Id},
 					}
-					err := SearchDashboards(query)
+					err := SearchDashboards(context.Background(), query)
 					So(err, ShouldBeNil)
 
 					So(len(query.Result), ShouldEqual, 1)
@@ -73,7 +74,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 							OrgId:        1,
 							DashboardIds: []int64{folder.Id, dashInRoot.Id},
 						}
-						err := SearchDashboards(query)
+						err := SearchDashboards(context.Background(), query)
 						So(err, ShouldBeNil)
 						So(len(query.Result), ShouldEqual, 2)
 						So(query.Result[0].ID, ShouldEqual, folder.Id)
@@ -92,7 +93,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 							OrgId:        1,
 							DashboardIds: []int64{folder.Id, dashInRoot.Id},
 						}
-						err := SearchDashboards(query)
+						err := SearchDashboards(context.Background(), query)
 						So(err, ShouldBeNil)
 						So(len(query.Result), ShouldEqual, 2)
 						So(query.Result[0].ID, ShouldEqual, folder.Id)
@@ -114,7 +115,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 					query := &search.FindPersistedDashboardsQuery{
 						SignedInUser: &models.SignedInUser{UserId: currentUser.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER}, OrgId: 1, DashboardIds: []int64{folder.Id, childDash.Id, dashInRoot.Id},
 					}
-					err := SearchDashboards(query)
+					err := SearchDashboards(context.Background(), query)
 					So(err, ShouldBeNil)
 					So(len(query.Result), ShouldEqual, 1)
 					So(query.Result[0].ID, ShouldEqual, dashInRoot.Id)
@@ -128,7 +129,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 
 					Convey("should be able to search for child dashboard but not folder", func() {
 						query := &search.FindPersistedDashboardsQuery{SignedInUser: &models.SignedInUser{UserId: currentUser.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER}, OrgId: 1, DashboardIds: []int64{folder.Id, childDash.Id, dashInRoot.Id}}
-						err := SearchDashboards(query)
+						err := SearchDashboards(context.Background(), query)
 						So(err, ShouldBeNil)
 						So(len(query.Result), ShouldEqual, 2)
 						So(query.Result[0].ID, ShouldEqual, childDash.Id)
@@ -147,7 +148,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 							OrgId:        1,
 							DashboardIds: []int64{folder.Id, dashInRoot.Id, childDash.Id},
 						}
-						err := SearchDashboards(query)
+						err := SearchDashboards(context.Background(), query)
 						So(err, ShouldBeNil)
 						So(len(query.Result), ShouldEqual, 3)
 						So(query.Result[0].ID, ShouldEqual, folder.Id)
@@ -177,7 +178,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 						},
 						OrgId: 1,
 					}
-					err := SearchDashboards(query)
+					err := SearchDashboards(context.Background(), query)
 					So(err, ShouldBeNil)
 					So(len(query.Result), ShouldEqual, 4)
 					So(query.Result[0].ID, ShouldEqual, folder1.Id)
@@ -203,7 +204,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 							OrgId:        1,
 							DashboardIds: []int64{folder1.Id, childDash1.Id, childDash2.Id, dashInRoot.Id},
 						}
-						err := SearchDashboards(query)
+						err := SearchDashboards(context.Background(), query)
 						So(err, ShouldBeNil)
 						So(len(query.Result), ShouldEqual, 1)
 						So(query.Result[0].ID, ShouldEqual, dashInRoot.Id)
@@ -218,7 +219,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 							OrgId:        1,
 							DashboardIds: []int64{folder2.Id, childDash1.Id, childDash2.Id, dashInRoot.Id},
 						}
-						err := SearchDashboards(query)
+						err := SearchDashboards(context.Background(), query)
 						So(err, ShouldBeNil)
 						So(len(query.Result), ShouldEqual, 4)
 						So(query.Result[0].ID, ShouldEqual, folder2.Id)
@@ -242,7 +243,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 							OrgId:        1,
 							DashboardIds: []int64{folder2.Id, childDash1.Id, childDash2.Id, dashInRoot.Id},
 						}
-						err := SearchDashboards(query)
+						err := SearchDashboards(context.Background(), query)
 						So(err, ShouldBeNil)
 						So(len(query.Result), ShouldEqual, 4)
 						So(query.Result[0].ID, ShouldEqual, folder2.Id)
@@ -272,7 +273,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 						Type:         "dash-folder",
 					}
 
-					err := SearchDashboards(&query)
+					err := SearchDashboards(context.Background(), &query)
 					So(err, ShouldBeNil)
 
 					So(len(query.Result), ShouldEqual, 2)
@@ -325,7 +326,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 				}
 
 				Convey("Should have write access to all dashboard folders with default ACL", func() {
-					err := SearchDashboards(&query)
+					err := SearchDashboards(context.Background(), &query)
 					So(err, ShouldBeNil)
 
 					So(len(query.Result), ShouldEqual, 2)
@@ -357,7 +358,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 					})
 					So(err, ShouldBeNil)
 
-					err = SearchDashboards(&query)
+					err = SearchDashboards(context.Background(), &query)
 					So(err, ShouldBeNil)
 
 					So(len(query.Result), ShouldEqual, 1)
@@ -391,7 +392,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 				}
 
 				Convey("Should have no write access to any dashboard folders with default ACL", func() {
-					err := SearchDashboards(&query)
+					err := SearchDashboards(context.Background(), &query)
 					So(err, ShouldBeNil)
 
 					So(len(query.Result), ShouldEqual, 0)
@@ -421,7 +422,7 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 					})
 					So(err, ShouldBeNil)
 
-					err = SearchDashboards(&query)
+					err = SearchDashboards(context.Background(), &query)
 					So(err, ShouldBeNil)
 
 					So(len(query.Result), ShouldEqual, 1)
@@ -215,7 +215,7 @@ func TestDashboardDataAccess(t *testing.T) {
 					SignedInUser: &models.SignedInUser{},
 				}
 
-				err = SearchDashboards(&query)
+				err = SearchDashboards(context.Background(), &query)
 				So(err, ShouldBeNil)
 
 				So(len(query.Result), ShouldEqual, 0)
@@ -266,7 +266,7 @@ func TestDashboardDataAccess(t *testing.T) {
 					SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR},
 				}
 
-				err := SearchDashboards(&query)
+				err := SearchDashboards(context.Background(), &query)
 				So(err, ShouldBeNil)
 
 				So(len(query.Result), ShouldEqual, 1)
@@ -283,7 +283,7 @@ func TestDashboardDataAccess(t *testing.T) {
 					SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR},
 				}
 
-				err := SearchDashboards(&query)
+				err := SearchDashboards(context.Background(), &query)
 				So(err, ShouldBeNil)
 
 				So(len(query.Result), ShouldEqual, 1)
@@ -298,7 +298,7 @@ func TestDashboardDataAccess(t *testing.T) {
 					SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR},
 				}
 
-				err := SearchDashboards(&query)
+				err := SearchDashboards(context.Background(), &query)
 				So(err, ShouldBeNil)
 
 				So(len(query.Result), ShouldEqual, 1)
@@ -313,7 +313,7 @@ func TestDashboardDataAccess(t *testing.T) {
 					SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR},
 				}
 
-				err := SearchDashboards(&query)
+				err := SearchDashboards(context.Background(), &query)
 				So(err, ShouldBeNil)
 
 				So(len(query.Result), ShouldEqual, 3)
@@ -327,7 +327,7 @@ func TestDashboardDataAccess(t *testing.T) {
 					SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR},
 				}
 
-				err := SearchDashboards(&query)
+				err := SearchDashboards(context.Background(), &query)
 				So(err, ShouldBeNil)
 
 				So(len(query.Result), ShouldEqual, 2)
@@ -347,7 +347,7 @@ func TestDashboardDataAccess(t *testing.T) {
 						SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR},
 					}
 
-					err := SearchDashboards(&query)
+					err := SearchDashboards(context.Background(), &query)
 					So(err, ShouldBeNil)
 
 					So(len(query.Result), ShouldEqual, 2)
@@ -379,7 +379,7 @@ func TestDashboardDataAccess(t *testing.T) {
 						SignedInUser: &models.SignedInUser{UserId: 10, OrgId: 1, OrgRole: models.ROLE_EDITOR},
 						IsStarred:    true,
 					}
-					err := SearchDashboards(&query)
+					err := SearchDashboards(context.Background(), &query)
 
 					So(err, ShouldBeNil)
 					So(len(query.Result), ShouldEqual, 1)
@@ -427,7 +427,7 @@ func TestDashboard_SortingOptions(t *testing.T) {
 				searchstore.TitleSorter{Descending: true},
 			},
 		}
-		dashboards, err := findDashboards(q)
+		dashboards, err := findDashboards(context.Background(), q)
 		require.NoError(t, err)
 
 		require.Len(t, dashboards, 2)
@@ -0,0 +1,49 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GetJWTURLSession returns the expiry of a session created from a JWT in the URL.
+func (ss *SQLStore) GetJWTURLSession(ctx context.Context, tokenID int64) (*models.JWTURLSession, error) {
+	var session models.JWTURLSession
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		exists, err := sess.Where("token_id=?", tokenID).Get(&session)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return models.ErrJWTURLSessionNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// CreateJWTURLSession stores the expiry of a session created from a JWT in the URL, and removes
+// the expiries of the sessions that no longer exist.
+func (ss *SQLStore) CreateJWTURLSession(ctx context.Context, tokenID int64, expiresAt int64) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		sql := "DELETE FROM jwt_url_session WHERE token_id NOT IN (SELECT id FROM user_auth_token)"
+		if _, err := sess.Exec(sql); err != nil {
+			return err
+		}
+
+		_, err := sess.Insert(&models.JWTURLSession{TokenId: tokenID, ExpiresAt: expiresAt})
+		return err
+	})
+}
+
+// DeleteJWTURLSession removes the expiry of a session created from a JWT in the URL.
+func (ss *SQLStore) DeleteJWTURLSession(ctx context.Context, tokenID int64) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Exec("DELETE FROM jwt_url_session WHERE token_id=?", tokenID)
+		return err
+	})
+}
@@ -0,0 +1,43 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTURLSessionDataAccess(t *testing.T) {
+	ss := InitTestDB(t)
+	ctx := context.Background()
+
+	t.Run("Should return not found for other sessions", func(t *testing.T) {
+		_, err := ss.GetJWTURLSession(ctx, 1)
+		require.Equal(t, models.ErrJWTURLSessionNotFound, err)
+	})
+
+	t.Run("Should create and delete", func(t *testing.T) {
+		require.NoError(t, ss.CreateJWTURLSession(ctx, 1, 100))
+
+		session, err := ss.GetJWTURLSession(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), session.ExpiresAt)
+
+		require.NoError(t, ss.DeleteJWTURLSession(ctx, 1))
+		_, err = ss.GetJWTURLSession(ctx, 1)
+		require.Equal(t, models.ErrJWTURLSessionNotFound, err)
+	})
+
+	t.Run("Should remove the expiries of the sessions that no longer exist", func(t *testing.T) {
+		require.NoError(t, ss.CreateJWTURLSession(ctx, 1, 100))
+		require.NoError(t, ss.CreateJWTURLSession(ctx, 2, 100))
+
+		_, err := ss.GetJWTURLSession(ctx, 1)
+		require.Equal(t, models.ErrJWTURLSessionNotFound, err)
+		_, err = ss.GetJWTURLSession(ctx, 2)
+		require.NoError(t, err)
+	})
+}
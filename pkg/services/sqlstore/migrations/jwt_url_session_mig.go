@@ -0,0 +1,23 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addJWTURLSessionMigrations(mg *Migrator) {
+	jwtURLSessionV1 := Table{
+		Name: "jwt_url_session",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "token_id", Type: DB_BigInt, Nullable: false},
+			{Name: "expires_at", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"token_id"}, Type: UniqueIndex},
+			{Cols: []string{"expires_at"}},
+		},
+	}
+
+	mg.AddMigration("create jwt_url_session table", NewAddTableMigration(jwtURLSessionV1))
+
+	//-------  indexes ------------------
+	addTableIndicesMigrations(mg, "v1", jwtURLSessionV1)
+}
@@ -35,6 +35,11 @@ func AddMigrations(mg *Migrator) {
 	addUserAuthTokenMigrations(mg)
 	addCacheMigration(mg)
 	addShortURLMigrations(mg)
+	addServiceAccountsMigrations(mg)
+	addTeamGroupMigrations(mg)
+	addUserTOTPMigrations(mg)
+	addAuditMigrations(mg)
+	addJWTURLSessionMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
@@ -28,4 +28,18 @@ func addQuotaMigration(mg *Migrator) {
 	mg.AddMigration("Update quota table charset", NewTableCharsetMigration("quota", []*Column{
 		{Name: "target", Type: DB_NVarchar, Length: 190, Nullable: false},
 	}))
+
+	mg.AddMigration("Add column folder_id to quota table", NewAddColumnMigration(quotaV1, &Column{
+		Name: "folder_id", Type: DB_BigInt, Nullable: true, Default: "0",
+	}))
+	mg.AddMigration("Add column team_id to quota table", NewAddColumnMigration(quotaV1, &Column{
+		Name: "team_id", Type: DB_BigInt, Nullable: true, Default: "0",
+	}))
+
+	mg.AddMigration("Remove unique index org_id_user_id_target from quota table", NewDropIndexMigration(quotaV1, &Index{
+		Cols: []string{"org_id", "user_id", "target"}, Type: UniqueIndex,
+	}))
+	mg.AddMigration("Add unique index org_id_user_id_folder_id_team_id_target to quota table", NewAddIndexMigration(quotaV1, &Index{
+		Cols: []string{"org_id", "user_id", "folder_id", "team_id", "target"}, Type: UniqueIndex,
+	}))
 }
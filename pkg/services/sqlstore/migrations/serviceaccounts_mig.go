@@ -0,0 +1,145 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/util"
+	"xorm.io/xorm"
+)
+
+func addServiceAccountsMigrations(mg *Migrator) {
+	mg.AddMigration("Add is_service_account column to user", NewAddColumnMigration(Table{Name: "user"}, &Column{
+		Name: "is_service_account", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+
+	apiKeyV2 := Table{Name: "api_key"}
+	mg.AddMigration("Add service_account_id column to api_key", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "service_account_id", Type: DB_BigInt, Nullable: true,
+	}))
+	mg.AddMigration("Add index api_key.service_account_id", NewAddIndexMigration(apiKeyV2, &Index{
+		Cols: []string{"service_account_id"},
+	}))
+
+	mg.AddMigration("Convert API keys to service accounts", &ConvertAPIKeysToServiceAccountsMigration{})
+}
+
+// ConvertAPIKeysToServiceAccountsMigration creates a service account for every API key, with the
+// name and role of the key, and makes the key a token of the service account.
+type ConvertAPIKeysToServiceAccountsMigration struct {
+	MigrationBase
+}
+
+func (m *ConvertAPIKeysToServiceAccountsMigration) SQL(dialect Dialect) string {
+	return "code migration"
+}
+
+type legacyAPIKey struct {
+	Id    int64
+	OrgId int64
+	Name  string
+	Role  string
+}
+
+type serviceAccountUser struct {
+	Id               int64
+	Version          int
+	Email            string
+	Name             string
+	Login            string
+	Password         string
+	Salt             string
+	Rands            string
+	Company          string
+	OrgId            int64
+	IsAdmin          bool
+	EmailVerified    bool
+	Theme            string
+	HelpFlags1       int64
+	IsDisabled       bool
+	IsServiceAccount bool
+	Created          time.Time
+	Updated          time.Time
+	LastSeenAt       time.Time
+}
+
+type serviceAccountOrgUser struct {
+	Id      int64
+	OrgId   int64
+	UserId  int64
+	Role    string
+	Created time.Time
+	Updated time.Time
+}
+
+func (m *ConvertAPIKeysToServiceAccountsMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	keys := make([]*legacyAPIKey, 0)
+	if err := sess.SQL("SELECT id, org_id, name, role FROM api_key WHERE service_account_id IS NULL").Find(&keys); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		login, err := serviceAccountLogin(sess, mg, key)
+		if err != nil {
+			return err
+		}
+		salt, err := util.GetRandomString(10)
+		if err != nil {
+			return err
+		}
+		rands, err := util.GetRandomString(10)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		user := serviceAccountUser{
+			Email:            login,
+			Name:             key.Name,
+			Login:            login,
+			Salt:             salt,
+			Rands:            rands,
+			OrgId:            key.OrgId,
+			IsServiceAccount: true,
+			Created:          now,
+			Updated:          now,
+			LastSeenAt:       now.AddDate(-10, 0, 0),
+		}
+		if _, err := sess.Table("user").Insert(&user); err != nil {
+			return err
+		}
+
+		if _, err := sess.Table("org_user").Insert(&serviceAccountOrgUser{
+			OrgId:   key.OrgId,
+			UserId:  user.Id,
+			Role:    key.Role,
+			Created: now,
+			Updated: now,
+		}); err != nil {
+			return err
+		}
+
+		if _, err := sess.Exec("UPDATE api_key SET service_account_id = ? WHERE id = ?", user.Id, key.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serviceAccountLogin returns a login for the service account of key that isn't taken yet.
+func serviceAccountLogin(sess *xorm.Session, mg *Migrator, key *legacyAPIKey) (string, error) {
+	login := fmt.Sprintf("%s%d-%s", models.ServiceAccountLoginPrefix, key.OrgId, models.SlugifyTitle(key.Name))
+
+	var count int64
+	if _, err := sess.SQL("SELECT COUNT(*) FROM "+mg.Dialect.Quote("user")+" WHERE login = ? OR email = ?", login, login).
+		Get(&count); err != nil {
+		return "", err
+	}
+	if count > 0 {
+		login = fmt.Sprintf("%s-%d", login, key.Id)
+	}
+	return login, nil
+}
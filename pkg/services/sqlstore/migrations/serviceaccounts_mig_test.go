@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func TestConvertAPIKeysToServiceAccountsMigration(t *testing.T) {
+	testDB := sqlutil.SQLite3TestDB()
+	x, err := xorm.NewEngine(testDB.DriverName, testDB.ConnStr)
+	require.NoError(t, err)
+	require.NoError(t, NewDialect(x).CleanDB())
+
+	mg := NewMigrator(x)
+	AddMigrations(mg)
+	require.NoError(t, mg.Start())
+
+	now := time.Now()
+	for _, name := range []string{"Deploy bot", "deploy-bot"} {
+		_, err = x.Exec("INSERT INTO api_key (org_id, name, `key`, role, created, updated) VALUES (?, ?, ?, ?, ?, ?)",
+			1, name, "hashed-"+name, "Editor", now, now)
+		require.NoError(t, err)
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	require.NoError(t, (&ConvertAPIKeysToServiceAccountsMigration{}).Exec(sess, mg))
+
+	type result struct {
+		Name             string
+		Login            string
+		Role             string
+		IsServiceAccount bool
+	}
+	results := make([]*result, 0)
+	err = x.SQL(`SELECT api_key.name, u.login, org_user.role, u.is_service_account
+		FROM api_key
+		INNER JOIN user u ON u.id = api_key.service_account_id
+		INNER JOIN org_user ON org_user.user_id = u.id AND org_user.org_id = api_key.org_id
+		ORDER BY api_key.id`).Find(&results)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.Equal(t, "Deploy bot", results[0].Name)
+	require.Equal(t, "sa-1-deploy-bot", results[0].Login)
+	require.Equal(t, "Editor", results[0].Role)
+	require.True(t, results[0].IsServiceAccount)
+
+	// the second key slugifies to the same login
+	require.Equal(t, "deploy-bot", results[1].Name)
+	require.Equal(t, "sa-1-deploy-bot-2", results[1].Login)
+
+	// converted keys are skipped when the migration runs again
+	require.NoError(t, (&ConvertAPIKeysToServiceAccountsMigration{}).Exec(sess, mg))
+	count, err := x.Table("user").Where("is_service_account = ?", true).Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addUserTOTPMigrations(mg *Migrator) {
+	userTOTPV1 := Table{
+		Name: "user_totp",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "secret", Type: DB_Text, Nullable: false},
+			{Name: "recovery_codes", Type: DB_Text, Nullable: false},
+			{Name: "enabled", Type: DB_Bool, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create user totp table", NewAddTableMigration(userTOTPV1))
+
+	//-------  indexes ------------------
+	mg.AddMigration("add unique index user_totp.user_id", NewAddIndexMigration(userTOTPV1, userTOTPV1.Indices[0]))
+
+	// last_used_step is the time step of the last accepted code, so that a code can't be used twice.
+	mg.AddMigration("Add last_used_step column to user_totp", NewAddColumnMigration(userTOTPV1, &Column{
+		Name: "last_used_step", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+}
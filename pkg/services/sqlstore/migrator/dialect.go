@@ -34,6 +34,11 @@ type Dialect interface {
 
 	IndexCheckSQL(tableName, indexName string) (string, []interface{})
 	ColumnCheckSQL(tableName, columnName string) (string, []interface{})
+	// TableNamesSQL returns the sql statement listing the names of the tables of the database
+	TableNamesSQL() string
+	// ResetSequenceSQL returns the sql statement setting the sequence of an auto increment column
+	// after its highest value, or an empty string if the database keeps it in sync by itself
+	ResetSequenceSQL(tableName, columnName string) string
 	// UpsertSQL returns the upsert sql statement for a dialect
 	UpsertSQL(tableName string, keyCols, updateCols []string) string
 
@@ -264,6 +269,10 @@ func (b *BaseDialect) LimitOffset(limit int64, offset int64) string {
 	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
 }
 
+func (b *BaseDialect) ResetSequenceSQL(tableName, columnName string) string {
+	return ""
+}
+
 func (b *BaseDialect) PreInsertId(table string, sess *xorm.Session) error {
 	return nil
 }
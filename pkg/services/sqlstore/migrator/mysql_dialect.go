@@ -116,6 +116,10 @@ func (db *MySQLDialect) ColumnCheckSQL(tableName, columnName string) (string, []
 	return sql, args
 }
 
+func (db *MySQLDialect) TableNamesSQL() string {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'"
+}
+
 func (db *MySQLDialect) CleanDB() error {
 	tables, err := db.engine.DBMetas()
 	if err != nil {
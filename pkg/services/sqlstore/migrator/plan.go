@@ -0,0 +1,206 @@
+package migrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// largeTableRows is the number of rows from which a migration working on the existing rows of a
+// table is reported as long-running by Plan.
+var largeTableRows int64 = 100000
+
+// MigrationStatus is the state of a migration in the migration log.
+type MigrationStatus struct {
+	ID       string
+	Executed bool
+	// Timestamp is the time of the successful execution, or of the last failed attempt.
+	Timestamp time.Time
+	// Error is the error of the last failed attempt of a pending migration.
+	Error string
+}
+
+// PlannedMigration is a pending migration with the SQL it would execute.
+type PlannedMigration struct {
+	ID string
+	// SQL is the statement of the migration. Code migrations have no statement, since their
+	// statements depend on the data.
+	SQL  string
+	Code bool
+	// ConditionSQL is the query checked before executing the migration, with its arguments.
+	ConditionSQL  string
+	ConditionArgs []interface{}
+	// Warnings are the long-running operations of the migration.
+	Warnings []string
+}
+
+// Status returns the state of every migration, in the order they are executed.
+func (mg *Migrator) Status() ([]MigrationStatus, error) {
+	logItems := make([]MigrationLog, 0)
+	exists, err := mg.x.IsTableExist(new(MigrationLog))
+	if err != nil {
+		return nil, errutil.Wrap("failed to check table existence", err)
+	}
+	if exists {
+		if err := mg.x.Asc("id").Find(&logItems); err != nil {
+			return nil, err
+		}
+	}
+
+	logMap := make(map[string]MigrationLog, len(logItems))
+	for _, logItem := range logItems {
+		// a successful execution is kept over the failed attempts
+		if previous, ok := logMap[logItem.MigrationID]; ok && previous.Success {
+			continue
+		}
+		logMap[logItem.MigrationID] = logItem
+	}
+
+	statuses := make([]MigrationStatus, 0, len(mg.migrations))
+	for _, m := range mg.migrations {
+		status := MigrationStatus{ID: m.Id()}
+		if logItem, ok := logMap[m.Id()]; ok {
+			status.Executed = logItem.Success
+			status.Timestamp = logItem.Timestamp
+			status.Error = logItem.Error
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Plan returns the pending migrations with the SQL they would execute with the dialect of the
+// migrator, without executing anything.
+func (mg *Migrator) Plan() ([]PlannedMigration, error) {
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := &plannedRowCounts{mg: mg, counts: make(map[string]int64)}
+	planned := make([]PlannedMigration, 0)
+	for _, m := range mg.migrations {
+		if _, exists := logMap[m.Id()]; exists {
+			continue
+		}
+
+		p := PlannedMigration{ID: m.Id()}
+		if _, ok := m.(CodeMigration); ok {
+			p.Code = true
+		} else {
+			p.SQL = m.SQL(mg.Dialect)
+		}
+		if condition := m.GetCondition(); condition != nil {
+			p.ConditionSQL, p.ConditionArgs = condition.SQL(mg.Dialect)
+		}
+		if p.Warnings, err = rows.warnings(m); err != nil {
+			return nil, err
+		}
+
+		planned = append(planned, p)
+	}
+
+	return planned, nil
+}
+
+// plannedRowCounts estimates the number of rows of the tables while the migrations are planned,
+// following the tables created, renamed and dropped by the previous pending migrations.
+type plannedRowCounts struct {
+	mg     *Migrator
+	counts map[string]int64
+}
+
+func (r *plannedRowCounts) count(table string) (int64, error) {
+	if count, ok := r.counts[table]; ok {
+		return count, nil
+	}
+
+	exists, err := r.mg.x.IsTableExist(table)
+	if err != nil {
+		return 0, errutil.Wrapf(err, "failed to check existence of table %q", table)
+	}
+	var count int64
+	if exists {
+		if _, err := r.mg.x.SQL("SELECT COUNT(*) FROM " + r.mg.Dialect.Quote(table)).Get(&count); err != nil {
+			return 0, errutil.Wrapf(err, "failed to count the rows of table %q", table)
+		}
+	}
+	r.counts[table] = count
+	return count, nil
+}
+
+func (r *plannedRowCounts) warnings(m Migration) ([]string, error) {
+	var operation, table string
+	switch m := m.(type) {
+	case *AddTableMigration:
+		r.counts[m.table.Name] = 0
+		return nil, nil
+	case *DropTableMigration:
+		r.counts[m.tableName] = 0
+		return nil, nil
+	case *RenameTableMigration:
+		count, err := r.count(m.oldName)
+		if err != nil {
+			return nil, err
+		}
+		r.counts[m.newName] = count
+		r.counts[m.oldName] = 0
+		return nil, nil
+	case *CopyTableDataMigration:
+		count, err := r.count(m.sourceTable)
+		if err != nil {
+			return nil, err
+		}
+		r.counts[m.targetTable] += count
+		operation, table = "copies the rows of", m.sourceTable
+	case *AddIndexMigration:
+		operation, table = "builds an index on", m.tableName
+	case *TableCharsetMigration:
+		operation, table = "rewrites", m.tableName
+	case *AddColumnMigration:
+		if r.mg.Dialect.DriverName() != MySQL {
+			return nil, nil
+		}
+		// MySQL may rebuild the table to add a column
+		operation, table = "adds a column to", m.tableName
+	default:
+		return nil, nil
+	}
+
+	count, err := r.count(table)
+	if err != nil {
+		return nil, err
+	}
+	if count < largeTableRows {
+		return nil, nil
+	}
+	return []string{fmt.Sprintf("%s table %q with %d rows, which can take long", operation, table, count)}, nil
+}
+
+// FormatPlan renders planned migrations as an SQL script to review, with the migration IDs, the
+// conditions and the warnings as comments.
+func FormatPlan(planned []PlannedMigration) string {
+	var sb strings.Builder
+	for _, p := range planned {
+		sb.WriteString("-- Migration: " + p.ID + "\n")
+		for _, warning := range p.Warnings {
+			sb.WriteString("-- Warning: " + warning + "\n")
+		}
+		if p.ConditionSQL != "" {
+			sb.WriteString(fmt.Sprintf("-- Condition: %s %v\n", p.ConditionSQL, p.ConditionArgs))
+		}
+		if p.Code {
+			sb.WriteString("-- Code migration, its statements depend on the data\n\n")
+			continue
+		}
+		sql := strings.TrimSpace(p.SQL)
+		if !strings.HasSuffix(sql, ";") {
+			sql += ";"
+		}
+		sb.WriteString(sql + "\n\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package migrator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func TestPlan(t *testing.T) {
+	x, err := xorm.NewEngine("sqlite3", "file:"+filepath.Join(t.TempDir(), "plan.db")+"?cache=private&mode=rwc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = x.Close() })
+	require.NoError(t, x.Sync2(new(MigrationLog)))
+
+	origLargeTableRows := largeTableRows
+	largeTableRows = 3
+	t.Cleanup(func() { largeTableRows = origLargeTableRows })
+
+	tableV1 := Table{
+		Name: "plan_test",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+	}
+	mg := NewMigrator(x)
+	mg.AddMigration("create plan_test table", NewAddTableMigration(tableV1))
+	mg.AddMigration("fill plan_test table", NewRawSQLMigration("INSERT INTO plan_test (name) VALUES ('a'), ('b'), ('c')"))
+	require.NoError(t, mg.Start())
+
+	tableV2 := Table{
+		Name: "plan_test",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: DB_NVarchar, Length: 255, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"name"}},
+		},
+	}
+	mg = NewMigrator(x)
+	mg.AddMigration("create plan_test table", NewAddTableMigration(tableV1))
+	mg.AddMigration("fill plan_test table", NewRawSQLMigration("INSERT INTO plan_test (name) VALUES ('a'), ('b'), ('c')"))
+	mg.AddMigration("rename plan_test table to plan_test_tmp_qwerty", NewRenameTableMigration("plan_test", "plan_test_tmp_qwerty"))
+	mg.AddMigration("create plan_test table v2", NewAddTableMigration(tableV2))
+	mg.AddMigration("copy plan_test v1 to v2", NewCopyTableDataMigration("plan_test", "plan_test_tmp_qwerty", map[string]string{
+		"id":   "id",
+		"name": "name",
+	}))
+	mg.AddMigration("add index plan_test.name", NewAddIndexMigration(tableV2, tableV2.Indices[0]))
+	mg.AddMigration("drop plan_test_tmp_qwerty", NewDropTableMigration("plan_test_tmp_qwerty"))
+
+	t.Run("status lists executed and pending migrations", func(t *testing.T) {
+		statuses, err := mg.Status()
+		require.NoError(t, err)
+		require.Len(t, statuses, 7)
+		assert.Equal(t, "create plan_test table", statuses[0].ID)
+		assert.True(t, statuses[0].Executed)
+		assert.False(t, statuses[0].Timestamp.IsZero())
+		assert.True(t, statuses[1].Executed)
+		for _, status := range statuses[2:] {
+			assert.False(t, status.Executed, status.ID)
+			assert.Empty(t, status.Error, status.ID)
+		}
+	})
+
+	t.Run("plan renders the pending migrations with warnings", func(t *testing.T) {
+		planned, err := mg.Plan()
+		require.NoError(t, err)
+		require.Len(t, planned, 5)
+
+		assert.Equal(t, "rename plan_test table to plan_test_tmp_qwerty", planned[0].ID)
+		assert.Equal(t, "ALTER TABLE `plan_test` RENAME TO `plan_test_tmp_qwerty`", planned[0].SQL)
+		assert.Empty(t, planned[0].Warnings)
+
+		assert.Equal(t, "copy plan_test v1 to v2", planned[2].ID)
+		assert.Contains(t, planned[2].SQL, "INSERT INTO `plan_test`")
+		assert.Equal(t, []string{`copies the rows of table "plan_test_tmp_qwerty" with 3 rows, which can take long`}, planned[2].Warnings)
+
+		// the copied rows are counted for the table created by the pending migrations
+		assert.Equal(t, []string{`builds an index on table "plan_test" with 3 rows, which can take long`}, planned[3].Warnings)
+		assert.Empty(t, planned[4].Warnings)
+
+		script := FormatPlan(planned)
+		assert.Contains(t, script, "-- Migration: copy plan_test v1 to v2\n-- Warning: copies the rows")
+		assert.Contains(t, script, "ALTER TABLE `plan_test` RENAME TO `plan_test_tmp_qwerty`;\n")
+	})
+
+	t.Run("plan doesn't execute anything", func(t *testing.T) {
+		exists, err := x.IsTableExist("plan_test_tmp_qwerty")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		planned, err := mg.Plan()
+		require.NoError(t, err)
+		assert.Len(t, planned, 5)
+	})
+}
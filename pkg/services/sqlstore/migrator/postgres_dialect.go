@@ -110,6 +110,15 @@ func (db *PostgresDialect) IndexCheckSQL(tableName, indexName string) (string, [
 	return sql, args
 }
 
+func (db *PostgresDialect) TableNamesSQL() string {
+	return "SELECT tablename FROM pg_tables WHERE schemaname = current_schema()"
+}
+
+func (db *PostgresDialect) ResetSequenceSQL(tableName, columnName string) string {
+	return fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+		db.Quote(tableName), columnName, db.Quote(columnName), db.Quote(tableName))
+}
+
 func (db *PostgresDialect) DropIndexSQL(tableName string, index *Index) string {
 	quote := db.Quote
 	idxName := index.XName(tableName)
@@ -77,6 +77,10 @@ func (db *SQLite3) IndexCheckSQL(tableName, indexName string) (string, []interfa
 	return sql, args
 }
 
+func (db *SQLite3) TableNamesSQL() string {
+	return "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'"
+}
+
 func (db *SQLite3) DropIndexSQL(tableName string, index *Index) string {
 	quote := db.Quote
 	// var unique string
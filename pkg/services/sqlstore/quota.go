@@ -2,6 +2,7 @@ package sqlstore
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -17,18 +18,135 @@ func init() {
 	bus.AddHandler("sql", GetUserQuotas)
 	bus.AddHandler("sql", UpdateUserQuota)
 	bus.AddHandler("sql", GetGlobalQuotaByTarget)
+	bus.AddHandler("sql", GetFolderQuotaByTarget)
+	bus.AddHandler("sql", GetFolderQuotas)
+	bus.AddHandler("sql", UpdateFolderQuota)
+	bus.AddHandler("sql", GetTeamQuotaByTarget)
+	bus.AddHandler("sql", GetTeamQuotas)
+	bus.AddHandler("sql", UpdateTeamQuota)
+	bus.AddHandler("sql", GetQuotaUsage)
 }
 
 type targetCount struct {
 	Count int64
 }
 
+// quotaUsage describes how the usage of a quota target is measured. Targets without
+// a quotaUsage count the rows of the table with the same name.
+type quotaUsage struct {
+	table string
+	// expr is the aggregate measuring the usage, COUNT(*) if empty.
+	expr string
+	// daily restricts the usage to the rows created since the start of the current day (UTC).
+	daily bool
+	// folderFilter restricts the usage to a folder ID, empty if the target can't be limited per folder.
+	folderFilter string
+	// creatorColumn is the column referencing the creator of a row, empty if the target can't be limited per team.
+	creatorColumn string
+	// optional is set when the table only exists if a feature is enabled.
+	optional bool
+}
+
+var quotaUsages = map[string]quotaUsage{
+	"dashboard": {
+		table:         "dashboard",
+		folderFilter:  "folder_id = ?",
+		creatorColumn: "created_by",
+	},
+	"dashboard_bytes": {
+		table:         "dashboard",
+		expr:          "COALESCE(SUM(LENGTH(data)), 0)",
+		folderFilter:  "folder_id = ?",
+		creatorColumn: "created_by",
+	},
+	"alert_rule": {
+		table:        "alert_rule",
+		folderFilter: "namespace_uid IN (SELECT uid FROM dashboard WHERE id = ?)",
+		optional:     true,
+	},
+	"library_panel": {
+		table:         "library_panel",
+		folderFilter:  "folder_id = ?",
+		creatorColumn: "created_by",
+		optional:      true,
+	},
+	"dashboard_snapshot": {
+		table:         "dashboard_snapshot",
+		creatorColumn: "user_id",
+	},
+	"annotation_per_day": {
+		table:         "annotation",
+		daily:         true,
+		creatorColumn: "user_id",
+	},
+}
+
+// getQuotaUsed returns the usage of target among the rows matching filter.
+func getQuotaUsed(target string, filter string, args ...interface{}) (int64, error) {
+	usage, ok := quotaUsages[target]
+	if !ok {
+		usage = quotaUsage{table: target}
+	}
+	if usage.optional {
+		exists, err := x.IsTableExist(usage.table)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			return 0, nil
+		}
+	}
+
+	expr := usage.expr
+	if expr == "" {
+		expr = "COUNT(*)"
+	}
+
+	var conditions []string
+	if filter != "" {
+		conditions = append(conditions, filter)
+	}
+	if usage.daily {
+		now := time.Now().UTC()
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		conditions = append(conditions, "created >= ?")
+		args = append(args, startOfDay.UnixNano()/int64(time.Millisecond))
+	}
+
+	rawSQL := fmt.Sprintf("SELECT %s as count from %s", expr, dialect.Quote(usage.table))
+	if len(conditions) > 0 {
+		rawSQL += " where " + strings.Join(conditions, " AND ")
+	}
+	resp := make([]*targetCount, 0)
+	if err := x.SQL(rawSQL, args...).Find(&resp); err != nil {
+		return 0, err
+	}
+	return resp[0].Count, nil
+}
+
+func getFolderQuotaUsed(target string, orgID, folderID int64) (int64, error) {
+	usage, ok := quotaUsages[target]
+	if !ok || usage.folderFilter == "" {
+		return 0, models.ErrInvalidQuotaTarget
+	}
+	return getQuotaUsed(target, "org_id=? AND "+usage.folderFilter, orgID, folderID)
+}
+
+func getTeamQuotaUsed(target string, orgID, teamID int64) (int64, error) {
+	usage, ok := quotaUsages[target]
+	if !ok || usage.creatorColumn == "" {
+		return 0, models.ErrInvalidQuotaTarget
+	}
+	filter := fmt.Sprintf("org_id=? AND %s IN (SELECT user_id FROM team_member WHERE team_id=?)", usage.creatorColumn)
+	return getQuotaUsed(target, filter, orgID, teamID)
+}
+
 func GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
 	quota := models.Quota{
 		Target: query.Target,
 		OrgId:  query.OrgId,
 	}
-	has, err := x.Get(&quota)
+	has, err := x.Where("folder_id=0 AND team_id=0").Get(&quota)
 	if err != nil {
 		return err
 	} else if !has {
@@ -36,9 +154,8 @@ func GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
 	}
 
 	// get quota used.
-	rawSQL := fmt.Sprintf("SELECT COUNT(*) as count from %s where org_id=?", dialect.Quote(query.Target))
-	resp := make([]*targetCount, 0)
-	if err := x.SQL(rawSQL, query.OrgId).Find(&resp); err != nil {
+	used, err := getQuotaUsed(query.Target, "org_id=?", query.OrgId)
+	if err != nil {
 		return err
 	}
 
@@ -46,7 +163,7 @@ func GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
 		Target: query.Target,
 		Limit:  quota.Limit,
 		OrgId:  query.OrgId,
-		Used:   resp[0].Count,
+		Used:   used,
 	}
 
 	return nil
@@ -55,7 +172,7 @@ func GetOrgQuotaByTarget(query *models.GetOrgQuotaByTargetQuery) error {
 func GetOrgQuotas(query *models.GetOrgQuotasQuery) error {
 	quotas := make([]*models.Quota, 0)
 	sess := x.Table("quota")
-	if err := sess.Where("org_id=? AND user_id=0", query.OrgId).Find(&quotas); err != nil {
+	if err := sess.Where("org_id=? AND user_id=0 AND folder_id=0 AND team_id=0", query.OrgId).Find(&quotas); err != nil {
 		return err
 	}
 
@@ -79,16 +196,15 @@ func GetOrgQuotas(query *models.GetOrgQuotasQuery) error {
 	result := make([]*models.OrgQuotaDTO, len(quotas))
 	for i, q := range quotas {
 		// get quota used.
-		rawSQL := fmt.Sprintf("SELECT COUNT(*) as count from %s where org_id=?", dialect.Quote(q.Target))
-		resp := make([]*targetCount, 0)
-		if err := x.SQL(rawSQL, q.OrgId).Find(&resp); err != nil {
+		used, err := getQuotaUsed(q.Target, "org_id=?", q.OrgId)
+		if err != nil {
 			return err
 		}
 		result[i] = &models.OrgQuotaDTO{
 			Target: q.Target,
 			Limit:  q.Limit,
 			OrgId:  q.OrgId,
-			Used:   resp[0].Count,
+			Used:   used,
 		}
 	}
 	query.Result = result
@@ -102,7 +218,7 @@ func UpdateOrgQuota(cmd *models.UpdateOrgQuotaCmd) error {
 			Target: cmd.Target,
 			OrgId:  cmd.OrgId,
 		}
-		has, err := sess.Get(&quota)
+		has, err := sess.Where("folder_id=0 AND team_id=0").Get(&quota)
 		if err != nil {
 			return err
 		}
@@ -231,17 +347,250 @@ func UpdateUserQuota(cmd *models.UpdateUserQuotaCmd) error {
 
 func GetGlobalQuotaByTarget(query *models.GetGlobalQuotaByTargetQuery) error {
 	// get quota used.
-	rawSQL := fmt.Sprintf("SELECT COUNT(*) as count from %s", dialect.Quote(query.Target))
-	resp := make([]*targetCount, 0)
-	if err := x.SQL(rawSQL).Find(&resp); err != nil {
+	used, err := getQuotaUsed(query.Target, "")
+	if err != nil {
 		return err
 	}
 
 	query.Result = &models.GlobalQuotaDTO{
 		Target: query.Target,
 		Limit:  query.Default,
-		Used:   resp[0].Count,
+		Used:   used,
 	}
 
 	return nil
 }
+
+func GetFolderQuotaByTarget(query *models.GetFolderQuotaByTargetQuery) error {
+	quota := models.Quota{
+		Target:   query.Target,
+		OrgId:    query.OrgId,
+		FolderId: query.FolderId,
+	}
+	has, err := x.Where("team_id=0").Get(&quota)
+	if err != nil {
+		return err
+	} else if !has {
+		quota.Limit = query.Default
+	}
+
+	used, err := getFolderQuotaUsed(query.Target, query.OrgId, query.FolderId)
+	if err != nil {
+		return err
+	}
+
+	query.Result = &models.FolderQuotaDTO{
+		Target:   query.Target,
+		Limit:    quota.Limit,
+		OrgId:    query.OrgId,
+		FolderId: query.FolderId,
+		Used:     used,
+	}
+
+	return nil
+}
+
+func GetFolderQuotas(query *models.GetFolderQuotasQuery) error {
+	quotas := make([]*models.Quota, 0)
+	sess := x.Table("quota")
+	if err := sess.Where("org_id=? AND folder_id=? AND team_id=0", query.OrgId, query.FolderId).Find(&quotas); err != nil {
+		return err
+	}
+
+	seenTargets := make(map[string]bool)
+	for _, q := range quotas {
+		seenTargets[q.Target] = true
+	}
+
+	for t, v := range setting.Quota.Folder.ToMap() {
+		if _, ok := seenTargets[t]; !ok {
+			quotas = append(quotas, &models.Quota{
+				OrgId:    query.OrgId,
+				FolderId: query.FolderId,
+				Target:   t,
+				Limit:    v,
+			})
+		}
+	}
+
+	result, err := toFolderQuotaDTOs(quotas)
+	if err != nil {
+		return err
+	}
+	query.Result = result
+	return nil
+}
+
+func UpdateFolderQuota(cmd *models.UpdateFolderQuotaCmd) error {
+	return inTransaction(func(sess *DBSession) error {
+		quota := models.Quota{
+			Target:   cmd.Target,
+			OrgId:    cmd.OrgId,
+			FolderId: cmd.FolderId,
+		}
+		has, err := sess.Where("team_id=0").Get(&quota)
+		if err != nil {
+			return err
+		}
+		return upsertQuota(sess, &quota, has, cmd.Limit)
+	})
+}
+
+func GetTeamQuotaByTarget(query *models.GetTeamQuotaByTargetQuery) error {
+	quota := models.Quota{
+		Target: query.Target,
+		OrgId:  query.OrgId,
+		TeamId: query.TeamId,
+	}
+	has, err := x.Where("folder_id=0").Get(&quota)
+	if err != nil {
+		return err
+	} else if !has {
+		quota.Limit = query.Default
+	}
+
+	used, err := getTeamQuotaUsed(query.Target, query.OrgId, query.TeamId)
+	if err != nil {
+		return err
+	}
+
+	query.Result = &models.TeamQuotaDTO{
+		Target: query.Target,
+		Limit:  quota.Limit,
+		OrgId:  query.OrgId,
+		TeamId: query.TeamId,
+		Used:   used,
+	}
+
+	return nil
+}
+
+func GetTeamQuotas(query *models.GetTeamQuotasQuery) error {
+	quotas := make([]*models.Quota, 0)
+	sess := x.Table("quota")
+	if err := sess.Where("org_id=? AND team_id=? AND folder_id=0", query.OrgId, query.TeamId).Find(&quotas); err != nil {
+		return err
+	}
+
+	seenTargets := make(map[string]bool)
+	for _, q := range quotas {
+		seenTargets[q.Target] = true
+	}
+
+	for t, v := range setting.Quota.Team.ToMap() {
+		if _, ok := seenTargets[t]; !ok {
+			quotas = append(quotas, &models.Quota{
+				OrgId:  query.OrgId,
+				TeamId: query.TeamId,
+				Target: t,
+				Limit:  v,
+			})
+		}
+	}
+
+	result, err := toTeamQuotaDTOs(quotas)
+	if err != nil {
+		return err
+	}
+	query.Result = result
+	return nil
+}
+
+func UpdateTeamQuota(cmd *models.UpdateTeamQuotaCmd) error {
+	return inTransaction(func(sess *DBSession) error {
+		quota := models.Quota{
+			Target: cmd.Target,
+			OrgId:  cmd.OrgId,
+			TeamId: cmd.TeamId,
+		}
+		has, err := sess.Where("folder_id=0").Get(&quota)
+		if err != nil {
+			return err
+		}
+		return upsertQuota(sess, &quota, has, cmd.Limit)
+	})
+}
+
+// GetQuotaUsage reports the usage of an org against its quotas, along with the usage of the
+// folders and teams that have their own quotas.
+func GetQuotaUsage(query *models.GetQuotaUsageQuery) error {
+	orgQuery := models.GetOrgQuotasQuery{OrgId: query.OrgId}
+	if err := GetOrgQuotas(&orgQuery); err != nil {
+		return err
+	}
+
+	folderQuotas := make([]*models.Quota, 0)
+	if err := x.Table("quota").Where("org_id=? AND folder_id<>0", query.OrgId).Asc("folder_id", "target").Find(&folderQuotas); err != nil {
+		return err
+	}
+	folders, err := toFolderQuotaDTOs(folderQuotas)
+	if err != nil {
+		return err
+	}
+
+	teamQuotas := make([]*models.Quota, 0)
+	if err := x.Table("quota").Where("org_id=? AND team_id<>0", query.OrgId).Asc("team_id", "target").Find(&teamQuotas); err != nil {
+		return err
+	}
+	teams, err := toTeamQuotaDTOs(teamQuotas)
+	if err != nil {
+		return err
+	}
+
+	query.Result = &models.QuotaUsageDTO{
+		Org:     orgQuery.Result,
+		Folders: folders,
+		Teams:   teams,
+	}
+	return nil
+}
+
+func toFolderQuotaDTOs(quotas []*models.Quota) ([]*models.FolderQuotaDTO, error) {
+	result := make([]*models.FolderQuotaDTO, len(quotas))
+	for i, q := range quotas {
+		used, err := getFolderQuotaUsed(q.Target, q.OrgId, q.FolderId)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = &models.FolderQuotaDTO{
+			Target:   q.Target,
+			Limit:    q.Limit,
+			OrgId:    q.OrgId,
+			FolderId: q.FolderId,
+			Used:     used,
+		}
+	}
+	return result, nil
+}
+
+func toTeamQuotaDTOs(quotas []*models.Quota) ([]*models.TeamQuotaDTO, error) {
+	result := make([]*models.TeamQuotaDTO, len(quotas))
+	for i, q := range quotas {
+		used, err := getTeamQuotaUsed(q.Target, q.OrgId, q.TeamId)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = &models.TeamQuotaDTO{
+			Target: q.Target,
+			Limit:  q.Limit,
+			OrgId:  q.OrgId,
+			TeamId: q.TeamId,
+			Used:   used,
+		}
+	}
+	return result, nil
+}
+
+func upsertQuota(sess *DBSession, quota *models.Quota, has bool, limit int64) error {
+	quota.Updated = time.Now()
+	quota.Limit = limit
+	if !has {
+		quota.Created = time.Now()
+		// No quota in the DB for this target, so create a new one.
+		_, err := sess.Insert(quota)
+		return err
+	}
+	// update existing quota entry in the DB, the limit is set explicitly since it can be 0.
+	_, err := sess.ID(quota.Id).Cols("limit", "updated").Update(quota)
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
@@ -13,7 +14,7 @@ import (
 
 func TestQuotaCommandsAndQueries(t *testing.T) {
 	Convey("Testing Quota commands & queries", t, func() {
-		InitTestDB(t)
+		sqlStore := InitTestDB(t)
 		userId := int64(1)
 		orgId := int64(0)
 
@@ -24,10 +25,29 @@ func TestQuotaCommandsAndQueries(t *testing.T) {
 				Dashboard:  5,
 				DataSource: 5,
 				ApiKey:     5,
+
+				AlertRule:         5,
+				LibraryPanel:      5,
+				DashboardSnapshot: 5,
+				AnnotationPerDay:  5,
+				DashboardBytes:    5,
 			},
 			User: &setting.UserQuota{
 				Org: 5,
 			},
+			Folder: &setting.FolderQuota{
+				Dashboard:      5,
+				AlertRule:      5,
+				LibraryPanel:   5,
+				DashboardBytes: 5,
+			},
+			Team: &setting.TeamQuota{
+				Dashboard:         5,
+				LibraryPanel:      5,
+				DashboardSnapshot: 5,
+				AnnotationPerDay:  5,
+				DashboardBytes:    5,
+			},
 			Global: &setting.GlobalQuota{
 				Org:        5,
 				User:       5,
@@ -92,7 +112,7 @@ func TestQuotaCommandsAndQueries(t *testing.T) {
 				err = GetOrgQuotas(&query)
 
 				So(err, ShouldBeNil)
-				So(len(query.Result), ShouldEqual, 4)
+				So(len(query.Result), ShouldEqual, 9)
 				for _, res := range query.Result {
 					limit := 5 // default quota limit
 					used := 0
@@ -153,6 +173,80 @@ func TestQuotaCommandsAndQueries(t *testing.T) {
 			})
 		})
 
+		Convey("Given a folder with a dashboard created by a team member", func() {
+			folder := insertTestDashboard(t, sqlStore, "quota folder", orgId, 0, true)
+			dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+				OrgId:    orgId,
+				FolderId: folder.Id,
+				UserId:   userId,
+				Dashboard: simplejson.NewFromAny(map[string]interface{}{
+					"title": "quota dashboard",
+				}),
+			})
+			So(err, ShouldBeNil)
+			team, err := sqlStore.CreateTeam("quota team", "", orgId)
+			So(err, ShouldBeNil)
+			err = sqlStore.AddTeamMember(userId, orgId, team.Id, false, 0)
+			So(err, ShouldBeNil)
+
+			err = UpdateFolderQuota(&models.UpdateFolderQuotaCmd{OrgId: orgId, FolderId: folder.Id, Target: "dashboard", Limit: 10})
+			So(err, ShouldBeNil)
+			err = UpdateTeamQuota(&models.UpdateTeamQuotaCmd{OrgId: orgId, TeamId: team.Id, Target: "dashboard", Limit: 0})
+			So(err, ShouldBeNil)
+
+			Convey("Should be able to get saved quota by folder id and target", func() {
+				query := models.GetFolderQuotaByTargetQuery{OrgId: orgId, FolderId: folder.Id, Target: "dashboard", Default: 1}
+				err = GetFolderQuotaByTarget(&query)
+
+				So(err, ShouldBeNil)
+				So(query.Result.Limit, ShouldEqual, 10)
+				So(query.Result.Used, ShouldEqual, 1)
+			})
+			Convey("Should be able to get used dashboard bytes by folder", func() {
+				query := models.GetFolderQuotaByTargetQuery{OrgId: orgId, FolderId: folder.Id, Target: "dashboard_bytes", Default: -1}
+				err = GetFolderQuotaByTarget(&query)
+
+				So(err, ShouldBeNil)
+				So(query.Result.Limit, ShouldEqual, -1)
+				data, err := dash.Data.Encode()
+				So(err, ShouldBeNil)
+				So(query.Result.Used, ShouldEqual, len(data))
+			})
+			Convey("Should not be able to get folder quota for a target that can't be limited per folder", func() {
+				query := models.GetFolderQuotaByTargetQuery{OrgId: orgId, FolderId: folder.Id, Target: "dashboard_snapshot", Default: 1}
+				err = GetFolderQuotaByTarget(&query)
+
+				So(err, ShouldEqual, models.ErrInvalidQuotaTarget)
+			})
+			Convey("Should be able to get saved quota by team id and target", func() {
+				query := models.GetTeamQuotaByTargetQuery{OrgId: orgId, TeamId: team.Id, Target: "dashboard", Default: 1}
+				err = GetTeamQuotaByTarget(&query)
+
+				So(err, ShouldBeNil)
+				So(query.Result.Limit, ShouldEqual, 0)
+				So(query.Result.Used, ShouldEqual, 1)
+			})
+			Convey("Should not mix folder and team quotas with org quotas", func() {
+				query := models.GetOrgQuotaByTargetQuery{OrgId: orgId, Target: "dashboard", Default: 5}
+				err = GetOrgQuotaByTarget(&query)
+
+				So(err, ShouldBeNil)
+				So(query.Result.Limit, ShouldEqual, 5)
+				So(query.Result.Used, ShouldEqual, 2)
+			})
+			Convey("Should be able to get quota usage for org", func() {
+				query := models.GetQuotaUsageQuery{OrgId: orgId}
+				err = GetQuotaUsage(&query)
+
+				So(err, ShouldBeNil)
+				So(len(query.Result.Org), ShouldEqual, 9)
+				So(len(query.Result.Folders), ShouldEqual, 1)
+				So(query.Result.Folders[0].FolderId, ShouldEqual, folder.Id)
+				So(len(query.Result.Teams), ShouldEqual, 1)
+				So(query.Result.Teams[0].TeamId, ShouldEqual, team.Id)
+			})
+		})
+
 		Convey("Should be able to global user quota", func() {
 			query := models.GetGlobalQuotaByTargetQuery{Target: "user", Default: 5}
 			err = GetGlobalQuotaByTarget(&query)
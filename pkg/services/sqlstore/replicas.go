@@ -0,0 +1,106 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// replicas holds the read replicas of the SQLStore, temporarily still a global var like x.
+var replicas *readReplicas
+
+// readReplicas balances the read-only sessions between the read replicas of the database.
+type readReplicas struct {
+	engines []*xorm.Engine
+	next    uint64
+}
+
+// engine returns the engine a read-only session should use. It is the primary when there is no
+// replica, when the context is within a transaction or when a transaction has already been
+// committed with the context, so that a request reads its own writes.
+func (r *readReplicas) engine(ctx context.Context, primary *xorm.Engine) *xorm.Engine {
+	if r == nil || len(r.engines) == 0 {
+		return primary
+	}
+	if _, ok := ctx.Value(ContextSessionKey{}).(*DBSession); ok {
+		return primary
+	}
+	if hasWritten(ctx) {
+		return primary
+	}
+
+	next := atomic.AddUint64(&r.next, 1)
+	return r.engines[next%uint64(len(r.engines))]
+}
+
+// buildReplicaConnectionStrings returns the connection strings of the read replicas. A replica uses
+// the host, the database name, the credentials and the query parameters of its URL, and the other
+// settings of the primary.
+func (ss *SQLStore) buildReplicaConnectionStrings() ([]string, error) {
+	if len(ss.dbCfg.ReadReplicaURLs) == 0 {
+		return nil, nil
+	}
+	if ss.dbCfg.Type != migrator.MySQL && ss.dbCfg.Type != migrator.Postgres {
+		return nil, fmt.Errorf("read replicas are not supported for database type %q", ss.dbCfg.Type)
+	}
+
+	connectionStrings := make([]string, 0, len(ss.dbCfg.ReadReplicaURLs))
+	for i, replicaURL := range ss.dbCfg.ReadReplicaURLs {
+		dbURL, err := url.Parse(replicaURL)
+		if err != nil {
+			// the URL isn't part of the error, since it can contain a password
+			return nil, fmt.Errorf("read replica URL %d is invalid", i+1)
+		}
+		if dbURL.Scheme != ss.dbCfg.Type {
+			return nil, fmt.Errorf("read replica URL %d has database type %q instead of %q", i+1, dbURL.Scheme, ss.dbCfg.Type)
+		}
+
+		replicaCfg := ss.dbCfg
+		replicaCfg.ConnectionString = ""
+		setDatabaseURL(&replicaCfg, dbURL)
+
+		connectionString, err := ss.buildConnectionString(&replicaCfg)
+		if err != nil {
+			return nil, err
+		}
+		connectionStrings = append(connectionStrings, connectionString)
+	}
+
+	return connectionStrings, nil
+}
+
+type writeTrackerKey struct{}
+
+// ContextWithWriteTracker returns a context recording the transactions committed with it. The
+// read-only sessions using the context afterwards go to the primary, instead of a read replica
+// which may not have replicated the writes yet.
+//
+// Most writes, like the commands dispatched on the bus and the annotations, don't pass the context
+// of the request down to the SQLStore, so a request whose method can write, like POST, reads from
+// the primary from the start.
+func ContextWithWriteTracker(ctx context.Context, method string) context.Context {
+	written := new(int32)
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		*written = 1
+	}
+	return context.WithValue(ctx, writeTrackerKey{}, written)
+}
+
+func markWritten(ctx context.Context) {
+	if written, ok := ctx.Value(writeTrackerKey{}).(*int32); ok {
+		atomic.StoreInt32(written, 1)
+	}
+}
+
+func hasWritten(ctx context.Context) bool {
+	written, ok := ctx.Value(writeTrackerKey{}).(*int32)
+	return ok && atomic.LoadInt32(written) == 1
+}
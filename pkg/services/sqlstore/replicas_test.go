@@ -0,0 +1,109 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+func setupTestReplica(t *testing.T, sqlStore *SQLStore) *xorm.Engine {
+	t.Helper()
+
+	// the replica connects to the test database too, so it reads the data of the primary
+	replica, err := xorm.NewEngine(sqlStore.engine.DriverName(), sqlStore.engine.DataSourceName())
+	require.NoError(t, err)
+
+	sqlStore.replicas = &readReplicas{engines: []*xorm.Engine{replica}}
+	replicas = sqlStore.replicas
+	t.Cleanup(func() {
+		sqlStore.replicas = nil
+		replicas = nil
+	})
+
+	return replica
+}
+
+func TestReadReplicas(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	primary := sqlStore.engine
+	replica := setupTestReplica(t, sqlStore)
+
+	t.Run("read-only sessions use the replica", func(t *testing.T) {
+		ctx := ContextWithWriteTracker(context.Background(), http.MethodGet)
+
+		assert.Same(t, replica, sqlStore.replicas.engine(ctx, primary))
+		assert.Same(t, replica, sqlStore.replicas.engine(context.Background(), primary))
+	})
+
+	t.Run("read-only sessions use the primary within a transaction", func(t *testing.T) {
+		err := sqlStore.InTransaction(context.Background(), func(ctx context.Context) error {
+			assert.Same(t, primary, sqlStore.replicas.engine(ctx, primary))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("read-only sessions use the primary after a transaction of the request", func(t *testing.T) {
+		ctx := ContextWithWriteTracker(context.Background(), http.MethodGet)
+		err := sqlStore.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			_, err := sess.Insert(&models.Star{UserId: 1, DashboardId: 1})
+			return err
+		})
+		require.NoError(t, err)
+
+		assert.Same(t, primary, sqlStore.replicas.engine(ctx, primary))
+		otherRequestCtx := ContextWithWriteTracker(context.Background(), http.MethodGet)
+		assert.Same(t, replica, sqlStore.replicas.engine(otherRequestCtx, primary))
+	})
+
+	t.Run("read-only sessions use the primary after an annotation saved by the request", func(t *testing.T) {
+		dash := insertTestDashboard(t, sqlStore, "annotated dashboard", 1, 0, false)
+		ctx := ContextWithWriteTracker(context.Background(), http.MethodPost)
+
+		// the annotation repository doesn't get the context of the request
+		repo := SQLAnnotationRepo{}
+		require.NoError(t, repo.Save(&annotations.Item{OrgId: 1, DashboardId: dash.Id, Epoch: 10, EpochEnd: 10, Text: "saved"}))
+
+		assert.Same(t, primary, sqlStore.replicas.engine(ctx, primary))
+		items, err := repo.Find(ctx, &annotations.ItemQuery{OrgId: 1, DashboardId: dash.Id, From: 0, To: 20})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "saved", items[0].Text)
+	})
+
+	t.Run("searches dashboards and annotations on both paths", func(t *testing.T) {
+		dash := insertTestDashboard(t, sqlStore, "replicated dashboard", 1, 0, false, "prod")
+		repo := SQLAnnotationRepo{}
+		require.NoError(t, repo.Save(&annotations.Item{OrgId: 1, DashboardId: dash.Id, Epoch: 10, EpochEnd: 10, Text: "deploy"}))
+
+		replicaCtx := ContextWithWriteTracker(context.Background(), http.MethodGet)
+		primaryCtx := ContextWithWriteTracker(context.Background(), http.MethodGet)
+		markWritten(primaryCtx)
+
+		for name, ctx := range map[string]context.Context{"replica": replicaCtx, "primary": primaryCtx} {
+			query := &search.FindPersistedDashboardsQuery{
+				Title:        "replicated",
+				OrgId:        1,
+				SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR},
+			}
+			require.NoError(t, SearchDashboards(ctx, query), name)
+			require.Len(t, query.Result, 1, name)
+			assert.Equal(t, dash.Id, query.Result[0].ID, name)
+
+			items, err := repo.Find(ctx, &annotations.ItemQuery{OrgId: 1, DashboardId: dash.Id, From: 0, To: 20})
+			require.NoError(t, err, name)
+			require.Len(t, items, 1, name)
+			assert.Equal(t, "deploy", items[0].Text, name)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateServiceAccount)
+	bus.AddHandler("sql", UpdateServiceAccount)
+	bus.AddHandler("sql", DeleteServiceAccount)
+	bus.AddHandler("sql", GetServiceAccounts)
+	bus.AddHandler("sql", GetServiceAccountById)
+	bus.AddHandler("sql", AddServiceAccountToken)
+	bus.AddHandler("sql", GetServiceAccountTokens)
+	bus.AddHandler("sql", DeleteServiceAccountToken)
+}
+
+// serviceAccountLogin returns the login of the service account named name in the org.
+// Service accounts have no email, so the login is used as email too.
+func serviceAccountLogin(orgID int64, name string) string {
+	return fmt.Sprintf("%s%d-%s", models.ServiceAccountLoginPrefix, orgID, models.SlugifyTitle(name))
+}
+
+func CreateServiceAccount(cmd *models.CreateServiceAccountCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if err := verifyExistingOrg(sess, cmd.OrgId); err != nil {
+			return err
+		}
+
+		login := serviceAccountLogin(cmd.OrgId, cmd.Name)
+		exists, err := sess.Where("email=? OR login=?", login, login).Get(&models.User{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return models.ErrServiceAccountNameTaken
+		}
+
+		now := time.Now()
+		user := models.User{
+			Email:            login,
+			Name:             cmd.Name,
+			Login:            login,
+			OrgId:            cmd.OrgId,
+			IsServiceAccount: true,
+			Created:          now,
+			Updated:          now,
+			LastSeenAt:       now.AddDate(-10, 0, 0),
+		}
+		if user.Salt, err = util.GetRandomString(10); err != nil {
+			return err
+		}
+		if user.Rands, err = util.GetRandomString(10); err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&user); err != nil {
+			return err
+		}
+
+		if _, err := sess.Insert(&models.OrgUser{
+			OrgId:   cmd.OrgId,
+			UserId:  user.Id,
+			Role:    cmd.Role,
+			Created: now,
+			Updated: now,
+		}); err != nil {
+			return err
+		}
+
+		cmd.Result, err = getServiceAccount(sess, cmd.OrgId, user.Id)
+		return err
+	})
+}
+
+func UpdateServiceAccount(cmd *models.UpdateServiceAccountCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		serviceAccount, err := getServiceAccount(sess, cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		user := models.User{Updated: time.Now()}
+		cols := []string{"updated"}
+		if cmd.Name != "" && cmd.Name != serviceAccount.Name {
+			user.Name = cmd.Name
+			cols = append(cols, "name")
+		}
+		if cmd.IsDisabled != nil {
+			user.IsDisabled = *cmd.IsDisabled
+			cols = append(cols, "is_disabled")
+		}
+		if _, err := sess.ID(cmd.Id).Cols(cols...).Update(&user); err != nil {
+			return err
+		}
+
+		if cmd.Role != "" && cmd.Role != serviceAccount.Role {
+			if _, err := sess.Exec("UPDATE org_user SET role = ?, updated = ? WHERE org_id = ? AND user_id = ?",
+				cmd.Role, user.Updated, cmd.OrgId, cmd.Id); err != nil {
+				return err
+			}
+			// the role of the tokens is only informative, the role of the service account applies
+			if _, err := sess.Exec("UPDATE api_key SET role = ?, updated = ? WHERE org_id = ? AND service_account_id = ?",
+				cmd.Role, user.Updated, cmd.OrgId, cmd.Id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func DeleteServiceAccount(cmd *models.DeleteServiceAccountCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := getServiceAccount(sess, cmd.OrgId, cmd.Id); err != nil {
+			return err
+		}
+
+		return deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: cmd.Id})
+	})
+}
+
+func GetServiceAccounts(query *models.GetServiceAccountsQuery) error {
+	sess := x.Table("org_user")
+	sess.Join("INNER", dialect.Quote("user")+" u", "org_user.user_id = u.id")
+	sess.Where("org_user.org_id = ? AND u.is_service_account = ?", query.OrgId, true)
+	if query.Query != "" {
+		queryWithWildcards := "%" + query.Query + "%"
+		sess.And("(u.name "+dialect.LikeStr()+" ? OR u.login "+dialect.LikeStr()+" ?)", queryWithWildcards, queryWithWildcards)
+	}
+	sess.Select(serviceAccountColumns).Asc("u.name")
+
+	query.Result = make([]*models.ServiceAccountDTO, 0)
+	if err := sess.Find(&query.Result); err != nil {
+		return err
+	}
+
+	for _, serviceAccount := range query.Result {
+		if err := fillServiceAccountTeams(serviceAccount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetServiceAccountById(query *models.GetServiceAccountByIdQuery) error {
+	return withDbSession(context.Background(), x, func(sess *DBSession) error {
+		var err error
+		query.Result, err = getServiceAccount(sess, query.OrgId, query.Id)
+		return err
+	})
+}
+
+const serviceAccountColumns = `u.id, org_user.org_id, u.name, u.login, org_user.role, u.is_disabled,
+	u.last_seen_at, u.created,
+	(SELECT COUNT(*) FROM api_key WHERE api_key.service_account_id = u.id) AS tokens`
+
+func getServiceAccount(sess *DBSession, orgID, id int64) (*models.ServiceAccountDTO, error) {
+	var serviceAccount models.ServiceAccountDTO
+	has, err := sess.Table("org_user").
+		Join("INNER", dialect.Quote("user")+" u", "org_user.user_id = u.id").
+		Where("org_user.org_id = ? AND u.id = ? AND u.is_service_account = ?", orgID, id, true).
+		Select(serviceAccountColumns).
+		Get(&serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, models.ErrServiceAccountNotFound
+	}
+
+	if err := fillServiceAccountTeams(&serviceAccount); err != nil {
+		return nil, err
+	}
+	return &serviceAccount, nil
+}
+
+func fillServiceAccountTeams(serviceAccount *models.ServiceAccountDTO) error {
+	query := models.GetTeamsByUserQuery{OrgId: serviceAccount.OrgId, UserId: serviceAccount.Id}
+	if err := GetTeamsByUser(&query); err != nil {
+		return err
+	}
+
+	serviceAccount.Teams = make([]string, 0, len(query.Result))
+	for _, team := range query.Result {
+		serviceAccount.Teams = append(serviceAccount.Teams, team.Name)
+	}
+	return nil
+}
+
+func AddServiceAccountToken(cmd *models.AddServiceAccountTokenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		serviceAccount, err := getServiceAccount(sess, cmd.OrgId, cmd.ServiceAccountId)
+		if err != nil {
+			return err
+		}
+
+		exists, err := sess.Get(&models.ApiKey{OrgId: cmd.OrgId, Name: cmd.Name})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return models.ErrDuplicateApiKey
+		}
+
+		updated := timeNow()
+		var expires *int64
+		if cmd.SecondsToLive > 0 {
+			v := updated.Add(time.Second * time.Duration(cmd.SecondsToLive)).Unix()
+			expires = &v
+		} else if cmd.SecondsToLive < 0 {
+			return models.ErrInvalidApiKeyExpiration
+		}
+
+		token := models.ApiKey{
+			OrgId:            cmd.OrgId,
+			Name:             cmd.Name,
+			Role:             serviceAccount.Role,
+			Key:              cmd.Key,
+			Created:          updated,
+			Updated:          updated,
+			Expires:          expires,
+			ServiceAccountId: &serviceAccount.Id,
+		}
+		if _, err := sess.Insert(&token); err != nil {
+			return err
+		}
+
+		cmd.Result = &token
+		return nil
+	})
+}
+
+func GetServiceAccountTokens(query *models.GetServiceAccountTokensQuery) error {
+	query.Result = make([]*models.ApiKey, 0)
+	return x.Where("org_id = ? AND service_account_id = ?", query.OrgId, query.ServiceAccountId).
+		Asc("name").
+		Find(&query.Result)
+}
+
+func DeleteServiceAccountToken(cmd *models.DeleteServiceAccountTokenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("DELETE FROM api_key WHERE id = ? AND org_id = ? AND service_account_id = ?",
+			cmd.Id, cmd.OrgId, cmd.ServiceAccountId)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return models.ErrServiceAccountTokenNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccountsDataAccess(t *testing.T) {
+	sqlStore := InitTestDB(t)
+	require.NoError(t, CreateOrg(&models.CreateOrgCommand{Name: "Main Org."}))
+
+	createCmd := models.CreateServiceAccountCommand{OrgId: 1, Name: "Deploy bot", Role: models.ROLE_EDITOR}
+	require.NoError(t, CreateServiceAccount(&createCmd))
+	sa := createCmd.Result
+	assert.Equal(t, "sa-1-deploy-bot", sa.Login)
+	assert.Equal(t, models.ROLE_EDITOR, sa.Role)
+
+	t.Run("Should not create service account with taken name", func(t *testing.T) {
+		cmd := models.CreateServiceAccountCommand{OrgId: 1, Name: "deploy bot", Role: models.ROLE_VIEWER}
+		require.Equal(t, models.ErrServiceAccountNameTaken, CreateServiceAccount(&cmd))
+	})
+
+	t.Run("Should not list service accounts as users", func(t *testing.T) {
+		_, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "human", Email: "human@example.com"})
+		require.NoError(t, err)
+
+		searchQuery := models.SearchUsersQuery{}
+		require.NoError(t, SearchUsers(&searchQuery))
+		require.Len(t, searchQuery.Result.Users, 1)
+		assert.Equal(t, "human", searchQuery.Result.Users[0].Login)
+		assert.Equal(t, int64(1), searchQuery.Result.TotalCount)
+
+		orgUsersQuery := models.GetOrgUsersQuery{OrgId: 1}
+		require.NoError(t, GetOrgUsers(&orgUsersQuery))
+		for _, orgUser := range orgUsersQuery.Result {
+			assert.NotEqual(t, sa.Id, orgUser.UserId)
+		}
+	})
+
+	t.Run("Should add, list and delete tokens", func(t *testing.T) {
+		addCmd := models.AddServiceAccountTokenCommand{OrgId: 1, ServiceAccountId: sa.Id, Name: "ci", Key: "hashed", SecondsToLive: 60}
+		require.NoError(t, AddServiceAccountToken(&addCmd))
+		require.Equal(t, sa.Id, *addCmd.Result.ServiceAccountId)
+		assert.Equal(t, models.ROLE_EDITOR, addCmd.Result.Role)
+		assert.NotNil(t, addCmd.Result.Expires)
+
+		duplicateCmd := models.AddServiceAccountTokenCommand{OrgId: 1, ServiceAccountId: sa.Id, Name: "ci", Key: "hashed2"}
+		require.Equal(t, models.ErrDuplicateApiKey, AddServiceAccountToken(&duplicateCmd))
+
+		getQuery := models.GetServiceAccountByIdQuery{OrgId: 1, Id: sa.Id}
+		require.NoError(t, GetServiceAccountById(&getQuery))
+		assert.Equal(t, int64(1), getQuery.Result.Tokens)
+
+		tokensQuery := models.GetServiceAccountTokensQuery{OrgId: 1, ServiceAccountId: sa.Id}
+		require.NoError(t, GetServiceAccountTokens(&tokensQuery))
+		require.Len(t, tokensQuery.Result, 1)
+
+		deleteCmd := models.DeleteServiceAccountTokenCommand{OrgId: 1, ServiceAccountId: sa.Id, Id: addCmd.Result.Id}
+		require.NoError(t, DeleteServiceAccountToken(&deleteCmd))
+		require.Equal(t, models.ErrServiceAccountTokenNotFound, DeleteServiceAccountToken(&deleteCmd))
+	})
+
+	t.Run("Should update service account and the role of its tokens", func(t *testing.T) {
+		addCmd := models.AddServiceAccountTokenCommand{OrgId: 1, ServiceAccountId: sa.Id, Name: "rotated", Key: "hashed3"}
+		require.NoError(t, AddServiceAccountToken(&addCmd))
+
+		disabled := true
+		updateCmd := models.UpdateServiceAccountCommand{OrgId: 1, Id: sa.Id, Name: "Release bot", Role: models.ROLE_ADMIN, IsDisabled: &disabled}
+		require.NoError(t, UpdateServiceAccount(&updateCmd))
+
+		getQuery := models.GetServiceAccountByIdQuery{OrgId: 1, Id: sa.Id}
+		require.NoError(t, GetServiceAccountById(&getQuery))
+		assert.Equal(t, "Release bot", getQuery.Result.Name)
+		assert.Equal(t, models.ROLE_ADMIN, getQuery.Result.Role)
+		assert.True(t, getQuery.Result.IsDisabled)
+
+		keyQuery := models.GetApiKeyByIdQuery{ApiKeyId: addCmd.Result.Id}
+		require.NoError(t, GetApiKeyById(&keyQuery))
+		assert.Equal(t, models.ROLE_ADMIN, keyQuery.Result.Role)
+	})
+
+	t.Run("Should sign in service account with flag", func(t *testing.T) {
+		query := models.GetSignedInUserQuery{UserId: sa.Id, OrgId: 1}
+		require.NoError(t, GetSignedInUser(&query))
+		assert.True(t, query.Result.IsServiceAccount)
+		assert.Equal(t, models.ROLE_ADMIN, query.Result.OrgRole)
+	})
+
+	t.Run("Should add service account to team", func(t *testing.T) {
+		team, err := sqlStore.CreateTeam("bots", "", 1)
+		require.NoError(t, err)
+		require.NoError(t, sqlStore.AddTeamMember(sa.Id, 1, team.Id, false, 0))
+
+		getQuery := models.GetServiceAccountByIdQuery{OrgId: 1, Id: sa.Id}
+		require.NoError(t, GetServiceAccountById(&getQuery))
+		assert.Equal(t, []string{"bots"}, getQuery.Result.Teams)
+	})
+
+	t.Run("Should delete service account with its tokens", func(t *testing.T) {
+		require.NoError(t, DeleteServiceAccount(&models.DeleteServiceAccountCommand{OrgId: 1, Id: sa.Id}))
+
+		getQuery := models.GetServiceAccountByIdQuery{OrgId: 1, Id: sa.Id}
+		require.Equal(t, models.ErrServiceAccountNotFound, GetServiceAccountById(&getQuery))
+
+		tokensQuery := models.GetServiceAccountTokensQuery{OrgId: 1, ServiceAccountId: sa.Id}
+		require.NoError(t, GetServiceAccountTokens(&tokensQuery))
+		assert.Empty(t, tokensQuery.Result)
+	})
+
+	t.Run("Should not find service account of other org", func(t *testing.T) {
+		cmd := models.DeleteServiceAccountCommand{OrgId: 2, Id: sa.Id}
+		require.Equal(t, models.ErrServiceAccountNotFound, DeleteServiceAccount(&cmd))
+	})
+}
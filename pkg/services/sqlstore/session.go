@@ -51,6 +51,16 @@ func (ss *SQLStore) WithDbSession(ctx context.Context, callback dbTransactionFun
 	return withDbSession(ctx, ss.engine, callback)
 }
 
+// WithReadReplicaDbSession calls the callback with a read-only session, which uses a read replica
+// if there is one. Use it for the queries that can read slightly stale data, like searches.
+func (ss *SQLStore) WithReadReplicaDbSession(ctx context.Context, callback dbTransactionFunc) error {
+	return withDbSession(ctx, ss.replicas.engine(ctx, ss.engine), callback)
+}
+
+func withReadReplicaDbSession(ctx context.Context, callback dbTransactionFunc) error {
+	return withDbSession(ctx, replicas.engine(ctx, x), callback)
+}
+
 func withDbSession(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc) error {
 	sess := &DBSession{Session: engine.NewSession()}
 	defer sess.Close()
@@ -0,0 +1,113 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// WithConsistentSnapshot calls the callback with a read-only transaction seeing a consistent
+// snapshot of the whole database, while Grafana keeps running. SQLite databases are copied to a
+// temporary file with the online backup API, the other databases use a repeatable read transaction.
+func (ss *SQLStore) WithConsistentSnapshot(ctx context.Context, callback func(tx *sql.Tx) error) error {
+	db := ss.engine.DB().DB
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	if ss.Dialect.DriverName() == migrator.SQLite {
+		// the snapshot isn't written, and SQLite only supports serializable transactions
+		opts = &sql.TxOptions{ReadOnly: true}
+
+		snapshotPath, err := ss.backupSQLite(ctx)
+		if err != nil {
+			return errutil.Wrap("failed to back up the SQLite database", err)
+		}
+		defer func() { _ = os.Remove(snapshotPath) }()
+
+		if db, err = sql.Open("sqlite3", "file:"+snapshotPath+"?mode=ro"); err != nil {
+			return err
+		}
+		defer func() { _ = db.Close() }()
+	}
+
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	return callback(tx)
+}
+
+// backupSQLite copies the SQLite database to a temporary file with the online backup API, and
+// returns the path of the file. The copy is consistent even if the database is written meanwhile.
+func (ss *SQLStore) backupSQLite(ctx context.Context) (string, error) {
+	f, err := ioutil.TempFile("", "grafana-snapshot-*.db")
+	if err != nil {
+		return "", err
+	}
+	snapshotPath := f.Name()
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	if err := copySQLite(ctx, ss.engine.DataSourceName(), "file:"+snapshotPath+"?mode=rw"); err != nil {
+		_ = os.Remove(snapshotPath)
+		return "", err
+	}
+	return snapshotPath, nil
+}
+
+func copySQLite(ctx context.Context, sourceConnStr, targetConnStr string) error {
+	// the engine may use the driver wrapped for the metrics, so the connections to back up are
+	// opened with the SQLite driver directly
+	sourceDB, err := sql.Open("sqlite3", sourceConnStr)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sourceDB.Close() }()
+	targetDB, err := sql.Open("sqlite3", targetConnStr)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = targetDB.Close() }()
+
+	sourceConn, err := sourceDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sourceConn.Close() }()
+	targetConn, err := targetDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = targetConn.Close() }()
+
+	return targetConn.Raw(func(targetDriverConn interface{}) error {
+		return sourceConn.Raw(func(sourceDriverConn interface{}) error {
+			target, ok := targetDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected SQLite connection type %T", targetDriverConn)
+			}
+			source, ok := sourceDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected SQLite connection type %T", sourceDriverConn)
+			}
+
+			backup, err := target.Backup("main", source, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				_ = backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
@@ -78,7 +78,7 @@ func TestSQLConnectionString(t *testing.T) {
 				sqlstore.Cfg = makeSQLStoreTestConfig(testCase.dbType, testCase.dbHost)
 				sqlstore.readConfig()
 
-				connStr, err := sqlstore.buildConnectionString()
+				connStr, err := sqlstore.buildConnectionString(&sqlstore.dbCfg)
 
 				So(err, ShouldBeNil)
 				for _, connSubStr := range testCase.connStrValues {
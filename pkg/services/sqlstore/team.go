@@ -134,8 +134,10 @@ func DeleteTeam(cmd *models.DeleteTeamCommand) error {
 
 		deletes := []string{
 			"DELETE FROM team_member WHERE org_id=? and team_id = ?",
+			"DELETE FROM team_group WHERE org_id=? and team_id = ?",
 			"DELETE FROM team WHERE org_id=? and id = ?",
 			"DELETE FROM dashboard_acl WHERE org_id=? and team_id = ?",
+			"DELETE FROM quota WHERE org_id=? and team_id = ?",
 		}
 
 		for _, sql := range deletes {
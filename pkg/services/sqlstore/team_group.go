@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", AddTeamGroup)
+	bus.AddHandler("sql", DeleteTeamGroup)
+	bus.AddHandler("sql", GetTeamGroups)
+	bus.AddHandler("sql", GetTeamGroupsByGroupIds)
+}
+
+// AddTeamGroup maps an external group to a team
+func AddTeamGroup(cmd *models.AddTeamGroupCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := teamExists(cmd.OrgId, cmd.TeamId, sess); err != nil {
+			return err
+		}
+
+		exists, err := sess.Get(&models.TeamGroup{OrgId: cmd.OrgId, TeamId: cmd.TeamId, GroupId: cmd.GroupId})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return models.ErrTeamGroupAlreadyAdded
+		}
+
+		entity := models.TeamGroup{
+			OrgId:   cmd.OrgId,
+			TeamId:  cmd.TeamId,
+			GroupId: cmd.GroupId,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}
+
+		_, err = sess.Insert(&entity)
+		return err
+	})
+}
+
+// DeleteTeamGroup removes the mapping of an external group to a team. Members of the team that
+// were added by the group stay in the team until they sign in again.
+func DeleteTeamGroup(cmd *models.DeleteTeamGroupCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := teamExists(cmd.OrgId, cmd.TeamId, sess); err != nil {
+			return err
+		}
+
+		res, err := sess.Exec("DELETE FROM team_group WHERE org_id=? and team_id=? and group_id=?",
+			cmd.OrgId, cmd.TeamId, cmd.GroupId)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return models.ErrTeamGroupNotFound
+		}
+
+		return nil
+	})
+}
+
+// GetTeamGroups returns the external groups mapped to a team
+func GetTeamGroups(query *models.GetTeamGroupsQuery) error {
+	query.Result = make([]*models.TeamGroupDTO, 0)
+	return x.Table("team_group").
+		Where("org_id=? and team_id=?", query.OrgId, query.TeamId).
+		Cols("org_id", "team_id", "group_id").
+		Asc("group_id").
+		Find(&query.Result)
+}
+
+// GetTeamGroupsByGroupIds returns the teams of all orgs mapped to any of the external groups
+func GetTeamGroupsByGroupIds(query *models.GetTeamGroupsByGroupIdsQuery) error {
+	query.Result = make([]*models.TeamGroup, 0)
+	if len(query.GroupIds) == 0 {
+		return nil
+	}
+
+	return x.In("group_id", query.GroupIds).Find(&query.Result)
+}
@@ -0,0 +1,61 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamGroupDataAccess(t *testing.T) {
+	sqlStore := InitTestDB(t)
+
+	team, err := sqlStore.CreateTeam("devs", "", 1)
+	require.NoError(t, err)
+	otherTeam, err := sqlStore.CreateTeam("devs", "", 2)
+	require.NoError(t, err)
+
+	const groupID = "cn=devs,ou=groups,dc=grafana,dc=org"
+
+	t.Run("Should add groups to teams", func(t *testing.T) {
+		require.NoError(t, AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 1, TeamId: team.Id, GroupId: groupID}))
+		require.NoError(t, AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 1, TeamId: team.Id, GroupId: "cn=admins"}))
+		require.NoError(t, AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 2, TeamId: otherTeam.Id, GroupId: groupID}))
+
+		err := AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 1, TeamId: team.Id, GroupId: groupID})
+		require.Equal(t, models.ErrTeamGroupAlreadyAdded, err)
+
+		err = AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 1, TeamId: otherTeam.Id, GroupId: groupID})
+		require.Equal(t, models.ErrTeamNotFound, err)
+
+		query := models.GetTeamGroupsQuery{OrgId: 1, TeamId: team.Id}
+		require.NoError(t, GetTeamGroups(&query))
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, "cn=admins", query.Result[0].GroupId)
+		assert.Equal(t, groupID, query.Result[1].GroupId)
+	})
+
+	t.Run("Should get teams of all orgs by groups", func(t *testing.T) {
+		query := models.GetTeamGroupsByGroupIdsQuery{GroupIds: []string{groupID, "cn=unknown"}}
+		require.NoError(t, GetTeamGroupsByGroupIds(&query))
+		require.Len(t, query.Result, 2)
+
+		query = models.GetTeamGroupsByGroupIdsQuery{}
+		require.NoError(t, GetTeamGroupsByGroupIds(&query))
+		require.Empty(t, query.Result)
+	})
+
+	t.Run("Should delete groups of team", func(t *testing.T) {
+		cmd := models.DeleteTeamGroupCommand{OrgId: 1, TeamId: team.Id, GroupId: groupID}
+		require.NoError(t, DeleteTeamGroup(&cmd))
+		require.Equal(t, models.ErrTeamGroupNotFound, DeleteTeamGroup(&cmd))
+
+		require.NoError(t, DeleteTeam(&models.DeleteTeamCommand{OrgId: 1, Id: team.Id}))
+		query := models.GetTeamGroupsQuery{OrgId: 1, TeamId: team.Id}
+		require.NoError(t, GetTeamGroups(&query))
+		require.Empty(t, query.Result)
+	})
+}
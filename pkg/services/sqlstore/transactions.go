@@ -63,6 +63,7 @@ func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callbac
 	if err := sess.Commit(); err != nil {
 		return err
 	}
+	markWritten(ctx)
 
 	if len(sess.events) > 0 {
 		for _, e := range sess.events {
@@ -521,6 +521,7 @@ func GetSignedInUser(query *models.GetSignedInUserQuery) error {
 		u.name           as name,
 		u.help_flags1    as help_flags1,
 		u.last_seen_at   as last_seen_at,
+		u.is_service_account as is_service_account,
 		(SELECT COUNT(*) FROM org_user where org_user.user_id = u.id) as org_count,
 		org.name         as org_name,
 		org_user.role    as org_role,
@@ -574,8 +575,9 @@ func SearchUsers(query *models.SearchUsersQuery) error {
 
 	queryWithWildcards := "%" + query.Query + "%"
 
-	whereConditions := make([]string, 0)
-	whereParams := make([]interface{}, 0)
+	// service accounts are listed by the service accounts API
+	whereConditions := []string{"is_service_account = ?"}
+	whereParams := []interface{}{false}
 	sess := x.Table("user").Alias("u")
 
 	// Join with only most recent auth module
@@ -713,6 +715,8 @@ func deleteUserInTransaction(sess *DBSession, cmd *models.DeleteUserCommand) err
 		"DELETE FROM user_auth WHERE user_id = ?",
 		"DELETE FROM user_auth_token WHERE user_id = ?",
 		"DELETE FROM quota WHERE user_id = ?",
+		"DELETE FROM api_key WHERE service_account_id = ?",
+		"DELETE FROM user_totp WHERE user_id = ?",
 	}
 
 	for _, sql := range deletes {
@@ -0,0 +1,140 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", GetUserTOTP)
+	bus.AddHandler("sql", SaveUserTOTP)
+	bus.AddHandler("sql", DeleteUserTOTP)
+	bus.AddHandler("sql", UseUserTOTPStep)
+	bus.AddHandler("sql", UseUserTOTPRecoveryCode)
+}
+
+// GetUserTOTP returns the second factor of a user
+func GetUserTOTP(query *models.GetUserTOTPQuery) error {
+	var userTOTP models.UserTOTP
+	exists, err := x.Where("user_id=?", query.UserId).Get(&userTOTP)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrUserTOTPNotFound
+	}
+
+	query.Result = &userTOTP
+	return nil
+}
+
+// SaveUserTOTP creates or replaces the second factor of a user
+func SaveUserTOTP(cmd *models.SaveUserTOTPCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		var userTOTP models.UserTOTP
+		exists, err := sess.Where("user_id=?", cmd.UserId).Get(&userTOTP)
+		if err != nil {
+			return err
+		}
+
+		userTOTP.UserId = cmd.UserId
+		userTOTP.Secret = cmd.Secret
+		userTOTP.RecoveryCodes = cmd.RecoveryCodes
+		userTOTP.Enabled = cmd.Enabled
+		userTOTP.Updated = time.Now()
+
+		if exists {
+			_, err = sess.ID(userTOTP.Id).AllCols().Update(&userTOTP)
+			return err
+		}
+
+		userTOTP.Created = userTOTP.Updated
+		_, err = sess.Insert(&userTOTP)
+		return err
+	})
+}
+
+// DeleteUserTOTP removes the second factor of a user
+func DeleteUserTOTP(cmd *models.DeleteUserTOTPCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("DELETE FROM user_totp WHERE user_id=?", cmd.UserId)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return models.ErrUserTOTPNotFound
+		}
+
+		return nil
+	})
+}
+
+// UseUserTOTPStep records the time step of an accepted code, unless a code of the same or a later
+// step was already accepted
+func UseUserTOTPStep(cmd *models.UseUserTOTPStepCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("UPDATE user_totp SET last_used_step=?, updated=? WHERE user_id=? AND last_used_step<?",
+			cmd.Step, time.Now(), cmd.UserId, cmd.Step)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return models.ErrInvalidTOTPCode
+		}
+
+		return nil
+	})
+}
+
+// UseUserTOTPRecoveryCode removes a recovery code of a user. The recovery codes are only updated if
+// they haven't changed since they were read, so that a recovery code can't be used twice.
+func UseUserTOTPRecoveryCode(cmd *models.UseUserTOTPRecoveryCodeCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		var userTOTP models.UserTOTP
+		exists, err := sess.Where("user_id=?", cmd.UserId).Get(&userTOTP)
+		if err != nil {
+			return err
+		}
+		if !exists || !userTOTP.Enabled || userTOTP.RecoveryCodes == "" {
+			return models.ErrInvalidTOTPCode
+		}
+
+		hashes := strings.Split(userTOTP.RecoveryCodes, ",")
+		remaining := make([]string, 0, len(hashes))
+		for _, hash := range hashes {
+			if hash != cmd.Hash {
+				remaining = append(remaining, hash)
+			}
+		}
+		if len(remaining) == len(hashes) {
+			return models.ErrInvalidTOTPCode
+		}
+
+		res, err := sess.Exec("UPDATE user_totp SET recovery_codes=?, updated=? WHERE user_id=? AND recovery_codes=?",
+			strings.Join(remaining, ","), time.Now(), cmd.UserId, userTOTP.RecoveryCodes)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return models.ErrInvalidTOTPCode
+		}
+
+		cmd.Result = len(remaining)
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserTOTPDataAccess(t *testing.T) {
+	InitTestDB(t)
+
+	t.Run("Should return not found when not set up", func(t *testing.T) {
+		err := GetUserTOTP(&models.GetUserTOTPQuery{UserId: 1})
+		require.Equal(t, models.ErrUserTOTPNotFound, err)
+
+		err = DeleteUserTOTP(&models.DeleteUserTOTPCommand{UserId: 1})
+		require.Equal(t, models.ErrUserTOTPNotFound, err)
+	})
+
+	t.Run("Should create, update and delete", func(t *testing.T) {
+		require.NoError(t, SaveUserTOTP(&models.SaveUserTOTPCommand{UserId: 1, Secret: "secret"}))
+
+		query := models.GetUserTOTPQuery{UserId: 1}
+		require.NoError(t, GetUserTOTP(&query))
+		assert.Equal(t, "secret", query.Result.Secret)
+		assert.False(t, query.Result.Enabled)
+
+		require.NoError(t, SaveUserTOTP(&models.SaveUserTOTPCommand{
+			UserId: 1, Secret: "secret", RecoveryCodes: "a,b", Enabled: true,
+		}))
+
+		require.NoError(t, GetUserTOTP(&query))
+		assert.Equal(t, "a,b", query.Result.RecoveryCodes)
+		assert.True(t, query.Result.Enabled)
+
+		require.NoError(t, UseUserTOTPStep(&models.UseUserTOTPStepCommand{UserId: 1, Step: 10}))
+		require.Equal(t, models.ErrInvalidTOTPCode, UseUserTOTPStep(&models.UseUserTOTPStepCommand{UserId: 1, Step: 10}))
+		require.Equal(t, models.ErrInvalidTOTPCode, UseUserTOTPStep(&models.UseUserTOTPStepCommand{UserId: 1, Step: 9}))
+		require.NoError(t, UseUserTOTPStep(&models.UseUserTOTPStepCommand{UserId: 1, Step: 11}))
+
+		useRecoveryCode := models.UseUserTOTPRecoveryCodeCommand{UserId: 1, Hash: "a"}
+		require.NoError(t, UseUserTOTPRecoveryCode(&useRecoveryCode))
+		assert.Equal(t, 1, useRecoveryCode.Result)
+		require.Equal(t, models.ErrInvalidTOTPCode, UseUserTOTPRecoveryCode(&useRecoveryCode))
+
+		require.NoError(t, GetUserTOTP(&query))
+		assert.Equal(t, "b", query.Result.RecoveryCodes)
+		assert.Equal(t, int64(11), query.Result.LastUsedStep)
+
+		require.NoError(t, DeleteUserTOTP(&models.DeleteUserTOTPCommand{UserId: 1}))
+		require.Equal(t, models.ErrUserTOTPNotFound, GetUserTOTP(&query))
+	})
+}
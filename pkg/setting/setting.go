@@ -256,8 +256,23 @@ type Cfg struct {
 	PluginSettings           PluginSettings
 	PluginsAllowUnsigned     []string
 	MarketplaceURL           string
-	DisableSanitizeHtml      bool
-	EnterpriseLicensePath    string
+	// PluginRepositories are the plugin repositories, in the order they're queried.
+	PluginRepositories []PluginRepository
+	// PluginSignaturePublicKeyFiles are the armored PGP public keys trusted to sign plugins, in
+	// addition to the Grafana key.
+	PluginSignaturePublicKeyFiles []string
+	// PluginsCDNBaseURL is the base URL of a CDN mirroring /public/plugins, which the plugin assets are loaded
+	// from when set.
+	PluginsCDNBaseURL string
+	// PluginsPrecompressAssets enables the gzip compression of the plugin assets when the plugins are loaded.
+	PluginsPrecompressAssets bool
+	// DefaultPluginLimits are the limits of the backend plugins, and PluginLimits the limits of the plugins
+	// configured separately.
+	DefaultPluginLimits PluginLimits
+	PluginLimits        map[string]PluginLimits
+
+	DisableSanitizeHtml   bool
+	EnterpriseLicensePath string
 
 	// Metrics
 	MetricsEndpointEnabled           bool
@@ -273,6 +288,10 @@ type Cfg struct {
 	LoginCookieName              string
 	LoginMaxInactiveLifetime     time.Duration
 	LoginMaxLifetime             time.Duration
+	LoginMaxConcurrentSessions   int
+	OrgLoginMaxInactiveLifetime  map[int64]time.Duration
+	OrgLoginMaxLifetime          map[int64]time.Duration
+	SessionGeoIPDatabasePath     string
 	TokenRotationIntervalMinutes int
 	SigV4AuthEnabled             bool
 	BasicAuthEnabled             bool
@@ -298,19 +317,59 @@ type Cfg struct {
 	OAuthCookieMaxAge int
 
 	// SAML Auth
-	SAMLEnabled             bool
-	SAMLSingleLogoutEnabled bool
+	SAMLEnabled                  bool
+	SAMLSingleLogoutEnabled      bool
+	SAMLAllowSignup              bool
+	SAMLCertificate              string
+	SAMLCertificatePath          string
+	SAMLPrivateKey               string
+	SAMLPrivateKeyPath           string
+	SAMLSignatureAlgorithm       string
+	SAMLIDPMetadata              string
+	SAMLIDPMetadataPath          string
+	SAMLIDPMetadataURL           string
+	SAMLMaxIssueDelay            time.Duration
+	SAMLMetadataValidDuration    time.Duration
+	SAMLAssertionAttributeName   string
+	SAMLAssertionAttributeLogin  string
+	SAMLAssertionAttributeEmail  string
+	SAMLAssertionAttributeGroups string
+	SAMLAssertionAttributeRole   string
+	SAMLAssertionAttributeOrg    string
+	SAMLAllowedOrganizations     []string
+	SAMLOrgMapping               string
+	SAMLRoleValuesEditor         []string
+	SAMLRoleValuesAdmin          []string
+	SAMLRoleValuesGrafanaAdmin   []string
+
+	// TOTP two-factor authentication
+	TOTPEnabled          bool
+	TOTPIssuer           string
+	TOTPEnforced         bool
+	TOTPEnforcedOrgIds   []int64
+	TOTPLoginMaxLifetime time.Duration
 
 	// JWT Auth
-	JWTAuthEnabled       bool
-	JWTAuthHeaderName    string
-	JWTAuthEmailClaim    string
-	JWTAuthUsernameClaim string
-	JWTAuthExpectClaims  string
-	JWTAuthJWKSetURL     string
-	JWTAuthCacheTTL      time.Duration
-	JWTAuthKeyFile       string
-	JWTAuthJWKSetFile    string
+	JWTAuthEnabled             bool
+	JWTAuthHeaderName          string
+	JWTAuthEmailClaim          string
+	JWTAuthUsernameClaim       string
+	JWTAuthExpectClaims        string
+	JWTAuthJWKSetURL           string
+	JWTAuthCacheTTL            time.Duration
+	JWTAuthKeyFile             string
+	JWTAuthJWKSetFile          string
+	JWTAuthAutoSignUp          bool
+	JWTAuthNameClaim           string
+	JWTAuthSyncTTL             int
+	JWTAuthRoleAttributePath   string
+	JWTAuthOrgAttributePath    string
+	JWTAuthOrgMapping          string
+	JWTAuthGroupsAttributePath string
+	JWTAuthOrgClaim            string
+	JWTAuthURLLogin            bool
+	JWTAuthURLParamName        string
+	JWTAuthURLSessionLifetime  time.Duration
 
 	// Dataproxy
 	SendUserHeader bool
@@ -360,11 +419,20 @@ type Cfg struct {
 	ReportingEnabled     bool
 
 	// LDAP
-	LDAPEnabled     bool
-	LDAPAllowSignup bool
+	LDAPEnabled           bool
+	LDAPAllowSignup       bool
+	LDAPSyncCron          string
+	LDAPActiveSyncEnabled bool
+	// LDAPSyncMaxDisabledPercent is the largest share of the enabled LDAP users, in percent, a single
+	// LDAP sync may disable.
+	LDAPSyncMaxDisabledPercent int
 
 	Quota QuotaSettings
 
+	RateLimiting RateLimitingSettings
+
+	Audit AuditSettings
+
 	DefaultTheme string
 	HomePage     string
 
@@ -888,6 +956,13 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 		cfg.PluginsAllowUnsigned = append(cfg.PluginsAllowUnsigned, plug)
 	}
 	cfg.MarketplaceURL = pluginsSection.Key("marketplace_url").MustString("https://grafana.com/grafana/plugins/")
+	for _, keyFile := range strings.Split(valueAsString(pluginsSection, "signature_public_key_files", ""), ",") {
+		if keyFile = strings.TrimSpace(keyFile); keyFile != "" {
+			cfg.PluginSignaturePublicKeyFiles = append(cfg.PluginSignaturePublicKeyFiles, keyFile)
+		}
+	}
+	cfg.PluginsCDNBaseURL = strings.TrimSuffix(valueAsString(pluginsSection, "cdn_base_url", ""), "/")
+	cfg.PluginsPrecompressAssets = pluginsSection.Key("precompress_assets").MustBool(true)
 
 	// Read and populate feature toggles list
 	featureTogglesSection := iniFile.Section("feature_toggles")
@@ -907,6 +982,12 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	cfg.readSessionConfig()
 	cfg.readSmtpSettings()
 	cfg.readQuotaSettings()
+	if err := cfg.readRateLimitingSettings(); err != nil {
+		return err
+	}
+	if err := cfg.readAuditSettings(); err != nil {
+		return err
+	}
 	cfg.readAnnotationSettings()
 	cfg.readExpressionsSettings()
 	if err := cfg.readGrafanaEnvironmentMetrics(); err != nil {
@@ -924,6 +1005,12 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	if GrafanaComUrl == "" {
 		GrafanaComUrl = valueAsString(iniFile.Section("grafana_com"), "url", "https://grafana.com")
 	}
+	if err := cfg.readPluginRepositories(); err != nil {
+		return err
+	}
+	if err := cfg.readPluginLimits(); err != nil {
+		return err
+	}
 
 	imageUploadingSection := iniFile.Section("external_image_storage")
 	cfg.ImageUploadProvider = valueAsString(imageUploadingSection, "provider", "")
@@ -963,6 +1050,9 @@ func (cfg *Cfg) readLDAPConfig() {
 	LDAPEnabled = ldapSec.Key("enabled").MustBool(false)
 	cfg.LDAPEnabled = LDAPEnabled
 	LDAPActiveSyncEnabled = ldapSec.Key("active_sync_enabled").MustBool(false)
+	cfg.LDAPActiveSyncEnabled = LDAPActiveSyncEnabled
+	cfg.LDAPSyncCron = LDAPSyncCron
+	cfg.LDAPSyncMaxDisabledPercent = ldapSec.Key("sync_max_disabled_percent").MustInt(50)
 	LDAPAllowSignup = ldapSec.Key("allow_sign_up").MustBool(true)
 	cfg.LDAPAllowSignup = LDAPAllowSignup
 }
@@ -1128,6 +1218,44 @@ func readSecuritySettings(iniFile *ini.File, cfg *Cfg) error {
 	return nil
 }
 
+// readOrgDurations reads a list of <org id>:<duration> pairs, such as "2:1h, 5:3d". The durations
+// can only be shorter than the global one, which is the upper bound of the token lookups.
+func readOrgDurations(section *ini.Section, key string, max time.Duration) (map[int64]time.Duration, error) {
+	durations := map[int64]time.Duration{}
+	for _, pair := range util.SplitString(valueAsString(section, key, "")) {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid value %q in [%s] %s, expected <org id>:<duration>", pair, section.Name(), key)
+		}
+		orgID, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid org id %q in [%s] %s", parts[0], section.Name(), key)
+		}
+		duration, err := gtime.ParseDuration(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q in [%s] %s: %w", parts[1], section.Name(), key, err)
+		}
+		if duration > max {
+			return nil, fmt.Errorf("duration %q of org %d in [%s] %s is longer than the global one", parts[1], orgID, section.Name(), key)
+		}
+		durations[orgID] = duration
+	}
+	return durations, nil
+}
+
+// LoginLifetimesForOrg returns the maximum inactive lifetime and the maximum lifetime of the
+// sessions in an organization.
+func (cfg *Cfg) LoginLifetimesForOrg(orgID int64) (maxInactive time.Duration, maxLifetime time.Duration) {
+	maxInactive, maxLifetime = cfg.LoginMaxInactiveLifetime, cfg.LoginMaxLifetime
+	if d, ok := cfg.OrgLoginMaxInactiveLifetime[orgID]; ok {
+		maxInactive = d
+	}
+	if d, ok := cfg.OrgLoginMaxLifetime[orgID]; ok {
+		maxLifetime = d
+	}
+	return maxInactive, maxLifetime
+}
+
 func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	auth := iniFile.Section("auth")
 
@@ -1158,6 +1286,19 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 		return err
 	}
 
+	cfg.LoginMaxConcurrentSessions = auth.Key("login_maximum_concurrent_sessions").MustInt(0)
+	cfg.OrgLoginMaxInactiveLifetime, err = readOrgDurations(auth, "org_login_maximum_inactive_lifetime_duration", cfg.LoginMaxInactiveLifetime)
+	if err != nil {
+		return err
+	}
+	cfg.OrgLoginMaxLifetime, err = readOrgDurations(auth, "org_login_maximum_lifetime_duration", cfg.LoginMaxLifetime)
+	if err != nil {
+		return err
+	}
+	if geoIPPath := valueAsString(auth, "session_geoip_database_path", ""); geoIPPath != "" {
+		cfg.SessionGeoIPDatabasePath = makeAbsolute(geoIPPath, HomePath)
+	}
+
 	cfg.ApiKeyMaxSecondsToLive = auth.Key("api_key_max_seconds_to_live").MustInt64(-1)
 
 	cfg.TokenRotationIntervalMinutes = auth.Key("token_rotation_interval_minutes").MustInt(10)
@@ -1176,8 +1317,46 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	cfg.SigV4AuthEnabled = SigV4AuthEnabled
 
 	// SAML auth
-	cfg.SAMLEnabled = iniFile.Section("auth.saml").Key("enabled").MustBool(false)
-	cfg.SAMLSingleLogoutEnabled = iniFile.Section("auth.saml").Key("single_logout").MustBool(false)
+	authSAML := iniFile.Section("auth.saml")
+	cfg.SAMLEnabled = authSAML.Key("enabled").MustBool(false)
+	cfg.SAMLSingleLogoutEnabled = authSAML.Key("single_logout").MustBool(false)
+	cfg.SAMLAllowSignup = authSAML.Key("allow_sign_up").MustBool(true)
+	cfg.SAMLCertificate = valueAsString(authSAML, "certificate", "")
+	cfg.SAMLCertificatePath = valueAsString(authSAML, "certificate_path", "")
+	cfg.SAMLPrivateKey = valueAsString(authSAML, "private_key", "")
+	cfg.SAMLPrivateKeyPath = valueAsString(authSAML, "private_key_path", "")
+	cfg.SAMLSignatureAlgorithm = valueAsString(authSAML, "signature_algorithm", "")
+	cfg.SAMLIDPMetadata = valueAsString(authSAML, "idp_metadata", "")
+	cfg.SAMLIDPMetadataPath = valueAsString(authSAML, "idp_metadata_path", "")
+	cfg.SAMLIDPMetadataURL = valueAsString(authSAML, "idp_metadata_url", "")
+	cfg.SAMLMaxIssueDelay = authSAML.Key("max_issue_delay").MustDuration(90 * time.Second)
+	cfg.SAMLMetadataValidDuration = authSAML.Key("metadata_valid_duration").MustDuration(48 * time.Hour)
+	cfg.SAMLAssertionAttributeName = valueAsString(authSAML, "assertion_attribute_name", "displayName")
+	cfg.SAMLAssertionAttributeLogin = valueAsString(authSAML, "assertion_attribute_login", "mail")
+	cfg.SAMLAssertionAttributeEmail = valueAsString(authSAML, "assertion_attribute_email", "mail")
+	cfg.SAMLAssertionAttributeGroups = valueAsString(authSAML, "assertion_attribute_groups", "")
+	cfg.SAMLAssertionAttributeRole = valueAsString(authSAML, "assertion_attribute_role", "")
+	cfg.SAMLAssertionAttributeOrg = valueAsString(authSAML, "assertion_attribute_org", "")
+	cfg.SAMLAllowedOrganizations = util.SplitString(valueAsString(authSAML, "allowed_organizations", ""))
+	cfg.SAMLOrgMapping = valueAsString(authSAML, "org_mapping", "")
+	cfg.SAMLRoleValuesEditor = util.SplitString(valueAsString(authSAML, "role_values_editor", ""))
+	cfg.SAMLRoleValuesAdmin = util.SplitString(valueAsString(authSAML, "role_values_admin", ""))
+	cfg.SAMLRoleValuesGrafanaAdmin = util.SplitString(valueAsString(authSAML, "role_values_grafana_admin", ""))
+
+	// TOTP two-factor authentication
+	authTOTP := iniFile.Section("auth.totp")
+	cfg.TOTPEnabled = authTOTP.Key("enabled").MustBool(false)
+	cfg.TOTPIssuer = valueAsString(authTOTP, "issuer", "Grafana")
+	cfg.TOTPEnforced = authTOTP.Key("enforced").MustBool(false)
+	cfg.TOTPEnforcedOrgIds = nil
+	for _, orgID := range util.SplitString(valueAsString(authTOTP, "enforced_org_ids", "")) {
+		id, err := strconv.ParseInt(orgID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid org id %q in [auth.totp] enforced_org_ids", orgID)
+		}
+		cfg.TOTPEnforcedOrgIds = append(cfg.TOTPEnforcedOrgIds, id)
+	}
+	cfg.TOTPLoginMaxLifetime = authTOTP.Key("login_max_lifetime").MustDuration(5 * time.Minute)
 
 	// anonymous access
 	AnonymousEnabled = iniFile.Section("auth.anonymous").Key("enabled").MustBool(false)
@@ -1202,6 +1381,17 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	cfg.JWTAuthCacheTTL = authJWT.Key("cache_ttl").MustDuration(time.Minute * 60)
 	cfg.JWTAuthKeyFile = valueAsString(authJWT, "key_file", "")
 	cfg.JWTAuthJWKSetFile = valueAsString(authJWT, "jwk_set_file", "")
+	cfg.JWTAuthAutoSignUp = authJWT.Key("auto_sign_up").MustBool(false)
+	cfg.JWTAuthNameClaim = valueAsString(authJWT, "name_claim", "")
+	cfg.JWTAuthSyncTTL = authJWT.Key("sync_ttl").MustInt(60)
+	cfg.JWTAuthRoleAttributePath = valueAsString(authJWT, "role_attribute_path", "")
+	cfg.JWTAuthOrgAttributePath = valueAsString(authJWT, "org_attribute_path", "")
+	cfg.JWTAuthOrgMapping = valueAsString(authJWT, "org_mapping", "")
+	cfg.JWTAuthGroupsAttributePath = valueAsString(authJWT, "groups_attribute_path", "")
+	cfg.JWTAuthOrgClaim = valueAsString(authJWT, "org_claim", "")
+	cfg.JWTAuthURLLogin = authJWT.Key("url_login").MustBool(false)
+	cfg.JWTAuthURLParamName = valueAsString(authJWT, "url_param_name", "auth_token")
+	cfg.JWTAuthURLSessionLifetime = authJWT.Key("url_login_session_lifetime").MustDuration(time.Hour)
 
 	authProxy := iniFile.Section("auth.proxy")
 	AuthProxyEnabled = authProxy.Key("enabled").MustBool(false)
@@ -0,0 +1,35 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+)
+
+type AuditSettings struct {
+	Enabled bool
+	// Retention is how long audit entries are kept in the database, 0 to keep them forever.
+	Retention time.Duration
+	// LogPath is the path of a file mirroring the audit entries as JSON lines, empty to disable.
+	LogPath string
+}
+
+func (cfg *Cfg) readAuditSettings() error {
+	section := cfg.Raw.Section("audit")
+
+	retention, err := gtime.ParseDuration(valueAsString(section, "retention", "90d"))
+	if err != nil {
+		return fmt.Errorf("invalid audit retention: %w", err)
+	}
+
+	cfg.Audit = AuditSettings{
+		Enabled:   section.Key("enabled").MustBool(false),
+		Retention: retention,
+	}
+	if logPath := valueAsString(section, "log_path", ""); logPath != "" {
+		cfg.Audit.LogPath = makeAbsolute(logPath, HomePath)
+	}
+
+	return nil
+}
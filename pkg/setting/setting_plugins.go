@@ -1,14 +1,55 @@
 package setting
 
 import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/ini.v1"
 )
 
+// GrafanaComPluginRepositoryName is the name of the grafana.com plugin repository, which is configured by default.
+const GrafanaComPluginRepositoryName = "grafana.com"
+
 // PluginSettings maps plugin id to map of key/value settings.
 type PluginSettings map[string]map[string]string
 
+// PluginRepository is a plugin repository implementing the plugin API of grafana.com.
+type PluginRepository struct {
+	Name string
+	// URL is the URL of the plugin API, e.g. https://grafana.com/api/plugins.
+	URL string
+	// Priority orders the repositories, the ones with the lowest priority are queried first.
+	Priority int
+	// Headers are sent with every request to the repository, e.g. for authentication.
+	Headers map[string]string
+}
+
+// PluginLimits limits the requests and the resources of a backend plugin, a zero value meaning unlimited.
+type PluginLimits struct {
+	// MaxConcurrentQueries, MaxConcurrentResourceCalls and MaxConcurrentStreams limit the concurrent data
+	// queries, resource calls and streams.
+	MaxConcurrentQueries       int
+	MaxConcurrentResourceCalls int
+	MaxConcurrentStreams       int
+	// QueueTimeout is how long a request over a concurrency limit waits before being rejected.
+	QueueTimeout time.Duration
+	// RequestTimeout is the default timeout of the requests, except streams.
+	RequestTimeout time.Duration
+	// MaxRecvMsgSize and MaxSendMsgSize are the maximum sizes in bytes of the messages received from and
+	// sent to the plugin.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	// MemoryLimit is the memory limit in bytes of the plugin process, enforced with a cgroup on Linux.
+	MemoryLimit int64
+	// CgroupParent is the cgroup v2 directory, delegated to Grafana, in which the cgroups of the plugin
+	// processes with a memory limit are created.
+	CgroupParent string
+}
+
 func extractPluginSettings(sections []*ini.Section) PluginSettings {
 	psMap := PluginSettings{}
 	for _, section := range sections {
@@ -23,3 +64,144 @@ func extractPluginSettings(sections []*ini.Section) PluginSettings {
 
 	return psMap
 }
+
+// readPluginRepositories reads the [plugin_repository.<name>] sections. The grafana.com repository is
+// configured unless its section disables it.
+func (cfg *Cfg) readPluginRepositories() error {
+	repos := map[string]PluginRepository{
+		GrafanaComPluginRepositoryName: {
+			Name:     GrafanaComPluginRepositoryName,
+			URL:      GrafanaComUrl + "/api/plugins",
+			Priority: 100,
+			Headers:  map[string]string{},
+		},
+	}
+
+	for _, section := range cfg.Raw.Sections() {
+		if !strings.HasPrefix(section.Name(), "plugin_repository.") {
+			continue
+		}
+
+		name := strings.TrimPrefix(section.Name(), "plugin_repository.")
+		repo, exists := repos[name]
+		if !exists {
+			repo = PluginRepository{Name: name, Headers: map[string]string{}}
+		}
+		if !section.Key("enabled").MustBool(true) {
+			delete(repos, name)
+			continue
+		}
+
+		repo.URL = strings.TrimSuffix(valueAsString(section, "url", repo.URL), "/")
+		if repo.URL == "" {
+			return fmt.Errorf("plugin repository %q: url is required", name)
+		}
+		if _, err := url.ParseRequestURI(repo.URL); err != nil {
+			return fmt.Errorf("plugin repository %q: invalid url: %w", name, err)
+		}
+		repo.Priority = section.Key("priority").MustInt(repo.Priority)
+		for _, key := range section.Keys() {
+			if header := strings.TrimPrefix(key.Name(), "header."); header != key.Name() && header != "" {
+				repo.Headers[header] = key.Value()
+			}
+		}
+		repos[name] = repo
+	}
+
+	cfg.PluginRepositories = make([]PluginRepository, 0, len(repos))
+	for _, repo := range repos {
+		cfg.PluginRepositories = append(cfg.PluginRepositories, repo)
+	}
+	sort.Slice(cfg.PluginRepositories, func(i, j int) bool {
+		a, b := cfg.PluginRepositories[i], cfg.PluginRepositories[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.Name < b.Name
+	})
+
+	return nil
+}
+
+// PluginLimitsFor returns the limits of a backend plugin.
+func (cfg *Cfg) PluginLimitsFor(pluginID string) PluginLimits {
+	if limits, exists := cfg.PluginLimits[pluginID]; exists {
+		return limits
+	}
+	return cfg.DefaultPluginLimits
+}
+
+// readPluginLimits reads the default limits of the backend plugins from the [plugin_limits] section, and
+// the limits of single plugins from the [plugin_limits.<plugin id>] sections.
+func (cfg *Cfg) readPluginLimits() error {
+	defaults, err := readPluginLimitsSection(cfg.Raw.Section("plugin_limits"), PluginLimits{
+		QueueTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("plugin_limits: %w", err)
+	}
+	defaults.CgroupParent = valueAsString(cfg.Raw.Section("plugin_limits"), "cgroup_parent", "")
+	cfg.DefaultPluginLimits = defaults
+
+	cfg.PluginLimits = map[string]PluginLimits{}
+	for _, section := range cfg.Raw.Sections() {
+		pluginID := strings.TrimPrefix(section.Name(), "plugin_limits.")
+		if pluginID == section.Name() || pluginID == "" {
+			continue
+		}
+
+		limits, err := readPluginLimitsSection(section, defaults)
+		if err != nil {
+			return fmt.Errorf("plugin_limits.%s: %w", pluginID, err)
+		}
+		cfg.PluginLimits[pluginID] = limits
+	}
+
+	return nil
+}
+
+func readPluginLimitsSection(section *ini.Section, defaults PluginLimits) (PluginLimits, error) {
+	limits := defaults
+	for key, value := range map[string]*int{
+		"max_concurrent_queries":        &limits.MaxConcurrentQueries,
+		"max_concurrent_resource_calls": &limits.MaxConcurrentResourceCalls,
+		"max_concurrent_streams":        &limits.MaxConcurrentStreams,
+		"max_recv_msg_size_bytes":       &limits.MaxRecvMsgSize,
+		"max_send_msg_size_bytes":       &limits.MaxSendMsgSize,
+	} {
+		raw := valueAsString(section, key, "")
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return PluginLimits{}, fmt.Errorf("invalid %s %q", key, raw)
+		}
+		*value = n
+	}
+
+	if raw := valueAsString(section, "memory_limit_bytes", ""); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			return PluginLimits{}, fmt.Errorf("invalid memory_limit_bytes %q", raw)
+		}
+		limits.MemoryLimit = n
+	}
+
+	for key, value := range map[string]*time.Duration{
+		"queue_timeout":   &limits.QueueTimeout,
+		"request_timeout": &limits.RequestTimeout,
+	} {
+		raw := valueAsString(section, key, "")
+		if raw == "" {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return PluginLimits{}, fmt.Errorf("invalid %s %q", key, raw)
+		}
+		*value = d
+	}
+
+	return limits, nil
+}
@@ -2,6 +2,7 @@ package setting
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -41,3 +42,129 @@ func TestPluginSettings(t *testing.T) {
 	require.Equal(t, ps["plugin2"]["key3"], "value3")
 	require.Equal(t, ps["plugin2"]["key4"], "value4")
 }
+
+func TestPluginRepositories(t *testing.T) {
+	origGrafanaComURL := GrafanaComUrl
+	t.Cleanup(func() {
+		GrafanaComUrl = origGrafanaComURL
+	})
+	GrafanaComUrl = "https://grafana.com"
+
+	t.Run("Should configure grafana.com by default", func(t *testing.T) {
+		cfg := NewCfg()
+		require.NoError(t, cfg.readPluginRepositories())
+		require.Equal(t, []PluginRepository{
+			{Name: "grafana.com", URL: "https://grafana.com/api/plugins", Priority: 100, Headers: map[string]string{}},
+		}, cfg.PluginRepositories)
+	})
+
+	t.Run("Should order repositories by priority", func(t *testing.T) {
+		cfg := NewCfg()
+		sec, err := cfg.Raw.NewSection("plugin_repository.internal")
+		require.NoError(t, err)
+		_, err = sec.NewKey("url", "https://plugins.example.com/api/plugins/")
+		require.NoError(t, err)
+		_, err = sec.NewKey("priority", "10")
+		require.NoError(t, err)
+		_, err = sec.NewKey("header.Authorization", "Bearer token")
+		require.NoError(t, err)
+		_, err = sec.NewKey("other", "value")
+		require.NoError(t, err)
+
+		sec, err = cfg.Raw.NewSection("plugin_repository.grafana.com")
+		require.NoError(t, err)
+		_, err = sec.NewKey("priority", "5")
+		require.NoError(t, err)
+
+		require.NoError(t, cfg.readPluginRepositories())
+		require.Equal(t, []PluginRepository{
+			{Name: "grafana.com", URL: "https://grafana.com/api/plugins", Priority: 5, Headers: map[string]string{}},
+			{
+				Name: "internal", URL: "https://plugins.example.com/api/plugins", Priority: 10,
+				Headers: map[string]string{"Authorization": "Bearer token"},
+			},
+		}, cfg.PluginRepositories)
+	})
+
+	t.Run("Should disable grafana.com", func(t *testing.T) {
+		cfg := NewCfg()
+		sec, err := cfg.Raw.NewSection("plugin_repository.grafana.com")
+		require.NoError(t, err)
+		_, err = sec.NewKey("enabled", "false")
+		require.NoError(t, err)
+
+		require.NoError(t, cfg.readPluginRepositories())
+		require.Empty(t, cfg.PluginRepositories)
+	})
+
+	t.Run("Should require url", func(t *testing.T) {
+		cfg := NewCfg()
+		_, err := cfg.Raw.NewSection("plugin_repository.internal")
+		require.NoError(t, err)
+
+		require.EqualError(t, cfg.readPluginRepositories(), `plugin repository "internal": url is required`)
+	})
+}
+
+func TestPluginLimits(t *testing.T) {
+	t.Run("Should not limit plugins by default", func(t *testing.T) {
+		cfg := NewCfg()
+		require.NoError(t, cfg.readPluginLimits())
+		require.Equal(t, PluginLimits{QueueTimeout: 10 * time.Second}, cfg.PluginLimitsFor("test-datasource"))
+	})
+
+	t.Run("Should override the default limits per plugin", func(t *testing.T) {
+		cfg := NewCfg()
+		sec, err := cfg.Raw.NewSection("plugin_limits")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_concurrent_queries", "10")
+		require.NoError(t, err)
+		_, err = sec.NewKey("request_timeout", "30s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_send_msg_size_bytes", "")
+		require.NoError(t, err)
+		_, err = sec.NewKey("cgroup_parent", "/sys/fs/cgroup/grafana-plugins")
+		require.NoError(t, err)
+
+		sec, err = cfg.Raw.NewSection("plugin_limits.test-datasource")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_concurrent_queries", "2")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_concurrent_streams", "1")
+		require.NoError(t, err)
+		_, err = sec.NewKey("queue_timeout", "1s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_recv_msg_size_bytes", "1048576")
+		require.NoError(t, err)
+		_, err = sec.NewKey("memory_limit_bytes", "268435456")
+		require.NoError(t, err)
+
+		require.NoError(t, cfg.readPluginLimits())
+		require.Equal(t, PluginLimits{
+			MaxConcurrentQueries: 10,
+			QueueTimeout:         10 * time.Second,
+			RequestTimeout:       30 * time.Second,
+			CgroupParent:         "/sys/fs/cgroup/grafana-plugins",
+		}, cfg.PluginLimitsFor("other-datasource"))
+		require.Equal(t, PluginLimits{
+			MaxConcurrentQueries: 2,
+			MaxConcurrentStreams: 1,
+			QueueTimeout:         time.Second,
+			RequestTimeout:       30 * time.Second,
+			MaxRecvMsgSize:       1048576,
+			MemoryLimit:          268435456,
+			CgroupParent:         "/sys/fs/cgroup/grafana-plugins",
+		}, cfg.PluginLimitsFor("test-datasource"))
+	})
+
+	t.Run("Should refuse invalid limits", func(t *testing.T) {
+		cfg := NewCfg()
+		sec, err := cfg.Raw.NewSection("plugin_limits.test-datasource")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_concurrent_resource_calls", "-1")
+		require.NoError(t, err)
+
+		require.EqualError(t, cfg.readPluginLimits(),
+			`plugin_limits.test-datasource: invalid max_concurrent_resource_calls "-1"`)
+	})
+}
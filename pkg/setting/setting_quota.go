@@ -5,23 +5,51 @@ import (
 )
 
 type OrgQuota struct {
-	User       int64 `target:"org_user"`
-	DataSource int64 `target:"data_source"`
-	Dashboard  int64 `target:"dashboard"`
-	ApiKey     int64 `target:"api_key"`
+	User              int64 `target:"org_user"`
+	DataSource        int64 `target:"data_source"`
+	Dashboard         int64 `target:"dashboard"`
+	ApiKey            int64 `target:"api_key"`
+	AlertRule         int64 `target:"alert_rule"`
+	LibraryPanel      int64 `target:"library_panel"`
+	DashboardSnapshot int64 `target:"dashboard_snapshot"`
+	AnnotationPerDay  int64 `target:"annotation_per_day"`
+	DashboardBytes    int64 `target:"dashboard_bytes"`
 }
 
 type UserQuota struct {
 	Org int64 `target:"org_user"`
 }
 
+// FolderQuota holds the default limits of the targets that can be limited per folder.
+type FolderQuota struct {
+	Dashboard      int64 `target:"dashboard"`
+	AlertRule      int64 `target:"alert_rule"`
+	LibraryPanel   int64 `target:"library_panel"`
+	DashboardBytes int64 `target:"dashboard_bytes"`
+}
+
+// TeamQuota holds the default limits of the targets that can be limited per team. The usage
+// of a team is what has been created by its members.
+type TeamQuota struct {
+	Dashboard         int64 `target:"dashboard"`
+	LibraryPanel      int64 `target:"library_panel"`
+	DashboardSnapshot int64 `target:"dashboard_snapshot"`
+	AnnotationPerDay  int64 `target:"annotation_per_day"`
+	DashboardBytes    int64 `target:"dashboard_bytes"`
+}
+
 type GlobalQuota struct {
-	Org        int64 `target:"org"`
-	User       int64 `target:"user"`
-	DataSource int64 `target:"data_source"`
-	Dashboard  int64 `target:"dashboard"`
-	ApiKey     int64 `target:"api_key"`
-	Session    int64 `target:"-"`
+	Org               int64 `target:"org"`
+	User              int64 `target:"user"`
+	DataSource        int64 `target:"data_source"`
+	Dashboard         int64 `target:"dashboard"`
+	ApiKey            int64 `target:"api_key"`
+	Session           int64 `target:"-"`
+	AlertRule         int64 `target:"alert_rule"`
+	LibraryPanel      int64 `target:"library_panel"`
+	DashboardSnapshot int64 `target:"dashboard_snapshot"`
+	AnnotationPerDay  int64 `target:"annotation_per_day"`
+	DashboardBytes    int64 `target:"dashboard_bytes"`
 }
 
 func (q *OrgQuota) ToMap() map[string]int64 {
@@ -32,6 +60,14 @@ func (q *UserQuota) ToMap() map[string]int64 {
 	return quotaToMap(*q)
 }
 
+func (q *FolderQuota) ToMap() map[string]int64 {
+	return quotaToMap(*q)
+}
+
+func (q *TeamQuota) ToMap() map[string]int64 {
+	return quotaToMap(*q)
+}
+
 func quotaToMap(q interface{}) map[string]int64 {
 	qMap := make(map[string]int64)
 	typ := reflect.TypeOf(q)
@@ -56,6 +92,8 @@ type QuotaSettings struct {
 	Enabled bool
 	Org     *OrgQuota
 	User    *UserQuota
+	Folder  *FolderQuota
+	Team    *TeamQuota
 	Global  *GlobalQuota
 }
 
@@ -70,6 +108,12 @@ func (cfg *Cfg) readQuotaSettings() {
 		DataSource: quota.Key("org_data_source").MustInt64(10),
 		Dashboard:  quota.Key("org_dashboard").MustInt64(10),
 		ApiKey:     quota.Key("org_api_key").MustInt64(10),
+
+		AlertRule:         quota.Key("org_alert_rule").MustInt64(-1),
+		LibraryPanel:      quota.Key("org_library_panel").MustInt64(-1),
+		DashboardSnapshot: quota.Key("org_dashboard_snapshot").MustInt64(-1),
+		AnnotationPerDay:  quota.Key("org_annotation_per_day").MustInt64(-1),
+		DashboardBytes:    quota.Key("org_dashboard_bytes").MustInt64(-1),
 	}
 
 	// per User limits
@@ -77,6 +121,23 @@ func (cfg *Cfg) readQuotaSettings() {
 		Org: quota.Key("user_org").MustInt64(10),
 	}
 
+	// per Folder limits
+	Quota.Folder = &FolderQuota{
+		Dashboard:      quota.Key("folder_dashboard").MustInt64(-1),
+		AlertRule:      quota.Key("folder_alert_rule").MustInt64(-1),
+		LibraryPanel:   quota.Key("folder_library_panel").MustInt64(-1),
+		DashboardBytes: quota.Key("folder_dashboard_bytes").MustInt64(-1),
+	}
+
+	// per Team limits
+	Quota.Team = &TeamQuota{
+		Dashboard:         quota.Key("team_dashboard").MustInt64(-1),
+		LibraryPanel:      quota.Key("team_library_panel").MustInt64(-1),
+		DashboardSnapshot: quota.Key("team_dashboard_snapshot").MustInt64(-1),
+		AnnotationPerDay:  quota.Key("team_annotation_per_day").MustInt64(-1),
+		DashboardBytes:    quota.Key("team_dashboard_bytes").MustInt64(-1),
+	}
+
 	// Global Limits
 	Quota.Global = &GlobalQuota{
 		User:       quota.Key("global_user").MustInt64(-1),
@@ -85,6 +146,12 @@ func (cfg *Cfg) readQuotaSettings() {
 		Dashboard:  quota.Key("global_dashboard").MustInt64(-1),
 		ApiKey:     quota.Key("global_api_key").MustInt64(-1),
 		Session:    quota.Key("global_session").MustInt64(-1),
+
+		AlertRule:         quota.Key("global_alert_rule").MustInt64(-1),
+		LibraryPanel:      quota.Key("global_library_panel").MustInt64(-1),
+		DashboardSnapshot: quota.Key("global_dashboard_snapshot").MustInt64(-1),
+		AnnotationPerDay:  quota.Key("global_annotation_per_day").MustInt64(-1),
+		DashboardBytes:    quota.Key("global_dashboard_bytes").MustInt64(-1),
 	}
 
 	cfg.Quota = Quota
@@ -0,0 +1,95 @@
+package setting
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strings"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Route groups of the HTTP API which have their own rate limits. The api group contains all API
+// routes, so requests to the other groups count against both limits.
+const (
+	RateLimitGroupAPI    = "api"
+	RateLimitGroupQuery  = "query"
+	RateLimitGroupSearch = "search"
+)
+
+var rateLimitGroups = []string{RateLimitGroupAPI, RateLimitGroupQuery, RateLimitGroupSearch}
+
+// RateLimit is a token bucket allowing an average of RPS requests per second, with bursts of up to
+// Burst requests. A zero RPS disables the limit.
+type RateLimit struct {
+	RPS   float64
+	Burst int
+}
+
+func (l RateLimit) Enabled() bool {
+	return l.RPS > 0
+}
+
+// RouteGroupRateLimits holds the limits of a route group, per user, per API key and per
+// organization.
+type RouteGroupRateLimits struct {
+	User   RateLimit
+	APIKey RateLimit
+	Org    RateLimit
+}
+
+type RateLimitingSettings struct {
+	Enabled bool
+	Groups  map[string]RouteGroupRateLimits
+	// TrustedProxies are the networks of the reverse proxies whose X-Forwarded-For and X-Real-IP
+	// headers identify the client of anonymous requests.
+	TrustedProxies []*net.IPNet
+}
+
+func (cfg *Cfg) readRateLimitingSettings() error {
+	rateLimiting := cfg.Raw.Section("rate_limiting")
+	cfg.RateLimiting = RateLimitingSettings{
+		Enabled: rateLimiting.Key("enabled").MustBool(false),
+		Groups:  map[string]RouteGroupRateLimits{},
+	}
+
+	for _, proxy := range util.SplitString(strings.TrimSpace(rateLimiting.Key("trusted_proxies").String())) {
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q in [rate_limiting]: %w", proxy, err)
+		}
+		cfg.RateLimiting.TrustedProxies = append(cfg.RateLimiting.TrustedProxies, network)
+	}
+
+	for _, group := range rateLimitGroups {
+		section := cfg.Raw.Section("rate_limiting." + group)
+		cfg.RateLimiting.Groups[group] = RouteGroupRateLimits{
+			User:   readRateLimit(section, "user"),
+			APIKey: readRateLimit(section, "api_key"),
+			Org:    readRateLimit(section, "org"),
+		}
+	}
+
+	return nil
+}
+
+func readRateLimit(section *ini.Section, prefix string) RateLimit {
+	limit := RateLimit{
+		RPS:   section.Key(prefix + "_rps").MustFloat64(0),
+		Burst: section.Key(prefix + "_burst").MustInt(0),
+	}
+	// by default, allow a second of requests at once
+	if limit.Burst <= 0 {
+		limit.Burst = int(math.Max(1, math.Ceil(limit.RPS)))
+	}
+	return limit
+}
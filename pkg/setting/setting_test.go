@@ -391,6 +391,42 @@ func TestAuthDurationSettings(t *testing.T) {
 	require.Equal(t, maxLifetimeDurationTest, cfg.LoginMaxLifetime)
 }
 
+func TestOrgLoginLifetimeSettings(t *testing.T) {
+	f := ini.Empty()
+	cfg := NewCfg()
+	sec, err := f.NewSection("auth")
+	require.NoError(t, err)
+	_, err = sec.NewKey("login_maximum_concurrent_sessions", "3")
+	require.NoError(t, err)
+	_, err = sec.NewKey("org_login_maximum_inactive_lifetime_duration", "2:30m, 3:1d")
+	require.NoError(t, err)
+	_, err = sec.NewKey("org_login_maximum_lifetime_duration", "3:7d")
+	require.NoError(t, err)
+	err = readAuthSettings(f, cfg)
+	require.NoError(t, err)
+	require.Equal(t, 3, cfg.LoginMaxConcurrentSessions)
+
+	maxInactive, maxLifetime := cfg.LoginLifetimesForOrg(2)
+	require.Equal(t, 30*time.Minute, maxInactive)
+	require.Equal(t, 30*24*time.Hour, maxLifetime)
+	maxInactive, maxLifetime = cfg.LoginLifetimesForOrg(3)
+	require.Equal(t, 24*time.Hour, maxInactive)
+	require.Equal(t, 7*24*time.Hour, maxLifetime)
+	maxInactive, maxLifetime = cfg.LoginLifetimesForOrg(4)
+	require.Equal(t, 7*24*time.Hour, maxInactive)
+	require.Equal(t, 30*24*time.Hour, maxLifetime)
+
+	for _, value := range []string{"2", "two:1h", "2:1x", "2:8d"} {
+		f = ini.Empty()
+		sec, err = f.NewSection("auth")
+		require.NoError(t, err)
+		_, err = sec.NewKey("org_login_maximum_inactive_lifetime_duration", value)
+		require.NoError(t, err)
+		err = readAuthSettings(f, NewCfg())
+		require.Error(t, err, value)
+	}
+}
+
 func TestGetCDNPath(t *testing.T) {
 	var err error
 	cfg := NewCfg()
@@ -427,3 +463,24 @@ func TestGetCDNPathWithAlphaVersion(t *testing.T) {
 	require.Equal(t, "http://cdn.grafana.com/grafana-oss/pre-releases/v7.5.0-alpha.11124/", cfg.GetContentDeliveryURL("grafana-oss"))
 	require.Equal(t, "http://cdn.grafana.com/grafana/pre-releases/v7.5.0-alpha.11124/", cfg.GetContentDeliveryURL("grafana"))
 }
+
+func TestRateLimitingTrustedProxies(t *testing.T) {
+	f := ini.Empty()
+	sec, err := f.NewSection("rate_limiting")
+	require.NoError(t, err)
+	_, err = sec.NewKey("trusted_proxies", "10.0.0.0/8, 192.168.1.1 ::1")
+	require.NoError(t, err)
+	cfg := NewCfg()
+	cfg.Raw = f
+	require.NoError(t, cfg.readRateLimitingSettings())
+
+	proxies := make([]string, 0, len(cfg.RateLimiting.TrustedProxies))
+	for _, proxy := range cfg.RateLimiting.TrustedProxies {
+		proxies = append(proxies, proxy.String())
+	}
+	require.Equal(t, []string{"10.0.0.0/8", "192.168.1.1/32", "::1/128"}, proxies)
+
+	_, err = sec.NewKey("trusted_proxies", "10.0.0.300")
+	require.NoError(t, err)
+	require.Error(t, cfg.readRateLimitingSettings())
+}
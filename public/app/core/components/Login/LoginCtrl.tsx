@@ -3,6 +3,7 @@ import config from 'app/core/config';
 import { getBackendSrv } from '@grafana/runtime';
 import appEvents from 'app/core/app_events';
 import { AppEvents } from '@grafana/data';
+import { TOTPSetup } from './TOTPForm';
 
 const isOauthEnabled = () => {
   return !!config.oauth && Object.keys(config.oauth).length > 0;
@@ -30,12 +31,19 @@ interface Props {
     isOauthEnabled: boolean;
     loginHint: string;
     passwordHint: string;
+    isVerifyingTOTP: boolean;
+    totpSetup?: TOTPSetup;
+    recoveryCodes?: string[];
+    verifyTOTP: (code: string) => void;
   }) => JSX.Element;
 }
 
 interface State {
   isLoggingIn: boolean;
   isChangingPassword: boolean;
+  isVerifyingTOTP: boolean;
+  totpSetup?: TOTPSetup;
+  recoveryCodes?: string[];
 }
 
 export class LoginCtrl extends PureComponent<Props, State> {
@@ -46,6 +54,7 @@ export class LoginCtrl extends PureComponent<Props, State> {
     this.state = {
       isLoggingIn: false,
       isChangingPassword: false,
+      isVerifyingTOTP: false,
     };
 
     if (config.loginError) {
@@ -91,6 +100,10 @@ export class LoginCtrl extends PureComponent<Props, State> {
       .post('/login', formModel)
       .then((result: any) => {
         this.result = result;
+        if (result.totpRequired) {
+          this.startTOTP(result.totpSetupRequired);
+          return;
+        }
         if (formModel.password !== 'admin' || config.ldapEnabled || config.authProxyEnabled) {
           this.toGrafana();
           return;
@@ -105,6 +118,44 @@ export class LoginCtrl extends PureComponent<Props, State> {
       });
   };
 
+  startTOTP = (setupRequired: boolean) => {
+    if (!setupRequired) {
+      this.setState({ isLoggingIn: false, isVerifyingTOTP: true });
+      return;
+    }
+
+    getBackendSrv()
+      .post('/login/totp/setup')
+      .then((totpSetup: TOTPSetup) => {
+        this.setState({ isLoggingIn: false, isVerifyingTOTP: true, totpSetup });
+      })
+      .catch(() => {
+        this.setState({ isLoggingIn: false });
+      });
+  };
+
+  verifyTOTP = (code: string) => {
+    this.setState({
+      isLoggingIn: true,
+    });
+
+    getBackendSrv()
+      .post('/login/totp', { code })
+      .then((result: any) => {
+        this.result = result;
+        if (result.recoveryCodes) {
+          this.setState({ isLoggingIn: false, recoveryCodes: result.recoveryCodes });
+          return;
+        }
+        this.toGrafana();
+      })
+      .catch(() => {
+        this.setState({
+          isLoggingIn: false,
+        });
+      });
+  };
+
   changeView = () => {
     this.setState({
       isChangingPassword: true,
@@ -126,8 +177,8 @@ export class LoginCtrl extends PureComponent<Props, State> {
 
   render() {
     const { children } = this.props;
-    const { isLoggingIn, isChangingPassword } = this.state;
-    const { login, toGrafana, changePassword } = this;
+    const { isLoggingIn, isChangingPassword, isVerifyingTOTP, totpSetup, recoveryCodes } = this.state;
+    const { login, toGrafana, changePassword, verifyTOTP } = this;
     const { loginHint, passwordHint, disableLoginForm, ldapEnabled, authProxyEnabled, disableUserSignUp } = config;
 
     return (
@@ -145,6 +196,10 @@ export class LoginCtrl extends PureComponent<Props, State> {
           changePassword,
           skipPasswordChange: toGrafana,
           isChangingPassword,
+          isVerifyingTOTP,
+          totpSetup,
+          recoveryCodes,
+          verifyTOTP,
         })}
       </>
     );
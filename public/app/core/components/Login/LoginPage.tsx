@@ -7,6 +7,7 @@ import { UserSignup } from './UserSignup';
 import { LoginServiceButtons } from './LoginServiceButtons';
 import LoginCtrl from './LoginCtrl';
 import { LoginForm } from './LoginForm';
+import { TOTPForm } from './TOTPForm';
 import { ChangePassword } from '../ForgottenPassword/ChangePassword';
 import { Branding } from 'app/core/components/Branding/Branding';
 import { HorizontalGroup, LinkButton } from '@grafana/ui';
@@ -35,9 +36,13 @@ export const LoginPage: FC = () => {
           changePassword,
           skipPasswordChange,
           isChangingPassword,
+          isVerifyingTOTP,
+          totpSetup,
+          recoveryCodes,
+          verifyTOTP,
         }) => (
           <>
-            {!isChangingPassword && (
+            {!isChangingPassword && !isVerifyingTOTP && (
               <InnerBox>
                 {!disableLoginForm && (
                   <>
@@ -67,6 +72,17 @@ export const LoginPage: FC = () => {
                 {!disableUserSignUp && <UserSignup />}
               </InnerBox>
             )}
+            {isVerifyingTOTP && (
+              <InnerBox>
+                <TOTPForm
+                  setup={totpSetup}
+                  recoveryCodes={recoveryCodes}
+                  isLoggingIn={isLoggingIn}
+                  onSubmit={verifyTOTP}
+                  onContinue={() => skipPasswordChange()}
+                />
+              </InnerBox>
+            )}
             {isChangingPassword && (
               <InnerBox>
                 <ChangePassword onSubmit={changePassword} onSkip={() => skipPasswordChange()} />
@@ -0,0 +1,30 @@
+import React, { FC } from 'react';
+import { css } from '@emotion/css';
+import { Alert } from '@grafana/ui';
+
+interface Props {
+  codes: string[];
+}
+
+const codesStyles = css`
+  font-family: monospace;
+  columns: 2;
+  list-style: none;
+  padding-bottom: 16px;
+`;
+
+export const RecoveryCodes: FC<Props> = ({ codes }) => {
+  return (
+    <>
+      <Alert severity="info" title="Save your recovery codes">
+        Each recovery code can be used once to sign in if you lose access to your authenticator app. They will not be
+        shown again.
+      </Alert>
+      <ul className={codesStyles}>
+        {codes.map((code) => (
+          <li key={code}>{code}</li>
+        ))}
+      </ul>
+    </>
+  );
+};
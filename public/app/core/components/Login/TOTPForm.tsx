@@ -0,0 +1,83 @@
+import React, { FC } from 'react';
+import { css } from '@emotion/css';
+import { Button, Form, Input, Field } from '@grafana/ui';
+import { submitButton } from './LoginForm';
+import { RecoveryCodes } from './RecoveryCodes';
+
+export interface TOTPSetup {
+  secret: string;
+  url: string;
+}
+
+interface Props {
+  setup?: TOTPSetup;
+  recoveryCodes?: string[];
+  isLoggingIn: boolean;
+  onSubmit: (code: string) => void;
+  onContinue: () => void;
+}
+
+interface CodeDTO {
+  code: string;
+}
+
+const wrapperStyles = css`
+  width: 100%;
+  padding-bottom: 16px;
+`;
+
+const secretStyles = css`
+  font-family: monospace;
+  word-break: break-all;
+`;
+
+export const TOTPForm: FC<Props> = ({ setup, recoveryCodes, isLoggingIn, onSubmit, onContinue }) => {
+  if (recoveryCodes) {
+    return (
+      <div className={wrapperStyles}>
+        <RecoveryCodes codes={recoveryCodes} />
+        <Button className={submitButton} onClick={onContinue}>
+          Continue
+        </Button>
+      </div>
+    );
+  }
+
+  const submit = ({ code }: CodeDTO) => {
+    onSubmit(code);
+  };
+
+  return (
+    <div className={wrapperStyles}>
+      <Form onSubmit={submit}>
+        {({ errors, register }) => (
+          <>
+            {setup && (
+              <p>
+                Two-factor authentication is required. Add this key to your authenticator app, or open{' '}
+                <a href={setup.url}>this link</a> on your phone:
+                <br />
+                <span className={secretStyles}>{setup.secret}</span>
+              </p>
+            )}
+            <Field
+              label={setup ? 'Code of the authenticator app' : 'Code of the authenticator app or recovery code'}
+              invalid={!!errors.code}
+              error={errors.code?.message}
+            >
+              <Input
+                autoFocus
+                name="code"
+                autoComplete="one-time-code"
+                ref={register({ required: 'Code is required' })}
+              />
+            </Field>
+            <Button type="submit" className={submitButton} disabled={isLoggingIn}>
+              {isLoggingIn ? 'Verifying...' : 'Verify'}
+            </Button>
+          </>
+        )}
+      </Form>
+    </div>
+  );
+};
@@ -13,6 +13,7 @@ export interface UserAPI {
   loadSessions: () => void;
   setUserOrg: (org: UserOrg) => void;
   revokeUserSession: (tokenId: number) => void;
+  revokeOtherUserSessions: () => void;
 }
 
 export interface LoadingStates {
@@ -132,6 +133,7 @@ export class UserProvider extends PureComponent<Props, State> {
               os: session.os,
               osVersion: session.osVersion,
               device: session.device,
+              location: session.location,
             };
           });
 
@@ -153,6 +155,16 @@ export class UserProvider extends PureComponent<Props, State> {
       });
   };
 
+  revokeOtherUserSessions = async () => {
+    await getBackendSrv()
+      .post('/api/user/revoke-other-auth-tokens')
+      .then(() => {
+        const sessions = this.state.sessions.filter((session: UserSession) => session.isActive);
+
+        this.setState({ sessions });
+      });
+  };
+
   setUserOrg = async (org: UserOrg) => {
     this.setState({
       loadingStates: { ...this.state.loadingStates, updateUserOrg: true },
@@ -189,6 +201,7 @@ export class UserProvider extends PureComponent<Props, State> {
       loadOrgs: this.loadOrgs,
       loadSessions: this.loadSessions,
       revokeUserSession: this.revokeUserSession,
+      revokeOtherUserSessions: this.revokeOtherUserSessions,
       updateUserProfile: this.updateUserProfile,
       setUserOrg: this.setUserOrg,
     };
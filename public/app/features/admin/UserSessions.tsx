@@ -53,6 +53,7 @@ export class UserSessions extends PureComponent<Props, State> {
                   <th>Last seen</th>
                   <th>Logged on</th>
                   <th>IP address</th>
+                  <th>Location</th>
                   <th colSpan={2}>Browser and OS</th>
                 </tr>
               </thead>
@@ -63,6 +64,7 @@ export class UserSessions extends PureComponent<Props, State> {
                       <td>{session.isActive ? 'Now' : session.seenAt}</td>
                       <td>{session.createdAt}</td>
                       <td>{session.clientIp}</td>
+                      <td>{session.location}</td>
                       <td>{`${session.browser} on ${session.os} ${session.osVersion}`}</td>
                       <td>
                         <div className="pull-right">
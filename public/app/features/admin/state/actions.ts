@@ -148,6 +148,7 @@ export function loadUserSessions(userId: number): ThunkResult<void> {
         os: session.os,
         osVersion: session.osVersion,
         device: session.device,
+        location: session.location,
       };
     });
     dispatch(userSessionsLoadedAction(sessions));
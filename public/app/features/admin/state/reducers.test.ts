@@ -245,6 +245,7 @@ describe('Edit Admin user page reducer', () => {
               clientIp: '127.0.0.1',
               createdAt: '2020-01-01 00:00:00',
               device: 'a device',
+              location: 'Stockholm, Sweden',
               isActive: true,
               os: 'MacOS',
               osVersion: '15',
@@ -262,6 +263,7 @@ describe('Edit Admin user page reducer', () => {
               clientIp: '127.0.0.1',
               createdAt: '2020-01-01 00:00:00',
               device: 'a device',
+              location: 'Stockholm, Sweden',
               isActive: true,
               os: 'MacOS',
               osVersion: '15',
@@ -46,9 +46,13 @@ grafanaUI.DataSourceApi = grafanaData.DataSourceApi;
 import * as rxjs from 'rxjs';
 import * as rxjsOperators from 'rxjs/operators';
 
-// add cache busting
+// add cache busting, except for the modules loaded by their content hash
 const bust = `?_cache=${Date.now()}`;
+const contentHashRegExp = /\.[0-9a-f]{16}\.js$/;
 function locate(load: { address: string }) {
+  if (contentHashRegExp.test(load.address)) {
+    return load.address;
+  }
   return load.address + bust;
 }
 
@@ -169,7 +173,10 @@ for (const flotDep of flotDeps) {
   exposeToPlugin(flotDep, { fakeDep: 1 });
 }
 
-export async function importPluginModule(path: string): Promise<any> {
+export async function importPluginModule(
+  path: string,
+  integrity: string | undefined = config.pluginModuleIntegrity?.[path]
+): Promise<any> {
   const builtIn = builtInPlugins[path];
   if (builtIn) {
     // for handling dynamic imports
@@ -179,11 +186,17 @@ export async function importPluginModule(path: string): Promise<any> {
       return Promise.resolve(builtIn);
     }
   }
+
+  // the browser refuses a signed module whose content doesn't match its integrity hash
+  if (integrity) {
+    const address = await grafanaRuntime.SystemJS.resolve(path);
+    grafanaRuntime.SystemJS.config({ meta: { [address]: { integrity } } });
+  }
   return grafanaRuntime.SystemJS.import(path);
 }
 
 export function importDataSourcePlugin(meta: grafanaData.DataSourcePluginMeta): Promise<GenericDataSourcePlugin> {
-  return importPluginModule(meta.module).then((pluginExports) => {
+  return importPluginModule(meta.module, meta.moduleIntegrity).then((pluginExports) => {
     if (pluginExports.plugin) {
       const dsPlugin = pluginExports.plugin as GenericDataSourcePlugin;
       dsPlugin.meta = meta;
@@ -206,7 +219,7 @@ export function importDataSourcePlugin(meta: grafanaData.DataSourcePluginMeta):
 }
 
 export function importAppPlugin(meta: grafanaData.PluginMeta): Promise<grafanaData.AppPlugin> {
-  return importPluginModule(meta.module).then((pluginExports) => {
+  return importPluginModule(meta.module, meta.moduleIntegrity).then((pluginExports) => {
     const plugin = pluginExports.plugin ? (pluginExports.plugin as grafanaData.AppPlugin) : new grafanaData.AppPlugin();
     plugin.init(meta);
     plugin.meta = meta;
@@ -235,7 +248,7 @@ export function importPanelPlugin(id: string): Promise<grafanaData.PanelPlugin>
     throw new Error(`Plugin ${id} not found`);
   }
 
-  panelCache[id] = importPluginModule(meta.module)
+  panelCache[id] = importPluginModule(meta.module, meta.moduleIntegrity)
     .then((pluginExports) => {
       if (pluginExports.plugin) {
         return pluginExports.plugin as grafanaData.PanelPlugin;
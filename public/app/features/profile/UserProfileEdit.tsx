@@ -13,6 +13,7 @@ import { UserTeams } from './UserTeams';
 import { UserSessions } from './UserSessions';
 import { UserOrganizations } from './UserOrganizations';
 import { UserProfileEditForm } from './UserProfileEditForm';
+import { UserTwoFactorAuth } from './UserTwoFactorAuth';
 
 export interface Props {
   navModel: NavModel;
@@ -55,9 +56,11 @@ export const UserProfileEdit: FC<Props> = ({ navModel }) => (
                   isLoading={states.loadSessions}
                   loadSessions={api.loadSessions}
                   revokeUserSession={api.revokeUserSession}
+                  revokeOtherUserSessions={api.revokeOtherUserSessions}
                   sessions={sessions}
                   user={user!}
                 />
+                {config.totpEnabled && <UserTwoFactorAuth />}
               </>
             )}
           </Page.Contents>
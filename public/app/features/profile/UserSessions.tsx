@@ -8,6 +8,7 @@ export interface Props {
   isLoading: boolean;
   loadSessions: () => void;
   revokeUserSession: (tokenId: number) => void;
+  revokeOtherUserSessions: () => void;
 }
 
 export class UserSessions extends PureComponent<Props> {
@@ -16,7 +17,7 @@ export class UserSessions extends PureComponent<Props> {
   }
 
   render() {
-    const { isLoading, sessions, revokeUserSession } = this.props;
+    const { isLoading, sessions, revokeUserSession, revokeOtherUserSessions } = this.props;
 
     if (isLoading) {
       return <LoadingPlaceholder text="Loading sessions..." />;
@@ -34,6 +35,7 @@ export class UserSessions extends PureComponent<Props> {
                     <th>Last seen</th>
                     <th>Logged on</th>
                     <th>IP address</th>
+                    <th>Location</th>
                     <th>Browser &amp; OS</th>
                     <th></th>
                   </tr>
@@ -44,6 +46,7 @@ export class UserSessions extends PureComponent<Props> {
                       {session.isActive ? <td>Now</td> : <td>{session.seenAt}</td>}
                       <td>{session.createdAt}</td>
                       <td>{session.clientIp}</td>
+                      <td>{session.location}</td>
                       <td>
                         {session.browser} on {session.os} {session.osVersion}
                       </td>
@@ -57,6 +60,13 @@ export class UserSessions extends PureComponent<Props> {
                 </tbody>
               </table>
             </div>
+            {sessions.length > 1 && (
+              <div className="gf-form-group">
+                <Button variant="destructive" onClick={revokeOtherUserSessions}>
+                  Sign out all other sessions
+                </Button>
+              </div>
+            )}
           </>
         )}
       </>
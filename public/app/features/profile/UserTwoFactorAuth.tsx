@@ -0,0 +1,113 @@
+import React, { FC, useEffect, useState } from 'react';
+import { css } from '@emotion/css';
+import { getBackendSrv } from '@grafana/runtime';
+import { Button, Field, HorizontalGroup, Input, LoadingPlaceholder } from '@grafana/ui';
+import { RecoveryCodes } from 'app/core/components/Login/RecoveryCodes';
+import { TOTPSetup } from 'app/core/components/Login/TOTPForm';
+
+interface TOTPStatus {
+  enabled: boolean;
+  required: boolean;
+  recoveryCodesLeft: number;
+}
+
+const formStyles = css`
+  max-width: 400px;
+`;
+
+const secretStyles = css`
+  font-family: monospace;
+  word-break: break-all;
+`;
+
+export const UserTwoFactorAuth: FC = () => {
+  const [status, setStatus] = useState<TOTPStatus>();
+  const [setup, setSetup] = useState<TOTPSetup>();
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>();
+  const [code, setCode] = useState('');
+
+  const loadStatus = () => getBackendSrv().get('/api/user/totp').then(setStatus);
+
+  useEffect(() => {
+    loadStatus();
+  }, []);
+
+  const startSetup = () => {
+    setRecoveryCodes(undefined);
+    getBackendSrv().post('/api/user/totp/setup').then(setSetup);
+  };
+
+  const post = (url: string) => {
+    getBackendSrv()
+      .post(url, { code })
+      .then((result: any) => {
+        setCode('');
+        setSetup(undefined);
+        setRecoveryCodes(result.recoveryCodes);
+        return loadStatus();
+      });
+  };
+
+  if (!status) {
+    return <LoadingPlaceholder text="Loading two-factor authentication..." />;
+  }
+
+  const codeField = (
+    <Field label="Code of the authenticator app">
+      <Input
+        name="code"
+        autoComplete="one-time-code"
+        value={code}
+        onChange={(event) => setCode(event.currentTarget.value)}
+      />
+    </Field>
+  );
+
+  return (
+    <>
+      <h3 className="page-sub-heading">Two-factor authentication</h3>
+      <div className={`gf-form-group ${formStyles}`}>
+        {recoveryCodes && <RecoveryCodes codes={recoveryCodes} />}
+        {!status.enabled && !setup && (
+          <>
+            <p>
+              {status.required
+                ? 'Two-factor authentication is required to sign in.'
+                : 'Protect your account with a code of an authenticator app when signing in.'}
+            </p>
+            <Button onClick={startSetup}>Set up</Button>
+          </>
+        )}
+        {!status.enabled && setup && (
+          <>
+            <p>
+              Add this key to your authenticator app, or open <a href={setup.url}>this link</a> on your phone:
+              <br />
+              <span className={secretStyles}>{setup.secret}</span>
+            </p>
+            {codeField}
+            <Button onClick={() => post('/api/user/totp/enable')} disabled={!code}>
+              Enable
+            </Button>
+          </>
+        )}
+        {status.enabled && (
+          <>
+            <p>Two-factor authentication is enabled. {status.recoveryCodesLeft} recovery codes left.</p>
+            {codeField}
+            <HorizontalGroup>
+              <Button variant="secondary" onClick={() => post('/api/user/totp/recovery-codes')} disabled={!code}>
+                Create new recovery codes
+              </Button>
+              {!status.required && (
+                <Button variant="destructive" onClick={() => post('/api/user/totp/disable')} disabled={!code}>
+                  Disable
+                </Button>
+              )}
+            </HorizontalGroup>
+          </>
+        )}
+      </div>
+    </>
+  );
+};
@@ -67,7 +67,7 @@ export class TeamPages extends PureComponent<Props, State> {
 
     this.state = {
       isLoading: false,
-      isSyncEnabled: config.licenseInfo.hasLicense,
+      isSyncEnabled: true,
     };
   }
 
@@ -85,6 +85,7 @@ export interface UserSession {
   os: string;
   osVersion: string;
   device: string;
+  location: string;
 }
 
 export interface UserOrg {